@@ -0,0 +1,571 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+const (
+	anthropicAPIVersion       = "2023-06-01"
+	anthropicDefaultMaxTokens = 4096
+	// minimal budget allowed by Anthropic for extended thinking
+	anthropicMinThinkingBudget = 1024
+)
+
+var anthropicThinkingEffortBudget = map[string]int{
+	"low":    anthropicMinThinkingBudget,
+	"medium": 4096,
+	"high":   16384,
+}
+
+// AnthropicClient works with the native Anthropic Messages API
+// https://docs.anthropic.com/en/api/messages
+type AnthropicClient struct {
+	*OpenAICompatibleClient
+}
+
+func NewAnthropicClient(cfg config.AIProviderConfig, globalCfg *config.Config, log logger.Logger, httpClient *http.Client) *AnthropicClient {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com/v1"
+	}
+	chatURL := cfg.ChatURL
+	if chatURL == "" {
+		chatURL = "messages"
+	}
+	// api key is sent in x-api-key header instead of Authorization
+	baseClient := NewOpenAICompatibleClient(
+		cfg.Name,
+		baseURL,
+		chatURL,
+		"",
+		cfg.DefaultModel,
+		log,
+		cfg.OverrideModels,
+		globalCfg,
+		httpClient,
+	)
+	baseClient.httpClient.SetHeader("x-api-key", cfg.GetAPIKey())
+	baseClient.httpClient.SetHeader("anthropic-version", anthropicAPIVersion)
+
+	return &AnthropicClient{
+		OpenAICompatibleClient: baseClient,
+	}
+}
+
+type anthropicSource struct {
+	Type      string `json:"type"` // "base64", "url"
+	MediaType string `json:"media_type,omitzero"`
+	Data      string `json:"data,omitzero"`
+	URL       string `json:"url,omitzero"`
+}
+
+type anthropicContent struct {
+	Type string `json:"type"` // "text", "image", "document", "tool_use", "tool_result", "thinking"
+	Text string `json:"text,omitzero"`
+
+	Source *anthropicSource `json:"source,omitzero"`
+
+	// tool_use
+	ID    string          `json:"id,omitzero"`
+	Name  string          `json:"name,omitzero"`
+	Input json.RawMessage `json:"input,omitzero"`
+
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitzero"`
+	Content   string `json:"content,omitzero"`
+
+	// thinking
+	Thinking string `json:"thinking,omitzero"`
+}
+
+type anthropicMessage struct {
+	Role    string             `json:"role"`
+	Content []anthropicContent `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitzero"`
+	InputSchema Parameters `json:"input_schema"`
+}
+
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitzero"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Stream      bool               `json:"stream,omitzero"`
+	Temperature *float32           `json:"temperature,omitzero"`
+	TopP        *float32           `json:"top_p,omitzero"`
+	Tools       []anthropicTool    `json:"tools,omitzero"`
+	Thinking    *anthropicThinking `json:"thinking,omitzero"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+func (u anthropicUsage) toModelUsage() ModelUsage {
+	promptTokens := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return ModelUsage{
+		PromptTokens:       promptTokens,
+		PromptTokensDetail: UsageDetails{CachedTokens: int(u.CacheReadInputTokens)},
+		CompletionTokens:   u.OutputTokens,
+		TotalTokens:        promptTokens + u.OutputTokens,
+	}
+}
+
+type anthropicResponse struct {
+	ID         string             `json:"id"`
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Usage      anthropicUsage     `json:"usage"`
+}
+
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type anthropicStreamEvent struct {
+	Type         string             `json:"type"`
+	Index        int                `json:"index"`
+	Message      *anthropicResponse `json:"message,omitzero"`
+	ContentBlock *anthropicContent  `json:"content_block,omitzero"`
+	Delta        struct {
+		Type        string `json:"type"` // "text_delta", "thinking_delta", "input_json_delta"
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *anthropicUsage `json:"usage,omitzero"`
+	Error *anthropicError `json:"error,omitzero"`
+}
+
+func (c *AnthropicClient) Ask(
+	ctx context.Context,
+	request CompletionRequest,
+	headers map[string]string,
+) (
+	string,
+	string,
+	*CompletionResponse,
+	*ModelInfo,
+	error,
+) {
+	if request.Model == "" {
+		request.Model = c.defaultModel
+	}
+	_, body, aiErr := c.doRequest(ctx, http.MethodPost, c.chatURL, c.toAnthropicRequest(request, false), headers, false)
+	if aiErr != nil {
+		aiErr.ModelName = request.Model
+		return "", "", nil, nil, c.enrichError(aiErr, body)
+	}
+
+	var result anthropicResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", "", nil, nil, &AIError{
+			OriginalErr:  err,
+			ProviderName: c.Name(),
+			ModelName:    request.Model,
+			Message:      "failed to unmarshal response",
+		}
+	}
+
+	var content, reasoning strings.Builder
+	message := MessageResponse{}
+	for _, block := range result.Content {
+		switch block.Type {
+		case "text":
+			content.WriteString(block.Text)
+		case "thinking":
+			reasoning.WriteString(block.Thinking)
+		case "tool_use":
+			message.ToolCalls = append(message.ToolCalls, block.toToolCall(len(message.ToolCalls)))
+		}
+	}
+	message.Content = content.String()
+	message.Reasoning = reasoning.String()
+
+	response := &CompletionResponse{
+		ID: result.ID,
+		Choices: []struct {
+			Message MessageResponse `json:"message"`
+		}{{Message: message}},
+		Usage: result.Usage.toModelUsage(),
+	}
+
+	return message.Content, message.Reasoning, response, request.ModelInfo, nil
+}
+
+func (c *AnthropicClient) AskStream(
+	ctx context.Context,
+	request CompletionRequest,
+	headers map[string]string,
+) (<-chan Chunk, *ModelInfo, error) {
+	if request.Model == "" {
+		request.Model = c.defaultModel
+	}
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headers["Accept"] = "text/event-stream"
+	resp, body, aiErr := c.doRequest(ctx, http.MethodPost, c.chatURL, c.toAnthropicRequest(request, true), headers, true)
+	if aiErr != nil {
+		aiErr.ModelName = request.Model
+		return nil, nil, c.enrichError(aiErr, body)
+	}
+
+	chunkCh := make(chan Chunk)
+	go func() {
+		defer close(chunkCh)
+		defer resp.Body.Close()
+
+		reader := bufio.NewReader(resp.Body)
+		toolCalls := map[int]*ToolCall{}
+		toolsOrder := []int{}
+		usage := anthropicUsage{}
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				if err != io.EOF {
+					c.logger.WithError(err).Error("stream read error")
+				}
+				return
+			}
+
+			c.logger.WithFields(logger.Fields{
+				"raw_data": string(line),
+				"model":    request.Model,
+			}).Trace("Raw SSE event")
+
+			if !strings.HasPrefix(string(line), "data: ") {
+				continue
+			}
+
+			jsonData := strings.TrimSpace(strings.TrimPrefix(string(line), "data: "))
+			var event anthropicStreamEvent
+			if err := json.Unmarshal([]byte(jsonData), &event); err != nil {
+				c.logger.WithFields(logger.Fields{
+					"error": err,
+					"data":  jsonData,
+				}).Error("stream decode error")
+				continue
+			}
+
+			var chunk Chunk
+			switch event.Type {
+			case "message_start":
+				if event.Message != nil {
+					usage = event.Message.Usage
+				}
+				continue
+			case "content_block_start":
+				if block := event.ContentBlock; block != nil && block.Type == "tool_use" {
+					toolCall := block.toToolCall(len(toolsOrder))
+					toolCall.Function.Arguments = ""
+					toolCalls[event.Index] = &toolCall
+					toolsOrder = append(toolsOrder, event.Index)
+				}
+				continue
+			case "content_block_delta":
+				switch event.Delta.Type {
+				case "text_delta":
+					chunk.Content = event.Delta.Text
+				case "thinking_delta":
+					chunk.Reasoning = event.Delta.Thinking
+				case "input_json_delta":
+					if toolCall, exists := toolCalls[event.Index]; exists {
+						toolCall.Function.Arguments += event.Delta.PartialJSON
+					}
+					continue
+				default:
+					continue
+				}
+			case "message_delta":
+				if event.Usage != nil {
+					usage.OutputTokens = event.Usage.OutputTokens
+				}
+				if event.Delta.StopReason != "tool_use" || len(toolsOrder) == 0 {
+					continue
+				}
+				values := make([]*ToolCall, 0, len(toolsOrder))
+				for _, index := range toolsOrder {
+					if toolCalls[index].Function.Arguments == "" {
+						toolCalls[index].Function.Arguments = "{}"
+					}
+					values = append(values, toolCalls[index])
+				}
+				chunk.Tools = toolPtrsToValues(values)
+			case "message_stop":
+				modelUsage := usage.toModelUsage()
+				chunkCh <- Chunk{Usage: &modelUsage}
+				return
+			case "error":
+				chunk.Error = &AIError{
+					ProviderName: c.Name(),
+					ModelName:    request.Model,
+					Message:      "stream generation failed",
+				}
+				if event.Error != nil {
+					chunk.Error.ErrorCode = event.Error.Type
+					chunk.Error.Message = event.Error.Message
+					if event.Error.Type == "overloaded_error" {
+						chunk.Error.HTTPStatusCode = 529
+					}
+				}
+			default:
+				// ping, content_block_stop
+				continue
+			}
+			chunkCh <- chunk
+		}
+	}()
+
+	return chunkCh, request.ModelInfo, nil
+}
+
+func (c *AnthropicClient) GetModels(ctx context.Context, onlyFree, fresh bool) (map[string]*ModelInfo, error) {
+	models, err := c.OpenAICompatibleClient.GetModels(ctx, onlyFree, fresh)
+	if err != nil {
+		return nil, err
+	}
+	for _, model := range models {
+		setAnthropicModelCapabilities(model)
+	}
+	return models, nil
+}
+
+func (c *AnthropicClient) GetModelInfo(name string) (*ModelInfo, error) {
+	model, err := c.OpenAICompatibleClient.GetModelInfo(name)
+	if model != nil {
+		setAnthropicModelCapabilities(model)
+	}
+	return model, err
+}
+
+// setAnthropicModelCapabilities fills model info for models from api,
+// models api doesn't return modalities, but all current Claude models support them
+func setAnthropicModelCapabilities(model *ModelInfo) {
+	if model.Architecture != nil {
+		return
+	}
+	model.Architecture = &ModelArchitecture{
+		Modality:         "text+image->text",
+		InputModalities:  []string{"text", "image", "file"},
+		OutputModalities: []string{"text"},
+	}
+	model.SupportedParameters = []string{"tools", "reasoning", "temperature", "top_p", "max_tokens"}
+}
+
+// enrichError replaces the error code with the anthropic error type,
+// e.g. "overloaded_error", "rate_limit_error"
+func (c *AnthropicClient) enrichError(aiErr *AIError, body []byte) *AIError {
+	var errorResponse struct {
+		Error anthropicError `json:"error"`
+	}
+	if len(body) > 0 && json.Unmarshal(body, &errorResponse) == nil && errorResponse.Error.Type != "" {
+		aiErr.ErrorCode = errorResponse.Error.Type
+		if errorResponse.Error.Message != "" {
+			aiErr.Message = errorResponse.Error.Message
+		}
+	}
+	return aiErr
+}
+
+func (c *AnthropicClient) toAnthropicRequest(request CompletionRequest, stream bool) anthropicRequest {
+	result := anthropicRequest{
+		Model:       request.Model,
+		Stream:      stream,
+		MaxTokens:   anthropicDefaultMaxTokens,
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+	}
+	if request.MaxTokens != nil && *request.MaxTokens > 0 {
+		result.MaxTokens = *request.MaxTokens
+	}
+
+	var system []string
+	hasToolCalls := false
+	for _, message := range request.Messages {
+		if message.Role == RoleSystem {
+			if text := messageText(message); text != "" {
+				system = append(system, text)
+			}
+			continue
+		}
+		if len(message.ToolCalls) > 0 {
+			hasToolCalls = true
+		}
+		converted := c.toAnthropicMessage(message)
+		if len(converted.Content) == 0 {
+			continue
+		}
+		// anthropic requires alternating roles, merge consecutive messages
+		// (e.g. several tool results after one assistant message)
+		if last := len(result.Messages) - 1; last >= 0 && result.Messages[last].Role == converted.Role {
+			result.Messages[last].Content = append(result.Messages[last].Content, converted.Content...)
+			continue
+		}
+		result.Messages = append(result.Messages, converted)
+	}
+	result.System = strings.Join(system, "\n\n")
+
+	for _, tool := range request.Tools {
+		result.Tools = append(result.Tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: tool.Function.Parameters,
+		})
+	}
+
+	// thinking blocks are not stored in history, but anthropic requires them
+	// before tool_use blocks when thinking is enabled, so skip thinking for tool chains
+	if budget := thinkingBudget(request.Reasoning); budget > 0 && !hasToolCalls {
+		if result.MaxTokens <= budget {
+			result.MaxTokens = budget + anthropicDefaultMaxTokens
+		}
+		result.Thinking = &anthropicThinking{
+			Type:         "enabled",
+			BudgetTokens: budget,
+		}
+		// temperature and top_p are not compatible with thinking
+		result.Temperature = nil
+		result.TopP = nil
+	}
+
+	return result
+}
+
+func (c *AnthropicClient) toAnthropicMessage(message Message) anthropicMessage {
+	result := anthropicMessage{Role: RoleUser}
+
+	switch message.Role {
+	case RoleTool:
+		result.Content = append(result.Content, anthropicContent{
+			Type:      "tool_result",
+			ToolUseID: message.ToolCallID,
+			Content:   messageText(message),
+		})
+		return result
+	case RoleAssistant:
+		result.Role = RoleAssistant
+	}
+
+	if message.Text != "" {
+		result.Content = append(result.Content, anthropicContent{Type: "text", Text: message.Text})
+	}
+	for _, content := range message.Content {
+		switch content.Type {
+		case "text":
+			if strings.TrimSpace(content.Text) != "" {
+				result.Content = append(result.Content, anthropicContent{Type: "text", Text: content.Text})
+			}
+		case "image_url":
+			result.Content = append(result.Content, anthropicContent{
+				Type:   "image",
+				Source: newAnthropicSource(content.ImageURL.URL),
+			})
+		case "file":
+			result.Content = append(result.Content, anthropicContent{
+				Type:   "document",
+				Source: newAnthropicSource(content.File.FileData),
+			})
+		default:
+			c.logger.WithField("type", content.Type).Warn("Unsupported content type for anthropic, skip")
+		}
+	}
+	for _, toolCall := range message.ToolCalls {
+		input := json.RawMessage(toolCall.Function.Arguments)
+		if !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		result.Content = append(result.Content, anthropicContent{
+			Type:  "tool_use",
+			ID:    toolCall.ID,
+			Name:  toolCall.Function.Name,
+			Input: input,
+		})
+	}
+
+	return result
+}
+
+func (b anthropicContent) toToolCall(index int) ToolCall {
+	arguments := string(b.Input)
+	if arguments == "" {
+		arguments = "{}"
+	}
+	return ToolCall{
+		Index: index,
+		ID:    b.ID,
+		Type:  "function",
+		Function: FunctionCall{
+			Name:      b.Name,
+			Arguments: arguments,
+		},
+	}
+}
+
+// newAnthropicSource converts data URL (data:image/png;base64,...) or regular URL to source block
+func newAnthropicSource(url string) *anthropicSource {
+	if mediaType, data, found := strings.Cut(strings.TrimPrefix(url, "data:"), ";base64,"); found && strings.HasPrefix(url, "data:") {
+		return &anthropicSource{
+			Type:      "base64",
+			MediaType: mediaType,
+			Data:      data,
+		}
+	}
+	return &anthropicSource{
+		Type: "url",
+		URL:  url,
+	}
+}
+
+func messageText(message Message) string {
+	if message.Text != "" {
+		return message.Text
+	}
+	var texts []string
+	for _, content := range message.Content {
+		if content.Type == "text" && content.Text != "" {
+			texts = append(texts, content.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func thinkingBudget(reasoning *ModelReasoningParams) int {
+	if reasoning == nil || (reasoning.Enabled != nil && !*reasoning.Enabled) {
+		return 0
+	}
+	if reasoning.MaxTokens != nil {
+		return max(*reasoning.MaxTokens, anthropicMinThinkingBudget)
+	}
+	if reasoning.Effort != nil {
+		if budget, exists := anthropicThinkingEffortBudget[*reasoning.Effort]; exists {
+			return budget
+		}
+	}
+	if reasoning.Enabled != nil && *reasoning.Enabled {
+		return anthropicMinThinkingBudget
+	}
+	return 0
+}
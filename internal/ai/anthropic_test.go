@@ -0,0 +1,275 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAnthropicTestClient(t *testing.T, handler http.HandlerFunc) *AnthropicClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewAnthropicClient(config.AIProviderConfig{Name: "anthropic", BaseURL: server.URL}, nil, logger.NewTestLogger(), server.Client())
+}
+
+func TestAnthropicClient_toAnthropicRequest(t *testing.T) {
+	client := NewAnthropicClient(config.AIProviderConfig{Name: "anthropic"}, nil, logger.NewTestLogger(), http.DefaultClient)
+	temperature := float32(0.7)
+	effort := "medium"
+	weatherCall := ToolCall{ID: "toolu_1", Type: "function", Function: FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}}
+	timeCall := ToolCall{ID: "toolu_2", Type: "function", Function: FunctionCall{Name: "time", Arguments: "not json"}}
+
+	tests := []struct {
+		name    string
+		request CompletionRequest
+		want    anthropicRequest
+	}{
+		{
+			name: "system messages and content",
+			request: CompletionRequest{
+				Model: "claude",
+				Messages: []Message{
+					{Role: RoleSystem, Text: "Be short"},
+					{Role: RoleSystem, Content: []Content{{Type: "text", Text: "Answer in English"}}},
+					{Role: RoleUser, Content: []Content{
+						{Type: "text", Text: "what is it?"},
+						{Type: "text", Text: "  "},
+						{Type: "image_url", ImageURL: struct {
+							URL string `json:"url"`
+						}{URL: "data:image/png;base64,AAAA"}},
+						{Type: "image_url", ImageURL: struct {
+							URL string `json:"url"`
+						}{URL: "https://a.com/cat.png"}},
+					}},
+				},
+			},
+			want: anthropicRequest{
+				Model:     "claude",
+				System:    "Be short\n\nAnswer in English",
+				MaxTokens: anthropicDefaultMaxTokens,
+				Messages: []anthropicMessage{{Role: RoleUser, Content: []anthropicContent{
+					{Type: "text", Text: "what is it?"},
+					{Type: "image", Source: &anthropicSource{Type: "base64", MediaType: "image/png", Data: "AAAA"}},
+					{Type: "image", Source: &anthropicSource{Type: "url", URL: "https://a.com/cat.png"}},
+				}}},
+			},
+		},
+		{
+			name: "tool calls and merged tool results",
+			request: CompletionRequest{
+				Model: "claude",
+				Messages: []Message{
+					{Role: RoleUser, Text: "weather and time in Paris"},
+					{Role: RoleAssistant, ToolCalls: []ToolCall{weatherCall, timeCall}},
+					{Role: RoleTool, ToolCallID: "toolu_1", Text: "sunny"},
+					{Role: RoleTool, ToolCallID: "toolu_2", Content: []Content{{Type: "text", Text: "12:00"}}},
+				},
+				Tools: []Tool{{Type: "function", Function: ToolFunction{
+					Name:        "weather",
+					Description: "Weather in the city",
+					Parameters:  Parameters{Type: "object", Properties: map[string]Property{"city": {Type: "string"}}},
+				}}},
+				Temperature: &temperature,
+				Reasoning:   &ModelReasoningParams{Effort: &effort},
+			},
+			want: anthropicRequest{
+				Model:       "claude",
+				MaxTokens:   anthropicDefaultMaxTokens,
+				Temperature: &temperature,
+				Messages: []anthropicMessage{
+					{Role: RoleUser, Content: []anthropicContent{{Type: "text", Text: "weather and time in Paris"}}},
+					{Role: RoleAssistant, Content: []anthropicContent{
+						{Type: "tool_use", ID: "toolu_1", Name: "weather", Input: json.RawMessage(`{"city":"Paris"}`)},
+						{Type: "tool_use", ID: "toolu_2", Name: "time", Input: json.RawMessage("{}")},
+					}},
+					{Role: RoleUser, Content: []anthropicContent{
+						{Type: "tool_result", ToolUseID: "toolu_1", Content: "sunny"},
+						{Type: "tool_result", ToolUseID: "toolu_2", Content: "12:00"},
+					}},
+				},
+				Tools: []anthropicTool{{
+					Name:        "weather",
+					Description: "Weather in the city",
+					InputSchema: Parameters{Type: "object", Properties: map[string]Property{"city": {Type: "string"}}},
+				}},
+			},
+		},
+		{
+			name: "thinking without tool calls",
+			request: CompletionRequest{
+				Model:       "claude",
+				Messages:    []Message{{Role: RoleUser, Text: "think"}},
+				Temperature: &temperature,
+				Reasoning:   &ModelReasoningParams{Effort: &effort},
+			},
+			want: anthropicRequest{
+				Model:     "claude",
+				MaxTokens: anthropicThinkingEffortBudget["medium"] + anthropicDefaultMaxTokens,
+				Messages:  []anthropicMessage{{Role: RoleUser, Content: []anthropicContent{{Type: "text", Text: "think"}}}},
+				Thinking:  &anthropicThinking{Type: "enabled", BudgetTokens: anthropicThinkingEffortBudget["medium"]},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, client.toAnthropicRequest(tt.request, false))
+		})
+	}
+}
+
+func TestAnthropicClient_Ask(t *testing.T) {
+	client := newAnthropicTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		assert.Equal(t, anthropicAPIVersion, r.Header.Get("anthropic-version"))
+		fmt.Fprint(w, `{
+			"id": "msg_1",
+			"content": [
+				{"type": "thinking", "thinking": "sunny?"},
+				{"type": "text", "text": "Let me check"},
+				{"type": "tool_use", "id": "toolu_1", "name": "weather", "input": {"city": "Paris"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 10, "cache_read_input_tokens": 2, "output_tokens": 5}
+		}`)
+	})
+
+	content, reasoning, response, _, err := client.Ask(t.Context(), CompletionRequest{Model: "claude"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Let me check", content)
+	assert.Equal(t, "sunny?", reasoning)
+	toolCalls := response.Choices[0].Message.ToolCalls
+	require.Len(t, toolCalls, 1)
+	assert.Equal(t, "toolu_1", toolCalls[0].ID)
+	assert.Equal(t, "weather", toolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"city":"Paris"}`, toolCalls[0].Function.Arguments)
+	assert.Equal(t, int64(12), response.Usage.PromptTokens)
+	assert.Equal(t, 2, response.Usage.PromptTokensDetail.CachedTokens)
+	assert.Equal(t, int64(17), response.Usage.TotalTokens)
+}
+
+func TestAnthropicClient_Ask_Error(t *testing.T) {
+	client := newAnthropicTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(529)
+		fmt.Fprint(w, `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`)
+	})
+
+	_, _, _, _, err := client.Ask(t.Context(), CompletionRequest{Model: "claude"}, nil)
+	var aiErr *AIError
+	require.ErrorAs(t, err, &aiErr)
+	assert.Equal(t, "overloaded_error", aiErr.ErrorCode)
+	assert.Equal(t, "Overloaded", aiErr.Message)
+	assert.Equal(t, "claude", aiErr.ModelName)
+}
+
+// sseEvents formats the events as the server-sent events of the stream
+func sseEvents(events ...string) string {
+	var result strings.Builder
+	for _, event := range events {
+		var typed struct {
+			Type string `json:"type"`
+		}
+		_ = json.Unmarshal([]byte(event), &typed)
+		fmt.Fprintf(&result, "event: %s\ndata: %s\n\n", typed.Type, event)
+	}
+	return result.String()
+}
+
+func TestAnthropicClient_AskStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		stream    string
+		content   string
+		reasoning string
+		tools     []ToolCall
+		usage     *ModelUsage
+		errorCode string
+	}{
+		{
+			name: "text and tool use",
+			stream: sseEvents(
+				`{"type": "message_start", "message": {"id": "msg_1", "usage": {"input_tokens": 10, "output_tokens": 1}}}`,
+				`{"type": "content_block_start", "index": 0, "content_block": {"type": "thinking", "thinking": ""}}`,
+				`{"type": "content_block_delta", "index": 0, "delta": {"type": "thinking_delta", "thinking": "sunny?"}}`,
+				`{"type": "content_block_start", "index": 1, "content_block": {"type": "text", "text": ""}}`,
+				`{"type": "ping"}`,
+				`{"type": "content_block_delta", "index": 1, "delta": {"type": "text_delta", "text": "Let me "}}`,
+				`{"type": "content_block_delta", "index": 1, "delta": {"type": "text_delta", "text": "check"}}`,
+				`{"type": "content_block_stop", "index": 1}`,
+				`{"type": "content_block_start", "index": 2, "content_block": {"type": "tool_use", "id": "toolu_1", "name": "weather", "input": {}}}`,
+				`{"type": "content_block_delta", "index": 2, "delta": {"type": "input_json_delta", "partial_json": "{\"city\":"}}`,
+				`{"type": "content_block_delta", "index": 2, "delta": {"type": "input_json_delta", "partial_json": " \"Paris\"}"}}`,
+				`{"type": "content_block_start", "index": 3, "content_block": {"type": "tool_use", "id": "toolu_2", "name": "time", "input": {}}}`,
+				`{"type": "message_delta", "delta": {"stop_reason": "tool_use"}, "usage": {"output_tokens": 5}}`,
+				`{"type": "message_stop"}`,
+			),
+			content:   "Let me check",
+			reasoning: "sunny?",
+			tools: []ToolCall{
+				{Index: 0, ID: "toolu_1", Type: "function", Function: FunctionCall{Name: "weather", Arguments: `{"city": "Paris"}`}},
+				{Index: 1, ID: "toolu_2", Type: "function", Function: FunctionCall{Name: "time", Arguments: "{}"}},
+			},
+			usage: &ModelUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		},
+		{
+			name: "error event",
+			stream: sseEvents(
+				`{"type": "message_start", "message": {"id": "msg_1", "usage": {"input_tokens": 10}}}`,
+				`{"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "Hi"}}`,
+				`{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`,
+			),
+			content:   "Hi",
+			errorCode: "overloaded_error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newAnthropicTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				var request anthropicRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+				assert.True(t, request.Stream)
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, tt.stream)
+			})
+
+			chunks, _, err := client.AskStream(t.Context(), CompletionRequest{Model: "claude"}, nil)
+			require.NoError(t, err)
+			var content, reasoning strings.Builder
+			var tools []ToolCall
+			var usage *ModelUsage
+			var streamErr *AIError
+			for chunk := range chunks {
+				content.WriteString(chunk.Content)
+				reasoning.WriteString(chunk.Reasoning)
+				tools = append(tools, chunk.Tools...)
+				if chunk.Usage != nil {
+					usage = chunk.Usage
+				}
+				if chunk.Error != nil {
+					streamErr = chunk.Error
+				}
+			}
+
+			assert.Equal(t, tt.content, content.String())
+			assert.Equal(t, tt.reasoning, reasoning.String())
+			assert.Equal(t, tt.tools, tools)
+			assert.Equal(t, tt.usage, usage)
+			if tt.errorCode == "" {
+				assert.Nil(t, streamErr)
+				return
+			}
+			require.NotNil(t, streamErr)
+			assert.Equal(t, tt.errorCode, streamErr.ErrorCode)
+			assert.Equal(t, "Overloaded", streamErr.Message)
+			assert.Equal(t, 529, streamErr.HTTPStatusCode, "Overloaded error is retried like the 529 status")
+		})
+	}
+}
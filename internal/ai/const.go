@@ -13,6 +13,7 @@ const (
 	ProviderOpenrouter = "openrouter"
 	ProviderOpenai     = "openai-compatible"
 	ProviderLocal      = "local"
+	ProviderAnthropic  = "anthropic"
 
 	RoleUser      = "user"
 	RoleAssistant = "assistant"
@@ -49,6 +49,13 @@ func NewOpenAICompatibleClient(
 		chatURL = "/chat/completions"
 	}
 	baseHTTPClient := NewBaseHTTPClient(httpClient, baseURL, apiKey, log)
+	if cfg != nil {
+		redactor, err := logger.NewRedactor(cfg.Log().RedactPatterns)
+		if err != nil {
+			log.WithError(err).Warn("Invalid log redact patterns")
+		}
+		baseHTTPClient.SetRedactor(redactor)
+	}
 
 	return &OpenAICompatibleClient{
 		name:           name,
@@ -61,6 +68,17 @@ func NewOpenAICompatibleClient(
 	}
 }
 
+func (c *OpenAICompatibleClient) SetTokenProvider(provider TokenProvider) {
+	c.httpClient.SetTokenProvider(provider)
+}
+
+// SetHeaders adds static headers to every request, headers of a request take precedence
+func (c *OpenAICompatibleClient) SetHeaders(headers map[string]string) {
+	for key, value := range headers {
+		c.httpClient.SetHeader(key, value)
+	}
+}
+
 func (c *OpenAICompatibleClient) Name() string {
 	return c.name
 }
@@ -132,6 +150,7 @@ func (c *OpenAICompatibleClient) Ask(
 		}
 	}
 
+	result.Usage.Model = result.Model
 	choice := result.Choices[0]
 	message := choice.Message
 	reasoning := message.Reasoning
@@ -252,6 +271,7 @@ func (c *OpenAICompatibleClient) AskStream(
 
 				chunk.Reasoning = reasoning
 			} else if event.Usage != nil {
+				event.Usage.Model = event.Model
 				chunk = Chunk{
 					Usage: event.Usage,
 				}
@@ -263,6 +283,29 @@ func (c *OpenAICompatibleClient) AskStream(
 	return chunkCh, request.ModelInfo, nil
 }
 
+// Speech calls the OpenAI compatible audio/speech endpoint
+func (c *OpenAICompatibleClient) Speech(ctx context.Context, model, voice, input string) ([]byte, error) {
+	request := map[string]any{
+		"model":           model,
+		"voice":           voice,
+		"input":           input,
+		"response_format": "opus",
+	}
+	_, body, aiErr := c.doRequest(ctx, "POST", "/audio/speech", request, nil, false)
+	if aiErr != nil {
+		aiErr.ModelName = model
+		return nil, aiErr
+	}
+	if len(body) == 0 {
+		return nil, &AIError{
+			ProviderName: c.Name(),
+			ModelName:    model,
+			Message:      "empty speech response",
+		}
+	}
+	return body, nil
+}
+
 func (c *OpenAICompatibleClient) doRequest(
 	ctx context.Context,
 	method string,
@@ -308,6 +351,7 @@ func (c *OpenAICompatibleClient) doRequest(
 			ProviderName:   c.Name(),
 			HTTPStatusCode: resp.StatusCode,
 			Message:        fmt.Sprintf("HTTP request failed with status code: %d", resp.StatusCode),
+			RetryAfter:     parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
 		}
 
 		if len(responseBody) > 0 {
@@ -365,6 +409,7 @@ func (c *OpenAICompatibleClient) CreateRequest(
 		TopP:             params.TopP,
 		FrequencyPenalty: params.FrequencyPenalty,
 		PresencePenalty:  params.PresencePenalty,
+		Seed:             params.Seed,
 		Usage: struct {
 			Include bool "json:\"include\""
 		}{Include: true},
@@ -372,6 +417,9 @@ func (c *OpenAICompatibleClient) CreateRequest(
 		WebSearch: webSearch,
 	}
 
+	if params.Route != nil {
+		reqBody.Route = *params.Route
+	}
 	if len(tools) > 0 {
 		reqBody.Tools = tools
 	}
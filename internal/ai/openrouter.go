@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/muratoffalex/gachigazer/internal/config"
@@ -14,11 +17,23 @@ import (
 
 var ErrorModelNotFound = errors.New("model not found")
 
+// OpenRouterSortValues are the routes sorting providers instead of pinning a specific one
+var OpenRouterSortValues = []string{"price", "latency", "throughput"}
+
+// provider slugs like "anthropic", "google-vertex" or "deepinfra/turbo"
+var openRouterProviderRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9\-./]*$`)
+
+// IsValidOpenRouterRoute reports whether the value is a sort strategy or a provider slug
+func IsValidOpenRouterRoute(value string) bool {
+	return slices.Contains(OpenRouterSortValues, value) || openRouterProviderRegex.MatchString(value)
+}
+
 type OpenRouterClient struct {
 	*OpenAICompatibleClient
 	freeModels     []string
 	rng            *rand.Rand
 	onlyFreeModels bool
+	fallbackModels []string
 }
 
 func NewOpenRouterClient(cfg config.AIProviderConfig, globalCfg *config.Config, log logger.Logger, httpClient *http.Client) *OpenRouterClient {
@@ -42,6 +57,7 @@ func NewOpenRouterClient(cfg config.AIProviderConfig, globalCfg *config.Config,
 		OpenAICompatibleClient: baseClient,
 		rng:                    rand.New(rand.NewSource(time.Now().UnixNano())),
 		onlyFreeModels:         cfg.OnlyFreeModels,
+		fallbackModels:         cfg.FallbackModels,
 	}
 }
 
@@ -88,25 +104,29 @@ func (c *OpenRouterClient) GetModels(ctx context.Context, onlyFree, fresh bool)
 	return models, nil
 }
 
+// GetRandomFreeModel returns a random free model, models from ai.blocked_models are skipped
 func (c *OpenRouterClient) GetRandomFreeModel(ctx context.Context) (string, error) {
 	models, err := c.GetModels(ctx, true, false)
 	if err != nil {
 		return "", err
 	}
-	if len(models) == 0 {
-		return "", errors.New("no free models available")
+	var blocked func(string) bool
+	if c.cfg != nil {
+		blocked = c.cfg.AI().IsModelBlocked
 	}
-	randomIndex := c.rng.Intn(len(models))
-
-	i := 0
+	candidates := make([]string, 0, len(models))
 	for modelID := range models {
-		if i == randomIndex {
-			return modelID, nil
+		if blocked == nil || !blocked(c.Name()+":"+modelID) {
+			candidates = append(candidates, modelID)
 		}
-		i++
 	}
+	if len(candidates) == 0 {
+		return "", errors.New("no free models available")
+	}
+	// map order is random, sorting keeps the choice up to the rng only
+	slices.Sort(candidates)
 
-	return "", errors.New("failed to select random model")
+	return candidates[c.rng.Intn(len(candidates))], nil
 }
 
 func (c *OpenRouterClient) Ask(ctx context.Context, request CompletionRequest, headers map[string]string) (string, string, *CompletionResponse, *ModelInfo, error) {
@@ -122,6 +142,10 @@ func (c *OpenRouterClient) Ask(ctx context.Context, request CompletionRequest, h
 	}
 
 	headers = c.setHeaders(headers)
+	applyRoute(&request)
+	if len(request.Models) > 0 {
+		request.Models[0] = request.Model
+	}
 
 	return c.OpenAICompatibleClient.Ask(ctx, request, headers)
 }
@@ -140,19 +164,80 @@ func (c *OpenRouterClient) AskStream(ctx context.Context, request CompletionRequ
 
 	headers = c.setHeaders(headers)
 
-	request.Provider = struct {
-		Sort              string `json:"sort,omitzero"` // price, latency, throughput
-		RequireParameters bool   `json:"require_parameters,omitzero"`
-	}{
+	request.Provider = ProviderPreferences{
 		Sort: "price",
 	}
 	if request.ModelInfo.IsFree() {
 		request.Provider.Sort = "throughput"
 	}
+	applyRoute(&request)
+	if len(request.Models) > 0 {
+		request.Models[0] = request.Model
+	}
 
 	return c.OpenAICompatibleClient.AskStream(ctx, request, headers)
 }
 
+// CreateRequest adds provider.fallback_models, OpenRouter switches to them itself
+// when the requested model fails upstream, without another request from us
+func (c *OpenRouterClient) CreateRequest(
+	stream bool,
+	messages []Message,
+	tools []Tool,
+	model *ModelInfo,
+	params ModelParams,
+	webSearch bool,
+) CompletionRequest {
+	request := c.OpenAICompatibleClient.CreateRequest(stream, messages, tools, model, params, webSearch)
+	request.Models = c.fallbackRouting(request.Model, model.IsFree() || c.onlyFreeModels)
+	return request
+}
+
+// fallbackRouting returns the requested model followed by the fallback models, for a free
+// model only free fallbacks known from the models list are added. Nil without fallbacks
+func (c *OpenRouterClient) fallbackRouting(model string, free bool) []string {
+	models := []string{model}
+	for _, fallback := range c.fallbackModels {
+		if slices.Contains(models, fallback) {
+			continue
+		}
+		if free {
+			if info, ok := c.getModelFromCache(fallback); !ok || !info.IsFree() {
+				continue
+			}
+		}
+		models = append(models, fallback)
+	}
+	if len(models) == 1 {
+		return nil
+	}
+	return models
+}
+
+// AnsweredFallback returns the fallback model that answered instead of the requested one by
+// the model named in the response, it may be a dated version like openai/gpt-4o-2024-08-06,
+// so the longest matching model wins
+func (c *OpenRouterClient) AnsweredFallback(requested, responseModel string) (string, bool) {
+	answered, length := "", 0
+	for i, model := range slices.Concat([]string{requested}, c.fallbackModels) {
+		base := baseModelID(model)
+		if responseModel != base && !strings.HasPrefix(responseModel, base+"-") || len(base) <= length {
+			continue
+		}
+		answered, length = "", len(base)
+		if i > 0 {
+			answered = model
+		}
+	}
+	return answered, answered != ""
+}
+
+// baseModelID drops the variant, e.g. :free, the response names the model without it
+func baseModelID(model string) string {
+	model, _, _ = strings.Cut(model, ":")
+	return model
+}
+
 func (c *OpenRouterClient) GetModelInfo(name string) (*ModelInfo, error) {
 	if name == "random-free" {
 		model, err := c.GetRandomFreeModel(context.Background())
@@ -168,8 +253,27 @@ func (c *OpenRouterClient) setHeaders(headers map[string]string) map[string]stri
 	if headers == nil {
 		headers = map[string]string{}
 	}
-	headers["X-Title"] = "Gachigazer"
+	// can be replaced with provider headers from config
+	if !c.httpClient.hasHeader("X-Title") {
+		headers["X-Title"] = "Gachigazer"
+	}
 	// headers["HTTP-Referrer"] = ""
 
 	return headers
 }
+
+// applyRoute sets the provider routing from $route: a sort strategy replaces the default
+// one, a provider slug pins the provider without falling back to others
+func applyRoute(request *CompletionRequest) {
+	switch {
+	case request.Route == "":
+		return
+	case slices.Contains(OpenRouterSortValues, request.Route):
+		request.Provider.Sort = request.Route
+	default:
+		allowFallbacks := false
+		request.Provider.Sort = ""
+		request.Provider.Order = []string{request.Route}
+		request.Provider.AllowFallbacks = &allowFallbacks
+	}
+}
@@ -107,6 +107,23 @@ func (r *ProviderRegistry) ResolveModel(ctx context.Context, modelSpec string, c
 	return nil, "", err
 }
 
+// Speech synthesizes the text with the model in provider:model format,
+// the provider must support text to speech
+func (r *ProviderRegistry) Speech(ctx context.Context, modelSpec, voice, text string) ([]byte, error) {
+	if modelSpec == "" {
+		return nil, errors.New("speech model is not set")
+	}
+	provider, modelName, err := r.ResolveModel(ctx, modelSpec, 0)
+	if err != nil {
+		return nil, err
+	}
+	speechProvider, ok := provider.(SpeechProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s doesn't support speech synthesis", provider.Name())
+	}
+	return speechProvider.Speech(ctx, modelName, voice, text)
+}
+
 // Ask performs a request with automatic provider and model resolution
 func (r *ProviderRegistry) Ask(ctx context.Context, messages []Message, tools []Tool, model *ModelInfo, promptName string, chatID int64, webSearch bool, requestParams ModelParams) (string, string, *CompletionResponse, *ModelInfo, *ModelParams, error) {
 	provider, _, err := r.ResolveModel(ctx, model.FullName(), chatID)
@@ -180,6 +197,12 @@ func (r *ProviderRegistry) GetFormattedModel(ctx context.Context, modelName, pro
 			break
 		}
 	}
+	if model == nil {
+		if err == nil {
+			err = fmt.Errorf("%w: %s", ErrModelNotFound, modelName)
+		}
+		return nil, err
+	}
 	model.Alias = aliasName
 	return model, err
 }
@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// token is refreshed this long before it expires
+	tokenRefreshMargin  = 1 * time.Minute
+	defaultTokenTTL     = 1 * time.Hour
+	tokenCommandTimeout = 30 * time.Second
+)
+
+var ErrEmptyToken = errors.New("token provider returned empty token")
+
+// TokenProvider issues short-lived access tokens (e.g. cloud IAM)
+// sent in the Authorization header instead of a static api key
+type TokenProvider interface {
+	Token(ctx context.Context) (token string, expiresAt time.Time, err error)
+}
+
+// cachedToken keeps the last issued token and asks the provider
+// for a new one only when the current is about to expire
+type cachedToken struct {
+	provider  TokenProvider
+	token     string
+	expiresAt time.Time
+	mu        sync.Mutex
+	now       func() time.Time
+}
+
+func newCachedToken(provider TokenProvider) *cachedToken {
+	return &cachedToken{
+		provider: provider,
+		now:      time.Now,
+	}
+}
+
+func (c *cachedToken) Get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && c.now().Add(tokenRefreshMargin).Before(c.expiresAt) {
+		return c.token, nil
+	}
+
+	token, expiresAt, err := c.provider.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh token: %w", err)
+	}
+	if token == "" {
+		return "", ErrEmptyToken
+	}
+	c.token = token
+	c.expiresAt = expiresAt
+	return token, nil
+}
+
+// CommandTokenProvider gets a token from stdout of a shell command,
+// e.g. `gcloud auth print-access-token`
+type CommandTokenProvider struct {
+	command string
+	ttl     time.Duration
+}
+
+func NewCommandTokenProvider(command string, ttl time.Duration) *CommandTokenProvider {
+	if ttl <= 0 {
+		ttl = defaultTokenTTL
+	}
+	return &CommandTokenProvider{
+		command: command,
+		ttl:     ttl,
+	}
+}
+
+func (p *CommandTokenProvider) Token(ctx context.Context) (string, time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, tokenCommandTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "sh", "-c", p.command).Output()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("token command failed: %w", err)
+	}
+	return strings.TrimSpace(string(output)), time.Now().Add(p.ttl), nil
+}
@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTokenProvider struct {
+	calls int
+	ttl   time.Duration
+	now   func() time.Time
+	err   error
+}
+
+func (p *mockTokenProvider) Token(ctx context.Context) (string, time.Time, error) {
+	if p.err != nil {
+		return "", time.Time{}, p.err
+	}
+	p.calls++
+	return fmt.Sprintf("token-%d", p.calls), p.now().Add(p.ttl), nil
+}
+
+func TestBaseHTTPClient_Do_RotatesToken(t *testing.T) {
+	var authHeaders []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	clock := func() time.Time { return now }
+	provider := &mockTokenProvider{ttl: 10 * time.Minute, now: clock}
+
+	client := NewBaseHTTPClient(server.Client(), server.URL, "static-key", logger.NewTestLogger())
+	client.SetTokenProvider(provider)
+	client.token.now = clock
+
+	doRequest := func() {
+		req, err := http.NewRequest(http.MethodGet, "/models", nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	doRequest()
+	// still valid, cached token is reused
+	now = now.Add(5 * time.Minute)
+	doRequest()
+	// within refresh margin before expiry
+	now = now.Add(4*time.Minute + 30*time.Second)
+	doRequest()
+
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}, authHeaders)
+	assert.Equal(t, 2, provider.calls)
+}
+
+func TestBaseHTTPClient_Do_TokenProviderError(t *testing.T) {
+	provider := &mockTokenProvider{err: errors.New("iam unavailable"), now: time.Now}
+	client := NewBaseHTTPClient(http.DefaultClient, "http://localhost", "", logger.NewTestLogger())
+	client.SetTokenProvider(provider)
+
+	req, err := http.NewRequest(http.MethodGet, "/models", nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "iam unavailable")
+}
+
+func TestCommandTokenProvider_Token(t *testing.T) {
+	provider := NewCommandTokenProvider("echo '  secret-token  '", time.Minute)
+
+	token, expiresAt, err := provider.Token(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "secret-token", token)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, 5*time.Second)
+}
@@ -21,6 +21,8 @@ type Property struct {
 	Enum        []string  `json:"enum,omitzero"`
 	Description string    `json:"description,omitzero"`
 	Items       *Property `json:"items,omitzero"`
+	// properties of the object, e.g. of the array items
+	Properties map[string]Property `json:"properties,omitzero"`
 }
 
 type Tool struct {
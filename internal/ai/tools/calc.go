@@ -0,0 +1,387 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	calcMaxLength = 500
+	calcMaxDepth  = 50
+)
+
+var calcConstants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+type calcFunc struct {
+	args int
+	fn   func(args []float64) float64
+}
+
+func calcUnary(fn func(float64) float64) calcFunc {
+	return calcFunc{args: 1, fn: func(args []float64) float64 { return fn(args[0]) }}
+}
+
+func calcBinary(fn func(float64, float64) float64) calcFunc {
+	return calcFunc{args: 2, fn: func(args []float64) float64 { return fn(args[0], args[1]) }}
+}
+
+// trigonometric functions take radians
+var calcFunctions = map[string]calcFunc{
+	"sqrt":  calcUnary(math.Sqrt),
+	"cbrt":  calcUnary(math.Cbrt),
+	"abs":   calcUnary(math.Abs),
+	"sin":   calcUnary(math.Sin),
+	"cos":   calcUnary(math.Cos),
+	"tan":   calcUnary(math.Tan),
+	"asin":  calcUnary(math.Asin),
+	"acos":  calcUnary(math.Acos),
+	"atan":  calcUnary(math.Atan),
+	"ln":    calcUnary(math.Log),
+	"log":   calcUnary(math.Log10),
+	"log2":  calcUnary(math.Log2),
+	"exp":   calcUnary(math.Exp),
+	"floor": calcUnary(math.Floor),
+	"ceil":  calcUnary(math.Ceil),
+	"round": calcUnary(math.Round),
+	"pow":   calcBinary(math.Pow),
+	"min":   calcBinary(math.Min),
+	"max":   calcBinary(math.Max),
+}
+
+// Calc evaluates the math expression, only numbers, operators, constants and the known
+// functions are accepted, nothing else is executed
+func (t Tools) Calc(expression string) (string, error) {
+	value, normalized, err := EvaluateExpression(expression)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Result: %s\nExpression: %s", formatCalcNumber(value), normalized), nil
+}
+
+// EvaluateExpression returns the value of the expression and its normalized form.
+// Supported: + - * / ^ (or **), parentheses, pi and e, functions like sqrt(x) or log(x)
+// (log is base 10, ln is natural), percentages: 10% is 0.1, 200 + 10% is 220, 15% of 80 is 12
+func EvaluateExpression(expression string) (float64, string, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return 0, "", errors.New("expression is empty")
+	}
+	if len(expression) > calcMaxLength {
+		return 0, "", fmt.Errorf("expression is too long, max %d characters", calcMaxLength)
+	}
+	tokens, err := tokenizeExpression(expression)
+	if err != nil {
+		return 0, "", err
+	}
+	p := &calcParser{tokens: tokens}
+	result, err := p.parseExpression()
+	if err != nil {
+		return 0, "", err
+	}
+	if p.pos < len(p.tokens) {
+		return 0, "", fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if math.IsNaN(result.value) || math.IsInf(result.value, 0) {
+		return 0, "", fmt.Errorf("result of %s is not a finite number", result.text)
+	}
+	return result.value, result.text, nil
+}
+
+func formatCalcNumber(value float64) string {
+	if value == 0 {
+		// no negative zero
+		return "0"
+	}
+	return strconv.FormatFloat(value, 'g', 15, 64)
+}
+
+type calcTokenKind int
+
+const (
+	calcNumber calcTokenKind = iota
+	calcIdent
+	calcOperator
+)
+
+type calcToken struct {
+	kind  calcTokenKind
+	text  string
+	value float64
+}
+
+func tokenizeExpression(expression string) ([]calcToken, error) {
+	var tokens []calcToken
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			// exponent like 1e6 or 2.5E-3
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					i = j
+					for i < len(runes) && unicode.IsDigit(runes[i]) {
+						i++
+					}
+				}
+			}
+			text := string(runes[start:i])
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", text)
+			}
+			tokens = append(tokens, calcToken{kind: calcNumber, text: text, value: value})
+		case unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, calcToken{kind: calcIdent, text: strings.ToLower(string(runes[start:i]))})
+		case r == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			tokens = append(tokens, calcToken{kind: calcOperator, text: "^"})
+			i += 2
+		case strings.ContainsRune("+-*/^%(),", r):
+			tokens = append(tokens, calcToken{kind: calcOperator, text: string(r)})
+			i++
+		case r == '×':
+			tokens = append(tokens, calcToken{kind: calcOperator, text: "*"})
+			i++
+		case r == '÷':
+			tokens = append(tokens, calcToken{kind: calcOperator, text: "/"})
+			i++
+		default:
+			return nil, fmt.Errorf("unsupported character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+// calcNode is a parsed part of the expression, percent is set for a bare percentage
+// like 10%, added to or subtracted from a value it's taken of that value
+type calcNode struct {
+	value   float64
+	text    string
+	percent bool
+}
+
+type calcParser struct {
+	tokens []calcToken
+	pos    int
+	depth  int
+}
+
+func (p *calcParser) peek(text string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind != calcNumber && p.tokens[p.pos].text == text
+}
+
+func (p *calcParser) expect(text string) error {
+	if !p.peek(text) {
+		if p.pos < len(p.tokens) {
+			return fmt.Errorf("expected %q, got %q", text, p.tokens[p.pos].text)
+		}
+		return fmt.Errorf("expected %q at the end", text)
+	}
+	p.pos++
+	return nil
+}
+
+// expression = term (("+" | "-") term)*
+func (p *calcParser) parseExpression() (calcNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > calcMaxDepth {
+		return calcNode{}, errors.New("expression is nested too deeply")
+	}
+
+	left, err := p.parseTerm()
+	if err != nil {
+		return calcNode{}, err
+	}
+	for p.peek("+") || p.peek("-") {
+		operator := p.tokens[p.pos].text
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return calcNode{}, err
+		}
+		amount := right.value
+		if right.percent {
+			amount = left.value * right.value
+		}
+		if operator == "+" {
+			left.value += amount
+		} else {
+			left.value -= amount
+		}
+		left.text += " " + operator + " " + right.text
+		left.percent = false
+	}
+	return left, nil
+}
+
+// term = unary (("*" | "/" | "of") unary)*
+func (p *calcParser) parseTerm() (calcNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return calcNode{}, err
+	}
+	for p.peek("*") || p.peek("/") || p.peek("of") {
+		operator := p.tokens[p.pos].text
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return calcNode{}, err
+		}
+		switch operator {
+		case "/":
+			if right.value == 0 {
+				return calcNode{}, errors.New("division by zero")
+			}
+			left.value /= right.value
+			left.text += " / " + right.text
+		case "of":
+			left.value *= right.value
+			left.text += " of " + right.text
+		default:
+			left.value *= right.value
+			left.text += " * " + right.text
+		}
+		left.percent = false
+	}
+	return left, nil
+}
+
+// unary = ("+" | "-") unary | power
+func (p *calcParser) parseUnary() (calcNode, error) {
+	if p.peek("-") || p.peek("+") {
+		operator := p.tokens[p.pos].text
+		p.pos++
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > calcMaxDepth {
+			return calcNode{}, errors.New("expression is nested too deeply")
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return calcNode{}, err
+		}
+		if operator == "-" {
+			operand.value = -operand.value
+			operand.text = "-" + operand.text
+		}
+		return operand, nil
+	}
+	return p.parsePower()
+}
+
+// power = postfix ("^" unary)?, right associative, -2^2 is -4
+func (p *calcParser) parsePower() (calcNode, error) {
+	base, err := p.parsePostfix()
+	if err != nil {
+		return calcNode{}, err
+	}
+	if !p.peek("^") {
+		return base, nil
+	}
+	p.pos++
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return calcNode{}, err
+	}
+	return calcNode{
+		value: math.Pow(base.value, exponent.value),
+		text:  base.text + "^" + exponent.text,
+	}, nil
+}
+
+// postfix = primary "%"?
+func (p *calcParser) parsePostfix() (calcNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return calcNode{}, err
+	}
+	if p.peek("%") {
+		p.pos++
+		return calcNode{value: node.value / 100, text: node.text + "%", percent: true}, nil
+	}
+	return node, nil
+}
+
+// primary = number | constant | function "(" arguments ")" | "(" expression ")"
+func (p *calcParser) parsePrimary() (calcNode, error) {
+	if p.pos >= len(p.tokens) {
+		return calcNode{}, errors.New("unexpected end of expression")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch {
+	case token.kind == calcNumber:
+		return calcNode{value: token.value, text: formatCalcNumber(token.value)}, nil
+	case token.kind == calcIdent:
+		if value, ok := calcConstants[token.text]; ok {
+			return calcNode{value: value, text: token.text}, nil
+		}
+		function, ok := calcFunctions[token.text]
+		if !ok {
+			return calcNode{}, fmt.Errorf("unknown function or constant %q", token.text)
+		}
+		return p.parseCall(token.text, function)
+	case token.text == "(":
+		node, err := p.parseExpression()
+		if err != nil {
+			return calcNode{}, err
+		}
+		if err := p.expect(")"); err != nil {
+			return calcNode{}, err
+		}
+		return calcNode{value: node.value, text: "(" + node.text + ")"}, nil
+	}
+	return calcNode{}, fmt.Errorf("unexpected %q", token.text)
+}
+
+func (p *calcParser) parseCall(name string, function calcFunc) (calcNode, error) {
+	if err := p.expect("("); err != nil {
+		return calcNode{}, fmt.Errorf("%s: %w", name, err)
+	}
+	args := make([]float64, 0, function.args)
+	texts := make([]string, 0, function.args)
+	for {
+		arg, err := p.parseExpression()
+		if err != nil {
+			return calcNode{}, err
+		}
+		args = append(args, arg.value)
+		texts = append(texts, arg.text)
+		if !p.peek(",") {
+			break
+		}
+		p.pos++
+	}
+	if err := p.expect(")"); err != nil {
+		return calcNode{}, err
+	}
+	if len(args) != function.args {
+		return calcNode{}, fmt.Errorf("%s takes %d argument(s), got %d", name, function.args, len(args))
+	}
+	return calcNode{
+		value: function.fn(args),
+		text:  name + "(" + strings.Join(texts, ", ") + ")",
+	}, nil
+}
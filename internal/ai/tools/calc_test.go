@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	tests := []struct {
+		expression string
+		value      float64
+		normalized string
+	}{
+		{"2+3*4", 14, "2 + 3 * 4"},
+		{"(2+3)*4", 20, "(2 + 3) * 4"},
+		{"2^3^2", 512, "2^3^2"},
+		{"2**10", 1024, "2^10"},
+		{"-2^2", -4, "-2^2"},
+		{"10 / 4", 2.5, "10 / 4"},
+		{"7 × 6 ÷ 3", 14, "7 * 6 / 3"},
+		{"1.5e3 + .5", 1500.5, "1500 + 0.5"},
+		{"sqrt(16) + SIN(0)", 4, "sqrt(16) + sin(0)"},
+		{"log(1000) + ln(e)", 4, "log(1000) + ln(e)"},
+		{"pow(2, 8) - max(1, 3)", 253, "pow(2, 8) - max(1, 3)"},
+		{"cos(pi)", -1, "cos(pi)"},
+		{"50%", 0.5, "50%"},
+		{"200 + 10%", 220, "200 + 10%"},
+		{"1500 - 12%", 1320, "1500 - 12%"},
+		{"15% of 80", 12, "15% of 80"},
+		{"80 * 15%", 12, "80 * 15%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			value, normalized, err := EvaluateExpression(tt.expression)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.value, value, 1e-9)
+			assert.Equal(t, tt.normalized, normalized)
+		})
+	}
+}
+
+func TestEvaluateExpression_Errors(t *testing.T) {
+	tests := map[string]string{
+		"":                        "expression is empty",
+		"2 +":                     "unexpected end of expression",
+		"(2 + 3":                  `expected ")" at the end`,
+		"2 3":                     `unexpected "3"`,
+		"1 / 0":                   "division by zero",
+		"sqrt(-1)":                "result of sqrt(-1) is not a finite number",
+		"10^1000":                 "result of 10^1000 is not a finite number",
+		"$HOME":                   `unsupported character '$'`,
+		"system(1)":               `unknown function or constant "system"`,
+		"pow(2)":                  "pow takes 2 argument(s), got 1",
+		"2; rm -rf":               `unsupported character ';'`,
+		"1..2":                    `invalid number "1..2"`,
+		strings.Repeat("(", 100):  "expression is nested too deeply",
+		strings.Repeat("1", 1000): "expression is too long, max 500 characters",
+	}
+	for expression, message := range tests {
+		t.Run(expression, func(t *testing.T) {
+			_, _, err := EvaluateExpression(expression)
+			assert.EqualError(t, err, message)
+		})
+	}
+}
+
+func TestTools_Calc(t *testing.T) {
+	result, err := Tools{}.Calc("0.1 + 0.2")
+	require.NoError(t, err)
+	assert.Equal(t, "Result: 0.3\nExpression: 0.1 + 0.2", result)
+
+	result, err = Tools{}.Calc("-0 * 5")
+	require.NoError(t, err)
+	assert.Equal(t, "Result: 0\nExpression: -0 * 5", result)
+
+	_, err = Tools{}.Calc("2 +")
+	require.Error(t, err)
+}
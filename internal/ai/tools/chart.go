@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wcharczuk/go-chart/v2"
+)
+
+const (
+	ChartLine = "line"
+	ChartBar  = "bar"
+	ChartPie  = "pie"
+
+	chartWidth  = 1024
+	chartHeight = 640
+
+	// limits of the make_chart input, a chart with more data can't be read anyway
+	chartMaxLabels      = 50
+	chartMaxSeries      = 5
+	chartMaxLabelLength = 40
+	chartMaxTitleLength = 100
+)
+
+// ChartSeries is a named row of values, one value per label
+type ChartSeries struct {
+	Name   string
+	Values []float64
+}
+
+// Make_chart renders the chart as PNG. Line charts take several series,
+// bar and pie charts take one. Returns the description of the chart for the model
+func (t Tools) Make_chart(chartType, title string, labels []string, series []ChartSeries) (string, []byte, error) {
+	if err := validateChart(chartType, title, labels, series); err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch chartType {
+	case ChartLine:
+		err = lineChart(title, labels, series).Render(chart.PNG, &buf)
+	case ChartBar:
+		err = barChart(title, labels, series[0]).Render(chart.PNG, &buf)
+	case ChartPie:
+		err = pieChart(title, labels, series[0]).Render(chart.PNG, &buf)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to render chart: %w", err)
+	}
+	return chartDescription(chartType, title, labels, series), buf.Bytes(), nil
+}
+
+func validateChart(chartType, title string, labels []string, series []ChartSeries) error {
+	switch chartType {
+	case ChartLine, ChartBar, ChartPie:
+	default:
+		return fmt.Errorf("unknown chart type %q, use %s, %s or %s", chartType, ChartLine, ChartBar, ChartPie)
+	}
+	if utf8.RuneCountInString(title) > chartMaxTitleLength {
+		return fmt.Errorf("title is longer than %d characters", chartMaxTitleLength)
+	}
+	if len(labels) == 0 || len(labels) > chartMaxLabels {
+		return fmt.Errorf("chart needs from 1 to %d labels", chartMaxLabels)
+	}
+	if chartType == ChartLine && len(labels) < 2 {
+		return errors.New("line chart needs at least 2 labels")
+	}
+	for _, label := range labels {
+		if utf8.RuneCountInString(label) > chartMaxLabelLength {
+			return fmt.Errorf("label %q is longer than %d characters", label, chartMaxLabelLength)
+		}
+	}
+	if len(series) == 0 || len(series) > chartMaxSeries {
+		return fmt.Errorf("chart needs from 1 to %d series", chartMaxSeries)
+	}
+	if chartType != ChartLine && len(series) > 1 {
+		return fmt.Errorf("%s chart takes one series", chartType)
+	}
+	for _, s := range series {
+		if len(s.Values) != len(labels) {
+			return fmt.Errorf("series %q has %d values for %d labels", s.Name, len(s.Values), len(labels))
+		}
+		for _, value := range s.Values {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				return fmt.Errorf("series %q has not a finite value", s.Name)
+			}
+			if chartType == ChartPie && value < 0 {
+				return errors.New("pie chart values can't be negative")
+			}
+		}
+	}
+	return nil
+}
+
+func lineChart(title string, labels []string, series []ChartSeries) chart.Chart {
+	xValues := make([]float64, len(labels))
+	ticks := make([]chart.Tick, len(labels))
+	for i, label := range labels {
+		xValues[i] = float64(i)
+		ticks[i] = chart.Tick{Value: float64(i), Label: label}
+	}
+	graph := chart.Chart{
+		Title:  title,
+		Width:  chartWidth,
+		Height: chartHeight,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 40, Left: 20, Right: 20, Bottom: 20},
+		},
+		XAxis: chart.XAxis{Ticks: ticks},
+	}
+	for _, s := range series {
+		graph.Series = append(graph.Series, chart.ContinuousSeries{
+			Name:    s.Name,
+			XValues: xValues,
+			YValues: s.Values,
+		})
+	}
+	if len(series) > 1 {
+		graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+	}
+	return graph
+}
+
+func barChart(title string, labels []string, series ChartSeries) chart.BarChart {
+	bars := make([]chart.Value, len(labels))
+	for i, label := range labels {
+		bars[i] = chart.Value{Label: label, Value: series.Values[i]}
+	}
+	spacing := 8
+	return chart.BarChart{
+		Title:  title,
+		Width:  chartWidth,
+		Height: chartHeight,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 40},
+		},
+		BarSpacing: spacing,
+		// the bars fill the width of the chart
+		BarWidth: max((chartWidth-100)/len(bars)-spacing, 4),
+		Bars:     bars,
+	}
+}
+
+func pieChart(title string, labels []string, series ChartSeries) chart.PieChart {
+	values := make([]chart.Value, len(labels))
+	for i, label := range labels {
+		values[i] = chart.Value{Label: label, Value: series.Values[i]}
+	}
+	return chart.PieChart{
+		Title:  title,
+		Width:  chartHeight,
+		Height: chartHeight,
+		Values: values,
+	}
+}
+
+// chartDescription describes the chart for the model, so it can refer to the chart in the answer
+func chartDescription(chartType, title string, labels []string, series []ChartSeries) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("The %s chart", chartType))
+	if title != "" {
+		sb.WriteString(fmt.Sprintf(" %q", title))
+	}
+	sb.WriteString(fmt.Sprintf(" with %d labels from %q to %q is sent to the user as an image.", len(labels), labels[0], labels[len(labels)-1]))
+	for _, s := range series {
+		minValue, maxValue := s.Values[0], s.Values[0]
+		for _, value := range s.Values {
+			minValue = min(minValue, value)
+			maxValue = max(maxValue, value)
+		}
+		name := s.Name
+		if name == "" {
+			name = "values"
+		}
+		sb.WriteString(fmt.Sprintf(" Series %q: min %g, max %g.", name, minValue, maxValue))
+	}
+	sb.WriteString(" Don't repeat the data, refer to the chart.")
+	return sb.String()
+}
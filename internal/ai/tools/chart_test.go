@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTools_Make_chart(t *testing.T) {
+	labels := []string{"Jan", "Feb", "Mar"}
+	series := []ChartSeries{
+		{Name: "2025", Values: []float64{10, 20, 15}},
+		{Name: "2026", Values: []float64{12, 18, 25}},
+	}
+
+	for _, chartType := range []string{ChartLine, ChartBar, ChartPie} {
+		t.Run(chartType, func(t *testing.T) {
+			chartSeries := series
+			if chartType != ChartLine {
+				chartSeries = series[:1]
+			}
+			description, data, err := Tools{}.Make_chart(chartType, "Sales", labels, chartSeries)
+			require.NoError(t, err)
+			_, err = png.Decode(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.Contains(t, description, `"Sales"`)
+			assert.Contains(t, description, `Series "2025": min 10, max 20.`)
+		})
+	}
+
+	t.Run("bars fill the chart", func(t *testing.T) {
+		many := make([]string, chartMaxLabels)
+		values := make([]float64, chartMaxLabels)
+		for i := range many {
+			many[i] = strings.Repeat("x", i%5+1)
+			values[i] = float64(i)
+		}
+		_, data, err := Tools{}.Make_chart(ChartBar, "", many, []ChartSeries{{Values: values}})
+		require.NoError(t, err)
+		assert.NotEmpty(t, data)
+	})
+}
+
+func TestValidateChart(t *testing.T) {
+	labels := []string{"a", "b"}
+	one := []ChartSeries{{Name: "s", Values: []float64{1, 2}}}
+	tests := []struct {
+		name      string
+		chartType string
+		title     string
+		labels    []string
+		series    []ChartSeries
+		err       string
+	}{
+		{"unknown type", "radar", "", labels, one, "unknown chart type"},
+		{"long title", ChartBar, strings.Repeat("t", chartMaxTitleLength+1), labels, one, "title is longer"},
+		{"no labels", ChartBar, "", nil, one, "from 1 to 50 labels"},
+		{"too many labels", ChartBar, "", make([]string, chartMaxLabels+1), one, "from 1 to 50 labels"},
+		{"long label", ChartBar, "", []string{strings.Repeat("l", chartMaxLabelLength+1), "b"}, one, "is longer than"},
+		{"line of one point", ChartLine, "", []string{"a"}, []ChartSeries{{Values: []float64{1}}}, "at least 2 labels"},
+		{"no series", ChartLine, "", labels, nil, "from 1 to 5 series"},
+		{"too many series", ChartLine, "", labels, make([]ChartSeries, chartMaxSeries+1), "from 1 to 5 series"},
+		{"several bar series", ChartBar, "", labels, append(one, one...), "takes one series"},
+		{"missing values", ChartLine, "", labels, []ChartSeries{{Name: "s", Values: []float64{1}}}, "has 1 values for 2 labels"},
+		{"infinite value", ChartLine, "", labels, []ChartSeries{{Values: []float64{1, math.Inf(1)}}}, "not a finite value"},
+		{"negative pie value", ChartPie, "", labels, []ChartSeries{{Values: []float64{1, -1}}}, "can't be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateChart(tt.chartType, tt.title, tt.labels, tt.series)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+	assert.NoError(t, validateChart(ChartLine, "", labels, one))
+}
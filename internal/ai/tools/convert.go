@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const convertRatesTTL = time.Hour
+
+const (
+	ConvertKindCurrency    = "currency"
+	ConvertKindLength      = "length"
+	ConvertKindWeight      = "weight"
+	ConvertKindTemperature = "temperature"
+)
+
+// meters in the unit
+var lengthUnits = map[string]float64{
+	"mm": 0.001, "millimeter": 0.001, "millimeters": 0.001,
+	"cm": 0.01, "centimeter": 0.01, "centimeters": 0.01,
+	"m": 1, "meter": 1, "meters": 1, "metre": 1, "metres": 1,
+	"km": 1000, "kilometer": 1000, "kilometers": 1000,
+	"in": 0.0254, "inch": 0.0254, "inches": 0.0254,
+	"ft": 0.3048, "foot": 0.3048, "feet": 0.3048,
+	"yd": 0.9144, "yard": 0.9144, "yards": 0.9144,
+	"mi": 1609.344, "mile": 1609.344, "miles": 1609.344,
+	"nmi": 1852, "nautical mile": 1852, "nautical miles": 1852,
+}
+
+// kilograms in the unit
+var weightUnits = map[string]float64{
+	"mg": 0.000001, "milligram": 0.000001, "milligrams": 0.000001,
+	"g": 0.001, "gram": 0.001, "grams": 0.001,
+	"kg": 1, "kilogram": 1, "kilograms": 1,
+	"t": 1000, "tonne": 1000, "tonnes": 1000, "ton": 1000, "tons": 1000,
+	"oz": 0.028349523125, "ounce": 0.028349523125, "ounces": 0.028349523125,
+	"lb": 0.45359237, "lbs": 0.45359237, "pound": 0.45359237, "pounds": 0.45359237,
+	"st": 6.35029318, "stone": 6.35029318,
+}
+
+var temperatureUnits = map[string]string{
+	"c": "c", "°c": "c", "celsius": "c",
+	"f": "f", "°f": "f", "fahrenheit": "f",
+	"k": "k", "kelvin": "k",
+}
+
+// ratesCache keeps rates of the endpoint for convertRatesTTL
+type ratesCache struct {
+	mu      sync.Mutex
+	url     string
+	date    string
+	rates   map[string]float64
+	updated time.Time
+}
+
+var convertRates = &ratesCache{}
+
+// Convert converts the amount between currencies (live rates from ratesURL) or units of
+// length, weight and temperature
+func (t Tools) Convert(amount float64, from, to, kind, ratesURL string) (string, error) {
+	from = strings.ToLower(strings.TrimSpace(from))
+	to = strings.ToLower(strings.TrimSpace(to))
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case ConvertKindCurrency:
+		date, rates, err := convertRates.get(t.httpClient, ratesURL)
+		if err != nil {
+			return "", fmt.Errorf("failed to get currency rates: %w", err)
+		}
+		result, err := convertCurrency(amount, from, to, rates)
+		if err != nil {
+			return "", err
+		}
+		text := fmt.Sprintf("%s %s = %s %s", formatConvertAmount(amount), strings.ToUpper(from), formatConvertNumber(result), strings.ToUpper(to))
+		if date != "" {
+			text += fmt.Sprintf(" (rates of %s)", date)
+		}
+		return text, nil
+	case ConvertKindLength:
+		return convertByFactor(amount, from, to, lengthUnits, ConvertKindLength)
+	case ConvertKindWeight:
+		return convertByFactor(amount, from, to, weightUnits, ConvertKindWeight)
+	case ConvertKindTemperature:
+		result, err := convertTemperature(amount, from, to)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s = %s %s", formatConvertAmount(amount), from, formatConvertNumber(result), to), nil
+	}
+	return "", fmt.Errorf("unknown kind %q, use currency, length, weight or temperature", kind)
+}
+
+// convertCurrency converts by rates of one unit of the base currency in the currencies
+func convertCurrency(amount float64, from, to string, rates map[string]float64) (float64, error) {
+	fromRate, ok := rates[from]
+	if !ok || fromRate <= 0 {
+		return 0, fmt.Errorf("unknown currency %q, use ISO codes like USD or EUR", strings.ToUpper(from))
+	}
+	toRate, ok := rates[to]
+	if !ok || toRate <= 0 {
+		return 0, fmt.Errorf("unknown currency %q, use ISO codes like USD or EUR", strings.ToUpper(to))
+	}
+	return amount / fromRate * toRate, nil
+}
+
+func convertByFactor(amount float64, from, to string, units map[string]float64, kind string) (string, error) {
+	fromFactor, ok := units[from]
+	if !ok {
+		return "", fmt.Errorf("unknown %s unit %q", kind, from)
+	}
+	toFactor, ok := units[to]
+	if !ok {
+		return "", fmt.Errorf("unknown %s unit %q", kind, to)
+	}
+	result := amount * fromFactor / toFactor
+	return fmt.Sprintf("%s %s = %s %s", formatConvertAmount(amount), from, formatConvertNumber(result), to), nil
+}
+
+func convertTemperature(amount float64, from, to string) (float64, error) {
+	fromUnit, ok := temperatureUnits[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown temperature unit %q, use C, F or K", from)
+	}
+	toUnit, ok := temperatureUnits[to]
+	if !ok {
+		return 0, fmt.Errorf("unknown temperature unit %q, use C, F or K", to)
+	}
+	celsius := amount
+	switch fromUnit {
+	case "f":
+		celsius = (amount - 32) * 5 / 9
+	case "k":
+		celsius = amount - 273.15
+	}
+	if celsius < -273.15 {
+		return 0, fmt.Errorf("%s %s is below absolute zero", formatConvertAmount(amount), from)
+	}
+	switch toUnit {
+	case "f":
+		return celsius*9/5 + 32, nil
+	case "k":
+		return celsius + 273.15, nil
+	}
+	return celsius, nil
+}
+
+// formatConvertAmount keeps the given amount as is, without negative zero
+func formatConvertAmount(value float64) string {
+	if value == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(value, 'g', 15, 64)
+}
+
+// formatConvertNumber rounds the result to 6 significant digits, rates aren't more precise
+func formatConvertNumber(value float64) string {
+	if value == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%.6g", value)
+}
+
+// get returns the date and the rates of the endpoint, they are requested again
+// after convertRatesTTL or when the endpoint is changed
+func (c *ratesCache) get(client *http.Client, url string) (string, map[string]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.url == url && c.rates != nil && time.Since(c.updated) < convertRatesTTL {
+		return c.date, c.rates, nil
+	}
+
+	date, rates, err := fetchRates(client, url)
+	if err != nil {
+		return "", nil, err
+	}
+	c.url, c.date, c.rates, c.updated = url, date, rates, time.Now()
+	return date, rates, nil
+}
+
+// fetchRates parses rates of one USD: {"date": ..., "usd": {"eur": 0.9}} of currency-api
+// or of the base currency: {"base": "EUR", "date": ..., "rates": {"USD": 1.1}} of exchangerate APIs,
+// the base is USD when the response doesn't name it
+func fetchRates(client *http.Client, url string) (string, map[string]float64, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var data struct {
+		Base  string             `json:"base"`
+		Date  string             `json:"date"`
+		USD   map[string]float64 `json:"usd"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", nil, fmt.Errorf("failed to parse rates: %w", err)
+	}
+	source, base := data.USD, "usd"
+	if len(source) == 0 {
+		source = data.Rates
+		if data.Base != "" {
+			base = strings.ToLower(data.Base)
+		}
+	}
+	if len(source) == 0 {
+		return "", nil, fmt.Errorf("no rates in the response")
+	}
+	rates := make(map[string]float64, len(source)+1)
+	for code, rate := range source {
+		rates[strings.ToLower(code)] = rate
+	}
+	rates[base] = 1
+	return data.Date, rates, nil
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert_Units(t *testing.T) {
+	tests := []struct {
+		amount         float64
+		from, to, kind string
+		want           string
+	}{
+		{10, "km", "mi", ConvertKindLength, "10 km = 6.21371 mi"},
+		{6, "FT", "cm", ConvertKindLength, "6 ft = 182.88 cm"},
+		{1, "lb", "g", ConvertKindWeight, "1 lb = 453.592 g"},
+		{100, "C", "F", ConvertKindTemperature, "100 c = 212 f"},
+		{-40, "fahrenheit", "celsius", ConvertKindTemperature, "-40 fahrenheit = -40 celsius"},
+		{0, "K", "C", ConvertKindTemperature, "0 k = -273.15 c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			result, err := Tools{}.Convert(tt.amount, tt.from, tt.to, tt.kind, "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestConvert_UnitErrors(t *testing.T) {
+	_, err := Tools{}.Convert(1, "km", "kg", ConvertKindLength, "")
+	assert.EqualError(t, err, `unknown length unit "kg"`)
+
+	_, err = Tools{}.Convert(-300, "C", "K", ConvertKindTemperature, "")
+	assert.EqualError(t, err, "-300 c is below absolute zero")
+
+	_, err = Tools{}.Convert(1, "km", "mi", "volume", "")
+	assert.ErrorContains(t, err, `unknown kind "volume"`)
+}
+
+func TestConvert_Currency(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, `{"date":"2026-10-15","usd":{"eur":0.9,"rub":90}}`)
+	}))
+	defer server.Close()
+	convertRates = &ratesCache{}
+	tools := Tools{httpClient: server.Client()}
+
+	result, err := tools.Convert(50, "usd", "EUR", ConvertKindCurrency, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "50 USD = 45 EUR (rates of 2026-10-15)", result)
+
+	result, err = tools.Convert(9, "EUR", "RUB", ConvertKindCurrency, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "9 EUR = 900 RUB (rates of 2026-10-15)", result)
+
+	_, err = tools.Convert(1, "USD", "XXX", ConvertKindCurrency, server.URL)
+	assert.EqualError(t, err, `unknown currency "XXX", use ISO codes like USD or EUR`)
+
+	assert.Equal(t, int32(1), requests.Load(), "rates are cached")
+}
+
+func TestFetchRates_RatesFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"date":"2026-10-15","rates":{"EUR":0.9}}`)
+	}))
+	defer server.Close()
+
+	date, rates, err := fetchRates(server.Client(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10-15", date)
+	assert.Equal(t, map[string]float64{"eur": 0.9, "usd": 1}, rates)
+}
+
+func TestConvert_NonUSDBase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"base":"EUR","date":"2026-10-15","rates":{"USD":1.25,"GBP":0.8}}`)
+	}))
+	defer server.Close()
+	convertRates = &ratesCache{}
+	tools := Tools{httpClient: server.Client()}
+
+	result, err := tools.Convert(10, "EUR", "USD", ConvertKindCurrency, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "10 EUR = 12.5 USD (rates of 2026-10-15)", result)
+
+	result, err = tools.Convert(25, "USD", "GBP", ConvertKindCurrency, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "25 USD = 16 GBP (rates of 2026-10-15)", result)
+}
@@ -2,55 +2,50 @@ package tools
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math/rand"
+	"mime/multipart"
 	"net/http"
 	"slices"
+	"strings"
 )
 
 const (
 	modelsAPIURL = "https://api.imagerouter.io/v1/models"
 	generateURL  = "https://api.imagerouter.io/v1/openai/images/generations"
+	editURL      = "https://api.imagerouter.io/v1/openai/images/edits"
 )
 
-func (t Tools) Generate_image(prompt, model, apiKey string) (string, string, string, error) {
-	var err error
-	if model == "" {
-		model, err = t.getRandomFreeModel()
-		if err != nil {
-			return "", "", "", t.formatError(err.Error())
-		}
+// Generate_image generates an image from the prompt. If image (data URL) is passed
+// and the model supports editing, the image is modified instead of generating a new one
+func (t Tools) Generate_image(prompt, model, apiKey, image string) (string, string, string, error) {
+	models, err := t.getModels()
+	if err != nil && model == "" {
+		return "", "", "", t.formatError(err.Error())
 	}
-	url := generateURL
-
+	edit := image != ""
 	if model == "" {
-		model = "test/test"
+		model = t.getRandomFreeModel(models, edit)
 	}
-
-	requestBody := map[string]any{
-		"prompt":          prompt,
-		"model":           model,
-		"quality":         "auto",
-		"response_format": "b64_json",
+	if edit && !models[model].SupportedParams.Edit {
+		t.logger.WithField("model", model).Debug("Model doesn't support image editing, generate new image")
+		edit = false
 	}
 
-	t.logger.WithField("request", requestBody).Debug("Image generator request")
-
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", "", "", t.formatError(err.Error())
+	var req *http.Request
+	if edit {
+		req, err = t.newEditRequest(prompt, model, image)
+	} else {
+		req, err = t.newGenerateRequest(prompt, model)
 	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return "", "", "", t.formatError(err.Error())
 	}
-
 	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := t.httpClient.Do(req)
 	if err != nil {
@@ -74,12 +69,86 @@ func (t Tools) Generate_image(prompt, model, apiKey string) (string, string, str
 	}
 
 	if len(response.Data) > 0 {
+		if edit {
+			return "Image edited and sent in chat", response.Data[0].B64JSON, model, nil
+		}
 		return "Image generated and sent in chat", response.Data[0].B64JSON, model, nil
 	}
 
 	return "", "", "", t.formatError("")
 }
 
+func (t Tools) newGenerateRequest(prompt, model string) (*http.Request, error) {
+	requestBody := map[string]any{
+		"prompt":          prompt,
+		"model":           model,
+		"quality":         "auto",
+		"response_format": "b64_json",
+	}
+
+	t.logger.WithField("request", requestBody).Debug("Image generator request")
+
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", generateURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func (t Tools) newEditRequest(prompt, model, image string) (*http.Request, error) {
+	mimeType, data, found := strings.Cut(strings.TrimPrefix(image, "data:"), ";base64,")
+	if !found {
+		return nil, errors.New("image must be a base64 data URL")
+	}
+	imageBytes, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	t.logger.WithFields(map[string]any{
+		"prompt": prompt,
+		"model":  model,
+		"mime":   mimeType,
+	}).Debug("Image editor request")
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range map[string]string{
+		"prompt":          prompt,
+		"model":           model,
+		"quality":         "auto",
+		"response_format": "b64_json",
+	} {
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, err
+		}
+	}
+	extension := strings.TrimPrefix(mimeType, "image/")
+	part, err := writer.CreateFormFile("image[]", "image."+extension)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(imageBytes); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", editURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req, nil
+}
+
 type ModelInfo struct {
 	Providers []struct {
 		Pricing struct {
@@ -87,7 +156,10 @@ type ModelInfo struct {
 			Value float64 `json:"value"`
 		} `json:"pricing"`
 	} `json:"providers"`
-	Output []string `json:"output"`
+	Output          []string `json:"output"`
+	SupportedParams struct {
+		Edit bool `json:"edit"`
+	} `json:"supported_params"`
 }
 
 type ImageResponse struct {
@@ -101,7 +173,7 @@ type ImageResponse struct {
 	Cost    int `json:"cost"`
 }
 
-func (t Tools) getFreeModels() ([]string, error) {
+func (t Tools) getModels() (map[string]ModelInfo, error) {
 	resp, err := t.httpClient.Get(modelsAPIURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch models: %w", err)
@@ -116,33 +188,37 @@ func (t Tools) getFreeModels() ([]string, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&models); err != nil {
 		return nil, err
 	}
+	return models, nil
+}
 
+func getFreeModels(models map[string]ModelInfo, edit bool) []string {
 	var freeModels []string
 	for name, model := range models {
 		if len(model.Providers) > 0 &&
 			model.Providers[0].Pricing.Type == "fixed" &&
 			model.Providers[0].Pricing.Value == 0 &&
 			name != "test/test" &&
-			slices.Contains(model.Output, "image") {
+			slices.Contains(model.Output, "image") &&
+			(!edit || model.SupportedParams.Edit) {
 			freeModels = append(freeModels, name)
 		}
 	}
-
-	return freeModels, nil
+	return freeModels
 }
 
-func (t Tools) getRandomFreeModel() (string, error) {
-	freeModels, err := t.getFreeModels()
-	if err != nil {
-		return "", fmt.Errorf("failed to get free models: %w", err)
+// getRandomFreeModel prefers models that support editing if edit is requested
+func (t Tools) getRandomFreeModel(models map[string]ModelInfo, edit bool) string {
+	freeModels := getFreeModels(models, edit)
+	if len(freeModels) == 0 && edit {
+		freeModels = getFreeModels(models, false)
 	}
 
 	if len(freeModels) == 0 {
 		fmt.Println("0 free models from image router")
-		return "test/test", nil
+		return "test/test"
 	}
 
-	return freeModels[rand.Intn(len(freeModels))], nil
+	return freeModels[rand.Intn(len(freeModels))]
 }
 
 func (t Tools) formatError(message string) error {
@@ -0,0 +1,23 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxFactLength is the max length of one remembered fact in characters
+const MaxFactLength = 300
+
+// Remember validates the fact about the user, the fact is saved by the caller that knows the user
+func (t Tools) Remember(fact string) (string, error) {
+	fact = strings.TrimSpace(fact)
+	if fact == "" {
+		return "", errors.New("fact is empty")
+	}
+	if length := utf8.RuneCountInString(fact); length > MaxFactLength {
+		return "", fmt.Errorf("fact is too long: %d characters, max %d, write it shorter", length, MaxFactLength)
+	}
+	return fmt.Sprintf("Remembered: %s. The user can see and clear remembered facts with /memory", fact), nil
+}
@@ -0,0 +1,21 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTools_Remember(t *testing.T) {
+	result, err := Tools{}.Remember("  The user is a vegetarian ")
+	require.NoError(t, err)
+	assert.Equal(t, "Remembered: The user is a vegetarian. The user can see and clear remembered facts with /memory", result)
+
+	_, err = Tools{}.Remember(" ")
+	assert.EqualError(t, err, "fact is empty")
+
+	_, err = Tools{}.Remember(strings.Repeat("я", MaxFactLength+1))
+	assert.EqualError(t, err, "fact is too long: 301 characters, max 300, write it shorter")
+}
@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	reminderMinDelay = time.Minute
+	reminderMaxDelay = 365 * 24 * time.Hour
+)
+
+var (
+	reminderDurationRegex     = regexp.MustCompile(`^(?:\d+[smhdw])+$`)
+	reminderDurationPartRegex = regexp.MustCompile(`(\d+)([smhdw])`)
+	reminderTimeLayouts       = []string{
+		"2006-01-02 15:04",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04:05",
+	}
+	reminderUnits = map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+)
+
+// Set_reminder validates the reminder and returns the time to send it,
+// the reminder itself is saved by the caller that knows the chat and the user
+func (t Tools) Set_reminder(when, message string, now time.Time) (string, time.Time, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", time.Time{}, errors.New("reminder message is empty")
+	}
+	remindAt, err := ParseReminderTime(when, now)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return fmt.Sprintf(
+		"Reminder is set for %s (in %s). Tell the user that you will remind them",
+		remindAt.Format("2006-01-02 15:04 MST"),
+		remindAt.Sub(now).Round(time.Minute),
+	), remindAt, nil
+}
+
+// ParseReminderTime parses a relative duration (30m, 2h, 1d12h, 1w)
+// or an absolute time (RFC3339 or local "2006-01-02 15:04")
+func ParseReminderTime(when string, now time.Time) (time.Time, error) {
+	when = strings.TrimSpace(when)
+	if when == "" {
+		return time.Time{}, errors.New("reminder time is empty")
+	}
+
+	var remindAt time.Time
+	if duration := strings.ToLower(strings.ReplaceAll(when, " ", "")); reminderDurationRegex.MatchString(duration) {
+		var delay time.Duration
+		for _, part := range reminderDurationPartRegex.FindAllStringSubmatch(duration, -1) {
+			value, err := strconv.Atoi(part[1])
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid duration %q: %w", when, err)
+			}
+			delay += time.Duration(value) * reminderUnits[part[2]]
+		}
+		remindAt = now.Add(delay)
+	} else {
+		parsed, err := parseReminderTimestamp(when, now.Location())
+		if err != nil {
+			return time.Time{}, err
+		}
+		remindAt = parsed
+	}
+
+	delay := remindAt.Sub(now)
+	if delay < reminderMinDelay {
+		return time.Time{}, fmt.Errorf("reminder time %s is in the past or less than a minute from now", remindAt.Format(time.RFC3339))
+	}
+	if delay > reminderMaxDelay {
+		return time.Time{}, fmt.Errorf("reminder time %s is more than a year from now", remindAt.Format(time.RFC3339))
+	}
+	return remindAt, nil
+}
+
+func parseReminderTimestamp(when string, location *time.Location) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, strings.ToUpper(when)); err == nil {
+		return parsed, nil
+	}
+	for _, layout := range reminderTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, strings.ToUpper(when), location); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid reminder time %q, use a duration like 2h or 1d, or a time like 2006-01-02 15:04", when)
+}
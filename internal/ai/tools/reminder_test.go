@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReminderTime(t *testing.T) {
+	location := time.FixedZone("UTC+3", 3*60*60)
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, location)
+
+	tests := []struct {
+		when string
+		want time.Time
+	}{
+		{"30m", now.Add(30 * time.Minute)},
+		{"2h", now.Add(2 * time.Hour)},
+		{"1d 12h", now.Add(36 * time.Hour)},
+		{"1W", now.Add(7 * 24 * time.Hour)},
+		{"2026-10-16 09:00", time.Date(2026, 10, 16, 9, 0, 0, 0, location)},
+		{"2026-10-16T09:00:00Z", time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.when, func(t *testing.T) {
+			got, err := ParseReminderTime(tt.when, now)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+
+	for _, when := range []string{"", "tomorrow", "10s", "2026-10-14 09:00", "2y", "400d"} {
+		_, err := ParseReminderTime(when, now)
+		assert.Error(t, err, when)
+	}
+}
+
+func TestTools_Set_reminder(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	text, remindAt, err := Tools{}.Set_reminder("1h", "call mom", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), remindAt)
+	assert.Contains(t, text, "2026-10-15 13:00")
+
+	_, _, err = Tools{}.Set_reminder("1h", " ", now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,26 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+
+	fetch "github.com/muratoffalex/gachigazer/internal/fetcher"
+)
+
+func (t Tools) Fetch_rss(url string, limit int) (string, error) {
+	req, err := fetch.NewRequestPayload(url, nil, map[string]any{fetch.FeedOptionLimit: limit})
+	if err != nil {
+		return "Error", err
+	}
+	content, err := t.fetcher.FetchWith(fetch.FetcherNameFeed, req)
+	if errors.Is(err, fetch.ErrNotHandle) {
+		return "Error: not an RSS or Atom feed", fmt.Errorf("%s is not a feed", url)
+	}
+	if err != nil {
+		return "Error", err
+	}
+	if content.IsError {
+		return "Error", errors.New(content.GetText())
+	}
+	return content.GetText(), nil
+}
@@ -15,6 +15,7 @@ func (t Tools) Fetch_tg_posts(
 	channelName string,
 	duration string,
 	limit int,
+	keyword string,
 ) (string, error) {
 	td := service.GetTD()
 	var err error
@@ -42,6 +43,37 @@ func (t Tools) Fetch_tg_posts(
 	if len(posts) == 0 {
 		return "Not found", nil
 	}
+	if keyword != "" {
+		posts = filterPosts(posts, keyword)
+		if len(posts) == 0 {
+			return fmt.Sprintf("No posts matched the keyword %q", keyword), nil
+		}
+	}
 
 	return fmt.Sprintf("Found results: %d\n%s", len(posts), strings.Join(posts, "\n---\n")), nil
 }
+
+// filterPosts returns posts containing any of the comma-separated terms, case-insensitive
+func filterPosts(posts []string, keyword string) []string {
+	var terms []string
+	for term := range strings.SplitSeq(keyword, ",") {
+		if term = strings.ToLower(strings.TrimSpace(term)); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return posts
+	}
+
+	var result []string
+	for _, post := range posts {
+		text := strings.ToLower(post)
+		for _, term := range terms {
+			if strings.Contains(text, term) {
+				result = append(result, post)
+				break
+			}
+		}
+	}
+	return result
+}
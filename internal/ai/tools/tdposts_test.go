@@ -0,0 +1,21 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterPosts(t *testing.T) {
+	posts := []string{
+		"Bitcoin hits a new high",
+		"Weather in London",
+		"BTC ETF approved",
+		"Cats and dogs",
+	}
+
+	assert.Equal(t, []string{"Bitcoin hits a new high"}, filterPosts(posts, "BITCOIN"))
+	assert.Equal(t, []string{"Bitcoin hits a new high", "BTC ETF approved"}, filterPosts(posts, "bitcoin, btc"))
+	assert.Empty(t, filterPosts(posts, "ethereum"))
+	assert.Equal(t, posts, filterPosts(posts, " , "), "Empty terms don't filter")
+}
@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// max length in characters of a text part translated by one request,
+// longer texts are split on sentence boundaries
+const translateChunkSize = 3000
+
+// end of a sentence with the following spaces, or a line break
+var sentenceEndRegex = regexp.MustCompile(`[.!?…。！？]+["'»”)\]]*\s+|\n+`)
+
+// Translate translates the text with the translate func that calls the translation model.
+// Long texts are translated in parts and concatenated, empty or "auto" from means
+// the model detects the source language
+func (t Tools) Translate(text, from, to string, translate func(systemPrompt, text string) (string, error)) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("text is empty")
+	}
+	to = strings.TrimSpace(to)
+	if to == "" {
+		return "", errors.New("target language is empty")
+	}
+
+	prompt := translatePrompt(from, to)
+	var result strings.Builder
+	for _, chunk := range splitTranslateChunks(text, translateChunkSize) {
+		translated, err := translate(prompt, strings.TrimSpace(chunk))
+		if err != nil {
+			return "", fmt.Errorf("translate failed: %w", err)
+		}
+		// keep the spaces and line breaks between the parts
+		result.WriteString(strings.TrimSpace(translated))
+		result.WriteString(chunk[len(strings.TrimRightFunc(chunk, unicode.IsSpace)):])
+	}
+	return strings.TrimSpace(result.String()), nil
+}
+
+func translatePrompt(from, to string) string {
+	source := "Detect the source language"
+	if from = strings.TrimSpace(from); from != "" && !strings.EqualFold(from, "auto") {
+		source = "The source language is " + from
+	}
+	return fmt.Sprintf(
+		"You are a professional translator. %s. Translate the user's text to %s, keep the meaning, tone and formatting. "+
+			"The text may be a part of a longer text, translate it as is. "+
+			"Return only the translated text without notes, explanations or quotes.",
+		source, to,
+	)
+}
+
+// splitTranslateChunks splits the text into parts of up to size characters on sentence
+// boundaries, a sentence longer than size is split by words
+func splitTranslateChunks(text string, size int) []string {
+	var chunks []string
+	var current strings.Builder
+	currentLength := 0
+	add := func(piece string) {
+		length := utf8.RuneCountInString(piece)
+		if currentLength > 0 && currentLength+length > size {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentLength = 0
+		}
+		current.WriteString(piece)
+		currentLength += length
+	}
+
+	for _, sentence := range splitSentences(text) {
+		if utf8.RuneCountInString(sentence) <= size {
+			add(sentence)
+			continue
+		}
+		for _, word := range strings.SplitAfter(sentence, " ") {
+			for utf8.RuneCountInString(word) > size {
+				runes := []rune(word)
+				add(string(runes[:size]))
+				word = string(runes[size:])
+			}
+			add(word)
+		}
+	}
+	if currentLength > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// splitSentences splits the text after the end of each sentence, the parts keep
+// the following spaces, so they can be joined back into the same text
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for _, loc := range sentenceEndRegex.FindAllStringIndex(text, -1) {
+		sentences = append(sentences, text[start:loc[1]])
+		start = loc[1]
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
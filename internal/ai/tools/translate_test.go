@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTranslateChunks(t *testing.T) {
+	text := "First sentence. Second one! Third?\nFourth line"
+	assert.Equal(t, []string{text}, splitTranslateChunks(text, 100))
+	assert.Equal(t, []string{"First sentence. ", "Second one! Third?\n", "Fourth line"}, splitTranslateChunks(text, 20))
+	assert.Equal(t, text, strings.Join(splitTranslateChunks(text, 5), ""), "Parts are joined back into the text")
+
+	chunks := splitTranslateChunks("a very long sentence without an end", 10)
+	assert.Equal(t, []string{"a very ", "long ", "sentence ", "without ", "an end"}, chunks)
+	assert.Equal(t, []string{"Привет", "мир"}, splitTranslateChunks("Приветмир", 6), "Words are split by characters")
+}
+
+func TestTools_Translate(t *testing.T) {
+	var prompts []string
+	upper := func(systemPrompt, text string) (string, error) {
+		prompts = append(prompts, systemPrompt)
+		return " " + strings.ToUpper(text) + "\n", nil
+	}
+
+	text := strings.Repeat("Hello world. ", translateChunkSize/10) + "\n\nBye."
+	result, err := Tools{}.Translate(text, "", "German", upper)
+	require.NoError(t, err)
+	assert.Equal(t, strings.ToUpper(strings.TrimSpace(text)), result)
+	require.Greater(t, len(prompts), 1, "Long text is translated in parts")
+	assert.Contains(t, prompts[0], "Detect the source language")
+	assert.Contains(t, prompts[0], "to German")
+
+	prompts = nil
+	_, err = Tools{}.Translate("Hola", "Spanish", "English", upper)
+	require.NoError(t, err)
+	assert.Contains(t, prompts[0], "The source language is Spanish")
+
+	_, err = Tools{}.Translate("Hola", "auto", "English", func(string, string) (string, error) {
+		return "", errors.New("rate limit")
+	})
+	assert.EqualError(t, err, "translate failed: rate limit")
+
+	_, err = Tools{}.Translate(" ", "", "English", upper)
+	assert.EqualError(t, err, "text is empty")
+	_, err = Tools{}.Translate("Hola", "", "", upper)
+	assert.EqualError(t, err, "target language is empty")
+}
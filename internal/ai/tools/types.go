@@ -22,6 +22,14 @@ const (
 	ToolFetchTgPosts        = "fetch_tg_posts"
 	ToolFetchTgPostComments = "fetch_tg_post_comments"
 	ToolFetchYtComments     = "fetch_yt_comments"
+	ToolSetReminder         = "set_reminder"
+	ToolFetchRSS            = "fetch_rss"
+	ToolGetUserInfo         = "get_user_info"
+	ToolConvert             = "convert"
+	ToolTranslate           = "translate"
+	ToolMakeChart           = "make_chart"
+	ToolRemember            = "remember"
+	ToolCalc                = "calc"
 )
 
 func NewTools(
@@ -149,11 +157,12 @@ var AllTools = map[string]ai.Tool{
 		Type: "function",
 		Function: ai.ToolFunction{
 			Name:        ToolGenerateImage,
-			Description: `Generate image with prompt`,
+			Description: `Generate image with prompt, or modify the image attached by the user`,
 			Parameters: ai.Parameters{
 				Type: "object",
 				Properties: map[string]ai.Property{
 					"prompt": {Type: "string", Description: "Detailed prompt in English"},
+					"image":  {Type: "boolean", Description: "Set true to edit the image attached by the user instead of generating a new one"},
 				},
 				Required: []string{"prompt"},
 			},
@@ -170,12 +179,142 @@ var AllTools = map[string]ai.Tool{
 					"keywords":    {Type: "string", Description: "Search keywords"},
 					"max_results": {Type: "integer", Description: "Limit images in result. Min 1, max 5"},
 					"time_limit":  {Type: "string", Enum: []string{"", "d", "w", "m", "y"}, Description: "Time range for search results: 'd' (last 24h), 'w' (last week), 'm' (last month), 'y' (last year). Default: empty. Leave empty for all time."},
+					"analyze":     {Type: "boolean", Description: "Set true to get a description of each found image, e.g. to compare them or pick the best one"},
 				},
 				Required: []string{"keywords", "max_results"},
 			},
 		},
 	},
 	ToolFetchYtComments: ToolFetchYtCommentsSpec,
+	ToolFetchRSS: {
+		Type: "function",
+		Function: ai.ToolFunction{
+			Name:        ToolFetchRSS,
+			Description: `Fetch the latest items of an RSS or Atom feed: title, date, link and short summary. Use to get news from a feed URL`,
+			Parameters: ai.Parameters{
+				Type: "object",
+				Properties: map[string]ai.Property{
+					"url":   {Type: "string", Description: "Feed URL"},
+					"limit": {Type: "integer", Description: "Number of latest items (default: 10, max: 50)"},
+				},
+				Required: []string{"url"},
+			},
+		},
+	},
+	ToolGetUserInfo: {
+		Type: "function",
+		Function: ai.ToolFunction{
+			Name:        ToolGetUserInfo,
+			Description: `Get info about the user who sent the current message: first name, public ID and the description the user wrote about themselves. Use to address the user correctly or to personalize the answer`,
+			Parameters: ai.Parameters{
+				Type:       "object",
+				Properties: map[string]ai.Property{},
+			},
+		},
+	},
+	ToolTranslate: {
+		Type: "function",
+		Function: ai.ToolFunction{
+			Name:        ToolTranslate,
+			Description: `Translate text with a dedicated translation model, returns only the translated text. Use when the user asks to translate a text, especially a long one`,
+			Parameters: ai.Parameters{
+				Type: "object",
+				Properties: map[string]ai.Property{
+					"text": {Type: "string", Description: "Text to translate, exactly as is"},
+					"from": {Type: "string", Description: "Source language in English (e.g. `Japanese`), leave empty or `auto` to detect"},
+					"to":   {Type: "string", Description: "Target language in English (e.g. `Russian`)"},
+				},
+				Required: []string{"text", "to"},
+			},
+		},
+	},
+	ToolMakeChart: {
+		Type: "function",
+		Function: ai.ToolFunction{
+			Name:        ToolMakeChart,
+			Description: `Render a chart from data and send it to the user as an image. Use when the user asks to visualize or compare numbers, trends or shares`,
+			Parameters: ai.Parameters{
+				Type: "object",
+				Properties: map[string]ai.Property{
+					"type":   {Type: "string", Enum: []string{ChartLine, ChartBar, ChartPie}, Description: "Chart type: line for trends (several series allowed), bar to compare values, pie for shares of a whole"},
+					"title":  {Type: "string", Description: "Short chart title in the user's language"},
+					"labels": {Type: "array", Items: &ai.Property{Type: "string"}, Description: "X axis labels or pie slice names, max 50"},
+					"series": {
+						Type: "array",
+						Items: &ai.Property{
+							Type: "object",
+							Properties: map[string]ai.Property{
+								"name":   {Type: "string", Description: "Series name shown in the legend"},
+								"values": {Type: "array", Items: &ai.Property{Type: "number"}, Description: "One value per label"},
+							},
+						},
+						Description: "Data series, max 5 for line chart and exactly 1 for bar and pie charts",
+					},
+				},
+				Required: []string{"type", "labels", "series"},
+			},
+		},
+	},
+	ToolSetReminder: {
+		Type: "function",
+		Function: ai.ToolFunction{
+			Name:        ToolSetReminder,
+			Description: `Schedule a reminder, the message will be sent to this chat as a reply to the user's message at the given time. Use when the user asks to remind or follow up later`,
+			Parameters: ai.Parameters{
+				Type: "object",
+				Properties: map[string]ai.Property{
+					"when":    {Type: "string", Description: "Relative duration (e.g. `30m`, `2h`, `1d`, `1d12h`, `1w`) or absolute local time `YYYY-MM-DD HH:MM` based on the current date and time"},
+					"message": {Type: "string", Description: "Reminder text in the user's language, written as a message to the user"},
+				},
+				Required: []string{"when", "message"},
+			},
+		},
+	},
+	ToolConvert: {
+		Type: "function",
+		Function: ai.ToolFunction{
+			Name:        ToolConvert,
+			Description: `Convert an amount between currencies using live exchange rates or between units of length, weight and temperature. Use for any conversion instead of relying on memorized rates`,
+			Parameters: ai.Parameters{
+				Type: "object",
+				Properties: map[string]ai.Property{
+					"amount": {Type: "number", Description: "Amount to convert"},
+					"from":   {Type: "string", Description: "Source currency ISO code (`USD`, `EUR`) or unit (`km`, `mi`, `ft`, `kg`, `lb`, `oz`, `C`, `F`, `K`)"},
+					"to":     {Type: "string", Description: "Target currency ISO code or unit of the same kind"},
+					"kind":   {Type: "string", Enum: []string{"currency", "length", "weight", "temperature"}, Description: "What is converted"},
+				},
+				Required: []string{"amount", "from", "to", "kind"},
+			},
+		},
+	},
+	ToolCalc: {
+		Type: "function",
+		Function: ai.ToolFunction{
+			Name:        ToolCalc,
+			Description: `Evaluate a math expression exactly, returns the result and the normalized expression. Use for any arithmetic instead of calculating yourself. Supports + - * / ^, parentheses, pi, e, functions sqrt, cbrt, abs, sin, cos, tan, asin, acos, atan (radians), ln, log (base 10), log2, exp, floor, ceil, round, pow(x, y), min(x, y), max(x, y) and percentages: 10% is 0.1, 200 + 10% is 220, 15% of 80 is 12`,
+			Parameters: ai.Parameters{
+				Type: "object",
+				Properties: map[string]ai.Property{
+					"expression": {Type: "string", Description: "Math expression, e.g. `sqrt(2) * (3 + 4)^2` or `1500 - 12%`"},
+				},
+				Required: []string{"expression"},
+			},
+		},
+	},
+	ToolRemember: {
+		Type: "function",
+		Function: ai.ToolFunction{
+			Name:        ToolRemember,
+			Description: `Remember a stable fact about the user who sent the current message for future conversations (e.g. diet, profession, preferred language or units). Use when the user asks to remember something or shares a lasting personal preference, never for temporary details or sensitive data`,
+			Parameters: ai.Parameters{
+				Type: "object",
+				Properties: map[string]ai.Property{
+					"fact": {Type: "string", Description: "Short fact in English written in the third person, e.g. `The user is a vegetarian`"},
+				},
+				Required: []string{"fact"},
+			},
+		},
+	},
 }
 
 var ToolFetchTgPostsSpec = ai.Tool{
@@ -189,6 +328,7 @@ var ToolFetchTgPostsSpec = ai.Tool{
 				"channel_name": {Type: "string", Description: "Channel username"},
 				"duration":     {Type: "string", Description: "Only use when time period is specified (e.g. 'posts from last 24h'). Must end with 'h'. Max: " + fmt.Sprint(TG_MAX_DURATION) + "h"},
 				"limit":        {Type: "integer", Description: "Use when post count is specified (e.g. '5 posts'). Max: 100"},
+				"keyword":      {Type: "string", Description: "Return only posts containing the term, case-insensitive. Several comma-separated terms match any of them (e.g. 'bitcoin, btc')"},
 			},
 			Required: []string{"channel_name"},
 		},
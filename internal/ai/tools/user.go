@@ -0,0 +1,23 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Get_user_info describes the user who asked, the caller resolves the user from the conversation.
+// The telegram user ID is never passed here, only the public ID
+func (t Tools) Get_user_info(firstName, publicID, about string) (string, error) {
+	if publicID == "" {
+		return "", errors.New("user not found")
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "First name: %s\nPublic ID: %s\n", firstName, publicID)
+	if about != "" {
+		fmt.Fprintf(&result, "About (written by the user): %s\n", about)
+	} else {
+		result.WriteString("About: not set, the user can set it with /setabout\n")
+	}
+	return strings.TrimSpace(result.String()), nil
+}
@@ -0,0 +1,21 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTools_Get_user_info(t *testing.T) {
+	result, err := Tools{}.Get_user_info("Alice", "a1b2", "Backend developer, likes cats")
+	require.NoError(t, err)
+	assert.Equal(t, "First name: Alice\nPublic ID: a1b2\nAbout (written by the user): Backend developer, likes cats", result)
+
+	result, err = Tools{}.Get_user_info("Bob", "c3d4", "")
+	require.NoError(t, err)
+	assert.Equal(t, "First name: Bob\nPublic ID: c3d4\nAbout: not set, the user can set it with /setabout", result)
+
+	_, err = Tools{}.Get_user_info("", "", "")
+	assert.EqualError(t, err, "user not found")
+}
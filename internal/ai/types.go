@@ -18,10 +18,13 @@ import (
 )
 
 type baseHTTPClient struct {
-	baseURL string
-	apiKey  string
-	client  *http.Client
-	logger  logger.Logger
+	baseURL  string
+	apiKey   string
+	headers  map[string]string
+	client   *http.Client
+	logger   logger.Logger
+	redactor *logger.Redactor
+	token    *cachedToken
 }
 
 func NewBaseHTTPClient(client *http.Client, baseURL, apiKey string, log logger.Logger) *baseHTTPClient {
@@ -29,22 +32,45 @@ func NewBaseHTTPClient(client *http.Client, baseURL, apiKey string, log logger.L
 		client:  client,
 		baseURL: baseURL,
 		apiKey:  apiKey,
+		headers: map[string]string{},
 		logger:  log,
 	}
 }
 
+// SetHeader sets a header sent with every request of this client,
+// unless the request already has its own value for it
+func (c *baseHTTPClient) SetHeader(key, value string) {
+	c.headers[http.CanonicalHeaderKey(key)] = value
+}
+
+func (c *baseHTTPClient) hasHeader(key string) bool {
+	_, ok := c.headers[http.CanonicalHeaderKey(key)]
+	return ok
+}
+
+// SetRedactor sets the redactor used to mask sensitive data in logged requests
+func (c *baseHTTPClient) SetRedactor(redactor *logger.Redactor) {
+	c.redactor = redactor
+}
+
+// SetTokenProvider makes the client authorize requests with tokens
+// from the provider instead of the static api key
+func (c *baseHTTPClient) SetTokenProvider(provider TokenProvider) {
+	c.token = newCachedToken(provider)
+}
+
 func (c *baseHTTPClient) logRequest(req *http.Request, body []byte) {
 	var bodyData any
 	if len(body) > 0 {
 		if err := json.Unmarshal(body, &bodyData); err == nil {
 			if m, ok := bodyData.(map[string]any); ok {
-				truncateLargeFields(m)
+				truncateLargeFields(m, c.redactor)
 			}
 		}
 	}
 
 	logData := map[string]any{
-		"url":    req.URL.String(),
+		"url":    c.redactor.Redact(req.URL.String()),
 		"method": req.Method,
 		"body":   bodyData,
 	}
@@ -56,23 +82,28 @@ func (c *baseHTTPClient) logRequest(req *http.Request, body []byte) {
 	c.logger.WithField("request", string(jsonData)).Debug("HTTP request")
 }
 
-func truncateLargeFields(data map[string]any) {
+func truncateLargeFields(data map[string]any, redactor *logger.Redactor) {
 	for k, v := range data {
-		switch val := v.(type) {
-		case string:
-			if (k == "url" || k == "content" || k == "text" || k == "file_data") && len(val) > 1000 {
-				data[k] = val[:1000] + "...[truncated]"
-			}
-		case map[string]any:
-			truncateLargeFields(val)
-		case []any:
-			for _, item := range val {
-				if m, ok := item.(map[string]any); ok {
-					truncateLargeFields(m)
-				}
-			}
+		data[k] = truncateLargeValue(k, v, redactor)
+	}
+}
+
+func truncateLargeValue(key string, v any, redactor *logger.Redactor) any {
+	switch val := v.(type) {
+	case string:
+		val = redactor.Redact(val)
+		if (key == "url" || key == "content" || key == "text" || key == "file_data") && len(val) > 1000 {
+			return val[:1000] + "...[truncated]"
+		}
+		return val
+	case map[string]any:
+		truncateLargeFields(val, redactor)
+	case []any:
+		for i, item := range val {
+			val[i] = truncateLargeValue(key, item, redactor)
 		}
 	}
+	return v
 }
 
 func (c *baseHTTPClient) Do(req *http.Request) (*http.Response, error) {
@@ -83,9 +114,20 @@ func (c *baseHTTPClient) Do(req *http.Request) (*http.Response, error) {
 			strings.TrimPrefix(req.URL.String(), "/"),
 		))
 	}
-	if c.apiKey != "" {
+	if c.token != nil {
+		token, err := c.token.Get(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if c.apiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	}
+	for key, value := range c.headers {
+		if req.Header.Get(key) == "" {
+			req.Header.Set(key, value)
+		}
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	var body []byte
@@ -108,6 +150,16 @@ type ModelParams struct {
 	PresencePenalty  *float32              `json:"presence_penalty,omitzero"`
 	StopSequences    []string              `json:"stop_sequences,omitzero"`
 	Reasoning        *ModelReasoningParams `json:"reasoning,omitzero"`
+	// sampling seed from $seed argument for reproducible answers
+	Seed *int `json:"seed,omitzero"`
+	// answer length from $len argument, not sent to providers
+	Length *string `json:"length,omitzero"`
+	// OpenRouter provider routing from $route argument, not sent to other providers
+	Route *string `json:"route,omitzero"`
+	// system prompt override of the prompt the chain was started with, not sent to providers
+	System *string `json:"system,omitzero"`
+	// request timeout, not sent to providers and not saved with the message
+	Timeout *time.Duration `json:"-"`
 }
 
 func NewModelParamsFromMap(params map[string]any) (ModelParams, error) {
@@ -142,6 +194,18 @@ func NewModelParamsFromMap(params map[string]any) (ModelParams, error) {
 			if val, ok := v.([]string); ok {
 				result.StopSequences = val
 			}
+		case "route":
+			if val, ok := v.(string); ok {
+				result.Route = &val
+			}
+		case "seed":
+			if val, ok := v.(int); ok {
+				result.Seed = &val
+			}
+		case "timeout":
+			if val, ok := v.(time.Duration); ok {
+				result.Timeout = &val
+			}
 		case "reasoning":
 			if reasoningMap, ok := v.(map[string]any); ok {
 				var reasoningParams ModelReasoningParams
@@ -195,7 +259,22 @@ func (base ModelParams) Merge(override ModelParams) ModelParams {
 		base.StopSequences = override.StopSequences
 	}
 	if override.Reasoning != nil {
-		base.Reasoning = override.Reasoning
+		base.Reasoning = base.Reasoning.Merge(*override.Reasoning)
+	}
+	if override.Seed != nil {
+		base.Seed = override.Seed
+	}
+	if override.Length != nil {
+		base.Length = override.Length
+	}
+	if override.Route != nil {
+		base.Route = override.Route
+	}
+	if override.System != nil {
+		base.System = override.System
+	}
+	if override.Timeout != nil {
+		base.Timeout = override.Timeout
 	}
 	return base
 }
@@ -207,6 +286,26 @@ type ModelReasoningParams struct {
 	Effort    *string `json:"effort,omitzero"`
 }
 
+// Merge returns a copy of the params with set override fields. Effort and
+// max tokens are alternatives, so they are overridden together
+func (base *ModelReasoningParams) Merge(override ModelReasoningParams) *ModelReasoningParams {
+	result := ModelReasoningParams{}
+	if base != nil {
+		result = *base
+	}
+	if override.Enabled != nil {
+		result.Enabled = override.Enabled
+	}
+	if override.Exclude != nil {
+		result.Exclude = override.Exclude
+	}
+	if override.MaxTokens != nil || override.Effort != nil {
+		result.MaxTokens = override.MaxTokens
+		result.Effort = override.Effort
+	}
+	return &result
+}
+
 type ChatService interface {
 	GetCurrentModelSpec(ctx context.Context, chatID int64) (string, error)
 	MergeModelParams(chatID int64, provider, alias, prompt string, requestParams ModelParams) (ModelParams, error)
@@ -222,6 +321,12 @@ type Provider interface {
 	GetModelInfo(name string) (*ModelInfo, error)
 }
 
+// SpeechProvider is implemented by providers with a text to speech endpoint
+type SpeechProvider interface {
+	// Speech synthesizes the input with the voice, returns OGG audio with OPUS
+	Speech(ctx context.Context, model, voice, input string) ([]byte, error)
+}
+
 type AnnotationContent struct {
 	Type string `json:"type"` // "text", "image_url", "file"
 	Text string `json:"text,omitzero"`
@@ -346,17 +451,26 @@ type CompletionRequest struct {
 	TopP             *float32              `json:"top_p,omitzero"`
 	FrequencyPenalty *float32              `json:"frequency_penalty,omitzero"`
 	PresencePenalty  *float32              `json:"presence_penalty,omitzero"`
+	Seed             *int                  `json:"seed,omitempty"`
 	Plugins          []Plugin              `json:"plugins,omitzero"`
-	Provider         struct {
-		Sort              string `json:"sort,omitzero"` // price, latency, throughput
-		RequireParameters bool   `json:"require_parameters,omitzero"`
-	} `json:"provider,omitzero"`
-	Usage struct {
+	Provider         ProviderPreferences   `json:"provider,omitzero"`
+	Models           []string              `json:"models,omitzero"` // OpenRouter fallback routing, the requested model goes first
+	Usage            struct {
 		Include bool `json:"include"`
 	} `json:"usage,omitzero"`
 
 	WebSearch bool       `json:"-"`
 	ModelInfo *ModelInfo `json:"-"`
+	// OpenRouter provider routing from $route argument, ignored by other providers
+	Route string `json:"-"`
+}
+
+// ProviderPreferences is the OpenRouter provider routing of the request
+type ProviderPreferences struct {
+	Sort              string   `json:"sort,omitzero"` // price, latency, throughput
+	Order             []string `json:"order,omitzero"`
+	AllowFallbacks    *bool    `json:"allow_fallbacks,omitzero"`
+	RequireParameters bool     `json:"require_parameters,omitzero"`
 }
 
 type UsageDetails struct {
@@ -379,6 +493,8 @@ type ModelUsage struct {
 	PromptTokens           int64        `json:"prompt_tokens"`
 	PromptTokensDetail     UsageDetails `json:"prompt_tokens_details"`
 	TotalTokens            int64        `json:"total_tokens"`
+	// model named in the response, OpenRouter returns the fallback model that answered
+	Model string `json:"-"`
 }
 
 // GetCost returns the cost of executing the request.
@@ -407,6 +523,7 @@ type MessageResponse struct {
 
 type CompletionResponse struct {
 	ID      string `json:"id"`
+	Model   string `json:"model,omitempty"`
 	Choices []struct {
 		Message MessageResponse `json:"message"`
 	} `json:"choices"`
@@ -417,6 +534,7 @@ type CompletionResponse struct {
 
 type StreamResponse struct {
 	ID      string `json:"id"`
+	Model   string `json:"model,omitempty"`
 	Choices []struct {
 		Delta struct {
 			Content          string              `json:"content"`
@@ -613,6 +731,8 @@ type AIError struct {
 	ErrorCode string `json:"error_code"`
 	// Message is a human-readable error message
 	Message string `json:"message"`
+	// RetryAfter is the delay from the Retry-After header of the response (if any)
+	RetryAfter time.Duration `json:"retry_after,omitzero"`
 }
 
 // Error implements the error interface
@@ -688,6 +808,53 @@ func IsRetryableError(err error) bool {
 	return false
 }
 
+// IsModelUnavailableError checks if the request can succeed with another model:
+// the model doesn't exist or is unavailable, rate limited or the provider fails
+func IsModelUnavailableError(err error) bool {
+	if errors.Is(err, ErrModelNotFound) || errors.Is(err, ErrProviderNotFound) {
+		return true
+	}
+	var aiErr *AIError
+	if !errors.As(err, &aiErr) {
+		return false
+	}
+	switch aiErr.ErrorType() {
+	case ErrorTypeRateLimit, ErrorTypeServer:
+		return true
+	}
+	return aiErr.HTTPStatusCode == http.StatusNotFound ||
+		aiErr.HTTPStatusCode == http.StatusPaymentRequired ||
+		aiErr.ErrorCode == "model_not_found"
+}
+
+// imageFetchErrorPhrases are parts of provider messages about image links they can't download
+var imageFetchErrorPhrases = []string{
+	"fetch image",
+	"download image",
+	"downloading image",
+	"image url",
+	"unable to download",
+	"failed to download",
+	"timeout while downloading",
+	"could not process image",
+}
+
+// IsImageFetchError checks if the provider rejected the request because it couldn't
+// download an image by its URL, the request can succeed with the image inlined as base64
+func IsImageFetchError(err error) bool {
+	var aiErr *AIError
+	if !errors.As(err, &aiErr) || aiErr.ErrorType() != ErrorTypeClient {
+		return false
+	}
+	message := strings.ToLower(aiErr.Message)
+	for _, phrase := range imageFetchErrorPhrases {
+		if strings.Contains(message, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
 func GetErrorType(err error) ErrorType {
 	var aiErr *AIError
 	if errors.As(err, &aiErr) {
@@ -696,6 +863,30 @@ func GetErrorType(err error) ErrorType {
 	return ErrorTypeUnknown
 }
 
+// GetRetryAfter returns the delay requested by the provider before the next attempt, 0 if not set
+func GetRetryAfter(err error) time.Duration {
+	var aiErr *AIError
+	if errors.As(err, &aiErr) {
+		return aiErr.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter parses the Retry-After header, it's either seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
+
 // IsErrorType checks if an error is of a specific type
 func IsErrorType(err error, errorType ErrorType) bool {
 	return GetErrorType(err) == errorType
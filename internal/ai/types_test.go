@@ -0,0 +1,389 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseHTTPClient_logRequest_Redacts(t *testing.T) {
+	log := logger.NewTestLogger()
+	client := NewBaseHTTPClient(http.DefaultClient, "", "", log)
+	redactor, err := logger.NewRedactor([]string{
+		`(?i)bearer\s+[\w\-.~+/]+=*`,
+		`\bsk-[\w\-]{16,}`,
+	})
+	require.NoError(t, err)
+	client.SetRedactor(redactor)
+
+	body := `{
+		"model": "test",
+		"messages": [
+			{"role": "user", "content": "my key is sk-or-v1-abcdef0123456789abcdef"},
+			{"role": "user", "content": [{"type": "text", "text": "Authorization: Bearer secret.token-value"}]}
+		]
+	}`
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/chat?key=sk-abcdefghijklmnopqrst", strings.NewReader(body))
+	require.NoError(t, err)
+
+	client.logRequest(req, []byte(body))
+
+	entries := log.GetEntries()
+	require.Len(t, entries, 1)
+	logged, ok := entries[0].Fields["request"].(string)
+	require.True(t, ok)
+
+	assert.NotContains(t, logged, "sk-or-v1-abcdef0123456789abcdef")
+	assert.NotContains(t, logged, "sk-abcdefghijklmnopqrst")
+	assert.NotContains(t, logged, "secret.token-value")
+	assert.Contains(t, logged, "my key is "+logger.Redacted)
+	assert.Contains(t, logged, "Authorization: "+logger.Redacted)
+}
+
+func TestBaseHTTPClient_logRequest_WithoutRedactor(t *testing.T) {
+	log := logger.NewTestLogger()
+	client := NewBaseHTTPClient(http.DefaultClient, "", "", log)
+
+	body := `{"messages": [{"role": "user", "content": "user@example.com"}]}`
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/chat", strings.NewReader(body))
+	require.NoError(t, err)
+
+	client.logRequest(req, []byte(body))
+
+	entries := log.GetEntries()
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0].Fields["request"], "user@example.com")
+}
+
+func TestIsModelUnavailableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"model not found", fmt.Errorf("%w: test", ErrModelNotFound), true},
+		{"provider not found", fmt.Errorf("%w: test", ErrProviderNotFound), true},
+		{"rate limit", &AIError{HTTPStatusCode: http.StatusTooManyRequests}, true},
+		{"server error", &AIError{HTTPStatusCode: http.StatusBadGateway}, true},
+		{"not found status", &AIError{HTTPStatusCode: http.StatusNotFound}, true},
+		{"no credits", &AIError{HTTPStatusCode: http.StatusPaymentRequired}, true},
+		{"model not found code", &AIError{HTTPStatusCode: http.StatusBadRequest, ErrorCode: "model_not_found"}, true},
+		{"bad request", &AIError{HTTPStatusCode: http.StatusBadRequest}, false},
+		{"content policy", &AIError{HTTPStatusCode: http.StatusBadRequest, Message: "content policy violation"}, false},
+		{"other error", errors.New("context canceled"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsModelUnavailableError(tt.err))
+		})
+	}
+}
+
+func TestIsImageFetchError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"openai", &AIError{HTTPStatusCode: http.StatusBadRequest, Message: "Timeout while downloading https://example.com/cat.jpg."}, true},
+		{"openrouter", &AIError{HTTPStatusCode: http.StatusBadRequest, Message: "Provider returned error: Unable to fetch image from URL"}, true},
+		{"wrapped", fmt.Errorf("ask: %w", &AIError{HTTPStatusCode: http.StatusBadRequest, Message: "Invalid image URL"}), true},
+		{"server error", &AIError{HTTPStatusCode: http.StatusBadGateway, Message: "Failed to download image"}, false},
+		{"other client error", &AIError{HTTPStatusCode: http.StatusBadRequest, Message: "context length exceeded"}, false},
+		{"not ai error", errors.New("failed to download image"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsImageFetchError(tt.err))
+		})
+	}
+}
+
+func TestModelParams_Merge_Reasoning(t *testing.T) {
+	enabled := true
+	configTokens := 2000
+	effort := "high"
+	base := ModelParams{Reasoning: &ModelReasoningParams{Enabled: &enabled, MaxTokens: &configTokens}}
+
+	t.Run("effort replaces config max tokens", func(t *testing.T) {
+		merged := base.Merge(ModelParams{Reasoning: &ModelReasoningParams{Effort: &effort}})
+
+		require.NotNil(t, merged.Reasoning)
+		assert.Equal(t, &enabled, merged.Reasoning.Enabled, "Other reasoning params are kept")
+		assert.Nil(t, merged.Reasoning.MaxTokens)
+		assert.Equal(t, "high", *merged.Reasoning.Effort)
+		assert.Equal(t, 2000, *base.Reasoning.MaxTokens, "Base params must not be modified")
+	})
+
+	t.Run("without reasoning override", func(t *testing.T) {
+		merged := base.Merge(ModelParams{})
+		assert.Same(t, base.Reasoning, merged.Reasoning)
+	})
+
+	t.Run("without base reasoning", func(t *testing.T) {
+		merged := ModelParams{}.Merge(ModelParams{Reasoning: &ModelReasoningParams{Effort: &effort}})
+
+		require.NotNil(t, merged.Reasoning)
+		assert.Nil(t, merged.Reasoning.Enabled)
+		assert.Equal(t, "high", *merged.Reasoning.Effort)
+	})
+}
+
+func TestOpenRouterClient_SetHeaders(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_ORG_ID", "org-42")
+	cfg := config.AIProviderConfig{
+		Name:    "openrouter",
+		BaseURL: server.URL,
+		Headers: map[string]string{
+			"HTTP-Referer": "https://example.com",
+			"x-title":      "My bot",
+			"X-Org-ID":     "${TEST_ORG_ID}",
+		},
+	}
+	client := NewOpenRouterClient(cfg, nil, logger.NewTestLogger(), server.Client())
+	client.SetHeaders(cfg.GetHeaders())
+
+	content, _, _, _, err := client.OpenAICompatibleClient.Ask(context.Background(), CompletionRequest{Model: "test"}, map[string]string{"X-Request": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", content)
+	assert.Equal(t, "https://example.com", headers.Get("HTTP-Referer"))
+	assert.Equal(t, "org-42", headers.Get("X-Org-ID"))
+	assert.Equal(t, "1", headers.Get("X-Request"))
+	assert.Equal(t, "My bot", headers.Get("X-Title"))
+
+	// configured header replaces the default one
+	assert.Empty(t, client.setHeaders(nil))
+}
+
+func TestApplyRoute(t *testing.T) {
+	t.Run("without route", func(t *testing.T) {
+		request := CompletionRequest{Provider: ProviderPreferences{Sort: "price"}}
+		applyRoute(&request)
+		assert.Equal(t, ProviderPreferences{Sort: "price"}, request.Provider)
+	})
+
+	t.Run("sort strategy", func(t *testing.T) {
+		request := CompletionRequest{Route: "latency", Provider: ProviderPreferences{Sort: "price"}}
+		applyRoute(&request)
+		assert.Equal(t, "latency", request.Provider.Sort)
+		assert.Empty(t, request.Provider.Order)
+	})
+
+	t.Run("pinned provider", func(t *testing.T) {
+		request := CompletionRequest{Route: "deepinfra/turbo", Provider: ProviderPreferences{Sort: "price"}}
+		applyRoute(&request)
+
+		data, err := json.Marshal(request.Provider)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"order": ["deepinfra/turbo"], "allow_fallbacks": false}`, string(data))
+	})
+}
+
+func TestIsValidOpenRouterRoute(t *testing.T) {
+	for _, value := range []string{"price", "throughput", "anthropic", "google-vertex", "deepinfra/turbo"} {
+		assert.True(t, IsValidOpenRouterRoute(value), value)
+	}
+	for _, value := range []string{"", "Anthropic", "-openai", "open ai"} {
+		assert.False(t, IsValidOpenRouterRoute(value), value)
+	}
+}
+
+func TestOpenAICompatibleClient_Ask_RetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error": {"message": "Rate limit exceeded"}}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClient(config.AIProviderConfig{Name: "openrouter", BaseURL: server.URL}, nil, logger.NewTestLogger(), server.Client())
+	_, _, _, _, err := client.OpenAICompatibleClient.Ask(context.Background(), CompletionRequest{Model: "test"}, nil)
+
+	require.Error(t, err)
+	assert.True(t, IsRetryableError(err))
+	assert.Equal(t, 7*time.Second, GetRetryAfter(err))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-5", 0},
+		{"Thu, 15 Oct 2026 12:00:10 GMT", 10 * time.Second},
+		{"Thu, 15 Oct 2026 11:59:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseRetryAfter(tt.value, now), tt.value)
+	}
+}
+
+func TestOpenRouterClient_GetRandomFreeModel_SkipsBlocked(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("GACHIGAZER_TELEGRAM_TOKEN", "token")
+	toml := "[ai]\nblocked_models = [\"or:broken/*\", \"or:garbage:free\"]\n"
+	require.NoError(t, os.WriteFile("gachigazer.toml", []byte(toml), 0o644))
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	client := NewOpenRouterClient(config.AIProviderConfig{Name: "or"}, cfg, logger.NewTestLogger(), http.DefaultClient)
+	free := &ModelPricing{Completion: "0", Prompt: "0", Image: "0", WebSearch: "0"}
+	client.modelsCache = map[string]*ModelInfo{
+		"good/model:free":   {ID: "good/model:free", Pricing: free},
+		"broken/model:free": {ID: "broken/model:free", Pricing: free},
+		"broken/other:free": {ID: "broken/other:free", Pricing: free},
+		"garbage:free":      {ID: "garbage:free", Pricing: free},
+		"paid/model":        {ID: "paid/model", Pricing: &ModelPricing{Completion: "0.01"}},
+	}
+	client.lastSync = time.Now()
+
+	for range 50 {
+		model, err := client.GetRandomFreeModel(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "good/model:free", model)
+	}
+
+	delete(client.modelsCache, "good/model:free")
+	_, err = client.GetRandomFreeModel(t.Context())
+	require.Error(t, err, "All free models are blocked")
+}
+
+func TestOpenAICompatibleClient_Speech(t *testing.T) {
+	var path string
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		_, _ = w.Write([]byte("OggS"))
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClient(config.AIProviderConfig{Name: "openai", BaseURL: server.URL}, nil, logger.NewTestLogger(), server.Client())
+	audio, err := client.Speech(context.Background(), "tts-1", "alloy", "Hello")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("OggS"), audio)
+	assert.Equal(t, "/audio/speech", path)
+	assert.Equal(t, map[string]any{"model": "tts-1", "voice": "alloy", "input": "Hello", "response_format": "opus"}, request)
+
+	registry := NewProviderRegistry(nil, logger.NewTestLogger())
+	registry.RegisterProvider("openai", client)
+	_, err = registry.Speech(context.Background(), "", "alloy", "Hello")
+	assert.EqualError(t, err, "speech model is not set")
+	audio, err = registry.Speech(context.Background(), "openai:tts-1", "alloy", "Hello")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("OggS"), audio)
+}
+
+func TestOpenAICompatibleClient_CreateRequest_Seed(t *testing.T) {
+	client := NewOpenAICompatibleClient("test", "", "", "", "", logger.NewTestLogger(), false, nil, nil)
+	model := &ModelInfo{ID: "main"}
+
+	data, err := json.Marshal(client.CreateRequest(false, nil, nil, model, ModelParams{}, false))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "seed")
+
+	seed := 0
+	merged := ModelParams{}.Merge(ModelParams{Seed: &seed})
+	data, err = json.Marshal(client.CreateRequest(false, nil, nil, model, merged, false))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"seed":0`)
+}
+
+func TestOpenRouterClient_CreateRequest_FallbackModels(t *testing.T) {
+	client := NewOpenRouterClient(config.AIProviderConfig{
+		Name:           "or",
+		FallbackModels: []string{"backup/paid", "main/model", "backup/model:free"},
+	}, nil, logger.NewTestLogger(), http.DefaultClient)
+	free := &ModelPricing{Completion: "0", Prompt: "0", Image: "0", WebSearch: "0"}
+	client.modelsCache = map[string]*ModelInfo{
+		"backup/model:free": {ID: "backup/model:free", Pricing: free},
+		"backup/paid":       {ID: "backup/paid", Pricing: &ModelPricing{Completion: "0.01"}},
+	}
+
+	request := client.CreateRequest(false, nil, nil, &ModelInfo{ID: "main/model"}, ModelParams{}, false)
+	assert.Equal(t, []string{"main/model", "backup/paid", "backup/model:free"}, request.Models, "Requested model goes first")
+
+	request = client.CreateRequest(false, nil, nil, &ModelInfo{ID: "main/model:free", Pricing: free}, ModelParams{}, false)
+	assert.Equal(t, []string{"main/model:free", "backup/model:free"}, request.Models, "Paid fallbacks are skipped for a free model")
+
+	client.fallbackModels = nil
+	request = client.CreateRequest(false, nil, nil, &ModelInfo{ID: "main/model"}, ModelParams{}, false)
+	data, err := json.Marshal(request)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"models"`)
+}
+
+func TestOpenRouterClient_AnsweredFallback(t *testing.T) {
+	client := NewOpenRouterClient(config.AIProviderConfig{
+		Name:           "or",
+		FallbackModels: []string{"openai/gpt-4o-mini", "meta/llama:free"},
+	}, nil, logger.NewTestLogger(), http.DefaultClient)
+
+	tests := []struct {
+		requested string
+		response  string
+		fallback  string
+	}{
+		{"openai/gpt-4o", "", ""},
+		{"openai/gpt-4o", "openai/gpt-4o", ""},
+		{"openai/gpt-4o", "openai/gpt-4o-2024-08-06", ""},
+		{"openai/gpt-4o", "openai/gpt-4o-mini", "openai/gpt-4o-mini"},
+		{"openai/gpt-4o", "openai/gpt-4o-mini-2024-07-18", "openai/gpt-4o-mini"},
+		{"openai/gpt-4o", "meta/llama", "meta/llama:free"},
+		{"openai/gpt-4o", "unknown/model", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.response, func(t *testing.T) {
+			fallback, ok := client.AnsweredFallback(tt.requested, tt.response)
+			assert.Equal(t, tt.fallback != "", ok)
+			assert.Equal(t, tt.fallback, fallback)
+		})
+	}
+}
+
+func TestOpenRouterClient_Ask_FallbackModels(t *testing.T) {
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request CompletionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		models = request.Models
+		fmt.Fprint(w, `{"id":"1","model":"backup/model","choices":[{"message":{"content":"hi"}}],"usage":{"total_tokens":3}}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClient(config.AIProviderConfig{
+		Name:           "or",
+		BaseURL:        server.URL,
+		DefaultModel:   "main/model",
+		FallbackModels: []string{"backup/model"},
+	}, nil, logger.NewTestLogger(), server.Client())
+
+	request := client.CreateRequest(false, nil, nil, &ModelInfo{}, ModelParams{}, false)
+	_, _, response, _, err := client.Ask(t.Context(), request, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main/model", "backup/model"}, models, "Resolved model replaces the requested one")
+	assert.Equal(t, "backup/model", response.Usage.Model)
+}
@@ -2,22 +2,38 @@ package app
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/muratoffalex/gachigazer/internal/app/di"
+	"github.com/muratoffalex/gachigazer/internal/commands/about"
 	"github.com/muratoffalex/gachigazer/internal/commands/ask"
+	"github.com/muratoffalex/gachigazer/internal/commands/currency"
 	"github.com/muratoffalex/gachigazer/internal/commands/instagram"
+	"github.com/muratoffalex/gachigazer/internal/commands/memory"
 	"github.com/muratoffalex/gachigazer/internal/commands/model"
 	"github.com/muratoffalex/gachigazer/internal/commands/random"
+	"github.com/muratoffalex/gachigazer/internal/commands/selftest"
+	"github.com/muratoffalex/gachigazer/internal/commands/settings"
 	"github.com/muratoffalex/gachigazer/internal/commands/start"
+	"github.com/muratoffalex/gachigazer/internal/commands/stats"
 	"github.com/muratoffalex/gachigazer/internal/commands/youtube"
 	"github.com/muratoffalex/gachigazer/internal/config"
 	"github.com/muratoffalex/gachigazer/internal/core"
 	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/service"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
 )
 
-const FailedToInit = "Failed to init"
+const (
+	FailedToInit = "Failed to init"
+	// conversations deleted by one statement of the cleanup
+	conversationPurgeBatchSize = 100
+)
 
 type Application struct {
 	Logger logger.Logger
@@ -26,12 +42,15 @@ type Application struct {
 	di     *di.Container
 	ctx    context.Context
 	cancel context.CancelFunc
+	// set in the webhook mode, stopped after the context is done
+	webhook *telegram.Webhook
 }
 
 func New() (*Application, error) {
 	flag.Parse()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// stop on SIGINT and SIGTERM, in-flight webhook requests are finished before exit
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	cfg, err := config.Load()
 	if err != nil {
 		cancel()
@@ -75,7 +94,55 @@ func New() (*Application, error) {
 func (a *Application) Start() error {
 	a.Logger.Info("Starting application")
 	a.StartMessageCleaner()
-	return a.bot.Start(a.ctx)
+	a.StartConversationCleaner()
+	if metricsCfg := a.cfg.Metrics(); metricsCfg.Enabled {
+		a.di.Metrics.Serve(a.ctx, metricsCfg.Addr, a.Logger)
+	}
+	service.NewReminderService(a.di.DB, a.di.BotClient, a.di.Localizer, a.Logger).Start(a.ctx)
+
+	var err error
+	if webhookCfg := a.cfg.Telegram().Webhook; webhookCfg.Enabled {
+		err = a.startWebhook(webhookCfg)
+	} else {
+		err = a.bot.Start(a.ctx)
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// startWebhook receives updates by webhook instead of long polling
+func (a *Application) startWebhook(cfg config.TelegramWebhookConfig) error {
+	if cfg.PublicURL == "" {
+		return errors.New("telegram.webhook.public_url is required when the webhook is enabled")
+	}
+	webhook, err := a.di.BotClient.ListenWebhook(a.ctx, telegram.WebhookConfig{
+		ListenAddr:     cfg.ListenAddr,
+		PublicURL:      cfg.PublicURL,
+		SecretToken:    cfg.SecretToken,
+		AllowedUpdates: a.bot.AllowedUpdates(),
+	})
+	if err != nil {
+		return err
+	}
+	a.webhook = webhook
+	// the bot stops with the server, the application shouldn't keep running without updates
+	ctx, stop := context.WithCancel(a.ctx)
+	defer stop()
+	go func() {
+		<-webhook.Stopped()
+		stop()
+	}()
+	err = a.bot.Run(ctx, webhook.Updates())
+	select {
+	case <-webhook.Stopped():
+		if webhookErr := webhook.Err(); webhookErr != nil {
+			return webhookErr
+		}
+	default:
+	}
+	return err
 }
 
 func (a *Application) registerCommands(ctx context.Context) {
@@ -100,6 +167,24 @@ func (a *Application) registerCommands(ctx context.Context) {
 			a.Logger.WithField("command", youtube.CommandName).Info("YouTube command registered successfully")
 		}()
 	}
+	if a.cfg.GetCommandConfig(currency.CommandName).Enabled {
+		a.bot.RegisterCommand(currency.New(a.di))
+	}
+	if a.cfg.GetCommandConfig(about.CommandName).Enabled {
+		a.bot.RegisterCommand(about.New(a.di))
+	}
+	if a.cfg.GetCommandConfig(stats.CommandName).Enabled {
+		a.bot.RegisterCommand(stats.New(a.di))
+	}
+	if a.cfg.GetCommandConfig(settings.CommandName).Enabled {
+		a.bot.RegisterCommand(settings.New(a.di))
+	}
+	if a.cfg.GetCommandConfig(memory.CommandName).Enabled {
+		a.bot.RegisterCommand(memory.New(a.di))
+	}
+	if a.cfg.GetCommandConfig(selftest.CommandName).Enabled {
+		a.bot.RegisterCommand(selftest.New(a.di, a.bot.GetCommands))
+	}
 	if a.cfg.GetCommandConfig(start.CommandName).Enabled {
 		a.bot.RegisterCommand(start.New(a.di))
 	}
@@ -130,6 +215,9 @@ func (a *Application) registerCommands(ctx context.Context) {
 
 func (a *Application) WaitForShutdown() {
 	<-a.ctx.Done()
+	if a.webhook != nil {
+		<-a.webhook.Stopped()
+	}
 	a.Logger.Info("Application stopped")
 }
 
@@ -151,3 +239,52 @@ func (a *Application) StartMessageCleaner() {
 		}
 	}()
 }
+
+// StartConversationCleaner periodically deletes conversations older than
+// global.conversation_retention_days, nothing is deleted when it's 0
+func (a *Application) StartConversationCleaner() {
+	globalCfg := a.cfg.Global()
+	retentionDays := globalCfg.ConversationRetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+	interval := globalCfg.ConversationCleanupInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			a.purgeOldConversations(retentionDays)
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// purgeOldConversations deletes old conversations in small batches,
+// a single large delete locks SQLite for other writers
+func (a *Application) purgeOldConversations(retentionDays int) {
+	var total int64
+	for a.ctx.Err() == nil {
+		deleted, err := a.di.DB.PurgeOldConversations(retentionDays, conversationPurgeBatchSize)
+		if err != nil {
+			a.Logger.WithError(err).Error("Failed to purge old conversations")
+			break
+		}
+		if deleted == 0 {
+			break
+		}
+		total += deleted
+	}
+	if total > 0 {
+		a.Logger.WithFields(logger.Fields{
+			"messages":       total,
+			"retention_days": retentionDays,
+		}).Info("Purged old conversations")
+	}
+}
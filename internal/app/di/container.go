@@ -15,6 +15,7 @@ import (
 	"github.com/muratoffalex/gachigazer/internal/fetcher"
 	"github.com/muratoffalex/gachigazer/internal/logger"
 	"github.com/muratoffalex/gachigazer/internal/markdown"
+	"github.com/muratoffalex/gachigazer/internal/metrics"
 	"github.com/muratoffalex/gachigazer/internal/network"
 	"github.com/muratoffalex/gachigazer/internal/queue"
 	"github.com/muratoffalex/gachigazer/internal/service"
@@ -36,6 +37,8 @@ type Container struct {
 	Localizer   *service.Localizer
 	Fetcher     *fetcher.Manager
 	YtService   *youtube.Service
+	// Metrics is nil when metrics are disabled
+	Metrics *metrics.Metrics
 }
 
 func NewContainer(cfg *config.Config) (*Container, error) {
@@ -67,7 +70,13 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 		Localizer: localizer,
 	}
 
+	if cfg.Metrics().Enabled {
+		container.Metrics = metrics.New()
+	}
+
 	httpCfg := network.NewDefaultHTTPClientConfig(cfg.HTTP())
+	// AI requests are limited by their own timeout, the client must not cut them earlier
+	httpCfg.Timeout = max(httpCfg.Timeout, cfg.AI().MaxRequestTimeout())
 	container.HttpClient = network.SetupHTTPClient(httpCfg, l)
 
 	ytService := youtube.NewService(l, container.HttpClient, youtube.Config{
@@ -76,8 +85,11 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 	})
 	container.YtService = &ytService
 
-	fetcherHTTPCfg := network.NewHTTPClientConfigForFetcher(cfg.HTTP())
-	fetcherHTTPClient := network.SetupHTTPClient(fetcherHTTPCfg, l)
+	fetcherCfg := cfg.GetAskCommandConfig().Fetcher
+	if len(fetcherCfg.UserAgents) > 0 {
+		fetcher.UserAgents = fetcherCfg.UserAgents
+	}
+	fetcherHTTPClient := newFetcherHTTPClient(cfg.HTTP(), fetcherCfg.BrowserHeaders, l)
 	fetcherManager := fetcher.NewManager(l)
 	fetcherManager.RegisterFetcher(fetcher.NewFragranticaFetcher(l, fetcherHTTPClient))
 	fetcherManager.RegisterFetcher(fetcher.NewRedditFetcher(l, fetcherHTTPClient))
@@ -86,7 +98,26 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 	fetcherManager.RegisterFetcher(fetcher.NewOpennetFetcher(l, fetcherHTTPClient))
 	fetcherManager.RegisterFetcher(fetcher.NewTelegramFetcher(l, fetcherHTTPClient))
 	fetcherManager.RegisterFetcher(fetcher.NewYoutubeFetcher(l, fetcherHTTPClient, &ytService))
-	fetcherManager.SetDefaultFetcher(fetcher.NewDefaultFetcher(l, fetcherHTTPClient))
+	twitchCfg := cfg.Twitch()
+	fetcherManager.RegisterFetcher(fetcher.NewTwitchFetcher(l, fetcherHTTPClient, twitchCfg.ClientID, twitchCfg.ClientSecret))
+	xCfg := cfg.X()
+	fetcherManager.RegisterFetcher(fetcher.NewXFetcher(l, fetcherHTTPClient, xCfg.Instance, xCfg.Timeout))
+	fetcherManager.RegisterFetcher(fetcher.NewWikipediaFetcher(l, fetcherHTTPClient))
+	fetcherManager.RegisterFetcher(fetcher.NewArxivFetcher(l, fetcherHTTPClient))
+	fetcherManager.RegisterFetcher(fetcher.NewOsnovaFetcher(l, fetcherHTTPClient))
+	fetcherManager.RegisterFetcher(fetcher.NewTelegraphFetcher(l, fetcherHTTPClient))
+	fetcherManager.RegisterFetcher(fetcher.NewMusicFetcher(l, fetcherHTTPClient))
+	fetcherManager.RegisterFetcher(fetcher.NewFeedFetcher(l, fetcherHTTPClient))
+	var selectors []fetcher.HostSelectors
+	for _, item := range cfg.GetAskCommandConfig().Fetcher.Selectors {
+		selectors = append(selectors, fetcher.HostSelectors{Host: item.Host, Remove: item.Remove, Content: item.Content})
+	}
+	fetcherManager.SetDefaultFetcher(fetcher.NewDefaultFetcher(
+		l,
+		fetcherHTTPClient,
+		selectors,
+		cfg.GetAskCommandConfig().Fetcher.BlockedFallbackURL,
+	))
 	container.Fetcher = fetcherManager
 
 	providerRegistry := ai.NewProviderRegistry(cfg, l)
@@ -112,10 +143,22 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 			)
 		case ai.ProviderLocal:
 			provider = ai.NewLocalAIClient(providerCfg, cfg, l, container.HttpClient)
+		case ai.ProviderAnthropic:
+			provider = ai.NewAnthropicClient(providerCfg, cfg, l, container.HttpClient)
 		default:
 			l.Error("Unsupported AI provider type: " + providerCfg.Type)
 			continue
 		}
+		if len(providerCfg.Headers) > 0 {
+			if p, ok := provider.(interface{ SetHeaders(map[string]string) }); ok {
+				p.SetHeaders(providerCfg.GetHeaders())
+			}
+		}
+		if providerCfg.TokenCommand != "" {
+			if p, ok := provider.(interface{ SetTokenProvider(ai.TokenProvider) }); ok {
+				p.SetTokenProvider(ai.NewCommandTokenProvider(providerCfg.TokenCommand, providerCfg.TokenTTL))
+			}
+		}
 
 		providerRegistry.RegisterProvider(providerName, provider)
 		providerLog.WithField("type", providerCfg.Type).Info("Initialized AI provider")
@@ -156,3 +199,32 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 
 	return container, nil
 }
+
+// newFetcherHTTPClient creates the client of fetchers, with several proxies
+// requests are rotated between clients created per proxy. Requests to browserHeadersHosts
+// get browser-like headers
+func newFetcherHTTPClient(httpCfg config.HTTPConfig, browserHeadersHosts []string, l logger.Logger) fetcher.HTTPClient {
+	client := newFetcherProxyClient(httpCfg, l)
+	if len(browserHeadersHosts) > 0 {
+		return fetcher.NewBrowserHeadersClient(client, browserHeadersHosts)
+	}
+	return client
+}
+
+func newFetcherProxyClient(httpCfg config.HTTPConfig, l logger.Logger) fetcher.HTTPClient {
+	fetcherHTTPCfg := network.NewHTTPClientConfigForFetcher(httpCfg)
+	proxies := httpCfg.GetProxies()
+	if len(proxies) <= 1 {
+		return network.SetupHTTPClient(fetcherHTTPCfg, l)
+	}
+	clients := make([]fetcher.HTTPClient, 0, len(proxies))
+	for _, proxy := range proxies {
+		fetcherHTTPCfg.ProxyURL = proxy
+		clients = append(clients, network.SetupHTTPClient(fetcherHTTPCfg, l))
+	}
+	l.WithFields(logger.Fields{
+		"proxies":  len(proxies),
+		"rotation": httpCfg.ProxyRotation,
+	}).Info("Fetcher proxy rotation configured")
+	return fetcher.NewProxyRotator(clients, httpCfg.ProxyRotation == config.ProxyRotationSticky, l)
+}
@@ -0,0 +1,90 @@
+package about
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/muratoffalex/gachigazer/internal/app/di"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const (
+	CommandName = "setabout"
+
+	// MaxLength is the max length of the description in characters
+	MaxLength = 500
+)
+
+// Command sets the "about me" description that the model gets with get_user_info tool
+type Command struct {
+	*base.Command
+	db database.Database
+}
+
+func New(di *di.Container) *Command {
+	cmd := &Command{
+		db: di.DB,
+	}
+	cmd.Command = base.NewCommand(cmd, di)
+	return cmd
+}
+
+func (c *Command) Name() string {
+	return CommandName
+}
+
+func (c *Command) Execute(update telegram.Update) error {
+	if update.Message == nil {
+		return nil
+	}
+
+	args := strings.TrimSpace(strings.TrimPrefix(
+		update.Message.Text,
+		"/"+update.Message.Command(),
+	))
+	userID := update.Message.From.ID
+
+	if args == "" {
+		user, err := c.db.GetUser(userID)
+		if err != nil {
+			c.Logger.WithError(err).WithField("user_id", userID).Error("Failed to get user")
+			return c.reply(update, c.Localizer.Localize("setabout.fail", nil))
+		}
+		if user.About == "" {
+			return c.reply(update, c.Localizer.Localize("setabout.notSet", nil))
+		}
+		return c.reply(update, c.Localizer.Localize("setabout.current", map[string]any{
+			"About": user.About,
+		}))
+	}
+
+	about := args
+	if args == "reset" {
+		about = ""
+	}
+	if length := utf8.RuneCountInString(about); length > MaxLength {
+		return c.reply(update, c.Localizer.Localize("setabout.tooLong", map[string]any{
+			"Length":    length,
+			"MaxLength": MaxLength,
+		}))
+	}
+
+	if err := c.db.SetUserAbout(userID, about); err != nil {
+		c.Logger.WithError(err).WithField("user_id", userID).Error("Failed to save user about")
+		_ = c.reply(update, c.Localizer.Localize("setabout.fail", nil))
+		return err
+	}
+	c.Logger.WithField("user_id", userID).Info("User about changed")
+
+	if about == "" {
+		return c.reply(update, c.Localizer.Localize("setabout.reset.success", nil))
+	}
+	return c.reply(update, c.Localizer.Localize("setabout.set.success", nil))
+}
+
+func (c *Command) reply(update telegram.Update, text string) error {
+	_, err := c.Tg.Send(telegram.NewMessage(update.Message.Chat.ID, text, update.Message.MessageID))
+	return err
+}
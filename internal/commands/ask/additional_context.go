@@ -0,0 +1,42 @@
+package ask
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// limitAdditionalContext keeps the newest $c lines that fit into the limits.
+// The older lines are replaced with a single summary line, or dropped if
+// summarize is nil or fails. It returns the index of the first kept
+// line (lines before it must not add media and URLs) and the resulting lines
+func limitAdditionalContext(
+	lines []string,
+	maxMessages int,
+	maxLength int,
+	summarize func(text string) (string, error),
+) (int, []string) {
+	first := 0
+	length := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		kept := len(lines) - i
+		length += utf8.RuneCountInString(lines[i])
+		// the newest message is always kept
+		if kept > 1 && ((maxMessages > 0 && kept > maxMessages) || (maxLength > 0 && length > maxLength)) {
+			first = i + 1
+			break
+		}
+	}
+	if first == 0 {
+		return 0, lines
+	}
+
+	result := make([]string, 0, len(lines)-first+1)
+	if summarize != nil {
+		summary, err := summarize(strings.Join(lines[:first], "\n"))
+		if err == nil && summary != "" {
+			result = append(result, fmt.Sprintf("[summary of %d earlier messages]: %s", first, summary))
+		}
+	}
+	return first, append(result, lines[first:]...)
+}
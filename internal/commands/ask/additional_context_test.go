@@ -0,0 +1,91 @@
+package ask
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func contextLines(n, size int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		line := fmt.Sprintf("[msg:%d] user: ", i)
+		lines[i] = line + strings.Repeat("a", max(size-len(line), 0))
+	}
+	return lines
+}
+
+func TestLimitAdditionalContext(t *testing.T) {
+	t.Run("within limits", func(t *testing.T) {
+		lines := contextLines(5, 20)
+		called := false
+		first, result := limitAdditionalContext(lines, 10, 1000, func(string) (string, error) {
+			called = true
+			return "summary", nil
+		})
+
+		assert.Equal(t, 0, first)
+		assert.Equal(t, lines, result)
+		assert.False(t, called, "Summary must not be generated within limits")
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		lines := contextLines(500, 100)
+		first, result := limitAdditionalContext(lines, 0, 0, nil)
+
+		assert.Equal(t, 0, first)
+		assert.Len(t, result, 500)
+	})
+
+	t.Run("messages cap without summary", func(t *testing.T) {
+		lines := contextLines(10, 20)
+		first, result := limitAdditionalContext(lines, 3, 0, nil)
+
+		assert.Equal(t, 7, first)
+		assert.Equal(t, lines[7:], result, "Newest messages must be kept")
+	})
+
+	t.Run("length cap", func(t *testing.T) {
+		lines := contextLines(10, 100)
+		first, result := limitAdditionalContext(lines, 0, 350, nil)
+
+		assert.Equal(t, 7, first)
+		assert.Equal(t, lines[7:], result)
+	})
+
+	t.Run("newest message is kept even if too long", func(t *testing.T) {
+		lines := contextLines(3, 1000)
+		first, result := limitAdditionalContext(lines, 0, 100, nil)
+
+		assert.Equal(t, 2, first)
+		assert.Equal(t, lines[2:], result)
+	})
+
+	t.Run("older messages are summarized", func(t *testing.T) {
+		lines := contextLines(10, 20)
+		var summarized string
+		first, result := limitAdditionalContext(lines, 4, 0, func(text string) (string, error) {
+			summarized = text
+			return "they talked about cats", nil
+		})
+
+		assert.Equal(t, 6, first)
+		assert.Equal(t, strings.Join(lines[:6], "\n"), summarized, "Only overflowed messages must be summarized")
+		assert.Len(t, result, 5)
+		assert.Equal(t, "[summary of 6 earlier messages]: they talked about cats", result[0])
+		assert.Equal(t, lines[6:], result[1:])
+	})
+
+	t.Run("summary failure drops older messages", func(t *testing.T) {
+		lines := contextLines(10, 20)
+		first, result := limitAdditionalContext(lines, 4, 0, func(string) (string, error) {
+			return "", errors.New("utility model unavailable")
+		})
+
+		assert.Equal(t, 6, first)
+		assert.Equal(t, lines[6:], result)
+	})
+}
@@ -0,0 +1,59 @@
+package ask
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+// telegram usernames are 5-32 characters: letters, digits and underscores
+var usernameRegex = regexp.MustCompile(`^@?[A-Za-z][A-Za-z0-9_]{3,31}$`)
+
+// addressee is the chat participant the answer is addressed to with $at
+type addressee struct {
+	UserID    int64
+	FirstName string
+	EncodedID string
+}
+
+// resolveAddressee finds the user of $at among the known users, the user must be
+// a participant of the chat, otherwise the request is answered as usual
+func (c *Command) resolveAddressee(chatID int64, username string) *addressee {
+	username = strings.TrimPrefix(username, "@")
+	addresseeLog := c.Logger.WithFields(logger.Fields{
+		"chat_id":  chatID,
+		"username": username,
+	})
+	user, err := c.db.GetUserByUsername(username)
+	if err != nil {
+		addresseeLog.WithError(err).Warn("Addressee of $at is not found")
+		return nil
+	}
+	if !c.canAccessChat(chatID, user.ID) {
+		addresseeLog.Warn("Addressee of $at is not a participant of the chat")
+		return nil
+	}
+	return &addressee{UserID: user.ID, FirstName: user.FirstName, EncodedID: user.PublicID}
+}
+
+// addresseeBlock renders the instruction to address the answer to the participant
+func addresseeBlock(a *addressee) string {
+	if a == nil {
+		return ""
+	}
+	return fmt.Sprintf(
+		"[ADDRESSEE]\nAddress your answer to the chat participant %s(%s), speak to them directly and call them by the first name %q. Never mention their ID or username.",
+		a.FirstName, a.EncodedID, a.FirstName,
+	)
+}
+
+// addresseeMention returns the MarkdownV2 link mentioning the user by the first name,
+// telegram turns it into a text mention notifying the user
+func (c *Command) addresseeMention(a *addressee) string {
+	if a == nil || !c.cmdCfg.Display.MentionAddressee {
+		return ""
+	}
+	return fmt.Sprintf("[%s](tg://user?id=%d)", c.Tg.EscapeText(a.FirstName), a.UserID)
+}
@@ -0,0 +1,65 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/service"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateArg_At(t *testing.T) {
+	arg := &Argument{Name: "at", Type: "string", Values: []string{"`@username` of a chat participant"}}
+
+	for _, value := range []string{"@alice_w", "Bob_1234", "@" + "a123456789012345678901234567890"} {
+		assert.NoError(t, validateArg(arg, value), value)
+	}
+	for _, value := range []string{"", "@", "@bob", "@1alice", "@alice-w", "123456789", "@" + "a12345678901234567890123456789012"} {
+		assert.Error(t, validateArg(arg, value), value)
+	}
+}
+
+func TestAddresseeBlock(t *testing.T) {
+	assert.Empty(t, addresseeBlock(nil))
+
+	block := addresseeBlock(&addressee{UserID: 42, FirstName: "Alice", EncodedID: "abc123"})
+	assert.Contains(t, block, "[ADDRESSEE]")
+	assert.Contains(t, block, "Alice(abc123)")
+	assert.NotContains(t, block, "42", "Telegram ID is never sent to the model")
+}
+
+func TestCommand_addresseeMention(t *testing.T) {
+	tg := telegram.NewMockClient(t)
+	cmd := newInfoTestCommand(t, tg)
+	cmd.cmdCfg = &config.AskCommandConfig{}
+	alice := &addressee{UserID: 42, FirstName: "Alice.W", EncodedID: "abc123"}
+
+	assert.Empty(t, cmd.addresseeMention(alice), "Mention is disabled")
+
+	cmd.cmdCfg.Display.MentionAddressee = true
+	assert.Empty(t, cmd.addresseeMention(nil))
+
+	tg.EXPECT().EscapeText("Alice.W").Return("Alice\\.W")
+	assert.Equal(t, "[Alice\\.W](tg://user?id=42)", cmd.addresseeMention(alice))
+}
+
+func TestMessageBuilder_WithMention(t *testing.T) {
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+
+	tg := telegram.NewMockClient(t)
+	tg.EXPECT().TelegramifyMarkdown("hello").Return("hello", nil)
+
+	response := NewResponse()
+	response.Content = "hello"
+	text := NewMessageBuilder(tg, localizer).
+		SetResponse(response).
+		WithContext(false).
+		WithMetadata(config.MetadataModeOff).
+		WithMention("[Alice](tg://user?id=42)").
+		Build()
+
+	assert.Equal(t, "[Alice](tg://user?id=42)\nhello"+BotMessageMarker, text)
+}
@@ -0,0 +1,164 @@
+package ask
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const (
+	// longer answers aren't compared, the diff table grows as the product of their lengths
+	maxDiffWords = 2000
+	// unchanged words kept around the changes, longer unchanged runs are shortened
+	diffContextWords = 5
+)
+
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffInsert
+	diffDelete
+)
+
+// diffOp is a run of words that are kept, added or removed
+type diffOp struct {
+	Kind  diffOpKind
+	Words []string
+}
+
+// wordDiff compares the answers word by word with the longest common subsequence,
+// ok is false when one of them is longer than maxDiffWords
+func wordDiff(previous, current string) ([]diffOp, bool) {
+	a, b := strings.Fields(previous), strings.Fields(current)
+	if len(a) > maxDiffWords || len(b) > maxDiffWords {
+		return nil, false
+	}
+
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	add := func(kind diffOpKind, word string) {
+		if len(ops) > 0 && ops[len(ops)-1].Kind == kind {
+			ops[len(ops)-1].Words = append(ops[len(ops)-1].Words, word)
+			return
+		}
+		ops = append(ops, diffOp{Kind: kind, Words: []string{word}})
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			add(diffEqual, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(diffDelete, a[i])
+			i++
+		default:
+			add(diffInsert, b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		add(diffDelete, a[i])
+	}
+	for ; j < len(b); j++ {
+		add(diffInsert, b[j])
+	}
+	return ops, true
+}
+
+// hasChanges reports whether the diff has added or removed words
+func hasChanges(ops []diffOp) bool {
+	for _, op := range ops {
+		if op.Kind != diffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// renderDiff formats the diff in MarkdownV2: removed words are struck through, added ones
+// are underlined, long unchanged runs are shortened to the words around the changes.
+// The text is cut with … to fit maxLength
+func renderDiff(ops []diffOp, escape func(string) string, maxLength int) string {
+	var parts []string
+	for index, op := range ops {
+		text := strings.Join(op.Words, " ")
+		switch op.Kind {
+		case diffDelete:
+			parts = append(parts, "~"+escape(text)+"~")
+		case diffInsert:
+			parts = append(parts, "__"+escape(text)+"__")
+		default:
+			words := op.Words
+			head, tail := diffContextWords, diffContextWords
+			if index == 0 {
+				head = 0
+			}
+			if index == len(ops)-1 {
+				tail = 0
+			}
+			if len(words) > head+tail {
+				shortened := append(slices.Clone(words[:head]), "…")
+				shortened = append(shortened, words[len(words)-tail:]...)
+				text = strings.Join(shortened, " ")
+			}
+			parts = append(parts, escape(text))
+		}
+	}
+
+	var result strings.Builder
+	for _, part := range parts {
+		if result.Len() > 0 && result.Len()+1+len(part) > maxLength-len(" …") {
+			result.WriteString(" …")
+			break
+		}
+		if result.Len() > 0 {
+			result.WriteString(" ")
+		}
+		result.WriteString(part)
+	}
+	return result.String()
+}
+
+// sendAnswerDiff replies to the regenerated answer with the changes compared to the previous one
+func (c *Command) sendAnswerDiff(chatID int64, answerMessageID int, previous, current string) {
+	diffLog := c.Logger.WithFields(logger.Fields{
+		"chat_id":    chatID,
+		"message_id": answerMessageID,
+	})
+	ops, ok := wordDiff(stripReasoning(previous), stripReasoning(current))
+	if !ok {
+		diffLog.Info("Answers are too long to compare, skip diff")
+		return
+	}
+
+	text := c.Tg.EscapeText(c.L("ask.diff.noChanges", nil))
+	if hasChanges(ops) {
+		title := c.L("ask.diff.title", nil)
+		text = title + "\n" + renderDiff(ops, c.Tg.EscapeText, telegramMaxLength-len(title)-1)
+	}
+	msg := telegram.NewMessage(chatID, text, answerMessageID)
+	msg.ParseMode = telegram.ModeMarkdownV2
+	msg.LinkPreviewDisabled = true
+	if _, err := c.Tg.SendWithRetry(msg, 0); err != nil {
+		diffLog.WithError(err).Warn("Failed to send answer diff")
+	}
+}
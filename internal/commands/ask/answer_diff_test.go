@@ -0,0 +1,46 @@
+package ask
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordDiff(t *testing.T) {
+	ops, ok := wordDiff("The cat sat on the mat", "The black cat sat on a mat")
+	require.True(t, ok)
+	assert.Equal(t, []diffOp{
+		{Kind: diffEqual, Words: []string{"The"}},
+		{Kind: diffInsert, Words: []string{"black"}},
+		{Kind: diffEqual, Words: []string{"cat", "sat", "on"}},
+		{Kind: diffDelete, Words: []string{"the"}},
+		{Kind: diffInsert, Words: []string{"a"}},
+		{Kind: diffEqual, Words: []string{"mat"}},
+	}, ops)
+	assert.True(t, hasChanges(ops))
+
+	ops, ok = wordDiff("same  text\nhere", "same text here")
+	require.True(t, ok)
+	assert.False(t, hasChanges(ops), "Whitespace changes are ignored")
+
+	_, ok = wordDiff(strings.Repeat("word ", maxDiffWords+1), "word")
+	assert.False(t, ok, "Too long answers aren't compared")
+}
+
+func TestRenderDiff(t *testing.T) {
+	escape := func(text string) string { return strings.ReplaceAll(text, ".", "\\.") }
+
+	ops, ok := wordDiff("Go is fast.", "Go is very fast.")
+	require.True(t, ok)
+	assert.Equal(t, "Go is __very__ fast\\.", renderDiff(ops, escape, telegramMaxLength))
+
+	ops, ok = wordDiff("old start a b c d e f g h i j k l m n o", "new start a b c d e f g h i j k l m n o")
+	require.True(t, ok)
+	assert.Equal(t, "~old~ __new__ start a b c d …", renderDiff(ops, escape, telegramMaxLength), "Long unchanged runs are shortened")
+
+	ops, ok = wordDiff("a b c", "x y z")
+	require.True(t, ok)
+	assert.Equal(t, "~a b c~ …", renderDiff(ops, escape, 12), "Text is cut to fit the limit")
+}
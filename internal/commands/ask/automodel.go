@@ -0,0 +1,90 @@
+package ask
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/config"
+)
+
+const (
+	defaultAutoModelMinLength = 20000
+	// share of code-like lines for the message to be treated as a code question
+	codeLinesThreshold = 0.4
+	codeMinLines       = 5
+)
+
+var codeLineRegex = regexp.MustCompile(`^\s*(func|def|class|import|package|return|const|let|var|public|private|#include|SELECT|\$)\s|[;{}]\s*$|^\s*(//|/\*|#!)`)
+
+// autoModelName returns the model of the first rule matching the request input
+func autoModelName(rules []config.AutoModelRule, content *MessageContent, media []ai.Content) string {
+	for _, rule := range rules {
+		if rule.Model != "" && matchAutoModelRule(rule, content, media) {
+			return rule.Model
+		}
+	}
+	return ""
+}
+
+func matchAutoModelRule(rule config.AutoModelRule, content *MessageContent, media []ai.Content) bool {
+	switch rule.Input {
+	case config.AutoModelInputImages:
+		return hasMediaType(media, "image_url")
+	case config.AutoModelInputAudio:
+		return hasMediaType(media, "input_audio")
+	case config.AutoModelInputCode:
+		return isCodeHeavy(content.requestText())
+	case config.AutoModelInputLong:
+		minLength := rule.MinLength
+		if minLength <= 0 {
+			minLength = defaultAutoModelMinLength
+		}
+		length := utf8.RuneCountInString(content.requestText())
+		for _, urlContent := range content.URLsContent {
+			length += utf8.RuneCountInString(urlContent)
+		}
+		return length >= minLength
+	}
+	return false
+}
+
+func hasMediaType(media []ai.Content, contentType string) bool {
+	for _, item := range media {
+		if item.Type == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// requestText returns the text written or referenced by the user, without fetched content
+func (mc *MessageContent) requestText() string {
+	parts := []string{mc.Text}
+	if mc.Quote != "" {
+		parts = append(parts, mc.Quote)
+	} else if mc.ReplyMsgContent != nil {
+		parts = append(parts, mc.ReplyMsgContent.Text)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// isCodeHeavy reports whether the text has a code block or mostly consists of code lines
+func isCodeHeavy(text string) bool {
+	if strings.Count(text, "```") >= 2 {
+		return true
+	}
+	lines := 0
+	codeLines := 0
+	for line := range strings.SplitSeq(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines++
+		if codeLineRegex.MatchString(line) {
+			codeLines++
+		}
+	}
+	return lines >= codeMinLines && float64(codeLines) >= float64(lines)*codeLinesThreshold
+}
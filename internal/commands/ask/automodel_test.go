@@ -0,0 +1,75 @@
+package ask
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoModelName(t *testing.T) {
+	rules := []config.AutoModelRule{
+		{Input: config.AutoModelInputAudio, Model: "audio"},
+		{Input: config.AutoModelInputImages, Model: "multi"},
+		{Input: config.AutoModelInputCode, Model: "coder"},
+		{Input: config.AutoModelInputLong, Model: "large", MinLength: 100},
+	}
+	image := ai.Content{Type: "image_url"}
+	audio := ai.Content{Type: "input_audio"}
+	code := "why it doesn't compile?\n```go\nfunc main() {\n}\n```"
+
+	tests := []struct {
+		name    string
+		content *MessageContent
+		media   []ai.Content
+		want    string
+	}{
+		{"plain question", &MessageContent{Text: "what is the weather today?"}, nil, ""},
+		{"images", &MessageContent{Text: "what is it?"}, []ai.Content{image}, "multi"},
+		{"audio", &MessageContent{}, []ai.Content{audio}, "audio"},
+		{"code", &MessageContent{Text: code}, nil, "coder"},
+		{"code in reply", &MessageContent{Text: "explain", ReplyMsgContent: &MessageContent{Text: code}}, nil, "coder"},
+		{"long text", &MessageContent{Text: strings.Repeat("a", 100)}, nil, "large"},
+		{"long url content", &MessageContent{Text: "summarize", URLsContent: map[string]string{"https://example.com": strings.Repeat("a", 100)}}, nil, "large"},
+		{"audio precedes images", &MessageContent{}, []ai.Content{image, audio}, "audio"},
+		{"images precede code", &MessageContent{Text: code}, []ai.Content{image}, "multi"},
+		{"code precedes long", &MessageContent{Text: code + strings.Repeat("\n// comment", 20)}, nil, "coder"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, autoModelName(rules, tt.content, tt.media))
+		})
+	}
+
+	t.Run("default min length", func(t *testing.T) {
+		rules := []config.AutoModelRule{{Input: config.AutoModelInputLong, Model: "large"}}
+		assert.Empty(t, autoModelName(rules, &MessageContent{Text: strings.Repeat("a", 100)}, nil))
+		assert.Equal(t, "large", autoModelName(rules, &MessageContent{Text: strings.Repeat("a", defaultAutoModelMinLength)}, nil))
+	})
+
+	t.Run("unknown input and empty model are ignored", func(t *testing.T) {
+		rules := []config.AutoModelRule{
+			{Input: "video", Model: "video"},
+			{Input: config.AutoModelInputImages},
+		}
+		assert.Empty(t, autoModelName(rules, &MessageContent{}, []ai.Content{image}))
+	})
+}
+
+func TestIsCodeHeavy(t *testing.T) {
+	assert.True(t, isCodeHeavy("look:\n```\nls -la\n```"))
+	assert.True(t, isCodeHeavy(`why does this panic?
+func main() {
+	var m map[string]int
+	m["a"] = 1
+}
+it compiles fine`))
+	assert.False(t, isCodeHeavy("int x = 1;"))
+	assert.False(t, isCodeHeavy(`Dear team,
+please review the attached document.
+It describes the plan for the next quarter.
+Let me know what you think.
+Thanks!`))
+}
@@ -2,9 +2,12 @@ package ask
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"unicode/utf8"
 
+	"github.com/muratoffalex/gachigazer/internal/config"
 	"github.com/muratoffalex/gachigazer/internal/markdown"
 	"github.com/muratoffalex/gachigazer/internal/service"
 	"github.com/muratoffalex/gachigazer/internal/telegram"
@@ -16,6 +19,8 @@ const (
 	promptTitleTelegramify    = "**>_%s:_ "
 	reasoningTitle            = promptTitle
 	reasoningTitleTelegramify = promptTitleTelegramify
+	// the smallest content chunk when splitting a long answer
+	minSplitLength = 500
 )
 
 type MessageBuilder struct {
@@ -71,8 +76,11 @@ func (b *MessageBuilder) WithContext(show bool) *MessageBuilder {
 	return b
 }
 
-func (b *MessageBuilder) WithMetadata(show bool) *MessageBuilder {
-	b.config.ShowMetadata = show
+// WithMetadata sets the metadata display mode: config.MetadataModeFull,
+// config.MetadataModeCompact or config.MetadataModeOff
+func (b *MessageBuilder) WithMetadata(mode string) *MessageBuilder {
+	b.config.ShowMetadata = mode != config.MetadataModeOff
+	b.config.CompactMetadata = mode == config.MetadataModeCompact
 	return b
 }
 
@@ -81,6 +89,27 @@ func (b *MessageBuilder) WithReasoning(show bool) *MessageBuilder {
 	return b
 }
 
+func (b *MessageBuilder) WithSplit(split bool) *MessageBuilder {
+	b.config.SplitLongMessages = split
+	return b
+}
+
+func (b *MessageBuilder) WithTables(render bool) *MessageBuilder {
+	b.config.RenderTables = render
+	return b
+}
+
+func (b *MessageBuilder) WithRaw(raw bool) *MessageBuilder {
+	b.config.Raw = raw
+	return b
+}
+
+// WithMention sets the escaped markdown prepended to the content, ignored in raw mode
+func (b *MessageBuilder) WithMention(mention string) *MessageBuilder {
+	b.config.Mention = mention
+	return b
+}
+
 func (b *MessageBuilder) SetSeparator(sep string) *MessageBuilder {
 	b.config.Separators[SectionContent] = sep
 	return b
@@ -91,11 +120,30 @@ func (b *MessageBuilder) buildContent() (string, error) {
 		return "", nil
 	}
 
-	content, err := b.tg.TelegramifyMarkdown(b.response.Content)
+	content := b.telegramify(b.content())
+	if b.config.Mention != "" && !b.config.Raw {
+		content = b.config.Mention + "\n" + content
+	}
+	return content, nil
+}
+
+// content returns the answer with tables converted to code blocks when enabled
+func (b *MessageBuilder) content() string {
+	if !b.config.RenderTables || b.config.Raw {
+		return b.response.Content
+	}
+	return renderTables(b.response.Content)
+}
+
+func (b *MessageBuilder) telegramify(text string) string {
+	if b.config.Raw {
+		return strings.TrimSpace(b.tg.EscapeText(text))
+	}
+	escaped, err := b.tg.TelegramifyMarkdown(text)
 	if err != nil {
-		content = b.tg.EscapeText(b.response.Content)
+		escaped = b.tg.EscapeText(text)
 	}
-	return strings.TrimSpace(content), nil
+	return strings.TrimSpace(escaped)
 }
 
 func (b *MessageBuilder) buildPrompt() (string, error) {
@@ -137,6 +185,9 @@ func (b *MessageBuilder) buildMetadata() (string, error) {
 	if !b.config.ShowMetadata {
 		return "", nil
 	}
+	if b.config.CompactMetadata {
+		return strings.TrimSpace(b.response.Metadata.GetCompactString()), nil
+	}
 	return strings.TrimSpace(b.response.Metadata.GetFormattedString()), nil
 }
 
@@ -244,7 +295,117 @@ func (b *MessageBuilder) Build() string {
 	return final
 }
 
+// BuildParts builds the answer split into several messages if it doesn't fit
+// into one and splitting is enabled, otherwise it's the same as Build.
+// The prompt and reasoning go to the first part, the context and metadata
+// with BotMessageMarker go to the last one
+func (b *MessageBuilder) BuildParts() []string {
+	if !b.config.SplitLongMessages {
+		return []string{b.Build()}
+	}
+
+	sections := b.buildAllSections()
+	if utf8.RuneCountInString(b.buildWithSections(sections)) <= telegramMaxLength || sections[SectionContent] == "" {
+		return []string{b.Build()}
+	}
+
+	header := make(map[Section]string)
+	footer := make(map[Section]string)
+	contentIndex := slices.Index(b.config.SectionsOrder, SectionContent)
+	for i, section := range b.config.SectionsOrder {
+		content, exists := sections[section]
+		if !exists || section == SectionContent {
+			continue
+		}
+		if i < contentIndex {
+			header[section] = content
+		} else {
+			footer[section] = content
+		}
+	}
+	if reasoning, exists := header[SectionReasoning]; exists && utf8.RuneCountInString(b.joinSections(header)) > telegramMaxLength/2 {
+		if trimmed := b.trimReasoning(reasoning, telegramMaxLength/2-utf8.RuneCountInString(header[SectionPrompt])); trimmed != "" {
+			header[SectionReasoning] = trimmed
+		} else {
+			delete(header, SectionReasoning)
+		}
+	}
+
+	// separators, new lines and the marker
+	const partReserve = 32
+	overhead := max(utf8.RuneCountInString(b.joinSections(header)), utf8.RuneCountInString(b.joinSections(footer)))
+	overhead += utf8.RuneCountInString(markdown.Escape(b.config.Separators[SectionContent])) + partReserve
+	limit := telegramMaxLength - overhead
+	if limit < minSplitLength {
+		return []string{b.Build()}
+	}
+
+	content := b.content()
+	chunks := b.splitContent(content, limit)
+	if len(chunks) == 1 {
+		// the content fits alone, but not together with the header and footer
+		chunks = b.splitContent(content, max(utf8.RuneCountInString(chunks[0])/2+1, minSplitLength))
+	}
+
+	parts := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		partSections := map[Section]string{SectionContent: chunk}
+		if i == 0 {
+			maps.Copy(partSections, header)
+		}
+		if i == len(chunks)-1 {
+			maps.Copy(partSections, footer)
+			parts = append(parts, b.buildWithSections(partSections))
+			continue
+		}
+		parts = append(parts, b.joinSections(partSections))
+	}
+
+	return parts
+}
+
+// splitContent splits the content into telegramified chunks of at most limit runes
+func (b *MessageBuilder) splitContent(content string, limit int) []string {
+	rawLimit := limit
+	for {
+		chunks := splitMarkdown(content, rawLimit)
+		parts := make([]string, len(chunks))
+		fits := true
+		for i, chunk := range chunks {
+			parts[i] = b.telegramify(chunk)
+			fits = fits && utf8.RuneCountInString(parts[i]) <= limit
+		}
+		if fits || rawLimit <= minSplitLength {
+			return parts
+		}
+		// escaping makes the text longer, try smaller chunks
+		rawLimit = max(rawLimit*3/4, minSplitLength)
+	}
+}
+
+// trimReasoning cuts the built reasoning section to maxLength runes,
+// empty if even the title doesn't fit
+func (b *MessageBuilder) trimReasoning(reasoning string, maxLength int) string {
+	runes := []rune(reasoning)
+	if len(runes) <= maxLength {
+		return reasoning
+	}
+	_, title := b.getReasoningTitle()
+	suffix := markdown.Escape("... "+b.l.Localize("ask.maxLengthReached", nil)) + "||"
+	titleLength := utf8.RuneCountInString(title)
+	available := maxLength - titleLength - utf8.RuneCountInString(suffix)
+	if available <= 0 {
+		return ""
+	}
+	// don't leave a dangling escape character
+	return title + strings.TrimRight(string(runes[titleLength:titleLength+available]), "\\") + suffix
+}
+
 func (b *MessageBuilder) buildWithSections(sections map[Section]string) string {
+	return b.joinSections(sections) + BotMessageMarker
+}
+
+func (b *MessageBuilder) joinSections(sections map[Section]string) string {
 	var parts []string
 	for i, section := range b.config.SectionsOrder {
 		if content, exists := sections[section]; exists {
@@ -256,6 +417,5 @@ func (b *MessageBuilder) buildWithSections(sections map[Section]string) string {
 		}
 	}
 
-	final := strings.Join(parts, "\n")
-	return cleanText(final) + BotMessageMarker
+	return cleanText(strings.Join(parts, "\n"))
 }
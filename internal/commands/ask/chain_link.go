@@ -0,0 +1,70 @@
+package ask
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+// t.me/c/<chat>/<message> for private chats, t.me/<username>/<message> for public ones,
+// a topic ID may be between the chat and the message
+var messageLinkRegex = regexp.MustCompile(`(?:https?://)?(?:www\.)?t(?:elegram)?\.me/(?:c/(\d+)|([A-Za-z]\w{3,}))/(?:\d+/)?(\d+)\b`)
+
+// findChatMessageLink returns the first link to a message of the chat in the text
+func findChatMessageLink(text string, msg *telegram.MessageOriginal) (string, int, bool) {
+	for _, match := range messageLinkRegex.FindAllStringSubmatch(text, -1) {
+		chatID, username := match[1], match[2]
+		switch {
+		case chatID != "":
+			if !msg.Chat.IsSuperGroup() || chatID != strconv.FormatInt(getChatID(msg), 10) {
+				continue
+			}
+		case msg.Chat.UserName == "" || !strings.EqualFold(username, msg.Chat.UserName):
+			continue
+		}
+		messageID, err := strconv.Atoi(match[3])
+		if err != nil {
+			continue
+		}
+		return match[0], messageID, true
+	}
+	return "", 0, false
+}
+
+// resolveChainLink turns a pasted link to a message of the conversation into $id,
+// links to other messages are left in the text as context
+func (c *Command) resolveChainLink(msg *telegram.MessageOriginal, content *MessageContent) {
+	if _, exists := content.Args["id"]; exists {
+		return
+	}
+	link, messageID, ok := findChatMessageLink(content.Text, msg)
+	if !ok {
+		return
+	}
+	log := c.Logger.WithFields(logger.Fields{
+		"chat_id":         msg.Chat.ID,
+		"link_message_id": messageID,
+	})
+
+	if _, err := c.getMessageFromHistory(msg.Chat.ID, int64(messageID)); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.WithError(err).Warn("Failed to check linked message in history")
+		}
+		log.Debug("Linked message is not in conversation history, keep it as context")
+		return
+	}
+
+	content.Args["id"] = strconv.Itoa(messageID)
+	content.Text = strings.TrimSpace(strings.Replace(content.Text, link, "", 1))
+	for url := range content.URLs {
+		if strings.HasSuffix(url, strings.TrimPrefix(strings.TrimPrefix(link, "https://"), "http://")) {
+			delete(content.URLs, url)
+		}
+	}
+	log.Info("Continue chain from message link")
+}
@@ -0,0 +1,41 @@
+package ask
+
+import (
+	"testing"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindChatMessageLink(t *testing.T) {
+	supergroup := &tgbotapi.Message{Chat: tgbotapi.Chat{ID: -1001234567890, Type: "supergroup"}}
+	public := &tgbotapi.Message{Chat: tgbotapi.Chat{ID: -1009876543210, Type: "supergroup", UserName: "gachichat"}}
+	private := &tgbotapi.Message{Chat: tgbotapi.Chat{ID: 1234567890, Type: "private"}}
+
+	tests := []struct {
+		name      string
+		text      string
+		msg       *tgbotapi.Message
+		link      string
+		messageID int
+		found     bool
+	}{
+		{"private supergroup", "continue https://t.me/c/1234567890/42 please", supergroup, "https://t.me/c/1234567890/42", 42, true},
+		{"topic", "t.me/c/1234567890/7/43", supergroup, "t.me/c/1234567890/7/43", 43, true},
+		{"public chat", "https://t.me/GachiChat/44", public, "https://t.me/GachiChat/44", 44, true},
+		{"public chat by id", "https://t.me/c/9876543210/45", public, "https://t.me/c/9876543210/45", 45, true},
+		{"other chat", "https://t.me/c/1111111111/42", supergroup, "", 0, false},
+		{"other public chat", "https://t.me/durov/42", public, "", 0, false},
+		{"private chat", "https://t.me/c/1234567890/42", private, "", 0, false},
+		{"first matching link", "https://t.me/durov/1 and https://t.me/gachichat/46", public, "https://t.me/gachichat/46", 46, true},
+		{"no link", "just text", supergroup, "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, messageID, found := findChatMessageLink(tt.text, tt.msg)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.link, link)
+			assert.Equal(t, tt.messageID, messageID)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package ask
+
+import (
+	"fmt"
+
+	"github.com/muratoffalex/gachigazer/internal/ai/tools"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/markdown"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+// chartArgs converts the make_chart arguments decoded from JSON, values of other
+// types are skipped and caught by the validation of the tool
+func chartArgs(args map[string]any) ([]string, []tools.ChartSeries) {
+	rawLabels, _ := args["labels"].([]any)
+	labels := make([]string, 0, len(rawLabels))
+	for _, label := range rawLabels {
+		switch label := label.(type) {
+		case string:
+			labels = append(labels, label)
+		case float64:
+			labels = append(labels, fmt.Sprint(label))
+		}
+	}
+
+	rawSeries, _ := args["series"].([]any)
+	series := make([]tools.ChartSeries, 0, len(rawSeries))
+	for _, raw := range rawSeries {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := item["name"].(string)
+		rawValues, _ := item["values"].([]any)
+		values := make([]float64, 0, len(rawValues))
+		for _, value := range rawValues {
+			if value, ok := value.(float64); ok {
+				values = append(values, value)
+			}
+		}
+		series = append(series, tools.ChartSeries{Name: name, Values: values})
+	}
+	return labels, series
+}
+
+// sendChart sends the chart rendered by make_chart as a photo, saved with the message
+// so a reply to the chart brings it into the context
+func (c *Command) sendChart(assistantMessage *conversationMessage, data []byte, title string, toolLog logger.Logger) {
+	images, err := generatedImageContent(data, c.cmdCfg.Images.MaxDimension)
+	if err != nil {
+		toolLog.WithError(err).Warn("Chart can't be saved to context")
+	}
+	caption := BotMessageMarker
+	if title != "" {
+		caption = "*" + markdown.Escape(title) + "*" + BotMessageMarker
+	}
+	photo := telegram.NewPhotoMessage(
+		assistantMessage.ChatID,
+		telegram.FileBytes{Name: "chart.png", Bytes: data},
+		caption,
+		assistantMessage.MessageID,
+	)
+	photo.ParseMode = telegram.ModeMarkdownV2
+	c.sendGeneratedImageMessage(assistantMessage, photo, images, toolLog)
+}
@@ -0,0 +1,34 @@
+package ask
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChartArgs(t *testing.T) {
+	var args map[string]any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "line",
+		"labels": ["Q1", 2026, true],
+		"series": [
+			{"name": "Revenue", "values": [1.5, "2", 3]},
+			"invalid",
+			{"values": [4]}
+		]
+	}`), &args))
+
+	labels, series := chartArgs(args)
+	assert.Equal(t, []string{"Q1", "2026"}, labels)
+	assert.Equal(t, []tools.ChartSeries{
+		{Name: "Revenue", Values: []float64{1.5, 3}},
+		{Values: []float64{4}},
+	}, series)
+
+	labels, series = chartArgs(map[string]any{})
+	assert.Empty(t, labels)
+	assert.Empty(t, series)
+}
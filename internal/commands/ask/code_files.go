@@ -0,0 +1,162 @@
+package ask
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const (
+	codeFileCallback = "codefile"
+	// more long code blocks get no buttons, a large keyboard is rejected by telegram
+	maxCodeFileButtons = 5
+)
+
+// fenced code block with an optional language, the closing fence starts a line
+var fencedCodeBlockRegex = regexp.MustCompile("(?ms)^[ \\t]*```([^\\s`]*)[^\\n]*\\n(.*?)^[ \\t]*```")
+
+var codeFileExtensions = map[string]string{
+	"go":         "go",
+	"golang":     "go",
+	"python":     "py",
+	"py":         "py",
+	"javascript": "js",
+	"js":         "js",
+	"jsx":        "jsx",
+	"typescript": "ts",
+	"ts":         "ts",
+	"tsx":        "tsx",
+	"bash":       "sh",
+	"sh":         "sh",
+	"shell":      "sh",
+	"zsh":        "sh",
+	"powershell": "ps1",
+	"json":       "json",
+	"yaml":       "yaml",
+	"yml":        "yaml",
+	"toml":       "toml",
+	"xml":        "xml",
+	"html":       "html",
+	"css":        "css",
+	"scss":       "scss",
+	"sql":        "sql",
+	"rust":       "rs",
+	"rs":         "rs",
+	"java":       "java",
+	"kotlin":     "kt",
+	"kt":         "kt",
+	"swift":      "swift",
+	"c":          "c",
+	"cpp":        "cpp",
+	"c++":        "cpp",
+	"csharp":     "cs",
+	"cs":         "cs",
+	"c#":         "cs",
+	"php":        "php",
+	"ruby":       "rb",
+	"rb":         "rb",
+	"lua":        "lua",
+	"dart":       "dart",
+	"markdown":   "md",
+	"md":         "md",
+	"dockerfile": "dockerfile",
+}
+
+type codeBlock struct {
+	Language string
+	Code     string
+}
+
+// longCodeBlocks returns the fenced code blocks of the answer with at least minLines lines
+func longCodeBlocks(text string, minLines int) []codeBlock {
+	blocks := []codeBlock{}
+	for _, match := range fencedCodeBlockRegex.FindAllStringSubmatch(text, -1) {
+		code := strings.TrimRight(match[2], "\n")
+		if strings.TrimSpace(code) == "" || strings.Count(code, "\n")+1 < minLines {
+			continue
+		}
+		blocks = append(blocks, codeBlock{Language: strings.ToLower(match[1]), Code: code})
+	}
+	return blocks
+}
+
+// codeFileName names the file by the fence language, unknown languages are sent as .txt
+func codeFileName(block codeBlock, number int) string {
+	extension, ok := codeFileExtensions[block.Language]
+	if !ok {
+		extension = "txt"
+	}
+	return fmt.Sprintf("code_%d.%s", number, extension)
+}
+
+// codeFileButtons creates a "download code" button per long code block of the answer
+func (c *Command) codeFileButtons(blocks []codeBlock, botMessageID int) [][]telegram.InlineKeyboardButton {
+	buttonRows := [][]telegram.InlineKeyboardButton{}
+	if len(blocks) > maxCodeFileButtons {
+		blocks = blocks[:maxCodeFileButtons]
+	}
+	for i, block := range blocks {
+		text := ai.FileModality + " " + c.L("ask.codeFileButtonText", nil)
+		if len(blocks) > 1 {
+			text += fmt.Sprintf(" %d", i+1)
+		}
+		if block.Language != "" {
+			text += fmt.Sprintf(" (%s)", block.Language)
+		}
+		buttonRows = append(buttonRows, []telegram.InlineKeyboardButton{
+			telegram.NewInlineKeyboardButtonData(text, fmt.Sprintf("%s %s:%d:%d", CommandName, codeFileCallback, botMessageID, i)),
+		})
+	}
+	return buttonRows
+}
+
+func isCodeFileCallback(data string) bool {
+	return strings.HasPrefix(data, CommandName+" "+codeFileCallback+":")
+}
+
+// handleCodeFileCallback sends the code block of the answer as a file replying to the button message
+func (c *Command) handleCodeFileCallback(update telegram.Update) error {
+	callback := update.CallbackQuery
+	chatID := callback.Message.Chat.ID
+
+	parts := strings.Split(strings.TrimPrefix(callback.Data, CommandName+" "+codeFileCallback+":"), ":")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid code file callback data: %s", callback.Data)
+	}
+	messageID, errMsg := strconv.Atoi(parts[0])
+	index, errIndex := strconv.Atoi(parts[1])
+	if err := errors.Join(errMsg, errIndex); err != nil {
+		return fmt.Errorf("invalid code file callback data: %w", err)
+	}
+
+	answer, err := c.getMessageFromHistory(chatID, int64(messageID))
+	if err != nil {
+		return fmt.Errorf("get answer from history: %w", err)
+	}
+	// reasoning kept in the history isn't shown in the answer
+	content, _ := ai.HandleContentReasoning(answer.Text)
+	blocks := longCodeBlocks(content, c.cmdCfg.Display.CodeFileMinLines)
+	if index < 0 || index >= len(blocks) {
+		c.Logger.WithFields(logger.Fields{
+			"chat_id":        chatID,
+			"bot_message_id": messageID,
+			"index":          index,
+		}).Warn("Code block of the answer not found")
+		return nil
+	}
+
+	msg := telegram.NewDocumentMessage(
+		chatID,
+		telegram.FileBytes{Name: codeFileName(blocks[index], index+1), Bytes: []byte(blocks[index].Code + "\n")},
+		"",
+		callback.Message.MessageID,
+	)
+	_, err = c.Tg.Send(msg)
+	return err
+}
@@ -0,0 +1,69 @@
+package ask
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLongCodeBlocks(t *testing.T) {
+	long := strings.TrimSuffix(strings.Repeat("fmt.Println(1)\n", 5), "\n")
+	text := "Here is the code:\n```go\n" + long + "\n```\nAnd a short one:\n```\nls -la\n```\n" +
+		"Script:\n  ```Python title=\"main.py\"\n" + long + "\n  ```\nInline ```not a block``` here"
+
+	blocks := longCodeBlocks(text, 5)
+	require.Len(t, blocks, 2)
+	assert.Equal(t, codeBlock{Language: "go", Code: long}, blocks[0])
+	assert.Equal(t, "python", blocks[1].Language)
+
+	assert.Len(t, longCodeBlocks(text, 1), 3, "Short blocks are included with a lower threshold")
+	assert.Empty(t, longCodeBlocks(text, 6))
+	assert.Empty(t, longCodeBlocks("```go\n\n\n```", 1), "Empty blocks are skipped")
+}
+
+func TestCodeFileName(t *testing.T) {
+	assert.Equal(t, "code_1.go", codeFileName(codeBlock{Language: "go"}, 1))
+	assert.Equal(t, "code_2.py", codeFileName(codeBlock{Language: "python"}, 2))
+	assert.Equal(t, "code_1.cpp", codeFileName(codeBlock{Language: "c++"}, 1))
+	assert.Equal(t, "code_3.txt", codeFileName(codeBlock{Language: "brainfuck"}, 3))
+	assert.Equal(t, "code_1.txt", codeFileName(codeBlock{}, 1))
+}
+
+func TestCommand_codeFileButtons(t *testing.T) {
+	cmd := newInfoTestCommand(t, telegram.NewMockClient(t))
+
+	rows := cmd.codeFileButtons([]codeBlock{{Language: "go"}}, 20)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "📄 Download code (go)", rows[0][0].Text)
+	assert.Equal(t, "ask codefile:20:0", *rows[0][0].CallbackData)
+
+	blocks := make([]codeBlock, maxCodeFileButtons+2)
+	rows = cmd.codeFileButtons(blocks, 20)
+	require.Len(t, rows, maxCodeFileButtons)
+	assert.Equal(t, "📄 Download code 2", rows[1][0].Text)
+	assert.Equal(t, "ask codefile:20:1", *rows[1][0].CallbackData)
+}
+
+func TestCommand_handleCodeFileCallback(t *testing.T) {
+	t.Run("is code file callback", func(t *testing.T) {
+		assert.True(t, isCodeFileCallback("ask codefile:20:0"))
+		assert.False(t, isCodeFileCallback("ask rawdata:20:1"))
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		cmd := newInfoTestCommand(t, telegram.NewMockClient(t))
+		newUpdate := func(data string) telegram.Update {
+			return telegram.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+				Data:    data,
+				Message: &tgbotapi.Message{MessageID: 30, Chat: tgbotapi.Chat{ID: 100}},
+			}}
+		}
+
+		require.Error(t, cmd.handleCodeFileCallback(newUpdate("ask codefile:x:0")))
+		require.Error(t, cmd.handleCodeFileCallback(newUpdate("ask codefile:20")))
+	})
+}
@@ -1,8 +1,11 @@
 package ask
 
 import (
+	"cmp"
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
-	"errors"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -33,6 +36,8 @@ type URLInfo struct {
 	Error          string    `json:"error"`
 	RetryCount     int       `json:"retry_count"`
 	TrimmedContent string    `json:"trimmed_content"`
+	Content        string    `json:"content,omitzero"` // full content, only set when it's longer than the preview
+	CanonicalURL   string    `json:"canonical_url,omitzero"`
 }
 
 func (s *URLInfo) IsUnprocessed() bool {
@@ -124,6 +129,10 @@ type prompt struct {
 	Text    string
 	Name    string
 	Dynamic bool
+	// overrides the base system instructions with the bot persona
+	System string
+	// used instead of the chat model unless $m is set
+	Model string
 }
 
 type userInfo struct {
@@ -155,10 +164,17 @@ type MessageContent struct {
 	ImageURLs                 []string
 	FileURLs                  []string
 	Media                     []ai.Content
+	ImageTexts                []string // text extracted from images for models without image recognition
+	TextFiles                 []textFile
 	HistoryMedia              []ai.Content
 	Command                   string
 	Args                      map[string]string
 	Quote                     string
+	QuoteOnly                 bool
+	Raw                       bool
+	SystemPromptOverride      string   // set for the chat by /info prompt set
+	UserFacts                 []string // remembered facts about the user, only in chats with memory
+	Addressee                 *addressee
 	Prompt                    prompt
 	Context                   []string
 	UserInfo                  userInfo
@@ -171,15 +187,21 @@ type MessageContent struct {
 	ConversationHistoryLength int
 }
 
-func (mc *MessageContent) GetAllMedia(cfg *config.AskCommandConfig, args *CommandArgs) []ai.Content {
+// GetAllMedia returns the media of the message and the history, image limits of the model
+// are used, the global ones when the model is not known yet
+func (mc *MessageContent) GetAllMedia(cfg *config.AskCommandConfig, args *CommandArgs, model *ai.ModelInfo) []ai.Content {
 	media := mc.Media
 
 	if len(mc.HistoryMedia) > 0 {
 		media = append(media, mc.HistoryMedia...)
 	} else {
-		availableImagesInHistory := cfg.Images.Max - len(mc.GetImagesMedia())
+		images := cfg.Images
+		if model != nil {
+			images = images.ForModel(model.FullName(), model.Alias)
+		}
+		availableImagesInHistory := images.Max - len(mc.GetImagesMedia())
 		availableAudioInHistory := cfg.Audio.MaxInHistory - len(mc.GetAudioMedia())
-		imageLifetime := cfg.Images.Lifetime
+		imageLifetime := images.Lifetime
 		historyMedia := []ai.Content{}
 		for _, item := range mc.ConversationHistory {
 			if availableImagesInHistory > 0 && args.HandleImages && (imageLifetime == 0 || time.Now().Before(item.CreatedAt.Add(imageLifetime))) {
@@ -208,7 +230,40 @@ func (mc *MessageContent) GetAllMedia(cfg *config.AskCommandConfig, args *Comman
 		media = append(media, historyMedia...)
 	}
 
-	return media
+	return dedupeMedia(media, map[string]bool{})
+}
+
+// mediaKey identifies media by a hash of its URL or inline data
+func mediaKey(item ai.Content) string {
+	var data string
+	switch item.Type {
+	case "image_url":
+		data = item.ImageURL.URL
+	case "file":
+		data = item.File.FileData
+	case "input_audio":
+		data = item.InputAudio.Data
+	default:
+		return ""
+	}
+	sum := sha256.Sum256([]byte(data))
+	return item.Type + ":" + hex.EncodeToString(sum[:])
+}
+
+// dedupeMedia drops media already present in seen and records the rest,
+// media is ordered from the newest so the most recent copy is kept
+func dedupeMedia(media []ai.Content, seen map[string]bool) []ai.Content {
+	result := make([]ai.Content, 0, len(media))
+	for _, item := range media {
+		if key := mediaKey(item); key != "" {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		result = append(result, item)
+	}
+	return result
 }
 
 func (mc *MessageContent) AddConversationHistoryItems(items ...conversationMessage) {
@@ -270,6 +325,17 @@ func (mc *MessageContent) ContextTurnsCount() int {
 	return len(turns) + 1
 }
 
+// chainMessages returns the messages of one question-answer turn
+func chainMessages(messages []conversationMessage, chainID string) []conversationMessage {
+	result := make([]conversationMessage, 0, len(messages))
+	for _, item := range messages {
+		if item.ConversationChainID == chainID {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func (mc *MessageContent) GetLatestConversationMessage() *conversationMessage {
 	if mc.HasHistory() {
 		return &mc.ConversationHistory[0]
@@ -294,6 +360,33 @@ func (mc *MessageContent) GetImagesMedia() []ai.Content {
 	return images
 }
 
+// GetEditableImage returns the last attached image as a base64 data URL,
+// images referenced by http links are skipped. Without attached images the latest
+// image sent by a tool in the history is used, e.g. to edit a generated image in reply
+func (mc *MessageContent) GetEditableImage() string {
+	if image := lastDataImage(mc.GetImagesMedia()); image != "" {
+		return image
+	}
+	for _, item := range mc.ConversationHistory {
+		if !item.Role.IsInternal() {
+			continue
+		}
+		if image := lastDataImage(item.Images); image != "" {
+			return image
+		}
+	}
+	return ""
+}
+
+func lastDataImage(images []ai.Content) string {
+	for i := len(images) - 1; i >= 0; i-- {
+		if images[i].Type == "image_url" && strings.HasPrefix(images[i].ImageURL.URL, "data:image/") {
+			return images[i].ImageURL.URL
+		}
+	}
+	return ""
+}
+
 func (mc *MessageContent) GetFilesMedia() []ai.Content {
 	images := []ai.Content{}
 	for _, item := range mc.Media {
@@ -413,6 +506,46 @@ func (mc *MessageContent) AddFileURLs(urls ...string) {
 	mc.FileURLs = append(mc.FileURLs, urls...)
 }
 
+// mergeCanonicalURLs keeps one processed URL per canonical page, e.g. of links with different
+// tracking params. The URL that appears first in the text is kept for display
+func (mc *MessageContent) mergeCanonicalURLs() []string {
+	groups := map[string][]string{}
+	for url, state := range mc.URLs {
+		if !state.IsProcessed() {
+			continue
+		}
+		key := state.CanonicalURL
+		if key == "" {
+			key = url
+		}
+		groups[key] = append(groups[key], url)
+	}
+
+	var merged []string
+	for _, urls := range groups {
+		if len(urls) < 2 {
+			continue
+		}
+		slices.SortFunc(urls, func(a, b string) int {
+			return cmp.Or(cmp.Compare(mc.textPosition(a), mc.textPosition(b)), strings.Compare(a, b))
+		})
+		for _, url := range urls[1:] {
+			delete(mc.URLs, url)
+			delete(mc.URLsContent, url)
+			merged = append(merged, url)
+		}
+	}
+	return merged
+}
+
+// textPosition returns the position of the URL in the text, URLs not from the text go last
+func (mc *MessageContent) textPosition(url string) int {
+	if position := strings.Index(mc.Text, url); position >= 0 {
+		return position
+	}
+	return len(mc.Text)
+}
+
 func (mc *MessageContent) GetAllURLs() []string {
 	var items []string
 	for url := range mc.URLs {
@@ -475,6 +608,9 @@ func (mc *MessageContent) GetTextForTitleGenerating() string {
 }
 
 func (mc *MessageContent) GetMessageContent() string {
+	if mc.Raw {
+		return mc.getRawMessageContent()
+	}
 	request := []string{}
 	now := time.Now()
 	if mc.Summary != "" {
@@ -500,9 +636,10 @@ func (mc *MessageContent) GetMessageContent() string {
 			replyHeader += formatForwardOrigin(fo)
 		}
 
-		replyHeader += fmt.Sprintf(" @%s]\n%s",
-			now.Format("Jan02 15:04"),
-			replyMsg.Text)
+		replyHeader += fmt.Sprintf(" @%s]", now.Format("Jan02 15:04"))
+		if !mc.QuoteOnly {
+			replyHeader += "\n" + replyMsg.Text
+		}
 
 		request = append(request, replyHeader)
 	}
@@ -530,6 +667,12 @@ func (mc *MessageContent) GetMessageContent() string {
 			finalText += "\n\n[CONTENT from " + url + "]:\n" + content
 		}
 	}
+	for _, text := range mc.ImageTexts {
+		finalText += "\n\n[IMAGE TEXT]\n" + text
+	}
+	for _, file := range mc.TextFiles {
+		finalText += "\n\n[FILE: " + file.Name + "]\n" + file.Content
+	}
 
 	request = append(request, finalText)
 
@@ -538,6 +681,19 @@ func (mc *MessageContent) GetMessageContent() string {
 	return finalContent
 }
 
+// getRawMessageContent returns the text of the replied message and the user's
+// text as is, without technical markers
+func (mc *MessageContent) getRawMessageContent() string {
+	request := []string{}
+	if replyMsg := mc.ReplyMsgContent; replyMsg != nil && replyMsg.Text != "" {
+		request = append(request, replyMsg.Text)
+	}
+	if mc.Text != "" {
+		request = append(request, mc.Text)
+	}
+	return strings.TrimSpace(strings.Join(request, "\n\n"))
+}
+
 type MessageContext struct {
 	ChatID    int64
 	MessageID int
@@ -570,6 +726,17 @@ func (c *Command) ExtractMessageContent(msg *telegram.MessageOriginal, isCurrent
 
 	// Extract media files
 	content.Media = c.extractMediaFromMessage(msg)
+	if file, ok := c.extractTextFile(msg); ok {
+		content.TextFiles = append(content.TextFiles, file)
+	}
+
+	// images pasted as base64 data URLs
+	text, images, errs := extractInlineImages(content.Text, c.cmdCfg.Images.MaxDimension)
+	for _, err := range errs {
+		c.Logger.WithError(err).Warn("Skip invalid inline image")
+	}
+	content.Text = text
+	content.AddMedia(images...)
 
 	return content
 }
@@ -641,16 +808,26 @@ func (c *Command) filterURLs(urls []string) ([]string, []string, []string) {
 	var imageURLs []string
 	var fileURLs []string
 	var filteredURLs []string
-	// Filter image URLs
+	// Filter image URLs, only images and files are checked with HEAD requests
+	var checkedURLs []string
 	for _, url := range urls {
-		isImg, imgErr := telegram.IsAvailableImageURL(url)
-		isFile, fileErr := telegram.IsAvailableFileURL(url)
-		if isImg && imgErr == nil {
+		if telegram.IsImageURL(url) || telegram.IsFileURL(url) {
+			checkedURLs = append(checkedURLs, url)
+		} else {
+			filteredURLs = append(filteredURLs, url)
+		}
+	}
+	available, errs := c.checkURLs(context.Background(), checkedURLs)
+	for i, url := range checkedURLs {
+		switch {
+		case errs[i] != nil:
+			// unreachable hosts and timeouts are skipped
+		case !available[i]:
+			filteredURLs = append(filteredURLs, url)
+		case telegram.IsImageURL(url):
 			imageURLs = append(imageURLs, url)
-		} else if isFile && fileErr == nil {
+		default:
 			fileURLs = append(fileURLs, url)
-		} else if !isImg && !isFile {
-			filteredURLs = append(filteredURLs, url)
 		}
 	}
 
@@ -677,13 +854,14 @@ func (c *Command) postProcessURLs(urls []string) []string {
 			continue
 		}
 
-		if strings.Contains(u, ".gz") ||
+		// music hosts are metadata only, artist subdomains may look like archives (e.g. x.rarebird.bandcamp.com)
+		if !fetcher.IsMusicURL(u) && (strings.Contains(u, ".gz") ||
 			strings.Contains(u, ".tar") ||
 			strings.Contains(u, ".zip") ||
 			strings.Contains(u, ".rar") ||
 			strings.Contains(u, ".7z") ||
 			strings.Contains(u, ".exe") ||
-			strings.Contains(u, ".apk") {
+			strings.Contains(u, ".apk")) {
 			continue
 		}
 
@@ -720,6 +898,10 @@ func (c *Command) postProcessURLs(urls []string) []string {
 			parsed.RawQuery = ""
 		}
 
+		if parsed.Host == "m.soundcloud.com" {
+			parsed.Host = "soundcloud.com"
+		}
+
 		if strings.HasPrefix(parsed.Host, "telegram.me") {
 			parsed.Host = "t.me"
 		}
@@ -752,7 +934,7 @@ func (c *Command) extractMediaFromMessage(msg *telegram.MessageOriginal) []ai.Co
 	if len(msg.Photo) > 0 {
 		photo := msg.Photo[len(msg.Photo)-1]
 		if fileURL, err := c.Tg.GetFileURL(photo.FileID); err == nil {
-			if content, err := createImageContentFromTelegram(fileURL); err == nil {
+			if content, err := createImageContentFromTelegram(fileURL, c.cmdCfg.Images.MaxDimension); err == nil {
 				media = append(media, content)
 			} else {
 				c.Logger.WithError(err).Error("Error creating image content from telegram")
@@ -865,24 +1047,21 @@ func fileToBase64(data []byte) string {
 	return base64.StdEncoding.EncodeToString(data)
 }
 
-func createImageContentFromTelegram(url string) (ai.Content, error) {
+func createImageContentFromTelegram(url string, maxDimension int) (ai.Content, error) {
 	data, err := downloadFile(url)
 	if err != nil {
 		return ai.Content{}, err
 	}
 
-	var mimeType string
-	switch {
-	case len(data) > 8 && string(data[0:8]) == "\x89PNG\r\n\x1a\n":
-		mimeType = "png"
-	case len(data) > 2 && string(data[0:2]) == "\xff\xd8":
-		mimeType = "jpeg"
-	case len(data) > 4 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
-		mimeType = "webp"
-	default:
-		return ai.Content{}, errors.New("unsupported image type")
+	mimeType, err := detectImageType(data)
+	if err != nil {
+		return ai.Content{}, err
 	}
 
+	data, err = downscaleImage(data, maxDimension)
+	if err != nil {
+		return ai.Content{}, err
+	}
 	base64Str := fileToBase64(data)
 	return createImageContent("data:image/" + mimeType + ";base64," + base64Str), nil
 }
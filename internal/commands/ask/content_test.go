@@ -0,0 +1,322 @@
+package ask
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func imageContent(url string) ai.Content {
+	content := ai.Content{Type: "image_url"}
+	content.ImageURL.URL = url
+	return content
+}
+
+func TestMessageContent_GetEditableImage(t *testing.T) {
+	t.Run("no images", func(t *testing.T) {
+		mc := &MessageContent{Media: []ai.Content{{Type: "text", Text: "hello"}}}
+		assert.Empty(t, mc.GetEditableImage())
+	})
+
+	t.Run("last data url image", func(t *testing.T) {
+		mc := &MessageContent{Media: []ai.Content{
+			imageContent("data:image/png;base64,Zmlyc3Q="),
+			imageContent("data:image/jpeg;base64,c2Vjb25k"),
+			imageContent("https://example.com/image.jpg"),
+		}}
+		assert.Equal(t, "data:image/jpeg;base64,c2Vjb25k", mc.GetEditableImage())
+	})
+
+	t.Run("only http images", func(t *testing.T) {
+		mc := &MessageContent{Media: []ai.Content{imageContent("https://example.com/image.jpg")}}
+		assert.Empty(t, mc.GetEditableImage())
+	})
+
+	t.Run("image sent by tool in history", func(t *testing.T) {
+		mc := &MessageContent{ConversationHistory: []conversationMessage{
+			{Role: ai.RoleInternal, Images: []ai.Content{imageContent("data:image/png;base64,Z2VuZXJhdGVk")}},
+			{Role: ai.RoleUser, Images: []ai.Content{imageContent("data:image/png;base64,dXNlcg==")}},
+		}}
+		assert.Equal(t, "data:image/png;base64,Z2VuZXJhdGVk", mc.GetEditableImage())
+
+		mc.Media = []ai.Content{imageContent("data:image/jpeg;base64,YXR0YWNoZWQ=")}
+		assert.Equal(t, "data:image/jpeg;base64,YXR0YWNoZWQ=", mc.GetEditableImage(), "Attached image wins")
+	})
+}
+
+func fileContent(name, data string) ai.Content {
+	content := ai.Content{Type: "file"}
+	content.File.Filename = name
+	content.File.FileData = data
+	return content
+}
+
+func audioContent(data string) ai.Content {
+	content := ai.Content{Type: "input_audio"}
+	content.InputAudio.Data = data
+	content.InputAudio.Format = "audio/mpeg"
+	return content
+}
+
+func TestDedupeMedia(t *testing.T) {
+	seen := map[string]bool{}
+	media := dedupeMedia([]ai.Content{
+		imageContent("data:image/png;base64,Zmlyc3Q="),
+		{Type: "text", Text: "hello"},
+		imageContent("data:image/png;base64,Zmlyc3Q="),
+		imageContent("https://example.com/image.jpg"),
+		{Type: "text", Text: "hello"},
+	}, seen)
+	assert.Equal(t, []ai.Content{
+		imageContent("data:image/png;base64,Zmlyc3Q="),
+		{Type: "text", Text: "hello"},
+		imageContent("https://example.com/image.jpg"),
+		{Type: "text", Text: "hello"},
+	}, media)
+
+	// seen is shared between calls
+	assert.Empty(t, dedupeMedia([]ai.Content{imageContent("https://example.com/image.jpg")}, seen))
+}
+
+func TestCommand_buildPromptWithHistory_PromptSystem(t *testing.T) {
+	model := &ai.ModelInfo{ID: "main", Provider: "test"}
+	cmd := newToolsModelTestCommand(t, &CommandArgs{})
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Tools.Enabled = false
+
+	messages := cmd.buildPromptWithHistory(model, &MessageContent{Text: "hi"}, cmd.args, false)
+	assert.Contains(t, messages[0].Text, "Gachigazer", "Default persona is kept")
+
+	content := &MessageContent{
+		Text:   "fix the bug",
+		Prompt: prompt{Name: "code", System: "You are a senior engineer. Today is {{date}}, answer in {{language}}."},
+	}
+	messages = cmd.buildPromptWithHistory(model, content, cmd.args, false)
+	system := messages[0].Text
+	assert.True(t, strings.HasPrefix(system, fmt.Sprintf(
+		"You are a senior engineer. Today is %s, answer in %s.",
+		time.Now().Format("Monday, 02 January 2006"),
+		cmd.Cfg.AI().Language,
+	)), system)
+	assert.NotContains(t, system, "Gachigazer")
+	assert.Contains(t, system, "[User request message format]", "Technical notes are kept")
+}
+
+func TestCommand_buildPromptWithHistory_PromptSystemReply(t *testing.T) {
+	model := &ai.ModelInfo{ID: "main", Provider: "test"}
+	cmd := newToolsModelTestCommand(t, &CommandArgs{})
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Tools.Enabled = false
+
+	engineer := "You are a senior engineer."
+	answer := conversationMessage{
+		ConversationChainID: "code",
+		Role:                ai.RoleAssistant,
+		Text:                "Use a mutex",
+		Params:              &ai.ModelParams{System: &engineer},
+	}
+	// a reply to the answer of /code is sent with the "a" command and without a prompt
+	content := &MessageContent{Text: "and without a mutex?", ConversationHistory: []conversationMessage{answer}}
+	content.Prompt.System = chainSystemPrompt(content.Prompt, content.GetLatestConversationMessage())
+	messages := cmd.buildPromptWithHistory(model, content, cmd.args, false)
+	assert.True(t, strings.HasPrefix(messages[0].Text, engineer), messages[0].Text)
+	assert.NotContains(t, messages[0].Text, "Gachigazer")
+
+	assert.Equal(t, "You are a pirate.", chainSystemPrompt(prompt{Name: "pirate", System: "You are a pirate."}, &answer), "Prompt of the reply has priority")
+	assert.Empty(t, chainSystemPrompt(prompt{Name: "summary"}, &answer), "Prompt without override resets it")
+	assert.Empty(t, chainSystemPrompt(prompt{}, &conversationMessage{Params: &ai.ModelParams{}}))
+	assert.Empty(t, chainSystemPrompt(prompt{}, nil))
+}
+
+func TestCommand_buildPromptWithHistory_InternalImages(t *testing.T) {
+	model := &ai.ModelInfo{ID: "main", Provider: "test", Architecture: &ai.ModelArchitecture{
+		InputModalities: []string{"text", "image"},
+	}}
+	cmd := newToolsModelTestCommand(t, &CommandArgs{HandleImages: true})
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Tools.Enabled = false
+
+	generated := imageContent("data:image/png;base64,Z2VuZXJhdGVk")
+	content := &MessageContent{
+		Text: "make it darker",
+		// the user replied to the generated image
+		ConversationHistory: []conversationMessage{
+			{Role: ai.RoleInternal, CreatedAt: time.Now(), Images: []ai.Content{generated}},
+			{Role: ai.RoleAssistant, Text: "Here is your cat", CreatedAt: time.Now()},
+			{Role: ai.RoleInternal, CreatedAt: time.Now()},
+		},
+	}
+
+	messages := cmd.buildPromptWithHistory(model, content, cmd.args, false)
+	require.Len(t, messages, 4)
+	assert.Equal(t, ai.RoleAssistant, messages[1].Role)
+	assert.Equal(t, ai.RoleUser, messages[2].Role)
+	assert.Equal(t, []ai.Content{{Type: "text", Text: "[Image sent to the chat by the tool]"}, generated}, messages[2].Content)
+
+	cmd.args.HandleImages = false
+	messages = cmd.buildPromptWithHistory(model, content, cmd.args, false)
+	assert.Len(t, messages, 3, "Images are not handled")
+}
+
+func TestCommand_buildPromptWithHistory_DedupesMedia(t *testing.T) {
+	model := &ai.ModelInfo{ID: "main", Provider: "test", Architecture: &ai.ModelArchitecture{
+		InputModalities: []string{"text", "image", "file", "audio"},
+	}}
+	cmd := newToolsModelTestCommand(t, &CommandArgs{HandleImages: true, HandleFiles: true, HandleAudio: true})
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Tools.Enabled = false
+	cmd.cmdCfg.Audio.MaxInHistory = 5
+
+	image := imageContent("data:image/png;base64,c2FtZQ==")
+	file := fileContent("doc.pdf", "data:application/pdf;base64,ZG9j")
+	audio := audioContent("YXVkaW8=")
+	content := &MessageContent{
+		Text:  "the same again",
+		Media: []ai.Content{image, image, file, audio},
+		// history is ordered from the newest message
+		ConversationHistory: []conversationMessage{
+			{Role: ai.RoleAssistant, Text: "nice picture", CreatedAt: time.Now()},
+			{
+				Role:      ai.RoleUser,
+				Text:      "look",
+				CreatedAt: time.Now(),
+				Images:    []ai.Content{image, imageContent("https://example.com/other.jpg")},
+				Files:     []ai.Content{file},
+				Audio:     []ai.Content{audio},
+			},
+		},
+	}
+
+	messages := cmd.buildPromptWithHistory(model, content, cmd.args, false)
+	counts := map[string]int{}
+	for _, message := range messages {
+		for _, item := range message.Content {
+			if key := mediaKey(item); key != "" {
+				counts[key]++
+			}
+		}
+	}
+	assert.Equal(t, map[string]int{
+		mediaKey(image): 1,
+		mediaKey(imageContent("https://example.com/other.jpg")): 1,
+		mediaKey(file):  1,
+		mediaKey(audio): 1,
+	}, counts)
+
+	// the most recent copy is kept in the user message
+	userMessage := messages[len(messages)-1]
+	assert.Contains(t, userMessage.Content, image)
+	assert.Contains(t, userMessage.Content, file)
+}
+
+func TestChainMessages(t *testing.T) {
+	history := []conversationMessage{
+		{ID: 4, ConversationChainID: "current", Role: ai.RoleAssistant},
+		{ID: 3, ConversationChainID: "current", Role: ai.RoleUser},
+		{ID: 2, ConversationChainID: "previous", Role: ai.RoleAssistant},
+		{ID: 1, ConversationChainID: "previous", Role: ai.RoleUser},
+	}
+
+	messages := chainMessages(history, "current")
+	assert.Equal(t, []conversationMessage{history[0], history[1]}, messages)
+	assert.Empty(t, chainMessages(history, "other"))
+
+	// history of a $noctx turn isn't loaded, it counts as a single turn
+	assert.Equal(t, 1, (&MessageContent{}).ContextTurnsCount())
+}
+
+func TestCommand_contextHistory_NoContext(t *testing.T) {
+	const chatID = int64(-100)
+	cmd := newFallbackTestCommand(t, "[telegram]\ntoken = \"token\"\n\n[database]\ndsn = \"test.db\"\n")
+	db, err := database.NewSQLiteDB(cmd.Cfg, logger.NewTestLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	cmd.db = db
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Tools.Enabled = false
+
+	// the previous turn and the current request answered with a tool call
+	for _, msg := range []conversationMessage{
+		{ConversationChainID: "previous", MessageID: 1, Role: ai.RoleUser, Text: "what is the capital of France?"},
+		{ConversationChainID: "previous", MessageID: 2, ReplyToMessageID: sql.NullInt64{Int64: 1, Valid: true}, Role: ai.RoleAssistant, Text: "Paris"},
+		{ConversationChainID: "current", MessageID: 3, ReplyToMessageID: sql.NullInt64{Int64: 2, Valid: true}, Role: ai.RoleUser, Text: "what's the weather there?"},
+		{ConversationChainID: "current", MessageID: 4, ReplyToMessageID: sql.NullInt64{Int64: 3, Valid: true}, Role: ai.RoleAssistant, Text: "Checking the weather"},
+	} {
+		msg.ChatID = chatID
+		msg.ConversationID = 1
+		_, err := cmd.saveMessage(&msg)
+		require.NoError(t, err)
+	}
+	model := &ai.ModelInfo{ID: "main", Provider: "test"}
+	promptTexts := func(history []conversationMessage) string {
+		var texts []string
+		for _, message := range cmd.buildPromptWithHistory(model, &MessageContent{ConversationHistory: history}, cmd.args, true) {
+			texts = append(texts, message.Text)
+		}
+		return strings.Join(texts, "\n")
+	}
+
+	cmd.args = &CommandArgs{}
+	history, err := cmd.contextHistory(chatID, 4, "current")
+	require.NoError(t, err)
+	assert.Len(t, history, 4)
+	assert.Contains(t, promptTexts(history), "Paris")
+
+	cmd.args = &CommandArgs{NoContext: true}
+	history, err = cmd.contextHistory(chatID, 4, "current")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	prompt := promptTexts(history)
+	assert.NotContains(t, prompt, "capital of France")
+	assert.NotContains(t, prompt, "Paris")
+	assert.Contains(t, prompt, "what's the weather there?")
+}
+
+func TestCommand_buildPromptWithHistory_ImagesModelOverride(t *testing.T) {
+	multimodal := &ai.ModelArchitecture{InputModalities: []string{"text", "image"}}
+	model := &ai.ModelInfo{ID: "main", Provider: "test", Architecture: multimodal}
+	pricey := &ai.ModelInfo{ID: "vision", Provider: "test", Alias: "pricey", Architecture: multimodal}
+	cmd := newToolsModelTestCommand(t, &CommandArgs{HandleImages: true})
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Tools.Enabled = false
+	cmd.cmdCfg.Images.Max = 5
+	cmd.cmdCfg.Images.Lifetime = time.Hour
+	shortLifetime := time.Minute
+	cmd.cmdCfg.Images.Overrides = []config.AskImagesOverride{{Model: "pricey", Lifetime: &shortLifetime}}
+
+	image := imageContent("https://example.com/old.jpg")
+	newContent := func() *MessageContent {
+		return &MessageContent{
+			Text: "what about the picture",
+			ConversationHistory: []conversationMessage{
+				{Role: ai.RoleAssistant, Text: "a cat", CreatedAt: time.Now().Add(-9 * time.Minute)},
+				{Role: ai.RoleUser, Text: "look", CreatedAt: time.Now().Add(-10 * time.Minute), Images: []ai.Content{image}},
+			},
+		}
+	}
+	hasImage := func(messages []ai.Message) bool {
+		for _, message := range messages {
+			for _, item := range message.Content {
+				if mediaKey(item) == mediaKey(image) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	assert.True(t, hasImage(cmd.buildPromptWithHistory(model, newContent(), cmd.args, false)), "Global lifetime keeps the image")
+	assert.Contains(t, newContent().GetAllMedia(cmd.cmdCfg, cmd.args, model), image)
+
+	assert.False(t, hasImage(cmd.buildPromptWithHistory(pricey, newContent(), cmd.args, false)), "Override of the alias drops the image")
+	assert.NotContains(t, newContent().GetAllMedia(cmd.cmdCfg, cmd.args, pricey), image)
+}
@@ -155,9 +155,12 @@ func NewToolConversationMessage(
 	return msg
 }
 
+// NewInternalConversationMessage links a message sent by a tool to the answer, images sent
+// with the message are saved too, so they are in the context of replies to it
 func NewInternalConversationMessage(
 	assistantMessage *conversationMessage,
 	messageID int,
+	images []ai.Content,
 ) *conversationMessage {
 	msg := &conversationMessage{
 		ParentMessageID:     sql.NullInt64{Int64: assistantMessage.ID, Valid: true},
@@ -167,6 +170,7 @@ func NewInternalConversationMessage(
 		ConversationID:      assistantMessage.ConversationID,
 		ConversationChainID: assistantMessage.ConversationChainID,
 		Role:                ai.RoleInternal,
+		Images:              images,
 		IsFirst:             false,
 	}
 	return msg
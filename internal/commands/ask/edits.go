@@ -0,0 +1,97 @@
+package ask
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const editCallback = "edit"
+
+// EditCallbackData returns the callback data of the update the dispatcher
+// passes for the edited message of a user
+func EditCallbackData() string {
+	return CommandName + " " + editCallback
+}
+
+func isEditCallback(data string) bool {
+	return data == EditCallbackData()
+}
+
+// editTooLate reports whether the message was edited later than the window after it was sent
+func editTooLate(msg *telegram.MessageOriginal, window time.Duration) bool {
+	if window <= 0 || msg.EditDate == 0 {
+		return false
+	}
+	return time.Duration(msg.EditDate-int64(msg.Date))*time.Second > window
+}
+
+// handleEdit reruns the request the user edited with the retry callback, the answer
+// is edited with the new one and the chain is kept. Messages without an answer,
+// late edits and requests run max_attempts times are ignored
+func (c *Command) handleEdit(update telegram.Update, parked *parkedRequest) error {
+	edited := update.CallbackQuery.Message
+	chatID := edited.Chat.ID
+	editLog := c.Logger.WithFields(logger.Fields{
+		"chat_id":    chatID,
+		"message_id": edited.MessageID,
+	})
+	editsCfg := c.cmdCfg.Edits
+	if !editsCfg.Enabled {
+		return nil
+	}
+	if editTooLate(edited, editsCfg.Window) {
+		editLog.Debug("Message is edited too late, skip")
+		return nil
+	}
+
+	request, err := c.getMessageFromHistory(chatID, int64(edited.MessageID))
+	if err != nil || !request.Role.IsUser() {
+		editLog.Debug("Edited message isn't a request, skip")
+		return nil
+	}
+	if editsCfg.MaxAttempts > 0 && int(request.AttemptsCount) >= editsCfg.MaxAttempts {
+		editLog.WithField("attempts", request.AttemptsCount).Info("Request is run too many times, skip edit")
+		return nil
+	}
+	answer, err := c.getAnswerFromHistory(chatID, edited.MessageID)
+	if err != nil {
+		editLog.Debug("Edited request has no answer, skip")
+		return nil
+	}
+
+	editLog.WithField("answer_id", answer.MessageID).Info("Rerun edited request")
+	return c.execute(telegram.Update{
+		Message: edited,
+		CallbackQuery: &telegram.CallbackQuery{
+			From:    edited.From,
+			Message: &telegram.MessageOriginal{MessageID: answer.MessageID, Chat: edited.Chat},
+			Data:    fmt.Sprintf("%s retry:%d", CommandName, edited.MessageID),
+		},
+	}, parked)
+}
+
+// getAnswerFromHistory returns the latest answer to the request message
+func (c *Command) getAnswerFromHistory(chatID int64, requestMessageID int) (*conversationMessage, error) {
+	query := `SELECT id, chat_id, parent_message_id, conversation_chain_id, message_id, reply_to_message_id, role, text, conversation_id, is_first, created_at, model_name,
+              prompt_tokens, completion_tokens, total_tokens, total_cost, attempts_count,
+              params, images, files, audio, urls, annotations, tool_calls, tool_responses, tool_name, tool_params
+              FROM conversation_history
+              WHERE chat_id = ? AND reply_to_message_id = ? AND role = 'assistant'
+              ORDER BY id DESC LIMIT 1`
+
+	row := c.db.QueryRow(query, chatID, requestMessageID)
+	return c.mapHistoryMessageToStruct(row)
+}
+
+// updateConversationMessageText saves the text of the edited request, the chain
+// continues with the corrected text
+func (c *Command) updateConversationMessageText(id int64, text string) error {
+	query := `UPDATE conversation_history set text = ? where id = ?`
+
+	_, err := c.db.ExecWithRetry(context.Background(), query, text, id)
+	return err
+}
@@ -0,0 +1,128 @@
+package ask
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsEditCallback(t *testing.T) {
+	assert.True(t, isEditCallback(EditCallbackData()))
+	assert.False(t, isEditCallback("ask retry:10"))
+	assert.False(t, isEditCallback(QuickActionCallbackData("regenerate")))
+}
+
+func TestEditTooLate(t *testing.T) {
+	const sent = 1_700_000_000
+	tests := []struct {
+		name     string
+		editDate int64
+		window   time.Duration
+		want     bool
+	}{
+		{"within window", sent + 60, 10 * time.Minute, false},
+		{"after window", sent + 601, 10 * time.Minute, true},
+		{"no window", sent + 3600, 0, false},
+		{"not edited", 0, time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &telegram.MessageOriginal{Date: sent, EditDate: tt.editDate}
+			assert.Equal(t, tt.want, editTooLate(msg, tt.window))
+		})
+	}
+}
+
+const editsTestChatID = int64(-100)
+
+// newEditsTestCommand returns the command with edits enabled, any message sent to telegram fails the test
+func newEditsTestCommand(t *testing.T, messages ...conversationMessage) *Command {
+	cmd := newFallbackTestCommand(t, "[telegram]\ntoken = \"token\"\n\n[database]\ndsn = \"test.db\"\n")
+	db, err := database.NewSQLiteDB(cmd.Cfg, logger.NewTestLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	cmd.db = db
+	cmd.Tg = telegram.NewMockClient(t)
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Edits.Enabled = true
+	cmd.cmdCfg.Edits.MaxAttempts = 3
+
+	for _, msg := range messages {
+		msg.ChatID = editsTestChatID
+		msg.ConversationChainID = "chain"
+		_, err := cmd.saveMessage(&msg)
+		require.NoError(t, err)
+	}
+	return cmd
+}
+
+func editUpdate(messageID int) telegram.Update {
+	return telegram.Update{CallbackQuery: &telegram.CallbackQuery{
+		From: &tgbotapi.User{ID: 1},
+		Message: &telegram.MessageOriginal{
+			MessageID: messageID,
+			Chat:      tgbotapi.Chat{ID: editsTestChatID},
+			From:      &tgbotapi.User{ID: 1},
+			Text:      "edited request",
+		},
+		Data: EditCallbackData(),
+	}}
+}
+
+func TestCommand_handleEdit_Skipped(t *testing.T) {
+	answered := sql.NullInt64{Int64: 1, Valid: true}
+	tests := []struct {
+		name      string
+		messageID int
+		messages  []conversationMessage
+	}{
+		{"message isn't in history", 1, nil},
+		{"request without answer", 1, []conversationMessage{
+			{MessageID: 1, Role: ai.RoleUser, Text: "request", AttemptsCount: 1},
+		}},
+		{"not a user message", 2, []conversationMessage{
+			{MessageID: 1, Role: ai.RoleUser, Text: "request", AttemptsCount: 1},
+			{MessageID: 2, ReplyToMessageID: answered, Role: ai.RoleAssistant, Text: "answer"},
+		}},
+		{"max attempts reached", 1, []conversationMessage{
+			{MessageID: 1, Role: ai.RoleUser, Text: "request", AttemptsCount: 3},
+			{MessageID: 2, ReplyToMessageID: answered, Role: ai.RoleAssistant, Text: "answer"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newEditsTestCommand(t, tt.messages...)
+
+			assert.NoError(t, cmd.handleEdit(editUpdate(tt.messageID), nil), "Edit is skipped without rerun")
+		})
+	}
+}
+
+func TestCommand_updateConversationMessageText(t *testing.T) {
+	cmd := newEditsTestCommand(t,
+		conversationMessage{MessageID: 1, Role: ai.RoleUser, Text: "request", AttemptsCount: 2},
+		conversationMessage{MessageID: 2, Role: ai.RoleUser, Text: "other request", AttemptsCount: 1},
+	)
+	request, err := cmd.getMessageFromHistory(editsTestChatID, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, cmd.updateConversationMessageText(request.ID, "edited request"))
+
+	edited, err := cmd.getMessageFromHistory(editsTestChatID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "edited request", edited.Text)
+	assert.Equal(t, request.ID, edited.ID)
+	assert.Equal(t, "chain", edited.ConversationChainID, "The chain is kept")
+	assert.EqualValues(t, 2, edited.AttemptsCount)
+	other, err := cmd.getMessageFromHistory(editsTestChatID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "other request", other.Text)
+}
@@ -0,0 +1,123 @@
+package ask
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+// imageLongestSide returns the longest side of the image in pixels and its format,
+// 0 if the format is not supported
+func imageLongestSide(data []byte) (int, string) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, ""
+	}
+	return max(cfg.Width, cfg.Height), format
+}
+
+// generatedImageContent returns the image as a base64 data URL to save it with the sent message,
+// so a reply to the message brings the image back into the context
+func generatedImageContent(data []byte, maxDimension int) ([]ai.Content, error) {
+	imageType, err := detectImageType(data)
+	if err != nil {
+		return nil, err
+	}
+	data, err = downscaleImage(data, maxDimension)
+	if err != nil {
+		return nil, err
+	}
+	return []ai.Content{createImageContent("data:image/" + imageType + ";base64," + fileToBase64(data))}, nil
+}
+
+// sendGeneratedImage sends the image as a photo. Telegram recompresses large photos,
+// so they are also sent as a document and the caption moves to the document
+func (c *Command) sendGeneratedImage(assistantMessage *conversationMessage, data []byte, caption string, toolLog logger.Logger) {
+	images, err := generatedImageContent(data, c.cmdCfg.Images.MaxDimension)
+	if err != nil {
+		toolLog.WithError(err).Warn("Generated image can't be saved to context")
+	}
+	side, format := imageLongestSide(data)
+	minSize := c.cmdCfg.Tools.ImageDocumentMinSize
+	asDocument := minSize > 0 && side > minSize
+
+	photoCaption := caption
+	if asDocument {
+		photoCaption = BotMessageMarker
+	}
+	photo := telegram.NewPhotoMessage(
+		assistantMessage.ChatID,
+		telegram.FileBytes{Name: "generated_image.jpg", Bytes: data},
+		photoCaption,
+		assistantMessage.MessageID,
+	)
+	photo.ParseMode = telegram.ModeMarkdownV2
+	c.sendGeneratedImageMessage(assistantMessage, photo, images, toolLog)
+	if !asDocument {
+		return
+	}
+
+	extension := format
+	if extension == "jpeg" {
+		extension = "jpg"
+	}
+	document := telegram.NewDocumentMessage(
+		assistantMessage.ChatID,
+		telegram.FileBytes{Name: "generated_image." + extension, Bytes: data},
+		caption,
+		assistantMessage.MessageID,
+	)
+	document.ParseMode = telegram.ModeMarkdownV2
+	toolLog.WithField("size", side).Info("Send generated image as document")
+	c.sendGeneratedImageMessage(assistantMessage, document, images, toolLog)
+}
+
+func (c *Command) sendGeneratedImageMessage(assistantMessage *conversationMessage, msg telegram.MessageConfig, images []ai.Content, toolLog logger.Logger) {
+	resp, err := c.Tg.Send(msg)
+	if err != nil {
+		toolLog.WithError(err).Error("Send generated image failed")
+		return
+	}
+	if _, err := c.saveMessage(NewInternalConversationMessage(assistantMessage, resp.MessageID, images)); err != nil {
+		toolLog.WithError(err).Error("Save internal message failed")
+	}
+}
+
+// sendFoundImages sends the images found by search_images as a media group,
+// each photo is saved with its image, so a reply to any of them brings it into the context
+func (c *Command) sendFoundImages(assistantMessage *conversationMessage, images []string, toolLog logger.Logger) {
+	mediaInputs := []telegram.InputMedia{}
+	for _, image := range images {
+		mediaInputs = append(mediaInputs, telegram.NewPhotoMedia(telegram.FileURL(image)))
+	}
+	tgMsg := telegram.NewMediaGroupMessage(assistantMessage.ChatID, mediaInputs)
+	tgMsg.ReplyTo = assistantMessage.MessageID
+	resp, err := c.Tg.Request(tgMsg)
+	if err != nil {
+		toolLog.WithError(err).Error("Send media group failed")
+		return
+	}
+
+	var sent []struct {
+		MessageID int `json:"message_id"`
+	}
+	if err := json.Unmarshal(resp.Result, &sent); err != nil {
+		toolLog.WithError(err).Error("Parse sent media group failed")
+		return
+	}
+	for i, msg := range sent {
+		if i >= len(images) {
+			break
+		}
+		internalMessage := NewInternalConversationMessage(assistantMessage, msg.MessageID, []ai.Content{createImageContent(images[i])})
+		if _, err := c.saveMessage(internalMessage); err != nil {
+			toolLog.WithError(err).Error("Save internal message failed")
+		}
+	}
+}
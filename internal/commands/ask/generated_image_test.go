@@ -0,0 +1,44 @@
+package ask
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageLongestSide(t *testing.T) {
+	var pngData, jpegData bytes.Buffer
+	require.NoError(t, png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 2048, 1024))))
+	require.NoError(t, jpeg.Encode(&jpegData, image.NewRGBA(image.Rect(0, 0, 640, 960)), nil))
+
+	side, format := imageLongestSide(pngData.Bytes())
+	assert.Equal(t, 2048, side)
+	assert.Equal(t, "png", format)
+
+	side, format = imageLongestSide(jpegData.Bytes())
+	assert.Equal(t, 960, side)
+	assert.Equal(t, "jpeg", format)
+
+	side, format = imageLongestSide([]byte("not an image"))
+	assert.Zero(t, side)
+	assert.Empty(t, format)
+}
+
+func TestGeneratedImageContent(t *testing.T) {
+	data := newTestImage(t, "png", 40, 20)
+
+	images, err := generatedImageContent(data, 10)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.True(t, strings.HasPrefix(images[0].ImageURL.URL, "data:image/png;base64,"))
+	assert.NotEqual(t, "data:image/png;base64,"+fileToBase64(data), images[0].ImageURL.URL, "Image is downscaled")
+
+	_, err = generatedImageContent([]byte("not an image"), 10)
+	assert.ErrorIs(t, err, errUnsupportedImageType)
+}
@@ -21,12 +21,14 @@ import (
 	"github.com/muratoffalex/gachigazer/internal/ai"
 	"github.com/muratoffalex/gachigazer/internal/ai/tools"
 	"github.com/muratoffalex/gachigazer/internal/app/di"
+	"github.com/muratoffalex/gachigazer/internal/cache"
 	"github.com/muratoffalex/gachigazer/internal/commands/base"
 	"github.com/muratoffalex/gachigazer/internal/config"
 	"github.com/muratoffalex/gachigazer/internal/database"
 	fetch "github.com/muratoffalex/gachigazer/internal/fetcher"
 	"github.com/muratoffalex/gachigazer/internal/logger"
 	"github.com/muratoffalex/gachigazer/internal/markdown"
+	"github.com/muratoffalex/gachigazer/internal/metrics"
 	"github.com/muratoffalex/gachigazer/internal/service"
 	"github.com/muratoffalex/gachigazer/internal/telegram"
 )
@@ -34,6 +36,25 @@ import (
 const (
 	CommandName      = "ask"
 	BotMessageMarker = "\u200B"
+	maxTitleLength   = 40
+	// system prompt for $raw mode instead of the default instructions
+	rawSystemInstructions = "You are a helpful assistant. Answer the user's message directly."
+	// used when model_params.timeout is not set anywhere
+	defaultRequestTimeout = 3 * time.Minute
+	// delay before retrying a failed request when the provider doesn't set Retry-After
+	defaultRetryDelay = time.Second
+	// Retry-After of the provider is capped, so a long value can't stall the request
+	maxRetryDelay = 20 * time.Second
+	// telegram limit for callback data of inline buttons, in bytes
+	maxCallbackDataLength = 64
+	// streamed messages are edited often at the start and less often as they grow,
+	// long answers hit the telegram rate limit with frequent edits
+	minStreamEditInterval = 700 * time.Millisecond
+	maxStreamEditInterval = 5 * time.Second
+	// the interval grows by a second for each streamEditGrowthLength bytes of the message
+	streamEditGrowthLength = 1000
+	// edits that add less than this number of bytes are skipped
+	minStreamEditDelta = 16
 )
 
 type Argument struct {
@@ -54,11 +75,32 @@ type Command struct {
 	db            database.Database
 	supportedArgs []Argument
 	fetcher       *fetch.Manager
+	cache         cache.Cache
 	httpClient    *http.Client
 	retryCount    int
 	args          *CommandArgs
 	cmdCfg        *config.AskCommandConfig
 	toolsRunner   *tools.Tools
+	logRedactor   *logger.Redactor
+	metrics       *metrics.Metrics
+	inflight      *inflightRequests
+}
+
+// requestState is the state of a single request. The queue workers share the command,
+// so it's passed along with the request instead of being stored on the command
+type requestState struct {
+	// the chat is switched to free models only with /settings
+	freeOnly bool
+	// facts about users are remembered in the chat, switched on with /settings
+	memory bool
+	// mechanism of $search for the request: web plugin, search tool or none
+	search string
+	// chat model replaced by the multimodal auto switch, used when the multimodal model fails
+	multimodalFallback *ai.ModelInfo
+	// fallbacks of the requested model left to try, it's nil until the first fallback
+	fallbackChain []string
+	// images are inlined after the provider failed to download them, it's done once
+	imagesInlined bool
 }
 
 func (c *Command) Name() string {
@@ -66,7 +108,7 @@ func (c *Command) Name() string {
 }
 
 func (c *Command) Aliases() []string {
-	aliases := []string{"ai", "a", "info", "tools", "new", "help"}
+	aliases := []string{"ai", "a", "info", "tools", "new", "help", importCommand, exportCommand, cancelCommand}
 	aliases = append(aliases, c.Cfg.AI().GetAllCommands()...)
 	return aliases
 }
@@ -79,9 +121,12 @@ func New(di *di.Container) *Command {
 	toolsRunner := tools.NewTools(di.HttpClient, di.Fetcher, di.YtService, di.Logger)
 	cmd := &Command{
 		fetcher:     di.Fetcher,
+		cache:       di.Cache,
 		httpClient:  di.HttpClient,
 		cmdCfg:      di.Cfg.GetAskCommandConfig(),
 		toolsRunner: toolsRunner,
+		metrics:     di.Metrics,
+		inflight:    newInflightRequests(),
 		supportedArgs: []Argument{
 			{
 				Name:        "m",
@@ -97,7 +142,7 @@ func New(di *di.Container) *Command {
 			},
 			{
 				Name:         "search",
-				Description:  "Internet search with openrouter engine",
+				Description:  "Internet search: the web plugin of the provider when supported (OpenRouter), otherwise the search tool",
 				Type:         "bool",
 				DefaultValue: "no",
 			},
@@ -163,6 +208,12 @@ func New(di *di.Container) *Command {
 				Type:        "string",
 				Values:      []string{"Tool list in format: `$tools:search,second_tool`, or just `$tools` to send all tools"},
 			},
+			{
+				Name:        "toolsmodel",
+				Description: "Model for tools handling in this request (overrides ai.tools_model)",
+				Type:        "string",
+				Values:      []string{"model name (e.g. `$toolsmodel:or:openai/gpt-4o-mini`) or alias"},
+			},
 			{
 				Name:        "think",
 				Description: "Use thinking model (alias for $m:think)",
@@ -202,6 +253,36 @@ func New(di *di.Container) *Command {
 				Description: "Get response as stream (default: yes)",
 				Type:        "bool",
 			},
+			{
+				Name:        "len",
+				Description: "Answer length, persists in subsequent messages",
+				Type:        "string",
+				Values:      []string{LengthShort, LengthMedium, LengthLong, "word count (e.g. `$len:150`)"},
+			},
+			{
+				Name:        "effort",
+				Description: "Reasoning effort for OpenAI-style reasoning models, persists in subsequent messages",
+				Type:        "string",
+				Values:      effortValues,
+			},
+			{
+				Name:        "rtokens",
+				Description: "Reasoning tokens budget for Anthropic-style reasoning models, has priority over $effort",
+				Type:        "int",
+				Min:         ptr(1.0),
+			},
+			{
+				Name:        "seed",
+				Description: "Sampling seed for reproducible answers, kept for the whole chain. Ignored by models without seed support",
+				Type:        "int",
+				Min:         ptr(0.0),
+			},
+			{
+				Name:        "route",
+				Description: "OpenRouter provider routing: sort strategy or provider to pin, persists in subsequent messages",
+				Type:        "string",
+				Values:      append(slices.Clone(ai.OpenRouterSortValues), "provider slug (e.g. `anthropic`)"),
+			},
 			{
 				Name:        "p",
 				Description: "Prompt",
@@ -213,9 +294,45 @@ func New(di *di.Container) *Command {
 				Description: "Create conversation summary and start new one based on it",
 				Type:        "bool",
 			},
+			{
+				Name:        "noctx",
+				Description: "Ignore conversation history for this message, it's still saved into the chain unlike $new",
+				Type:        "bool",
+			},
+			{
+				Name:        "quoteonly",
+				Description: "Answer only about the quoted fragment of the replied message",
+				Type:        "bool",
+			},
+			{
+				Name:        "raw",
+				Description: "Send only the message text without system instructions, tools are disabled and the answer is shown verbatim",
+				Type:        "bool",
+			},
+			{
+				Name:        "voice",
+				Description: "Also send the answer as a voice message",
+				Type:        "bool",
+			},
+			{
+				Name:        "compact",
+				Description: "Show the model and the cost of the answer in one line instead of the full metadata",
+				Type:        "bool",
+			},
+			{
+				Name:        "diff",
+				Description: "Show what changed compared to the previous answer when the answer is regenerated",
+				Type:        "bool",
+			},
+			{
+				Name:        "at",
+				Description: "Address the answer to a participant of the chat, the participant is mentioned in the answer",
+				Type:        "string",
+				Values:      []string{"`@username` of a chat participant"},
+			},
 			{
 				Name:        "id",
-				Description: "Continue message chain with id. Example: $id:123456",
+				Description: "Continue message chain with id. Example: $id:123456. A pasted link to a message of this chat works the same way",
 				Type:        "int",
 				Values:      []string{"message id to continue chain from"},
 			},
@@ -224,12 +341,28 @@ func New(di *di.Container) *Command {
 	cmd.Command = base.NewCommand(cmd, di)
 	cmd.ai = di.AI
 	cmd.db = di.DB
+	redactor, err := logger.NewRedactor(di.Cfg.Log().RedactPatterns)
+	if err != nil {
+		di.Logger.WithError(err).Warn("Invalid log redact patterns")
+	}
+	cmd.logRedactor = redactor
+	warnSearchUnavailable(di.AI, *cmd.cmdCfg, di.Logger)
 	return cmd
 }
 
 func (c *Command) Execute(update telegram.Update) error {
+	return c.execute(update, c.inflight.take(requestKey(update)))
+}
+
+// execute runs the request of the update. The parked request waited for the previous ones before
+// it was queued, it's run instead of registering the request again and the reruns of reactions
+// and edits take it over
+func (c *Command) execute(update telegram.Update, parked *parkedRequest) error {
 	chainID := uuid.New()
 	ctx := context.Background()
+	if parked != nil {
+		defer parked.finish()
+	}
 
 	var err error
 	var editedMessage int
@@ -247,12 +380,38 @@ func (c *Command) Execute(update telegram.Update) error {
 	c.Logger = c.Logger.WithField("message_id", messageID)
 	replyToMessageID := int64(0)
 
+	if callback := update.CallbackQuery; callback != nil && isURLContentCallback(callback.Data) {
+		return c.handleURLContentCallback(update)
+	}
+	if callback := update.CallbackQuery; callback != nil && isRawDataCallback(callback.Data) {
+		return c.handleRawDataCallback(update)
+	}
+	if callback := update.CallbackQuery; callback != nil && isCodeFileCallback(callback.Data) {
+		return c.handleCodeFileCallback(update)
+	}
+	if callback := update.CallbackQuery; callback != nil {
+		if action, ok := parseQuickActionCallback(callback.Data); ok {
+			return c.handleQuickAction(update, action, parked)
+		}
+	}
+	if callback := update.CallbackQuery; callback != nil && isEditCallback(callback.Data) {
+		return c.handleEdit(update, parked)
+	}
+
 	var attempt uint8
 	var historyMessage *conversationMessage
+	var retryModel string
+	// the answer replaced by the retry, compared with the new one in the diff mode
+	var previousAnswer *conversationMessage
 	toolFromCallback := false
 	if callback := update.CallbackQuery; callback != nil {
 		if strings.Contains(callback.Data, "retry:") {
 			editedMessage = callback.Message.MessageID
+			// retry with another model after the content policy refusal
+			_, retryModel, _ = strings.Cut(callback.Data, " $m:")
+			if answer, err := c.getMessageFromHistory(msg.Chat.ID, int64(editedMessage)); err == nil && answer.Role.IsAssistant() {
+				previousAnswer = answer
+			}
 			historyMessage, err = c.getMessageFromHistory(msg.Chat.ID, int64(msg.MessageID))
 			if err != nil {
 				if errors.Is(err, sql.ErrNoRows) {
@@ -289,10 +448,22 @@ func (c *Command) Execute(update telegram.Update) error {
 	encodedUserID := c.getUserPublicID(userID)
 
 	currentContent := c.ExtractMessageContent(msg, true)
+	if retryModel != "" {
+		currentContent.Args["m"] = retryModel
+	}
 	command := currentContent.Command
 	switch command {
 	case "info":
+		if action, value, ok := parseSystemPromptCommand(msg.Text); ok {
+			return c.handleSystemPromptCommand(msg, action, value)
+		}
 		return c.handleInfoCommand(update)
+	case importCommand:
+		return c.handleImportCommand(ctx, msg)
+	case exportCommand:
+		return c.handleExportCommand(msg)
+	case cancelCommand:
+		return c.handleCancelCommand(msg)
 	case "new":
 		command = "a"
 		currentContent.Args["new"] = "yes"
@@ -303,6 +474,27 @@ func (c *Command) Execute(update telegram.Update) error {
 		command = "a"
 		currentContent.Args["p"] = "help"
 	}
+	requesterID := userID
+	if callback := update.CallbackQuery; callback != nil && callback.From != nil {
+		requesterID = callback.From.ID
+	}
+	if parked != nil {
+		ctx = parked.ctx
+	} else {
+		var finish func()
+		ctx, finish, err = c.inflight.start(ctx, chatID, requesterID, c.cmdCfg.Dedup.Policy, c.cmdCfg.Dedup.PerUser)
+		if err != nil {
+			c.Logger.WithField("chat_id", chatID).Info("Request canceled while waiting for the previous one")
+			return nil
+		}
+		defer finish()
+	}
+	if ctx.Err() != nil {
+		c.Logger.WithField("chat_id", chatID).Info("Request canceled before it was run")
+		return nil
+	}
+	c.resolveChainLink(msg, currentContent)
+	currentContent.SystemPromptOverride = c.systemPromptOverride(chatID)
 	if !c.cmdCfg.Tools.Enabled {
 		delete(currentContent.Args, "tools")
 	} else if _, exists := currentContent.Args["tools"]; !exists && c.cmdCfg.Tools.AutoRun {
@@ -313,6 +505,11 @@ func (c *Command) Execute(update telegram.Update) error {
 		currentContent.Text = currentContent.Text + "\n" + err.Error()
 		c.Logger.WithError(err).Error("Map args to struct error, add error text in message text")
 	}
+	if c.args.Raw {
+		// tool calls and their instructions don't fit the verbatim answer
+		c.args.Tools = ""
+		currentContent.Raw = true
+	}
 
 	c.sendTypingMessage(chatID)
 
@@ -320,31 +517,6 @@ func (c *Command) Execute(update telegram.Update) error {
 		"args": currentContent.Args,
 	}).Debug("Parsed arguments")
 
-	model, err := c.ChatService.GetCurrentModelForChat(ctx, chatID, userID, c.args.Model)
-	if err != nil || (!model.IsFree() && !c.Cfg.Telegram().IsAllowed(userID, chatID)) {
-		modelName := c.args.Model
-		if model != nil {
-			modelName = model.FullName()
-		}
-		c.Logger.WithError(err).WithFields(logger.Fields{
-			"model": modelName,
-		}).Error("Failed to get a model for LLM")
-		modelName = c.Tg.EscapeText(modelName)
-		text := c.L("ask.modelNotAvailable", map[string]any{
-			"ModelName": modelName,
-		})
-		_, errSend := c.sendOrEditMessage(chatID, messageID, editedMessage, text, &telegram.TextMessage{
-			ParseMode: telegram.ModeMarkdownV2,
-		})
-		if errSend != nil {
-			c.Logger.WithError(err).WithFields(logger.Fields{
-				"chatID": chatID,
-				"text":   text,
-			}).Error("Failed to send a message")
-		}
-		return err
-	}
-
 	if command == "" || !slices.Contains(c.Aliases(), command) {
 		command = "a"
 	}
@@ -359,51 +531,29 @@ func (c *Command) Execute(update telegram.Update) error {
 			Text:    aiPrompt.Text,
 			Name:    aiPrompt.Name,
 			Dynamic: aiPrompt.DynamicPrompt,
+			System:  aiPrompt.SystemPrompt,
+			Model:   aiPrompt.Model,
 		}
 	} else if aiPrompt, exists := c.Cfg.AI().GetPromptByCommand(command); exists {
 		currentContent.Prompt = prompt{
 			Text:    aiPrompt.Text,
 			Name:    aiPrompt.Name,
 			Dynamic: aiPrompt.DynamicPrompt,
+			System:  aiPrompt.SystemPrompt,
+			Model:   aiPrompt.Model,
 		}
 	}
 
-	var dynamicPrompt string
-	if prompt := currentContent.Prompt; prompt.Name != "" {
-		if prompt.Dynamic {
-			cleanedText, extractedPattern := extractAndRemovePattern(currentContent.Text)
-			currentContent.Text = cleanedText
-			sentMsgID, err := c.sendOrEditMessage(
-				chatID,
-				messageID,
-				editedMessage,
-				c.L("ask.generatingPerson", nil),
-				nil,
-			)
-			if err != nil {
-				c.Logger.WithError(err).Error("Failed to send generating person message")
-				_, _ = c.sendOrEditMessage(
-					chatID,
-					messageID,
-					editedMessage,
-					c.L("ask.errorSendMessage", nil),
-					nil,
-				)
-				return err
-			}
-			editedMessage = sentMsgID
-			dynamicPrompt, err = c.generate(ctx, prompt.Text, extractedPattern, chatID)
-			if err != nil {
-				return err
-			}
-			currentContent.Prompt.Text = dynamicPrompt
-		}
+	request := &requestState{
+		freeOnly: c.ChatService.IsFreeOnly(chatID),
+		memory:   c.ChatService.IsMemoryEnabled(chatID),
+	}
+	if request.memory {
+		currentContent.UserFacts = c.userFacts(userID)
+	}
+	if c.args.At != "" {
+		currentContent.Addressee = c.resolveAddressee(chatID, c.args.At)
 	}
-
-	// TODO: mb move thinking message there?
-
-	currentContent.UserInfo.Name = msg.From.FirstName
-	currentContent.UserInfo.EncodedID = encodedUserID
 
 	// Determine the primary text content and the message ID to potentially fetch history from
 	historyStartMessageID := int64(0)
@@ -414,6 +564,12 @@ func (c *Command) Execute(update telegram.Update) error {
 		replyToMessageID = int64(replyMsg.MessageID)
 		// get history if reply to bot message, not user
 		if msg.ReplyToMessage.From.ID == c.Tg.Self().ID {
+			// a part of a split answer is stored under its first message
+			if firstMessageID, err := c.db.GetFirstMessagePart(chatID, replyMsg.MessageID); err != nil {
+				c.Logger.WithError(err).Warn("Failed to get first message of split answer")
+			} else {
+				replyToMessageID = int64(firstMessageID)
+			}
 			historyStartMessageID = replyToMessageID
 		}
 	}
@@ -437,7 +593,9 @@ func (c *Command) Execute(update telegram.Update) error {
 	// --- Fetch Conversation History ---
 	totalUsage := &MetadataUsage{}
 	var preprocessUsage *MetadataUsage
-	if latestMessage != nil {
+	if latestMessage != nil && c.args.NoContext {
+		c.Logger.WithField("latest_message_id", latestMessage.ID).Info("Conversation history skipped")
+	} else if latestMessage != nil {
 		var conversationHistory []conversationMessage
 		c.Logger.WithFields(logger.Fields{
 			"chat_id":    chatID,
@@ -483,6 +641,101 @@ func (c *Command) Execute(update telegram.Update) error {
 		}
 	}
 
+	// before the model is resolved because the default prompt can pin it
+	c.applyDefaultPrompt(currentContent)
+
+	modelName := requestModelName(c.args, currentContent.Prompt)
+	model, err := c.ChatService.GetCurrentModelForChat(ctx, chatID, userID, modelName)
+	if err == nil && c.args.Model != "" && c.Cfg.AI().IsModelBlocked(model.FullName()) {
+		if c.args.Think || c.args.Multi || c.args.Fast || c.args.RF {
+			c.Logger.WithField("model", model.FullName()).Warn("Model of the alias is blocked, use chat model")
+			c.args.Model = ""
+			modelName = currentContent.Prompt.Model
+			model, err = c.ChatService.GetCurrentModelForChat(ctx, chatID, userID, modelName)
+		} else {
+			c.Logger.WithField("model", model.FullName()).Warn("Blocked model is requested explicitly")
+		}
+	}
+	if err != nil || (!model.IsFree() && !c.Cfg.Telegram().IsAllowed(userID, chatID)) {
+		if model != nil {
+			modelName = model.FullName()
+		}
+		return c.sendModelNotAvailable(chatID, messageID, editedMessage, modelName, err)
+	}
+
+	if c.args.ToolsModel != "" {
+		toolsModel, err := c.ai.GetFormattedModel(ctx, c.args.ToolsModel, "")
+		if err == nil && !toolsModel.IsFree() && request.freeOnly {
+			err = service.ErrPaidModelsDisabled
+		}
+		if err != nil || (!toolsModel.IsFree() && !c.Cfg.Telegram().IsAllowed(userID, chatID)) {
+			modelName := c.args.ToolsModel
+			if toolsModel != nil {
+				modelName = toolsModel.FullName()
+			}
+			return c.sendModelNotAvailable(chatID, messageID, editedMessage, modelName, err)
+		}
+	}
+
+	if limit := c.Cfg.AI().DailyCostLimit; limit > 0 && !model.IsFree() {
+		spent, err := c.db.GetChatDailyCost(chatID)
+		if err != nil {
+			c.Logger.WithError(err).Error("Failed to get daily cost for chat")
+		} else if spent >= limit {
+			c.Logger.WithFields(logger.Fields{
+				"chat_id": chatID,
+				"spent":   spent,
+				"limit":   limit,
+			}).Warn("Daily cost limit exceeded")
+			currencyConfig := c.ChatService.GetChatCurrency(chatID)
+			text := c.L("ask.dailyCostLimitExceeded", map[string]any{
+				"Spent": markdown.Escape(service.FormatCost(spent, &currencyConfig)),
+				"Limit": markdown.Escape(service.FormatCost(limit, &currencyConfig)),
+			})
+			_, err = c.sendOrEditMessage(chatID, messageID, editedMessage, text, &telegram.TextMessage{
+				ParseMode: telegram.ModeMarkdownV2,
+			})
+			return err
+		}
+	}
+
+	var dynamicPrompt string
+	if prompt := currentContent.Prompt; prompt.Name != "" {
+		if prompt.Dynamic {
+			cleanedText, extractedPattern := extractAndRemovePattern(currentContent.Text)
+			currentContent.Text = cleanedText
+			sentMsgID, err := c.sendOrEditMessage(
+				chatID,
+				messageID,
+				editedMessage,
+				c.L("ask.generatingPerson", nil),
+				nil,
+			)
+			if err != nil {
+				c.Logger.WithError(err).Error("Failed to send generating person message")
+				_, _ = c.sendOrEditMessage(
+					chatID,
+					messageID,
+					editedMessage,
+					c.L("ask.errorSendMessage", nil),
+					nil,
+				)
+				return err
+			}
+			editedMessage = sentMsgID
+			dynamicPrompt, err = c.generate(ctx, prompt.Text, extractedPattern, chatID)
+			if err != nil {
+				return err
+			}
+			currentContent.Prompt.Text = dynamicPrompt
+		}
+	}
+
+	// TODO: mb move thinking message there?
+
+	currentContent.UserInfo.Name = msg.From.FirstName
+	currentContent.UserInfo.EncodedID = encodedUserID
+
 	var additionalContext []telegram.Update
 	if additionalContextArg := c.args.Context; additionalContextArg != "" {
 		count, duration, username, contextMessageID, _ := parseAdditionalContextArg(additionalContextArg)
@@ -508,6 +761,8 @@ func (c *Command) Execute(update telegram.Update) error {
 		}
 		additionalContext = msgs
 		if len(msgs) > 0 {
+			contextLines := make([]string, 0, len(msgs))
+			contextContents := make([]*MessageContent, 0, len(msgs))
 			for _, m := range msgs {
 				msg := m.Message
 				messageContent := c.ExtractMessageContent(msg, false)
@@ -532,7 +787,31 @@ func (c *Command) Execute(update telegram.Update) error {
 					}
 				}
 				contextLine += ": " + metadata + messageContent.Text
-				currentContent.Context = append(currentContent.Context, contextLine)
+				contextLines = append(contextLines, contextLine)
+				contextContents = append(contextContents, messageContent)
+			}
+
+			var summarize func(string) (string, error)
+			if c.cmdCfg.AdditionalContext.Summarize {
+				summarize = func(text string) (string, error) {
+					return c.summarize(ctx, text, chatID)
+				}
+			}
+			first, lines := limitAdditionalContext(
+				contextLines,
+				c.cmdCfg.AdditionalContext.MaxMessages,
+				c.cmdCfg.AdditionalContext.MaxLength,
+				summarize,
+			)
+			if first > 0 {
+				c.Logger.WithFields(logger.Fields{
+					"chat_id":   chatID,
+					"total":     len(contextLines),
+					"compacted": first,
+				}).Info("Additional context exceeds limits, older messages compacted")
+			}
+			currentContent.Context = append(currentContent.Context, lines...)
+			for _, messageContent := range contextContents[first:] {
 				// TODO: maybe no need to add URLs and images to the main request,
 				// because there can be a lot of them
 				currentContent.Media = append(currentContent.Media, messageContent.Media...)
@@ -542,16 +821,6 @@ func (c *Command) Execute(update telegram.Update) error {
 		}
 	}
 
-	// if this is first message and default prompt exists then use it
-	if currentContent.Prompt.Name == "" && !currentContent.HasHistory() {
-		if aiPrompt, exists := c.Cfg.AI().GetPromptByCommand("default"); exists {
-			currentContent.Prompt = prompt{
-				Text: aiPrompt.Text,
-				Name: aiPrompt.Name,
-			}
-		}
-	}
-
 	if replyMsg != nil {
 		// Auto-detect if replying to bot's Ask completed response (contains BotMessageMarker)
 		isReplyingToAsk := strings.Contains(replyMsg.Text, BotMessageMarker) &&
@@ -571,6 +840,7 @@ func (c *Command) Execute(update telegram.Update) error {
 			currentContent.AddURLsFromMap(replyContent.URLs)
 			currentContent.AddImageURLs(replyContent.ImageURLs...)
 			currentContent.AddFileURLs(replyContent.FileURLs...)
+			currentContent.TextFiles = append(currentContent.TextFiles, replyContent.TextFiles...)
 
 			if mediaGroupID := replyMsg.MediaGroupID; mediaGroupID != "" {
 				c.Logger.WithFields(logger.Fields{
@@ -594,6 +864,7 @@ func (c *Command) Execute(update telegram.Update) error {
 					messageContent := c.ExtractMessageContent(m.Message, false)
 					currentContent.AddMedia(messageContent.Media...)
 					currentContent.AddURLsFromMap(messageContent.URLs)
+					currentContent.TextFiles = append(currentContent.TextFiles, messageContent.TextFiles...)
 					if m.Message.Caption != "" {
 						replyContent.Text = m.Message.Caption
 					}
@@ -607,6 +878,20 @@ func (c *Command) Execute(update telegram.Update) error {
 		currentContent.Quote = msg.Quote.Text
 	}
 
+	if c.args.QuoteOnly {
+		if currentContent.Quote != "" {
+			currentContent.QuoteOnly = true
+			if currentContent.ReplyMsgContent != nil {
+				currentContent.ReplyMsgContent.Text = ""
+			}
+		} else {
+			warning := telegram.NewMessage(chatID, c.L("ask.quoteOnlyNoQuote", nil), messageID)
+			if _, err := c.Tg.Send(warning); err != nil {
+				c.Logger.WithError(err).Warn("Failed to send quote only warning")
+			}
+		}
+	}
+
 	if currentContent.Text == "" && len(currentContent.GetImagesMedia()) > 0 && model.SupportsImageRecognition() {
 		currentContent.Text = "What is shown in the picture?"
 	}
@@ -692,47 +977,54 @@ func (c *Command) Execute(update telegram.Update) error {
 			} else {
 				editedMessage = newMsgID
 			}
+			userImages := len(currentContent.ImageURLs)
 			currentContent, _ = c.handleURLs(currentContent, chatID, c.args.Recursive)
+			pageImages := c.dedupImageURLs(chatID, messageID, currentContent.ImageURLs[userImages:])
+			currentContent.ImageURLs = append(currentContent.ImageURLs[:userImages], pageImages...)
 		}
 	}
 
-	uniqueImageUrls := make(map[string]bool)
-	for _, url := range currentContent.ImageURLs {
-		uniqueImageUrls[url] = true
+	// the message is saved into the chain also when its history is skipped
+	previousMessage := currentContent.GetLatestConversationMessage()
+	if previousMessage == nil && c.args.NoContext {
+		previousMessage = latestMessage
 	}
-
 	conversationID := int64(messageID)
-	if item := currentContent.GetLatestConversationMessage(); item != nil {
-		conversationID = item.ConversationID
-	}
-
-	for url := range uniqueImageUrls {
-		if isURLValid(url) {
-			currentContent.AddMedia(ai.Content{
-				Type: "image_url",
-				ImageURL: struct {
-					URL string `json:"url"`
-				}{
-					URL: url,
-				},
-			})
-		} else {
-			delete(uniqueImageUrls, url)
-		}
+	if previousMessage != nil {
+		conversationID = previousMessage.ConversationID
+	}
+
+	// image URLs are checked concurrently, a slow host is limited by urlCheckTimeout
+	for _, url := range c.validURLs(context.Background(), uniqueSlice(currentContent.ImageURLs)) {
+		currentContent.AddMedia(ai.Content{
+			Type: "image_url",
+			ImageURL: struct {
+				URL string `json:"url"`
+			}{
+				URL: url,
+			},
+		})
 	}
 
 	currentContent.Media = currentContent.FilterMedia(c.args.HandleImages, c.args.HandleAudio, c.args.HandleFiles)
+	if !c.args.HandleFiles {
+		currentContent.TextFiles = nil
+	}
 
 	// Create timeout context for AI calls (preprocessing + main request)
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout(model, currentContent.Prompt.Name))
 	defer cancel()
 
 	// --- Send thinking message before any processing ---
+	// quick answers can be acknowledged with a reaction, then the answer is sent as a new message
 	thinkingText := c.L("ask.thinking", nil)
-	botMessageID, err := c.sendOrEditMessage(chatID, messageID, editedMessage, thinkingText, nil)
-	if err != nil {
-		c.Logger.WithError(err).Error("Failed to send thinking message")
-		return err
+	botMessageID := 0
+	if editedMessage != 0 || !c.isQuickRequest() || !c.acknowledgeWithReaction(chatID, messageID) {
+		botMessageID, err = c.sendOrEditMessage(chatID, messageID, editedMessage, thinkingText, nil)
+		if err != nil {
+			c.Logger.WithError(err).Error("Failed to send thinking message")
+			return err
+		}
 	}
 
 	// --- Preprocess images with multimodal model if enabled ---
@@ -807,6 +1099,7 @@ func (c *Command) Execute(update telegram.Update) error {
 		}
 	}
 
+	request.search = c.applySearch(model)
 	if c.args.Tools != "" {
 		toolsList := []string{}
 		if c.args.Tools != "all" {
@@ -815,7 +1108,7 @@ func (c *Command) Execute(update telegram.Update) error {
 				toolsList[i] = strings.TrimSpace(item)
 			}
 		}
-		currentContent.Tools = c.getTools(toolsList)
+		currentContent.Tools = c.getTools(request, toolsList)
 	}
 
 	var latestMessageID int64
@@ -844,6 +1137,16 @@ func (c *Command) Execute(update telegram.Update) error {
 		)
 	}
 	userMessage.AttemptsCount = attempt
+	// the rerun request is edited, the chain continues with the new text
+	if historyMessage != nil && msg.EditDate != 0 {
+		if text := currentContent.GetMessageContent(); text != historyMessage.Text {
+			if err := c.updateConversationMessageText(historyMessage.ID, text); err != nil {
+				c.Logger.WithError(err).Error("Failed to update text of edited message")
+			} else {
+				userMessage.Text = text
+			}
+		}
+	}
 	userMessage, err = c.saveMessage(userMessage)
 	if err != nil {
 		c.Logger.WithError(err).Error("Failed to save user message to history")
@@ -854,34 +1157,56 @@ func (c *Command) Execute(update telegram.Update) error {
 		}).Info("Saved user message")
 	}
 
-	if c.Cfg.AI().UseMultimodalAuto && len(currentContent.GetAllMedia(c.cmdCfg, c.args)) > 0 && c.args.Model == "" && len(currentContent.Tools) == 0 {
-		modelName := c.Cfg.AI().MultimodalModel
-		multiModel, err := c.ai.GetFormattedModel(ctx, modelName, "")
-		if err != nil {
-			c.Logger.WithError(err).Error("Failed get multimodal model. Fallback to current chat model")
-		} else {
-			model = multiModel
+	if aiCfg := c.Cfg.AI(); (len(aiCfg.AutoModels) > 0 || aiCfg.UseMultimodalAuto) && c.args.Model == "" && currentContent.Prompt.Model == "" && len(currentContent.Tools) == 0 {
+		media := currentContent.GetAllMedia(c.cmdCfg, c.args, nil)
+		modelName := autoModelName(aiCfg.AutoModels, currentContent, media)
+		multimodalAuto := false
+		if modelName == "" && aiCfg.UseMultimodalAuto && len(media) > 0 {
+			modelName = aiCfg.MultimodalModel
+			multimodalAuto = true
+		}
+		if modelName != "" {
+			autoModel, err := c.ai.GetFormattedModel(ctx, modelName, "")
+			if err != nil {
+				c.Logger.WithError(err).WithField("model", modelName).Error("Failed get auto selected model. Fallback to current chat model")
+			} else if aiCfg.IsModelBlocked(autoModel.FullName()) {
+				c.Logger.WithField("model", autoModel.FullName()).Warn("Auto selected model is blocked. Fallback to current chat model")
+			} else if !autoModel.IsFree() && request.freeOnly {
+				c.Logger.WithField("model", autoModel.FullName()).Warn("Auto selected model is paid in free only chat. Fallback to current chat model")
+			} else {
+				if multimodalAuto && autoModel.FullName() != model.FullName() {
+					request.multimodalFallback = model
+				}
+				model = autoModel
+			}
 		}
 	}
 
+	c.recognizeImagesText(ctx, chatID, model, currentContent)
+
 	// --- Call AI ---
 	// Note: ctx is already created earlier with timeout
 
+	if c.args.Length == "" && previousMessage != nil && previousMessage.Params != nil && previousMessage.Params.Length != nil {
+		c.args.Length = *previousMessage.Params.Length
+	}
+	currentContent.Prompt.System = chainSystemPrompt(currentContent.Prompt, previousMessage)
+
 	messages := c.buildPromptWithHistory(model, currentContent, c.args, false)
 	logMessages := c.Logger.WithFields(logger.Fields{
 		"urls":   currentContent.GetProcessedURLs(),
 		"images": currentContent.ImageURLs,
 	})
 	if c.Cfg.Log().IsDebug() {
-		messagesLog := prepareMessagesForLog(messages, 50, 0)
+		messagesLog := prepareMessagesForLog(messages, 50, 0, c.logRedactor)
 		logMessages.WithField("messages", messagesLog).Debug("Prepared messages for AI")
 	} else {
 		logMessages.WithField("messages", len(messages)).Info("Prepared messages for AI")
 	}
 
 	params := &ai.ModelParams{}
-	if item := currentContent.GetLatestConversationMessage(); item != nil && item.Params != nil {
-		params = item.Params
+	if previousMessage != nil && previousMessage.Params != nil {
+		params = previousMessage.Params
 	}
 	if temp := c.args.Temperature; temp != nil {
 		params.Temperature = temp
@@ -889,6 +1214,40 @@ func (c *Command) Execute(update telegram.Update) error {
 	if topp := c.args.TopP; topp != nil {
 		params.TopP = topp
 	}
+	if length := c.args.Length; length != "" {
+		params.Length = &length
+		if supportsMaxTokens(model) {
+			maxTokens := lengthMaxTokens(length, c.Cfg.AI().ModelParams.MaxTokens)
+			params.MaxTokens = &maxTokens
+		}
+	}
+	if c.args.Effort != "" || c.args.RTokens > 0 {
+		if c.args.Effort != "" && c.args.RTokens > 0 {
+			c.Logger.WithFields(logger.Fields{
+				"effort":  c.args.Effort,
+				"rtokens": c.args.RTokens,
+			}).Warn("Both $effort and $rtokens passed, $rtokens has priority")
+		}
+		if supportsReasoning(model) {
+			params.Reasoning = applyReasoningArgs(params.Reasoning, c.args.Effort, c.args.RTokens)
+		} else {
+			c.Logger.WithField("model", model.FullName()).Warn("Model doesn't support reasoning params, skip $effort and $rtokens")
+		}
+	}
+	if system := currentContent.Prompt.System; system != "" {
+		params.System = &system
+	}
+	if route := c.args.Route; route != "" {
+		provider, _ := c.ai.GetProvider(model.Provider)
+		if _, isOpenrouter := provider.(*ai.OpenRouterClient); isOpenrouter {
+			params.Route = &route
+		} else {
+			c.Logger.WithField("model", model.FullName()).Warn("Provider routing is supported only by OpenRouter, skip $route")
+		}
+	}
+	if !applySeed(params, c.args.Seed, model) {
+		c.Logger.WithField("model", model.FullName()).Debug("Model doesn't support seed, skip it")
+	}
 	useStreamArg := c.args.Stream
 	useStreamConf := c.Cfg.AI().UseStream
 	useStream := useStreamConf
@@ -903,12 +1262,23 @@ func (c *Command) Execute(update telegram.Update) error {
 		"model": model.FullName(),
 	}).Info("Send request to AI")
 
+	// stream and tools update the message in progress, so it must exist
+	if botMessageID == 0 && (useStream || len(currentContent.Tools) > 0) {
+		botMessageID, err = c.sendOrEditMessage(chatID, messageID, 0, thinkingText, nil)
+		if err != nil {
+			c.Logger.WithError(err).Error("Failed to send thinking message")
+			return err
+		}
+	}
+
 	response := NewResponse()
-	response.Context.SetSeparatedModelForTools(c.Cfg.AI().ToolsModel != "")
+	response.Context.SetSeparatedModelForTools(c.Cfg.AI().ToolsModel != "" || c.args.ToolsModel != "")
+	response.Context.SetSearch(request.search)
 	var usageInfo *MetadataUsage
 
-	usageInfo, params, err = c.handleRequest(
+	usageInfo, params, botMessageID, err = c.handleRequest(
 		ctx,
+		request,
 		userMessage,
 		chatID,
 		messages,
@@ -921,8 +1291,15 @@ func (c *Command) Execute(update telegram.Update) error {
 		toolFromCallback,
 	)
 	if err != nil {
+		if isRequestCanceled(ctx) {
+			// not a failure, the queue must not retry the request
+			return nil
+		}
 		return err
 	}
+	if answerModel := response.Metadata.Model; answerModel != nil {
+		model = answerModel
+	}
 
 	if usageInfo != nil {
 		totalUsage.Add(usageInfo)
@@ -982,7 +1359,7 @@ func (c *Command) Execute(update telegram.Update) error {
 		}
 	}
 
-	currentContent.Media = currentContent.GetAllMedia(c.cmdCfg, c.args)
+	currentContent.Media = currentContent.GetAllMedia(c.cmdCfg, c.args, model)
 	// Add media info to context
 	if len(currentContent.Media) > 0 {
 		for _, media := range currentContent.GetImagesMedia() {
@@ -1032,7 +1409,7 @@ func (c *Command) Execute(update telegram.Update) error {
 	}
 
 	provider, _ := c.ai.GetProvider(model.Provider)
-	currencyConfig := c.Cfg.Currency()
+	currencyConfig := c.ChatService.GetChatCurrency(chatID)
 
 	// Add preprocessing usage to total
 	if preprocessUsage != nil {
@@ -1058,9 +1435,13 @@ func (c *Command) Execute(update telegram.Update) error {
 	// Build final message using builder
 	builder := NewMessageBuilder(c.Tg, c.Localizer).
 		SetResponse(response).
-		WithMetadata(c.cmdCfg.Display.Metadata).
+		WithMetadata(c.metadataMode()).
 		WithContext(c.cmdCfg.Display.Context).
 		WithReasoning(c.cmdCfg.Display.Reasoning).
+		WithSplit(c.cmdCfg.Display.SplitLongMessages).
+		WithTables(c.cmdCfg.Display.RenderTables).
+		WithRaw(c.args.Raw).
+		WithMention(c.addresseeMention(currentContent.Addressee)).
 		SetSeparator(c.cmdCfg.Display.Separator)
 
 	if reasoning := c.args.Reasoning; reasoning != nil {
@@ -1068,50 +1449,55 @@ func (c *Command) Execute(update telegram.Update) error {
 	}
 
 	var replyMarkup *telegram.InlineKeyboardMarkup
-	toolsPattern := regexp.MustCompile(`\**(\w+)(\@\d)*\**\s*(\{[^}]*\})`)
-	matches := toolsPattern.FindAllStringSubmatch(finalText, -1)
 	buttonRows := [][]telegram.InlineKeyboardButton{}
-	toolCallNumber := 0
-	for _, match := range matches {
-		if len(match) >= 4 {
-			functionName := match[1]
-			toolNumber := match[2]
-			functionCallName := functionName
-			if toolNumber != "" {
-				functionCallName = functionName + toolNumber
-			}
-			c.Logger.Debug("Tool name " + functionName)
-			if slices.Contains(tools.ToolNames(c.cmdCfg.Tools.Allowed, c.cmdCfg.Tools.Excluded), functionName) {
-				toolCallNumber++
-				button := telegram.NewInlineKeyboardButtonData(
-					fmt.Sprintf(ai.Tools+" Run %s", functionCallName),
-					fmt.Sprintf("ask %s $tools $id:%d", functionCallName, botMessageID),
-				)
-				if len(buttonRows) == 0 || len(buttonRows[len(buttonRows)-1]) == 2 {
-					buttonRows = append(buttonRows, []telegram.InlineKeyboardButton{})
-				}
-				buttonRows[len(buttonRows)-1] = append(buttonRows[len(buttonRows)-1], button)
+	if !c.args.Raw {
+		toolNames := tools.ToolNames(c.cmdCfg.Tools.Allowed, c.cmdCfg.Tools.Excluded)
+		maxButtons := c.cmdCfg.Tools.MaxButtons
+		var toolCallNumber int
+		buttonRows, toolCallNumber = toolButtons(finalText, toolNames, botMessageID, maxButtons)
+		if toolCallNumber > 0 {
+			replyMarkup = &telegram.InlineKeyboardMarkup{
+				InlineKeyboard: buttonRows,
 			}
 		}
+		if toolCallNumber > 1 && toolCallNumber > maxButtons {
+			response.SetContent(finalText + "\n\n" + c.L("ask.toolButtonsCollapsed", map[string]any{"Count": toolCallNumber}))
+		}
 	}
-
-	if toolCallNumber > 0 {
-		toolsButton := []telegram.InlineKeyboardButton{
-			telegram.NewInlineKeyboardButtonData(
-				ai.Tools+" Run all tools",
-				fmt.Sprintf("ask all $tools $id:%d", botMessageID),
-			),
+	// the code stays in the message, the file is easier to copy on mobile
+	if !c.args.Raw && c.cmdCfg.Display.CodeFiles {
+		if blocks := longCodeBlocks(finalText, c.cmdCfg.Display.CodeFileMinLines); len(blocks) > 0 {
+			buttonRows = append(buttonRows, c.codeFileButtons(blocks, botMessageID)...)
+			replyMarkup = &telegram.InlineKeyboardMarkup{
+				InlineKeyboard: buttonRows,
+			}
 		}
-		if toolCallNumber == 1 {
-			buttonRows = [][]telegram.InlineKeyboardButton{}
+	}
+
+	// nothing succeeded, offer to run just the failed tools again
+	if response.Context.AllToolsFailed() {
+		for _, name := range uniqueSlice(response.Context.FailedTools) {
+			buttonRows = append(buttonRows, []telegram.InlineKeyboardButton{
+				telegram.NewInlineKeyboardButtonData(
+					ai.Tools+" "+c.L("ask.retryToolButtonText", map[string]any{"Tool": name}),
+					fmt.Sprintf("ask %s $tools $id:%d", name, botMessageID),
+				),
+			})
 		}
-		buttonRows = append(buttonRows, toolsButton)
 		replyMarkup = &telegram.InlineKeyboardMarkup{
 			InlineKeyboard: buttonRows,
 		}
 	}
 
-	finalMessageEscaped := builder.Build()
+	parts := builder.BuildParts()
+	if len(parts) > 1 {
+		c.Logger.WithFields(logger.Fields{
+			"chat_id":        chatID,
+			"bot_message_id": botMessageID,
+			"parts":          len(parts),
+		}).Info("Final message split into several parts")
+	}
+	finalMessageEscaped := parts[0]
 	c.Logger.WithField("text", finalMessageEscaped).Trace("Escaped final message")
 	textForSend := finalMessageEscaped
 	firstAttempt := telegram.NewEditMessageText(
@@ -1129,7 +1515,8 @@ func (c *Command) Execute(update telegram.Update) error {
 	)
 	secondAttempt.LinkPreviewDisabled = true
 
-	if replyMarkup != nil {
+	// buttons go to the last part, replies to it continue the chain
+	if replyMarkup != nil && len(parts) == 1 {
 		firstAttempt.ReplyMarkup = replyMarkup
 		secondAttempt.ReplyMarkup = replyMarkup
 	}
@@ -1147,12 +1534,37 @@ func (c *Command) Execute(update telegram.Update) error {
 			return c.handleErrorWithRetry(chatID, "", botMessageID, messageID, err, toolFromCallback)
 		}
 	}
-
-	if !currentContent.HasHistory() {
-		title := c.L("ask.emptyConversationTitle", nil)
-		source := "initial"
-		if text := currentContent.GetTextForTitleGenerating(); text != "" {
-			title, source = c.generateConversationTitle(ctx, text, chatID)
+	for i, part := range parts[1:] {
+		var markup *telegram.InlineKeyboardMarkup
+		if i == len(parts)-2 {
+			markup = replyMarkup
+		}
+		if err := c.sendMessagePart(chatID, botMessageID, part, markup); err != nil {
+			c.Logger.WithError(err).WithFields(logger.Fields{
+				"bot_message_id": botMessageID,
+				"part":           i + 2,
+			}).Error("Failed to send final message part")
+			break
+		}
+	}
+
+	if c.args.Voice {
+		c.sendVoiceAnswer(ctx, chatID, botMessageID, finalText)
+	}
+
+	if editedMessage != 0 && (c.args.Diff || c.cmdCfg.Display.RetryDiff) && !c.args.Raw {
+		if previousAnswer != nil && strings.TrimSpace(previousAnswer.Text) != "" {
+			c.sendAnswerDiff(chatID, botMessageID, previousAnswer.Text, finalText)
+		} else {
+			c.Logger.Debug("Previous answer of the retry not found, skip diff")
+		}
+	}
+
+	if previousMessage == nil {
+		title := c.L("ask.emptyConversationTitle", nil)
+		source := "initial"
+		if text := currentContent.GetTextForTitleGenerating(); strings.TrimSpace(text) != "" {
+			title, source = c.generateConversationTitle(ctx, text, chatID)
 		}
 
 		err := c.updateConversationTitle(title, source, chatID, conversationID)
@@ -1170,11 +1582,31 @@ func (c *Command) Execute(update telegram.Update) error {
 	return nil
 }
 
+// sendMessagePart sends a part of the split answer as a reply to the first one
+// and remembers it, so replies to any part continue the conversation
+func (c *Command) sendMessagePart(chatID int64, firstMessageID int, text string, markup *telegram.InlineKeyboardMarkup) error {
+	msg := telegram.NewMessage(chatID, text, firstMessageID)
+	msg.ParseMode = telegram.ModeMarkdownV2
+	msg.LinkPreviewDisabled = true
+	msg.ReplyMarkup = markup
+
+	sent, err := c.Tg.SendWithRetry(msg, 0)
+	if err != nil {
+		c.Logger.WithError(err).WithField("text", text).Warn("Failed to send message part with markdown, sending as plain text")
+		msg.ParseMode = ""
+		if sent, err = c.Tg.SendWithRetry(msg, 0); err != nil {
+			return err
+		}
+	}
+
+	return c.db.SaveMessagePart(chatID, sent.MessageID, firstMessageID)
+}
+
 func (c *Command) updateConversationTitle(title, source string, chatID, conversationID int64) error {
 	query := `UPDATE conversation_history set conversation_title = ?, conversation_title_source = ?
 	where chat_id = ? and message_id = ?`
 
-	_, err := c.db.Exec(query, title, source, chatID, conversationID)
+	_, err := c.db.ExecWithRetry(context.Background(), query, title, source, chatID, conversationID)
 	return err
 }
 
@@ -1182,14 +1614,14 @@ func (c *Command) updateConversationSummary(summary string, chatID, conversation
 	query := `UPDATE conversation_history set conversation_summary = ?
 	where chat_id = ? and conversation_id = ? and is_first = 1`
 
-	_, err := c.db.Exec(query, summary, chatID, conversationID)
+	_, err := c.db.ExecWithRetry(context.Background(), query, summary, chatID, conversationID)
 	return err
 }
 
 func (c *Command) updateConversationMessageAttempts(id int64, attempts uint8) error {
 	query := `UPDATE conversation_history set attempts_count = ? where id = ?`
 
-	_, err := c.db.Exec(query, attempts, id)
+	_, err := c.db.ExecWithRetry(context.Background(), query, attempts, id)
 	return err
 }
 
@@ -1281,63 +1713,67 @@ func (c *Command) saveMessage(
 		query = `INSERT INTO conversation_history (parent_message_id, conversation_chain_id, chat_id, message_id, reply_to_message_id, user_id, role, text, is_first, conversation_id, attempts_count, params, images, files, audio, urls, annotations, tool_calls, tool_responses, tool_name, tool_params)
 				  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 				  RETURNING id`
-		err = c.db.QueryRow(
-			query,
-			msg.ParentMessageID,
-			msg.ConversationChainID,
-			msg.ChatID,
-			msg.MessageID,
-			msg.ReplyToMessageID,
-			msg.UserID,
-			msg.Role,
-			msg.Text,
-			msg.IsFirst,
-			msg.ConversationID,
-			msg.AttemptsCount,
-			paramsJSON,
-			imagesJSON,
-			filesJSON,
-			audioJSON,
-			urlsJSON,
-			annotationsJSON,
-			toolCallsJSON,
-			toolResponsesJSON,
-			msg.ToolName,
-			toolParamsJSON,
-		).Scan(&insertedID)
+		err = c.db.WithRetry(context.Background(), func() error {
+			return c.db.QueryRow(
+				query,
+				msg.ParentMessageID,
+				msg.ConversationChainID,
+				msg.ChatID,
+				msg.MessageID,
+				msg.ReplyToMessageID,
+				msg.UserID,
+				msg.Role,
+				msg.Text,
+				msg.IsFirst,
+				msg.ConversationID,
+				msg.AttemptsCount,
+				paramsJSON,
+				imagesJSON,
+				filesJSON,
+				audioJSON,
+				urlsJSON,
+				annotationsJSON,
+				toolCallsJSON,
+				toolResponsesJSON,
+				msg.ToolName,
+				toolParamsJSON,
+			).Scan(&insertedID)
+		})
 	} else {
 		query = `INSERT INTO conversation_history (parent_message_id, conversation_chain_id, chat_id, message_id, reply_to_message_id, user_id, role, text, is_first, conversation_id, total_tokens, completion_tokens, prompt_tokens, total_cost, model_name, attempts_count, params, images, files, audio, urls, annotations, tool_calls, tool_responses, tool_name, tool_params)
 				  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 				  RETURNING id`
-		err = c.db.QueryRow(
-			query,
-			msg.ParentMessageID,
-			msg.ConversationChainID,
-			msg.ChatID,
-			msg.MessageID,
-			msg.ReplyToMessageID,
-			msg.UserID,
-			msg.Role,
-			msg.Text,
-			msg.IsFirst,
-			msg.ConversationID,
-			msg.Usage.Total,
-			msg.Usage.Output,
-			msg.Usage.Input,
-			msg.Usage.Cost,
-			msg.ModelName,
-			msg.AttemptsCount,
-			paramsJSON,
-			imagesJSON,
-			filesJSON,
-			audioJSON,
-			urlsJSON,
-			annotationsJSON,
-			toolCallsJSON,
-			toolResponsesJSON,
-			msg.ToolName,
-			toolParamsJSON,
-		).Scan(&insertedID)
+		err = c.db.WithRetry(context.Background(), func() error {
+			return c.db.QueryRow(
+				query,
+				msg.ParentMessageID,
+				msg.ConversationChainID,
+				msg.ChatID,
+				msg.MessageID,
+				msg.ReplyToMessageID,
+				msg.UserID,
+				msg.Role,
+				msg.Text,
+				msg.IsFirst,
+				msg.ConversationID,
+				msg.Usage.Total,
+				msg.Usage.Output,
+				msg.Usage.Input,
+				msg.Usage.Cost,
+				msg.ModelName,
+				msg.AttemptsCount,
+				paramsJSON,
+				imagesJSON,
+				filesJSON,
+				audioJSON,
+				urlsJSON,
+				annotationsJSON,
+				toolCallsJSON,
+				toolResponsesJSON,
+				msg.ToolName,
+				toolParamsJSON,
+			).Scan(&insertedID)
+		})
 	}
 	if err != nil {
 		c.Logger.WithError(err).WithFields(logger.Fields{
@@ -1404,6 +1840,16 @@ func (c *Command) getConversationHistory(chatID int64, startMessageID int) ([]co
 	return history, nil
 }
 
+// contextHistory returns the conversation history from the message, with $noctx
+// only the messages of the chain of the current request are kept
+func (c *Command) contextHistory(chatID int64, startMessageID int, chainID string) ([]conversationMessage, error) {
+	history, err := c.getConversationHistory(chatID, startMessageID)
+	if err != nil || !c.args.NoContext {
+		return history, err
+	}
+	return chainMessages(history, chainID), nil
+}
+
 func (c *Command) getLatestMessageFromHistory(chatID int64) (*conversationMessage, error) {
 	query := `SELECT id, chat_id, parent_message_id, conversation_chain_id, message_id, reply_to_message_id, role, text, conversation_id, is_first, created_at, model_name, 
               prompt_tokens, completion_tokens, total_tokens, total_cost, attempts_count,
@@ -1538,13 +1984,21 @@ func (c *Command) getMessagesFromHistoryByID(chatID int64, messageID int) ([]con
 }
 
 // --- Modified Prompt Builder ---
-func (c *Command) buildPromptWithHistory(model *ai.ModelInfo, currentContent *MessageContent, args *CommandArgs, withoutUserMessage bool) []ai.Message {
-	var messages []ai.Message
-	history := currentContent.ConversationHistory
-	provider, _ := c.ai.GetProvider(model.Provider)
-	_, isOpenrouter := provider.(*ai.OpenRouterClient)
+// chainSystemPrompt returns the system prompt override of the prompt, replies without
+// a prompt continue with the override the chain was started with
+func chainSystemPrompt(current prompt, previousMessage *conversationMessage) string {
+	if current.System != "" || current.Name != "" {
+		return current.System
+	}
+	if previousMessage != nil && previousMessage.Params != nil && previousMessage.Params.System != nil {
+		return *previousMessage.Params.System
+	}
+	return ""
+}
 
-	now := time.Now()
+// systemInstructions assembles the system message from the config, the chat override,
+// the prompt and options of the current message, placeholders are substituted
+func (c *Command) systemInstructions(currentContent *MessageContent, args *CommandArgs, now time.Time) string {
 	dateStr := now.Format("Monday, 02 January 2006")
 	timeStr := now.Format("15:04")
 
@@ -1560,6 +2014,14 @@ Technical notes:
 5. Keep responses under 4000 characters (Telegram limit)
 6. Use tools with parameters in English`
 
+	if currentContent.QuoteOnly {
+		defaultSystemInstructions += `
+[Quote mode]
+The user quoted a fragment of the message they replied to, it is marked with [QUOTE].
+Address ONLY the quoted passage, do not discuss anything outside of it.`
+	}
+	defaultSystemInstructions += lengthInstruction(args.Length)
+
 	if c.cmdCfg.Tools.Enabled && len(currentContent.Tools) == 0 && len(tools.AvailableTools(c.cmdCfg.Tools.Allowed, c.cmdCfg.Tools.Excluded)) > 0 {
 		runToolsInstruction := ""
 		if !c.cmdCfg.Tools.AutoRun {
@@ -1623,13 +2085,44 @@ You MUST follow the Markdown rules. STRICTLY RESPOND IN: {{language}}. NEVER swi
 	if extra := c.Cfg.AI().ExtraSystemPrompt; extra != "" {
 		systemInstructions += " " + extra
 	}
+	// the chat override set by /info prompt set replaces the persona the same way
+	if override := currentContent.SystemPromptOverride; override != "" {
+		systemInstructions = override
+	}
+	// prompts like /code replace the chat persona, the technical notes are kept
+	// because messages are still sent with the markers
+	if system := currentContent.Prompt.System; system != "" {
+		systemInstructions = system
+	}
 	systemInstructions += defaultSystemInstructions
+	if currentContent.Raw {
+		// raw mode sends the text as is, without markers and instructions about them
+		systemInstructions = rawSystemInstructions
+	}
 
 	systemInstructions = strings.TrimSpace(systemInstructions)
 	systemInstructions = strings.ReplaceAll(systemInstructions, "{{date}}", dateStr)
 	systemInstructions = strings.ReplaceAll(systemInstructions, "{{time}}", timeStr)
 	systemInstructions = strings.ReplaceAll(systemInstructions, "{{language}}", c.Cfg.AI().Language)
 
+	return systemInstructions
+}
+
+func (c *Command) buildPromptWithHistory(model *ai.ModelInfo, currentContent *MessageContent, args *CommandArgs, withoutUserMessage bool) []ai.Message {
+	var messages []ai.Message
+	history := currentContent.ConversationHistory
+	provider, _ := c.ai.GetProvider(model.Provider)
+	_, isOpenrouter := provider.(*ai.OpenRouterClient)
+
+	now := time.Now()
+	systemInstructions := c.systemInstructions(currentContent, args, now)
+	if block := knownAboutUserBlock(currentContent.UserFacts); block != "" && !currentContent.Raw {
+		systemInstructions += "\n\n" + block
+	}
+	if block := addresseeBlock(currentContent.Addressee); block != "" && !currentContent.Raw {
+		systemInstructions += "\n\n" + block
+	}
+
 	systemMessage := ai.Message{
 		Role: ai.RoleSystem,
 	}
@@ -1646,17 +2139,43 @@ You MUST follow the Markdown rules. STRICTLY RESPOND IN: {{language}}. NEVER swi
 	}
 	messages = append(messages, systemMessage)
 
-	maxImages := c.cmdCfg.Images.Max
+	// re-sent media is sent only once, the current message is the most recent copy
+	seenMedia := map[string]bool{}
+	currentImages := dedupeMedia(currentContent.GetImagesMedia(), seenMedia)
+	currentAudio := dedupeMedia(currentContent.GetAudioMedia(), seenMedia)
+	currentFiles := dedupeMedia(currentContent.GetFilesMedia(), seenMedia)
+
+	images := c.cmdCfg.Images.ForModel(model.FullName(), model.Alias)
+	maxImages := images.Max
 	maxAudio := c.cmdCfg.Audio.MaxInHistory
-	allowedImagesCount := maxImages - len(currentContent.GetImagesMedia())
-	allowedAudioCount := maxAudio - len(currentContent.GetAudioMedia())
+	allowedImagesCount := maxImages - len(currentImages)
+	allowedAudioCount := maxAudio - len(currentAudio)
 
 	// history
 	imagesInHistoryCount := 0
 	audioInHistoryCount := 0
 	historyMessages := []ai.Message{}
-	imageLifetime := c.cmdCfg.Images.Lifetime
+	imageLifetime := images.Lifetime
 	for _, msg := range history {
+		// images sent by tools, e.g. generated ones, are added as user content to ask about them in replies
+		if msg.Role.IsInternal() {
+			if len(msg.Images) == 0 || !args.HandleImages || !model.SupportsImageRecognition() ||
+				(imageLifetime != 0 && !now.Before(msg.CreatedAt.Add(imageLifetime))) {
+				continue
+			}
+			contentList := []ai.Content{{Type: "text", Text: "[Image sent to the chat by the tool]"}}
+			for _, image := range dedupeMedia(msg.Images, seenMedia) {
+				if imagesInHistoryCount >= allowedImagesCount {
+					break
+				}
+				contentList = append(contentList, image)
+				imagesInHistoryCount++
+			}
+			if len(contentList) > 1 {
+				historyMessages = append(historyMessages, ai.Message{Role: ai.RoleUser, Content: contentList})
+			}
+			continue
+		}
 		if !msg.Role.Supported() {
 			c.Logger.WithField("role", msg.Role).Warn("Unsupported role")
 			continue
@@ -1679,6 +2198,11 @@ You MUST follow the Markdown rules. STRICTLY RESPOND IN: {{language}}. NEVER swi
 			continue
 		}
 		message := ai.Message{Role: string(msg.Role)}
+		text := msg.Text
+		// answers saved before the option was disabled still have reasoning
+		if msg.Role.IsAssistant() && !c.cmdCfg.Display.PersistReasoning {
+			text = stripReasoning(text)
+		}
 		if model.SupportsTools() {
 			if msg.Role.IsTool() {
 				if len(msg.ToolResponses) > 0 && model.SupportsTools() {
@@ -1697,7 +2221,7 @@ You MUST follow the Markdown rules. STRICTLY RESPOND IN: {{language}}. NEVER swi
 			contentList := []ai.Content{}
 			content := ai.Content{
 				Type: "text",
-				Text: msg.Text,
+				Text: text,
 			}
 			if len(msg.Annotations) > 0 {
 				content.Annotations = msg.Annotations
@@ -1705,8 +2229,8 @@ You MUST follow the Markdown rules. STRICTLY RESPOND IN: {{language}}. NEVER swi
 			contentList = append(contentList, content)
 
 			if args.HandleImages && model.SupportsImageRecognition() && (imageLifetime == 0 || now.Before(msg.CreatedAt.Add(imageLifetime))) {
-				if len(msg.Images) > 0 && model.IsMultimodal() && imagesInHistoryCount <= allowedImagesCount {
-					for _, image := range msg.Images {
+				if images := dedupeMedia(msg.Images, seenMedia); len(images) > 0 && model.IsMultimodal() && imagesInHistoryCount <= allowedImagesCount {
+					for _, image := range images {
 						contentList = append(contentList, image)
 						imagesInHistoryCount++
 						if imagesInHistoryCount == allowedImagesCount {
@@ -1716,17 +2240,18 @@ You MUST follow the Markdown rules. STRICTLY RESPOND IN: {{language}}. NEVER swi
 				}
 			}
 
-			if args.HandleFiles && model.SupportsFiles() && len(msg.Files) > 0 {
-				contentList = append(contentList, msg.Files...)
+			if args.HandleFiles && model.SupportsFiles() {
+				contentList = append(contentList, dedupeMedia(msg.Files, seenMedia)...)
 			}
-			if args.HandleAudio && model.SupportsAudioRecognition() && len(msg.Audio) > 0 && audioInHistoryCount < allowedAudioCount {
-				contentList = append(contentList, msg.Audio...)
-				audioInHistoryCount += len(msg.Audio)
+			if args.HandleAudio && model.SupportsAudioRecognition() && audioInHistoryCount < allowedAudioCount {
+				audio := dedupeMedia(msg.Audio, seenMedia)
+				contentList = append(contentList, audio...)
+				audioInHistoryCount += len(audio)
 			}
 
 			message.Content = contentList
 		} else {
-			message.Text = msg.Text
+			message.Text = text
 		}
 		historyMessages = append(historyMessages, message)
 	}
@@ -1755,8 +2280,8 @@ You MUST follow the Markdown rules. STRICTLY RESPOND IN: {{language}}. NEVER swi
 		}
 	}
 	imagesCount := 0
-	if model.SupportsImageRecognition() && len(currentContent.GetImagesMedia()) > 0 {
-		for _, image := range currentContent.GetImagesMedia() {
+	if model.SupportsImageRecognition() && len(currentImages) > 0 {
+		for _, image := range currentImages {
 			userMessage.Content = append(userMessage.Content, image)
 			imagesCount++
 			if imagesCount == (maxImages - imagesInHistoryCount) {
@@ -1764,9 +2289,9 @@ You MUST follow the Markdown rules. STRICTLY RESPOND IN: {{language}}. NEVER swi
 			}
 		}
 	}
-	if model.SupportsAudioRecognition() && len(currentContent.GetAudioMedia()) > 0 {
+	if model.SupportsAudioRecognition() && len(currentAudio) > 0 {
 		audioItems := []ai.Content{}
-		for _, media := range currentContent.GetAudioMedia() {
+		for _, media := range currentAudio {
 			switch strings.ToLower(media.InputAudio.Format) {
 			case "audio/mpeg":
 				media.InputAudio.Format = "mp3"
@@ -1790,8 +2315,8 @@ You MUST follow the Markdown rules. STRICTLY RESPOND IN: {{language}}. NEVER swi
 		}
 		userMessage.Content = append(userMessage.Content, audioItems...)
 	}
-	if (model.SupportsFiles() || isOpenrouter) && len(currentContent.GetFilesMedia()) > 0 {
-		userMessage.Content = append(userMessage.Content, currentContent.GetFilesMedia()...)
+	if (model.SupportsFiles() || isOpenrouter) && len(currentFiles) > 0 {
+		userMessage.Content = append(userMessage.Content, currentFiles...)
 	}
 
 	messages = append(messages, userMessage)
@@ -1842,14 +2367,6 @@ func cleanText(text string) string {
 	return strings.TrimSpace(strings.ToValidUTF8(text, ""))
 }
 
-func isURLValid(url string) bool {
-	resp, err := http.Head(url)
-	if err != nil {
-		return false
-	}
-	return resp.StatusCode == http.StatusOK
-}
-
 func (c *Command) generateArgumentsHelpText() string {
 	var help strings.Builder
 	help.WriteString("📚 *Available arguments:*\n\n")
@@ -1914,6 +2431,12 @@ func parseAdditionalContextArg(contextStr string) (count int, duration time.Dura
 }
 
 func (c *Command) handleURLs(currentContent *MessageContent, chatID int64, recursive bool) (*MessageContent, error) {
+	// shared with the recursive pass to limit requests to sites and proxy
+	sem := make(chan struct{}, max(c.cmdCfg.Fetcher.Concurrency, 1))
+	return c.fetchURLs(currentContent, chatID, recursive, sem)
+}
+
+func (c *Command) fetchURLs(currentContent *MessageContent, chatID int64, recursive bool, sem chan struct{}) (*MessageContent, error) {
 	c.Logger.WithFields(logger.Fields{
 		"chat_id": chatID,
 	}).Info("Handling URLs in message content")
@@ -1950,7 +2473,9 @@ func (c *Command) handleURLs(currentContent *MessageContent, chatID int64, recur
 				"chat_id": chatID,
 				"url":     url,
 			}).Debug("Fetching URL content")
+			sem <- struct{}{}
 			content, _ := c.fetcher.Fetch(fetch.MustNewRequestPayload(url, nil, nil))
+			<-sem
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -1958,6 +2483,7 @@ func (c *Command) handleURLs(currentContent *MessageContent, chatID int64, recur
 			state := currentContent.URLs[url]
 			if content.IsError {
 				c.Logger.WithField("content", content.Content).Error("Fail fetch URL")
+				c.metrics.ObserveFetchError()
 				state.MarkFailed(content.GetText())
 			} else {
 				c.Logger.WithFields(logger.Fields{
@@ -1965,14 +2491,16 @@ func (c *Command) handleURLs(currentContent *MessageContent, chatID int64, recur
 					"url":     url,
 				}).Debug("Fetched URL content successfully")
 				state.MarkProcessed()
+				state.CanonicalURL = content.GetCanonicalURL()
 			}
 			text := content.GetText()
-			if utf8.RuneCountInString(text) > 500 {
-				state.TrimmedContent = string([]rune(text)[:500]) +
+			if utf8.RuneCountInString(text) > urlContentPreviewLength {
+				state.TrimmedContent = string([]rune(text)[:urlContentPreviewLength]) +
 					fmt.Sprintf(
 						"... [%s]",
 						c.L("ask.response.truncated", nil),
 					)
+				state.Content = text
 			} else {
 				state.TrimmedContent = text
 			}
@@ -1980,13 +2508,13 @@ func (c *Command) handleURLs(currentContent *MessageContent, chatID int64, recur
 			if maxLength != 0 && utf8.RuneCountInString(content.Content[0].Text) > maxLength {
 				content.Content[0].Text = string([]rune(content.Content[0].Text)[:maxLength]) + "...[truncated]"
 			}
-			if strings.Contains(url, "t.me") || strings.Contains(url, "reddit.com") || strings.Contains(url, "habr") {
+			if strings.Contains(url, "t.me") || strings.Contains(url, "reddit.com") || strings.Contains(url, "habr") || fetch.IsOsnovaURL(url) || fetch.IsTelegraphURL(url) {
 				c.extractImageURLs(content.Content, currentContent)
 			}
 			// Mark URL as handled
 			currentContent.URLsContent[url] = content.GetText()
 			if recursive {
-				if strings.Contains(url, "t.me") || strings.Contains(url, "reddit.com") || strings.Contains(url, "habr") {
+				if strings.Contains(url, "t.me") || strings.Contains(url, "reddit.com") || strings.Contains(url, "habr") || fetch.IsXStatusURL(url) || fetch.IsOsnovaURL(url) || fetch.IsTelegraphURL(url) {
 					urls := fetch.ExtractStrictURLs(content.Content[0].Text)
 					urls, _, _ = c.filterURLs(urls)
 					currentContent.AddURLs(urls...)
@@ -2011,6 +2539,10 @@ func (c *Command) handleURLs(currentContent *MessageContent, chatID int64, recur
 
 	wg.Wait()
 
+	if merged := currentContent.mergeCanonicalURLs(); len(merged) > 0 {
+		c.Logger.WithField("urls", merged).Info("Merged URLs of the same canonical page")
+	}
+
 	if recursive {
 		var newURLs []string
 		for url, state := range currentContent.URLs {
@@ -2019,13 +2551,37 @@ func (c *Command) handleURLs(currentContent *MessageContent, chatID int64, recur
 			}
 		}
 		if len(newURLs) > 0 {
-			currentContent, _ = c.handleURLs(currentContent, chatID, false)
+			currentContent, _ = c.fetchURLs(currentContent, chatID, false, sem)
 		}
 	}
 
 	return currentContent, nil
 }
 
+// dedupImageURLs skips page images already sent to the chat by another message within the dedup window
+func (c *Command) dedupImageURLs(chatID int64, messageID int, urls []string) []string {
+	window := c.cmdCfg.Fetcher.ImageDedupWindow
+	if window <= 0 || c.cache == nil {
+		return urls
+	}
+
+	// message ID is stored to keep images on retries of the same message
+	owner := []byte(strconv.Itoa(messageID))
+	result := make([]string, 0, len(urls))
+	for _, url := range urls {
+		key := fmt.Sprintf("ask:image:%d:%s", chatID, url)
+		if data, found := c.cache.Get(key); found && string(data) != string(owner) {
+			c.Logger.WithField("url", url).Debug("Skip image already sent to chat")
+			continue
+		}
+		if err := c.cache.Set(key, owner, window); err != nil {
+			c.Logger.WithError(err).WithField("url", url).Warn("Failed to save image for dedup")
+		}
+		result = append(result, url)
+	}
+	return result
+}
+
 func (c *Command) extractImageURLs(content []fetch.Content, currentContent *MessageContent) {
 	var urls []string
 	for _, c := range content {
@@ -2107,19 +2663,20 @@ func (c *Command) formatMessageLink(msg *telegram.MessageOriginal, text string)
 	)
 }
 
-func prepareMessagesForLog(messages []ai.Message, maxURLLen, maxTextLen int) []ai.Message {
+func prepareMessagesForLog(messages []ai.Message, maxURLLen, maxTextLen int, redactor *logger.Redactor) []ai.Message {
 	messagesCopy := make([]ai.Message, len(messages))
 	for i, msg := range messages {
 		messagesCopy[i] = ai.Message{
 			Role:    msg.Role,
 			Content: msg.Content,
-			Text:    msg.Text,
+			Text:    redactor.Redact(msg.Text),
 		}
 
 		if len(msg.Content) > 0 {
 			messagesCopy[i].Content = make([]ai.Content, len(msg.Content))
 			for j, content := range msg.Content {
 				messagesCopy[i].Content[j] = content
+				messagesCopy[i].Content[j].Text = redactor.Redact(content.Text)
 
 				if content.Type == "image_url" && len(content.ImageURL.URL) > maxURLLen {
 					messagesCopy[i].Content[j].ImageURL.URL = content.ImageURL.URL[:maxURLLen] + "..."
@@ -2129,15 +2686,15 @@ func prepareMessagesForLog(messages []ai.Message, maxURLLen, maxTextLen int) []a
 					messagesCopy[i].Content[j].File.FileData = content.File.FileData[:maxURLLen] + "..."
 				}
 
-				if content.Type == "text" && maxTextLen > 0 && len(content.Text) > maxTextLen {
-					messagesCopy[i].Content[j].Text = content.Text[:maxTextLen] + "..."
+				if text := messagesCopy[i].Content[j].Text; content.Type == "text" && maxTextLen > 0 && len(text) > maxTextLen {
+					messagesCopy[i].Content[j].Text = text[:maxTextLen] + "..."
 				}
 			}
 		}
 		if len(msg.Text) > 0 {
 			// for not multimodal mode, e.g. deepseek
-			if maxTextLen > 0 && len(msg.Text) > maxTextLen {
-				messagesCopy[i].Text = msg.Text[:maxTextLen] + "..."
+			if maxTextLen > 0 && len(messagesCopy[i].Text) > maxTextLen {
+				messagesCopy[i].Text = messagesCopy[i].Text[:maxTextLen] + "..."
 			}
 		}
 	}
@@ -2179,10 +2736,38 @@ func (c *Command) Ask(
 		annotations = response.Annotations
 		usage = &response.Usage
 		requestedTools = response.Choices[0].Message.ToolCalls
+		c.recordCost(chatID, model, usage)
 	}
 	return
 }
 
+// requestTimeout resolves the timeout of AI calls from model params,
+// prompt params override alias, provider and global ones
+func (c *Command) requestTimeout(model *ai.ModelInfo, promptName string) time.Duration {
+	configParams, err := c.Cfg.AI().GetFullModelParams(model.Provider, model.Alias, promptName)
+	if err != nil {
+		c.Logger.WithError(err).Warn("Failed to get model params, use default timeout")
+		return defaultRequestTimeout
+	}
+	params, _ := ai.NewModelParamsFromMap(configParams)
+	if timeout := params.Timeout; timeout != nil {
+		return *timeout
+	}
+	return defaultRequestTimeout
+}
+
+func (c *Command) recordCost(chatID int64, model *ai.ModelInfo, usage *ai.ModelUsage) {
+	if usage == nil || usage.GetCost() <= 0 {
+		return
+	}
+	if err := c.db.AddChatCost(chatID, model.FullName(), usage.GetCost()); err != nil {
+		c.Logger.WithError(err).WithFields(logger.Fields{
+			"chat_id": chatID,
+			"model":   model.FullName(),
+		}).Error("Failed to record request cost")
+	}
+}
+
 func (c *Command) AskStream(
 	ctx context.Context,
 	messages []ai.Message,
@@ -2193,6 +2778,7 @@ func (c *Command) AskStream(
 	webSearch bool,
 	params ai.ModelParams,
 	sentMsgID int,
+	onFirstChunk func(),
 ) (content string, reasoning string, requestedTools []ai.ToolCall, usage *ai.ModelUsage, annotations []ai.AnnotationContent, finalParams *ai.ModelParams, err error) {
 	stream, _, finalParams, err := c.ai.AskStream(ctx, messages, tools, model, promptName, chatID, webSearch, params)
 	if err != nil {
@@ -2200,18 +2786,25 @@ func (c *Command) AskStream(
 	}
 
 	var (
-		fullResponse             strings.Builder
-		reasoningBuffer          strings.Builder
-		lastUpdateReasoning      = time.Now()
-		lastUpdate               = time.Now()
-		hasContent               = false
-		updateThreshold          = 2 * time.Second
-		reasoningUpdateThreshold = 3 * time.Second
-		errorCount               = 0
+		fullResponse        strings.Builder
+		reasoningBuffer     strings.Builder
+		lastUpdateReasoning = time.Now()
+		lastUpdate          = time.Now()
+		lastReasoningLength = 0
+		lastLength          = 0
+		hasContent          = false
+		errorCount          = 0
 	)
 
 	for chunk := range stream {
+		if onFirstChunk != nil {
+			onFirstChunk()
+			onFirstChunk = nil
+		}
 		if chunk.Error != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && fullResponse.Len() > 0 {
+				break
+			}
 			err = chunk.Error
 			return
 		}
@@ -2232,7 +2825,7 @@ func (c *Command) AskStream(
 		if chunk.Reasoning != "" {
 			reasoningBuffer.WriteString(chunk.Reasoning)
 
-			if !hasContent && time.Since(lastUpdateReasoning) > reasoningUpdateThreshold && reasoningBuffer.Len() > 3 {
+			if !hasContent && shouldEditStream(time.Since(lastUpdateReasoning), reasoningBuffer.Len(), lastReasoningLength) {
 				msg := telegram.NewEditMessageText(
 					chatID,
 					int(sentMsgID),
@@ -2242,6 +2835,7 @@ func (c *Command) AskStream(
 				)
 				editMsg = &msg
 				lastUpdateReasoning = time.Now()
+				lastReasoningLength = reasoningBuffer.Len()
 			}
 		}
 
@@ -2249,18 +2843,23 @@ func (c *Command) AskStream(
 			hasContent = true
 			fullResponse.WriteString(chunk.Content)
 
-			if time.Since(lastUpdate) > updateThreshold && fullResponse.Len() > 3 {
-				msgText := fullResponse.String() + "..."
+			if shouldEditStream(time.Since(lastUpdate), fullResponse.Len(), lastLength) {
+				var msgText string
+				if c.cmdCfg.Display.Reasoning && c.cmdCfg.Display.StreamReasoning && reasoningBuffer.Len() > 0 {
+					msgText = c.buildStreamWithReasoning(reasoningBuffer.String(), fullResponse.String())
+				} else {
+					msgText = fullResponse.String() + "..."
 
-				if utf8.RuneCountInString(msgText) > 4000 {
-					msgText = string([]rune(msgText)[:4000]) + "... " + c.L(
-						"ask.telegramLengthRestriction",
-						nil,
-					)
-				}
+					if utf8.RuneCountInString(msgText) > 4000 {
+						msgText = string([]rune(msgText)[:4000]) + "... " + c.L(
+							"ask.telegramLengthRestriction",
+							nil,
+						)
+					}
 
-				msgText = cleanText(msgText)
-				msgText, _ = c.Tg.TelegramifyMarkdown(msgText)
+					msgText = cleanText(msgText)
+					msgText, _ = c.Tg.TelegramifyMarkdown(msgText)
+				}
 
 				msg := telegram.NewEditMessageText(
 					chatID,
@@ -2270,6 +2869,7 @@ func (c *Command) AskStream(
 				msg.ParseMode = telegram.ModeMarkdownV2
 				editMsg = &msg
 				lastUpdate = time.Now()
+				lastLength = fullResponse.Len()
 			}
 		}
 
@@ -2291,14 +2891,75 @@ func (c *Command) AskStream(
 		}
 	}
 
+	// the provider closes the stream when the request times out, the answer so far is kept
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if fullResponse.Len() == 0 {
+			return "", "", nil, usage, nil, finalParams, fmt.Errorf("stream timed out: %w", ctx.Err())
+		}
+		c.Logger.WithField("model", model.FullName()).Warn("Stream timed out, save partial response")
+		fullResponse.WriteString("\n\n" + c.L("ask.streamTimeout", nil))
+		requestedTools = nil
+	}
+
 	// --- Finalize and Save AI Response ---
 	content = fullResponse.String()
 	reasoning = reasoningBuffer.String()
 	err = nil
+	c.recordCost(chatID, model, usage)
 
 	return
 }
 
+// streamEditInterval returns the minimal interval between edits of the streamed message,
+// short messages are edited eagerly to show the progress, long ones less often
+func streamEditInterval(length int) time.Duration {
+	interval := minStreamEditInterval + time.Duration(length/streamEditGrowthLength)*time.Second
+	return min(interval, maxStreamEditInterval)
+}
+
+// shouldEditStream reports whether the streamed message of the length should be edited,
+// the interval since the last edit has passed and enough text was added
+func shouldEditStream(sinceLastEdit time.Duration, length, lastEditLength int) bool {
+	return sinceLastEdit > streamEditInterval(length) && length-lastEditLength >= minStreamEditDelta
+}
+
+// buildStreamWithReasoning renders reasoning in a collapsible blockquote above the streaming answer,
+// the reasoning is truncated first when the message doesn't fit
+func (c *Command) buildStreamWithReasoning(reasoning, content string) string {
+	response := NewResponse()
+	response.SetReasoning(reasoning)
+	response.SetContent(content + "...")
+	return NewMessageBuilder(c.Tg, c.Localizer).
+		SetResponse(response).
+		WithContext(false).
+		WithMetadata(config.MetadataModeOff).
+		WithSectionOrder(SectionReasoning, SectionContent).
+		Build()
+}
+
+func (c *Command) sendModelNotAvailable(chatID int64, messageID, editedMessage int, modelName string, err error) error {
+	c.Logger.WithError(err).WithFields(logger.Fields{
+		"model": modelName,
+	}).Error("Failed to get a model for LLM")
+	messageKey := "ask.modelNotAvailable"
+	if errors.Is(err, service.ErrPaidModelsDisabled) {
+		messageKey = "ask.paidModelsDisabled"
+	}
+	text := c.L(messageKey, map[string]any{
+		"ModelName": c.Tg.EscapeText(modelName),
+	})
+	_, errSend := c.sendOrEditMessage(chatID, messageID, editedMessage, text, &telegram.TextMessage{
+		ParseMode: telegram.ModeMarkdownV2,
+	})
+	if errSend != nil {
+		c.Logger.WithError(err).WithFields(logger.Fields{
+			"chatID": chatID,
+			"text":   text,
+		}).Error("Failed to send a message")
+	}
+	return err
+}
+
 func (c *Command) mapArgsToStruct(argsMap map[string]string) (*CommandArgs, error) {
 	args := &CommandArgs{
 		HandleImages: c.cmdCfg.Images.Enabled,
@@ -2376,6 +3037,8 @@ func (c *Command) mapArgsToStruct(argsMap map[string]string) (*CommandArgs, erro
 			} else {
 				args.Tools = value
 			}
+		case "toolsmodel":
+			args.ToolsModel = value
 		case "new":
 			args.New = value == "yes"
 		case "think":
@@ -2411,8 +3074,33 @@ func (c *Command) mapArgsToStruct(argsMap map[string]string) (*CommandArgs, erro
 				stream := value == "yes"
 				args.Stream = &stream
 			}
+		case "len":
+			args.Length = value
+		case "effort":
+			args.Effort = value
+		case "rtokens":
+			args.RTokens, _ = strconv.Atoi(value)
+		case "seed":
+			seed, _ := strconv.Atoi(value)
+			args.Seed = &seed
+		case "route":
+			args.Route = value
 		case "p":
 			args.Prompt = value
+		case "noctx":
+			args.NoContext = value == "yes"
+		case "quoteonly":
+			args.QuoteOnly = value == "yes"
+		case "raw":
+			args.Raw = value == "yes"
+		case "voice":
+			args.Voice = value == "yes"
+		case "compact":
+			args.Compact = value == "yes"
+		case "at":
+			args.At = value
+		case "diff":
+			args.Diff = value == "yes"
 		case "id":
 			id, _ := strconv.Atoi(value)
 			args.ChainID = id
@@ -2451,7 +3139,19 @@ func validateArg(arg *Argument, value string) error {
 			return fmt.Errorf("value must be ≤ %.1f", *arg.Max)
 		}
 	case "string":
-		if len(arg.Values) > 1 && !slices.Contains(arg.Values, value) {
+		if arg.Name == "len" {
+			if !isValidLength(value) {
+				return fmt.Errorf("allowed values: %v or word count", strings.Join(lengthValues, ", "))
+			}
+		} else if arg.Name == "route" {
+			if !ai.IsValidOpenRouterRoute(value) {
+				return fmt.Errorf("allowed values: %v or provider slug", strings.Join(ai.OpenRouterSortValues, ", "))
+			}
+		} else if arg.Name == "at" {
+			if !usernameRegex.MatchString(value) {
+				return fmt.Errorf("telegram username required, e.g. @username")
+			}
+		} else if len(arg.Values) > 1 && !slices.Contains(arg.Values, value) {
 			return fmt.Errorf("allowed values: %v", strings.Join(arg.Values, ", "))
 		}
 	}
@@ -2502,17 +3202,34 @@ func (c *Command) handleErrorWithRetry(chatID int64, text string, messageID int,
 		text, _ = c.Tg.TelegramifyMarkdown(c.L("ask.failedToProcessAIRequest", nil))
 		text = fmt.Sprintf("%s\n_%s_", text, markdown.Escape(err.Error()))
 	}
+	// acknowledged with reaction, there is no message to edit
+	sendNew := messageID == 0 && !toolFromCallback
 	if toolFromCallback {
 		messageID = 0
 		text = c.L("ask.toolUsageHint", nil)
 	}
-	errorMsg := telegram.NewEditMessageText(chatID, messageID, text)
-	errorMsg.ParseMode = telegram.ModeMarkdownV2
 
 	// Add the button only if there is originalMessageID (the message that we answer)
-	if originalMessageID != 0 {
+	var replyMarkup *telegram.InlineKeyboardMarkup
+	if !toolFromCallback && ai.IsErrorType(err, ai.ErrorTypeContentPolicy) {
+		// the same request will be refused again, only another model can help
+		text = c.contentPolicyText(err)
+		if model := c.otherModel(err); originalMessageID != 0 && model != "" {
+			callbackData := fmt.Sprintf("ask retry:%d $m:%s", originalMessageID, model)
+			if len(callbackData) <= maxCallbackDataLength {
+				replyMarkup = &telegram.InlineKeyboardMarkup{
+					InlineKeyboard: [][]telegram.InlineKeyboardButton{
+						{telegram.NewInlineKeyboardButtonData(
+							c.L("ask.tryOtherModelButtonText", nil),
+							callbackData,
+						)},
+					},
+				}
+			}
+		}
+	} else if originalMessageID != 0 {
 		callbackData := fmt.Sprintf("ask retry:%d", originalMessageID)
-		errorMsg.ReplyMarkup = &telegram.InlineKeyboardMarkup{
+		replyMarkup = &telegram.InlineKeyboardMarkup{
 			InlineKeyboard: [][]telegram.InlineKeyboardButton{
 				{telegram.NewInlineKeyboardButtonData(
 					c.L("ask.retryButtonText", nil),
@@ -2521,51 +3238,153 @@ func (c *Command) handleErrorWithRetry(chatID int64, text string, messageID int,
 			},
 		}
 	}
+	if sendNew {
+		errorMsg := telegram.NewMessage(chatID, text, originalMessageID)
+		errorMsg.ParseMode = telegram.ModeMarkdownV2
+		errorMsg.ReplyMarkup = replyMarkup
+		c.Tg.SendWithRetry(&errorMsg, 0)
+		return err
+	}
+	errorMsg := telegram.NewEditMessageText(chatID, messageID, text)
+	errorMsg.ParseMode = telegram.ModeMarkdownV2
+	errorMsg.ReplyMarkup = replyMarkup
 	c.Tg.SendWithRetry(&errorMsg, 0)
 
 	return err
 }
 
+// contentPolicyText returns the message about the refused request with the refusal reason
+func (c *Command) contentPolicyText(err error) string {
+	var aiErr *ai.AIError
+	reason := err.Error()
+	if errors.As(err, &aiErr) && aiErr.Message != "" {
+		reason = aiErr.Message
+	}
+	c.Logger.WithError(err).WithField("reason", reason).Warn("Request refused by content policy")
+
+	message := c.L("ask.contentPolicyRefused", nil)
+	text, convErr := c.Tg.TelegramifyMarkdown(message)
+	if convErr != nil {
+		text = markdown.Escape(message)
+	}
+	return fmt.Sprintf("%s\n_%s_", text, markdown.Escape(reason))
+}
+
+// otherModel returns the model to retry the refused request with: the first fallback
+// of the failed model or the default model, empty if there is nothing to switch to
+func (c *Command) otherModel(err error) string {
+	var aiErr *ai.AIError
+	if !errors.As(err, &aiErr) || aiErr.ProviderName == "" || aiErr.ModelName == "" {
+		return ""
+	}
+	return c.modelOtherThan(aiErr.ProviderName + ":" + aiErr.ModelName)
+}
+
+// modelOtherThan returns the first fallback of the model or the default model,
+// empty if there is nothing to switch to
+func (c *Command) modelOtherThan(name string) string {
+	for _, model := range append(c.Cfg.AI().GetFallbacks(name, ""), c.Cfg.AI().GetDefaultModel()) {
+		if model != "" && model != name {
+			return model
+		}
+	}
+	return ""
+}
+
+// metadataMode returns the metadata display mode of the answer, $compact shortens
+// the metadata unless it's turned off
+func (c *Command) metadataMode() string {
+	mode := c.cmdCfg.Display.MetadataDisplayMode()
+	if c.args.Compact && mode != config.MetadataModeOff {
+		return config.MetadataModeCompact
+	}
+	return mode
+}
+
+// failureAnswer returns the friendly answer sent when all models failed,
+// a canned answer for the query is added if configured
+func (c *Command) failureAnswer(err error, query string) string {
+	failure := c.cmdCfg.Failure
+	message := failure.Message
+	if message == "" {
+		message = c.L("ask.allModelsFailed", nil)
+	}
+	if answer := failure.CannedAnswer(query); answer != "" {
+		message += "\n\n" + answer
+	}
+	text, convErr := c.Tg.TelegramifyMarkdown(message)
+	if convErr != nil {
+		text = markdown.Escape(message)
+	}
+	if failure.ShowError {
+		text = fmt.Sprintf("%s\n_%s_", text, markdown.Escape(err.Error()))
+	}
+	return text
+}
+
 func (c *Command) generateConversationTitle(ctx context.Context, text string, chatID int64) (string, string) {
-	var title string
-	var err error
 	if words := strings.Fields(text); len(words) <= 5 {
-		return text, "initial"
+		return fallbackTitle(text), "initial"
+	}
+
+	if !c.cmdCfg.GenerateTitleWithAI {
+		return fallbackTitle(text), "initial"
 	}
 
-	generateTitle := c.cmdCfg.GenerateTitleWithAI
-	if generateTitle {
-		modelName := c.Cfg.AI().GetUtilityModel()
-		model, _ := c.ai.GetFormattedModel(ctx, modelName, "")
+	modelName := c.Cfg.AI().GetUtilityModel()
+	model, err := c.ai.GetFormattedModel(ctx, modelName, "")
+	if err != nil {
+		c.Logger.WithError(err).WithField("model", modelName).Error("Failed to get utility model, fallback to initial title")
+		return fallbackTitle(text), "initial"
+	}
 
-		prompt := `Generate a brief title (no more than 5 words) for the following text.
+	prompt := `Generate a brief title (no more than 5 words) for the following text.
 The title should be informative and reflect the essence.
 Do not use quotes or add explanations.
 
 Text:
 ` + text
 
-		title, _, _, _, _, err = c.ai.Ask(ctx, []ai.Message{
-			{Role: ai.RoleSystem, Text: "You are an assistant for generating short titles. Respond only with a title."},
-			{Role: ai.RoleUser, Text: prompt},
-		}, nil, model, "", chatID, false, ai.ModelParams{})
-		if err != nil {
-			c.Logger.WithError(err).Error("Generating conversation title failed")
-		}
-	} else {
-		if utf8.RuneCountInString(title) > 40 {
-			return string([]rune(title)[40:]) + "...", "initial"
-		} else {
-			return title, "initial"
-		}
+	title, _, _, _, _, err := c.ai.Ask(ctx, []ai.Message{
+		{Role: ai.RoleSystem, Text: "You are an assistant for generating short titles. Respond only with a title."},
+		{Role: ai.RoleUser, Text: prompt},
+	}, nil, model, "", chatID, false, ai.ModelParams{})
+	if err != nil {
+		c.Logger.WithError(err).Error("Generating conversation title failed, fallback to initial title")
+		return fallbackTitle(text), "initial"
+	}
+
+	title = fallbackTitle(strings.Trim(strings.TrimSpace(title), `"'«»`))
+	if title == "" {
+		c.Logger.Warn("Utility model returned empty title, fallback to initial title")
+		return fallbackTitle(text), "initial"
 	}
 
 	return title, "llm"
 }
 
-func (c *Command) getTools(toolsList []string) []ai.Tool {
+// fallbackTitle returns the first non-empty line of the text truncated to maxTitleLength runes
+func fallbackTitle(text string) string {
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > maxTitleLength {
+			return strings.TrimSpace(string([]rune(line)[:maxTitleLength])) + "..."
+		}
+		return line
+	}
+	return ""
+}
+
+func (c *Command) getTools(request *requestState, toolsList []string) []ai.Tool {
 	responseTools := []ai.Tool{}
 	for name, tool := range tools.AvailableTools(c.cmdCfg.Tools.Allowed, c.cmdCfg.Tools.Excluded) {
+		// nothing is remembered in chats without memory
+		if name == tools.ToolRemember && !request.memory {
+			continue
+		}
 		if len(toolsList) == 0 || slices.Contains(toolsList, name) {
 			responseTools = append(responseTools, tool)
 		}
@@ -2573,8 +3392,125 @@ func (c *Command) getTools(toolsList []string) []ai.Tool {
 	return responseTools
 }
 
+// resolveToolsModel returns the model for tools iterations: $toolsmodel argument,
+// then ai.tools_model, otherwise the request model itself
+func (c *Command) resolveToolsModel(ctx context.Context, model *ai.ModelInfo) (*ai.ModelInfo, error) {
+	toolsModelName := c.Cfg.AI().ToolsModel
+	if c.args != nil && c.args.ToolsModel != "" {
+		toolsModelName = c.args.ToolsModel
+	}
+	if toolsModelName == "" {
+		return model, nil
+	}
+	return c.ai.GetFormattedModel(ctx, toolsModelName, "")
+}
+
+// answeredModel returns the OpenRouter fallback model named in the response when it
+// answered instead of the requested one, otherwise the requested model
+func (c *Command) answeredModel(model *ai.ModelInfo, usage *ai.ModelUsage) *ai.ModelInfo {
+	if usage == nil || usage.Model == "" {
+		return model
+	}
+	provider, err := c.ai.GetProvider(model.Provider)
+	if err != nil {
+		return model
+	}
+	openRouter, ok := provider.(*ai.OpenRouterClient)
+	if !ok {
+		return model
+	}
+	fallback, ok := openRouter.AnsweredFallback(model.ID, usage.Model)
+	if !ok {
+		return model
+	}
+	c.Logger.WithFields(logger.Fields{
+		"model":    model.FullName(),
+		"fallback": fallback,
+	}).Info("OpenRouter answered with fallback model")
+	info, err := openRouter.GetModelInfo(fallback)
+	if err != nil {
+		return &ai.ModelInfo{ID: fallback, Provider: model.Provider}
+	}
+	return info
+}
+
+// applyDefaultPrompt uses the default prompt if it exists for the first message of a conversation
+func (c *Command) applyDefaultPrompt(content *MessageContent) {
+	if content.Prompt.Name != "" || content.HasHistory() {
+		return
+	}
+	if aiPrompt, exists := c.Cfg.AI().GetPromptByCommand("default"); exists {
+		content.Prompt = prompt{
+			Text:   aiPrompt.Text,
+			Name:   aiPrompt.Name,
+			System: aiPrompt.SystemPrompt,
+			Model:  aiPrompt.Model,
+		}
+	}
+}
+
+// requestModelName returns the model requested for the message by precedence:
+// explicit $m, then the model of the prompt. Empty means the chat model or the default one
+func requestModelName(args *CommandArgs, prompt prompt) string {
+	if args.Model != "" {
+		return args.Model
+	}
+	return prompt.Model
+}
+
+// retryDelay returns how long to wait before retrying the request, Retry-After
+// of the provider is respected up to maxRetryDelay
+func retryDelay(err error) time.Duration {
+	if delay := ai.GetRetryAfter(err); delay > 0 {
+		return min(delay, maxRetryDelay)
+	}
+	return defaultRetryDelay
+}
+
+// nextFallbackModel returns the next available model from ai.fallbacks of the requested model,
+// if the error can be solved by switching the model
+func (c *Command) nextFallbackModel(ctx context.Context, request *requestState, model *ai.ModelInfo, err error, userID, chatID int64) *ai.ModelInfo {
+	if !ai.IsModelUnavailableError(err) {
+		return nil
+	}
+	if request.fallbackChain == nil {
+		request.fallbackChain = c.Cfg.AI().GetFallbacks(model.FullName(), model.Alias)
+	}
+	for len(request.fallbackChain) > 0 {
+		modelName := request.fallbackChain[0]
+		request.fallbackChain = request.fallbackChain[1:]
+		fallbackModel, err := c.ai.GetFormattedModel(ctx, modelName, "")
+		if err != nil {
+			c.Logger.WithError(err).WithField("model", modelName).Warn("Fallback model not available, skip")
+			continue
+		}
+		if !fallbackModel.IsFree() && (!c.Cfg.Telegram().IsAllowed(userID, chatID) || request.freeOnly) {
+			c.Logger.WithField("model", modelName).Warn("Fallback model not allowed for user, skip")
+			continue
+		}
+		return fallbackModel
+	}
+	return nil
+}
+
+// takeMultimodalFallback returns the chat model replaced by the multimodal auto switch,
+// the chat model may not understand media, so it's dropped from the request and the context notes it
+func (c *Command) takeMultimodalFallback(ctx context.Context, request *requestState, currentContent *MessageContent, response *Response) *ai.ModelInfo {
+	chatModel := request.multimodalFallback
+	if chatModel == nil || ctx.Err() != nil {
+		return nil
+	}
+	request.multimodalFallback = nil
+	c.args.HandleImages, c.args.HandleAudio, c.args.HandleFiles = false, false, false
+	currentContent.Media = nil
+	currentContent.HistoryMedia = nil
+	response.Context.SetMediaSkipped(true)
+	return chatModel
+}
+
 func (c *Command) handleRequest(
 	ctx context.Context,
+	request *requestState,
 	userConversationMessage *conversationMessage,
 	chatID int64,
 	messages []ai.Message,
@@ -2585,23 +3521,29 @@ func (c *Command) handleRequest(
 	messageID int,
 	response *Response,
 	toolFromCallback bool,
-) (totalUsage *MetadataUsage, params *ai.ModelParams, err error) {
+) (totalUsage *MetadataUsage, params *ai.ModelParams, answerMsgID int, err error) {
 	// TODO: move to config
 	maxRetries := 2
 	// first iteration - basic tools request, second iteration - request with tools results
 	maxIterations := c.cmdCfg.Tools.MaxIterations + 1
 	params = customParams
 	totalUsage = &MetadataUsage{}
+	answerMsgID = sentMsgID
 	requestTools := currentContent.Tools
 
 	currentModel := model
-	toolsModelName := c.Cfg.AI().ToolsModel
-	toolsModel := currentModel
-	if toolsModelName != "" {
-		toolsModel, err = c.ai.GetFormattedModel(ctx, toolsModelName, "")
+	toolsModel, err := c.resolveToolsModel(ctx, model)
+	if err != nil {
+		c.Logger.WithError(err).Warn("Failed to get tools model, use request model")
+		toolsModel, err = model, nil
+	}
+	if !toolsModel.IsFree() && request.freeOnly {
+		c.Logger.WithField("model", toolsModel.FullName()).Warn("Tools model is paid in free only chat, use request model")
+		toolsModel = model
 	}
 
 	isStream := *params.Stream
+	statusText := c.L("ask.thinking", nil)
 	for iteration := range maxIterations {
 		var annotations []ai.AnnotationContent
 
@@ -2611,7 +3553,7 @@ func (c *Command) handleRequest(
 		if iteration+1 == maxIterations {
 			currentModel = model
 
-			conversationHistory, err := c.getConversationHistory(chatID, sentMsgID)
+			conversationHistory, err := c.contextHistory(chatID, sentMsgID, userConversationMessage.ConversationChainID)
 			if err == nil {
 				// HACK: needed since metadata's ConversationHistoryLength is calculated from
 				// ConversationHistory. When loading from DB, we get 2 but actually want 1 here.
@@ -2626,24 +3568,54 @@ func (c *Command) handleRequest(
 		} else if len(requestTools) > 0 {
 			currentModel = toolsModel
 		}
+		// the auto switch or the tools model may use a provider without the web plugin
+		webSearch := request.search == searchPlugin && c.supportsWebSearchPlugin(currentModel)
+		stopProgress := c.startProgress(chatID, sentMsgID, statusText)
 		if isStream {
 			response.Content, response.Reasoning, tools, usage, annotations, params, err = c.AskStream(
 				ctx, messages, requestTools, currentModel, currentContent.Prompt.Name,
-				chatID, false, *params, sentMsgID,
+				chatID, webSearch, *params, sentMsgID, stopProgress,
 			)
 		} else {
 			response.Content, response.Reasoning, tools, _, usage, annotations, params, err = c.Ask(
 				ctx, messages, requestTools, currentModel, currentContent.Prompt.Name,
-				chatID, false, *params,
+				chatID, webSearch, *params,
 			)
 		}
+		stopProgress()
 		if err != nil {
+			if isRequestCanceled(ctx) {
+				c.Logger.WithField("chat_id", chatID).Info("Request canceled")
+				c.sendCanceled(chatID, sentMsgID)
+				return nil, nil, sentMsgID, err
+			}
+			// the provider couldn't download an image link, one more attempt with the images inlined
+			if ai.IsImageFetchError(err) && !request.imagesInlined {
+				request.imagesInlined = true
+				if inlinedMessages, ok := c.inlineImageURLs(messages, currentContent); ok {
+					return c.handleRequest(
+						ctx,
+						request,
+						userConversationMessage,
+						chatID,
+						inlinedMessages,
+						currentContent,
+						model,
+						customParams,
+						sentMsgID,
+						messageID,
+						response,
+						toolFromCallback,
+					)
+				}
+			}
 			if ai.IsRetryableError(err) && c.retryCount < maxRetries {
 				c.retryCount++
-				time.Sleep(time.Second)
+				time.Sleep(retryDelay(err))
 				c.Logger.Warn("RETRY " + fmt.Sprint(c.retryCount))
 				return c.handleRequest(
 					ctx,
+					request,
 					userConversationMessage,
 					chatID,
 					messages,
@@ -2656,26 +3628,99 @@ func (c *Command) handleRequest(
 					toolFromCallback,
 				)
 			}
+			// after tools iterations the messages can't be rebuilt for another model
+			if iteration == 0 && currentModel == model {
+				if fallbackModel := c.nextFallbackModel(ctx, request, model, err, userConversationMessage.UserID, chatID); fallbackModel != nil {
+					c.Logger.WithError(err).WithFields(logger.Fields{
+						"model":    model.FullName(),
+						"fallback": fallbackModel.FullName(),
+					}).Warn("Model unavailable, switch to fallback model")
+					c.retryCount = 0
+					return c.handleRequest(
+						ctx,
+						request,
+						userConversationMessage,
+						chatID,
+						c.buildPromptWithHistory(fallbackModel, currentContent, c.args, false),
+						currentContent,
+						fallbackModel,
+						customParams,
+						sentMsgID,
+						messageID,
+						response,
+						toolFromCallback,
+					)
+				}
+				if chatModel := c.takeMultimodalFallback(ctx, request, currentContent, response); chatModel != nil {
+					c.Logger.WithError(err).WithFields(logger.Fields{
+						"model":    model.FullName(),
+						"fallback": chatModel.FullName(),
+					}).Warn("Multimodal model failed, answer with chat model without media")
+					c.retryCount = 0
+					return c.handleRequest(
+						ctx,
+						request,
+						userConversationMessage,
+						chatID,
+						c.buildPromptWithHistory(chatModel, currentContent, c.args, false),
+						currentContent,
+						chatModel,
+						customParams,
+						sentMsgID,
+						messageID,
+						response,
+						toolFromCallback,
+					)
+				}
+			}
+			// total failure when nothing was answered by any model, after tools the raw error is shown
+			text, retryMessageID := "", 0
+			if iteration == 0 {
+				text = c.failureAnswer(err, currentContent.Text)
+				if c.cmdCfg.Failure.RetryButton {
+					retryMessageID = messageID
+				}
+			}
 			c.handleErrorWithRetry(
 				chatID,
-				"",
+				text,
 				sentMsgID,
-				0, // messageID if want add reply button
+				retryMessageID,
 				err,
 				toolFromCallback,
 			)
-			return nil, nil, err
+			return nil, nil, sentMsgID, err
+		}
+		c.Logger.WithField("usage", usage).Debug("Usage info")
+		// acknowledged with reaction, there is no message yet
+		if sentMsgID == 0 {
+			sentMsgID, err = c.sendAnswerMessage(chatID, messageID, response.Content)
+			if err != nil {
+				c.Logger.WithError(err).Error("Failed to send answer message")
+				return nil, nil, 0, err
+			}
+			answerMsgID = sentMsgID
 		}
-		c.Logger.Debug("Usage info: %v", usage)
 		usageInfo := NewMetadataUsageFrom(usage)
 		totalUsage.Add(usageInfo)
+		c.metrics.ObserveRequest(model.FullName(), usageInfo.Input, usageInfo.Output, usageInfo.Cost)
+		// the model that actually answered, it differs from the requested one after fallback
+		answerModel := model
+		if currentModel == model {
+			answerModel = c.answeredModel(model, usage)
+		}
+		response.Metadata.Model = answerModel
 
+		historyText := response.Content
+		if !c.cmdCfg.Display.PersistReasoning {
+			historyText = stripReasoning(historyText)
+		}
 		assistantMessage, saveErr := c.saveMessage(NewAssistantConversationMessage(
 			userConversationMessage,
 			sentMsgID,
 			c.Tg.Self().ID,
-			response.Content,
-			model.FullName(),
+			historyText,
+			answerModel.FullName(),
 			params,
 			usageInfo,
 			annotations,
@@ -2720,19 +3765,23 @@ func (c *Command) handleRequest(
 		)
 		c.Tg.Send(tgMsg)
 
-		messagesTools, saveErr := c.handleTools(ctx, tools, assistantMessage)
+		messagesTools, failedTools, saveErr := c.handleTools(ctx, tools, assistantMessage, currentContent.GetEditableImage())
 		if saveErr != nil {
 			c.Logger.WithError(saveErr).Warn("Partial tool execution failure")
 		}
+		for _, name := range failedTools {
+			response.Context.AddFailedTool(name)
+		}
 		messages = append(messages, ai.Message{
 			Role:      ai.RoleAssistant,
 			ToolCalls: tools,
 		})
 		messages = append(messages, messagesTools...)
+		statusText = c.L("ask.stillThinking", nil)
 		tgMsg = telegram.NewEditMessageText(
 			chatID,
 			sentMsgID,
-			c.L("ask.stillThinking", nil),
+			statusText,
 		)
 		c.Tg.Send(tgMsg)
 
@@ -2746,31 +3795,106 @@ func (c *Command) handleRequest(
 	return
 }
 
-func (c *Command) handleTools(ctx context.Context, toolsList []ai.ToolCall, assistantMessage *conversationMessage) ([]ai.Message, error) {
+// handleTools runs the tools concurrently. It returns the tool messages for the
+// follow-up request and the names of tools failed after all attempts
+func (c *Command) handleTools(ctx context.Context, toolsList []ai.ToolCall, assistantMessage *conversationMessage, sourceImage string) ([]ai.Message, []string, error) {
 	if len(toolsList) == 0 {
-		return nil, errors.New("tools empty")
+		return nil, nil, errors.New("tools empty")
 	}
 
 	// NOTE: HANDLE TOOLS
-	const maxRetries = 3
+	results := c.runTools(ctx, toolsList, func(ctx context.Context, tool ai.ToolCall, args map[string]any, toolLog logger.Logger) (string, error) {
+		return c.runSingleTool(ctx, tool, args, assistantMessage, sourceImage, toolLog)
+	})
+
 	response := []ai.Message{}
+	var failed []string
+	for i, result := range results {
+		tool := toolsList[i]
+		toolFailed := result.err != nil || result.failed
+		if result.err == nil {
+			strategy := c.toolTruncateStrategy(tool.Function.Name)
+			if truncated := truncateToolResponse(result.response, c.cmdCfg.Tools.MaxResponseLength, strategy); truncated != result.response {
+				c.Logger.WithFields(logger.Fields{
+					"function": tool.Function.Name,
+					"length":   utf8.RuneCountInString(result.response),
+					"strategy": strategy,
+				}).Info("Tool response truncated")
+				result.response = truncated
+			}
+			toolResponseMsg := ai.Message{
+				Role:       ai.RoleTool,
+				ToolCallID: tool.ID,
+				Text:       result.response,
+			}
+			_, err := c.saveMessage(NewToolConversationMessage(
+				assistantMessage,
+				result.response,
+				tool.Function.Name,
+				result.args,
+				[]ai.Message{toolResponseMsg},
+			))
+			if err != nil {
+				c.Logger.WithError(err).WithFields(logger.Fields{
+					"function":      tool.Function.Name,
+					"tool_id":       tool.ID,
+					"tool_response": result.response,
+				}).Error("Error saving tool response to database. Skip tool")
+				toolFailed = true
+			} else {
+				response = append(response, toolResponseMsg)
+			}
+		}
+		if toolFailed {
+			failed = append(failed, tool.Function.Name)
+		}
+		c.metrics.ObserveTool(tool.Function.Name, toolFailed)
+	}
 
-	type toolResult struct {
-		index    int
-		response ai.Message
-		err      error
+	return response, failed, nil
+}
+
+// toolRunFunc runs one attempt of the tool call
+type toolRunFunc func(ctx context.Context, tool ai.ToolCall, args map[string]any, toolLog logger.Logger) (string, error)
+
+type toolResult struct {
+	args     map[string]any
+	response string
+	// the response describes the error when all attempts failed or the tool timed out
+	failed bool
+	// the arguments can't be parsed, the tool isn't run
+	err error
+}
+
+// errToolTimeout is returned for the tool attempt cut off by tools.timeout or tools.total_timeout
+var errToolTimeout = errors.New("tool timed out")
+
+// errToolsTotalTimeout is the cause of the end of the tools context after tools.total_timeout
+var errToolsTotalTimeout = errors.New("tools total timeout")
+
+// toolsWithoutTimeout take longer than tools.timeout by nature, only tools.total_timeout limits them
+var toolsWithoutTimeout = []string{tools.ToolGenerateImage}
+
+// runTools runs the tools concurrently with retries and returns the results in the order of the calls.
+// Each attempt is limited by tools.timeout and all tools by tools.total_timeout,
+// a timed out tool isn't retried and doesn't delay the others. A cut off attempt keeps running
+// in the background, runSingleTool skips its side effects when the context is done
+func (c *Command) runTools(ctx context.Context, toolsList []ai.ToolCall, run toolRunFunc) []toolResult {
+	const maxRetries = 3
+	toolsCfg := c.cmdCfg.Tools
+	if toolsCfg.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, toolsCfg.TotalTimeout, fmt.Errorf("%w %s exceeded", errToolsTotalTimeout, toolsCfg.TotalTimeout))
+		defer cancel()
 	}
 
-	resultChan := make(chan toolResult, len(toolsList))
+	results := make([]toolResult, len(toolsList))
 	var wg sync.WaitGroup
-
 	for i, tool := range toolsList {
 		wg.Add(1)
 		go func(idx int, tool ai.ToolCall) {
 			defer wg.Done()
 
-			var toolResponse string
-			var toolResponseMsg ai.Message
 			toolLog := c.Logger.WithFields(logger.Fields{
 				"function":  tool.Function.Name,
 				"tool_id":   tool.ID,
@@ -2779,32 +3903,50 @@ func (c *Command) handleTools(ctx context.Context, toolsList []ai.ToolCall, assi
 			args, err := tool.Function.GetArguments()
 			if err != nil {
 				toolLog.WithError(err).Error("Args unmarshal error")
-				resultChan <- toolResult{index: idx, err: err}
+				results[idx] = toolResult{err: err}
 				return
 			}
 
+			timeout := toolsCfg.Timeout
+			if slices.Contains(toolsWithoutTimeout, tool.Function.Name) {
+				timeout = 0
+			}
+			var toolResponse string
 			var lastErr error
 			retryCount := 0
 			for attempt := 1; attempt <= maxRetries; attempt++ {
 				retryCount++
 				toolLog.WithField("attempt", attempt).Info("Running tool...")
 
-				toolResponse, lastErr = c.runSingleTool(ctx, tool, args, assistantMessage, toolLog)
+				toolResponse, lastErr = runToolWithTimeout(ctx, timeout, func(ctx context.Context) (string, error) {
+					return run(ctx, tool, args, toolLog)
+				})
 				if lastErr == nil {
 					break
 				}
 
-				if strings.Contains(lastErr.Error(), "403") {
+				// the request is canceled or ended, the next attempts are stopped too
+				if strings.Contains(lastErr.Error(), "403") || errors.Is(lastErr, errToolTimeout) || ctx.Err() != nil {
 					break
 				}
 
 				toolLog.WithError(lastErr).Warn(fmt.Sprintf("Tool attempt %d failed", attempt))
 				if attempt < maxRetries {
-					time.Sleep(time.Second * time.Duration(attempt)) // Exponential backoff
+					select {
+					case <-time.After(time.Second * time.Duration(attempt)): // Exponential backoff
+					case <-ctx.Done():
+					}
 				}
 			}
 
-			if lastErr != nil {
+			switch {
+			case errors.Is(lastErr, errToolTimeout):
+				toolLog.WithError(lastErr).Error("Tool timed out")
+				toolResponse = fmt.Sprintf("Tool error: %v, no result", lastErr)
+			case lastErr != nil && ctx.Err() != nil:
+				toolLog.WithError(lastErr).Warn("Tool stopped with the request")
+				toolResponse = fmt.Sprintf("Tool error: %v, no result", lastErr)
+			case lastErr != nil:
 				toolLog.WithError(lastErr).Error("All tool attempts failed")
 				toolResponse = fmt.Sprintf(
 					"Tool error after %d attempts: %v",
@@ -2812,52 +3954,68 @@ func (c *Command) handleTools(ctx context.Context, toolsList []ai.ToolCall, assi
 					lastErr,
 				)
 			}
+			results[idx] = toolResult{args: args, response: toolResponse, failed: lastErr != nil}
+		}(i, tool)
+	}
+	wg.Wait()
 
-			toolResponseMsg = ai.Message{
-				Role:       ai.RoleTool,
-				ToolCallID: tool.ID,
-				Text:       toolResponse,
-			}
-
-			_, err = c.saveMessage(NewToolConversationMessage(
-				assistantMessage,
-				toolResponse,
-				tool.Function.Name,
-				args,
-				[]ai.Message{toolResponseMsg},
-			))
-			if err != nil {
-				toolLog.WithFields(logger.Fields{
-					"tool_response": toolResponse,
-				}).Error("Error saving tool response to database. Skip tool")
-				resultChan <- toolResult{index: idx, err: err}
-				return
-			}
+	return results
+}
 
-			resultChan <- toolResult{index: idx, response: toolResponseMsg}
-		}(i, tool)
+// runToolWithTimeout runs the tool attempt until the timeout or the end of ctx.
+// Tools don't stop on the context, so the cut off attempt finishes in the background,
+// its result is dropped and its side effects are skipped by runSingleTool
+func runToolWithTimeout(ctx context.Context, timeout time.Duration, run func(context.Context) (string, error)) (string, error) {
+	parent := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if ctx.Err() != nil {
+		return "", toolTimeoutError(parent, timeout)
 	}
 
+	type attemptResult struct {
+		response string
+		err      error
+	}
+	done := make(chan attemptResult, 1)
 	go func() {
-		wg.Wait()
-		close(resultChan)
+		response, err := run(ctx)
+		done <- attemptResult{response: response, err: err}
 	}()
-
-	results := make([]*toolResult, len(toolsList))
-	for result := range resultChan {
-		results[result.index] = &result
+	select {
+	case result := <-done:
+		return result.response, result.err
+	case <-ctx.Done():
+		return "", toolTimeoutError(parent, timeout)
 	}
+}
 
-	for _, result := range results {
-		if result != nil && result.err == nil {
-			response = append(response, result.response)
-		}
+// toolTimeoutError describes what cut off the attempt, the parent context ends with
+// tools.total_timeout or with the request, /cancel and the request timeout aren't tool timeouts
+func toolTimeoutError(parent context.Context, timeout time.Duration) error {
+	cause := context.Cause(parent)
+	switch {
+	case parent.Err() == nil:
+		return fmt.Errorf("%w after %s", errToolTimeout, timeout)
+	case errors.Is(cause, errToolsTotalTimeout):
+		return fmt.Errorf("%w: %w", errToolTimeout, cause)
+	case isRequestCanceled(parent):
+		return fmt.Errorf("tool canceled: %w", cause)
+	default:
+		return fmt.Errorf("tool stopped with the request: %w", cause)
 	}
+}
 
-	return response, nil
+// toolCutOffError is returned instead of the side effect of the attempt that ran past its context,
+// the user mustn't get an image or a reminder the model was told about as timed out
+func toolCutOffError(ctx context.Context) error {
+	return fmt.Errorf("result dropped: %w", context.Cause(ctx))
 }
 
-func (c *Command) runSingleTool(ctx context.Context, tool ai.ToolCall, args map[string]any, assistantMessage *conversationMessage, toolLog logger.Logger) (string, error) {
+func (c *Command) runSingleTool(ctx context.Context, tool ai.ToolCall, args map[string]any, assistantMessage *conversationMessage, sourceImage string, toolLog logger.Logger) (string, error) {
 	toolName := capitalizeFirst(tool.Function.Name)
 	method := reflect.ValueOf(c.toolsRunner).MethodByName(toolName)
 	if !method.IsValid() {
@@ -2891,10 +4049,15 @@ func (c *Command) runSingleTool(ctx context.Context, tool ai.ToolCall, args map[
 			limitFloat = 0
 		}
 		limitArg := int(limitFloat)
+		keywordArg, ok := args["keyword"].(string)
+		if !ok {
+			keywordArg = ""
+		}
 		argsReflect = []reflect.Value{
 			reflect.ValueOf(channelNameArg),
 			reflect.ValueOf(durationArg),
 			reflect.ValueOf(limitArg),
+			reflect.ValueOf(keywordArg),
 		}
 		results = method.Call(argsReflect)
 	case tools.ToolFetchTgPostComments:
@@ -2942,14 +4105,37 @@ func (c *Command) runSingleTool(ctx context.Context, tool ai.ToolCall, args map[
 			reflect.ValueOf(urlArg),
 		}
 		results = method.Call(argsReflect)
+	case tools.ToolFetchRSS:
+		urlArg, _ := args["url"].(string)
+		limitFloat, ok := args["limit"].(float64)
+		if !ok {
+			limitFloat = 0
+		}
+		argsReflect = []reflect.Value{
+			reflect.ValueOf(urlArg),
+			reflect.ValueOf(int(limitFloat)),
+		}
+		results = method.Call(argsReflect)
 	case tools.ToolGenerateImage:
 		prompt := args["prompt"].(string)
+		// the source image is sent only on explicit request to edit it
+		inputImage := ""
+		if edit, _ := args["image"].(bool); edit {
+			inputImage = sourceImage
+			if inputImage == "" {
+				toolLog.Warn("Image edit requested without attached image, generate new image")
+			}
+		}
 		argsReflect := []reflect.Value{
 			reflect.ValueOf(prompt),
 			reflect.ValueOf(c.Cfg.AI().ImageRouterModel),
 			reflect.ValueOf(c.Cfg.AI().ImageRouterAPIKey),
+			reflect.ValueOf(inputImage),
 		}
 		results = method.Call(argsReflect)
+		if ctx.Err() != nil {
+			return "", toolCutOffError(ctx)
+		}
 		if !results[3].IsNil() {
 			err := results[3].Interface().(error)
 			toolLog.WithError(err).Error("Generate image failed")
@@ -2967,23 +4153,146 @@ func (c *Command) runSingleTool(ctx context.Context, tool ai.ToolCall, args map[
 					markdown.Escape(prompt),
 					BotMessageMarker,
 				)
-				tgMsg := telegram.NewPhotoMessage(
-					assistantMessage.ChatID,
-					telegram.FileBytes{Name: "generated_image.jpg", Bytes: decodedImage},
-					text,
-					assistantMessage.MessageID,
-				)
-				tgMsg.ParseMode = telegram.ModeMarkdownV2
-				resp, err := c.Tg.Send(tgMsg)
-				if err != nil {
-					toolLog.WithError(err).Error("Send generated image failed")
-				} else if _, err := c.saveMessage(NewInternalConversationMessage(assistantMessage, resp.MessageID)); err != nil {
-					toolLog.WithError(err).Error("Save internal message failed")
-				}
+				c.sendGeneratedImage(assistantMessage, decodedImage, text, toolLog)
 			} else {
 				toolLog.WithError(err).Error("Decode base64 generated image failed")
 			}
 		}
+	case tools.ToolMakeChart:
+		chartType, _ := args["type"].(string)
+		title, _ := args["title"].(string)
+		labels, series := chartArgs(args)
+		argsReflect = []reflect.Value{
+			reflect.ValueOf(chartType),
+			reflect.ValueOf(title),
+			reflect.ValueOf(labels),
+			reflect.ValueOf(series),
+		}
+		results = method.Call(argsReflect)
+		if ctx.Err() != nil {
+			return "", toolCutOffError(ctx)
+		}
+		if results[2].IsNil() {
+			c.sendChart(assistantMessage, results[1].Bytes(), title, toolLog)
+		}
+	case tools.ToolSetReminder:
+		userID, userMessageID, err := c.getUserMessageInfo(assistantMessage)
+		if err != nil {
+			return "", fmt.Errorf("failed to get user message: %w", err)
+		}
+		if limit := c.cmdCfg.Tools.MaxPendingReminders; limit > 0 {
+			pending, err := c.db.CountPendingReminders(userID)
+			if err != nil {
+				return "", fmt.Errorf("failed to count pending reminders: %w", err)
+			}
+			// not an error, the model should tell the user about it
+			if pending >= limit {
+				return fmt.Sprintf("Reminder is not set: the user already has %d pending reminders, the limit is %d", pending, limit), nil
+			}
+		}
+		when, _ := args["when"].(string)
+		message, _ := args["message"].(string)
+		argsReflect = []reflect.Value{
+			reflect.ValueOf(when),
+			reflect.ValueOf(message),
+			reflect.ValueOf(time.Now()),
+		}
+		results = method.Call(argsReflect)
+		if ctx.Err() != nil {
+			return "", toolCutOffError(ctx)
+		}
+		if results[2].IsNil() {
+			reminderID, err := c.db.AddReminder(database.Reminder{
+				ChatID:    assistantMessage.ChatID,
+				MessageID: userMessageID,
+				UserID:    userID,
+				Text:      message,
+				RemindAt:  results[1].Interface().(time.Time),
+			})
+			if err != nil {
+				return "", fmt.Errorf("failed to save reminder: %w", err)
+			}
+			toolLog.WithField("reminder_id", reminderID).Info("Reminder saved")
+		}
+	case tools.ToolRemember:
+		// not an error, the model should tell the user about it
+		if !c.ChatService.IsMemoryEnabled(assistantMessage.ChatID) {
+			return "Fact is not remembered: memory is off in this chat, it can be switched on with /settings memory on", nil
+		}
+		userID, _, err := c.getUserMessageInfo(assistantMessage)
+		if err != nil {
+			return "", fmt.Errorf("failed to get user message: %w", err)
+		}
+		fact, _ := args["fact"].(string)
+		argsReflect = []reflect.Value{
+			reflect.ValueOf(fact),
+		}
+		results = method.Call(argsReflect)
+		if ctx.Err() != nil {
+			return "", toolCutOffError(ctx)
+		}
+		if results[1].IsNil() {
+			if err := c.rememberFact(userID, fact, toolLog); err != nil {
+				return "", fmt.Errorf("failed to save fact: %w", err)
+			}
+		}
+	case tools.ToolGetUserInfo:
+		userID, _, err := c.getUserMessageInfo(assistantMessage)
+		if err != nil {
+			return "", fmt.Errorf("failed to get user message: %w", err)
+		}
+		user, err := c.db.GetUser(userID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get user: %w", err)
+		}
+		// only the public ID, the telegram ID is never shown to the model
+		argsReflect = []reflect.Value{
+			reflect.ValueOf(user.FirstName),
+			reflect.ValueOf(user.PublicID),
+			reflect.ValueOf(user.About),
+		}
+		results = method.Call(argsReflect)
+	case tools.ToolConvert:
+		amount, _ := args["amount"].(float64)
+		from, _ := args["from"].(string)
+		to, _ := args["to"].(string)
+		kind, _ := args["kind"].(string)
+		argsReflect = []reflect.Value{
+			reflect.ValueOf(amount),
+			reflect.ValueOf(from),
+			reflect.ValueOf(to),
+			reflect.ValueOf(kind),
+			reflect.ValueOf(c.cmdCfg.Tools.ConvertRatesURL),
+		}
+		results = method.Call(argsReflect)
+	case tools.ToolCalc:
+		expression, _ := args["expression"].(string)
+		argsReflect = []reflect.Value{
+			reflect.ValueOf(expression),
+		}
+		results = method.Call(argsReflect)
+	case tools.ToolTranslate:
+		model, err := c.ai.GetFormattedModel(ctx, c.Cfg.AI().GetTranslateModel(), "")
+		if err != nil {
+			return "", fmt.Errorf("failed to get translate model: %w", err)
+		}
+		translate := func(systemPrompt, text string) (string, error) {
+			answer, _, _, _, _, _, _, err := c.Ask(ctx, []ai.Message{
+				{Role: ai.RoleSystem, Text: systemPrompt},
+				{Role: ai.RoleUser, Text: text},
+			}, nil, model, "", assistantMessage.ChatID, false, ai.ModelParams{})
+			return answer, err
+		}
+		text, _ := args["text"].(string)
+		from, _ := args["from"].(string)
+		to, _ := args["to"].(string)
+		argsReflect = []reflect.Value{
+			reflect.ValueOf(text),
+			reflect.ValueOf(from),
+			reflect.ValueOf(to),
+			reflect.ValueOf(translate),
+		}
+		results = method.Call(argsReflect)
 	case tools.ToolSearchImages:
 		keywords := args["keywords"]
 		maxResultsFloat, ok := args["max_results"].(float64)
@@ -3001,17 +4310,16 @@ func (c *Command) runSingleTool(ctx context.Context, tool ai.ToolCall, args map[
 			reflect.ValueOf(timeLimitArg),
 		}
 		results = method.Call(argsReflect)
+		if ctx.Err() != nil {
+			return "", toolCutOffError(ctx)
+		}
 		if !results[1].IsNil() && results[1].Len() > 0 {
 			images := extractStringSlice(results[1])
-			mediaInputs := []telegram.InputMedia{}
-			for _, image := range images {
-				mediaInputs = append(mediaInputs, telegram.NewPhotoMedia(telegram.FileURL(image)))
-			}
-			tgMsg := telegram.NewMediaGroupMessage(assistantMessage.ChatID, mediaInputs)
-			tgMsg.ReplyTo = assistantMessage.MessageID
-			_, err := c.Tg.Send(tgMsg)
-			if err != nil {
-				toolLog.WithError(err).Error("Send media group failed")
+			c.sendFoundImages(assistantMessage, images, toolLog)
+			if analyze, _ := args["analyze"].(bool); analyze {
+				query, _ := keywords.(string)
+				analysis := c.analyzeFoundImages(ctx, assistantMessage, query, images, toolLog)
+				return results[0].String() + "\n\n" + analysis, nil
 			}
 		} else {
 			toolLog.Warn("Images not found")
@@ -3032,6 +4340,17 @@ func (c *Command) runSingleTool(ctx context.Context, tool ai.ToolCall, args map[
 	return toolResponse, nil
 }
 
+// getUserMessageInfo returns the author and telegram id of the message the assistant answered
+func (c *Command) getUserMessageInfo(assistantMessage *conversationMessage) (int64, int, error) {
+	var userID int64
+	var messageID int
+	err := c.db.QueryRow(
+		"SELECT user_id, message_id FROM conversation_history WHERE id = ?",
+		assistantMessage.ParentMessageID.Int64,
+	).Scan(&userID, &messageID)
+	return userID, messageID, err
+}
+
 func (c *Command) summarize(ctx context.Context, text string, chatID int64) (summary string, err error) {
 	modelName := c.Cfg.AI().GetUtilityModel()
 	model, err := c.ai.GetFormattedModel(ctx, modelName, "")
@@ -3067,9 +4386,15 @@ Text to summarize:
 	}, nil, model, "", chatID, false, ai.ModelParams{})
 	if err != nil {
 		c.Logger.WithError(err).Error("Generating conversation summary failed")
+		return "", err
 	}
 
-	return
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return "", errors.New("utility model returned empty summary")
+	}
+
+	return summary, nil
 }
 
 func (c *Command) generate(ctx context.Context, prompt, addition string, chatID int64) (answer string, err error) {
@@ -3107,6 +4432,46 @@ Darkness breathes softly`},
 	return
 }
 
+// isQuickRequest reports whether the answer doesn't need a message to update
+// in progress (stream or tools), so it can be acknowledged with a reaction
+func (c *Command) isQuickRequest() bool {
+	useStream := c.Cfg.AI().UseStream
+	if c.args.Stream != nil {
+		useStream = *c.args.Stream
+	}
+	return !useStream && c.args.Tools == ""
+}
+
+// acknowledgeWithReaction sets a reaction on the user's message instead of the thinking message.
+// Returns false when reactions are disabled for the chat or the bot can't react there
+func (c *Command) acknowledgeWithReaction(chatID int64, messageID int) bool {
+	if !c.cmdCfg.Reaction.IsEnabledForChat(chatID) {
+		return false
+	}
+	if err := c.Tg.SetReaction(chatID, messageID, c.cmdCfg.Reaction.Emoji); err != nil {
+		c.Logger.WithError(err).WithFields(logger.Fields{
+			"chat_id":    chatID,
+			"message_id": messageID,
+		}).Warn("Failed to set reaction, fallback to thinking message")
+		return false
+	}
+	return true
+}
+
+// sendAnswerMessage sends the raw answer as a new message, it's edited with the formatted one later
+func (c *Command) sendAnswerMessage(chatID int64, replyTo int, content string) (int, error) {
+	text := strings.TrimSpace(content)
+	if text == "" {
+		text = c.L("ask.thinking", nil)
+	}
+	if utf8.RuneCountInString(text) > 4000 {
+		text = string([]rune(text)[:4000]) + "..."
+	}
+	return c.sendOrEditMessage(chatID, replyTo, 0, text, &telegram.TextMessage{
+		LinkPreviewDisabled: true,
+	})
+}
+
 func (c *Command) sendTypingMessage(chatID int64) {
 	// Send typing action
 	err := c.Tg.SendChatAction(chatID, telegram.ActionTyping)
@@ -3153,17 +4518,18 @@ Response anatomy. The response consists of:
 
 To interact with the bot, use one of the commands (explained later), mention @gachigazer_bot with your message, or reply to any message you want the bot to process. To continue dialogue, reply to the bot's message. This works in both private chats and group chats. The bot maintains full conversation history including images and links regardless of the model used, allowing model switching. For more thoughtful responses, you can switch to a reasoning model using appropriate arguments ($think if default reasoning model is set).
 Important! If the bot responds in streaming mode, you can only reply to completed responses.
-When replying to the bot's message, the context is taken from that message's state. This adds flexibility - you can reply to the same message twice for different results, repeat requests, or easily discard parts of the message chain by replying to older messages. Multiple people can ask different questions without interfering. The bot stores all message info in context - sender, timestamp, content, forwarding info, channel info (if applicable), images, polls etc. This allows natural conversation flow, remembering participants by name and characterizing them within message chains. Useful for scenarios like turn-based games with the bot, group discussions, and fun interactions. No user IDs or usernames are sent to the bot unless explicitly included in messages.
-Responses are limited to ~850 tokens and contained in a single message.
+When replying to the bot's message, the context is taken from that message's state. This adds flexibility - you can reply to the same message twice for different results, repeat requests, or easily discard parts of the message chain by replying to older messages. Multiple people can ask different questions without interfering. The bot stores all message info in context - sender, timestamp, content, forwarding info, channel info (if applicable), images, polls etc. This allows natural conversation flow, remembering participants by name and characterizing them within message chains. Useful for scenarios like turn-based games with the bot, group discussions, and fun interactions. No user IDs or usernames are sent to the bot unless explicitly included in messages. With the get_user_info tool the bot can see the first name, public ID and the description set with /setabout of the asker, never the Telegram ID.
+Responses are limited to ~850 tokens and contained in a single message ($len:long lifts the limit, $len:short asks for a few sentences).
 
 The bot can process various content types:
 
 1. Links. Extracts text content from URLs. Be cautious with paid models as content may be too long (configurable max length). Link processing can be disabled by default and enabled manually via $u argument. Custom fetchers optimize content for LLMs:  
-- YouTube: Video title and transcript
+- YouTube: Video title, description, chapters and transcript
 - Reddit: Post content (including images) and comments
 - GitHub: Readme content, repo info (stars, activity, issues, author), file contents
 - Habr: Article content and rated comments
 - Telegram: Post content and images
+- Twitch: Clip/VOD title, streamer, category, views and thumbnail
 - etc.
 2. Images. Accepts any images (model must support vision). Config can specify multimodal model for automatic switching when images are detected, with limits on image count and lifetime.
 3. PDF files. Processed via Openrouter's free engine or natively by supporting models.
@@ -3190,8 +4556,10 @@ Available tools:
 %s
 
 Use /info on bot messages to view context images, tool responses, and fetched link content.
+Use /import <t.me/c link> to copy a conversation from another chat into the current one and continue it there, for private chats /export on a bot message gives the import command.
+Use /cancel to stop your running requests in the chat.
 
-The bot supports various message arguments (all starting with $). Some model behavior arguments ($stream, $temp, $topp) persist in subsequent messages. The $c argument injects additional context from previous chat messages (requires bot access to all messages).
+The bot supports various message arguments (all starting with $). Some model behavior arguments ($stream, $temp, $topp, $len, $effort, $rtokens) persist in subsequent messages. The $c argument injects additional context from previous chat messages (requires bot access to all messages).
 Available arguments:
 %s
 
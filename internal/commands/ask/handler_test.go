@@ -0,0 +1,970 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/ai/tools"
+	"github.com/muratoffalex/gachigazer/internal/cache"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	fetch "github.com/muratoffalex/gachigazer/internal/fetcher"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/markdown"
+	"github.com/muratoffalex/gachigazer/internal/service"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newReactionTestCommand(tg telegram.Client, enabled bool, chats []int64) *Command {
+	cmdCfg := &config.AskCommandConfig{}
+	cmdCfg.Reaction.Enabled = enabled
+	cmdCfg.Reaction.Emoji = "👀"
+	cmdCfg.Reaction.Chats = chats
+	return &Command{
+		Command: &base.Command{
+			Tg:     tg,
+			Logger: logger.NewTestLogger(),
+		},
+		cmdCfg: cmdCfg,
+	}
+}
+
+func TestCommand_acknowledgeWithReaction(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+		tg.EXPECT().SetReaction(int64(100), 42, "👀").Return(nil).Once()
+
+		cmd := newReactionTestCommand(tg, true, nil)
+
+		assert.True(t, cmd.acknowledgeWithReaction(100, 42), "Should acknowledge with reaction")
+	})
+
+	t.Run("ChatInList", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+		tg.EXPECT().SetReaction(int64(100), 42, "👀").Return(nil).Once()
+
+		cmd := newReactionTestCommand(tg, true, []int64{100, 200})
+
+		assert.True(t, cmd.acknowledgeWithReaction(100, 42), "Should acknowledge with reaction in listed chat")
+	})
+
+	t.Run("ChatNotInList", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+
+		cmd := newReactionTestCommand(tg, true, []int64{200})
+
+		assert.False(t, cmd.acknowledgeWithReaction(100, 42), "Should not react in unlisted chat")
+		tg.AssertNotCalled(t, "SetReaction")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+
+		cmd := newReactionTestCommand(tg, false, nil)
+
+		assert.False(t, cmd.acknowledgeWithReaction(100, 42), "Should not react when disabled")
+		tg.AssertNotCalled(t, "SetReaction")
+	})
+
+	t.Run("ReactionsNotAllowed", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+		tg.EXPECT().
+			SetReaction(int64(100), 42, "👀").
+			Return(errors.New("Bad Request: REACTION_INVALID")).
+			Once()
+
+		cmd := newReactionTestCommand(tg, true, nil)
+
+		assert.False(t, cmd.acknowledgeWithReaction(100, 42), "Should fallback when bot can't react")
+	})
+}
+
+func TestFallbackTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"only spaces", "  \n\t\n ", ""},
+		{"short", "Hello there", "Hello there"},
+		{"first non-empty line", "\n\n  First line  \nSecond line", "First line"},
+		{"exactly max length", strings.Repeat("a", maxTitleLength), strings.Repeat("a", maxTitleLength)},
+		{"long", strings.Repeat("a", maxTitleLength) + "tail", strings.Repeat("a", maxTitleLength) + "..."},
+		{"long multibyte", strings.Repeat("я", maxTitleLength+1), strings.Repeat("я", maxTitleLength) + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fallbackTitle(tt.text))
+		})
+	}
+}
+
+func TestCommand_generateConversationTitle_WithoutAI(t *testing.T) {
+	cmd := &Command{
+		Command: &base.Command{Logger: logger.NewTestLogger()},
+		cmdCfg:  &config.AskCommandConfig{GenerateTitleWithAI: false},
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		title, source := cmd.generateConversationTitle(t.Context(), "", 1)
+		assert.Empty(t, title)
+		assert.Equal(t, "initial", source)
+	})
+
+	t.Run("short", func(t *testing.T) {
+		title, source := cmd.generateConversationTitle(t.Context(), "What is Go?", 1)
+		assert.Equal(t, "What is Go?", title)
+		assert.Equal(t, "initial", source)
+	})
+
+	t.Run("long takes beginning of the text", func(t *testing.T) {
+		text := "Please explain how the garbage collector works in Go and when it runs\nSecond line"
+		title, source := cmd.generateConversationTitle(t.Context(), text, 1)
+		assert.Equal(t, "Please explain how the garbage collector...", title)
+		assert.Equal(t, "initial", source)
+	})
+
+	t.Run("long single word does not panic", func(t *testing.T) {
+		text := strings.Repeat("word ", 3) + strings.Repeat("x", 100) + " a b c"
+		assert.NotPanics(t, func() {
+			title, _ := cmd.generateConversationTitle(t.Context(), text, 1)
+			assert.Equal(t, maxTitleLength+len("..."), len([]rune(title)))
+		})
+	})
+}
+
+func TestMessageContent_GetMessageContent_QuoteOnly(t *testing.T) {
+	newContent := func(quoteOnly bool) *MessageContent {
+		return &MessageContent{
+			Text:      "what does it mean?",
+			Quote:     "the quoted sentence",
+			QuoteOnly: quoteOnly,
+			ReplyMsgContent: &MessageContent{
+				Text:     "a long forwarded message with the quoted sentence inside",
+				UserInfo: userInfo{Name: "Alice"},
+			},
+		}
+	}
+
+	full := newContent(false).GetMessageContent()
+	assert.Contains(t, full, "a long forwarded message")
+	assert.Contains(t, full, "[QUOTE]\nthe quoted sentence")
+
+	quoteOnly := newContent(true).GetMessageContent()
+	assert.NotContains(t, quoteOnly, "a long forwarded message")
+	assert.Contains(t, quoteOnly, "[REPLY TO: Alice(")
+	assert.Contains(t, quoteOnly, "[QUOTE]\nthe quoted sentence")
+	assert.Contains(t, quoteOnly, "what does it mean?")
+}
+
+func TestMessageContent_GetMessageContent_Raw(t *testing.T) {
+	content := &MessageContent{
+		Text:     "translate to French",
+		Raw:      true,
+		UserInfo: userInfo{Name: "Bob"},
+		ReplyMsgContent: &MessageContent{
+			Text:     "good morning",
+			UserInfo: userInfo{Name: "Alice"},
+		},
+	}
+
+	assert.Equal(t, "good morning\n\ntranslate to French", content.GetMessageContent())
+
+	content.ReplyMsgContent = nil
+	content.Text = ""
+	assert.Empty(t, content.GetMessageContent(), "Raw content has no [NO TEXT] marker")
+}
+
+type stubProvider struct {
+	ai.Provider
+	models map[string]*ai.ModelInfo
+}
+
+func (p stubProvider) GetModelInfo(name string) (*ai.ModelInfo, error) {
+	if model, ok := p.models[name]; ok {
+		return model, nil
+	}
+	return nil, errors.New("model not found")
+}
+
+func newToolsModelTestCommand(t *testing.T, args *CommandArgs) *Command {
+	t.Setenv("GACHIGAZER_TELEGRAM_TOKEN", "token")
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	registry := ai.NewProviderRegistry(cfg, logger.NewTestLogger())
+	registry.RegisterProvider("test", stubProvider{models: map[string]*ai.ModelInfo{
+		"override": {ID: "override", Provider: "test"},
+	}})
+
+	return &Command{
+		Command: &base.Command{Cfg: cfg, Logger: logger.NewTestLogger()},
+		ai:      registry,
+		args:    args,
+	}
+}
+
+func TestCommand_resolveToolsModel(t *testing.T) {
+	requestModel := &ai.ModelInfo{ID: "main", Provider: "test"}
+
+	t.Run("override is used for tools iteration", func(t *testing.T) {
+		cmd := newToolsModelTestCommand(t, &CommandArgs{ToolsModel: "test:override"})
+
+		toolsModel, err := cmd.resolveToolsModel(t.Context(), requestModel)
+		require.NoError(t, err)
+		assert.Equal(t, "test:override", toolsModel.FullName())
+	})
+
+	t.Run("without override request model is used", func(t *testing.T) {
+		cmd := newToolsModelTestCommand(t, &CommandArgs{})
+
+		toolsModel, err := cmd.resolveToolsModel(t.Context(), requestModel)
+		require.NoError(t, err)
+		assert.Same(t, requestModel, toolsModel)
+	})
+
+	t.Run("unknown override returns error", func(t *testing.T) {
+		cmd := newToolsModelTestCommand(t, &CommandArgs{ToolsModel: "test:missing"})
+
+		toolsModel, err := cmd.resolveToolsModel(t.Context(), requestModel)
+		require.Error(t, err)
+		assert.Nil(t, toolsModel)
+	})
+}
+
+func TestCommand_answeredModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":"backup/model","context_length":1000}]}`)
+	}))
+	defer server.Close()
+	cmd := newToolsModelTestCommand(t, &CommandArgs{})
+	cmd.ai.RegisterProvider("or", ai.NewOpenRouterClient(config.AIProviderConfig{
+		Name:           "or",
+		BaseURL:        server.URL,
+		FallbackModels: []string{"backup/model"},
+	}, cmd.Cfg, logger.NewTestLogger(), server.Client()))
+
+	model := &ai.ModelInfo{ID: "main/model", Provider: "or"}
+	assert.Same(t, model, cmd.answeredModel(model, nil))
+	assert.Same(t, model, cmd.answeredModel(model, &ai.ModelUsage{Model: "main/model"}))
+	assert.Equal(t, "or:backup/model", cmd.answeredModel(model, &ai.ModelUsage{Model: "backup/model"}).FullName())
+
+	other := &ai.ModelInfo{ID: "main", Provider: "test"}
+	assert.Same(t, other, cmd.answeredModel(other, &ai.ModelUsage{Model: "backup/model"}), "Only OpenRouter routes to fallbacks")
+}
+
+func newFallbackTestCommand(t *testing.T, toml string) *Command {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("gachigazer.toml", []byte(toml), 0o644))
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	free := &ai.ModelPricing{Completion: "0", Prompt: "0", Image: "0", WebSearch: "0"}
+	registry := ai.NewProviderRegistry(cfg, logger.NewTestLogger())
+	registry.RegisterProvider("test", stubProvider{models: map[string]*ai.ModelInfo{
+		"main": {ID: "main", Provider: "test"},
+		"paid": {ID: "paid", Provider: "test", Pricing: &ai.ModelPricing{Completion: "0.01"}},
+		"free": {ID: "free", Provider: "test", Pricing: free},
+	}})
+
+	return &Command{
+		Command: &base.Command{Cfg: cfg, Logger: logger.NewTestLogger()},
+		ai:      registry,
+	}
+}
+
+func TestCommand_nextFallbackModel(t *testing.T) {
+	const toml = `
+[telegram]
+token = "token"
+allowed_users = [1]
+allowed_chats = [100]
+
+[[ai.fallbacks]]
+model = "test:main"
+fallbacks = ["test:missing", "test:paid", "test:free"]
+`
+	model := &ai.ModelInfo{ID: "main", Provider: "test"}
+	unavailable := &ai.AIError{HTTPStatusCode: http.StatusServiceUnavailable}
+
+	t.Run("non recoverable error has no fallback", func(t *testing.T) {
+		cmd := newFallbackTestCommand(t, toml)
+
+		fallback := cmd.nextFallbackModel(t.Context(), &requestState{}, model, &ai.AIError{HTTPStatusCode: http.StatusBadRequest}, 1, 100)
+		assert.Nil(t, fallback)
+	})
+
+	t.Run("chain is consumed in order", func(t *testing.T) {
+		cmd := newFallbackTestCommand(t, toml)
+		request := &requestState{}
+
+		fallback := cmd.nextFallbackModel(t.Context(), request, model, unavailable, 1, 100)
+		require.NotNil(t, fallback)
+		assert.Equal(t, "test:paid", fallback.FullName())
+
+		fallback = cmd.nextFallbackModel(t.Context(), request, fallback, unavailable, 1, 100)
+		require.NotNil(t, fallback)
+		assert.Equal(t, "test:free", fallback.FullName())
+
+		assert.Nil(t, cmd.nextFallbackModel(t.Context(), request, fallback, unavailable, 1, 100))
+	})
+
+	t.Run("concurrent requests have own chains", func(t *testing.T) {
+		cmd := newFallbackTestCommand(t, toml)
+		first, second := &requestState{}, &requestState{}
+
+		fallback := cmd.nextFallbackModel(t.Context(), first, model, unavailable, 1, 100)
+		require.NotNil(t, fallback)
+		assert.Equal(t, "test:paid", fallback.FullName())
+
+		fallback = cmd.nextFallbackModel(t.Context(), second, model, unavailable, 1, 100)
+		require.NotNil(t, fallback)
+		assert.Equal(t, "test:paid", fallback.FullName(), "Chain isn't consumed by another request")
+	})
+
+	t.Run("paid fallback is skipped for not allowed user", func(t *testing.T) {
+		cmd := newFallbackTestCommand(t, toml)
+
+		fallback := cmd.nextFallbackModel(t.Context(), &requestState{}, model, unavailable, 2, 200)
+		require.NotNil(t, fallback)
+		assert.Equal(t, "test:free", fallback.FullName())
+	})
+
+	t.Run("paid fallback is skipped in free only chat", func(t *testing.T) {
+		cmd := newFallbackTestCommand(t, toml)
+
+		fallback := cmd.nextFallbackModel(t.Context(), &requestState{freeOnly: true}, model, unavailable, 1, 100)
+		require.NotNil(t, fallback)
+		assert.Equal(t, "test:free", fallback.FullName())
+	})
+
+	t.Run("model without fallbacks", func(t *testing.T) {
+		cmd := newFallbackTestCommand(t, toml)
+
+		other := &ai.ModelInfo{ID: "free", Provider: "test"}
+		assert.Nil(t, cmd.nextFallbackModel(t.Context(), &requestState{}, other, unavailable, 1, 100))
+	})
+}
+
+func TestCommand_takeMultimodalFallback(t *testing.T) {
+	chatModel := &ai.ModelInfo{ID: "main", Provider: "test"}
+	newContent := func() *MessageContent {
+		return &MessageContent{
+			Text:         "what is it?",
+			Media:        []ai.Content{{Type: "image_url", Text: "https://a.com/1.png"}},
+			HistoryMedia: []ai.Content{{Type: "image_url", Text: "https://a.com/2.png"}},
+		}
+	}
+
+	t.Run("media is dropped for chat model", func(t *testing.T) {
+		cmd := newToolsModelTestCommand(t, &CommandArgs{HandleImages: true, HandleAudio: true, HandleFiles: true})
+		request := &requestState{multimodalFallback: chatModel}
+		content := newContent()
+		response := NewResponse()
+		assert.Nil(t, cmd.takeMultimodalFallback(t.Context(), &requestState{}, newContent(), NewResponse()), "Fallback of another request isn't taken")
+
+		assert.Equal(t, chatModel, cmd.takeMultimodalFallback(t.Context(), request, content, response))
+		assert.Empty(t, content.Media)
+		assert.Empty(t, content.HistoryMedia)
+		assert.Equal(t, "what is it?", content.Text)
+		assert.False(t, cmd.args.HandleImages || cmd.args.HandleAudio || cmd.args.HandleFiles)
+		assert.True(t, response.Context.MediaSkipped)
+
+		assert.Nil(t, cmd.takeMultimodalFallback(t.Context(), request, newContent(), NewResponse()), "Fallback is used once")
+	})
+
+	t.Run("without auto switch", func(t *testing.T) {
+		cmd := newToolsModelTestCommand(t, &CommandArgs{HandleImages: true})
+		content := newContent()
+
+		assert.Nil(t, cmd.takeMultimodalFallback(t.Context(), &requestState{}, content, NewResponse()))
+		assert.Len(t, content.Media, 1)
+		assert.True(t, cmd.args.HandleImages)
+	})
+
+	t.Run("canceled request", func(t *testing.T) {
+		cmd := newToolsModelTestCommand(t, &CommandArgs{HandleImages: true})
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		assert.Nil(t, cmd.takeMultimodalFallback(ctx, &requestState{multimodalFallback: chatModel}, newContent(), NewResponse()))
+	})
+}
+
+func TestCommand_runTools_Timeout(t *testing.T) {
+	newCommand := func(t *testing.T, timeout, total time.Duration) *Command {
+		cmd := newToolsModelTestCommand(t, &CommandArgs{})
+		cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+		cmd.cmdCfg.Tools.Timeout = timeout
+		cmd.cmdCfg.Tools.TotalTimeout = total
+		return cmd
+	}
+	toolsList := []ai.ToolCall{
+		{ID: "1", Function: ai.FunctionCall{Name: "slow", Arguments: "{}"}},
+		{ID: "2", Function: ai.FunctionCall{Name: "fast", Arguments: "{}"}},
+	}
+	var slowCalls atomic.Int32
+	run := func(ctx context.Context, tool ai.ToolCall, args map[string]any, toolLog logger.Logger) (string, error) {
+		if tool.Function.Name == "slow" {
+			slowCalls.Add(1)
+			time.Sleep(time.Second)
+		}
+		return tool.Function.Name + " done", nil
+	}
+
+	t.Run("slow tool is cut off", func(t *testing.T) {
+		slowCalls.Store(0)
+		cmd := newCommand(t, 50*time.Millisecond, time.Minute)
+
+		started := time.Now()
+		results := cmd.runTools(t.Context(), toolsList, run)
+		assert.Less(t, time.Since(started), 500*time.Millisecond)
+
+		require.Len(t, results, 2)
+		assert.True(t, results[0].failed)
+		assert.Equal(t, "Tool error: tool timed out after 50ms, no result", results[0].response)
+		assert.Equal(t, int32(1), slowCalls.Load(), "Timed out tool isn't retried")
+		assert.False(t, results[1].failed)
+		assert.Equal(t, "fast done", results[1].response)
+	})
+
+	t.Run("total timeout", func(t *testing.T) {
+		cmd := newCommand(t, time.Minute, 50*time.Millisecond)
+
+		results := cmd.runTools(t.Context(), toolsList, run)
+		require.Len(t, results, 2)
+		assert.True(t, results[0].failed)
+		assert.Contains(t, results[0].response, "tools total timeout 50ms exceeded")
+		assert.Equal(t, "fast done", results[1].response)
+	})
+
+	t.Run("canceled request", func(t *testing.T) {
+		slowCalls.Store(0)
+		cmd := newCommand(t, time.Minute, time.Minute)
+		ctx, cancel := context.WithCancelCause(t.Context())
+		time.AfterFunc(50*time.Millisecond, func() { cancel(errRequestCanceled) })
+
+		results := cmd.runTools(ctx, toolsList, run)
+		require.Len(t, results, 2)
+		assert.True(t, results[0].failed)
+		assert.Equal(t, "Tool error: tool canceled: request canceled, no result", results[0].response)
+		assert.Equal(t, int32(1), slowCalls.Load(), "Canceled tool isn't retried")
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		cmd := newCommand(t, time.Minute, time.Minute)
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+
+		results := cmd.runTools(ctx, toolsList, run)
+		require.Len(t, results, 2)
+		assert.True(t, results[0].failed)
+		assert.Equal(t, "Tool error: tool stopped with the request: context deadline exceeded, no result", results[0].response)
+		assert.NotContains(t, results[0].response, "timed out")
+	})
+}
+
+func TestCommand_runTools_TimedOutSideEffect(t *testing.T) {
+	// no Send is expected, the chart of the timed out attempt isn't sent
+	cmd := newToolsModelTestCommand(t, &CommandArgs{})
+	cmd.Tg = telegram.NewMockClient(t)
+	cmd.toolsRunner = &tools.Tools{}
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Tools.Timeout = 50 * time.Millisecond
+	cmd.cmdCfg.Tools.TotalTimeout = time.Minute
+
+	chart := ai.ToolCall{ID: "1", Function: ai.FunctionCall{
+		Name:      tools.ToolMakeChart,
+		Arguments: `{"type": "bar", "title": "Sales", "labels": ["Jan"], "series": [{"name": "2026", "values": [1]}]}`,
+	}}
+	lateErr := make(chan error, 1)
+	run := func(ctx context.Context, tool ai.ToolCall, args map[string]any, toolLog logger.Logger) (string, error) {
+		// the tool is slower than the timeout
+		<-ctx.Done()
+		_, err := cmd.runSingleTool(ctx, tool, args, &conversationMessage{ChatID: 100}, "", toolLog)
+		lateErr <- err
+		return "chart is sent", err
+	}
+
+	results := cmd.runTools(t.Context(), []ai.ToolCall{chart}, run)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].failed)
+	assert.Equal(t, "Tool error: tool timed out after 50ms, no result", results[0].response, "Late result is dropped")
+
+	select {
+	case err := <-lateErr:
+		assert.ErrorContains(t, err, "result dropped")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out attempt isn't finished")
+	}
+}
+
+func TestCommand_runTools_WithoutTimeout(t *testing.T) {
+	cmd := newToolsModelTestCommand(t, &CommandArgs{})
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Tools.Timeout = 20 * time.Millisecond
+	cmd.cmdCfg.Tools.TotalTimeout = time.Minute
+
+	run := func(ctx context.Context, tool ai.ToolCall, args map[string]any, toolLog logger.Logger) (string, error) {
+		time.Sleep(100 * time.Millisecond)
+		return "image is generated", nil
+	}
+	results := cmd.runTools(t.Context(), []ai.ToolCall{
+		{ID: "1", Function: ai.FunctionCall{Name: tools.ToolGenerateImage, Arguments: "{}"}},
+	}, run)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].failed, "Image generation is limited only by the total timeout")
+	assert.Equal(t, "image is generated", results[0].response)
+}
+
+func TestCommand_requestTimeout(t *testing.T) {
+	const toml = `
+[telegram]
+token = "token"
+
+[ai]
+model_params = {timeout = "1m"}
+
+[[ai.providers]]
+type = "openai"
+name = "ollama"
+model_params = {timeout = "10m"}
+
+[[ai.aliases]]
+alias = "fast"
+model = "ollama:qwen3"
+model_params = {timeout = "20s"}
+
+[[ai.prompts]]
+name = "long"
+enabled = true
+text = "Think carefully"
+model_params = {timeout = "15m"}
+`
+	tests := []struct {
+		name   string
+		model  *ai.ModelInfo
+		prompt string
+		want   time.Duration
+	}{
+		{"global", &ai.ModelInfo{ID: "main", Provider: "test"}, "", time.Minute},
+		{"provider", &ai.ModelInfo{ID: "qwen3", Provider: "ollama"}, "", 10 * time.Minute},
+		{"alias", &ai.ModelInfo{ID: "qwen3", Provider: "ollama", Alias: "fast"}, "", 20 * time.Second},
+		{"prompt", &ai.ModelInfo{ID: "qwen3", Provider: "ollama", Alias: "fast"}, "long", 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newFallbackTestCommand(t, toml)
+			assert.Equal(t, tt.want, cmd.requestTimeout(tt.model, tt.prompt))
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		cmd := newFallbackTestCommand(t, "[telegram]\ntoken = \"token\"\n")
+		assert.Equal(t, defaultRequestTimeout, cmd.requestTimeout(&ai.ModelInfo{ID: "main", Provider: "test"}, ""))
+	})
+}
+
+func TestCommand_buildStreamWithReasoning(t *testing.T) {
+	tg := telegram.NewMockClient(t)
+	tg.EXPECT().TelegramifyMarkdown(mock.Anything).RunAndReturn(func(text string) (string, error) {
+		return text, nil
+	})
+	cmd := newInfoTestCommand(t, tg)
+
+	t.Run("reasoning above answer", func(t *testing.T) {
+		text := cmd.buildStreamWithReasoning("thinking about it", "the answer")
+
+		reasoningAt := strings.Index(text, "thinking about it")
+		answerAt := strings.Index(text, "the answer...")
+		require.NotEqual(t, -1, reasoningAt)
+		require.NotEqual(t, -1, answerAt)
+		assert.Less(t, reasoningAt, answerAt)
+	})
+
+	t.Run("long reasoning is truncated first", func(t *testing.T) {
+		answer := strings.Repeat("answer ", 300)
+		text := cmd.buildStreamWithReasoning(strings.Repeat("thinking ", 1000), answer)
+
+		assert.LessOrEqual(t, utf8.RuneCountInString(text), telegramMaxLength)
+		assert.Contains(t, text, answer+"...")
+		assert.Contains(t, text, "thinking")
+		assert.Contains(t, text, markdown.Escape("... "+cmd.L("ask.maxLengthReached", nil)))
+	})
+}
+
+func TestStreamEditInterval(t *testing.T) {
+	assert.Equal(t, minStreamEditInterval, streamEditInterval(0))
+	assert.Equal(t, minStreamEditInterval, streamEditInterval(streamEditGrowthLength-1))
+	assert.Equal(t, minStreamEditInterval+2*time.Second, streamEditInterval(2*streamEditGrowthLength+10))
+	assert.Equal(t, maxStreamEditInterval, streamEditInterval(100*streamEditGrowthLength), "Interval is capped")
+}
+
+func TestShouldEditStream(t *testing.T) {
+	assert.True(t, shouldEditStream(time.Second, 100, 0))
+	assert.False(t, shouldEditStream(100*time.Millisecond, 100, 0), "Too early")
+	assert.False(t, shouldEditStream(time.Second, 100, 90), "Small delta")
+	assert.False(t, shouldEditStream(2*time.Second, 3000, 2000), "Long message is edited less often")
+	assert.True(t, shouldEditStream(4*time.Second, 3000, 2000))
+}
+
+func TestCommand_dedupImageURLs(t *testing.T) {
+	newCommand := func(window time.Duration) *Command {
+		cmdCfg := &config.AskCommandConfig{}
+		cmdCfg.Fetcher.ImageDedupWindow = window
+		return &Command{
+			Command: &base.Command{Logger: logger.NewTestLogger()},
+			cache:   cache.NewMemoryCache(),
+			cmdCfg:  cmdCfg,
+		}
+	}
+	images := []string{"https://example.com/a.jpg", "https://example.com/b.jpg"}
+
+	t.Run("same images are skipped for another message", func(t *testing.T) {
+		cmd := newCommand(time.Minute)
+
+		assert.Equal(t, images, cmd.dedupImageURLs(100, 1, images))
+		assert.Equal(t,
+			[]string{"https://example.com/c.jpg"},
+			cmd.dedupImageURLs(100, 2, []string{"https://example.com/a.jpg", "https://example.com/c.jpg"}),
+		)
+	})
+
+	t.Run("retry of the same message keeps images", func(t *testing.T) {
+		cmd := newCommand(time.Minute)
+
+		cmd.dedupImageURLs(100, 1, images)
+		assert.Equal(t, images, cmd.dedupImageURLs(100, 1, images))
+	})
+
+	t.Run("dedup is per chat", func(t *testing.T) {
+		cmd := newCommand(time.Minute)
+
+		cmd.dedupImageURLs(100, 1, images)
+		assert.Equal(t, images, cmd.dedupImageURLs(200, 2, images))
+	})
+
+	t.Run("images are sent again after window", func(t *testing.T) {
+		cmd := newCommand(50 * time.Millisecond)
+
+		cmd.dedupImageURLs(100, 1, images)
+		assert.Empty(t, cmd.dedupImageURLs(100, 2, images))
+
+		time.Sleep(60 * time.Millisecond)
+		assert.Equal(t, images, cmd.dedupImageURLs(100, 3, images))
+	})
+
+	t.Run("disabled window", func(t *testing.T) {
+		cmd := newCommand(0)
+
+		cmd.dedupImageURLs(100, 1, images)
+		assert.Equal(t, images, cmd.dedupImageURLs(100, 2, images))
+	})
+}
+
+func TestCommand_handleURLs_Concurrency(t *testing.T) {
+	const concurrency = 3
+
+	var running, maxRunning atomic.Int32
+	mockFetcher := fetch.NewMockFetcher(t)
+	mockFetcher.EXPECT().GetName().Return("mock")
+	mockFetcher.EXPECT().CanHandle(mock.Anything).Return(true)
+	mockFetcher.EXPECT().Handle(mock.Anything).RunAndReturn(func(request fetch.Request) (fetch.Response, error) {
+		current := running.Add(1)
+		for {
+			prev := maxRunning.Load()
+			if current <= prev || maxRunning.CompareAndSwap(prev, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return fetch.Response{Content: []fetch.Content{{Type: fetch.ContentTypeText, Text: "content of " + request.URL()}}}, nil
+	})
+	manager := fetch.NewManager(logger.NewTestLogger())
+	manager.RegisterFetcher(mockFetcher)
+
+	cmdCfg := &config.AskCommandConfig{}
+	cmdCfg.Fetcher.Concurrency = concurrency
+	cmd := newInfoTestCommand(t, nil)
+	cmd.fetcher = manager
+	cmd.cmdCfg = cmdCfg
+
+	content := &MessageContent{URLsContent: map[string]string{}}
+	for i := range 20 {
+		content.AddURLs(fmt.Sprintf("https://example.com/%d", i))
+	}
+
+	content, err := cmd.handleURLs(content, 100, false)
+	require.NoError(t, err)
+
+	assert.Len(t, content.URLsContent, 20)
+	assert.Equal(t, "content of https://example.com/7", content.URLsContent["https://example.com/7"])
+	assert.LessOrEqual(t, maxRunning.Load(), int32(concurrency))
+	assert.Greater(t, maxRunning.Load(), int32(1))
+}
+
+func TestCommand_handleRequest_AllModelsFailed(t *testing.T) {
+	const toml = `
+[telegram]
+token = "token"
+allowed_users = [1]
+allowed_chats = [100]
+
+[[commands.ask.failure.canned]]
+pattern = "weather"
+answer = "Check the forecast on the weather service"
+`
+	newCommand := func(t *testing.T, sent *telegram.EditMessageTextConfig) *Command {
+		cmd := newFallbackTestCommand(t, toml)
+		localizer, err := service.NewLocalizer("en")
+		require.NoError(t, err)
+		tg := telegram.NewMockClient(t)
+		tg.EXPECT().TelegramifyMarkdown(mock.Anything).RunAndReturn(func(text string) (string, error) {
+			return text, nil
+		})
+		tg.EXPECT().SendWithRetry(mock.Anything, 0).RunAndReturn(func(msg telegram.MessageConfig, _ int) (*telegram.Message, error) {
+			*sent = *msg.(*telegram.EditMessageTextConfig)
+			return &telegram.Message{}, nil
+		})
+		cmd.Tg = tg
+		cmd.Localizer = localizer
+		cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+		cmd.args = &CommandArgs{}
+		return cmd
+	}
+	handleRequest := func(cmd *Command, query string) error {
+		stream := false
+		_, _, _, err := cmd.handleRequest(
+			t.Context(),
+			&requestState{},
+			&conversationMessage{UserID: 1},
+			100,
+			nil,
+			&MessageContent{Text: query},
+			&ai.ModelInfo{ID: "main", Provider: "offline"},
+			&ai.ModelParams{Stream: &stream},
+			55,
+			10,
+			NewResponse(),
+			false,
+		)
+		return err
+	}
+
+	t.Run("friendly message with retry button", func(t *testing.T) {
+		var sent telegram.EditMessageTextConfig
+		cmd := newCommand(t, &sent)
+
+		err := handleRequest(cmd, "tell me a joke")
+		require.Error(t, err)
+
+		assert.Equal(t, 55, sent.MessageID)
+		assert.Contains(t, sent.Text, cmd.L("ask.allModelsFailed", nil))
+		assert.NotContains(t, sent.Text, cmd.L("ask.failedToProcessAIRequest", nil))
+		assert.Contains(t, sent.Text, markdown.Escape(err.Error()), "Error is shown by default")
+		require.NotNil(t, sent.ReplyMarkup)
+		assert.Equal(t, "ask retry:10", *sent.ReplyMarkup.InlineKeyboard[0][0].CallbackData)
+	})
+
+	t.Run("canned answer for common query", func(t *testing.T) {
+		var sent telegram.EditMessageTextConfig
+		cmd := newCommand(t, &sent)
+
+		require.Error(t, handleRequest(cmd, "What's the WEATHER today?"))
+		assert.Contains(t, sent.Text, "Check the forecast on the weather service")
+	})
+
+	t.Run("custom message without error and retry", func(t *testing.T) {
+		var sent telegram.EditMessageTextConfig
+		cmd := newCommand(t, &sent)
+		cmd.cmdCfg.Failure.Message = "Bot is resting"
+		cmd.cmdCfg.Failure.ShowError = false
+		cmd.cmdCfg.Failure.RetryButton = false
+
+		require.Error(t, handleRequest(cmd, "tell me a joke"))
+		assert.Equal(t, "Bot is resting", sent.Text)
+		assert.Nil(t, sent.ReplyMarkup)
+	})
+}
+
+func TestRequestModelName(t *testing.T) {
+	assert.Equal(t, "or:explicit", requestModelName(&CommandArgs{Model: "or:explicit"}, prompt{Model: "or:prompt"}), "Explicit model wins")
+	assert.Equal(t, "or:prompt", requestModelName(&CommandArgs{}, prompt{Model: "or:prompt"}))
+	assert.Empty(t, requestModelName(&CommandArgs{}, prompt{}), "Chat model is used")
+}
+
+func TestCommand_applyDefaultPrompt_Model(t *testing.T) {
+	const toml = `
+[telegram]
+token = "token"
+allowed_users = [1]
+
+[database]
+dsn = "test.db"
+
+[ai]
+default_model = "test:main"
+
+[[ai.prompts]]
+name = "default"
+enabled = true
+model = "test:free"
+text = "Be brief."
+`
+	cmd := newFallbackTestCommand(t, toml)
+	db, err := database.NewSQLiteDB(cmd.Cfg, logger.NewTestLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	cmd.ChatService = service.NewChatService(db, cmd.ai, cmd.Cfg)
+	cmd.args = &CommandArgs{}
+
+	resolve := func(content *MessageContent) string {
+		cmd.applyDefaultPrompt(content)
+		model, err := cmd.ChatService.GetCurrentModelForChat(t.Context(), 100, 1, requestModelName(cmd.args, content.Prompt))
+		require.NoError(t, err)
+		return model.FullName()
+	}
+
+	assert.Equal(t, "test:free", resolve(&MessageContent{}), "Model of the default prompt is used for the first message")
+	assert.Equal(t, "test:main", resolve(&MessageContent{
+		ConversationHistory: []conversationMessage{{Role: ai.RoleUser, Text: "hi"}},
+	}), "Default prompt isn't used in the conversation")
+
+	cmd.args = &CommandArgs{Model: "test:paid"}
+	assert.Equal(t, "test:paid", resolve(&MessageContent{}), "Explicit model wins")
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, defaultRetryDelay, retryDelay(errors.New("network error")))
+	assert.Equal(t, defaultRetryDelay, retryDelay(&ai.AIError{HTTPStatusCode: http.StatusTooManyRequests}))
+	assert.Equal(t, 5*time.Second, retryDelay(&ai.AIError{HTTPStatusCode: http.StatusTooManyRequests, RetryAfter: 5 * time.Second}))
+	assert.Equal(t, maxRetryDelay, retryDelay(fmt.Errorf("ask: %w", &ai.AIError{RetryAfter: time.Hour})), "Long delay is capped")
+}
+
+func TestCommand_handleErrorWithRetry_ContentPolicy(t *testing.T) {
+	refused := &ai.AIError{
+		ProviderName:   "test",
+		ModelName:      "main",
+		HTTPStatusCode: http.StatusBadRequest,
+		Message:        "Request blocked by content policy",
+	}
+	send := func(t *testing.T, toml string, err error) telegram.EditMessageTextConfig {
+		cmd := newFallbackTestCommand(t, toml)
+		localizer, errLocalizer := service.NewLocalizer("en")
+		require.NoError(t, errLocalizer)
+		var sent telegram.EditMessageTextConfig
+		tg := telegram.NewMockClient(t)
+		tg.EXPECT().TelegramifyMarkdown(mock.Anything).RunAndReturn(func(text string) (string, error) {
+			return text, nil
+		})
+		tg.EXPECT().SendWithRetry(mock.Anything, 0).RunAndReturn(func(msg telegram.MessageConfig, _ int) (*telegram.Message, error) {
+			sent = *msg.(*telegram.EditMessageTextConfig)
+			return &telegram.Message{}, nil
+		})
+		cmd.Tg = tg
+		cmd.Localizer = localizer
+
+		require.ErrorIs(t, cmd.handleErrorWithRetry(100, "", 55, 10, err, false), err)
+		return sent
+	}
+
+	t.Run("another model instead of retry", func(t *testing.T) {
+		sent := send(t, `
+[telegram]
+token = "token"
+
+[[ai.fallbacks]]
+model = "test:main"
+fallbacks = ["test:free"]
+`, refused)
+
+		assert.Contains(t, sent.Text, "content policy")
+		assert.Contains(t, sent.Text, markdown.Escape(refused.Message))
+		require.NotNil(t, sent.ReplyMarkup)
+		require.Len(t, sent.ReplyMarkup.InlineKeyboard, 1)
+		require.Len(t, sent.ReplyMarkup.InlineKeyboard[0], 1)
+		button := sent.ReplyMarkup.InlineKeyboard[0][0]
+		assert.Equal(t, "ask retry:10 $m:test:free", *button.CallbackData)
+	})
+
+	t.Run("no buttons without another model", func(t *testing.T) {
+		sent := send(t, `
+[telegram]
+token = "token"
+
+[ai]
+default_model = "test:main"
+`, refused)
+
+		assert.Nil(t, sent.ReplyMarkup)
+	})
+
+	t.Run("other errors keep retry button", func(t *testing.T) {
+		sent := send(t, `
+[telegram]
+token = "token"
+`, &ai.AIError{HTTPStatusCode: http.StatusBadRequest, Message: "invalid request"})
+
+		require.NotNil(t, sent.ReplyMarkup)
+		assert.Equal(t, "ask retry:10", *sent.ReplyMarkup.InlineKeyboard[0][0].CallbackData)
+	})
+}
+
+func TestCommand_handleURLs_MergesCanonical(t *testing.T) {
+	mockFetcher := fetch.NewMockFetcher(t)
+	mockFetcher.EXPECT().GetName().Return("mock")
+	mockFetcher.EXPECT().CanHandle(mock.Anything).Return(true)
+	mockFetcher.EXPECT().Handle(mock.Anything).RunAndReturn(func(request fetch.Request) (fetch.Response, error) {
+		canonical := "https://example.com/other"
+		if strings.HasPrefix(request.URL(), "https://example.com/article") {
+			canonical = "https://example.com/article"
+		}
+		return fetch.Response{Content: []fetch.Content{
+			{Type: fetch.ContentTypeText, Text: "content of " + request.URL()},
+			{Type: fetch.ContentTypeCanonicalURL, Text: canonical},
+		}}, nil
+	})
+	manager := fetch.NewManager(logger.NewTestLogger())
+	manager.RegisterFetcher(mockFetcher)
+
+	cmd := newInfoTestCommand(t, nil)
+	cmd.fetcher = manager
+	cmd.cmdCfg = &config.AskCommandConfig{}
+
+	first := "https://example.com/article?utm_source=telegram"
+	second := "https://example.com/article?utm_source=twitter&utm_medium=social"
+	other := "https://example.com/other?ref=1"
+	content := &MessageContent{
+		Text:        "compare " + first + " and " + second + " with " + other,
+		URLsContent: map[string]string{},
+	}
+	// the order of adding doesn't matter, the first URL in the text is kept
+	content.AddURLs(second, other, first)
+
+	content, err := cmd.handleURLs(content, 100, false)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{first, other}, content.GetAllURLs())
+	assert.Equal(t, map[string]string{
+		first: "content of " + first,
+		other: "content of " + other,
+	}, content.URLsContent)
+	assert.Equal(t, "https://example.com/article", content.URLs[first].CanonicalURL)
+}
@@ -0,0 +1,52 @@
+package ask
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+const imageAnalysisPrompt = `Describe each of the images found for the query "%s" in one or two sentences, numbered in the order of the images: what is shown, its quality and how well it matches the query. Finish with the image that fits the query best.`
+
+// imageAnalysisMessage returns the vision request with the found images,
+// at most maxImages of them are sent
+func imageAnalysisMessage(query string, urls []string, maxImages int) ai.Message {
+	if maxImages > 0 && len(urls) > maxImages {
+		urls = urls[:maxImages]
+	}
+	content := []ai.Content{{Type: "text", Text: fmt.Sprintf(imageAnalysisPrompt, query)}}
+	for _, url := range urls {
+		content = append(content, createImageContent(url))
+	}
+	return ai.Message{Role: ai.RoleUser, Content: content}
+}
+
+// analyzeFoundImages asks the model of the answer to comment on the images found by
+// search_images, the comment is added to the tool response. Unavailable images are
+// skipped, the images are capped by images.max
+func (c *Command) analyzeFoundImages(ctx context.Context, assistantMessage *conversationMessage, query string, urls []string, toolLog logger.Logger) string {
+	model, err := c.ai.GetFormattedModel(ctx, assistantMessage.ModelName.String, "")
+	if err != nil {
+		toolLog.WithError(err).Warn("Failed to get model for image analysis")
+		return "Images weren't analyzed: the model is unknown"
+	}
+	if !model.SupportsImageRecognition() {
+		return "Images weren't analyzed: the current model doesn't support images"
+	}
+	urls = c.validURLs(ctx, urls)
+	if len(urls) == 0 {
+		return "Images weren't analyzed: found images aren't available"
+	}
+
+	message := imageAnalysisMessage(query, urls, c.cmdCfg.Images.Max)
+	analysis, _, _, _, _, _, _, err := c.Ask(ctx, []ai.Message{message}, nil, model, "", assistantMessage.ChatID, false, ai.ModelParams{})
+	if err != nil {
+		toolLog.WithError(err).Warn("Image analysis failed")
+		return "Images weren't analyzed: " + err.Error()
+	}
+	toolLog.WithField("images", len(message.Content)-1).Info("Found images analyzed")
+	return "Analysis of the found images:\n" + strings.TrimSpace(analysis)
+}
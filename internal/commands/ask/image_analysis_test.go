@@ -0,0 +1,30 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageAnalysisMessage(t *testing.T) {
+	urls := []string{"https://a.com/1.jpg", "https://a.com/2.jpg", "https://a.com/3.jpg"}
+
+	t.Run("all images", func(t *testing.T) {
+		message := imageAnalysisMessage("red cat", urls, 5)
+		assert.Equal(t, ai.RoleUser, message.Role)
+		require.Len(t, message.Content, 4)
+		assert.Contains(t, message.Content[0].Text, `"red cat"`)
+		for i, url := range urls {
+			assert.Equal(t, "image_url", message.Content[i+1].Type)
+			assert.Equal(t, url, message.Content[i+1].ImageURL.URL)
+		}
+	})
+
+	t.Run("capped by images max", func(t *testing.T) {
+		message := imageAnalysisMessage("red cat", urls, 2)
+		require.Len(t, message.Content, 3)
+		assert.Equal(t, "https://a.com/2.jpg", message.Content[2].ImageURL.URL)
+	})
+}
@@ -0,0 +1,55 @@
+package ask
+
+import (
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+)
+
+// inlineImageURLs downloads images the provider couldn't fetch by link and replaces the links
+// in the messages and the media with base64 data URLs. Images that can't be downloaded by the
+// bot either are dropped. Returns false if there are no image links to replace
+func (c *Command) inlineImageURLs(messages []ai.Message, content *MessageContent) ([]ai.Message, bool) {
+	inlined := map[string]ai.Content{}
+	found := false
+	replace := func(items []ai.Content) []ai.Content {
+		if items == nil {
+			return nil
+		}
+		result := make([]ai.Content, 0, len(items))
+		for _, item := range items {
+			url := item.ImageURL.URL
+			if item.Type != "image_url" || !strings.HasPrefix(url, "http") {
+				result = append(result, item)
+				continue
+			}
+			found = true
+			image, ok := inlined[url]
+			if !ok {
+				var err error
+				image, err = createImageContentFromTelegram(url, c.cmdCfg.Images.MaxDimension)
+				if err != nil {
+					c.Logger.WithError(err).WithField("url", url).Warn("Failed to download image for inlining, skip it")
+				}
+				inlined[url] = image
+			}
+			if image.Type != "" {
+				result = append(result, image)
+			}
+		}
+		return result
+	}
+
+	result := make([]ai.Message, len(messages))
+	for i, message := range messages {
+		message.Content = replace(message.Content)
+		result[i] = message
+	}
+	content.Media = replace(content.Media)
+	content.HistoryMedia = replace(content.HistoryMedia)
+
+	if found {
+		c.Logger.WithField("images", len(inlined)).Info("Provider can't fetch image URLs, retry with base64 images")
+	}
+	return result, found
+}
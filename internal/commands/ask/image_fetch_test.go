@@ -0,0 +1,115 @@
+package ask
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/service"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type stubChatService struct{}
+
+func (stubChatService) GetCurrentModelSpec(context.Context, int64) (string, error) {
+	return "", nil
+}
+
+func (stubChatService) MergeModelParams(_ int64, _, _, _ string, requestParams ai.ModelParams) (ai.ModelParams, error) {
+	return requestParams, nil
+}
+
+// imageFetchProvider records requests and fails them with the given errors in turn
+type imageFetchProvider struct {
+	stubProvider
+	requests *[]ai.CompletionRequest
+	errs     []error
+}
+
+func (p imageFetchProvider) CreateRequest(_ bool, messages []ai.Message, _ []ai.Tool, _ *ai.ModelInfo, _ ai.ModelParams, _ bool) ai.CompletionRequest {
+	return ai.CompletionRequest{Messages: messages}
+}
+
+func (p imageFetchProvider) Ask(_ context.Context, request ai.CompletionRequest, _ map[string]string) (string, string, *ai.CompletionResponse, *ai.ModelInfo, error) {
+	*p.requests = append(*p.requests, request)
+	return "", "", nil, nil, p.errs[len(*p.requests)-1]
+}
+
+func TestCommand_handleRequest_InlinesUnreachableImages(t *testing.T) {
+	png := newTestImage(t, "png", 4, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cat.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(png)
+	}))
+	defer server.Close()
+
+	cmd := newFallbackTestCommand(t, "[telegram]\ntoken = \"token\"\n")
+	var requests []ai.CompletionRequest
+	cmd.ai.SetChatService(stubChatService{})
+	cmd.ai.RegisterProvider("offline", imageFetchProvider{
+		requests: &requests,
+		errs: []error{
+			&ai.AIError{HTTPStatusCode: http.StatusBadRequest, Message: "Unable to fetch image from URL"},
+			&ai.AIError{HTTPStatusCode: http.StatusBadRequest, Message: "Unable to fetch image from URL"},
+		},
+	})
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+	tg := telegram.NewMockClient(t)
+	tg.EXPECT().TelegramifyMarkdown(mock.Anything).RunAndReturn(func(text string) (string, error) {
+		return text, nil
+	})
+	tg.EXPECT().SendWithRetry(mock.Anything, 0).Return(&telegram.Message{}, nil)
+	cmd.Tg = tg
+	cmd.Localizer = localizer
+	cmd.Logger = logger.NewTestLogger()
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.args = &CommandArgs{}
+
+	content := &MessageContent{Text: "what is it?"}
+	content.AddMedia(
+		createImageContent(server.URL+"/cat.png"),
+		createImageContent(server.URL+"/missing.png"),
+	)
+	messages := []ai.Message{{
+		Role:    "user",
+		Content: append([]ai.Content{{Type: "text", Text: content.Text}}, content.Media...),
+	}}
+	stream := false
+	request := &requestState{}
+	_, _, _, err = cmd.handleRequest(
+		t.Context(),
+		request,
+		&conversationMessage{UserID: 1},
+		100,
+		messages,
+		content,
+		&ai.ModelInfo{ID: "main", Provider: "offline"},
+		&ai.ModelParams{Stream: &stream},
+		55,
+		10,
+		NewResponse(),
+		false,
+	)
+	require.Error(t, err, "Images are inlined only once")
+	require.Len(t, requests, 2)
+	assert.True(t, request.imagesInlined)
+
+	assert.Equal(t, server.URL+"/cat.png", requests[0].Messages[0].Content[1].ImageURL.URL)
+	retried := requests[1].Messages[0].Content
+	require.Len(t, retried, 2, "Image the bot can't download is dropped")
+	assert.Equal(t, "what is it?", retried[0].Text)
+	assert.Equal(t, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(png), retried[1].ImageURL.URL)
+	assert.Equal(t, retried[1:], content.Media, "Media is inlined for the next iterations")
+	assert.Equal(t, server.URL+"/cat.png", messages[0].Content[1].ImageURL.URL, "Original messages are not modified")
+}
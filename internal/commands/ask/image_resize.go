@@ -0,0 +1,82 @@
+package ask
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+const downscaleJPEGQuality = 90
+
+// downscaleImage shrinks the image so that its longest side is at most maxDimension,
+// the aspect ratio and the format are preserved. Small images, unsupported formats
+// and maxDimension <= 0 return the data unchanged
+func downscaleImage(data []byte, maxDimension int) ([]byte, error) {
+	if maxDimension <= 0 {
+		return data, nil
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != "jpeg" && format != "png" {
+		// webp can't be decoded by the standard library, it's sent as is
+		return data, nil
+	}
+	if max(cfg.Width, cfg.Height) <= maxDimension {
+		return data, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	width, height := cfg.Width, cfg.Height
+	if width >= height {
+		width, height = maxDimension, max(height*maxDimension/width, 1)
+	} else {
+		width, height = max(width*maxDimension/height, 1), maxDimension
+	}
+	dst := resizeBox(src, width, height)
+
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		err = png.Encode(&buf, dst)
+	default:
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: downscaleJPEGQuality})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// resizeBox downscales the image by averaging the source pixels covered by each
+// destination pixel, good enough for photos and much cheaper than proper filters
+func resizeBox(src image.Image, width, height int) *image.NRGBA {
+	bounds := src.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		y0, y1 := y*srcH/height, max((y+1)*srcH/height, y*srcH/height+1)
+		for x := range width {
+			x0, x1 := x*srcW/width, max((x+1)*srcW/width, x*srcW/width+1)
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r, g, b, a = r+int(p[0]), g+int(p[1]), b+int(p[2]), a+int(p[3])
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,72 @@
+package ask
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestImage(t *testing.T, format string, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestDownscaleImage(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		width        int
+		height       int
+		maxDimension int
+		wantWidth    int
+		wantHeight   int
+	}{
+		{"landscape jpeg", "jpeg", 3000, 2000, 1536, 1536, 1024},
+		{"portrait png", "png", 1000, 2000, 500, 250, 500},
+		{"small image is kept", "jpeg", 800, 600, 1536, 800, 600},
+		{"disabled", "png", 3000, 100, 0, 3000, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newTestImage(t, tt.format, tt.width, tt.height)
+			result, err := downscaleImage(data, tt.maxDimension)
+			require.NoError(t, err)
+
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(result))
+			require.NoError(t, err)
+			assert.Equal(t, tt.format, format)
+			assert.Equal(t, tt.wantWidth, cfg.Width)
+			assert.Equal(t, tt.wantHeight, cfg.Height)
+			if max(tt.width, tt.height) <= tt.maxDimension || tt.maxDimension == 0 {
+				assert.Equal(t, data, result)
+			}
+		})
+	}
+}
+
+func TestDownscaleImage_UnsupportedFormat(t *testing.T) {
+	data := []byte("RIFF\x00\x00\x00\x00WEBPVP8 ")
+	result, err := downscaleImage(data, 100)
+	require.NoError(t, err)
+	assert.Equal(t, data, result)
+}
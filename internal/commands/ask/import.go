@@ -0,0 +1,253 @@
+package ask
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const (
+	importCommand = "import"
+	exportCommand = "export"
+)
+
+// <chat>:<message> reference printed by /export, messages of private chats have no links
+var conversationRefRegex = regexp.MustCompile(`^(-?\d+):(\d+)$`)
+
+// parseImportSource returns the source chat and message of the /import argument,
+// a t.me/c link to a message of a group or a reference from /export
+func parseImportSource(text string) (int64, int, bool) {
+	text = strings.TrimSpace(text)
+	if match := conversationRefRegex.FindStringSubmatch(text); match != nil {
+		chatID, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		messageID, err := strconv.Atoi(match[2])
+		if err != nil {
+			return 0, 0, false
+		}
+		return chatID, messageID, true
+	}
+
+	match := messageLinkRegex.FindStringSubmatch(text)
+	if match == nil || match[1] == "" {
+		return 0, 0, false
+	}
+	// t.me/c links contain the supergroup ID without -100 prefix
+	chatID, err := strconv.ParseInt("-100"+match[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	messageID, err := strconv.Atoi(match[3])
+	if err != nil {
+		return 0, 0, false
+	}
+	return chatID, messageID, true
+}
+
+func conversationRef(chatID int64, messageID int) string {
+	return fmt.Sprintf("%d:%d", chatID, messageID)
+}
+
+// commandArgument returns the text after the command
+func commandArgument(text string) string {
+	_, argument, _ := strings.Cut(strings.TrimSpace(text), " ")
+	return strings.TrimSpace(argument)
+}
+
+// handleExportCommand replies with the /import command copying the conversation
+// of the replied bot message into another chat
+func (c *Command) handleExportCommand(msg *telegram.MessageOriginal) error {
+	chatID := msg.Chat.ID
+	reply := func(text string) error {
+		_, err := c.Tg.Send(telegram.NewMessage(chatID, text, msg.MessageID))
+		return err
+	}
+	if msg.ReplyToMessage == nil || msg.ReplyToMessage.From == nil || msg.ReplyToMessage.From.ID != c.Tg.Self().ID {
+		return reply(c.L("ask.export.usage", nil))
+	}
+
+	messageID, err := c.db.GetFirstMessagePart(chatID, msg.ReplyToMessage.MessageID)
+	if err != nil {
+		c.Logger.WithError(err).Warn("Failed to get first message of split answer")
+		messageID = msg.ReplyToMessage.MessageID
+	}
+	if _, err := c.getMessageFromHistory(chatID, int64(messageID)); err != nil {
+		return reply(c.L("ask.import.notFound", nil))
+	}
+	return reply(c.L("ask.export.done", map[string]any{
+		"Command": fmt.Sprintf("/%s %s", importCommand, conversationRef(chatID, messageID)),
+	}))
+}
+
+// handleImportCommand copies the conversation chain of the linked message into the chat
+// as a new conversation, replies to the import message continue it. The source chat must be
+// allowed by telegram.allowed_chats and the user must have access to it
+func (c *Command) handleImportCommand(ctx context.Context, msg *telegram.MessageOriginal) error {
+	chatID := msg.Chat.ID
+	reply := func(text string) error {
+		_, err := c.Tg.Send(telegram.NewMessage(chatID, text, msg.MessageID))
+		return err
+	}
+	sourceChatID, sourceMessageID, ok := parseImportSource(commandArgument(msg.Text))
+	if !ok {
+		return reply(c.L("ask.import.usage", nil))
+	}
+	importLog := c.Logger.WithFields(logger.Fields{
+		"chat_id":           chatID,
+		"source_chat_id":    sourceChatID,
+		"source_message_id": sourceMessageID,
+	})
+	if msg.From == nil || !c.Cfg.Telegram().IsChatAllowed(sourceChatID) || !c.canAccessChat(sourceChatID, msg.From.ID) {
+		importLog.Warn("Import from not accessible chat")
+		return reply(c.L("ask.import.notAllowed", nil))
+	}
+
+	firstMessageID, err := c.db.GetFirstMessagePart(sourceChatID, sourceMessageID)
+	if err != nil {
+		importLog.WithError(err).Warn("Failed to get first message of split answer")
+		firstMessageID = sourceMessageID
+	}
+	history, err := c.getConversationHistory(sourceChatID, firstMessageID)
+	if err != nil {
+		importLog.WithError(err).Error("Get conversation history failed")
+		return reply(c.L("ask.import.failed", nil))
+	}
+	if len(history) == 0 {
+		return reply(c.L("ask.import.notFound", nil))
+	}
+
+	sent, err := c.Tg.Send(telegram.NewMessage(chatID, c.L("ask.import.done", map[string]any{
+		"Count": len(history),
+	}), msg.MessageID))
+	if err != nil {
+		return err
+	}
+
+	var minMessageID int
+	if err := c.db.QueryRow(
+		"SELECT COALESCE(MIN(message_id), 0) FROM conversation_history WHERE chat_id = ?",
+		chatID,
+	).Scan(&minMessageID); err != nil {
+		importLog.WithError(err).Error("Failed to get message IDs of the chat")
+		return c.editImportFailed(chatID, sent.MessageID)
+	}
+
+	imported := importedMessages(history, chatID, sent.MessageID, min(minMessageID, 0)-1)
+	c.revalidateImportedMedia(ctx, imported)
+	newIDs := make(map[int64]int64, len(history))
+	for i := range imported {
+		message := &imported[i]
+		if parentID, ok := newIDs[message.ParentMessageID.Int64]; ok && message.ParentMessageID.Valid {
+			message.ParentMessageID = sql.NullInt64{Int64: parentID, Valid: true}
+		} else {
+			message.ParentMessageID = sql.NullInt64{}
+		}
+		sourceID := message.ID
+		message.ID = 0
+		if _, err := c.saveMessage(message); err != nil {
+			importLog.WithError(err).Error("Failed to save imported message")
+			return c.editImportFailed(chatID, sent.MessageID)
+		}
+		newIDs[sourceID] = message.ID
+	}
+
+	importLog.WithField("messages", len(imported)).Info("Conversation imported")
+	return nil
+}
+
+func (c *Command) editImportFailed(chatID int64, messageID int) error {
+	_, err := c.Tg.Send(telegram.NewEditMessageText(chatID, messageID, c.L("ask.import.failed", nil)))
+	return err
+}
+
+// canAccessChat checks the user can read the chat, a private chat is
+// accessible only to its user, in groups the user must be a member
+func (c *Command) canAccessChat(chatID, userID int64) bool {
+	if chatID > 0 {
+		return chatID == userID
+	}
+	resp, err := c.Tg.RequestRaw(telegram.NewGetChatMember(chatID, userID))
+	if err != nil {
+		c.Logger.WithError(err).WithField("source_chat_id", chatID).Warn("Failed to get chat member")
+		return false
+	}
+	var member telegram.ChatMember
+	if err := json.Unmarshal(resp.Result, &member); err != nil {
+		return false
+	}
+	return !member.HasLeft() && !member.WasKicked()
+}
+
+// importedMessages copies the history into the chat as a new conversation, ordered from the
+// oldest message. The latest message of the history becomes the import message, other
+// Telegram messages get negative IDs below nextMessageID, so they never match real messages
+// and the replies still link the chain. Source IDs are kept in ID to restore the parents on save
+func importedMessages(history []conversationMessage, chatID int64, importMessageID, nextMessageID int) []conversationMessage {
+	messages := slices.Clone(history)
+	slices.SortFunc(messages, func(a, b conversationMessage) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	messageIDs := map[int]int{messages[len(messages)-1].MessageID: importMessageID}
+	chainIDs := map[string]string{}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if _, ok := messageIDs[messages[i].MessageID]; !ok {
+			messageIDs[messages[i].MessageID] = nextMessageID
+			nextMessageID--
+		}
+	}
+
+	for i := range messages {
+		message := &messages[i]
+		chainID, ok := chainIDs[message.ConversationChainID]
+		if !ok {
+			chainID = uuid.NewString()
+			chainIDs[message.ConversationChainID] = chainID
+		}
+		replyToID, ok := messageIDs[int(message.ReplyToMessageID.Int64)]
+		message.ReplyToMessageID = sql.NullInt64{Int64: int64(replyToID), Valid: ok && message.ReplyToMessageID.Valid}
+		message.ConversationChainID = chainID
+		message.ChatID = chatID
+		message.MessageID = messageIDs[message.MessageID]
+		message.ConversationID = int64(importMessageID)
+		message.IsFirst = i == 0
+		// the answers are paid in the source chat
+		message.Usage = nil
+	}
+	return messages
+}
+
+// revalidateImportedMedia drops image links that aren't available anymore,
+// inlined images are kept
+func (c *Command) revalidateImportedMedia(ctx context.Context, messages []conversationMessage) {
+	urls := []string{}
+	for _, message := range messages {
+		for _, image := range message.Images {
+			if url := image.ImageURL.URL; !strings.HasPrefix(url, "data:") {
+				urls = append(urls, url)
+			}
+		}
+	}
+	if len(urls) == 0 {
+		return
+	}
+	valid := c.validURLs(ctx, uniqueSlice(urls))
+	for i := range messages {
+		messages[i].Images = slices.DeleteFunc(messages[i].Images, func(image ai.Content) bool {
+			return !strings.HasPrefix(image.ImageURL.URL, "data:") && !slices.Contains(valid, image.ImageURL.URL)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package ask
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImportSource(t *testing.T) {
+	tests := []struct {
+		text      string
+		chatID    int64
+		messageID int
+		ok        bool
+	}{
+		{"https://t.me/c/1234567890/42", -1001234567890, 42, true},
+		{"t.me/c/1234567890/5/42", -1001234567890, 42, true},
+		{"-1001234567890:42", -1001234567890, 42, true},
+		{"100:7", 100, 7, true},
+		{"https://t.me/somechannel/42", 0, 0, false},
+		{"", 0, 0, false},
+		{"hello", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			chatID, messageID, ok := parseImportSource(tt.text)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.chatID, chatID)
+			assert.Equal(t, tt.messageID, messageID)
+		})
+	}
+}
+
+func TestImportedMessages(t *testing.T) {
+	reply := func(id int64) sql.NullInt64 { return sql.NullInt64{Int64: id, Valid: true} }
+	image := ai.Content{Type: "image_url"}
+	image.ImageURL.URL = "https://a.com/1.png"
+	// newest first, as returned by getConversationHistory
+	history := []conversationMessage{
+		{ID: 4, ParentMessageID: reply(3), ConversationChainID: "b", ChatID: 100, MessageID: 13, ReplyToMessageID: reply(12), Role: ai.RoleAssistant, Text: "answer 2", ConversationID: 10, Usage: &MetadataUsage{Cost: 1}},
+		{ID: 3, ParentMessageID: reply(2), ConversationChainID: "b", ChatID: 100, MessageID: 12, ReplyToMessageID: reply(11), Role: ai.RoleUser, Text: "question 2", ConversationID: 10, UserID: 5},
+		{ID: 2, ParentMessageID: reply(1), ConversationChainID: "a", ChatID: 100, MessageID: 11, ReplyToMessageID: reply(10), Role: ai.RoleAssistant, Text: "answer 1", ConversationID: 10},
+		{ID: 1, ConversationChainID: "a", ChatID: 100, MessageID: 10, ReplyToMessageID: reply(9), Role: ai.RoleUser, Text: "question 1", ConversationID: 10, UserID: 5, Images: []ai.Content{image}, IsFirst: true},
+	}
+
+	messages := importedMessages(history, 200, 500, -3)
+	require.Len(t, messages, 4)
+
+	var texts []string
+	for _, message := range messages {
+		texts = append(texts, message.Text)
+		assert.Equal(t, int64(200), message.ChatID)
+		assert.Equal(t, int64(500), message.ConversationID)
+		assert.Nil(t, message.Usage)
+	}
+	assert.Equal(t, []string{"question 1", "answer 1", "question 2", "answer 2"}, texts)
+
+	assert.Equal(t, 500, messages[3].MessageID, "Latest message is the import message")
+	assert.Equal(t, reply(-3), messages[3].ReplyToMessageID)
+	assert.Equal(t, -3, messages[2].MessageID)
+	assert.Equal(t, reply(-4), messages[2].ReplyToMessageID)
+	assert.Equal(t, -4, messages[1].MessageID)
+	assert.Equal(t, -5, messages[0].MessageID)
+	assert.False(t, messages[0].ReplyToMessageID.Valid, "Reply outside of the conversation is dropped")
+
+	assert.True(t, messages[0].IsFirst)
+	assert.Equal(t, ai.RoleUser, string(messages[0].Role))
+	assert.Equal(t, int64(5), messages[0].UserID)
+	assert.Len(t, messages[0].Images, 1)
+	assert.Equal(t, int64(1), messages[0].ID, "Source ID is kept to restore parents")
+
+	assert.Equal(t, messages[0].ConversationChainID, messages[1].ConversationChainID)
+	assert.NotEqual(t, "a", messages[0].ConversationChainID)
+	assert.NotEqual(t, messages[1].ConversationChainID, messages[2].ConversationChainID)
+
+	assert.Equal(t, 13, history[0].MessageID, "Source history isn't changed")
+}
+
+func TestCommand_canAccessChat_Private(t *testing.T) {
+	cmd := newToolsModelTestCommand(t, &CommandArgs{})
+
+	assert.True(t, cmd.canAccessChat(5, 5))
+	assert.False(t, cmd.canAccessChat(6, 5))
+}
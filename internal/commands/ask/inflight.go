@@ -0,0 +1,277 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const cancelCommand = "cancel"
+
+// errRequestCanceled cancels the context of the request stopped by /cancel or replaced by a new one
+var errRequestCanceled = errors.New("request canceled")
+
+type inflightRequest struct {
+	userID int64
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+// parkedRequest is a request that waited for the previous ones before it was queued,
+// Execute takes it over instead of registering the request again
+type parkedRequest struct {
+	ctx    context.Context
+	finish func()
+}
+
+// inflightRequests tracks the running requests of the chats, so a new request can wait for
+// or replace the previous one and /cancel can stop the requests of the user
+type inflightRequests struct {
+	mu       sync.Mutex
+	requests map[int64][]*inflightRequest
+	// by requestKey, the same request can be parked twice (e.g. a double reaction)
+	parked map[string][]*parkedRequest
+}
+
+func newInflightRequests() *inflightRequests {
+	return &inflightRequests{
+		requests: make(map[int64][]*inflightRequest),
+		parked:   make(map[string][]*parkedRequest),
+	}
+}
+
+// start registers the request of the user in the chat. With the replace policy the previous requests
+// are canceled, with the queue policy start waits until they finish, with perUser only requests of
+// the same user count. The request context is returned with the func to call when the request ends,
+// the error is returned when the request is canceled while waiting in the queue
+func (r *inflightRequests) start(ctx context.Context, chatID, userID int64, policy string, perUser bool) (context.Context, func(), error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	request := &inflightRequest{userID: userID, cancel: cancel, done: make(chan struct{})}
+
+	r.mu.Lock()
+	var previous []*inflightRequest
+	for _, running := range r.requests[chatID] {
+		if !perUser || running.userID == userID {
+			previous = append(previous, running)
+		}
+	}
+	r.requests[chatID] = append(r.requests[chatID], request)
+	r.mu.Unlock()
+
+	finish := func() {
+		cancel(nil)
+		r.remove(chatID, request)
+	}
+	switch policy {
+	case config.DedupReplace:
+		for _, running := range previous {
+			running.cancel(errRequestCanceled)
+		}
+	case config.DedupQueue:
+		for _, running := range previous {
+			select {
+			case <-running.done:
+			case <-ctx.Done():
+				finish()
+				return ctx, func() {}, context.Cause(ctx)
+			}
+		}
+	}
+	return ctx, finish, nil
+}
+
+// busy reports whether requests of the chat are running or waiting, with perUser only requests of the user count
+func (r *inflightRequests) busy(chatID, userID int64, perUser bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, running := range r.requests[chatID] {
+		if !perUser || running.userID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// park keeps the started request until Execute takes it by the key
+func (r *inflightRequests) park(key string, ctx context.Context, finish func()) *parkedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request := &parkedRequest{ctx: ctx, finish: finish}
+	r.parked[key] = append(r.parked[key], request)
+	return request
+}
+
+// take returns the request parked first by the key or nil, the caller owns
+// the taken request and finishes it
+func (r *inflightRequests) take(key string) *parkedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	parked := r.parked[key]
+	if len(parked) == 0 {
+		return nil
+	}
+	r.removeParked(key, 0)
+	return parked[0]
+}
+
+// unpark removes the request that isn't taken yet, it reports whether the request was still parked
+func (r *inflightRequests) unpark(key string, request *parkedRequest) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := slices.Index(r.parked[key], request)
+	if i < 0 {
+		return false
+	}
+	r.removeParked(key, i)
+	return true
+}
+
+func (r *inflightRequests) removeParked(key string, i int) {
+	parked := slices.Delete(slices.Clone(r.parked[key]), i, i+1)
+	if len(parked) == 0 {
+		delete(r.parked, key)
+	} else {
+		r.parked[key] = parked
+	}
+}
+
+func (r *inflightRequests) remove(chatID int64, request *inflightRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	requests := r.requests[chatID]
+	for i, running := range requests {
+		if running == request {
+			close(request.done)
+			requests = append(requests[:i:i], requests[i+1:]...)
+			break
+		}
+	}
+	if len(requests) == 0 {
+		delete(r.requests, chatID)
+	} else {
+		r.requests[chatID] = requests
+	}
+}
+
+// cancelUser cancels the running and waiting requests of the user in the chat
+// and returns their number
+func (r *inflightRequests) cancelUser(chatID, userID int64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, running := range r.requests[chatID] {
+		if running.userID == userID {
+			running.cancel(errRequestCanceled)
+			count++
+		}
+	}
+	return count
+}
+
+// isCancelCommand reports whether the text is /cancel, also addressed to the bot (/cancel@bot)
+func isCancelCommand(text string) bool {
+	command, _, _ := strings.Cut(strings.TrimSpace(text), " ")
+	command, _, _ = strings.Cut(command, "@")
+	return command == "/"+cancelCommand
+}
+
+// isRequestCanceled reports whether the request was stopped by /cancel or replaced by a new one
+func isRequestCanceled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errRequestCanceled)
+}
+
+// requester returns the chat and the user of the request in the update
+func requester(update telegram.Update) (chatID, userID int64, ok bool) {
+	if callback := update.CallbackQuery; callback != nil {
+		if callback.Message == nil || callback.From == nil {
+			return 0, 0, false
+		}
+		return callback.Message.Chat.ID, callback.From.ID, true
+	}
+	if msg := update.Message; msg != nil && msg.From != nil {
+		return msg.Chat.ID, msg.From.ID, true
+	}
+	return 0, 0, false
+}
+
+// requestKey identifies the request of the update after it went through the queue,
+// updates made by the bot from reactions and edits don't have IDs
+func requestKey(update telegram.Update) string {
+	if callback := update.CallbackQuery; callback != nil && callback.Message != nil {
+		var userID int64
+		if callback.From != nil {
+			userID = callback.From.ID
+		}
+		return fmt.Sprintf("%d:%d:%d:%s", callback.Message.Chat.ID, callback.Message.MessageID, userID, callback.Data)
+	}
+	if msg := update.Message; msg != nil {
+		return fmt.Sprintf("%d:%d", msg.Chat.ID, msg.MessageID)
+	}
+	return ""
+}
+
+// Handle runs /cancel right away, it must not wait in the queue behind the request it cancels.
+// With the queue policy a request of the busy chat waits for the previous ones before it is queued
+func (c *Command) Handle(update telegram.Update) error {
+	if update.Message != nil && isCancelCommand(update.Message.Text) {
+		return c.Execute(update)
+	}
+	if c.cmdCfg.Dedup.Policy == config.DedupQueue {
+		if chatID, userID, ok := requester(update); ok && c.inflight.busy(chatID, userID, c.cmdCfg.Dedup.PerUser) {
+			go c.handleAfterPrevious(update, chatID, userID)
+			return nil
+		}
+	}
+	return c.Command.Handle(update)
+}
+
+// handleAfterPrevious queues the request when the previous requests of the chat finish,
+// so the waiting request doesn't hold a queue worker and doesn't run out of the queue timeout
+func (c *Command) handleAfterPrevious(update telegram.Update, chatID, userID int64) {
+	ctx, finish, err := c.inflight.start(context.Background(), chatID, userID, config.DedupQueue, c.cmdCfg.Dedup.PerUser)
+	if err != nil {
+		c.Logger.WithField("chat_id", chatID).Info("Request canceled while waiting for the previous one")
+		return
+	}
+	key := requestKey(update)
+	parked := c.inflight.park(key, ctx, finish)
+	if err := c.Command.Handle(update); err != nil {
+		c.Logger.WithError(err).WithField("chat_id", chatID).Error("Failed to handle request after the previous one")
+		if c.inflight.unpark(key, parked) {
+			finish()
+		}
+	}
+}
+
+// handleCancelCommand cancels the running requests of the user in the chat
+func (c *Command) handleCancelCommand(msg *telegram.MessageOriginal) error {
+	count := c.inflight.cancelUser(msg.Chat.ID, msg.From.ID)
+	c.Logger.WithFields(logger.Fields{
+		"chat_id":  msg.Chat.ID,
+		"user_id":  msg.From.ID,
+		"requests": count,
+	}).Info("Requests canceled by user")
+	text := c.L("ask.cancel.nothing", nil)
+	if count > 0 {
+		text = c.L("ask.cancel.done", map[string]any{"Count": count})
+	}
+	_, err := c.Tg.Send(telegram.NewMessage(msg.Chat.ID, text, msg.MessageID))
+	return err
+}
+
+// sendCanceled replaces the status message of the canceled request
+func (c *Command) sendCanceled(chatID int64, sentMsgID int) {
+	if sentMsgID == 0 {
+		return
+	}
+	if _, err := c.Tg.Send(telegram.NewEditMessageText(chatID, sentMsgID, c.L("ask.canceled", nil))); err != nil {
+		c.Logger.WithError(err).Warn("Failed to edit canceled request message")
+	}
+}
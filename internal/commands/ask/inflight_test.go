@@ -0,0 +1,217 @@
+package ask
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/muratoffalex/gachigazer/internal/app/di"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/queue"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInflightRequests_Replace(t *testing.T) {
+	requests := newInflightRequests()
+	first, finishFirst, err := requests.start(context.Background(), 1, 10, config.DedupReplace, false)
+	require.NoError(t, err)
+	defer finishFirst()
+
+	second, finishSecond, err := requests.start(context.Background(), 1, 20, config.DedupReplace, false)
+	require.NoError(t, err)
+	defer finishSecond()
+
+	assert.True(t, isRequestCanceled(first))
+	assert.NoError(t, second.Err())
+}
+
+func TestInflightRequests_ReplacePerUser(t *testing.T) {
+	requests := newInflightRequests()
+	first, finishFirst, err := requests.start(context.Background(), 1, 10, config.DedupReplace, true)
+	require.NoError(t, err)
+	defer finishFirst()
+
+	_, finishOther, err := requests.start(context.Background(), 1, 20, config.DedupReplace, true)
+	require.NoError(t, err)
+	defer finishOther()
+	assert.NoError(t, first.Err(), "requests of other users are kept")
+
+	_, finishSame, err := requests.start(context.Background(), 1, 10, config.DedupReplace, true)
+	require.NoError(t, err)
+	defer finishSame()
+	assert.True(t, isRequestCanceled(first))
+}
+
+func TestInflightRequests_Queue(t *testing.T) {
+	requests := newInflightRequests()
+	_, finishFirst, err := requests.start(context.Background(), 1, 10, config.DedupQueue, false)
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	go func() {
+		_, finish, err := requests.start(context.Background(), 1, 10, config.DedupQueue, false)
+		assert.NoError(t, err)
+		finish()
+		close(started)
+	}()
+
+	select {
+	case <-started:
+		t.Fatal("queued request started before the previous one finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+	finishFirst()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("queued request didn't start")
+	}
+}
+
+func TestInflightRequests_CancelUser(t *testing.T) {
+	requests := newInflightRequests()
+	_, finishFirst, err := requests.start(context.Background(), 1, 10, config.DedupQueue, false)
+	require.NoError(t, err)
+	defer finishFirst()
+
+	queued := make(chan error)
+	go func() {
+		_, _, err := requests.start(context.Background(), 1, 10, config.DedupQueue, false)
+		queued <- err
+	}()
+	assert.Eventually(t, func() bool {
+		requests.mu.Lock()
+		defer requests.mu.Unlock()
+		return len(requests.requests[1]) == 2
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, 0, requests.cancelUser(1, 20))
+	assert.Equal(t, 2, requests.cancelUser(1, 10))
+	assert.ErrorIs(t, <-queued, errRequestCanceled)
+
+	finishFirst()
+	assert.Empty(t, requests.requests)
+}
+
+func TestInflightRequests_Park(t *testing.T) {
+	requests := newInflightRequests()
+	assert.False(t, requests.busy(1, 10, false))
+	_, finish, err := requests.start(context.Background(), 1, 10, config.DedupQueue, false)
+	require.NoError(t, err)
+	assert.True(t, requests.busy(1, 20, false))
+	assert.False(t, requests.busy(1, 20, true), "Only requests of the user count")
+
+	assert.Nil(t, requests.take("1:5"))
+	first, cancelFirst := context.WithCancel(t.Context())
+	defer cancelFirst()
+	second, cancelSecond := context.WithCancel(t.Context())
+	defer cancelSecond()
+	requests.park("1:5", first, finish)
+	requests.park("1:5", second, func() {})
+
+	parked := requests.take("1:5")
+	require.NotNil(t, parked)
+	assert.Same(t, first, parked.ctx, "The request parked first is taken first")
+	parked.finish()
+	assert.False(t, requests.busy(1, 10, false))
+	parked = requests.take("1:5")
+	require.NotNil(t, parked)
+	assert.Same(t, second, parked.ctx)
+	assert.Empty(t, requests.parked)
+}
+
+func TestInflightRequests_Unpark(t *testing.T) {
+	requests := newInflightRequests()
+	ctx, finishFirst, err := requests.start(context.Background(), 1, 10, config.DedupQueue, false)
+	require.NoError(t, err)
+	first := requests.park("1:5", ctx, finishFirst)
+	ctx, finishSecond, err := requests.start(context.Background(), 1, 20, config.DedupQueue, true)
+	require.NoError(t, err)
+	second := requests.park("1:5", ctx, finishSecond)
+
+	assert.True(t, requests.unpark("1:5", second))
+	assert.False(t, requests.unpark("1:5", second))
+	finishSecond()
+	assert.Same(t, first, requests.take("1:5"), "Only the own request is removed")
+	assert.False(t, requests.unpark("1:5", first), "Taken request isn't removed")
+	assert.Empty(t, requests.parked)
+	finishFirst()
+}
+
+func TestCommand_Execute_FinishesOnlyTakenRequest(t *testing.T) {
+	cmd := &Command{inflight: newInflightRequests()}
+	update := telegram.Update{}
+	for _, userID := range []int64{10, 20} {
+		ctx, finish, err := cmd.inflight.start(context.Background(), 1, userID, config.DedupQueue, true)
+		require.NoError(t, err)
+		cmd.inflight.park(requestKey(update), ctx, finish)
+	}
+
+	require.Error(t, cmd.Execute(update))
+	assert.False(t, cmd.inflight.busy(1, 10, true))
+	assert.True(t, cmd.inflight.busy(1, 20, true), "The same request parked twice keeps its place")
+	assert.NotNil(t, cmd.inflight.take(requestKey(update)))
+}
+
+func TestCommand_Handle_QueueWaitsBeforeQueue(t *testing.T) {
+	const toml = `
+[telegram]
+token = "token"
+
+[database]
+dsn = "test.db"
+
+[commands.ask.dedup]
+policy = "queue"
+`
+	cmd := newFallbackTestCommand(t, toml)
+	db, err := database.NewSQLiteDB(cmd.Cfg, logger.NewTestLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	cmd.Command = base.NewCommand(cmd, &di.Container{
+		Cfg:    cmd.Cfg,
+		Logger: logger.NewTestLogger(),
+		Queue:  queue.NewQueue(db, logger.NewTestLogger()),
+	})
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.inflight = newInflightRequests()
+	queuedTasks := func() int {
+		var count int
+		require.NoError(t, db.GetDB().QueryRow("SELECT COUNT(*) FROM tasks").Scan(&count))
+		return count
+	}
+
+	_, finishFirst, err := cmd.inflight.start(t.Context(), 100, 1, config.DedupQueue, false)
+	require.NoError(t, err)
+	update := telegram.Update{Message: &telegram.MessageOriginal{
+		MessageID: 5,
+		Chat:      tgbotapi.Chat{ID: 100},
+		From:      &tgbotapi.User{ID: 2},
+		Text:      "/a hi",
+	}}
+	require.NoError(t, cmd.Handle(update), "Handle doesn't block while the previous request runs")
+	time.Sleep(20 * time.Millisecond)
+	assert.Zero(t, queuedTasks(), "Waiting request doesn't take a queue worker")
+
+	finishFirst()
+	assert.Eventually(t, func() bool { return queuedTasks() == 1 }, time.Second, time.Millisecond)
+	assert.True(t, cmd.inflight.busy(100, 3, false), "Queued request keeps its place until it runs")
+
+	parked := cmd.inflight.take(requestKey(update))
+	require.NotNil(t, parked, "Execute takes over the request")
+	parked.finish()
+	assert.False(t, cmd.inflight.busy(100, 3, false))
+}
+
+func TestIsCancelCommand(t *testing.T) {
+	assert.True(t, isCancelCommand("/cancel"))
+	assert.True(t, isCancelCommand(" /cancel@gachi_bot "))
+	assert.False(t, isCancelCommand("/cancellation"))
+	assert.False(t, isCancelCommand("/a cancel"))
+}
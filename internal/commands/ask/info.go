@@ -3,14 +3,29 @@ package ask
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/markdown"
+	"github.com/muratoffalex/gachigazer/internal/service"
 	"github.com/muratoffalex/gachigazer/internal/telegram"
 )
 
+const (
+	urlContentCallback      = "urlcontent"
+	urlFileCallback         = "urlfile"
+	rawDataCallback         = "rawdata"
+	urlContentPreviewLength = 500
+	// full content longer than this number of messages is sent as a .txt file
+	urlContentMaxMessages = 5
+)
+
 func (c *Command) handleInfoCommand(update telegram.Update) error {
 	originalMsgID := int(0)
 	if update.Message.ReplyToMessage != nil {
@@ -107,7 +122,7 @@ func (c *Command) handleInfoCommand(update telegram.Update) error {
 
 	content := MessageContent{ConversationHistory: conversationHistory}
 	allContext := currentContext.GetFormattedString(model, provider, c.Localizer, true)
-	currencyConfig := c.Cfg.Currency()
+	currencyConfig := c.ChatService.GetChatCurrency(msg.ChatID)
 	metadata := NewMetadata(
 		model,
 		provider,
@@ -132,6 +147,23 @@ func (c *Command) handleInfoCommand(update telegram.Update) error {
 	blocks = append(blocks, metadata.GetDetailedInfo())
 	blocks = append(blocks, metadata.GetFormattedString())
 
+	if spent, err := c.db.GetChatDailyCost(chatID); err != nil {
+		c.Logger.WithError(err).Error("Failed to get daily cost for chat")
+	} else if limit := c.Cfg.AI().DailyCostLimit; limit > 0 {
+		blocks = append(blocks, c.L("ask.info.dailyCostWithLimit", map[string]any{
+			"Spent": markdown.Escape(service.FormatCost(spent, &currencyConfig)),
+			"Limit": markdown.Escape(service.FormatCost(limit, &currencyConfig)),
+		}))
+	} else if spent > 0 {
+		blocks = append(blocks, c.L("ask.info.dailyCost", map[string]any{
+			"Spent": markdown.Escape(service.FormatCost(spent, &currencyConfig)),
+		}))
+	}
+
+	if c.ChatService.IsFreeOnly(chatID) {
+		blocks = append(blocks, c.L("ask.info.freeOnly", nil))
+	}
+
 	response := strings.Join(blocks, "\n\n")
 	response = strings.ToValidUTF8(response, "")
 
@@ -140,6 +172,13 @@ func (c *Command) handleInfoCommand(update telegram.Update) error {
 	infoMsg := telegram.NewMessage(chatID, response, messageID)
 	infoMsg.ParseMode = telegram.ModeMarkdownV2
 	infoMsg.LinkPreviewDisabled = true
+	keyboard := c.urlContentKeyboard(currentContext.URLs, originalMsgID, update.Message.From.ID)
+	if rawDataDocument(conversationHistory, originalMsgID) != "" {
+		keyboard = c.withRawDataButton(keyboard, originalMsgID, update.Message.From.ID)
+	}
+	if keyboard != nil {
+		infoMsg.ReplyMarkup = keyboard
+	}
 	if len(images) > 0 {
 		inputs := []telegram.InputMedia{}
 		for i, image := range images {
@@ -171,3 +210,190 @@ func (c *Command) handleInfoCommand(update telegram.Update) error {
 	_, err = c.Tg.Send(infoMsg)
 	return err
 }
+
+// urlContentKeyboard adds buttons to get the full content of URLs truncated in the preview
+func (c *Command) urlContentKeyboard(urls []*URLInfo, messageID int, userID int64) *telegram.InlineKeyboardMarkup {
+	var rows [][]telegram.InlineKeyboardButton
+	for i, url := range urls {
+		if url.Content == "" {
+			continue
+		}
+		action, textID := urlContentCallback, "ask.info.showFullContent"
+		if utf8.RuneCountInString(url.Content) > urlContentMaxMessages*telegramMaxLength {
+			action, textID = urlFileCallback, "ask.info.sendContentAsFile"
+		}
+		rows = append(rows, telegram.NewInlineKeyboardRow(telegram.NewInlineKeyboardButtonData(
+			c.L(textID, map[string]any{"Number": i + 1}),
+			fmt.Sprintf("%s %s:%d:%d:%d", CommandName, action, messageID, i, userID),
+		)))
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// withRawDataButton adds a button to download stored tool responses and fetched URL content
+func (c *Command) withRawDataButton(keyboard *telegram.InlineKeyboardMarkup, messageID int, userID int64) *telegram.InlineKeyboardMarkup {
+	if keyboard == nil {
+		keyboard = &telegram.InlineKeyboardMarkup{}
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, telegram.NewInlineKeyboardRow(telegram.NewInlineKeyboardButtonData(
+		c.L("ask.info.sendRawData", nil),
+		fmt.Sprintf("%s %s:%d:%d", CommandName, rawDataCallback, messageID, userID),
+	)))
+	return keyboard
+}
+
+// rawDataDocument collects full tool responses of the message and fetched URL content of its context
+func rawDataDocument(conversationHistory []conversationMessage, messageID int) string {
+	var sb strings.Builder
+	for _, message := range conversationHistory {
+		if message.MessageID != messageID || !message.Role.IsTool() {
+			continue
+		}
+		fmt.Fprintf(&sb, "=== Tool: %s ===\n", message.ToolName.String)
+		if len(message.ToolParams) > 0 {
+			params, _ := json.Marshal(message.ToolParams)
+			fmt.Fprintf(&sb, "Params: %s\n", params)
+		}
+		if len(message.ToolResponses) == 0 {
+			sb.WriteString(message.Text + "\n\n")
+			continue
+		}
+		for _, response := range message.ToolResponses {
+			text := response.Text
+			if text == "" && len(response.Content) > 0 {
+				content, _ := json.Marshal(response.Content)
+				text = string(content)
+			}
+			sb.WriteString(text + "\n\n")
+		}
+	}
+	for _, message := range conversationHistory {
+		for _, url := range message.URLs {
+			content := url.Content
+			if content == "" {
+				content = url.TrimmedContent
+			}
+			if content == "" {
+				continue
+			}
+			fmt.Fprintf(&sb, "=== URL: %s ===\n%s\n\n", url.URL, content)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func isURLContentCallback(data string) bool {
+	return strings.HasPrefix(data, CommandName+" "+urlContentCallback+":") ||
+		strings.HasPrefix(data, CommandName+" "+urlFileCallback+":")
+}
+
+// handleURLContentCallback re-sends the untruncated URL content from /info,
+// only for the user who requested the info
+func (c *Command) handleURLContentCallback(update telegram.Update) error {
+	callback := update.CallbackQuery
+	chatID := callback.Message.Chat.ID
+	replyTo := callback.Message.MessageID
+
+	parts := strings.Split(strings.TrimPrefix(callback.Data, CommandName+" "), ":")
+	if len(parts) != 4 {
+		return fmt.Errorf("invalid url content callback data: %s", callback.Data)
+	}
+	action := parts[0]
+	messageID, errMsg := strconv.Atoi(parts[1])
+	index, errIndex := strconv.Atoi(parts[2])
+	userID, errUser := strconv.ParseInt(parts[3], 10, 64)
+	if err := errors.Join(errMsg, errIndex, errUser); err != nil {
+		return fmt.Errorf("invalid url content callback data: %w", err)
+	}
+
+	if callback.From == nil || callback.From.ID != userID {
+		c.Logger.WithFields(logger.Fields{
+			"chat_id": chatID,
+			"user_id": userID,
+		}).Warn("URL content requested not by the info requester, skip")
+		return nil
+	}
+
+	conversationHistory, err := c.getConversationHistory(chatID, messageID)
+	if err != nil {
+		return fmt.Errorf("get conversation history: %w", err)
+	}
+	var urls []*URLInfo
+	for _, message := range conversationHistory {
+		urls = append(urls, message.URLs...)
+	}
+	if index < 0 || index >= len(urls) || urls[index].Content == "" {
+		return fmt.Errorf("url content %d not found", index)
+	}
+	url := urls[index]
+
+	if action == urlFileCallback {
+		msg := telegram.NewDocumentMessage(
+			chatID,
+			telegram.FileBytes{Name: fmt.Sprintf("content_%d.txt", index+1), Bytes: []byte(url.Content)},
+			url.URL,
+			replyTo,
+		)
+		_, err := c.Tg.Send(msg)
+		return err
+	}
+
+	for _, chunk := range splitText(url.Content, telegramMaxLength) {
+		msg := telegram.NewMessage(chatID, chunk, replyTo)
+		msg.LinkPreviewDisabled = true
+		if _, err := c.Tg.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isRawDataCallback(data string) bool {
+	return strings.HasPrefix(data, CommandName+" "+rawDataCallback+":")
+}
+
+// handleRawDataCallback sends tool responses and fetched URL content from /info as a .txt file,
+// only for the user who requested the info
+func (c *Command) handleRawDataCallback(update telegram.Update) error {
+	callback := update.CallbackQuery
+	chatID := callback.Message.Chat.ID
+
+	parts := strings.Split(strings.TrimPrefix(callback.Data, CommandName+" "+rawDataCallback+":"), ":")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid raw data callback data: %s", callback.Data)
+	}
+	messageID, errMsg := strconv.Atoi(parts[0])
+	userID, errUser := strconv.ParseInt(parts[1], 10, 64)
+	if err := errors.Join(errMsg, errUser); err != nil {
+		return fmt.Errorf("invalid raw data callback data: %w", err)
+	}
+
+	if callback.From == nil || callback.From.ID != userID {
+		c.Logger.WithFields(logger.Fields{
+			"chat_id": chatID,
+			"user_id": userID,
+		}).Warn("Raw data requested not by the info requester, skip")
+		return nil
+	}
+
+	conversationHistory, err := c.getConversationHistory(chatID, messageID)
+	if err != nil {
+		return fmt.Errorf("get conversation history: %w", err)
+	}
+	document := rawDataDocument(conversationHistory, messageID)
+	if document == "" {
+		return fmt.Errorf("raw data for message %d not found", messageID)
+	}
+
+	msg := telegram.NewDocumentMessage(
+		chatID,
+		telegram.FileBytes{Name: fmt.Sprintf("raw_%d.txt", messageID), Bytes: []byte(document)},
+		"",
+		callback.Message.MessageID,
+	)
+	_, err = c.Tg.Send(msg)
+	return err
+}
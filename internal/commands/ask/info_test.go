@@ -0,0 +1,190 @@
+package ask
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/service"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newInfoTestCommand(t *testing.T, tg telegram.Client) *Command {
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+	return &Command{
+		Command: &base.Command{
+			Tg:        tg,
+			Logger:    logger.NewTestLogger(),
+			Localizer: localizer,
+		},
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	t.Run("short text is not split", func(t *testing.T) {
+		assert.Equal(t, []string{"hello"}, splitText("hello", 10))
+	})
+
+	t.Run("splits on line break", func(t *testing.T) {
+		text := "first line\nsecond line"
+		assert.Equal(t, []string{"first line", "second line"}, splitText(text, 15))
+	})
+
+	t.Run("splits long line by limit", func(t *testing.T) {
+		text := strings.Repeat("я", 25)
+		chunks := splitText(text, 10)
+		require.Len(t, chunks, 3)
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, utf8.RuneCountInString(chunk), 10)
+		}
+		assert.Equal(t, text, strings.Join(chunks, ""))
+	})
+}
+
+func TestCommand_urlContentKeyboard(t *testing.T) {
+	cmd := newInfoTestCommand(t, nil)
+
+	t.Run("no truncated content", func(t *testing.T) {
+		urls := []*URLInfo{{URL: "https://example.com", TrimmedContent: "short"}}
+		assert.Nil(t, cmd.urlContentKeyboard(urls, 10, 1))
+	})
+
+	t.Run("buttons for truncated content", func(t *testing.T) {
+		urls := []*URLInfo{
+			{URL: "https://example.com/short", TrimmedContent: "short"},
+			{URL: "https://example.com/long", Content: strings.Repeat("a", 1000)},
+			{URL: "https://example.com/huge", Content: strings.Repeat("a", urlContentMaxMessages*telegramMaxLength+1)},
+		}
+
+		keyboard := cmd.urlContentKeyboard(urls, 10, 1)
+		require.NotNil(t, keyboard)
+		require.Len(t, keyboard.InlineKeyboard, 2)
+
+		full := keyboard.InlineKeyboard[0][0]
+		assert.Equal(t, "📄 Full content #2", full.Text)
+		assert.Equal(t, "ask urlcontent:10:1:1", *full.CallbackData)
+
+		file := keyboard.InlineKeyboard[1][0]
+		assert.Equal(t, "📎 Content #3 as .txt", file.Text)
+		assert.Equal(t, "ask urlfile:10:2:1", *file.CallbackData)
+	})
+}
+
+func TestCommand_handleURLContentCallback(t *testing.T) {
+	newUpdate := func(data string, userID int64) telegram.Update {
+		return telegram.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			Data: data,
+			From: &tgbotapi.User{ID: userID},
+			Message: &tgbotapi.Message{
+				MessageID: 20,
+				Chat:      tgbotapi.Chat{ID: 100},
+			},
+		}}
+	}
+
+	t.Run("is url content callback", func(t *testing.T) {
+		assert.True(t, isURLContentCallback("ask urlcontent:10:1:1"))
+		assert.True(t, isURLContentCallback("ask urlfile:10:1:1"))
+		assert.False(t, isURLContentCallback("ask retry:10"))
+	})
+
+	t.Run("other user is ignored", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+		cmd := newInfoTestCommand(t, tg)
+
+		err := cmd.handleURLContentCallback(newUpdate("ask urlcontent:10:1:1", 2))
+		require.NoError(t, err)
+		tg.AssertNotCalled(t, "Send")
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		cmd := newInfoTestCommand(t, telegram.NewMockClient(t))
+
+		err := cmd.handleURLContentCallback(newUpdate("ask urlcontent:10:x:1", 1))
+		require.Error(t, err)
+
+		err = cmd.handleURLContentCallback(newUpdate("ask urlcontent:10", 1))
+		require.Error(t, err)
+	})
+}
+
+func TestRawDataDocument(t *testing.T) {
+	longResponse := strings.Repeat("tool output line\n", 1000)
+	longContent := strings.Repeat("page content ", 1000)
+	history := []conversationMessage{
+		{
+			MessageID:     20,
+			Role:          ai.RoleTool,
+			ToolName:      sql.NullString{String: "search", Valid: true},
+			ToolParams:    map[string]any{"query": "golang"},
+			ToolResponses: []ai.Message{{Role: ai.RoleTool, Text: longResponse}},
+		},
+		{
+			MessageID:     15,
+			Role:          ai.RoleTool,
+			ToolName:      sql.NullString{String: "other", Valid: true},
+			ToolResponses: []ai.Message{{Role: ai.RoleTool, Text: "previous answer tool"}},
+		},
+		{
+			MessageID: 10,
+			Role:      ai.RoleUser,
+			URLs: []*URLInfo{
+				{URL: "https://example.com/long", TrimmedContent: longContent[:urlContentPreviewLength], Content: longContent},
+				{URL: "https://example.com/short", TrimmedContent: "short content"},
+			},
+		},
+	}
+
+	t.Run("contains full stored content", func(t *testing.T) {
+		document := rawDataDocument(history, 20)
+
+		assert.Contains(t, document, "=== Tool: search ===")
+		assert.Contains(t, document, `Params: {"query":"golang"}`)
+		assert.Contains(t, document, strings.TrimSpace(longResponse))
+		assert.Contains(t, document, "=== URL: https://example.com/long ===\n"+longContent)
+		assert.Contains(t, document, "=== URL: https://example.com/short ===\nshort content")
+		assert.NotContains(t, document, "previous answer tool")
+	})
+
+	t.Run("empty without tools and urls", func(t *testing.T) {
+		assert.Empty(t, rawDataDocument([]conversationMessage{{MessageID: 20, Role: ai.RoleAssistant}}, 20))
+	})
+}
+
+func TestCommand_handleRawDataCallback(t *testing.T) {
+	newUpdate := func(data string, userID int64) telegram.Update {
+		return telegram.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			Data:    data,
+			From:    &tgbotapi.User{ID: userID},
+			Message: &tgbotapi.Message{MessageID: 30, Chat: tgbotapi.Chat{ID: 100}},
+		}}
+	}
+
+	t.Run("is raw data callback", func(t *testing.T) {
+		assert.True(t, isRawDataCallback("ask rawdata:20:1"))
+		assert.False(t, isRawDataCallback("ask urlfile:20:1:1"))
+	})
+
+	t.Run("other user is ignored", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+		cmd := newInfoTestCommand(t, tg)
+
+		require.NoError(t, cmd.handleRawDataCallback(newUpdate("ask rawdata:20:1", 2)))
+		tg.AssertNotCalled(t, "Send")
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		cmd := newInfoTestCommand(t, telegram.NewMockClient(t))
+
+		require.Error(t, cmd.handleRawDataCallback(newUpdate("ask rawdata:x:1", 1)))
+		require.Error(t, cmd.handleRawDataCallback(newUpdate("ask rawdata:20", 1)))
+	})
+}
@@ -0,0 +1,87 @@
+package ask
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+)
+
+// max decoded size of an image pasted as a data URL
+const maxInlineImageSize = 5 << 20
+
+var (
+	inlineImageRegex = regexp.MustCompile(`data:image/([a-zA-Z0-9.+\-]+);base64,([A-Za-z0-9+/]+=*)`)
+
+	errUnsupportedImageType = errors.New("unsupported image type")
+)
+
+// detectImageType returns the image format by its signature: png, jpeg or webp
+func detectImageType(data []byte) (string, error) {
+	switch {
+	case len(data) > 8 && string(data[0:8]) == "\x89PNG\r\n\x1a\n":
+		return "png", nil
+	case len(data) > 2 && string(data[0:2]) == "\xff\xd8":
+		return "jpeg", nil
+	case len(data) > 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp", nil
+	default:
+		return "", errUnsupportedImageType
+	}
+}
+
+// extractInlineImages cuts base64 data URLs of images out of the text and returns them as
+// image contents, so images can be pasted as text. The declared type must match the data,
+// invalid images are removed from the text too and returned as errors
+func extractInlineImages(text string, maxDimension int) (string, []ai.Content, []error) {
+	if !strings.Contains(text, "data:image/") {
+		return text, nil, nil
+	}
+
+	var images []ai.Content
+	var errs []error
+	text = inlineImageRegex.ReplaceAllStringFunc(text, func(match string) string {
+		groups := inlineImageRegex.FindStringSubmatch(match)
+		image, err := createInlineImageContent(strings.ToLower(groups[1]), groups[2], maxDimension)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			images = append(images, image)
+		}
+		return ""
+	})
+	return strings.TrimSpace(text), images, errs
+}
+
+func createInlineImageContent(declaredType, encoded string, maxDimension int) (ai.Content, error) {
+	if base64.StdEncoding.DecodedLen(len(encoded)) > maxInlineImageSize+2 {
+		return ai.Content{}, fmt.Errorf("inline image is larger than %d bytes", maxInlineImageSize)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return ai.Content{}, fmt.Errorf("invalid base64 of inline image: %w", err)
+	}
+	if len(data) > maxInlineImageSize {
+		return ai.Content{}, fmt.Errorf("inline image is larger than %d bytes", maxInlineImageSize)
+	}
+
+	imageType, err := detectImageType(data)
+	if err != nil {
+		return ai.Content{}, err
+	}
+	if declaredType == "jpg" {
+		declaredType = "jpeg"
+	}
+	if declaredType != imageType {
+		return ai.Content{}, fmt.Errorf("inline image is declared as %s, but it is %s", declaredType, imageType)
+	}
+
+	data, err = downscaleImage(data, maxDimension)
+	if err != nil {
+		return ai.Content{}, err
+	}
+	return createImageContent("data:image/" + imageType + ";base64," + fileToBase64(data)), nil
+}
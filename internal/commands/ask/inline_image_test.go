@@ -0,0 +1,58 @@
+package ask
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractInlineImages(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString(newTestImage(t, "png", 4, 4))
+	jpeg := base64.StdEncoding.EncodeToString(newTestImage(t, "jpeg", 4, 4))
+
+	t.Run("images are cut from text", func(t *testing.T) {
+		text := "what is on these pictures? data:image/png;base64," + png + "\nand data:image/jpg;base64," + jpeg
+
+		result, images, errs := extractInlineImages(text, 0)
+		assert.Empty(t, errs)
+		assert.Equal(t, "what is on these pictures? \nand", result)
+		require.Len(t, images, 2)
+		assert.Equal(t, "image_url", images[0].Type)
+		assert.Equal(t, "data:image/png;base64,"+png, images[0].ImageURL.URL)
+		assert.Equal(t, "data:image/jpeg;base64,"+jpeg, images[1].ImageURL.URL)
+	})
+
+	t.Run("declared type must match data", func(t *testing.T) {
+		result, images, errs := extractInlineImages("data:image/png;base64,"+jpeg+" look", 0)
+		assert.Equal(t, "look", result)
+		assert.Empty(t, images)
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Error(), "declared as png")
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		data := base64.StdEncoding.EncodeToString([]byte("just some text, not an image"))
+		_, images, errs := extractInlineImages("data:image/png;base64,"+data, 0)
+		assert.Empty(t, images)
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], errUnsupportedImageType)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		data := strings.Repeat("A", base64.StdEncoding.EncodedLen(maxInlineImageSize+100))
+		_, images, errs := extractInlineImages("data:image/png;base64,"+data, 0)
+		assert.Empty(t, images)
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Error(), "larger than")
+	})
+
+	t.Run("text without images", func(t *testing.T) {
+		result, images, errs := extractInlineImages("data:text/plain;base64,aGk=", 0)
+		assert.Equal(t, "data:text/plain;base64,aGk=", result)
+		assert.Empty(t, images)
+		assert.Empty(t, errs)
+	})
+}
@@ -0,0 +1,88 @@
+package ask
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+)
+
+const (
+	LengthShort  = "short"
+	LengthMedium = "medium"
+	LengthLong   = "long"
+
+	defaultLengthMaxTokens = 1000
+	// tokens per word with a margin for markdown and non-english languages
+	tokensPerWord      = 2
+	minLengthMaxTokens = 128
+)
+
+var lengthValues = []string{LengthShort, LengthMedium, LengthLong}
+
+// lengthMaxTokensScale scales the configured max_tokens for each length
+var lengthMaxTokensScale = map[string]float64{
+	LengthShort:  0.5,
+	LengthMedium: 1,
+	LengthLong:   4,
+}
+
+// parseLengthWords returns the word count of $len value, 0 if it's not a number
+func parseLengthWords(value string) int {
+	words, err := strconv.Atoi(value)
+	if err != nil || words <= 0 {
+		return 0
+	}
+	return words
+}
+
+func isValidLength(value string) bool {
+	return slices.Contains(lengthValues, value) || parseLengthWords(value) > 0
+}
+
+// lengthInstruction returns the system prompt part describing the expected answer length
+func lengthInstruction(length string) string {
+	switch length {
+	case LengthShort:
+		return `
+[Response length: short]
+Answer in a few sentences (2-4), only the essentials, no introductions or summaries.`
+	case LengthMedium:
+		return `
+[Response length: medium]
+Answer in a few short paragraphs, covering the main points without going into every detail.`
+	case LengthLong:
+		return `
+[Response length: long]
+Give a detailed and thorough answer, the usual brevity preference does not apply. Still keep it within a single message.`
+	}
+	if words := parseLengthWords(length); words > 0 {
+		return fmt.Sprintf(`
+[Response length: ~%d words]
+Answer in about %d words.`, words, words)
+	}
+	return ""
+}
+
+// lengthMaxTokens returns max_tokens for the length based on the configured value
+func lengthMaxTokens(length string, baseMaxTokens *int) int {
+	base := defaultLengthMaxTokens
+	if baseMaxTokens != nil && *baseMaxTokens > 0 {
+		base = *baseMaxTokens
+	}
+	if words := parseLengthWords(length); words > 0 {
+		return max(words*tokensPerWord, minLengthMaxTokens)
+	}
+	scale, ok := lengthMaxTokensScale[length]
+	if !ok {
+		return base
+	}
+	return max(int(float64(base)*scale), minLengthMaxTokens)
+}
+
+// supportsMaxTokens reports whether max_tokens can be sent to the model,
+// models without known parameters are assumed to support it
+func supportsMaxTokens(model *ai.ModelInfo) bool {
+	return model == nil || len(model.SupportedParameters) == 0 || slices.Contains(model.SupportedParameters, "max_tokens")
+}
@@ -0,0 +1,58 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateArg_Length(t *testing.T) {
+	arg := &Argument{
+		Name:   "len",
+		Type:   "string",
+		Values: []string{LengthShort, LengthMedium, LengthLong, "word count"},
+	}
+
+	for _, value := range []string{"short", "medium", "long", "150"} {
+		assert.NoError(t, validateArg(arg, value), value)
+	}
+	for _, value := range []string{"huge", "0", "-5", "word count"} {
+		assert.Error(t, validateArg(arg, value), value)
+	}
+}
+
+func TestLengthMaxTokens(t *testing.T) {
+	base := 1000
+	tests := []struct {
+		name   string
+		length string
+		base   *int
+		want   int
+	}{
+		{"short", LengthShort, &base, 500},
+		{"medium", LengthMedium, &base, 1000},
+		{"long lifts the cap", LengthLong, &base, 4000},
+		{"default base", LengthLong, nil, 4000},
+		{"word count", "300", &base, 600},
+		{"tiny word count", "10", &base, minLengthMaxTokens},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, lengthMaxTokens(tt.length, tt.base))
+		})
+	}
+}
+
+func TestLengthInstruction(t *testing.T) {
+	assert.Empty(t, lengthInstruction(""))
+	assert.Contains(t, lengthInstruction(LengthShort), "few sentences")
+	assert.Contains(t, lengthInstruction(LengthLong), "brevity preference does not apply")
+	assert.Contains(t, lengthInstruction("200"), "about 200 words")
+}
+
+func TestSupportsMaxTokens(t *testing.T) {
+	assert.True(t, supportsMaxTokens(&ai.ModelInfo{}))
+	assert.True(t, supportsMaxTokens(&ai.ModelInfo{SupportedParameters: []string{"tools", "max_tokens"}}))
+	assert.False(t, supportsMaxTokens(&ai.ModelInfo{SupportedParameters: []string{"tools"}}))
+}
@@ -0,0 +1,73 @@
+package ask
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+// userFacts returns the remembered facts about the user, they are added to the
+// system prompt in chats with memory enabled
+func (c *Command) userFacts(userID int64) []string {
+	facts, err := c.db.GetUserFacts(userID)
+	if err != nil {
+		c.Logger.WithError(err).WithField("user_id", userID).Error("Failed to get user facts")
+		return nil
+	}
+	texts := make([]string, 0, len(facts))
+	for _, fact := range facts {
+		texts = append(texts, fact.Fact)
+	}
+	return texts
+}
+
+// knownAboutUserBlock renders the facts about the user for the system prompt
+func knownAboutUserBlock(facts []string) string {
+	if len(facts) == 0 {
+		return ""
+	}
+	var block strings.Builder
+	block.WriteString("[KNOWN ABOUT USER]\nFacts the user asked to remember in previous conversations, take them into account when relevant:")
+	for _, fact := range facts {
+		block.WriteString("\n- " + fact)
+	}
+	return block.String()
+}
+
+// factsOverLimit returns the oldest facts to forget, so the rest fit memory.max_facts
+// and memory.max_length. The facts are ordered from the oldest one, limits of 0 are ignored
+func factsOverLimit(facts []database.UserFact, maxFacts, maxLength int) []database.UserFact {
+	total := 0
+	for _, fact := range facts {
+		total += utf8.RuneCountInString(fact.Fact)
+	}
+	forget := 0
+	for forget < len(facts) && ((maxFacts > 0 && len(facts)-forget > maxFacts) || (maxLength > 0 && total > maxLength)) {
+		total -= utf8.RuneCountInString(facts[forget].Fact)
+		forget++
+	}
+	return facts[:forget]
+}
+
+// rememberFact saves the fact about the user and forgets the oldest facts over the limits
+func (c *Command) rememberFact(userID int64, fact string, log logger.Logger) error {
+	factID, err := c.db.AddUserFact(userID, strings.TrimSpace(fact))
+	if err != nil {
+		return err
+	}
+	log.WithField("fact_id", factID).Info("Fact about the user remembered")
+
+	facts, err := c.db.GetUserFacts(userID)
+	if err != nil {
+		return err
+	}
+	for _, old := range factsOverLimit(facts, c.cmdCfg.Memory.MaxFacts, c.cmdCfg.Memory.MaxLength) {
+		if err := c.db.DeleteUserFact(userID, old.ID); err != nil {
+			return err
+		}
+		log.WithField("fact_id", old.ID).Info("Old fact about the user forgotten")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactsOverLimit(t *testing.T) {
+	facts := []database.UserFact{
+		{ID: 1, Fact: "aaaa"},
+		{ID: 2, Fact: "bbbb"},
+		{ID: 3, Fact: "cccc"},
+	}
+
+	assert.Empty(t, factsOverLimit(facts, 0, 0), "No limits")
+	assert.Empty(t, factsOverLimit(facts, 3, 12))
+	assert.Equal(t, facts[:1], factsOverLimit(facts, 2, 0), "Oldest facts over the count are forgotten")
+	assert.Equal(t, facts[:2], factsOverLimit(facts, 0, 5), "Oldest facts over the length are forgotten")
+	assert.Equal(t, facts, factsOverLimit(facts, 5, 3), "A fact longer than the limit is not kept")
+}
+
+func TestKnownAboutUserBlock(t *testing.T) {
+	assert.Empty(t, knownAboutUserBlock(nil))
+	assert.Equal(t,
+		"[KNOWN ABOUT USER]\nFacts the user asked to remember in previous conversations, take them into account when relevant:\n- The user is a vegetarian\n- The user lives in Berlin",
+		knownAboutUserBlock([]string{"The user is a vegetarian", "The user lives in Berlin"}),
+	)
+}
+
+func TestCommand_buildPromptWithHistory_UserFacts(t *testing.T) {
+	model := &ai.ModelInfo{ID: "main", Provider: "test"}
+	cmd := newToolsModelTestCommand(t, &CommandArgs{})
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Tools.Enabled = false
+
+	messages := cmd.buildPromptWithHistory(model, &MessageContent{Text: "hi"}, cmd.args, false)
+	assert.NotContains(t, messages[0].Text, "[KNOWN ABOUT USER]")
+
+	content := &MessageContent{Text: "what to cook?", UserFacts: []string{"The user is a vegetarian"}}
+	messages = cmd.buildPromptWithHistory(model, content, cmd.args, false)
+	assert.Contains(t, messages[0].Text, "[KNOWN ABOUT USER]")
+	assert.Contains(t, messages[0].Text, "- The user is a vegetarian")
+
+	content.Raw = true
+	messages = cmd.buildPromptWithHistory(model, content, cmd.args, false)
+	assert.NotContains(t, messages[0].Text, "[KNOWN ABOUT USER]", "Raw mode has no instructions")
+}
+
+func TestCommand_getTools_Remember(t *testing.T) {
+	cmd := newToolsModelTestCommand(t, &CommandArgs{})
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+
+	names := func(request *requestState) []string {
+		result := []string{}
+		for _, tool := range cmd.getTools(request, nil) {
+			result = append(result, tool.Function.Name)
+		}
+		return result
+	}
+	assert.NotContains(t, names(&requestState{}), "remember", "Not offered in chats without memory")
+	assert.Contains(t, names(&requestState{memory: true}), "remember")
+}
@@ -0,0 +1,85 @@
+package ask
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+const (
+	ocrCacheTTL = 24 * time.Hour
+	// answer of the OCR model for images without text
+	ocrNoText = "NO_TEXT"
+	ocrPrompt = "Extract all text from this image exactly as written, keep the line breaks and the reading order. " +
+		"Return only the extracted text without comments. If there is no text, return " + ocrNoText
+)
+
+// recognizeImagesText extracts text from the attached images with the OCR model if the request
+// model can't see images, so text models can work with screenshots. Results are cached by image
+func (c *Command) recognizeImagesText(ctx context.Context, chatID int64, model *ai.ModelInfo, content *MessageContent) {
+	images := content.GetImagesMedia()
+	if !c.cmdCfg.Images.OCREnabled || len(images) == 0 || model.SupportsImageRecognition() {
+		return
+	}
+	modelName := c.cmdCfg.Images.OCRModel
+	if modelName == "" {
+		modelName = c.Cfg.AI().MultimodalModel
+	}
+	if modelName == "" {
+		c.Logger.Warn("Images.OCREnabled is enabled but neither Images.OCRModel nor AI.MultimodalModel is set")
+		return
+	}
+	ocrModel, err := c.ai.GetFormattedModel(ctx, modelName, "")
+	if err != nil {
+		c.Logger.WithError(err).WithField("model", modelName).Error("Failed to get OCR model")
+		return
+	}
+
+	for _, image := range images {
+		text, err := c.imageText(ctx, chatID, ocrModel, image)
+		if err != nil {
+			c.Logger.WithError(err).WithField("model", ocrModel.FullName()).Error("Failed to extract text from image")
+			continue
+		}
+		if text != "" {
+			content.ImageTexts = append(content.ImageTexts, text)
+		}
+	}
+	c.Logger.WithFields(logger.Fields{
+		"images": len(images),
+		"texts":  len(content.ImageTexts),
+		"model":  ocrModel.FullName(),
+	}).Info("Extracted text from images for model without image recognition")
+}
+
+func (c *Command) imageText(ctx context.Context, chatID int64, model *ai.ModelInfo, image ai.Content) (string, error) {
+	key := "ask:ocr:" + mediaKey(image)
+	if c.cache != nil {
+		if data, found := c.cache.Get(key); found {
+			return string(data), nil
+		}
+	}
+
+	answer, _, _, _, _, _, _, err := c.Ask(ctx, []ai.Message{{
+		Role:    ai.RoleUser,
+		Content: []ai.Content{{Type: "text", Text: ocrPrompt}, image},
+	}}, nil, model, "", chatID, false, ai.ModelParams{})
+	if err != nil {
+		return "", fmt.Errorf("ocr request failed: %w", err)
+	}
+	text := strings.TrimSpace(answer)
+	if text == ocrNoText {
+		text = ""
+	}
+
+	if c.cache != nil {
+		if err := c.cache.Set(key, []byte(text), ocrCacheTTL); err != nil {
+			c.Logger.WithError(err).Warn("Failed to cache image text")
+		}
+	}
+	return text, nil
+}
@@ -0,0 +1,86 @@
+package ask
+
+import (
+	"context"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ocrProvider answers every request with the text and counts requests
+type ocrProvider struct {
+	stubProvider
+	answer string
+	calls  *int
+}
+
+func (p ocrProvider) CreateRequest(_ bool, messages []ai.Message, _ []ai.Tool, _ *ai.ModelInfo, _ ai.ModelParams, _ bool) ai.CompletionRequest {
+	return ai.CompletionRequest{Messages: messages}
+}
+
+func (p ocrProvider) Ask(context.Context, ai.CompletionRequest, map[string]string) (string, string, *ai.CompletionResponse, *ai.ModelInfo, error) {
+	*p.calls++
+	return p.answer, "", nil, nil, nil
+}
+
+func TestCommand_recognizeImagesText(t *testing.T) {
+	vision := &ai.ModelArchitecture{InputModalities: []string{"text", "image"}}
+	newCommand := func(t *testing.T, answer string, calls *int) *Command {
+		cmd := newFallbackTestCommand(t, "[telegram]\ntoken = \"token\"\n")
+		cmd.ai.SetChatService(stubChatService{})
+		cmd.ai.RegisterProvider("ocr", ocrProvider{
+			stubProvider: stubProvider{models: map[string]*ai.ModelInfo{
+				"reader": {ID: "reader", Provider: "ocr", Architecture: vision},
+			}},
+			answer: answer,
+			calls:  calls,
+		})
+		cmd.cache = cache.NewMemoryCache()
+		cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+		cmd.cmdCfg.Images.OCREnabled = true
+		cmd.cmdCfg.Images.OCRModel = "ocr:reader"
+		return cmd
+	}
+	textModel := &ai.ModelInfo{ID: "main", Provider: "test"}
+	screenshot := imageContent("data:image/png;base64,c2NyZWVu")
+
+	t.Run("text is extracted once per image", func(t *testing.T) {
+		calls := 0
+		cmd := newCommand(t, "  Error: connection refused\n", &calls)
+
+		content := &MessageContent{Text: "what does it mean?", Media: []ai.Content{screenshot}}
+		cmd.recognizeImagesText(t.Context(), 1, textModel, content)
+		assert.Equal(t, []string{"Error: connection refused"}, content.ImageTexts)
+		assert.Contains(t, content.GetMessageContent(), "what does it mean?\n\n[IMAGE TEXT]\nError: connection refused")
+
+		again := &MessageContent{Media: []ai.Content{screenshot}}
+		cmd.recognizeImagesText(t.Context(), 1, textModel, again)
+		assert.Equal(t, content.ImageTexts, again.ImageTexts)
+		assert.Equal(t, 1, calls, "Result is cached by image")
+	})
+
+	t.Run("image without text", func(t *testing.T) {
+		calls := 0
+		cmd := newCommand(t, ocrNoText, &calls)
+
+		content := &MessageContent{Media: []ai.Content{screenshot}}
+		cmd.recognizeImagesText(t.Context(), 1, textModel, content)
+		assert.Empty(t, content.ImageTexts)
+		assert.NotContains(t, content.GetMessageContent(), "[IMAGE TEXT]")
+	})
+
+	t.Run("skipped for vision model or when disabled", func(t *testing.T) {
+		calls := 0
+		cmd := newCommand(t, "text", &calls)
+
+		content := &MessageContent{Media: []ai.Content{screenshot}}
+		cmd.recognizeImagesText(t.Context(), 1, &ai.ModelInfo{ID: "main", Provider: "test", Architecture: vision}, content)
+		cmd.cmdCfg.Images.OCREnabled = false
+		cmd.recognizeImagesText(t.Context(), 1, textModel, content)
+		assert.Empty(t, content.ImageTexts)
+		require.Zero(t, calls)
+	})
+}
@@ -0,0 +1,56 @@
+package ask
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+// interval between edits of the status message, frequent edits of
+// one message hit the telegram rate limit
+const progressInterval = 3 * time.Second
+
+// startProgress periodically adds the elapsed time to the status message while the model
+// is thinking, if display.progress is enabled. The returned stop waits for the edit in
+// progress, so the message can be edited with the answer right after it
+func (c *Command) startProgress(chatID int64, messageID int, text string) (stop func()) {
+	if !c.cmdCfg.Display.Progress || messageID == 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	started := time.Now()
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				msg := telegram.NewEditMessageText(chatID, messageID, progressText(text, time.Since(started)))
+				if _, err := c.Tg.Send(msg); err != nil {
+					// the answer is more important than the progress
+					c.Logger.WithError(err).Warn("Failed to update progress, stop updates")
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
+
+func progressText(text string, elapsed time.Duration) string {
+	return fmt.Sprintf("%s %ds", text, int(elapsed.Seconds()))
+}
@@ -0,0 +1,28 @@
+package ask
+
+import (
+	"testing"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressText(t *testing.T) {
+	assert.Equal(t, "Thinking... 0s", progressText("Thinking...", 400*time.Millisecond))
+	assert.Equal(t, "Still thinking... 75s", progressText("Still thinking...", 75*time.Second+900*time.Millisecond))
+}
+
+func TestCommand_startProgress_Disabled(t *testing.T) {
+	// the mock fails the test on any edit
+	cmd := newInfoTestCommand(t, telegram.NewMockClient(t))
+	cmd.cmdCfg = &config.AskCommandConfig{}
+
+	stop := cmd.startProgress(1, 10, "Thinking...")
+	stop()
+	stop()
+
+	cmd.cmdCfg.Display.Progress = true
+	cmd.startProgress(1, 0, "Thinking...")()
+}
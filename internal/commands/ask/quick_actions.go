@@ -0,0 +1,87 @@
+package ask
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const quickActionCallback = "reaction"
+
+// QuickActionCallbackData returns the callback data of the update the dispatcher
+// passes for the reaction with the quick action on a bot message
+func QuickActionCallbackData(action string) string {
+	return fmt.Sprintf("%s %s:%s", CommandName, quickActionCallback, action)
+}
+
+func parseQuickActionCallback(data string) (string, bool) {
+	return strings.CutPrefix(data, CommandName+" "+quickActionCallback+":")
+}
+
+// quickActionRetryData returns the retry callback data rerunning the request,
+// ok is false when there is no other model for config.QuickActionOtherModel
+func (c *Command) quickActionRetryData(action string, requestMessageID int, answerModel string) (string, bool) {
+	data := fmt.Sprintf("%s retry:%d", CommandName, requestMessageID)
+	switch action {
+	case config.QuickActionRegenerate:
+		return data, true
+	case config.QuickActionOtherModel:
+		model := c.modelOtherThan(answerModel)
+		if model == "" {
+			return "", false
+		}
+		return data + " $m:" + model, true
+	}
+	return "", false
+}
+
+// handleQuickAction reruns the request of the answer the requester reacted to with the retry
+// callback, the answer is edited with the new one. Reactions of other users are ignored
+func (c *Command) handleQuickAction(update telegram.Update, action string, parked *parkedRequest) error {
+	callback := update.CallbackQuery
+	chatID := callback.Message.Chat.ID
+	actionLog := c.Logger.WithFields(logger.Fields{
+		"chat_id": chatID,
+		"user_id": callback.From.ID,
+		"action":  action,
+	})
+
+	answerID, err := c.db.GetFirstMessagePart(chatID, callback.Message.MessageID)
+	if err != nil {
+		actionLog.WithError(err).Warn("Failed to get first message of split answer")
+		answerID = callback.Message.MessageID
+	}
+	answer, err := c.getMessageFromHistory(chatID, int64(answerID))
+	if err != nil || !answer.Role.IsAssistant() || !answer.ReplyToMessageID.Valid {
+		actionLog.Debug("Reaction isn't on an answer, skip")
+		return nil
+	}
+	request, err := c.db.GetMessage(chatID, int(answer.ReplyToMessageID.Int64))
+	if err != nil {
+		actionLog.WithError(err).Warn("Failed to get request of the answer")
+		return nil
+	}
+	if request.Message == nil && request.BusinessMessage != nil {
+		request.Message = request.BusinessMessage
+	}
+	if request.Message == nil || request.Message.From == nil || request.Message.From.ID != callback.From.ID {
+		actionLog.Debug("Reaction of not the requester, skip")
+		return nil
+	}
+
+	data, ok := c.quickActionRetryData(action, request.Message.MessageID, answer.ModelName.String)
+	if !ok {
+		actionLog.WithField("model", answer.ModelName.String).Info("No model to rerun the request with")
+		return nil
+	}
+	actionLog.Info("Rerun request by reaction")
+	request.CallbackQuery = &telegram.CallbackQuery{
+		From:    callback.From,
+		Message: &telegram.MessageOriginal{MessageID: answerID, Chat: callback.Message.Chat},
+		Data:    data,
+	}
+	return c.execute(*request, parked)
+}
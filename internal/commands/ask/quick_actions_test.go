@@ -0,0 +1,56 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuickActionCallback(t *testing.T) {
+	action, ok := parseQuickActionCallback(QuickActionCallbackData(config.QuickActionOtherModel))
+	assert.True(t, ok)
+	assert.Equal(t, config.QuickActionOtherModel, action)
+
+	_, ok = parseQuickActionCallback("ask retry:10")
+	assert.False(t, ok)
+}
+
+func TestCommand_quickActionRetryData(t *testing.T) {
+	const toml = `
+[telegram]
+token = "token"
+allowed_users = [1]
+
+[ai]
+default_model = "test:main"
+
+[[ai.fallbacks]]
+model = "test:main"
+fallbacks = ["test:main", "test:free"]
+`
+	cmd := newFallbackTestCommand(t, toml)
+
+	t.Run("regenerate with the chat model", func(t *testing.T) {
+		data, ok := cmd.quickActionRetryData(config.QuickActionRegenerate, 10, "test:main")
+		assert.True(t, ok)
+		assert.Equal(t, "ask retry:10", data)
+	})
+
+	t.Run("other model is the first fallback", func(t *testing.T) {
+		data, ok := cmd.quickActionRetryData(config.QuickActionOtherModel, 10, "test:main")
+		assert.True(t, ok)
+		assert.Equal(t, "ask retry:10 $m:test:free", data)
+	})
+
+	t.Run("other model is the default model", func(t *testing.T) {
+		data, ok := cmd.quickActionRetryData(config.QuickActionOtherModel, 10, "test:free")
+		assert.True(t, ok)
+		assert.Equal(t, "ask retry:10 $m:test:main", data)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		_, ok := cmd.quickActionRetryData("delete", 10, "test:main")
+		assert.False(t, ok)
+	})
+}
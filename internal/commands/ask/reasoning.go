@@ -0,0 +1,49 @@
+package ask
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+)
+
+const (
+	EffortLow    = "low"
+	EffortMedium = "medium"
+	EffortHigh   = "high"
+)
+
+var effortValues = []string{EffortLow, EffortMedium, EffortHigh}
+
+// reasoning inlined into the answer by models like DeepSeek R1 or QwQ
+var inlineReasoningRegex = regexp.MustCompile(`(?s)^\s*<(?:think|thinking)>.*?</(?:think|thinking)>`)
+
+// stripReasoning returns the answer without the inlined reasoning block
+func stripReasoning(text string) string {
+	return strings.TrimSpace(inlineReasoningRegex.ReplaceAllString(text, ""))
+}
+
+// applyReasoningArgs sets $effort and $rtokens to the reasoning params of the chain.
+// They are mutually exclusive, max tokens has priority like in the config
+func applyReasoningArgs(reasoning *ai.ModelReasoningParams, effort string, maxTokens int) *ai.ModelReasoningParams {
+	if effort == "" && maxTokens <= 0 {
+		return reasoning
+	}
+	result := &ai.ModelReasoningParams{}
+	if reasoning != nil {
+		result.Enabled = reasoning.Enabled
+		result.Exclude = reasoning.Exclude
+	}
+	if maxTokens > 0 {
+		result.MaxTokens = &maxTokens
+	} else {
+		result.Effort = &effort
+	}
+	return result
+}
+
+// supportsReasoning reports whether reasoning params can be sent to the model
+func supportsReasoning(model *ai.ModelInfo) bool {
+	return model != nil && slices.Contains(model.SupportedParameters, "reasoning")
+}
@@ -0,0 +1,99 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyReasoningArgs(t *testing.T) {
+	exclude := true
+	previousTokens := 3000
+	previous := &ai.ModelReasoningParams{Exclude: &exclude, MaxTokens: &previousTokens}
+
+	t.Run("without arguments", func(t *testing.T) {
+		assert.Same(t, previous, applyReasoningArgs(previous, "", 0))
+		assert.Nil(t, applyReasoningArgs(nil, "", 0))
+	})
+
+	t.Run("effort replaces persisted max tokens", func(t *testing.T) {
+		reasoning := applyReasoningArgs(previous, EffortLow, 0)
+
+		require.NotNil(t, reasoning.Effort)
+		assert.Equal(t, EffortLow, *reasoning.Effort)
+		assert.Nil(t, reasoning.MaxTokens)
+		assert.Equal(t, &exclude, reasoning.Exclude)
+		assert.Equal(t, 3000, *previous.MaxTokens, "Previous params must not be modified")
+	})
+
+	t.Run("max tokens has priority", func(t *testing.T) {
+		reasoning := applyReasoningArgs(nil, EffortHigh, 1024)
+
+		require.NotNil(t, reasoning.MaxTokens)
+		assert.Equal(t, 1024, *reasoning.MaxTokens)
+		assert.Nil(t, reasoning.Effort)
+	})
+}
+
+func TestValidateArg_Effort(t *testing.T) {
+	arg := &Argument{Name: "effort", Type: "string", Values: effortValues}
+
+	for _, value := range effortValues {
+		assert.NoError(t, validateArg(arg, value), value)
+	}
+	assert.Error(t, validateArg(arg, "extreme"))
+}
+
+func TestSupportsReasoning(t *testing.T) {
+	assert.True(t, supportsReasoning(&ai.ModelInfo{SupportedParameters: []string{"tools", "reasoning"}}))
+	assert.False(t, supportsReasoning(&ai.ModelInfo{SupportedParameters: []string{"tools"}}))
+	assert.False(t, supportsReasoning(&ai.ModelInfo{}))
+}
+
+func TestStripReasoning(t *testing.T) {
+	assert.Equal(t, "Answer", stripReasoning("<think>\nlong thoughts\n</think>\n\nAnswer"))
+	assert.Equal(t, "Answer", stripReasoning("  <thinking>thoughts</thinking>Answer"))
+	assert.Equal(t, "Use <think> tags", stripReasoning("Use <think> tags"), "Tags inside the answer are kept")
+}
+
+func TestCommand_buildPromptWithHistory_PersistReasoning(t *testing.T) {
+	model := &ai.ModelInfo{ID: "main", Provider: "test"}
+	newContent := func() *MessageContent {
+		// history is ordered from the newest message
+		return &MessageContent{
+			Text: "and now?",
+			ConversationHistory: []conversationMessage{
+				{Role: ai.RoleAssistant, Text: "<think>very long reasoning</think>\n42"},
+				{Role: ai.RoleUser, Text: "question"},
+			},
+		}
+	}
+	historyTexts := func(messages []ai.Message) []string {
+		var texts []string
+		for _, message := range messages[1:] {
+			texts = append(texts, message.Text)
+		}
+		return texts
+	}
+
+	t.Run("persisted", func(t *testing.T) {
+		cmd := newToolsModelTestCommand(t, &CommandArgs{})
+		cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+		cmd.cmdCfg.Tools.Enabled = false
+
+		messages := cmd.buildPromptWithHistory(model, newContent(), cmd.args, true)
+		assert.Equal(t, []string{"question", "<think>very long reasoning</think>\n42"}, historyTexts(messages))
+	})
+
+	t.Run("stripped", func(t *testing.T) {
+		cmd := newToolsModelTestCommand(t, &CommandArgs{})
+		cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+		cmd.cmdCfg.Tools.Enabled = false
+		cmd.cmdCfg.Display.PersistReasoning = false
+
+		messages := cmd.buildPromptWithHistory(model, newContent(), cmd.args, true)
+		assert.Equal(t, []string{"question", "42"}, historyTexts(messages))
+	})
+}
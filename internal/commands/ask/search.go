@@ -0,0 +1,133 @@
+package ask
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/ai/tools"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+// search mechanisms used for $search, shown in the context of the answer
+const (
+	searchNone   = ""
+	searchPlugin = "plugin"
+	searchTool   = "tool"
+)
+
+// resolveSearch picks the mechanism for the mode: the web plugin of the provider
+// when it's supported, otherwise the search tool in auto mode
+func resolveSearch(mode string, pluginSupported, toolAvailable bool) string {
+	switch mode {
+	case config.SearchModePlugin:
+		if pluginSupported {
+			return searchPlugin
+		}
+	case config.SearchModeTool:
+		if toolAvailable {
+			return searchTool
+		}
+	default:
+		if pluginSupported {
+			return searchPlugin
+		}
+		if toolAvailable {
+			return searchTool
+		}
+	}
+	return searchNone
+}
+
+// supportsWebSearchPlugin reports whether the provider of the model has the web plugin,
+// only OpenRouter has it
+func (c *Command) supportsWebSearchPlugin(model *ai.ModelInfo) bool {
+	if model == nil {
+		return false
+	}
+	provider, err := c.ai.GetProvider(model.Provider)
+	if err != nil {
+		return false
+	}
+	_, isOpenrouter := provider.(*ai.OpenRouterClient)
+	return isOpenrouter
+}
+
+// searchToolAvailable reports whether the search tool can be called
+func searchToolAvailable(cmdCfg config.AskCommandConfig) bool {
+	if !cmdCfg.Tools.Enabled {
+		return false
+	}
+	_, ok := tools.AvailableTools(cmdCfg.Tools.Allowed, cmdCfg.Tools.Excluded)[tools.ToolSearch]
+	return ok
+}
+
+// applySearch resolves the mechanism of $search for the model, the search tool
+// is added to the requested tools when it's used
+func (c *Command) applySearch(model *ai.ModelInfo) string {
+	if !c.args.SearchWeb {
+		return searchNone
+	}
+	searchLog := c.Logger.WithFields(logger.Fields{
+		"model": model.FullName(),
+		"mode":  c.cmdCfg.Search.GetMode(),
+	})
+	if !c.cmdCfg.Search.Enabled {
+		searchLog.Info("Search is disabled, skip $search")
+		return searchNone
+	}
+	// tool calls don't fit the verbatim answer
+	toolAvailable := searchToolAvailable(*c.cmdCfg) && !c.args.Raw
+	search := resolveSearch(c.cmdCfg.Search.GetMode(), c.supportsWebSearchPlugin(model), toolAvailable)
+	switch search {
+	case searchNone:
+		searchLog.Warn("No search mechanism available for the model, skip $search")
+	case searchTool:
+		c.args.Tools = withSearchTool(c.args.Tools)
+		searchLog.Info("Search tool is used for $search")
+	default:
+		searchLog.Info("Web plugin is used for $search")
+	}
+	return search
+}
+
+// withSearchTool adds the search tool to the value of $tools, "all" already has it
+func withSearchTool(requested string) string {
+	if requested == "" || requested == "no" {
+		return tools.ToolSearch
+	}
+	if requested == "all" {
+		return requested
+	}
+	names := strings.Split(requested, ",")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+	}
+	if slices.Contains(names, tools.ToolSearch) {
+		return requested
+	}
+	return strings.Join(append(names, tools.ToolSearch), ",")
+}
+
+// warnSearchUnavailable logs at startup when $search can't work with the configuration
+func warnSearchUnavailable(registry *ai.ProviderRegistry, cmdCfg config.AskCommandConfig, l logger.Logger) {
+	if !cmdCfg.Search.Enabled {
+		return
+	}
+	pluginAvailable := false
+	for _, name := range registry.Providers() {
+		provider, err := registry.GetProvider(name)
+		if err != nil {
+			continue
+		}
+		if _, isOpenrouter := provider.(*ai.OpenRouterClient); isOpenrouter {
+			pluginAvailable = true
+			break
+		}
+	}
+	mode := cmdCfg.Search.GetMode()
+	if resolveSearch(mode, pluginAvailable, searchToolAvailable(cmdCfg)) == searchNone {
+		l.WithField("mode", mode).Warn("$search is enabled, but no search mechanism is available: add an OpenRouter provider or enable the search tool")
+	}
+}
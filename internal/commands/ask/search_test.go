@@ -0,0 +1,69 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSearch(t *testing.T) {
+	tests := []struct {
+		name            string
+		mode            string
+		pluginSupported bool
+		toolAvailable   bool
+		want            string
+	}{
+		{"auto prefers plugin", config.SearchModeAuto, true, true, searchPlugin},
+		{"auto falls back to tool", config.SearchModeAuto, false, true, searchTool},
+		{"auto without mechanisms", config.SearchModeAuto, false, false, searchNone},
+		{"plugin only", config.SearchModePlugin, true, true, searchPlugin},
+		{"plugin without support", config.SearchModePlugin, false, true, searchNone},
+		{"tool only", config.SearchModeTool, true, true, searchTool},
+		{"tool unavailable", config.SearchModeTool, true, false, searchNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resolveSearch(tt.mode, tt.pluginSupported, tt.toolAvailable))
+		})
+	}
+}
+
+func TestWithSearchTool(t *testing.T) {
+	assert.Equal(t, "search", withSearchTool(""))
+	assert.Equal(t, "search", withSearchTool("no"))
+	assert.Equal(t, "all", withSearchTool("all"))
+	assert.Equal(t, "fetch_url,search", withSearchTool("fetch_url"))
+	assert.Equal(t, "fetch_url, search", withSearchTool("fetch_url, search"))
+}
+
+func TestCommand_applySearch(t *testing.T) {
+	model := &ai.ModelInfo{ID: "main", Provider: "test"}
+	newCommand := func(t *testing.T, args *CommandArgs) *Command {
+		cmd := newToolsModelTestCommand(t, args)
+		cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+		return cmd
+	}
+
+	t.Run("search tool without web plugin", func(t *testing.T) {
+		cmd := newCommand(t, &CommandArgs{SearchWeb: true})
+
+		assert.Equal(t, searchTool, cmd.applySearch(model))
+		assert.Equal(t, "search", cmd.args.Tools)
+	})
+
+	t.Run("without $search", func(t *testing.T) {
+		cmd := newCommand(t, &CommandArgs{})
+
+		assert.Equal(t, searchNone, cmd.applySearch(model))
+		assert.Empty(t, cmd.args.Tools)
+	})
+
+	t.Run("raw answer has no search tool", func(t *testing.T) {
+		cmd := newCommand(t, &CommandArgs{SearchWeb: true, Raw: true})
+
+		assert.Equal(t, searchNone, cmd.applySearch(model))
+	})
+}
@@ -0,0 +1,25 @@
+package ask
+
+import (
+	"slices"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+)
+
+// supportsSeed reports whether the model advertises the seed parameter
+func supportsSeed(model *ai.ModelInfo) bool {
+	return model != nil && slices.Contains(model.SupportedParameters, "seed")
+}
+
+// applySeed sets the seed from $seed argument, the seed of the chain is kept otherwise.
+// The seed isn't sent to models without seed support, returns false then
+func applySeed(params *ai.ModelParams, seed *int, model *ai.ModelInfo) bool {
+	if seed != nil {
+		params.Seed = seed
+	}
+	if params.Seed != nil && !supportsSeed(model) {
+		params.Seed = nil
+		return false
+	}
+	return true
+}
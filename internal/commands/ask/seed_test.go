@@ -0,0 +1,30 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySeed(t *testing.T) {
+	withSeed := &ai.ModelInfo{SupportedParameters: []string{"tools", "seed"}}
+	withoutSeed := &ai.ModelInfo{SupportedParameters: []string{"tools"}}
+
+	zero, chainSeed, newSeed := 0, 42, 7
+
+	params := &ai.ModelParams{}
+	assert.True(t, applySeed(params, &zero, withSeed))
+	require.NotNil(t, params.Seed)
+	assert.Equal(t, 0, *params.Seed)
+
+	chainParams := &ai.ModelParams{Seed: &chainSeed}
+	assert.True(t, applySeed(chainParams, nil, withSeed))
+	assert.Equal(t, 42, *chainParams.Seed, "Seed of the chain is kept")
+
+	assert.False(t, applySeed(chainParams, &newSeed, withoutSeed))
+	assert.Nil(t, chainParams.Seed)
+
+	assert.True(t, applySeed(&ai.ModelParams{}, nil, withoutSeed), "Nothing to skip without seed")
+}
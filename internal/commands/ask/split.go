@@ -0,0 +1,128 @@
+package ask
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// reserved for closing and reopening a code block cut between chunks
+const codeFenceReserve = 32
+
+var sentenceEndRegex = regexp.MustCompile(`[.!?…](?:\s+)`)
+
+// splitMarkdown splits markdown text into chunks of at most limit runes,
+// preferring paragraph, then line, then sentence boundaries. A code block
+// cut between chunks is closed and reopened in the next chunk
+func splitMarkdown(text string, limit int) []string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	chunks := packText(text, max(limit-codeFenceReserve, 1), 0)
+	return fixCodeFences(chunks)
+}
+
+// packText greedily joins units of the text into chunks, units that
+// don't fit are split at the next level: paragraphs, lines, sentences, runes
+func packText(text string, limit int, level int) []string {
+	var units []string
+	var sep string
+	switch level {
+	case 0:
+		units, sep = strings.Split(text, "\n\n"), "\n\n"
+	case 1:
+		units, sep = strings.Split(text, "\n"), "\n"
+	case 2:
+		units, sep = splitSentences(text), ""
+	default:
+		return splitRunes(text, limit)
+	}
+
+	var chunks []string
+	var current strings.Builder
+	currentLength := 0
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentLength = 0
+		}
+	}
+	for _, unit := range units {
+		unitLength := utf8.RuneCountInString(unit)
+		if currentLength > 0 && currentLength+utf8.RuneCountInString(sep)+unitLength <= limit {
+			current.WriteString(sep + unit)
+			currentLength += utf8.RuneCountInString(sep) + unitLength
+			continue
+		}
+		flush()
+		if unitLength <= limit {
+			current.WriteString(unit)
+			currentLength = unitLength
+			continue
+		}
+		chunks = append(chunks, packText(unit, limit, level+1)...)
+	}
+	flush()
+
+	return chunks
+}
+
+// splitSentences splits text after sentence endings keeping the whitespace
+// with the preceding sentence, so joining the result gives the original text
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for _, loc := range sentenceEndRegex.FindAllStringIndex(text, -1) {
+		sentences = append(sentences, text[start:loc[1]])
+		start = loc[1]
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
+
+func splitRunes(text string, limit int) []string {
+	runes := []rune(text)
+	chunks := make([]string, 0, len(runes)/limit+1)
+	for len(runes) > limit {
+		chunks = append(chunks, string(runes[:limit]))
+		runes = runes[limit:]
+	}
+	return append(chunks, string(runes))
+}
+
+// fixCodeFences closes code blocks at the end of a chunk and
+// reopens them with the same info string in the next one
+func fixCodeFences(chunks []string) []string {
+	result := make([]string, 0, len(chunks))
+	openFence := ""
+	for _, chunk := range chunks {
+		chunk = strings.TrimSpace(chunk)
+		if chunk == "" {
+			continue
+		}
+		if openFence != "" {
+			chunk = openFence + "\n" + chunk
+		}
+		for line := range strings.SplitSeq(chunk, "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "```") {
+				continue
+			}
+			if openFence == "" {
+				openFence = line
+			} else if line == "```" {
+				openFence = ""
+			}
+		}
+		if openFence != "" {
+			chunk += "\n```"
+		}
+		result = append(result, chunk)
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package ask
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/service"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitMarkdown(t *testing.T) {
+	t.Run("short text", func(t *testing.T) {
+		assert.Equal(t, []string{"hello"}, splitMarkdown("  hello\n", 100))
+	})
+
+	t.Run("paragraph boundaries", func(t *testing.T) {
+		paragraph := strings.Repeat("word ", 20) + "end."
+		text := strings.Join([]string{paragraph, paragraph, paragraph}, "\n\n")
+
+		chunks := splitMarkdown(text, 250)
+		require.Len(t, chunks, 2)
+		assert.Equal(t, paragraph+"\n\n"+paragraph, chunks[0])
+		assert.Equal(t, paragraph, chunks[1])
+	})
+
+	t.Run("sentence boundaries", func(t *testing.T) {
+		sentence := strings.Repeat("a", 30) + ". "
+		chunks := splitMarkdown(strings.Repeat(sentence, 10), 100)
+
+		require.Greater(t, len(chunks), 1)
+		for _, chunk := range chunks {
+			assert.True(t, strings.HasSuffix(chunk, "."), chunk)
+			assert.LessOrEqual(t, utf8.RuneCountInString(chunk), 100)
+		}
+	})
+
+	t.Run("hard cut", func(t *testing.T) {
+		chunks := splitMarkdown(strings.Repeat("я", 250), 100)
+
+		require.Len(t, chunks, 4)
+		assert.Equal(t, strings.Repeat("я", 250), strings.Join(chunks, ""))
+	})
+
+	t.Run("code block is reopened", func(t *testing.T) {
+		lines := make([]string, 30)
+		for i := range lines {
+			lines[i] = "fmt.Println(\"line\")"
+		}
+		text := "Example:\n\n```go\n" + strings.Join(lines, "\n") + "\n```\n\nDone."
+
+		chunks := splitMarkdown(text, 300)
+		require.Greater(t, len(chunks), 3)
+		assert.Equal(t, "Example:", chunks[0])
+		assert.Equal(t, "Done.", chunks[len(chunks)-1])
+		for _, chunk := range chunks[1 : len(chunks)-1] {
+			assert.True(t, strings.HasPrefix(chunk, "```go\n"), "Code block must be reopened: %s", chunk)
+			assert.True(t, strings.HasSuffix(chunk, "\n```"), "Code block must be closed: %s", chunk)
+			assert.LessOrEqual(t, utf8.RuneCountInString(chunk), 300)
+		}
+	})
+}
+
+func TestMessageBuilder_BuildParts(t *testing.T) {
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+
+	tg := telegram.NewMockClient(t)
+	tg.EXPECT().TelegramifyMarkdown(mock.Anything).RunAndReturn(func(text string) (string, error) {
+		return text, nil
+	}).Maybe()
+
+	paragraph := strings.Repeat("long answer ", 100)
+	content := strings.TrimSpace(strings.Repeat(paragraph+"\n\n", 10))
+	newBuilder := func(split bool) *MessageBuilder {
+		response := NewResponse()
+		response.Prompt = "question"
+		response.Content = content
+		return NewMessageBuilder(tg, localizer).
+			SetResponse(response).
+			WithContext(false).
+			WithMetadata(config.MetadataModeOff).
+			WithSplit(split)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		builder := newBuilder(false)
+		parts := builder.BuildParts()
+
+		require.Len(t, parts, 1)
+		assert.Equal(t, builder.Build(), parts[0], "Long answer is truncated")
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		parts := newBuilder(true).BuildParts()
+
+		require.Greater(t, len(parts), 1)
+		for i, part := range parts {
+			assert.LessOrEqual(t, utf8.RuneCountInString(part), telegramMaxLength)
+			assert.Equal(t, i == len(parts)-1, strings.Contains(part, BotMessageMarker), "Only the last part has the marker")
+			assert.Equal(t, i == 0, strings.Contains(part, "question"), "Only the first part has the prompt")
+		}
+		assert.Equal(t, strings.Count(content, "long answer"), strings.Count(strings.Join(parts, ""), "long answer"), "Content must not be lost")
+	})
+
+	t.Run("short answer", func(t *testing.T) {
+		builder := newBuilder(true)
+		builder.response.Content = "short"
+
+		parts := builder.BuildParts()
+		require.Len(t, parts, 1)
+		assert.Equal(t, builder.Build(), parts[0])
+	})
+}
+
+func TestMessageBuilder_WithRaw(t *testing.T) {
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+
+	tg := telegram.NewMockClient(t)
+	tg.EXPECT().EscapeText("**bold**").Return("\\*\\*bold\\*\\*")
+
+	response := NewResponse()
+	response.Content = "**bold**"
+	text := NewMessageBuilder(tg, localizer).
+		SetResponse(response).
+		WithContext(false).
+		WithMetadata(config.MetadataModeOff).
+		WithRaw(true).
+		Build()
+
+	assert.Equal(t, "\\*\\*bold\\*\\*"+BotMessageMarker, text)
+}
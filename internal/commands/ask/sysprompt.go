@@ -0,0 +1,108 @@
+package ask
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const (
+	systemPromptSubcommand = "prompt"
+	// the override is temporary, for debugging the prompt behavior
+	systemPromptOverrideTTL = 24 * time.Hour
+	// longer system instructions are cut in /info prompt
+	systemPromptDisplayLength = 3500
+)
+
+// /info prompt [action] [value], the value keeps its line breaks
+var systemPromptCommandRegex = regexp.MustCompile(`^\S+\s+` + systemPromptSubcommand + `(?:\s+(\S+))?(?:\s+([\s\S]*))?$`)
+
+func systemPromptOverrideKey(chatID int64) string {
+	return fmt.Sprintf("ask:sysprompt:%d", chatID)
+}
+
+// parseSystemPromptCommand returns the action and its argument of "/info prompt [set <text>|reset]",
+// ok is false for other /info calls
+func parseSystemPromptCommand(text string) (action, value string, ok bool) {
+	match := systemPromptCommandRegex.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], strings.TrimSpace(match[2]), true
+}
+
+// systemPromptOverride returns the system prompt set for the chat by /info prompt set
+func (c *Command) systemPromptOverride(chatID int64) string {
+	if data, found := c.cache.Get(systemPromptOverrideKey(chatID)); found {
+		return string(data)
+	}
+	return ""
+}
+
+// handleSystemPromptCommand shows the resolved system instructions of the chat,
+// sets or resets the temporary chat override of the system prompt
+func (c *Command) handleSystemPromptCommand(msg *telegram.MessageOriginal, action, value string) error {
+	chatID := msg.Chat.ID
+	reply := func(text string) error {
+		_, err := c.Tg.Send(telegram.NewMessage(chatID, text, msg.MessageID))
+		return err
+	}
+	if msg.From == nil || !c.Cfg.Telegram().IsUserAllowed(msg.From.ID) {
+		return reply(c.L("ask.sysprompt.notAllowed", nil))
+	}
+
+	promptLog := c.Logger.WithFields(logger.Fields{
+		"chat_id": chatID,
+		"user_id": msg.From.ID,
+	})
+	key := systemPromptOverrideKey(chatID)
+	switch action {
+	case "":
+		return reply(c.renderSystemPrompt(chatID))
+	case "set":
+		if value == "" {
+			return reply(c.L("ask.sysprompt.usage", nil))
+		}
+		if err := c.cache.Set(key, []byte(value), systemPromptOverrideTTL); err != nil {
+			promptLog.WithError(err).Error("Failed to save system prompt override")
+			return err
+		}
+		promptLog.Info("System prompt overridden for the chat")
+		return reply(c.L("ask.sysprompt.set", map[string]any{"Hours": int(systemPromptOverrideTTL.Hours())}))
+	case "reset":
+		if err := c.cache.Delete(key); err != nil {
+			promptLog.WithError(err).Error("Failed to delete system prompt override")
+			return err
+		}
+		promptLog.Info("System prompt override reset for the chat")
+		return reply(c.L("ask.sysprompt.reset", nil))
+	default:
+		return reply(c.L("ask.sysprompt.usage", nil))
+	}
+}
+
+// renderSystemPrompt returns the system instructions sent with a plain message in the chat
+func (c *Command) renderSystemPrompt(chatID int64) string {
+	args, _ := c.mapArgsToStruct(map[string]string{})
+	override := c.systemPromptOverride(chatID)
+	instructions := c.systemInstructions(&MessageContent{SystemPromptOverride: override}, args, time.Now())
+
+	header := c.L("ask.sysprompt.header", nil)
+	if override != "" {
+		header = c.L("ask.sysprompt.headerOverridden", nil)
+	}
+	return header + "\n\n" + truncateSystemPrompt(instructions, systemPromptDisplayLength)
+}
+
+func truncateSystemPrompt(text string, limit int) string {
+	length := utf8.RuneCountInString(text)
+	if length <= limit {
+		return text
+	}
+	return string([]rune(text)[:limit]) + fmt.Sprintf("\n… (%d/%d)", limit, length)
+}
@@ -0,0 +1,61 @@
+package ask
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSystemPromptCommand(t *testing.T) {
+	tests := []struct {
+		text   string
+		action string
+		value  string
+		ok     bool
+	}{
+		{"/info", "", "", false},
+		{"/info $c:5", "", "", false},
+		{"/info prompt", "", "", true},
+		{"/info@gachi_bot prompt reset", "reset", "", true},
+		{"/info prompt set You are a pirate.\nAnswer briefly.", "set", "You are a pirate.\nAnswer briefly.", true},
+		{"/info prompts", "", "", false},
+	}
+	for _, tt := range tests {
+		action, value, ok := parseSystemPromptCommand(tt.text)
+		assert.Equal(t, tt.ok, ok, tt.text)
+		assert.Equal(t, tt.action, action, tt.text)
+		assert.Equal(t, tt.value, value, tt.text)
+	}
+}
+
+func TestTruncateSystemPrompt(t *testing.T) {
+	assert.Equal(t, "short", truncateSystemPrompt("short", 10))
+	assert.Equal(t, "Привет\n… (6/10)", truncateSystemPrompt("Приветмир!", 6))
+}
+
+func TestCommand_systemInstructions_Override(t *testing.T) {
+	cmd := newToolsModelTestCommand(t, &CommandArgs{})
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+	cmd.cmdCfg.Tools.Enabled = false
+	cmd.cache = cache.NewMemoryCache()
+
+	require.Empty(t, cmd.systemPromptOverride(100))
+	require.NoError(t, cmd.cache.Set(systemPromptOverrideKey(100), []byte("You are a pirate. Answer in {{language}}."), systemPromptOverrideTTL))
+	override := cmd.systemPromptOverride(100)
+	assert.Empty(t, cmd.systemPromptOverride(200), "Override is per chat")
+
+	system := cmd.systemInstructions(&MessageContent{SystemPromptOverride: override}, cmd.args, time.Now())
+	assert.True(t, strings.HasPrefix(system, "You are a pirate. Answer in English."))
+	assert.NotContains(t, system, "Gachigazer")
+	assert.Contains(t, system, "Technical notes", "Technical notes are kept")
+
+	system = cmd.systemInstructions(&MessageContent{
+		SystemPromptOverride: override,
+		Prompt:               prompt{Name: "code", System: "You are a senior engineer."},
+	}, cmd.args, time.Now())
+	assert.True(t, strings.HasPrefix(system, "You are a senior engineer."), "Prompt persona has priority")
+}
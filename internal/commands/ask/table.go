@@ -0,0 +1,160 @@
+package ask
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type tableAlign int
+
+const (
+	alignLeft tableAlign = iota
+	alignCenter
+	alignRight
+)
+
+var (
+	tableDelimiterCellRegex = regexp.MustCompile(`^:?-+:?$`)
+	// inline markup that makes no sense inside a code block
+	tableCellMarkupReplacer = strings.NewReplacer("**", "", "__", "", "`", "", `\|`, "|")
+)
+
+// renderTables converts GFM tables into code blocks with columns padded to equal width,
+// telegram can't render tables and shows them as a mess of pipes and dashes.
+// Tables inside code blocks are left as is
+func renderTables(text string) string {
+	if !strings.Contains(text, "|") {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	result := make([]string, 0, len(lines))
+	inCode := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		if inCode || i+1 >= len(lines) || !strings.Contains(line, "|") {
+			result = append(result, line)
+			continue
+		}
+
+		header := splitTableRow(line)
+		aligns, ok := parseTableDelimiter(lines[i+1])
+		if !ok || len(aligns) != len(header) {
+			result = append(result, line)
+			continue
+		}
+
+		rows := [][]string{header}
+		end := i + 2
+		for ; end < len(lines); end++ {
+			row := strings.TrimSpace(lines[end])
+			if row == "" || !strings.Contains(row, "|") || strings.HasPrefix(row, "```") {
+				break
+			}
+			rows = append(rows, splitTableRow(row))
+		}
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		result = append(result, indent+"```")
+		for _, row := range formatTable(rows, aligns) {
+			result = append(result, indent+row)
+		}
+		result = append(result, indent+"```")
+		i = end - 1
+	}
+	return strings.Join(result, "\n")
+}
+
+// splitTableRow returns trimmed cells of the row, escaped pipes don't split cells
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteString(`\|`)
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// parseTableDelimiter parses the row under the header like |:---|:-:|--:|
+func parseTableDelimiter(line string) ([]tableAlign, bool) {
+	if !strings.Contains(line, "-") {
+		return nil, false
+	}
+	cells := splitTableRow(line)
+	aligns := make([]tableAlign, len(cells))
+	for i, cell := range cells {
+		cell = strings.ReplaceAll(cell, " ", "")
+		if !tableDelimiterCellRegex.MatchString(cell) {
+			return nil, false
+		}
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			aligns[i] = alignCenter
+		case right:
+			aligns[i] = alignRight
+		}
+	}
+	return aligns, true
+}
+
+// formatTable pads the cells, missing cells are empty and extra ones are dropped like in GFM
+func formatTable(rows [][]string, aligns []tableAlign) []string {
+	widths := make([]int, len(aligns))
+	for i, row := range rows {
+		row = append(row, make([]string, max(len(aligns)-len(row), 0))...)[:len(aligns)]
+		for j, cell := range row {
+			row[j] = tableCellMarkupReplacer.Replace(cell)
+			widths[j] = max(widths[j], utf8.RuneCountInString(row[j]))
+		}
+		rows[i] = row
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = padCell(cell, widths[j], aligns[j])
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, " | "), " "))
+		if i == 0 {
+			dashes := make([]string, len(widths))
+			for j, width := range widths {
+				dashes[j] = strings.Repeat("-", max(width, 1))
+			}
+			lines = append(lines, strings.Join(dashes, "-+-"))
+		}
+	}
+	return lines
+}
+
+func padCell(cell string, width int, align tableAlign) string {
+	padding := width - utf8.RuneCountInString(cell)
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", padding) + cell
+	case alignCenter:
+		return strings.Repeat(" ", padding/2) + cell + strings.Repeat(" ", padding-padding/2)
+	default:
+		return cell + strings.Repeat(" ", padding)
+	}
+}
@@ -0,0 +1,71 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTables(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "simple",
+			text: "Prices:\n\n| Name | Price |\n|------|-------|\n| Apple | 1 |\n| Watermelon | 10 |\n\nThat's all",
+			want: "Prices:\n\n```\nName       | Price\n-----------+------\nApple      | 1\nWatermelon | 10\n```\n\nThat's all",
+		},
+		{
+			name: "without outer pipes",
+			text: "a | b\n--- | ---\n1 | 2",
+			want: "```\na | b\n--+--\n1 | 2\n```",
+		},
+		{
+			name: "alignment",
+			text: "| Left | Center | Right |\n|:-----|:------:|------:|\n| a | b | c |",
+			want: "```\nLeft | Center | Right\n-----+--------+------\na    |   b    |     c\n```",
+		},
+		{
+			name: "missing and extra cells",
+			text: "| A | B | C |\n|---|---|---|\n| 1 |\n| 1 | 2 | 3 | 4 |",
+			want: "```\nA | B | C\n--+---+--\n1 |   |\n1 | 2 | 3\n```",
+		},
+		{
+			name: "empty cells",
+			text: "| Key | Value |\n|---|---|\n| | empty key |\n| x | |",
+			want: "```\nKey | Value\n----+----------\n    | empty key\nx   |\n```",
+		},
+		{
+			name: "markup and escaped pipe",
+			text: "| **Name** | `Code` |\n|---|---|\n| Бабушка | a \\| b |",
+			want: "```\nName    | Code\n--------+------\nБабушка | a | b\n```",
+		},
+		{
+			name: "table in code block is kept",
+			text: "```\n| a | b |\n|---|---|\n```",
+			want: "```\n| a | b |\n|---|---|\n```",
+		},
+		{
+			name: "no delimiter row",
+			text: "a | b\nc | d",
+			want: "a | b\nc | d",
+		},
+		{
+			name: "different number of columns in delimiter",
+			text: "| a | b |\n|---|\n| 1 | 2 |",
+			want: "| a | b |\n|---|\n| 1 | 2 |",
+		},
+		{
+			name: "no tables",
+			text: "Just text\n---",
+			want: "Just text\n---",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, renderTables(tt.text))
+		})
+	}
+}
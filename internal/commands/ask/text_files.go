@@ -0,0 +1,119 @@
+package ask
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// textFile is a document read as text and added to the message as a [FILE: name] block,
+// unlike PDFs it's understood by any model
+type textFile struct {
+	Name    string
+	Content string
+}
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// isTextDocument checks the extension of the document against files.text_extensions
+func isTextDocument(fileName string, extensions []string) bool {
+	extension := strings.ToLower(filepath.Ext(fileName))
+	return extension != "" && slices.ContainsFunc(extensions, func(allowed string) bool {
+		return strings.EqualFold(extension, allowed)
+	})
+}
+
+// decodeText converts the file content into UTF-8. UTF-16 files with BOM are decoded,
+// invalid UTF-8 looking like Cyrillic text is read as Windows-1251, other invalid bytes are dropped
+func decodeText(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		data = data[len(utf8BOM):]
+	case bytes.HasPrefix(data, utf16LEBOM):
+		return decodeUTF16(data[len(utf16LEBOM):], false)
+	case bytes.HasPrefix(data, utf16BEBOM):
+		return decodeUTF16(data[len(utf16BEBOM):], true)
+	}
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	if looksLikeWindows1251(data) {
+		if decoded, err := charmap.Windows1251.NewDecoder().Bytes(data); err == nil {
+			return string(decoded)
+		}
+	}
+	return strings.ToValidUTF8(string(data), "")
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// looksLikeWindows1251 reports whether most non-ASCII bytes are Cyrillic letters of Windows-1251
+func looksLikeWindows1251(data []byte) bool {
+	var high, cyrillic int
+	for _, b := range data {
+		if b < 0x80 {
+			continue
+		}
+		high++
+		if b >= 0xc0 || b == 0xa8 || b == 0xb8 {
+			cyrillic++
+		}
+	}
+	return high > 0 && cyrillic*10 >= high*7
+}
+
+// readTextFile decodes the file and cuts the content longer than maxLength characters
+func readTextFile(name string, data []byte, maxLength int) textFile {
+	content := strings.TrimSpace(decodeText(data))
+	if length := utf8.RuneCountInString(content); maxLength > 0 && length > maxLength {
+		content = string([]rune(content)[:maxLength]) + fmt.Sprintf("\n… [truncated, %d of %d characters]", maxLength, length)
+	}
+	return textFile{Name: name, Content: content}
+}
+
+// extractTextFile reads the text document of the message, files over
+// files.max_text_size are skipped
+func (c *Command) extractTextFile(msg *telegram.MessageOriginal) (textFile, bool) {
+	filesCfg := c.cmdCfg.Files
+	if !filesCfg.Enabled || msg == nil || msg.Document == nil || !isTextDocument(msg.Document.FileName, filesCfg.TextExtensions) {
+		return textFile{}, false
+	}
+	document := msg.Document
+	fileLog := c.Logger.WithField("file_name", document.FileName)
+	if maxSize := int64(filesCfg.MaxTextSize) * 1000; maxSize > 0 && document.FileSize > maxSize {
+		fileLog.WithField("size", document.FileSize).Warn("Text file is bigger than max size, skip it")
+		return textFile{}, false
+	}
+	fileURL, err := c.Tg.GetFileURL(document.FileID)
+	if err != nil {
+		fileLog.WithError(err).Error("Fail get file url")
+		return textFile{}, false
+	}
+	data, err := downloadFile(fileURL)
+	if err != nil {
+		fileLog.WithError(err).Error("Failed to download text file")
+		return textFile{}, false
+	}
+	return readTextFile(document.FileName, data, filesCfg.MaxTextLength), true
+}
@@ -0,0 +1,81 @@
+package ask
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestIsTextDocument(t *testing.T) {
+	extensions := []string{".txt", ".md", ".csv"}
+	assert.True(t, isTextDocument("notes.md", extensions))
+	assert.True(t, isTextDocument("DATA.CSV", extensions))
+	assert.False(t, isTextDocument("report.pdf", extensions))
+	assert.False(t, isTextDocument("Makefile", extensions))
+}
+
+func TestDecodeText(t *testing.T) {
+	t.Run("utf8 with bom", func(t *testing.T) {
+		assert.Equal(t, "name,price", decodeText(append([]byte{0xef, 0xbb, 0xbf}, "name,price"...)))
+	})
+
+	t.Run("utf16 little endian", func(t *testing.T) {
+		data := []byte{0xff, 0xfe, 'h', 0, 'i', 0, 0x3f, 0x04}
+		assert.Equal(t, "hiп", decodeText(data))
+	})
+
+	t.Run("windows-1251", func(t *testing.T) {
+		data, err := charmap.Windows1251.NewEncoder().Bytes([]byte("Привет, мир"))
+		require.NoError(t, err)
+		assert.Equal(t, "Привет, мир", decodeText(data))
+	})
+
+	t.Run("invalid bytes are dropped", func(t *testing.T) {
+		assert.Equal(t, "ab", decodeText([]byte{'a', 0x80, 0x81, 'b'}))
+	})
+}
+
+func TestReadTextFile(t *testing.T) {
+	file := readTextFile("notes.txt", []byte("  "+strings.Repeat("a", 20)+"\n"), 10)
+	assert.Equal(t, "notes.txt", file.Name)
+	assert.Equal(t, strings.Repeat("a", 10)+"\n… [truncated, 10 of 20 characters]", file.Content)
+}
+
+func TestCommand_extractTextFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "gachi"}`))
+	}))
+	defer server.Close()
+
+	tg := telegram.NewMockClient(t)
+	tg.EXPECT().GetFileURL("file-1").Return(server.URL, nil)
+	cmd := newToolsModelTestCommand(t, &CommandArgs{})
+	cmd.Tg = tg
+	cmd.cmdCfg = cmd.Cfg.GetAskCommandConfig()
+
+	msg := &telegram.MessageOriginal{Document: &telegram.Document{FileID: "file-1", FileName: "data.json", FileSize: 17}}
+	file, ok := cmd.extractTextFile(msg)
+	require.True(t, ok)
+	assert.Equal(t, textFile{Name: "data.json", Content: `{"name": "gachi"}`}, file)
+
+	content := &MessageContent{Text: "what is the name?", TextFiles: []textFile{file}}
+	assert.Contains(t, content.GetMessageContent(), "what is the name?\n\n[FILE: data.json]\n{\"name\": \"gachi\"}")
+
+	t.Run("too big file is skipped", func(t *testing.T) {
+		msg := &telegram.MessageOriginal{Document: &telegram.Document{FileID: "file-2", FileName: "big.txt", FileSize: 10_000_000}}
+		_, ok := cmd.extractTextFile(msg)
+		assert.False(t, ok)
+	})
+
+	t.Run("pdf is not a text file", func(t *testing.T) {
+		msg := &telegram.MessageOriginal{Document: &telegram.Document{FileID: "file-3", FileName: "doc.pdf"}}
+		_, ok := cmd.extractTextFile(msg)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,51 @@
+package ask
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+// tool call suggested in the answer text, e.g. **weather@1** {"location": "London"}
+var toolCallRegex = regexp.MustCompile(`\**(\w+)(\@\d)*\**\s*(\{[^}]*\})`)
+
+// toolButtons creates buttons to run the tools called in the answer text, two per row, and
+// the "Run all tools" button. With more than maxButtons calls only "Run all tools" is left,
+// a large keyboard is rejected by telegram. Returns the number of found calls
+func toolButtons(text string, toolNames []string, botMessageID, maxButtons int) ([][]telegram.InlineKeyboardButton, int) {
+	buttonRows := [][]telegram.InlineKeyboardButton{}
+	toolCallNumber := 0
+	for _, match := range toolCallRegex.FindAllStringSubmatch(text, -1) {
+		functionName := match[1]
+		if !slices.Contains(toolNames, functionName) {
+			continue
+		}
+		toolCallNumber++
+		functionCallName := functionName + match[2]
+		button := telegram.NewInlineKeyboardButtonData(
+			fmt.Sprintf(ai.Tools+" Run %s", functionCallName),
+			fmt.Sprintf("ask %s $tools $id:%d", functionCallName, botMessageID),
+		)
+		if len(buttonRows) == 0 || len(buttonRows[len(buttonRows)-1]) == 2 {
+			buttonRows = append(buttonRows, []telegram.InlineKeyboardButton{})
+		}
+		buttonRows[len(buttonRows)-1] = append(buttonRows[len(buttonRows)-1], button)
+	}
+	if toolCallNumber == 0 {
+		return buttonRows, 0
+	}
+
+	if toolCallNumber == 1 || toolCallNumber > maxButtons {
+		buttonRows = [][]telegram.InlineKeyboardButton{}
+	}
+	buttonRows = append(buttonRows, []telegram.InlineKeyboardButton{
+		telegram.NewInlineKeyboardButtonData(
+			ai.Tools+" Run all tools",
+			fmt.Sprintf("ask all $tools $id:%d", botMessageID),
+		),
+	})
+	return buttonRows, toolCallNumber
+}
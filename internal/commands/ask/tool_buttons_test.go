@@ -0,0 +1,61 @@
+package ask
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolButtons(t *testing.T) {
+	toolNames := []string{"weather", "search"}
+
+	t.Run("button for each tool, two per row", func(t *testing.T) {
+		text := `**weather@1** {"location": "London"} **search@2** {"query": "news"} **weather@3** {"location": "Paris"} **unknown** {}`
+
+		rows, count := toolButtons(text, toolNames, 42, 8)
+		assert.Equal(t, 3, count)
+		require.Len(t, rows, 3)
+		assert.Len(t, rows[0], 2)
+		assert.Len(t, rows[1], 1)
+		assert.Equal(t, "ask weather@1 $tools $id:42", *rows[0][0].CallbackData)
+		assert.Equal(t, "ask search@2 $tools $id:42", *rows[0][1].CallbackData)
+		assert.Equal(t, "ask all $tools $id:42", *rows[2][0].CallbackData)
+	})
+
+	t.Run("single tool", func(t *testing.T) {
+		rows, count := toolButtons(`**weather** {"location": "London"}`, toolNames, 42, 8)
+		assert.Equal(t, 1, count)
+		require.Len(t, rows, 1)
+		assert.Equal(t, "ask all $tools $id:42", *rows[0][0].CallbackData)
+	})
+
+	t.Run("too many tools collapse", func(t *testing.T) {
+		var text strings.Builder
+		for i := range 20 {
+			fmt.Fprintf(&text, "**search@%d** {\"query\": \"q%d\"}\n", i%10, i)
+		}
+
+		rows, count := toolButtons(text.String(), toolNames, 42, 8)
+		assert.Equal(t, 20, count)
+		require.Len(t, rows, 1)
+		require.Len(t, rows[0], 1)
+		assert.Equal(t, "ask all $tools $id:42", *rows[0][0].CallbackData)
+
+		rows, _ = toolButtons(text.String(), toolNames, 42, 20)
+		buttons := 0
+		for _, row := range rows {
+			assert.LessOrEqual(t, len(row), 2)
+			buttons += len(row)
+		}
+		assert.Equal(t, 21, buttons, "Limit is inclusive")
+	})
+
+	t.Run("no tools", func(t *testing.T) {
+		rows, count := toolButtons("just an answer {not a tool}", toolNames, 42, 8)
+		assert.Zero(t, count)
+		assert.Empty(t, rows)
+	})
+}
@@ -0,0 +1,55 @@
+package ask
+
+import (
+	"fmt"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/muratoffalex/gachigazer/internal/ai/tools"
+	"github.com/muratoffalex/gachigazer/internal/config"
+)
+
+// fetched pages and search results are the least relevant in the middle,
+// the beginning holds the main content and the end often the conclusions
+var middleTruncatedTools = []string{
+	tools.ToolFetchURL,
+	tools.ToolSearch,
+	tools.ToolSearchImages,
+	tools.ToolFetchRSS,
+	tools.ToolFetchTgPosts,
+	tools.ToolFetchTgPostComments,
+	tools.ToolFetchYtComments,
+}
+
+// toolTruncateStrategy returns tools.truncate or the default strategy of the tool
+func (c *Command) toolTruncateStrategy(toolName string) string {
+	if strategy := c.cmdCfg.Tools.Truncate; strategy != "" {
+		return strategy
+	}
+	if slices.Contains(middleTruncatedTools, toolName) {
+		return config.ToolTruncateMiddle
+	}
+	return config.ToolTruncateHead
+}
+
+// truncateToolResponse cuts the response to the limit in characters keeping the part of the
+// strategy, the note about the cut tells the model the content is incomplete. 0 - unlimited
+func truncateToolResponse(text string, limit int, strategy string) string {
+	length := utf8.RuneCountInString(text)
+	if limit <= 0 || length <= limit {
+		return text
+	}
+	runes := []rune(text)
+	cut := length - limit
+	switch strategy {
+	case config.ToolTruncateTail:
+		return fmt.Sprintf("[Response truncated: the first %d of %d characters are cut]\n", cut, length) + string(runes[cut:])
+	case config.ToolTruncateMiddle:
+		head := limit / 2
+		return string(runes[:head]) +
+			fmt.Sprintf("\n\n[Response truncated: %d of %d characters are cut here]\n\n", cut, length) +
+			string(runes[length-(limit-head):])
+	default:
+		return string(runes[:limit]) + fmt.Sprintf("\n[Response truncated: the last %d of %d characters are cut]", cut, length)
+	}
+}
@@ -0,0 +1,43 @@
+package ask
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai/tools"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateToolResponse(t *testing.T) {
+	text := "abcdefghij"
+
+	assert.Equal(t, text, truncateToolResponse(text, 0, config.ToolTruncateHead), "0 is unlimited")
+	assert.Equal(t, text, truncateToolResponse(text, 10, config.ToolTruncateHead))
+
+	assert.Equal(t,
+		"abcd\n[Response truncated: the last 6 of 10 characters are cut]",
+		truncateToolResponse(text, 4, config.ToolTruncateHead),
+	)
+	assert.Equal(t,
+		"[Response truncated: the first 6 of 10 characters are cut]\nghij",
+		truncateToolResponse(text, 4, config.ToolTruncateTail),
+	)
+	assert.Equal(t,
+		"ab\n\n[Response truncated: 5 of 10 characters are cut here]\n\nhij",
+		truncateToolResponse(text, 5, config.ToolTruncateMiddle),
+	)
+
+	truncated := truncateToolResponse(strings.Repeat("я", 10), 4, config.ToolTruncateHead)
+	assert.True(t, strings.HasPrefix(truncated, "яяяя\n"), "Characters are counted, not bytes")
+}
+
+func TestCommand_toolTruncateStrategy(t *testing.T) {
+	cmd := &Command{cmdCfg: &config.AskCommandConfig{}}
+	assert.Equal(t, config.ToolTruncateMiddle, cmd.toolTruncateStrategy(tools.ToolFetchURL))
+	assert.Equal(t, config.ToolTruncateMiddle, cmd.toolTruncateStrategy(tools.ToolSearch))
+	assert.Equal(t, config.ToolTruncateHead, cmd.toolTruncateStrategy(tools.ToolWeather))
+
+	cmd.cmdCfg.Tools.Truncate = config.ToolTruncateTail
+	assert.Equal(t, config.ToolTruncateTail, cmd.toolTruncateStrategy(tools.ToolFetchURL))
+}
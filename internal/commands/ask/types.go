@@ -1,9 +1,9 @@
 package ask
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/muratoffalex/gachigazer/internal/ai"
@@ -26,6 +26,16 @@ type BuilderConfig struct {
 	ShowContext   bool
 	ShowReasoning bool
 	ShowMetadata  bool
+	// CompactMetadata shows the metadata as a single line with the model and the cost
+	CompactMetadata bool
+	// SplitLongMessages splits content over the telegram limit into several messages
+	SplitLongMessages bool
+	// RenderTables converts markdown tables into aligned code blocks
+	RenderTables bool
+	// Raw shows the content verbatim instead of converting markdown
+	Raw bool
+	// Mention is prepended to the content, e.g. the text mention of the addressee
+	Mention       string
 	SectionsOrder []Section
 	Separators    map[Section]string
 }
@@ -41,6 +51,12 @@ type CommandArgs struct {
 	Recursive    bool
 	Reasoning    *bool
 	Tools        string
+	ToolsModel   string
+	Length       string
+	Effort       string
+	RTokens      int
+	Route        string
+	Seed         *int
 	Think        bool
 	Multi        bool
 	Fast         bool
@@ -51,6 +67,13 @@ type CommandArgs struct {
 	Prompt       string
 	ChainID      int
 	New          bool
+	NoContext    bool
+	QuoteOnly    bool
+	Raw          bool
+	Voice        bool
+	Compact      bool
+	At           string // username of the participant the answer is addressed to
+	Diff         bool
 }
 
 type MetadataUsage struct {
@@ -82,28 +105,7 @@ func (u *MetadataUsage) Add(usage *MetadataUsage) {
 func (u *MetadataUsage) GetFormattedString(isTotal bool, currency *config.CurrencyConfig, l *service.Localizer) string {
 	var totalCostStr string
 	if u.Cost > 0 {
-		totalCostDollars := u.Cost
-		precision := countSignificantDecimals(totalCostDollars)
-		if currency != nil && currency.Precision > 0 {
-			precision = currency.Precision
-		}
-		totalCostStr = fmt.Sprintf(" $%.*f", precision, totalCostDollars)
-
-		if currency != nil && currency.Code != "" {
-			if rate, err := service.GetCurrencyService().GetUSDRate(context.Background(), currency.Code); err == nil {
-				totalCostInCurrency := totalCostDollars * rate
-				if currency.Symbol == "" {
-					currency.Symbol = currency.Code
-				}
-				precision = currency.Precision
-				if precision == 0 {
-					precision = countSignificantDecimals(totalCostInCurrency)
-				}
-				totalCostStr = fmt.Sprintf(" ≈%s%.*f", currency.Symbol, precision, totalCostInCurrency)
-			} else {
-				fmt.Printf("Currency error: %v\n", err)
-			}
-		}
+		totalCostStr = " " + service.FormatCost(u.Cost, currency)
 	}
 	label := l.Localize("ask.response.tokens", nil)
 	if isTotal {
@@ -165,6 +167,15 @@ func (m *Metadata) GetDetailedInfo() string {
 	if m.ModelParams.TopP != nil {
 		params = append(params, fmt.Sprintf("*Top P:* %s", markdown.Escape(fmt.Sprintf("%.1f", *m.ModelParams.TopP))))
 	}
+	if m.ModelParams.Length != nil {
+		params = append(params, fmt.Sprintf("*Length:* %s", markdown.Escape(*m.ModelParams.Length)))
+	}
+	if m.ModelParams.Route != nil {
+		params = append(params, fmt.Sprintf("*Route:* %s", markdown.Escape(*m.ModelParams.Route)))
+	}
+	if m.ModelParams.Seed != nil {
+		params = append(params, fmt.Sprintf("*Seed:* %d", *m.ModelParams.Seed))
+	}
 	if m.ModelParams.Reasoning != nil {
 		reasoningParams := []string{}
 
@@ -240,6 +251,29 @@ func (m *Metadata) GetFormattedString() string {
 	return strings.Join(formatted, "\n")
 }
 
+// GetCompactString returns the model and the cost of the answer in one line,
+// tokens are shown instead of the cost for free models
+func (m *Metadata) GetCompactString() string {
+	formatted := []string{}
+	if m.Model != nil {
+		formatted = append(formatted, fmt.Sprintf("`%s`", markdown.Escape(m.Model.FullName())))
+	}
+	usage := m.Usage
+	if m.TotalUsage != nil && usage != nil && m.TotalUsage.Total > usage.Total {
+		usage = m.TotalUsage
+	}
+	if usage != nil && usage.Cost > 0 {
+		formatted = append(formatted, markdown.Escape(service.FormatCost(usage.Cost, m.Currency)))
+	} else if usage != nil && usage.Total > 0 {
+		formatted = append(formatted, fmt.Sprintf(
+			"*%s:* %s",
+			m.l.Localize("ask.response.tokens", nil),
+			markdown.Escape(fmt.Sprint(usage.Total)),
+		))
+	}
+	return strings.Join(formatted, " · ")
+}
+
 type ContextToolDetailed struct {
 	Name     string
 	Params   map[string]any
@@ -254,18 +288,24 @@ type AudioInput struct {
 type Context struct {
 	Images                 []string
 	Tools                  []string
+	FailedTools            []string
 	Files                  []string
 	Audio                  []AudioInput
 	URLs                   []*URLInfo
 	DetailedTools          []ContextToolDetailed
 	Additional             []string
 	SeparatedModelForTools bool
+	// the media was dropped, the multimodal model failed and the chat model answered
+	MediaSkipped bool
+	// mechanism of $search: plugin or tool, empty without search
+	Search string
 }
 
 func NewContext() Context {
 	return Context{
 		Images:                 make([]string, 0),
 		Tools:                  make([]string, 0),
+		FailedTools:            make([]string, 0),
 		Files:                  make([]string, 0),
 		URLs:                   make([]*URLInfo, 0),
 		DetailedTools:          make([]ContextToolDetailed, 0),
@@ -278,10 +318,38 @@ func (c *Context) SetSeparatedModelForTools(value bool) {
 	c.SeparatedModelForTools = value
 }
 
+func (c *Context) SetMediaSkipped(value bool) {
+	c.MediaSkipped = value
+}
+
+func (c *Context) SetSearch(mechanism string) {
+	c.Search = mechanism
+}
+
 func (c *Context) AddTool(name string) {
 	c.Tools = append(c.Tools, name)
 }
 
+func (c *Context) AddFailedTool(name string) {
+	c.FailedTools = append(c.FailedTools, name)
+}
+
+// SucceededTools returns the tools without failed ones
+func (c *Context) SucceededTools() []string {
+	succeeded := slices.Clone(c.Tools)
+	for _, name := range c.FailedTools {
+		if i := slices.Index(succeeded, name); i != -1 {
+			succeeded = slices.Delete(succeeded, i, i+1)
+		}
+	}
+	return succeeded
+}
+
+// AllToolsFailed reports whether no tool succeeded
+func (c *Context) AllToolsFailed() bool {
+	return len(c.FailedTools) > 0 && len(c.SucceededTools()) == 0
+}
+
 func (c *Context) AddDetailedTool(tool ContextToolDetailed) {
 	c.DetailedTools = append(c.DetailedTools, tool)
 }
@@ -356,6 +424,16 @@ func (c *Context) GetFormattedString(
 		item = strings.TrimSpace(item)
 		formatted = append(formatted, item)
 	}
+	if c.MediaSkipped {
+		formatted = append(formatted, "⚠️ "+l.Localize("ask.response.mediaSkipped", nil))
+	}
+	if c.Search != "" {
+		mechanism := l.Localize("ask.response.searchTool", nil)
+		if c.Search == searchPlugin {
+			mechanism = l.Localize("ask.response.searchPlugin", nil)
+		}
+		formatted = append(formatted, fmt.Sprintf("*%s:* %s", l.Localize("ask.response.search", nil), mechanism))
+	}
 	if len(c.URLs) > 0 {
 		item := fmt.Sprintf("*%s:*\n", l.Localize("ask.response.urls", nil))
 		for _, url := range c.URLs {
@@ -364,11 +442,25 @@ func (c *Context) GetFormattedString(
 		formatted = append(formatted, strings.TrimSpace(item))
 	}
 	if len(c.Tools) > 0 {
+		tools := c.Tools
+		if len(c.FailedTools) > 0 {
+			tools = c.SucceededTools()
+		}
 		item := fmt.Sprintf(
 			"*%s:* %s",
 			l.Localize("ask.response.tools", nil),
-			markdown.Escape(strings.Join(c.Tools, ", ")),
+			markdown.Escape(strings.Join(tools, ", ")),
 		)
+		if len(c.FailedTools) > 0 {
+			if len(tools) == 0 {
+				item = fmt.Sprintf("*%s:*", l.Localize("ask.response.tools", nil))
+			}
+			item += fmt.Sprintf(
+				" · ⚠️ *%s:* %s",
+				l.Localize("ask.response.failedTools", nil),
+				markdown.Escape(strings.Join(c.FailedTools, ", ")),
+			)
+		}
 		if !model.SupportsTools() && !c.SeparatedModelForTools {
 			item += " · ⚠️ " + l.Localize("ask.response.modelDoesntSupportTools", nil)
 		}
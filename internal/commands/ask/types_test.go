@@ -0,0 +1,124 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatCost_ChatCurrency(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency *config.CurrencyConfig
+		expected string
+	}{
+		{"without currency", nil, "$0.500000"},
+		{"usd precision", &config.CurrencyConfig{Precision: 3}, "$0.500"},
+		{"fixed rate", &config.CurrencyConfig{Code: "eur", Symbol: "€", Precision: 2, Rate: 0.9}, "≈€0.45"},
+		{"fixed rate without symbol", &config.CurrencyConfig{Code: "kzt", Rate: 470}, "≈kzt235"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, service.FormatCost(0.5, tt.currency))
+		})
+	}
+}
+
+func TestMetadataUsage_GetFormattedString_ChatCurrency(t *testing.T) {
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+	usage := &MetadataUsage{Total: 30, Input: 20, Output: 10, Cost: 0.01}
+
+	first := &config.CurrencyConfig{Code: "eur", Symbol: "€", Precision: 3, Rate: 0.9}
+	second := &config.CurrencyConfig{Code: "kzt", Symbol: "₸", Precision: 1, Rate: 470}
+
+	assert.Contains(t, usage.GetFormattedString(false, first, localizer), "≈€0\\.009")
+	assert.Contains(t, usage.GetFormattedString(false, second, localizer), "≈₸4\\.7")
+}
+
+func TestContext_FailedTools(t *testing.T) {
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+	model := &ai.ModelInfo{SupportedParameters: []string{"tools"}}
+
+	t.Run("partial failure", func(t *testing.T) {
+		context := NewContext()
+		context.AddTool("search")
+		context.AddTool("weather")
+		context.AddTool("search")
+		context.AddFailedTool("search")
+
+		assert.Equal(t, []string{"weather", "search"}, context.SucceededTools())
+		assert.False(t, context.AllToolsFailed())
+		assert.Contains(t, context.GetFormattedString(model, nil, localizer, false), "*Tools:* weather, search · ⚠️ *Failed:* search")
+	})
+
+	t.Run("all failed", func(t *testing.T) {
+		context := NewContext()
+		context.AddTool("weather")
+		context.AddFailedTool("weather")
+
+		assert.Empty(t, context.SucceededTools())
+		assert.True(t, context.AllToolsFailed())
+		assert.Contains(t, context.GetFormattedString(model, nil, localizer, false), "*Tools:* · ⚠️ *Failed:* weather")
+	})
+
+	t.Run("without failures", func(t *testing.T) {
+		context := NewContext()
+		context.AddTool("weather")
+
+		assert.False(t, context.AllToolsFailed())
+		assert.Contains(t, context.GetFormattedString(model, nil, localizer, false), "*Tools:* weather")
+		assert.NotContains(t, context.GetFormattedString(model, nil, localizer, false), "Failed")
+	})
+}
+
+func TestContext_MediaSkipped(t *testing.T) {
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+	model := &ai.ModelInfo{}
+
+	context := NewContext()
+	assert.NotContains(t, context.GetFormattedString(model, nil, localizer, false), "Media skipped")
+
+	context.SetMediaSkipped(true)
+	assert.Contains(t, context.GetFormattedString(model, nil, localizer, false), "⚠️ Media skipped")
+}
+
+func TestContext_Search(t *testing.T) {
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+	model := &ai.ModelInfo{}
+
+	context := NewContext()
+	assert.NotContains(t, context.GetFormattedString(model, nil, localizer, false), "Search")
+
+	context.SetSearch(searchPlugin)
+	assert.Contains(t, context.GetFormattedString(model, nil, localizer, false), "*Search:* web plugin of the provider")
+
+	context.SetSearch(searchTool)
+	assert.Contains(t, context.GetFormattedString(model, nil, localizer, false), "*Search:* search tool")
+}
+
+func TestMetadata_GetCompactString(t *testing.T) {
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+	model := &ai.ModelInfo{Provider: "openrouter", ID: "gpt-4.1"}
+
+	t.Run("cost of the whole answer", func(t *testing.T) {
+		metadata := NewMetadata(model, nil,
+			&MetadataUsage{Total: 30, Cost: 0.01}, 1,
+			&MetadataUsage{Total: 60, Cost: 0.02}, 0, 0, 0, 0, nil,
+			&config.CurrencyConfig{Precision: 2}, localizer)
+		assert.Equal(t, "`openrouter:gpt\\-4\\.1` · $0\\.02", metadata.GetCompactString())
+	})
+
+	t.Run("tokens for free model", func(t *testing.T) {
+		metadata := NewMetadata(model, nil, &MetadataUsage{Total: 30}, 1, nil, 0, 0, 0, 0, nil, nil, localizer)
+		assert.Equal(t, "`openrouter:gpt\\-4\\.1` · *Tokens:* 30", metadata.GetCompactString())
+	})
+}
@@ -0,0 +1,114 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// urlCheckConcurrency limits HEAD checks running at once
+	urlCheckConcurrency = 8
+	urlCheckCacheTTL    = 5 * time.Minute
+)
+
+// urlCheckTimeout limits a single HEAD check, so a slow host doesn't delay the whole response
+var urlCheckTimeout = 5 * time.Second
+
+var errURLUnreachable = errors.New("url is unreachable")
+
+// cached check results
+const (
+	urlCheckAvailable   = "available"
+	urlCheckUnavailable = "unavailable"
+	urlCheckUnreachable = "unreachable"
+)
+
+// checkURL sends a HEAD request to the url through the command's http client,
+// so the proxy config is respected. It returns an error if the host is unreachable
+// or doesn't respond within urlCheckTimeout. Results are cached for urlCheckCacheTTL
+func (c *Command) checkURL(ctx context.Context, url string) (bool, error) {
+	key := "ask:url_check:" + url
+	if c.cache != nil {
+		if data, found := c.cache.Get(key); found {
+			switch string(data) {
+			case urlCheckAvailable:
+				return true, nil
+			case urlCheckUnavailable:
+				return false, nil
+			default:
+				return false, errURLUnreachable
+			}
+		}
+	}
+
+	available, err := c.headURL(ctx, url)
+	if c.cache != nil && ctx.Err() == nil {
+		result := urlCheckUnavailable
+		if err != nil {
+			result = urlCheckUnreachable
+		} else if available {
+			result = urlCheckAvailable
+		}
+		if err := c.cache.Set(key, []byte(result), urlCheckCacheTTL); err != nil {
+			c.Logger.WithError(err).WithField("url", url).Warn("Failed to cache URL check")
+		}
+	}
+	return available, err
+}
+
+func (c *Command) headURL(ctx context.Context, url string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, urlCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return false, err
+	}
+	client := c.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// checkURLs checks urls concurrently with at most urlCheckConcurrency
+// requests at once, results are in the order of urls
+func (c *Command) checkURLs(ctx context.Context, urls []string) ([]bool, []error) {
+	available := make([]bool, len(urls))
+	errs := make([]error, len(urls))
+
+	sem := make(chan struct{}, urlCheckConcurrency)
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			available[i], errs[i] = c.checkURL(ctx, url)
+		}()
+	}
+	wg.Wait()
+
+	return available, errs
+}
+
+// validURLs returns the available urls keeping their order
+func (c *Command) validURLs(ctx context.Context, urls []string) []string {
+	available, errs := c.checkURLs(ctx, urls)
+	result := make([]string, 0, len(urls))
+	for i, url := range urls {
+		if available[i] && errs[i] == nil {
+			result = append(result, url)
+		}
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package ask
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/cache"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type urlCheckServer struct {
+	*httptest.Server
+	requests   atomic.Int32
+	running    atomic.Int32
+	maxRunning atomic.Int32
+}
+
+func newURLCheckServer(t *testing.T, delay time.Duration) *urlCheckServer {
+	release := make(chan struct{})
+	server := &urlCheckServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.requests.Add(1)
+		current := server.running.Add(1)
+		defer server.running.Add(-1)
+		for {
+			prev := server.maxRunning.Load()
+			if current <= prev || server.maxRunning.CompareAndSwap(prev, current) {
+				break
+			}
+		}
+
+		switch r.URL.Path {
+		case "/slow.jpg":
+			select {
+			case <-time.After(delay):
+			case <-release:
+			}
+		case "/missing.jpg":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	return server
+}
+
+func newURLCheckTestCommand() *Command {
+	return &Command{
+		Command: &base.Command{Logger: logger.NewTestLogger()},
+		cache:   cache.NewMemoryCache(),
+	}
+}
+
+func setURLCheckTimeout(t *testing.T, timeout time.Duration) {
+	previous := urlCheckTimeout
+	urlCheckTimeout = timeout
+	t.Cleanup(func() { urlCheckTimeout = previous })
+}
+
+func TestCommand_validURLs_SlowHeadDoesNotBlock(t *testing.T) {
+	setURLCheckTimeout(t, 200*time.Millisecond)
+	server := newURLCheckServer(t, 5*time.Second)
+
+	urls := []string{
+		server.URL + "/fast.jpg",
+		server.URL + "/slow.jpg",
+		server.URL + "/missing.jpg",
+		server.URL + "/slow.jpg?second",
+		server.URL + "/other.png",
+	}
+
+	start := time.Now()
+	result := newURLCheckTestCommand().validURLs(context.Background(), urls)
+	elapsed := time.Since(start)
+
+	assert.Equal(t, []string{server.URL + "/fast.jpg", server.URL + "/other.png"}, result)
+	assert.Less(t, elapsed, time.Second, "Slow hosts must be checked concurrently and limited by timeout")
+}
+
+func TestCommand_validURLs_DeadHost(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	deadURL := server.URL + "/dead.jpg"
+	server.Close()
+
+	start := time.Now()
+	result := newURLCheckTestCommand().validURLs(context.Background(), []string{deadURL})
+
+	assert.Empty(t, result)
+	assert.Less(t, time.Since(start), urlCheckTimeout)
+}
+
+func TestCommand_validURLs_Cache(t *testing.T) {
+	setURLCheckTimeout(t, 200*time.Millisecond)
+	server := newURLCheckServer(t, 5*time.Second)
+	cmd := newURLCheckTestCommand()
+
+	urls := []string{server.URL + "/fast.jpg", server.URL + "/missing.jpg", server.URL + "/slow.jpg"}
+	first := cmd.validURLs(context.Background(), urls)
+	require.Equal(t, int32(3), server.requests.Load())
+
+	start := time.Now()
+	second := cmd.validURLs(context.Background(), urls)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, []string{server.URL + "/fast.jpg"}, second)
+	assert.Equal(t, int32(3), server.requests.Load(), "Checked URLs must be taken from cache")
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "Slow host result must be cached")
+}
+
+func TestCommand_validURLs_BoundedConcurrency(t *testing.T) {
+	server := newURLCheckServer(t, 0)
+
+	var urls []string
+	for i := range urlCheckConcurrency * 3 {
+		urls = append(urls, fmt.Sprintf("%s/%d.jpg", server.URL, i))
+	}
+
+	result := newURLCheckTestCommand().validURLs(context.Background(), urls)
+
+	assert.Equal(t, urls, result)
+	assert.LessOrEqual(t, server.maxRunning.Load(), int32(urlCheckConcurrency))
+	assert.Greater(t, server.maxRunning.Load(), int32(1))
+}
+
+func TestCommand_filterURLs_SlowHeadDoesNotBlock(t *testing.T) {
+	setURLCheckTimeout(t, 200*time.Millisecond)
+	server := newURLCheckServer(t, 5*time.Second)
+
+	start := time.Now()
+	pages, images, files := newURLCheckTestCommand().filterURLs([]string{
+		server.URL + "/slow.jpg",
+		server.URL + "/slow.jpg?second",
+		server.URL + "/image.jpg",
+		server.URL + "/doc.pdf",
+	})
+	elapsed := time.Since(start)
+
+	assert.Empty(t, pages)
+	assert.Equal(t, []string{server.URL + "/image.jpg"}, images)
+	assert.Equal(t, []string{server.URL + "/doc.pdf"}, files)
+	assert.Less(t, elapsed, time.Second, "Slow hosts must be checked concurrently and limited by timeout")
+}
@@ -8,21 +8,6 @@ import (
 	"unicode"
 )
 
-func countSignificantDecimals(f float64) int {
-	str := fmt.Sprintf("%f", f)
-	parts := strings.Split(str, ".")
-	if len(parts) < 2 {
-		return 0
-	}
-	decimalPart := parts[1]
-	for i, ch := range decimalPart {
-		if ch != '0' {
-			return len(decimalPart) - i
-		}
-	}
-	return 0
-}
-
 func formatForwardOrigin(fo *forwardOrigin) string {
 	replyHeader := fmt.Sprintf(" [forwarded from %s %s", fo.Type, fo.Name)
 	if fo.EncodedID != "" {
@@ -64,3 +49,26 @@ func extractStringSlice(v reflect.Value) []string {
 	}
 	return result
 }
+
+// splitText splits text into chunks of at most limit runes, preferring to cut on line breaks
+func splitText(text string, limit int) []string {
+	var chunks []string
+	runes := []rune(text)
+	for len(runes) > limit {
+		cut := limit
+		for i := limit - 1; i > limit/2; i-- {
+			if runes[i] == '\n' {
+				cut = i + 1
+				break
+			}
+		}
+		if chunk := strings.TrimSpace(string(runes[:cut])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		runes = runes[cut:]
+	}
+	if chunk := strings.TrimSpace(string(runes)); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
@@ -0,0 +1,89 @@
+package ask
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+// max length in characters of a text synthesized by one request,
+// OpenAI speech endpoint accepts up to 4096
+const voiceChunkSize = 4000
+
+var (
+	speechCodeBlockRegex = regexp.MustCompile("(?s)```.*?```")
+	speechLinkRegex      = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	speechMarkupRegex    = regexp.MustCompile("[*_`#>|~]+")
+	speechSpacesRegex    = regexp.MustCompile(`[ \t]+`)
+	speechNewlinesRegex  = regexp.MustCompile(`\n\s*\n\s*`)
+)
+
+// speechText converts the markdown answer into plain text to be read aloud,
+// code blocks are left out and links are replaced with their text
+func speechText(text string) string {
+	text = speechCodeBlockRegex.ReplaceAllString(text, "")
+	text = speechLinkRegex.ReplaceAllString(text, "$1")
+	text = speechMarkupRegex.ReplaceAllString(text, "")
+	text = speechSpacesRegex.ReplaceAllString(text, " ")
+	text = speechNewlinesRegex.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// sendVoiceAnswer synthesizes the answer with ai.tts_model and sends it as voice messages
+// replying to the answer, long answers are split into several messages. The text answer
+// is already sent, so when speech isn't configured or fails only a warning is sent
+func (c *Command) sendVoiceAnswer(ctx context.Context, chatID int64, botMessageID int, text string) {
+	aiCfg := c.Cfg.AI()
+	if aiCfg.TTSModel == "" {
+		c.sendVoiceWarning(chatID, botMessageID, "ask.voiceNotConfigured")
+		return
+	}
+	text = speechText(text)
+	if text == "" {
+		return
+	}
+
+	voiceLog := c.Logger.WithFields(logger.Fields{
+		"chat_id":        chatID,
+		"bot_message_id": botMessageID,
+		"model":          aiCfg.TTSModel,
+	})
+	for i, chunk := range splitMarkdown(text, voiceChunkSize) {
+		if err := c.Tg.SendChatAction(chatID, telegram.ActionUploadVoice); err != nil {
+			voiceLog.WithError(err).Debug("Failed to send chat action")
+		}
+		audio, err := c.ai.Speech(ctx, aiCfg.TTSModel, aiCfg.TTSVoice, chunk)
+		if err != nil {
+			voiceLog.WithError(err).WithField("part", i+1).Warn("Speech synthesis failed")
+			c.sendVoiceWarning(chatID, botMessageID, "ask.voiceFailed")
+			return
+		}
+		msg := telegram.NewVoiceMessage(
+			chatID,
+			telegram.FileBytes{Name: fmt.Sprintf("answer_%d.ogg", i+1), Bytes: audio},
+			"",
+			botMessageID,
+		)
+		sent, err := c.Tg.Send(msg)
+		if err != nil {
+			voiceLog.WithError(err).WithField("part", i+1).Warn("Send voice message failed")
+			c.sendVoiceWarning(chatID, botMessageID, "ask.voiceFailed")
+			return
+		}
+		// replies to the voice message continue the chain
+		if err := c.db.SaveMessagePart(chatID, sent.MessageID, botMessageID); err != nil {
+			voiceLog.WithError(err).Warn("Failed to save voice message part")
+		}
+	}
+}
+
+func (c *Command) sendVoiceWarning(chatID int64, botMessageID int, messageID string) {
+	warning := telegram.NewMessage(chatID, c.L(messageID, nil), botMessageID)
+	if _, err := c.Tg.Send(warning); err != nil {
+		c.Logger.WithError(err).Warn("Failed to send voice warning")
+	}
+}
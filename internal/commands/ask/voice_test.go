@@ -0,0 +1,13 @@
+package ask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpeechText(t *testing.T) {
+	text := "# Weather\n\n**Sunny**, see [the forecast](https://example.com) and _details_:\n\n```go\nfmt.Println(1)\n```\n\n> `22°C`  today"
+	assert.Equal(t, "Weather\n\nSunny, see the forecast and details:\n\n22°C today", speechText(text))
+	assert.Empty(t, speechText("```\ncode only\n```"))
+}
@@ -0,0 +1,145 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/app/di"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/service"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const CommandName = "currency"
+
+var ErrInvalidCurrency = errors.New("invalid currency")
+
+type Command struct {
+	*base.Command
+}
+
+func New(di *di.Container) *Command {
+	cmd := &Command{}
+	cmd.Command = base.NewCommand(cmd, di)
+	return cmd
+}
+
+func (c *Command) Name() string {
+	return CommandName
+}
+
+func (c *Command) Execute(update telegram.Update) error {
+	if update.Message == nil {
+		return nil
+	}
+
+	args := strings.TrimSpace(strings.TrimPrefix(
+		update.Message.Text,
+		"/"+update.Message.Command(),
+	))
+	chatID := update.Message.Chat.ID
+
+	if args == "" {
+		return c.reply(update, c.Localizer.Localize("currency.current", map[string]any{
+			"Currency": describeCurrency(c.ChatService.GetChatCurrency(chatID)),
+		}))
+	}
+
+	if !c.Cfg.Telegram().IsUserAllowed(update.Message.From.ID) {
+		return c.reply(update, c.Localizer.Localize("currency.notAllowed", nil))
+	}
+
+	if args == "reset" {
+		if err := c.ChatService.ResetChatCurrency(chatID); err != nil {
+			c.Logger.WithError(err).WithField("chat_id", chatID).Error("Failed to reset chat currency")
+			_ = c.reply(update, c.Localizer.Localize("currency.fail", nil))
+			return err
+		}
+		c.Logger.WithField("chat_id", chatID).Info("Chat currency reset to default")
+		return c.reply(update, c.Localizer.Localize("currency.reset.success", map[string]any{
+			"Currency": describeCurrency(c.Cfg.Currency()),
+		}))
+	}
+
+	currency, err := parseCurrencyArgs(args, c.Cfg.Currency().Precision)
+	if err != nil {
+		return c.reply(update, c.Localizer.Localize("currency.usage", nil))
+	}
+	if currency.Rate == 0 {
+		if _, err := service.GetCurrencyService().GetUSDRate(context.Background(), currency.Code); err != nil {
+			c.Logger.WithError(err).WithField("code", currency.Code).Warn("Currency rate not found")
+			return c.reply(update, c.Localizer.Localize("currency.unknown", map[string]any{
+				"Code": currency.Code,
+			}))
+		}
+	}
+
+	if err := c.ChatService.SetChatCurrency(chatID, currency); err != nil {
+		c.Logger.WithError(err).WithField("chat_id", chatID).Error("Failed to save chat currency")
+		_ = c.reply(update, c.Localizer.Localize("currency.fail", nil))
+		return err
+	}
+	c.Logger.WithFields(logger.Fields{
+		"chat_id": chatID,
+		"code":    currency.Code,
+		"rate":    currency.Rate,
+	}).Info("Chat currency changed")
+
+	return c.reply(update, c.Localizer.Localize("currency.set.success", map[string]any{
+		"Currency": describeCurrency(currency),
+	}))
+}
+
+func (c *Command) reply(update telegram.Update, text string) error {
+	_, err := c.Tg.Send(telegram.NewMessage(update.Message.Chat.ID, text, update.Message.MessageID))
+	return err
+}
+
+// parseCurrencyArgs parses "<code> [symbol] [rate]", e.g. "eur € 0.92"
+func parseCurrencyArgs(args string, precision int) (config.CurrencyConfig, error) {
+	currency := config.CurrencyConfig{Precision: precision}
+	fields := strings.Fields(args)
+	if len(fields) == 0 || len(fields) > 3 {
+		return currency, ErrInvalidCurrency
+	}
+
+	currency.Code = strings.ToLower(fields[0])
+	if len(currency.Code) < 3 || strings.ContainsAny(currency.Code, "0123456789") {
+		return currency, fmt.Errorf("%w: code %s", ErrInvalidCurrency, fields[0])
+	}
+
+	for _, field := range fields[1:] {
+		if rate, err := strconv.ParseFloat(strings.ReplaceAll(field, ",", "."), 64); err == nil {
+			if rate <= 0 || currency.Rate != 0 {
+				return currency, fmt.Errorf("%w: rate %s", ErrInvalidCurrency, field)
+			}
+			currency.Rate = rate
+			continue
+		}
+		if currency.Symbol != "" {
+			return currency, fmt.Errorf("%w: symbol %s", ErrInvalidCurrency, field)
+		}
+		currency.Symbol = field
+	}
+
+	return currency, nil
+}
+
+func describeCurrency(currency config.CurrencyConfig) string {
+	if currency.Code == "" {
+		return "USD"
+	}
+	description := strings.ToUpper(currency.Code)
+	if currency.Symbol != "" {
+		description += " (" + currency.Symbol + ")"
+	}
+	if currency.Rate > 0 {
+		description += fmt.Sprintf(", 1 USD = %g", currency.Rate)
+	}
+	return description
+}
@@ -0,0 +1,43 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurrencyArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     string
+		expected config.CurrencyConfig
+	}{
+		{"code only", "EUR", config.CurrencyConfig{Code: "eur", Precision: 3}},
+		{"code and symbol", "eur €", config.CurrencyConfig{Code: "eur", Symbol: "€", Precision: 3}},
+		{"code and rate", "kzt 470", config.CurrencyConfig{Code: "kzt", Rate: 470, Precision: 3}},
+		{"all", "kzt ₸ 470,5", config.CurrencyConfig{Code: "kzt", Symbol: "₸", Rate: 470.5, Precision: 3}},
+		{"rate before symbol", "kzt 470 ₸", config.CurrencyConfig{Code: "kzt", Symbol: "₸", Rate: 470, Precision: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currency, err := parseCurrencyArgs(tt.args, 3)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, currency)
+		})
+	}
+
+	for _, args := range []string{"", "eu", "123", "eur € $", "eur 1 2", "eur -1", "eur € 1 extra"} {
+		t.Run("invalid "+args, func(t *testing.T) {
+			_, err := parseCurrencyArgs(args, 3)
+			assert.ErrorIs(t, err, ErrInvalidCurrency)
+		})
+	}
+}
+
+func TestDescribeCurrency(t *testing.T) {
+	assert.Equal(t, "USD", describeCurrency(config.CurrencyConfig{}))
+	assert.Equal(t, "EUR (€)", describeCurrency(config.CurrencyConfig{Code: "eur", Symbol: "€"}))
+	assert.Equal(t, "KZT (₸), 1 USD = 470", describeCurrency(config.CurrencyConfig{Code: "kzt", Symbol: "₸", Rate: 470}))
+}
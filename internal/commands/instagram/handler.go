@@ -694,6 +694,11 @@ func (c *Command) checkAndRefreshSession() error {
 	return c.relogin()
 }
 
+// SessionValid reports whether the Instagram session works, used by /selftest
+func (c *Command) SessionValid() bool {
+	return c.isSessionValid()
+}
+
 func (c *Command) isSessionValid() bool {
 	_, err := executeWithRelogin(c, func() (*goinsta.Account, error) {
 		return c.insta.Account, nil
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/app/di"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const CommandName = "memory"
+
+var ErrInvalidArgs = errors.New("invalid arguments")
+
+// Command shows and clears the facts about the user remembered with remember tool
+type Command struct {
+	*base.Command
+	db database.Database
+}
+
+func New(di *di.Container) *Command {
+	cmd := &Command{
+		db: di.DB,
+	}
+	cmd.Command = base.NewCommand(cmd, di)
+	return cmd
+}
+
+func (c *Command) Name() string {
+	return CommandName
+}
+
+func (c *Command) Execute(update telegram.Update) error {
+	if update.Message == nil || update.Message.From == nil {
+		return nil
+	}
+
+	args := strings.TrimSpace(strings.TrimPrefix(
+		update.Message.Text,
+		"/"+update.Message.Command(),
+	))
+	userID := update.Message.From.ID
+	userLog := c.Logger.WithField("user_id", userID)
+
+	action, number, err := parseMemoryArgs(args)
+	if err != nil {
+		return c.reply(update, c.Localizer.Localize("memory.usage", nil))
+	}
+
+	facts, err := c.db.GetUserFacts(userID)
+	if err != nil {
+		userLog.WithError(err).Error("Failed to get user facts")
+		return c.reply(update, c.Localizer.Localize("memory.fail", nil))
+	}
+
+	switch action {
+	case "clear":
+		count, err := c.db.ClearUserFacts(userID)
+		if err != nil {
+			userLog.WithError(err).Error("Failed to clear user facts")
+			_ = c.reply(update, c.Localizer.Localize("memory.fail", nil))
+			return err
+		}
+		userLog.WithField("facts", count).Info("User facts cleared")
+		return c.reply(update, c.Localizer.Localize("memory.cleared", map[string]any{"Count": count}))
+	case "delete":
+		if number > len(facts) {
+			return c.reply(update, c.Localizer.Localize("memory.notFound", map[string]any{"Number": number}))
+		}
+		fact := facts[number-1]
+		if err := c.db.DeleteUserFact(userID, fact.ID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			userLog.WithError(err).Error("Failed to delete user fact")
+			_ = c.reply(update, c.Localizer.Localize("memory.fail", nil))
+			return err
+		}
+		userLog.WithField("fact_id", fact.ID).Info("User fact deleted")
+		return c.reply(update, c.Localizer.Localize("memory.deleted", map[string]any{"Fact": fact.Fact}))
+	}
+
+	if len(facts) == 0 {
+		return c.reply(update, c.Localizer.Localize("memory.empty", nil))
+	}
+	text := c.Localizer.Localize("memory.current", map[string]any{
+		"Facts": formatFacts(facts),
+	})
+	// the facts are kept, but not used in this chat
+	if !c.ChatService.IsMemoryEnabled(update.Message.Chat.ID) {
+		text += "\n\n" + c.Localizer.Localize("memory.disabled", nil)
+	}
+	return c.reply(update, text)
+}
+
+func (c *Command) reply(update telegram.Update, text string) error {
+	_, err := c.Tg.Send(telegram.NewMessage(update.Message.Chat.ID, text, update.Message.MessageID))
+	return err
+}
+
+// parseMemoryArgs parses "", "clear" or "delete <number>", the number starts from 1
+func parseMemoryArgs(args string) (string, int, error) {
+	fields := strings.Fields(strings.ToLower(args))
+	switch {
+	case len(fields) == 0:
+		return "", 0, nil
+	case len(fields) == 1 && fields[0] == "clear":
+		return "clear", 0, nil
+	case len(fields) == 2 && fields[0] == "delete":
+		number, err := strconv.Atoi(fields[1])
+		if err != nil || number < 1 {
+			return "", 0, ErrInvalidArgs
+		}
+		return "delete", number, nil
+	}
+	return "", 0, ErrInvalidArgs
+}
+
+// formatFacts numbers the facts for /memory delete
+func formatFacts(facts []database.UserFact) string {
+	lines := make([]string, 0, len(facts))
+	for i, fact := range facts {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, fact.Fact))
+	}
+	return strings.Join(lines, "\n")
+}
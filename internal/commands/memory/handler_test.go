@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMemoryArgs(t *testing.T) {
+	tests := []struct {
+		args   string
+		action string
+		number int
+	}{
+		{"", "", 0},
+		{" Clear ", "clear", 0},
+		{"delete 2", "delete", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			action, number, err := parseMemoryArgs(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.action, action)
+			assert.Equal(t, tt.number, number)
+		})
+	}
+
+	for _, args := range []string{"delete", "delete 0", "delete two", "clear all", "forget"} {
+		t.Run("invalid "+args, func(t *testing.T) {
+			_, _, err := parseMemoryArgs(args)
+			assert.ErrorIs(t, err, ErrInvalidArgs)
+		})
+	}
+}
+
+func TestFormatFacts(t *testing.T) {
+	assert.Equal(t, "1. The user is a vegetarian\n2. The user lives in Berlin", formatFacts([]database.UserFact{
+		{ID: 7, Fact: "The user is a vegetarian"},
+		{ID: 9, Fact: "The user lives in Berlin"},
+	}))
+}
@@ -54,9 +54,34 @@ func (c *Command) Execute(update telegram.Update) error {
 		"/"+command,
 	))
 	chatID := update.Message.Chat.ID
+	userID := update.Message.From.ID
+
+	// --user scope manages the personal default model used in all chats
+	userScope := false
+	if rest, ok := strings.CutPrefix(args, "--user"); ok && (rest == "" || rest[0] == ' ') {
+		userScope = true
+		args = strings.TrimSpace(rest)
+	}
+
+	if userScope && args == "" {
+		userModel, err := c.ChatService.GetUserModel(userID)
+		if err != nil {
+			return err
+		}
+		text := c.Localizer.Localize("model.user.notSet", nil)
+		if userModel != "" {
+			text = c.Localizer.Localize("model.user.current", map[string]any{
+				"ModelName": c.Tg.EscapeText(userModel),
+			})
+		}
+		msg := telegram.NewMessage(chatID, text, update.Message.MessageID)
+		msg.ParseMode = telegram.ModeMarkdownV2
+		_, err = c.Tg.Send(msg)
+		return err
+	}
 
 	if args == "" {
-		currentModel, _ := c.ChatService.GetCurrentModelForChat(context.Background(), chatID, update.Message.From.ID, "")
+		currentModel, _ := c.ChatService.GetCurrentModelForChat(context.Background(), chatID, userID, "")
 		currentModelStr := fmt.Sprintf(
 			"`%s` %s",
 			c.Tg.EscapeText(currentModel.FullName()),
@@ -77,7 +102,7 @@ func (c *Command) Execute(update telegram.Update) error {
 		}
 
 		// Show current model and permissions
-		isAllowedUser := c.Cfg.Telegram().IsUserAllowed(update.Message.From.ID) && !provider.OnlyFreeModels
+		isAllowedUser := c.Cfg.Telegram().IsUserAllowed(userID) && !provider.OnlyFreeModels && !c.ChatService.IsFreeOnly(chatID)
 
 		permissionInfo := ""
 		if isAllowedUser {
@@ -241,11 +266,34 @@ func (c *Command) Execute(update telegram.Update) error {
 		return nil
 	}
 
+	if userScope && args == "reset" {
+		if err := c.ChatService.ResetUserModel(userID); err != nil {
+			c.Logger.WithFields(logger.Fields{
+				"user_id": userID,
+			}).WithError(err).Error("Failed to reset user model")
+			msg := telegram.NewMessage(chatID, c.Localizer.Localize("model.reset.fail", nil), update.Message.MessageID)
+			_, _ = c.Tg.Send(msg)
+			return err
+		}
+
+		c.Logger.WithFields(logger.Fields{
+			"user_id": userID,
+		}).Info("User model reset")
+
+		msg := telegram.NewMessage(
+			chatID,
+			c.Localizer.Localize("model.user.reset.success", nil),
+			update.Message.MessageID,
+		)
+		_, err := c.Tg.Send(msg)
+		return err
+	}
+
 	if strings.HasPrefix(args, "reset") {
 		modelSpec := c.Cfg.AI().GetDefaultModel()
 		model, err := c.ai.GetFormattedModel(ctx, modelSpec, "")
 		provider := c.Cfg.AI().GetProvider(model.Provider)
-		isAllowedUser := c.Cfg.Telegram().IsUserAllowed(update.Message.From.ID) && !provider.OnlyFreeModels
+		isAllowedUser := c.Cfg.Telegram().IsUserAllowed(userID) && !provider.OnlyFreeModels
 
 		if !isAllowedUser {
 			freeModels, err := c.ai.GetAllModels(context.Background(), true, true)
@@ -303,8 +351,10 @@ func (c *Command) Execute(update telegram.Update) error {
 	}
 	provider := c.Cfg.AI().GetProvider(model.Provider)
 
-	// Check if user is allowed to use paid models
-	isAllowedUser := c.Cfg.Telegram().IsUserAllowed(update.Message.From.ID) && !provider.OnlyFreeModels
+	// Check if user is allowed to use paid models, in free only chats nobody is for the chat.
+	// The personal model is used in all chats, free only chats skip it if it's paid
+	isAllowedUser := c.Cfg.Telegram().IsUserAllowed(userID) && !provider.OnlyFreeModels &&
+		(userScope || !c.ChatService.IsFreeOnly(chatID))
 
 	// For regular users, check if model is free
 	if !isAllowedUser {
@@ -338,6 +388,40 @@ func (c *Command) Execute(update telegram.Update) error {
 		}
 	}
 
+	if userScope {
+		if err := c.ChatService.SetUserModel(ctx, userID, modelSpec); err != nil {
+			c.Logger.WithFields(logger.Fields{
+				"user_id": userID,
+				"model":   modelSpec,
+			}).WithError(err).Error("Failed to save user model")
+			msg := telegram.NewMessage(
+				chatID,
+				c.Localizer.Localize("model.modelNotFoundError", map[string]any{
+					"Model": modelSpec,
+				}),
+				update.Message.MessageID,
+			)
+			_, _ = c.Tg.Send(msg)
+			return err
+		}
+
+		c.Logger.WithFields(logger.Fields{
+			"user_id": userID,
+			"model":   model.FullName(),
+		}).Info("User model switched")
+
+		msg := telegram.NewMessage(
+			chatID,
+			c.Localizer.Localize("model.user.switchSuccess", map[string]any{
+				"ModelName": c.Tg.EscapeText(model.FullName()),
+			}),
+			update.Message.MessageID,
+		)
+		msg.ParseMode = telegram.ModeMarkdownV2
+		_, err := c.Tg.Send(msg)
+		return err
+	}
+
 	// Save model to DB and cache
 	err = c.ChatService.SetChatModel(context.Background(), chatID, modelSpec)
 	if err != nil {
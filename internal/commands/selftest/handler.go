@@ -0,0 +1,247 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lrstanley/go-ytdlp"
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/app/di"
+	"github.com/muratoffalex/gachigazer/internal/commands"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/muratoffalex/gachigazer/internal/fetcher"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const (
+	CommandName = "selftest"
+
+	checkTimeout = 15 * time.Second
+	// pages expected to be always available
+	fetchCheckURL = "https://example.com"
+	proxyCheckURL = "https://www.gstatic.com/generate_204"
+
+	instagramCommandName = "instagram"
+)
+
+// errSkipped is returned by checks of subsystems that aren't configured
+var errSkipped = errors.New("not configured")
+
+type check struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+type result struct {
+	name     string
+	err      error
+	duration time.Duration
+}
+
+// sessionChecker is implemented by the instagram command
+type sessionChecker interface {
+	SessionValid() bool
+}
+
+// Command checks the configured subsystems and reports pass or fail for each one,
+// only for allowed users
+type Command struct {
+	*base.Command
+	db         database.Database
+	ai         *ai.ProviderRegistry
+	fetcher    *fetcher.Manager
+	httpClient *http.Client
+	// commands returns the registered commands, some of them are registered after start
+	commands func() map[string]commands.Command
+}
+
+func New(di *di.Container, registered func() map[string]commands.Command) *Command {
+	cmd := &Command{
+		db:         di.DB,
+		ai:         di.AI,
+		fetcher:    di.Fetcher,
+		httpClient: di.HttpClient,
+		commands:   registered,
+	}
+	cmd.Command = base.NewCommand(cmd, di)
+	return cmd
+}
+
+func (c *Command) Name() string {
+	return CommandName
+}
+
+func (c *Command) Execute(update telegram.Update) error {
+	if update.Message == nil || update.Message.From == nil {
+		return nil
+	}
+	chatID := update.Message.Chat.ID
+	if !c.Cfg.Telegram().IsUserAllowed(update.Message.From.ID) {
+		_, err := c.Tg.Send(telegram.NewMessage(chatID, c.Localizer.Localize("selftest.notAllowed", nil), update.Message.MessageID))
+		return err
+	}
+
+	sent, err := c.Tg.Send(telegram.NewMessage(chatID, c.Localizer.Localize("selftest.running", nil), update.Message.MessageID))
+	if err != nil {
+		return err
+	}
+	results := runChecks(context.Background(), c.checks(), checkTimeout)
+	for _, r := range results {
+		if r.err != nil && !errors.Is(r.err, errSkipped) {
+			c.Logger.WithError(r.err).WithField("check", r.name).Warn("Self-test check failed")
+		}
+	}
+	c.Logger.WithFields(logger.Fields{
+		"chat_id": chatID,
+		"user_id": update.Message.From.ID,
+		"checks":  len(results),
+	}).Info("Self-test finished")
+
+	text := c.Localizer.Localize("selftest.title", nil) + "\n\n" + formatResults(results, c.Localizer.Localize("selftest.skipped", nil))
+	_, err = c.Tg.Send(telegram.NewEditMessageText(chatID, sent.MessageID, text))
+	return err
+}
+
+// checks returns the checks of the database, each AI provider, the proxy,
+// the fetcher, yt-dlp and the Instagram session
+func (c *Command) checks() []check {
+	checks := []check{{name: "database", run: c.checkDatabase}}
+	for _, name := range c.ai.Providers() {
+		checks = append(checks, check{name: "ai provider " + name, run: func(ctx context.Context) error {
+			return c.checkProvider(ctx, name)
+		}})
+	}
+	return append(checks,
+		check{name: "proxy", run: c.checkProxy},
+		check{name: "fetcher", run: c.checkFetcher},
+		check{name: "yt-dlp", run: checkYtDlp},
+		check{name: "instagram", run: c.checkInstagram},
+	)
+}
+
+func (c *Command) checkDatabase(ctx context.Context) error {
+	return c.db.GetDB().PingContext(ctx)
+}
+
+func (c *Command) checkProvider(ctx context.Context, name string) error {
+	provider, err := c.ai.GetProvider(name)
+	if err != nil {
+		return err
+	}
+	models, err := provider.GetModels(ctx, false, true)
+	if err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		return errors.New("no models returned")
+	}
+	return nil
+}
+
+func (c *Command) checkProxy(ctx context.Context) error {
+	if len(c.Cfg.HTTP().GetProxies()) == 0 {
+		return errSkipped
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, proxyCheckURL, http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *Command) checkFetcher(_ context.Context) error {
+	request, err := fetcher.NewRequestPayload(fetchCheckURL, nil, nil)
+	if err != nil {
+		return err
+	}
+	response, err := c.fetcher.Fetch(request)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(response.GetText()) == "" {
+		return errors.New("empty page")
+	}
+	return nil
+}
+
+func checkYtDlp(ctx context.Context) error {
+	_, err := ytdlp.New().Version(ctx)
+	return err
+}
+
+func (c *Command) checkInstagram(_ context.Context) error {
+	if !c.Cfg.GetCommandConfig(instagramCommandName).Enabled {
+		return errSkipped
+	}
+	command, ok := c.commands()[instagramCommandName].(sessionChecker)
+	if !ok {
+		return errors.New("command is not initialized")
+	}
+	if !command.SessionValid() {
+		return errors.New("session is invalid")
+	}
+	return nil
+}
+
+// runChecks runs the checks concurrently, each one is limited by the timeout.
+// Results are in the order of the checks
+func runChecks(ctx context.Context, checks []check, timeout time.Duration) []result {
+	results := make([]result, len(checks))
+	var wg sync.WaitGroup
+	for i, item := range checks {
+		wg.Go(func() {
+			results[i] = runCheck(ctx, item, timeout)
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// runCheck stops waiting for the check after the timeout, checks without
+// context support keep running in the background
+func runCheck(ctx context.Context, item check, timeout time.Duration) result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- item.run(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timeout after %s", timeout)
+	}
+	return result{name: item.name, err: err, duration: time.Since(start)}
+}
+
+func formatResults(results []result, skippedText string) string {
+	lines := make([]string, 0, len(results))
+	for _, r := range results {
+		switch {
+		case errors.Is(r.err, errSkipped):
+			lines = append(lines, fmt.Sprintf("➖ %s: %s", r.name, skippedText))
+		case r.err != nil:
+			lines = append(lines, fmt.Sprintf("❌ %s: %v", r.name, r.err))
+		default:
+			lines = append(lines, fmt.Sprintf("✅ %s (%d ms)", r.name, r.duration.Milliseconds()))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,57 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunChecks(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	checks := []check{
+		{name: "ok", run: func(context.Context) error { return nil }},
+		{name: "failed", run: func(context.Context) error { return errors.New("boom") }},
+		{name: "skipped", run: func(context.Context) error { return errSkipped }},
+		{name: "context", run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		// ignores the context, the runner must stop waiting anyway
+		{name: "stuck", run: func(context.Context) error {
+			<-block
+			return nil
+		}},
+	}
+
+	start := time.Now()
+	results := runChecks(context.Background(), checks, 50*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second, "checks must run concurrently and time out")
+
+	require.Len(t, results, len(checks))
+	for i, r := range results {
+		assert.Equal(t, checks[i].name, r.name, "results keep the order of the checks")
+	}
+	assert.NoError(t, results[0].err)
+	assert.EqualError(t, results[1].err, "boom")
+	assert.ErrorIs(t, results[2].err, errSkipped)
+	assert.Error(t, results[3].err)
+	assert.ErrorContains(t, results[4].err, "timeout")
+}
+
+func TestFormatResults(t *testing.T) {
+	results := []result{
+		{name: "database", duration: 12 * time.Millisecond},
+		{name: "fetcher", err: errors.New("status 503")},
+		{name: "proxy", err: errSkipped},
+	}
+	assert.Equal(t,
+		"✅ database (12 ms)\n❌ fetcher: status 503\n➖ proxy: not configured",
+		formatResults(results, "not configured"),
+	)
+}
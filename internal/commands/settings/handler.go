@@ -0,0 +1,141 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/app/di"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const (
+	CommandName = "settings"
+
+	SettingFreeOnly = "freeonly"
+	SettingMemory   = "memory"
+)
+
+var ErrInvalidSetting = errors.New("invalid setting")
+
+// Command shows and changes the runtime settings of the chat
+type Command struct {
+	*base.Command
+}
+
+func New(di *di.Container) *Command {
+	cmd := &Command{}
+	cmd.Command = base.NewCommand(cmd, di)
+	return cmd
+}
+
+func (c *Command) Name() string {
+	return CommandName
+}
+
+func (c *Command) Execute(update telegram.Update) error {
+	if update.Message == nil {
+		return nil
+	}
+
+	args := strings.TrimSpace(strings.TrimPrefix(
+		update.Message.Text,
+		"/"+update.Message.Command(),
+	))
+	chatID := update.Message.Chat.ID
+
+	if args == "" {
+		return c.reply(update, c.Localizer.Localize("settings.current", map[string]any{
+			"FreeOnly": c.describeSwitch(c.ChatService.IsFreeOnly(chatID)),
+			"Memory":   c.describeSwitch(c.ChatService.IsMemoryEnabled(chatID)),
+		}))
+	}
+
+	setting, value, err := parseSettingArgs(args)
+	if err != nil {
+		return c.reply(update, c.Localizer.Localize("settings.usage", nil))
+	}
+	if !c.canChangeSettings(update.Message) {
+		return c.reply(update, c.Localizer.Localize("settings.notAllowed", nil))
+	}
+
+	var changed string
+	switch setting {
+	case SettingFreeOnly:
+		if err := c.ChatService.SetFreeOnly(chatID, value); err != nil {
+			c.Logger.WithError(err).WithField("chat_id", chatID).Error("Failed to save free only mode")
+			_ = c.reply(update, c.Localizer.Localize("settings.fail", nil))
+			return err
+		}
+		changed = c.Localizer.Localize("settings.freeOnly.changed", map[string]any{
+			"FreeOnly": c.describeSwitch(value),
+		})
+	case SettingMemory:
+		if err := c.ChatService.SetMemory(chatID, value); err != nil {
+			c.Logger.WithError(err).WithField("chat_id", chatID).Error("Failed to save memory mode")
+			_ = c.reply(update, c.Localizer.Localize("settings.fail", nil))
+			return err
+		}
+		changed = c.Localizer.Localize("settings.memory.changed", map[string]any{
+			"Memory": c.describeSwitch(value),
+		})
+	}
+	c.Logger.WithFields(logger.Fields{
+		"chat_id": chatID,
+		"user_id": update.Message.From.ID,
+		"setting": setting,
+		"value":   value,
+	}).Info("Chat setting changed")
+
+	return c.reply(update, changed)
+}
+
+// canChangeSettings allows changes to the users from telegram.allowed_users,
+// to the user of a private chat and to administrators of a group
+func (c *Command) canChangeSettings(msg *telegram.MessageOriginal) bool {
+	if msg.From == nil {
+		return false
+	}
+	if c.Cfg.Telegram().IsUserAllowed(msg.From.ID) || msg.Chat.IsPrivate() {
+		return true
+	}
+	resp, err := c.Tg.RequestRaw(telegram.NewGetChatMember(msg.Chat.ID, msg.From.ID))
+	if err != nil {
+		c.Logger.WithError(err).WithField("chat_id", msg.Chat.ID).Warn("Failed to get chat member")
+		return false
+	}
+	var member telegram.ChatMember
+	if err := json.Unmarshal(resp.Result, &member); err != nil {
+		return false
+	}
+	return member.IsCreator() || member.IsAdministrator()
+}
+
+func (c *Command) describeSwitch(on bool) string {
+	if on {
+		return c.Localizer.Localize("settings.on", nil)
+	}
+	return c.Localizer.Localize("settings.off", nil)
+}
+
+func (c *Command) reply(update telegram.Update, text string) error {
+	_, err := c.Tg.Send(telegram.NewMessage(update.Message.Chat.ID, text, update.Message.MessageID))
+	return err
+}
+
+// parseSettingArgs parses "<setting> <on|off>", e.g. "freeonly on"
+func parseSettingArgs(args string) (string, bool, error) {
+	fields := strings.Fields(strings.ToLower(args))
+	if len(fields) != 2 || (fields[0] != SettingFreeOnly && fields[0] != SettingMemory) {
+		return "", false, ErrInvalidSetting
+	}
+	switch fields[1] {
+	case "on", "yes":
+		return fields[0], true, nil
+	case "off", "no":
+		return fields[0], false, nil
+	}
+	return "", false, ErrInvalidSetting
+}
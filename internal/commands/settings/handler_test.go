@@ -0,0 +1,38 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSettingArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		setting string
+		value   bool
+	}{
+		{"freeonly on", SettingFreeOnly, true},
+		{" FreeOnly  OFF ", SettingFreeOnly, false},
+		{"freeonly yes", SettingFreeOnly, true},
+		{"freeonly no", SettingFreeOnly, false},
+		{"memory on", SettingMemory, true},
+		{"Memory off", SettingMemory, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			setting, value, err := parseSettingArgs(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.setting, setting)
+			assert.Equal(t, tt.value, value)
+		})
+	}
+
+	for _, args := range []string{"freeonly", "freeonly maybe", "stream on", "freeonly on now", "memory"} {
+		t.Run("invalid "+args, func(t *testing.T) {
+			_, _, err := parseSettingArgs(args)
+			assert.ErrorIs(t, err, ErrInvalidSetting)
+		})
+	}
+}
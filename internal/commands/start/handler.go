@@ -2,20 +2,32 @@ package start
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/muratoffalex/gachigazer/internal/app/di"
 	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/markdown"
 	"github.com/muratoffalex/gachigazer/internal/telegram"
 )
 
 const CommandName = "start"
 
+type onboardingStore interface {
+	MarkUserOnboarded(userID, chatID int64) (bool, error)
+}
+
 type Command struct {
 	*base.Command
+	db     onboardingStore
+	cmdCfg *config.StartCommandConfig
 }
 
 func New(di *di.Container) *Command {
-	cmd := &Command{}
+	cmd := &Command{
+		db:     di.DB,
+		cmdCfg: di.Cfg.GetStartCommandConfig(),
+	}
 	cmd.Command = base.NewCommand(cmd, di)
 	return cmd
 }
@@ -25,13 +37,18 @@ func (c *Command) Name() string {
 }
 
 func (c *Command) Execute(update telegram.Update) error {
+	text := fmt.Sprintf(
+		"User ID: `%d`\nChat ID: `%d`",
+		update.Message.From.ID,
+		update.Message.Chat.ID,
+	)
+	if c.shouldOnboard(update.Message.From.ID, update.Message.Chat.ID) {
+		text = c.onboardingText(update.Message.From.FirstName) + "\n\n" + text
+	}
+
 	msg := telegram.NewMessage(
 		update.Message.Chat.ID,
-		fmt.Sprintf(
-			"User ID: `%d`\nChat ID: `%d`",
-			update.Message.From.ID,
-			update.Message.Chat.ID,
-		),
+		text,
 		update.Message.MessageID,
 	)
 	msg.ParseMode = telegram.ModeMarkdownV2
@@ -44,3 +61,44 @@ func (c *Command) Execute(update telegram.Update) error {
 
 	return nil
 }
+
+// shouldOnboard marks the user as onboarded and reports whether it happened for the first time
+func (c *Command) shouldOnboard(userID, chatID int64) bool {
+	if !c.cmdCfg.Onboarding {
+		return false
+	}
+	first, err := c.db.MarkUserOnboarded(userID, chatID)
+	if err != nil {
+		c.Logger.WithError(err).Warn("Failed to mark user as onboarded")
+		return false
+	}
+	return first
+}
+
+// onboardingText builds a short capability overview from config, unlike /help it doesn't use AI
+func (c *Command) onboardingText(name string) string {
+	aiCfg := c.Cfg.AI()
+
+	greeting := c.cmdCfg.Greeting
+	if greeting == "" {
+		greeting = c.L("start.greeting", map[string]any{"Name": name})
+	}
+
+	aliases := make([]string, 0, len(aiCfg.Aliases))
+	for _, alias := range aiCfg.Aliases {
+		aliases = append(aliases, "`"+alias.Alias+"`")
+	}
+
+	prompts := make([]string, 0, len(aiCfg.Prompts))
+	for _, command := range aiCfg.GetAllCommands() {
+		prompts = append(prompts, "/"+command)
+	}
+
+	return c.L("start.onboarding", map[string]any{
+		"Greeting":     markdown.Escape(greeting),
+		"DefaultModel": aiCfg.GetDefaultModel(),
+		"Aliases":      strings.Join(aliases, ", "),
+		"Prompts":      markdown.Escape(strings.Join(prompts, " ")),
+		"Tools":        c.Cfg.GetAskCommandConfig().Tools.Enabled,
+	})
+}
@@ -0,0 +1,123 @@
+package start
+
+import (
+	"errors"
+	"testing"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/service"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCommand(t *testing.T, tg telegram.Client, db onboardingStore, onboarding bool) *Command {
+	t.Setenv("GACHIGAZER_TELEGRAM_TOKEN", "token")
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	localizer, err := service.NewLocalizer("en")
+	require.NoError(t, err)
+
+	cmdCfg := cfg.GetStartCommandConfig()
+	cmdCfg.Onboarding = onboarding
+	return &Command{
+		Command: &base.Command{
+			Tg:        tg,
+			Logger:    logger.NewTestLogger(),
+			Cfg:       cfg,
+			Localizer: localizer,
+		},
+		db:     db,
+		cmdCfg: cmdCfg,
+	}
+}
+
+func newStartUpdate() telegram.Update {
+	return telegram.Update{
+		Message: &tgbotapi.Message{
+			MessageID: 42,
+			From:      &tgbotapi.User{ID: 1, FirstName: "Alice"},
+			Chat:      tgbotapi.Chat{ID: 100},
+		},
+	}
+}
+
+func sentText(tg *telegram.MockClient, text *string) {
+	tg.EXPECT().
+		Send(mock.Anything).
+		Run(func(msg telegram.MessageConfig) {
+			*text = msg.(telegram.TextMessage).Text
+		}).
+		Return(&telegram.Message{}, nil).
+		Once()
+}
+
+func TestCommand_Execute_Onboarding(t *testing.T) {
+	t.Run("first start shows onboarding", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+		db := newMockOnboardingStore(t)
+		db.EXPECT().MarkUserOnboarded(int64(1), int64(100)).Return(true, nil).Once()
+		var text string
+		sentText(tg, &text)
+
+		cmd := newTestCommand(t, tg, db, true)
+		require.NoError(t, cmd.Execute(newStartUpdate()))
+
+		assert.Contains(t, text, "Hi, Alice\\!")
+		assert.Contains(t, text, "*How to ask*")
+		assert.Contains(t, text, "User ID: `1`\nChat ID: `100`")
+	})
+
+	t.Run("repeated start shows only ids", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+		db := newMockOnboardingStore(t)
+		db.EXPECT().MarkUserOnboarded(int64(1), int64(100)).Return(false, nil).Once()
+		var text string
+		sentText(tg, &text)
+
+		cmd := newTestCommand(t, tg, db, true)
+		require.NoError(t, cmd.Execute(newStartUpdate()))
+
+		assert.Equal(t, "User ID: `1`\nChat ID: `100`", text)
+	})
+
+	t.Run("store error skips onboarding", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+		db := newMockOnboardingStore(t)
+		db.EXPECT().MarkUserOnboarded(int64(1), int64(100)).Return(false, errors.New("database is locked")).Once()
+		var text string
+		sentText(tg, &text)
+
+		cmd := newTestCommand(t, tg, db, true)
+		require.NoError(t, cmd.Execute(newStartUpdate()))
+
+		assert.Equal(t, "User ID: `1`\nChat ID: `100`", text)
+	})
+
+	t.Run("disabled onboarding doesn't touch store", func(t *testing.T) {
+		tg := telegram.NewMockClient(t)
+		db := newMockOnboardingStore(t)
+		var text string
+		sentText(tg, &text)
+
+		cmd := newTestCommand(t, tg, db, false)
+		require.NoError(t, cmd.Execute(newStartUpdate()))
+
+		assert.Equal(t, "User ID: `1`\nChat ID: `100`", text)
+		db.AssertNotCalled(t, "MarkUserOnboarded", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCommand_onboardingText_CustomGreeting(t *testing.T) {
+	cmd := newTestCommand(t, telegram.NewMockClient(t), newMockOnboardingStore(t), true)
+	cmd.cmdCfg.Greeting = "Welcome to our chat."
+
+	text := cmd.onboardingText("Alice")
+
+	assert.Contains(t, text, "Welcome to our chat\\.")
+	assert.NotContains(t, text, "Alice")
+}
@@ -0,0 +1,198 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/muratoffalex/gachigazer/internal/app/di"
+	"github.com/muratoffalex/gachigazer/internal/commands/base"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/muratoffalex/gachigazer/internal/fetcher"
+	"github.com/muratoffalex/gachigazer/internal/service"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const (
+	CommandName = "stats"
+
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	PeriodAll   = "all"
+
+	// rows of each table, the rest is summed into one row
+	maxRows = 10
+	// long model names break the table on phones
+	maxKeyLength = 28
+)
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+var periods = map[string]time.Duration{
+	PeriodDay:   24 * time.Hour,
+	PeriodWeek:  7 * 24 * time.Hour,
+	PeriodMonth: 30 * 24 * time.Hour,
+	PeriodAll:   0,
+}
+
+// Command shows the usage of the bot in the chat: answers, tokens and cost by users and models
+type Command struct {
+	*base.Command
+	db database.Database
+}
+
+func New(di *di.Container) *Command {
+	cmd := &Command{
+		db: di.DB,
+	}
+	cmd.Command = base.NewCommand(cmd, di)
+	return cmd
+}
+
+func (c *Command) Name() string {
+	return CommandName
+}
+
+func (c *Command) Execute(update telegram.Update) error {
+	if update.Message == nil {
+		return nil
+	}
+
+	args := strings.TrimSpace(strings.TrimPrefix(
+		update.Message.Text,
+		"/"+update.Message.Command(),
+	))
+	chatID := update.Message.Chat.ID
+
+	if !update.Message.Chat.IsPrivate() && !c.Cfg.Telegram().IsUserAllowed(update.Message.From.ID) {
+		return c.reply(update, c.Tg.EscapeText(c.Localizer.Localize("stats.notAllowed", nil)))
+	}
+
+	period, duration, err := parsePeriod(args)
+	if err != nil {
+		return c.reply(update, c.Tg.EscapeText(c.Localizer.Localize("stats.usage", nil)))
+	}
+
+	byUser, err := c.db.GetChatUsageByUser(chatID, duration)
+	if err == nil {
+		var byModel []database.UsageStats
+		byModel, err = c.db.GetChatUsageByModel(chatID, duration)
+		if err == nil {
+			return c.sendStats(update, period, byUser, byModel)
+		}
+	}
+	c.Logger.WithError(err).WithField("chat_id", chatID).Error("Failed to get chat usage")
+	_ = c.reply(update, c.Tg.EscapeText(c.Localizer.Localize("stats.fail", nil)))
+	return err
+}
+
+func (c *Command) sendStats(update telegram.Update, period string, byUser, byModel []database.UsageStats) error {
+	title := c.Localizer.Localize("stats.title", map[string]any{
+		"Period": c.Localizer.Localize("stats.period."+period, nil),
+	})
+	if len(byUser) == 0 {
+		return c.reply(update, c.Tg.EscapeText(title+"\n\n"+c.Localizer.Localize("stats.empty", nil)))
+	}
+
+	currency := c.ChatService.GetChatCurrency(update.Message.Chat.ID)
+	text := []string{
+		c.Tg.EscapeText(title),
+		c.Tg.EscapeText(c.Localizer.Localize("stats.byUser", nil)),
+		codeBlock(formatUsageTable(byUser, c.Localizer.Localize("stats.total", nil), &currency)),
+		c.Tg.EscapeText(c.Localizer.Localize("stats.byModel", nil)),
+		codeBlock(formatUsageTable(byModel, c.Localizer.Localize("stats.total", nil), &currency)),
+	}
+	return c.reply(update, strings.Join(text, "\n"))
+}
+
+func (c *Command) reply(update telegram.Update, text string) error {
+	msg := telegram.NewMessage(update.Message.Chat.ID, text, update.Message.MessageID)
+	msg.ParseMode = telegram.ModeMarkdownV2
+	_, err := c.Tg.Send(msg)
+	return err
+}
+
+// parsePeriod returns the period name and duration, week by default
+func parsePeriod(args string) (string, time.Duration, error) {
+	period := strings.ToLower(strings.TrimSpace(args))
+	if period == "" {
+		period = PeriodWeek
+	}
+	duration, ok := periods[period]
+	if !ok {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidPeriod, args)
+	}
+	return period, duration, nil
+}
+
+// formatUsageTable renders the stats as a table with aligned columns: name, answers,
+// tokens and cost. Rows after maxRows are summed into one row, the total is the last row
+func formatUsageTable(stats []database.UsageStats, totalName string, currency *config.CurrencyConfig) string {
+	var total, rest database.UsageStats
+	rows := [][]string{{"", "#", "tokens", "cost"}}
+	for i, item := range stats {
+		total.Messages += item.Messages
+		total.Tokens += item.Tokens
+		total.Cost += item.Cost
+		if i >= maxRows {
+			rest.Key = fmt.Sprintf("+%d", len(stats)-maxRows)
+			rest.Messages += item.Messages
+			rest.Tokens += item.Tokens
+			rest.Cost += item.Cost
+			continue
+		}
+		rows = append(rows, usageRow(item, currency))
+	}
+	if rest.Key != "" {
+		rows = append(rows, usageRow(rest, currency))
+	}
+	if len(stats) > 1 {
+		total.Key = totalName
+		rows = append(rows, usageRow(total, currency))
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			padding := strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+			if j == 0 {
+				cells[j] = cell + padding
+			} else {
+				cells[j] = padding + cell
+			}
+		}
+		lines[i] = strings.TrimRight(strings.Join(cells, " "), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func usageRow(item database.UsageStats, currency *config.CurrencyConfig) []string {
+	key := item.Key
+	if key == "" {
+		key = "?"
+	}
+	if runes := []rune(key); len(runes) > maxKeyLength {
+		key = string(runes[:maxKeyLength-1]) + "…"
+	}
+	return []string{
+		key,
+		fmt.Sprint(item.Messages),
+		fetcher.FormatCount(float64(item.Tokens)),
+		service.FormatCost(item.Cost, currency),
+	}
+}
+
+func codeBlock(text string) string {
+	return "```\n" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(text) + "\n```"
+}
@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		args         string
+		wantPeriod   string
+		wantDuration time.Duration
+		wantErr      bool
+	}{
+		{"", PeriodWeek, 7 * 24 * time.Hour, false},
+		{"day", PeriodDay, 24 * time.Hour, false},
+		{" Month ", PeriodMonth, 30 * 24 * time.Hour, false},
+		{"all", PeriodAll, 0, false},
+		{"year", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			period, duration, err := parsePeriod(tt.args)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPeriod)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPeriod, period)
+			assert.Equal(t, tt.wantDuration, duration)
+		})
+	}
+}
+
+func TestFormatUsageTable(t *testing.T) {
+	currency := &config.CurrencyConfig{Precision: 2}
+
+	t.Run("with total", func(t *testing.T) {
+		stats := []database.UsageStats{
+			{Key: "alice", Messages: 12, Tokens: 15300, Cost: 0.5},
+			{Key: "", Messages: 3, Tokens: 900, Cost: 0.01},
+		}
+		want := strings.Join([]string{
+			"       # tokens  cost",
+			"alice 12    15K $0.50",
+			"?      3    900 $0.01",
+			"total 15    16K $0.51",
+		}, "\n")
+		assert.Equal(t, want, formatUsageTable(stats, "total", currency))
+	})
+
+	t.Run("single row has no total", func(t *testing.T) {
+		stats := []database.UsageStats{{Key: "openai/gpt-4o-mini", Messages: 1, Tokens: 10, Cost: 0}}
+		want := "                   # tokens  cost\nopenai/gpt-4o-mini 1     10 $0.00"
+		assert.Equal(t, want, formatUsageTable(stats, "total", currency))
+	})
+
+	t.Run("rest rows are summed", func(t *testing.T) {
+		stats := make([]database.UsageStats, maxRows+2)
+		for i := range stats {
+			stats[i] = database.UsageStats{Key: fmt.Sprintf("user%d", i), Messages: 1, Tokens: 1, Cost: 0.01}
+		}
+		lines := strings.Split(formatUsageTable(stats, "total", currency), "\n")
+		require.Len(t, lines, maxRows+3)
+		assert.Equal(t, "+2     2      2 $0.02", lines[len(lines)-2])
+		assert.Equal(t, "total 12     12 $0.12", lines[len(lines)-1])
+	})
+
+	t.Run("long key is truncated", func(t *testing.T) {
+		stats := []database.UsageStats{{Key: strings.Repeat("a", maxKeyLength+5), Messages: 1}}
+		row := strings.Split(formatUsageTable(stats, "total", currency), "\n")[1]
+		assert.True(t, strings.HasPrefix(row, strings.Repeat("a", maxKeyLength-1)+"… "))
+	})
+}
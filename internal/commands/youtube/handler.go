@@ -23,7 +23,13 @@ import (
 	"github.com/muratoffalex/gachigazer/internal/telegram"
 )
 
-const CommandName = "youtube"
+const (
+	CommandName = "youtube"
+	// audioArgument switches the command to downloading only the audio track
+	audioArgument = "audio"
+	// audio container that is played inline by telegram clients
+	audioExtension = "m4a"
+)
 
 type Command struct {
 	*base.Command
@@ -68,6 +74,11 @@ func (c *Command) Execute(update telegram.Update) error {
 
 	chatID := update.Message.Chat.ID
 	messageID := update.Message.MessageID
+	audioOnly := isAudioMode(update.Message.CommandArguments())
+	keys := videoLocaleKeys
+	if audioOnly {
+		keys = audioLocaleKeys
+	}
 	url := ""
 	if len(urls) > 0 {
 		url = urls[0]
@@ -95,10 +106,17 @@ func (c *Command) Execute(update telegram.Update) error {
 		WriteComments()
 		// SkipDownload() // interesting option, so I can download all data separately, check the file size, and then download only it
 
-	if isYouTubeURL(url) {
+	_, err = exec.LookPath("ffmpeg")
+	hasFFmpeg := err == nil
+	if audioOnly {
+		dl.Format(audioFormat(c.Cfg.YtDlp().MaxSize, hasFFmpeg))
+		if hasFFmpeg {
+			dl.ExtractAudio().AudioFormat(audioExtension)
+		}
+	} else if isYouTubeURL(url) {
 		var format string
 		maxSizeStr := c.Cfg.YtDlp().MaxSize
-		if _, err := exec.LookPath("ffmpeg"); err == nil {
+		if hasFFmpeg {
 			// good quality, small size
 			format = fmt.Sprintf("bv*[vcodec!^=hev1][vcodec!^=av01][height<=1920][filesize<%s][ext=mp4]+ba[filesize<10M]/bv*[height<=1920][filesize<%s][ext=mp4]+ba[filesize<10M]", maxSizeStr, maxSizeStr)
 		} else {
@@ -113,7 +131,7 @@ func (c *Command) Execute(update telegram.Update) error {
 
 	startMessage := telegram.NewMessage(
 		chatID,
-		c.Localizer.Localize("youtube.download.start", map[string]any{
+		c.Localizer.Localize(keys.download, map[string]any{
 			"Info": "",
 		}),
 		messageID,
@@ -136,7 +154,7 @@ func (c *Command) Execute(update telegram.Update) error {
 		downloaded := formatFileSize(int64(update.DownloadedBytes))
 		total := formatFileSize(int64(update.TotalBytes))
 		info := fmt.Sprintf("(%s / %s)", downloaded, total)
-		text := c.Localizer.Localize("youtube.download.start", map[string]any{
+		text := c.Localizer.Localize(keys.download, map[string]any{
 			"Info": info,
 		})
 		c.Logger.WithFields(logger.Fields{
@@ -166,7 +184,7 @@ func (c *Command) Execute(update telegram.Update) error {
 				startMessageID,
 				messageID,
 				errors.New(
-					c.Localizer.Localize("youtube.fileTooBig", map[string]any{
+					c.Localizer.Localize(keys.tooBig, map[string]any{
 						"Size":    markdown.Escape(formatFileSize(fileSize)),
 						"MaxSize": strings.TrimSpace(markdown.Escape(c.Cfg.YtDlp().MaxSize)),
 					}),
@@ -212,7 +230,12 @@ func (c *Command) Execute(update telegram.Update) error {
 	}
 
 	file := files[0]
-	filePath := fmt.Sprintf("%s%s.%s", tempDirectory, file.ID, file.Extension)
+	extension := file.Extension
+	if audioOnly && hasFFmpeg {
+		// the info is printed before the audio is extracted
+		extension = audioExtension
+	}
+	filePath := fmt.Sprintf("%s%s.%s", tempDirectory, file.ID, extension)
 
 	defer func() {
 		if err := os.Remove(filePath); err != nil {
@@ -357,7 +380,7 @@ func (c *Command) Execute(update telegram.Update) error {
 	maxSize, err := parseSize(c.Cfg.YtDlp().MaxSize)
 	mediaTooLarge := err == nil && maxSize > 0 && fileSize > 0 && fileSize > maxSize
 
-	text = c.Localizer.Localize("youtube.uploadVideoInfo", map[string]any{
+	text = c.Localizer.Localize(keys.upload, map[string]any{
 		"FileSize": fileSizeStr,
 	})
 	editedMessage := telegram.NewEditMessageText(
@@ -374,14 +397,7 @@ func (c *Command) Execute(update telegram.Update) error {
 	if !mediaTooLarge {
 		if captionTooLarge {
 			// multi message answer
-			videoMessage := telegram.NewVideoMessage(
-				update.Message.Chat.ID,
-				tgbotapi.FilePath(filePath),
-				"",
-				update.Message.MessageID,
-			)
-			videoMessage.ParseMode = telegram.ModeMarkdownV2
-			videoMsg, err := c.Tg.Send(videoMessage)
+			videoMsg, err := c.Tg.Send(newMediaMessage(chatID, filePath, "", messageID, file, audioOnly))
 			if err != nil {
 				return c.handleError(
 					chatID,
@@ -389,7 +405,7 @@ func (c *Command) Execute(update telegram.Update) error {
 					messageID,
 					fmt.Errorf(
 						"%s\n%s",
-						markdown.Escape(c.L("youtube.failedToSendVideo", nil)),
+						markdown.Escape(c.L(keys.failedToSend, nil)),
 						caption,
 					),
 					true,
@@ -406,17 +422,10 @@ func (c *Command) Execute(update telegram.Update) error {
 			outputMessage = textMessage.ToChattable()
 		} else {
 			// single message
-			message := telegram.NewVideoMessage(
-				update.Message.Chat.ID,
-				tgbotapi.FilePath(filePath),
-				caption,
-				update.Message.MessageID,
-			)
-			message.ParseMode = telegram.ModeMarkdownV2
-			outputMessage = message.ToChattable()
+			outputMessage = newMediaMessage(chatID, filePath, caption, messageID, file, audioOnly).ToChattable()
 		}
 	} else {
-		caption = fmt.Sprintf("%s\n\n%s", c.Localizer.Localize("youtube.fileTooBig", map[string]any{
+		caption = fmt.Sprintf("%s\n\n%s", c.Localizer.Localize(keys.tooBig, map[string]any{
 			"Size":    c.Tg.EscapeText(fileSizeStr),
 			"MaxSize": c.Cfg.YtDlp().MaxSize,
 		}), caption)
@@ -426,7 +435,11 @@ func (c *Command) Execute(update telegram.Update) error {
 		outputMessage = message.ToChattable()
 	}
 
-	c.Tg.SendChatAction(chatID, telegram.ActionUploadVideo)
+	action := telegram.ActionUploadVideo
+	if audioOnly {
+		action = telegram.ActionUploadDocument
+	}
+	c.Tg.SendChatAction(chatID, action)
 	if _, err := c.Tg.RequestRaw(outputMessage); err != nil {
 		return c.handleError(
 			chatID,
@@ -434,7 +447,7 @@ func (c *Command) Execute(update telegram.Update) error {
 			messageID,
 			fmt.Errorf(
 				"%s\n%s",
-				markdown.Escape(c.L("youtube.failedToSendVideo", nil)),
+				markdown.Escape(c.L(keys.failedToSend, nil)),
 				caption,
 			),
 			true,
@@ -481,6 +494,69 @@ func (c *Command) handleError(chatID int64, startMessageID int, messageID int, o
 	return orErr
 }
 
+type localeKeys struct {
+	download     string
+	upload       string
+	tooBig       string
+	failedToSend string
+}
+
+var (
+	videoLocaleKeys = localeKeys{
+		download:     "youtube.download.start",
+		upload:       "youtube.uploadVideoInfo",
+		tooBig:       "youtube.fileTooBig",
+		failedToSend: "youtube.failedToSendVideo",
+	}
+	audioLocaleKeys = localeKeys{
+		download:     "youtube.download.audio",
+		upload:       "youtube.uploadAudioInfo",
+		tooBig:       "youtube.audioTooBig",
+		failedToSend: "youtube.failedToSendAudio",
+	}
+)
+
+// isAudioMode reports whether the command arguments start with "audio", e.g. /video audio <link>
+func isAudioMode(args string) bool {
+	fields := strings.Fields(args)
+	return len(fields) > 0 && strings.EqualFold(fields[0], audioArgument)
+}
+
+// audioFormat returns the yt-dlp format for the audio only mode. With ffmpeg
+// any audio is converted to m4a, without it only formats telegram can play
+// are picked. Formats without a known size are checked in ProgressFunc
+func audioFormat(maxSize string, hasFFmpeg bool) string {
+	if hasFFmpeg {
+		return fmt.Sprintf("ba[filesize<%s]/ba/b[filesize<%s]", maxSize, maxSize)
+	}
+	return fmt.Sprintf("ba[ext=m4a][filesize<%s]/ba[ext=mp3][filesize<%s]/ba[ext=m4a]/ba[ext=mp3]", maxSize, maxSize)
+}
+
+func newMediaMessage(chatID int64, filePath, caption string, replyTo int, file *ytdlp.ExtractedInfo, audioOnly bool) telegram.MessageConfig {
+	if !audioOnly {
+		message := telegram.NewVideoMessage(chatID, tgbotapi.FilePath(filePath), caption, replyTo)
+		message.ParseMode = telegram.ModeMarkdownV2
+		return message
+	}
+
+	message := telegram.NewAudioMessage(chatID, tgbotapi.FilePath(filePath), caption, replyTo)
+	message.ParseMode = telegram.ModeMarkdownV2
+	if file.Track != nil && *file.Track != "" {
+		message.Title = *file.Track
+	} else if file.Title != nil {
+		message.Title = *file.Title
+	}
+	if file.Artist != nil && *file.Artist != "" {
+		message.Performer = *file.Artist
+	} else if file.Uploader != nil {
+		message.Performer = *file.Uploader
+	}
+	if file.Duration != nil {
+		message.Duration = int(*file.Duration)
+	}
+	return message
+}
+
 func formatFileSize(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
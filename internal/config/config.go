@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -20,14 +21,18 @@ import (
 
 const (
 	globalMessageRetentionDays      = "global.message_retention_days"
+	globalConversationRetentionDays = "global.conversation_retention_days"
+	globalConversationCleanup       = "global.conversation_cleanup_interval"
 	globalLanguage                  = "global.interface_language"
 	globalFixInstagramPreviews      = "global.fix_instagram_previews"
 	globalFixXPreviews              = "global.fix_x_previews"
 	currencyCode                    = "currency.code"
 	currencySymbol                  = "currency.symbol"
 	currencyPrecision               = "currency.precision"
+	currencyRate                    = "currency.rate"
 	httpProxy                       = "http.proxy"
 	httpNoProxy                     = "http.no_proxy"
+	httpProxyRotation               = "http.proxy_rotation"
 	aiSystemPrompt                  = "ai.system_prompt"
 	aiUseStream                     = "ai.use_stream"
 	aiLanguage                      = "ai.language"
@@ -36,13 +41,22 @@ const (
 	aiMaxTokens                     = "ai.model_params.max_tokens"
 	aiMaxImagesInContext            = "ai.max_images_in_context"
 	aiUseMultimodalAuto             = "ai.use_multimodal_auto"
+	aiDailyCostLimit                = "ai.daily_cost_limit"
+	aiTTSModel                      = "ai.tts_model"
+	aiTTSVoice                      = "ai.tts_voice"
 	telegramToken                   = "telegram.token"
 	telegramTdEnabled               = "telegram.td_enabled"
 	telegramSessionPath             = "telegram.session_path"
+	telegramWebhookEnabled          = "telegram.webhook.enabled"
+	telegramWebhookListenAddr       = "telegram.webhook.listen_addr"
 	instagramUsername               = "instagram.username"
 	instagramPassword               = "instagram.password"
 	instagramSessionPath            = "instagram.session_path"
 	instagramSessionRefreshInterval = "instagram.session_refresh_interval"
+	twitchClientID                  = "twitch.client_id"
+	twitchClientSecret              = "twitch.client_secret"
+	xInstance                       = "x.instance"
+	xTimeout                        = "x.timeout"
 	chromeEnabled                   = "chrome.enabled"
 	chromePath                      = "chrome.path"
 	chromeOpts                      = "chrome.opts"
@@ -53,8 +67,17 @@ const (
 	loggingLevel                    = "logging.level"
 	loggingWriteInFile              = "logging.write_in_file"
 	loggingFilePath                 = "logging.file_path"
+	loggingRedactPatterns           = "logging.redact_patterns"
+	metricsEnabled                  = "metrics.enabled"
+	metricsAddr                     = "metrics.addr"
 )
 
+var defaultLogRedactPatterns = []string{
+	`(?i)bearer\s+[\w\-.~+/]+=*`,                  // bearer tokens
+	`[\w.+\-]+@[\w\-]+(\.[\w\-]+)*\.[a-zA-Z]{2,}`, // emails
+	`\bsk-[\w\-]{16,}`,                            // openai/openrouter/anthropic style API keys
+}
+
 var defaultSQLiteParams = map[string]string{
 	"_journal":      "WAL",
 	"_busy_timeout": "10000",
@@ -78,6 +101,7 @@ func Load() (*Config, error) {
 
 	defaults := map[string]any{
 		globalMessageRetentionDays: 1,
+		globalConversationCleanup:  "1h",
 		globalLanguage:             "en",
 		globalFixInstagramPreviews: true,
 		globalFixXPreviews:         true,
@@ -85,12 +109,19 @@ func Load() (*Config, error) {
 		telegramToken:              "",
 		telegramTdEnabled:          false,
 		telegramSessionPath:        "tg_session.json",
+		telegramWebhookEnabled:     false,
+		telegramWebhookListenAddr:  ":8443",
 		httpProxy:                  nil,
 		httpNoProxy:                []string{"localhost", "127.0.0.1"},
+		httpProxyRotation:          ProxyRotationRoundRobin,
 		instagramSessionPath:       "instagram_session.json",
+		xTimeout:                   10 * time.Second,
 		databaseDsn:                "bot.db?_journal=WAL&_busy_timeout=5000&_synchronous=NORMAL&_cache=shared",
 		loggingLevel:               "info",
 		loggingWriteInFile:         false,
+		loggingRedactPatterns:      defaultLogRedactPatterns,
+		metricsEnabled:             false,
+		metricsAddr:                ":9090",
 		ytdlpMaxSize:               "50M", // max size for normal bots without special permission
 		ytdlpTempDirectory:         "",
 		ytdlpDownloadURL:           "", // Leave empty to use GitHub + auto-detected os/arch.
@@ -102,6 +133,9 @@ func Load() (*Config, error) {
 		aiUtilityModel:             "",
 		aiMultimodalModel:          "",
 		aiUseMultimodalAuto:        false,
+		aiDailyCostLimit:           0.0,
+		aiTTSModel:                 "",
+		aiTTSVoice:                 "alloy",
 		chromeEnabled:              false,
 		chromePath:                 getDefaultChromePath(),
 		chromeOpts: []string{
@@ -120,6 +154,7 @@ func Load() (*Config, error) {
 		"commands.instagram.queue.session_refresh_interval": 12 * time.Hour,
 		"commands.start.enabled":                            true,
 		"commands.start.queue.enabled":                      false,
+		"commands.start.onboarding":                         true,
 		"commands.r.enabled":                                false,
 		"commands.r.queue.enabled":                          true,
 		"commands.r.queue.max_retries":                      3,
@@ -133,6 +168,18 @@ func Load() (*Config, error) {
 		"commands.youtube.queue.throttle.period":            30 * time.Second,
 		"commands.youtube.queue.throttle.requests":          3,
 		"commands.youtube.queue.throttle.concurrency":       3,
+		"commands.currency.enabled":                         true,
+		"commands.currency.queue.enabled":                   false,
+		"commands.setabout.enabled":                         true,
+		"commands.setabout.queue.enabled":                   false,
+		"commands.stats.enabled":                            true,
+		"commands.stats.queue.enabled":                      false,
+		"commands.settings.enabled":                         true,
+		"commands.settings.queue.enabled":                   false,
+		"commands.memory.enabled":                           true,
+		"commands.memory.queue.enabled":                     false,
+		"commands.selftest.enabled":                         true,
+		"commands.selftest.queue.enabled":                   false,
 		"commands.model.enabled":                            true,
 		"commands.model.queue.enabled":                      true,
 		"commands.model.queue.max_retries":                  0,
@@ -141,19 +188,33 @@ func Load() (*Config, error) {
 		"commands.ask.generate_title_with_ai":               false,
 		"commands.ask.max_context_turns":                    30,
 		"commands.ask.fetcher.enabled":                      true,
+		"commands.ask.fetcher.concurrency":                  4,
 		"commands.ask.audio.enabled":                        true,
 		"commands.ask.audio.max_in_history":                 0,
 		"commands.ask.audio.max_size":                       2000,    // 2mb
 		"commands.ask.audio.max_duration":                   60 * 10, // 10 min
 		"commands.ask.files.enabled":                        true,
+		"commands.ask.files.text_extensions":                []string{".txt", ".md", ".csv", ".json", ".log", ".yaml", ".yml", ".toml", ".xml", ".html", ".sql", ".go", ".py", ".js", ".ts", ".java", ".c", ".cpp", ".rs", ".sh"},
+		"commands.ask.files.max_text_size":                  1000, // 1mb
+		"commands.ask.files.max_text_length":                50000,
 		"commands.ask.images.enabled":                       true,
 		"commands.ask.images.max":                           5,
 		"commands.ask.images.lifetime":                      0 * time.Minute,
 		"commands.ask.images.preprocess_with_multimodal":    false,
 		"commands.ask.images.preprocess_prompt":             "Describe this image in detail",
+		"commands.ask.images.max_dimension":                 1536,
+		"commands.ask.images.ocr_enabled":                   false,
+		"commands.ask.images.ocr_model":                     "",
 		"commands.ask.tools.enabled":                        true,
 		"commands.ask.tools.auto_run":                       false,
 		"commands.ask.tools.max_iterations":                 2,
+		"commands.ask.tools.max_pending_reminders":          5,
+		"commands.ask.tools.image_document_min_size":        1280,
+		"commands.ask.tools.convert_rates_url":              "https://cdn.jsdelivr.net/npm/@fawazahmed0/currency-api@latest/v1/currencies/usd.min.json",
+		"commands.ask.tools.max_buttons":                    8,
+		"commands.ask.tools.timeout":                        30 * time.Second,
+		"commands.ask.tools.total_timeout":                  2 * time.Minute,
+		"commands.ask.tools.max_response_length":            30000,
 		"commands.ask.queue.enabled":                        true,
 		"commands.ask.queue.timeout":                        2 * time.Minute,
 		"commands.ask.queue.max_retries":                    0,
@@ -161,9 +222,38 @@ func Load() (*Config, error) {
 		"commands.ask.queue.throttle.concurrency":           2,
 		"commands.ask.queue.throttle.requests":              2,
 		"commands.ask.display.metadata":                     true,
+		"commands.ask.display.metadata_mode":                "full",
 		"commands.ask.display.context":                      true,
 		"commands.ask.display.reasoning":                    true,
+		"commands.ask.display.stream_reasoning":             false,
+		"commands.ask.display.split_long_messages":          false,
+		"commands.ask.display.render_tables":                true,
+		"commands.ask.display.persist_reasoning":            true,
+		"commands.ask.display.progress":                     false,
 		"commands.ask.display.separator":                    "──────",
+		"commands.ask.display.code_files":                   false,
+		"commands.ask.display.code_file_min_lines":          30,
+		"commands.ask.display.mention_addressee":            true,
+		"commands.ask.display.retry_diff":                   false,
+		"commands.ask.reaction.enabled":                     false,
+		"commands.ask.reaction.emoji":                       "👀",
+		"commands.ask.quick_actions.enabled":                false,
+		"commands.ask.quick_actions.regenerate":             []string{"👎"},
+		"commands.ask.quick_actions.other_model":            []string{"🔁"},
+		"commands.ask.dedup.policy":                         "allow",
+		"commands.ask.dedup.per_user":                       false,
+		"commands.ask.search.enabled":                       true,
+		"commands.ask.search.mode":                          SearchModeAuto,
+		"commands.ask.edits.enabled":                        false,
+		"commands.ask.edits.window":                         10 * time.Minute,
+		"commands.ask.edits.max_attempts":                   5,
+		"commands.ask.memory.max_facts":                     20,
+		"commands.ask.memory.max_length":                    2000,
+		"commands.ask.additional_context.max_messages":      100,
+		"commands.ask.additional_context.max_length":        20000,
+		"commands.ask.additional_context.summarize":         true,
+		"commands.ask.failure.show_error":                   true,
+		"commands.ask.failure.retry_button":                 true,
 	}
 	k.Load(confmap.Provider(defaults, "."), nil)
 
@@ -187,7 +277,17 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("telegram token is required")
 	}
 
-	return &Config{k: k}, nil
+	cfg := &Config{k: k}
+	if rotation := cfg.HTTP().ProxyRotation; !slices.Contains(ProxyRotations, rotation) {
+		return nil, fmt.Errorf("invalid http.proxy_rotation %q, expected one of: %s", rotation, strings.Join(ProxyRotations, ", "))
+	}
+	for _, provider := range cfg.AI().Providers {
+		if err := provider.validateHeaders(); err != nil {
+			return nil, fmt.Errorf("provider %s: %w", provider.Name, err)
+		}
+	}
+
+	return cfg, nil
 }
 
 func (c *Config) GetCommandConfig(name string) *commandConfig {
@@ -234,6 +334,10 @@ func (c *Config) GetAskCommandConfig() *AskCommandConfig {
 			Lifetime:                 c.k.Duration("commands.ask.images.lifetime"),
 			PreprocessWithMultimodal: c.k.Bool("commands.ask.images.preprocess_with_multimodal"),
 			PreprocessPrompt:         c.k.String("commands.ask.images.preprocess_prompt"),
+			MaxDimension:             c.k.Int("commands.ask.images.max_dimension"),
+			OCREnabled:               c.k.Bool("commands.ask.images.ocr_enabled"),
+			OCRModel:                 c.k.String("commands.ask.images.ocr_model"),
+			Overrides:                c.getAskImagesOverrides(),
 		},
 		Audio: askAudioOptions{
 			Enabled:      c.k.Bool("commands.ask.audio.enabled"),
@@ -242,27 +346,123 @@ func (c *Config) GetAskCommandConfig() *AskCommandConfig {
 			MaxSize:      c.k.Int("commands.ask.audio.max_size"),
 		},
 		Files: askFilesOptions{
-			Enabled: c.k.Bool("commands.ask.files.enabled"),
+			Enabled:        c.k.Bool("commands.ask.files.enabled"),
+			TextExtensions: c.k.Strings("commands.ask.files.text_extensions"),
+			MaxTextSize:    c.k.Int("commands.ask.files.max_text_size"),
+			MaxTextLength:  c.k.Int("commands.ask.files.max_text_length"),
 		},
 		Fetcher: askFetcherOptions{
-			Enabled:   c.k.Bool("commands.ask.fetcher.enabled"),
-			MaxLength: c.k.Int("commands.ask.fetcher.max_length"),
-			Whitelist: c.k.Strings("commands.ask.fetcher.whitelist"),
-			Blacklist: c.k.Strings("commands.ask.fetcher.blacklist"),
+			Enabled:            c.k.Bool("commands.ask.fetcher.enabled"),
+			MaxLength:          c.k.Int("commands.ask.fetcher.max_length"),
+			Whitelist:          c.k.Strings("commands.ask.fetcher.whitelist"),
+			Blacklist:          c.k.Strings("commands.ask.fetcher.blacklist"),
+			ImageDedupWindow:   c.k.Duration("commands.ask.fetcher.image_dedup_window"),
+			Concurrency:        c.k.Int("commands.ask.fetcher.concurrency"),
+			BlockedFallbackURL: c.k.String("commands.ask.fetcher.blocked_fallback_url"),
+			UserAgents:         c.k.Strings("commands.ask.fetcher.user_agents"),
+			BrowserHeaders:     c.k.Strings("commands.ask.fetcher.browser_headers"),
+			Selectors:          c.getAskFetcherSelectors(),
 		},
 		Display: askDisplayOptions{
-			Metadata:  c.k.Bool("commands.ask.display.metadata"),
-			Context:   c.k.Bool("commands.ask.display.context"),
-			Reasoning: c.k.Bool("commands.ask.display.reasoning"),
-			Separator: c.k.String("commands.ask.display.separator"),
+			Metadata:          c.k.Bool("commands.ask.display.metadata"),
+			Context:           c.k.Bool("commands.ask.display.context"),
+			Reasoning:         c.k.Bool("commands.ask.display.reasoning"),
+			StreamReasoning:   c.k.Bool("commands.ask.display.stream_reasoning"),
+			SplitLongMessages: c.k.Bool("commands.ask.display.split_long_messages"),
+			RenderTables:      c.k.Bool("commands.ask.display.render_tables"),
+			PersistReasoning:  c.k.Bool("commands.ask.display.persist_reasoning"),
+			Progress:          c.k.Bool("commands.ask.display.progress"),
+			Separator:         c.k.String("commands.ask.display.separator"),
+			MetadataMode:      c.k.String("commands.ask.display.metadata_mode"),
+			CodeFiles:         c.k.Bool("commands.ask.display.code_files"),
+			CodeFileMinLines:  c.k.Int("commands.ask.display.code_file_min_lines"),
+			MentionAddressee:  c.k.Bool("commands.ask.display.mention_addressee"),
+			RetryDiff:         c.k.Bool("commands.ask.display.retry_diff"),
 		},
 		Tools: askToolsOptions{
-			Enabled:       c.k.Bool("commands.ask.tools.enabled"),
-			AutoRun:       c.k.Bool("commands.ask.tools.auto_run"),
-			Allowed:       c.k.Strings("commands.ask.tools.allowed"),
-			Excluded:      c.k.Strings("commands.ask.tools.excluded"),
-			MaxIterations: c.k.Int("commands.ask.tools.max_iterations"),
+			Enabled:              c.k.Bool("commands.ask.tools.enabled"),
+			AutoRun:              c.k.Bool("commands.ask.tools.auto_run"),
+			Allowed:              c.k.Strings("commands.ask.tools.allowed"),
+			Excluded:             c.k.Strings("commands.ask.tools.excluded"),
+			MaxIterations:        c.k.Int("commands.ask.tools.max_iterations"),
+			MaxPendingReminders:  c.k.Int("commands.ask.tools.max_pending_reminders"),
+			ImageDocumentMinSize: c.k.Int("commands.ask.tools.image_document_min_size"),
+			ConvertRatesURL:      c.k.String("commands.ask.tools.convert_rates_url"),
+			MaxButtons:           c.k.Int("commands.ask.tools.max_buttons"),
+			Timeout:              c.k.Duration("commands.ask.tools.timeout"),
+			TotalTimeout:         c.k.Duration("commands.ask.tools.total_timeout"),
+			MaxResponseLength:    c.k.Int("commands.ask.tools.max_response_length"),
+			Truncate:             c.k.String("commands.ask.tools.truncate"),
+		},
+		Reaction: askReactionOptions{
+			Enabled: c.k.Bool("commands.ask.reaction.enabled"),
+			Emoji:   c.k.String("commands.ask.reaction.emoji"),
+			Chats:   c.k.Int64s("commands.ask.reaction.chats"),
+		},
+		QuickActions: askQuickActionsOptions{
+			Enabled:    c.k.Bool("commands.ask.quick_actions.enabled"),
+			Regenerate: c.k.Strings("commands.ask.quick_actions.regenerate"),
+			OtherModel: c.k.Strings("commands.ask.quick_actions.other_model"),
+		},
+		Dedup: askDedupOptions{
+			Policy:  c.k.String("commands.ask.dedup.policy"),
+			PerUser: c.k.Bool("commands.ask.dedup.per_user"),
+		},
+		Search: askSearchOptions{
+			Enabled: c.k.Bool("commands.ask.search.enabled"),
+			Mode:    c.k.String("commands.ask.search.mode"),
 		},
+		Edits: askEditsOptions{
+			Enabled:     c.k.Bool("commands.ask.edits.enabled"),
+			Window:      c.k.Duration("commands.ask.edits.window"),
+			MaxAttempts: c.k.Int("commands.ask.edits.max_attempts"),
+		},
+		Memory: askMemoryOptions{
+			MaxFacts:  c.k.Int("commands.ask.memory.max_facts"),
+			MaxLength: c.k.Int("commands.ask.memory.max_length"),
+		},
+		AdditionalContext: askAdditionalContextOptions{
+			MaxMessages: c.k.Int("commands.ask.additional_context.max_messages"),
+			MaxLength:   c.k.Int("commands.ask.additional_context.max_length"),
+			Summarize:   c.k.Bool("commands.ask.additional_context.summarize"),
+		},
+		Failure: c.getAskFailureOptions(),
+	}
+}
+
+func (c *Config) getAskFetcherSelectors() []askFetcherSelectors {
+	var selectors []askFetcherSelectors
+	if err := c.k.Unmarshal("commands.ask.fetcher.selectors", &selectors); err != nil {
+		log.Printf("commands.ask.fetcher.selectors unmarshal error: %v", err)
+	}
+	return selectors
+}
+
+func (c *Config) getAskImagesOverrides() []AskImagesOverride {
+	var overrides []AskImagesOverride
+	if err := c.k.Unmarshal("commands.ask.images.overrides", &overrides); err != nil {
+		log.Printf("commands.ask.images.overrides unmarshal error: %v", err)
+	}
+	return overrides
+}
+
+func (c *Config) getAskFailureOptions() askFailureOptions {
+	options := askFailureOptions{
+		Message:     c.k.String("commands.ask.failure.message"),
+		ShowError:   c.k.Bool("commands.ask.failure.show_error"),
+		RetryButton: c.k.Bool("commands.ask.failure.retry_button"),
+	}
+	if err := c.k.Unmarshal("commands.ask.failure.canned", &options.Canned); err != nil {
+		log.Printf("commands.ask.failure.canned unmarshal error: %v", err)
+	}
+	return options
+}
+
+func (c *Config) GetStartCommandConfig() *StartCommandConfig {
+	return &StartCommandConfig{
+		CommandConfig: *c.GetCommandConfig("start"),
+		Onboarding:    c.k.Bool("commands.start.onboarding"),
+		Greeting:      c.k.String("commands.start.greeting"),
 	}
 }
 
@@ -293,6 +493,20 @@ func (c *Config) Instagram() instagramConfig {
 	}
 }
 
+func (c *Config) Twitch() twitchConfig {
+	return twitchConfig{
+		ClientID:     c.k.String(twitchClientID),
+		ClientSecret: c.k.String(twitchClientSecret),
+	}
+}
+
+func (c *Config) X() xConfig {
+	return xConfig{
+		Instance: c.k.String(xInstance),
+		Timeout:  c.k.Duration(xTimeout),
+	}
+}
+
 func (c *Config) YtDlp() ytdlpConfig {
 	return ytdlpConfig{
 		MaxSize:       c.k.String(ytdlpMaxSize),
@@ -314,14 +528,23 @@ func (c *Config) Currency() CurrencyConfig {
 		Code:      c.k.String(currencyCode),
 		Symbol:    c.k.String(currencySymbol),
 		Precision: c.k.Int(currencyPrecision),
+		Rate:      c.k.Float64(currencyRate),
 	}
 }
 
 func (c *Config) Log() LoggingConfig {
 	return LoggingConfig{
-		LogLevel:    c.k.String(loggingLevel),
-		WriteInFile: c.k.Bool(loggingWriteInFile),
-		FilePath:    c.k.String(loggingFilePath),
+		LogLevel:       c.k.String(loggingLevel),
+		WriteInFile:    c.k.Bool(loggingWriteInFile),
+		FilePath:       c.k.String(loggingFilePath),
+		RedactPatterns: c.k.Strings(loggingRedactPatterns),
+	}
+}
+
+func (c *Config) Metrics() metricsConfig {
+	return metricsConfig{
+		Enabled: c.k.Bool(metricsEnabled),
+		Addr:    c.k.String(metricsAddr),
 	}
 }
 
@@ -359,18 +582,32 @@ func (c *Config) GetDatabaseDSN() string {
 
 func (c *Config) Global() globalConfig {
 	return globalConfig{
-		MessageRetentionDays: c.k.Int(globalMessageRetentionDays),
-		InterfaceLanguage:    c.k.String(globalLanguage),
-		FixInstagramPreviews: c.k.Bool(globalFixInstagramPreviews),
-		FixXPreviews:         c.k.Bool(globalFixXPreviews),
+		MessageRetentionDays:        c.k.Int(globalMessageRetentionDays),
+		ConversationRetentionDays:   c.k.Int(globalConversationRetentionDays),
+		ConversationCleanupInterval: c.k.Duration(globalConversationCleanup),
+		InterfaceLanguage:           c.k.String(globalLanguage),
+		FixInstagramPreviews:        c.k.Bool(globalFixInstagramPreviews),
+		FixXPreviews:                c.k.Bool(globalFixXPreviews),
 	}
 }
 
 func (c *Config) HTTP() HTTPConfig {
-	proxy := c.k.String(httpProxy)
+	// a single proxy or a list of proxies rotated by the fetcher
+	proxies := c.k.Strings(httpProxy)
+	if len(proxies) == 0 {
+		if proxy := c.k.String(httpProxy); proxy != "" {
+			proxies = []string{proxy}
+		}
+	}
+	var proxy string
+	if len(proxies) > 0 {
+		proxy = proxies[0]
+	}
 	return HTTPConfig{
-		Proxy:   &proxy,
-		NoProxy: c.k.Strings(httpNoProxy),
+		Proxy:         &proxy,
+		Proxies:       proxies,
+		NoProxy:       c.k.Strings(httpNoProxy),
+		ProxyRotation: c.k.String(httpProxyRotation),
 	}
 }
 
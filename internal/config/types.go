@@ -4,27 +4,47 @@ import (
 	"fmt"
 	"maps"
 	"os"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
+
+	"golang.org/x/net/http/httpguts"
 )
 
 type globalConfig struct {
-	MessageRetentionDays int    `koanf:"message_retention_days"`
-	InterfaceLanguage    string `koanf:"interface_language"`
-	FixInstagramPreviews bool   `koanf:"fix_instagram_previews"`
-	FixXPreviews         bool   `koanf:"fix_x_previews"`
+	MessageRetentionDays int `koanf:"message_retention_days"`
+	// conversations with the newest message older than this are deleted, 0 - keep forever
+	ConversationRetentionDays   int           `koanf:"conversation_retention_days"`
+	ConversationCleanupInterval time.Duration `koanf:"conversation_cleanup_interval"`
+	InterfaceLanguage           string        `koanf:"interface_language"`
+	FixInstagramPreviews        bool          `koanf:"fix_instagram_previews"`
+	FixXPreviews                bool          `koanf:"fix_x_previews"`
 }
 
 type CurrencyConfig struct {
-	Code      string `koanf:"code"`
-	Symbol    string `koanf:"symbol"`
-	Precision int    `koanf:"precision"`
+	Code      string  `koanf:"code"`
+	Symbol    string  `koanf:"symbol"`
+	Precision int     `koanf:"precision"`
+	Rate      float64 `koanf:"rate"` // fixed rate to USD, 0 - fetch the current rate
 }
 
+const (
+	// ProxyRotationRoundRobin uses the next proxy for each request
+	ProxyRotationRoundRobin = "round_robin"
+	// ProxyRotationSticky keeps a proxy per host until it's blocked
+	ProxyRotationSticky = "sticky"
+)
+
+var ProxyRotations = []string{ProxyRotationRoundRobin, ProxyRotationSticky}
+
 type HTTPConfig struct {
+	// Proxy is the first of Proxies, used by all clients except the fetcher
 	Proxy   *string  `koanf:"proxy"`
 	NoProxy []string `koanf:"no_proxy"`
+	// Proxies are rotated by the fetcher with ProxyRotation strategy
+	Proxies       []string `koanf:"-"`
+	ProxyRotation string   `koanf:"proxy_rotation"`
 }
 
 func (c HTTPConfig) GetNoProxy() []string {
@@ -41,6 +61,17 @@ func (c HTTPConfig) GetNoProxy() []string {
 	return nil
 }
 
+// GetProxies returns the configured proxies or the proxy from the environment
+func (c HTTPConfig) GetProxies() []string {
+	if len(c.Proxies) > 0 {
+		return c.Proxies
+	}
+	if proxy := c.GetProxy(); proxy != "" {
+		return []string{proxy}
+	}
+	return nil
+}
+
 func (c HTTPConfig) GetProxy() string {
 	if c.Proxy != nil && *c.Proxy != "" {
 		return *c.Proxy
@@ -61,9 +92,10 @@ func (c HTTPConfig) GetProxy() string {
 }
 
 type LoggingConfig struct {
-	LogLevel    string `koanf:"level"`
-	WriteInFile bool   `koanf:"write_in_file"`
-	FilePath    string `koanf:"file_path"`
+	LogLevel       string   `koanf:"level"`
+	WriteInFile    bool     `koanf:"write_in_file"`
+	FilePath       string   `koanf:"file_path"`
+	RedactPatterns []string `koanf:"redact_patterns"` // regexps masked with [REDACTED] in debug logs of requests
 }
 
 func (c LoggingConfig) Level() string {
@@ -88,6 +120,18 @@ type TelegramConfig struct {
 	Phone       string `koanf:"phone"`
 	Password    string `koanf:"password"`
 	SessionPath string `koanf:"session_path"`
+
+	Webhook TelegramWebhookConfig `koanf:"webhook"`
+}
+
+// TelegramWebhookConfig enables receiving updates by webhook instead of long polling
+type TelegramWebhookConfig struct {
+	Enabled    bool   `koanf:"enabled"`
+	ListenAddr string `koanf:"listen_addr"`
+	// PublicURL is the HTTPS URL telegram sends updates to, its path is served on ListenAddr
+	PublicURL string `koanf:"public_url"`
+	// SecretToken is sent by telegram in X-Telegram-Bot-Api-Secret-Token header of each update
+	SecretToken string `koanf:"secret_token"`
 }
 
 func (c TelegramConfig) IsAllowed(userID int64, chatID int64) bool {
@@ -123,12 +167,29 @@ func (c instagramConfig) Credentials() (string, string) {
 	return c.Username, c.Password
 }
 
+// twitchConfig contains app credentials for Helix API (https://dev.twitch.tv/console/apps)
+type twitchConfig struct {
+	ClientID     string `koanf:"client_id"`
+	ClientSecret string `koanf:"client_secret"`
+}
+
+// xConfig contains Nitter-style instance for X (Twitter) posts, the official API is paywalled
+type xConfig struct {
+	Instance string        `koanf:"instance"`
+	Timeout  time.Duration `koanf:"timeout"`
+}
+
 type chromeConfig struct {
 	Enabled bool     `koanf:"enabled"`
 	Path    string   `koanf:"path"`
 	Opts    []string `koanf:"opts"`
 }
 
+type metricsConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	Addr    string `koanf:"addr"`
+}
+
 type aiPrompt struct {
 	Enabled       bool          `koanf:"enabled"`
 	Name          string        `koanf:"name"`
@@ -138,6 +199,10 @@ type aiPrompt struct {
 	Commands      []string      `koanf:"commands"`
 	ModelParams   aiModelParams `koanf:"model_params"`
 	DynamicPrompt bool          `koanf:"dynamic_prompt"`
+	// replaces ai.system_prompt and ai.extra_system_prompt for this prompt
+	SystemPrompt string `koanf:"system_prompt"`
+	// model spec or alias used instead of the chat model, explicit $m still wins
+	Model string `koanf:"model"`
 }
 
 type aiModelParams struct {
@@ -224,6 +289,28 @@ type aiModelAlias struct {
 	ModelParams aiModelParams `koanf:"model_params"`
 }
 
+// aiModelFallback is an ordered list of models to try when the model is unavailable
+type aiModelFallback struct {
+	Model     string   `koanf:"model"` // model spec (provider:model) or alias
+	Fallbacks []string `koanf:"fallbacks"`
+}
+
+type AutoModelInput string
+
+const (
+	AutoModelInputImages AutoModelInput = "images"
+	AutoModelInputAudio  AutoModelInput = "audio"
+	AutoModelInputCode   AutoModelInput = "code"
+	AutoModelInputLong   AutoModelInput = "long"
+)
+
+// AutoModelRule selects the model by request input when the model is not set explicitly
+type AutoModelRule struct {
+	Input     AutoModelInput `koanf:"input"`
+	Model     string         `koanf:"model"`      // model spec (provider:model) or alias
+	MinLength int            `koanf:"min_length"` // for long input, in characters
+}
+
 type ModelsBehavior string
 
 const (
@@ -243,6 +330,34 @@ type AIProviderConfig struct {
 	ModelParams    aiModelParams     `koanf:"model_params"`
 	Models         []ModelInfoConfig `koanf:"models"`
 	OverrideModels bool              `koanf:"override_models"`
+	// command printing a short-lived access token, used instead of api_key
+	TokenCommand string        `koanf:"token_command"`
+	TokenTTL     time.Duration `koanf:"token_ttl"`
+	// static headers of every request, values may contain $ENV_VAR
+	Headers map[string]string `koanf:"headers"`
+	// OpenRouter only, models tried by OpenRouter when the requested one fails upstream
+	FallbackModels []string `koanf:"fallback_models"`
+}
+
+// GetHeaders returns static headers with expanded environment variables
+func (c *AIProviderConfig) GetHeaders() map[string]string {
+	headers := make(map[string]string, len(c.Headers))
+	for name, value := range c.Headers {
+		headers[name] = os.ExpandEnv(value)
+	}
+	return headers
+}
+
+func (c *AIProviderConfig) validateHeaders() error {
+	for name, value := range c.Headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid value of header %s", name)
+		}
+	}
+	return nil
 }
 
 func (c *AIProviderConfig) GetAPIKey() string {
@@ -265,12 +380,19 @@ type aiConfig struct {
 	UtilityModel      string             `koanf:"utility_model"`    // generating titles and summaries
 	MultimodalModel   string             `koanf:"multimodal_model"` // use for handle context with images
 	ToolsModel        string             `koanf:"tools_model"`      // use for handle tools
+	TranslateModel    string             `koanf:"translate_model"`  // use for translate tool
+	TTSModel          string             `koanf:"tts_model"`        // speech synthesis for $voice, empty - disabled
+	TTSVoice          string             `koanf:"tts_voice"`
 	UseMultimodalAuto bool               `koanf:"use_multimodal_auto"`
+	DailyCostLimit    float64            `koanf:"daily_cost_limit"` // per chat in USD, 0 - unlimited
 	ImageRouterAPIKey string             `koanf:"imagerouter_api_key"`
 	ImageRouterModel  string             `koanf:"imagerouter_model"`
 	Providers         []AIProviderConfig `koanf:"providers"`
 	Prompts           []aiPrompt         `koanf:"prompts"`
 	Aliases           []aiModelAlias     `koanf:"aliases"`
+	Fallbacks         []aiModelFallback  `koanf:"fallbacks"`
+	AutoModels        []AutoModelRule    `koanf:"auto_models"`    // checked in order, the first matched is used
+	BlockedModels     []string           `koanf:"blocked_models"` // never auto-selected, specs or patterns with *
 }
 
 func (c aiConfig) GetPromptText() string {
@@ -315,6 +437,54 @@ func (c aiConfig) GetToolsModel() string {
 	return ""
 }
 
+// GetTranslateModel returns the model for the translate tool, the utility model if not set
+func (c aiConfig) GetTranslateModel() string {
+	if model := c.TranslateModel; model != "" {
+		return model
+	}
+	return c.GetUtilityModel()
+}
+
+// GetFallbacks returns fallback models for the model, searched by full name or alias
+func (c aiConfig) GetFallbacks(modelName, alias string) []string {
+	for _, f := range c.Fallbacks {
+		if f.Model == modelName || (alias != "" && f.Model == alias) {
+			return f.Fallbacks
+		}
+	}
+	return nil
+}
+
+// IsModelBlocked reports whether the model spec (provider:model) matches one of ai.blocked_models,
+// * in a pattern matches any characters, e.g. "or:meta-llama/*" or "or:*:free"
+func (c aiConfig) IsModelBlocked(modelSpec string) bool {
+	for _, pattern := range c.BlockedModels {
+		if matchModelPattern(pattern, modelSpec) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchModelPattern(pattern, modelSpec string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == modelSpec
+	}
+	if !strings.HasPrefix(modelSpec, parts[0]) {
+		return false
+	}
+	rest := modelSpec[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	return strings.HasSuffix(rest, parts[len(parts)-1])
+}
+
 func (c aiConfig) GetDefaultModel() string {
 	return c.DefaultModel
 }
@@ -348,6 +518,10 @@ func (p aiModelParams) Validate() error {
 		return fmt.Errorf("presence_penalty must be between -2 and 2, got %.2f", *p.PresencePenalty)
 	}
 
+	if p.Timeout != nil && *p.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got %s", *p.Timeout)
+	}
+
 	return nil
 }
 
@@ -360,6 +534,9 @@ func (c aiConfig) ValidateAll() error {
 		if err := p.ModelParams.Validate(); err != nil {
 			return fmt.Errorf("provider %s: %w", p.Name, err)
 		}
+		if err := p.validateHeaders(); err != nil {
+			return fmt.Errorf("provider %s: %w", p.Name, err)
+		}
 	}
 
 	for _, a := range c.Aliases {
@@ -404,6 +581,27 @@ func (c aiConfig) GetFullModelParams(providerName, aliasName, promptName string)
 	return params, nil
 }
 
+// MaxRequestTimeout returns the longest timeout of all model params, 0 if none is set
+func (c aiConfig) MaxRequestTimeout() time.Duration {
+	params := []aiModelParams{c.ModelParams}
+	for _, p := range c.Providers {
+		params = append(params, p.ModelParams)
+	}
+	for _, a := range c.Aliases {
+		params = append(params, a.ModelParams)
+	}
+	for _, p := range c.Prompts {
+		params = append(params, p.ModelParams)
+	}
+	var result time.Duration
+	for _, p := range params {
+		if p.Timeout != nil {
+			result = max(result, *p.Timeout)
+		}
+	}
+	return result
+}
+
 func (c aiConfig) GetProvider(name string) *AIProviderConfig {
 	for _, p := range c.Providers {
 		if p.Name == name {
@@ -541,18 +739,176 @@ type queueOptions struct {
 }
 
 type askDisplayOptions struct {
-	Context   bool   `koanf:"context"`
-	Metadata  bool   `koanf:"metadata"`
-	Reasoning bool   `koanf:"reasoning"`
+	Context         bool `koanf:"context"`
+	Metadata        bool `koanf:"metadata"`
+	Reasoning       bool `koanf:"reasoning"`
+	StreamReasoning bool `koanf:"stream_reasoning"` // keep reasoning above the answer while streaming
+	// send answers over the telegram limit as several messages instead of truncating
+	SplitLongMessages bool `koanf:"split_long_messages"`
+	// show markdown tables as code blocks with aligned columns
+	RenderTables bool `koanf:"render_tables"`
+	// keep reasoning inlined by the model into the answer in the history
+	PersistReasoning bool `koanf:"persist_reasoning"`
+	// add the elapsed time to the "thinking" message until the answer starts
+	Progress  bool   `koanf:"progress"`
 	Separator string `koanf:"separator"`
+	// full, compact (model and cost in one line) or off
+	MetadataMode string `koanf:"metadata_mode"`
+	// add "download code" buttons sending code blocks longer than CodeFileMinLines as files
+	CodeFiles        bool `koanf:"code_files"`
+	CodeFileMinLines int  `koanf:"code_file_min_lines"`
+	// mention the participant of $at at the start of the answer
+	MentionAddressee bool `koanf:"mention_addressee"`
+	// reply to a regenerated answer with the word diff against the previous one
+	RetryDiff bool `koanf:"retry_diff"`
+}
+
+const (
+	MetadataModeFull    = "full"
+	MetadataModeCompact = "compact"
+	MetadataModeOff     = "off"
+)
+
+// MetadataDisplayMode returns the metadata mode, metadata = false turns it off
+func (o askDisplayOptions) MetadataDisplayMode() string {
+	if !o.Metadata {
+		return MetadataModeOff
+	}
+	switch o.MetadataMode {
+	case MetadataModeCompact, MetadataModeOff:
+		return o.MetadataMode
+	default:
+		return MetadataModeFull
+	}
+}
+
+// askReactionOptions replaces the "thinking" message with a reaction
+// on the user's message for quick (non-stream, without tools) answers
+type askReactionOptions struct {
+	Enabled bool    `koanf:"enabled"`
+	Emoji   string  `koanf:"emoji"`
+	Chats   []int64 `koanf:"chats"` // empty - all chats
+}
+
+func (o askReactionOptions) IsEnabledForChat(chatID int64) bool {
+	if !o.Enabled || o.Emoji == "" {
+		return false
+	}
+	return len(o.Chats) == 0 || slices.Contains(o.Chats, chatID)
+}
+
+const (
+	QuickActionRegenerate = "regenerate"
+	QuickActionOtherModel = "other_model"
+)
+
+const (
+	DedupAllow   = "allow"
+	DedupQueue   = "queue"
+	DedupReplace = "replace"
+)
+
+// askDedupOptions decides what happens with a new request while a previous
+// request of the chat is still running
+type askDedupOptions struct {
+	Policy  string `koanf:"policy"`   // allow, queue (wait for the previous one) or replace (cancel it)
+	PerUser bool   `koanf:"per_user"` // only requests of the same user in the chat are affected
+}
+
+const (
+	SearchModeAuto   = "auto"
+	SearchModePlugin = "plugin"
+	SearchModeTool   = "tool"
+)
+
+// askSearchOptions decides how $search looks up the web: the web plugin of the provider
+// (only OpenRouter has it) or the search tool
+type askSearchOptions struct {
+	Enabled bool   `koanf:"enabled"` // $search is ignored when disabled
+	Mode    string `koanf:"mode"`    // auto (plugin when the provider has it, otherwise the tool), plugin or tool
+}
+
+// GetMode returns the search mode, unknown modes are auto
+func (o askSearchOptions) GetMode() string {
+	switch o.Mode {
+	case SearchModePlugin, SearchModeTool:
+		return o.Mode
+	default:
+		return SearchModeAuto
+	}
+}
+
+// askEditsOptions reruns the request when the user edits the message the bot answered,
+// the answer is replaced with the new one
+type askEditsOptions struct {
+	Enabled     bool          `koanf:"enabled"`
+	Window      time.Duration `koanf:"window"`       // edits later than this after the message was sent are ignored, 0 - no limit
+	MaxAttempts int           `koanf:"max_attempts"` // runs of the request including regenerations, later edits are ignored
+}
+
+// askMemoryOptions bounds the facts about the user saved with remember tool,
+// they are added to the system prompt of every request in chats with memory enabled
+type askMemoryOptions struct {
+	MaxFacts  int `koanf:"max_facts"`  // facts kept per user, the oldest ones are removed
+	MaxLength int `koanf:"max_length"` // total length of the facts in characters
+}
+
+// askQuickActionsOptions maps reactions of the requester on the answer to actions
+type askQuickActionsOptions struct {
+	Enabled    bool     `koanf:"enabled"`
+	Regenerate []string `koanf:"regenerate"`  // emojis rerunning the request
+	OtherModel []string `koanf:"other_model"` // emojis rerunning the request with another model
+}
+
+// Action returns the action of the reaction emoji, empty if the emoji has no action
+func (o askQuickActionsOptions) Action(emoji string) string {
+	switch {
+	case !o.Enabled:
+		return ""
+	case slices.Contains(o.Regenerate, emoji):
+		return QuickActionRegenerate
+	case slices.Contains(o.OtherModel, emoji):
+		return QuickActionOtherModel
+	}
+	return ""
 }
 
 type askImagesOptions struct {
-	Enabled                    bool          `koanf:"enabled"`
-	Max                        int           `koanf:"max"`
-	Lifetime                   time.Duration `koanf:"lifetime"`
-	PreprocessWithMultimodal bool            `koanf:"preprocess_with_multimodal"`
-	PreprocessPrompt           string        `koanf:"preprocess_prompt"`
+	Enabled                  bool                `koanf:"enabled"`
+	Max                      int                 `koanf:"max"`
+	Lifetime                 time.Duration       `koanf:"lifetime"`
+	PreprocessWithMultimodal bool                `koanf:"preprocess_with_multimodal"`
+	PreprocessPrompt         string              `koanf:"preprocess_prompt"`
+	MaxDimension             int                 `koanf:"max_dimension"` // in pixels, 0 to send images as is
+	OCREnabled               bool                `koanf:"ocr_enabled"`   // extract text from images for models without image recognition
+	OCRModel                 string              `koanf:"ocr_model"`     // multimodal model if not set
+	Overrides                []AskImagesOverride `koanf:"overrides"`
+}
+
+// AskImagesOverride replaces the image limits for the model, e.g. a shorter lifetime
+// for models with expensive images
+type AskImagesOverride struct {
+	Model    string         `koanf:"model"` // model spec (provider:model) or alias
+	Lifetime *time.Duration `koanf:"lifetime"`
+	Max      *int           `koanf:"max"`
+}
+
+// ForModel returns the options with the override of the model applied,
+// searched by full name or alias, the global values are kept when there is none
+func (o askImagesOptions) ForModel(modelName, alias string) askImagesOptions {
+	for _, override := range o.Overrides {
+		if override.Model != modelName && (alias == "" || override.Model != alias) {
+			continue
+		}
+		if override.Lifetime != nil {
+			o.Lifetime = *override.Lifetime
+		}
+		if override.Max != nil {
+			o.Max = *override.Max
+		}
+		break
+	}
+	return o
 }
 
 type askAudioOptions struct {
@@ -564,21 +920,104 @@ type askAudioOptions struct {
 
 type askFilesOptions struct {
 	Enabled bool `koanf:"enabled"`
+	// documents read as text and added to the message, any model can process them
+	TextExtensions []string `koanf:"text_extensions"`
+	MaxTextSize    int      `koanf:"max_text_size"`   // in kb
+	MaxTextLength  int      `koanf:"max_text_length"` // characters of one file, the rest is cut
 }
 
 type askFetcherOptions struct {
-	Enabled   bool     `koanf:"enabled"`
-	MaxLength int      `koanf:"max_length"`
-	Whitelist []string `koanf:"whitelist"`
-	Blacklist []string `koanf:"blacklist"`
+	Enabled          bool          `koanf:"enabled"`
+	MaxLength        int           `koanf:"max_length"`
+	Whitelist        []string      `koanf:"whitelist"`
+	Blacklist        []string      `koanf:"blacklist"`
+	ImageDedupWindow time.Duration `koanf:"image_dedup_window"` // don't resend the same page images in a chat, 0 - disabled
+	Concurrency      int           `koanf:"concurrency"`        // max URLs fetched at once
+	// BlockedFallbackURL is the template of a page copy fetched when the page is behind a paywall
+	// or a consent wall, {url} is replaced with the page URL. Empty - no fallback
+	BlockedFallbackURL string `koanf:"blocked_fallback_url"`
+	Selectors          []askFetcherSelectors
+	UserAgents         []string `koanf:"user_agents"` // random one is sent, built-in desktop ones if empty
+	// parts of hosts getting browser-like Accept, Accept-Language and Sec-Fetch-* headers, "*" - all hosts
+	BrowserHeaders []string `koanf:"browser_headers"`
+}
+
+// askFetcherSelectors overrides HTML cleanup of the default fetcher for matching hosts,
+// a list instead of a map since koanf splits dotted host keys
+type askFetcherSelectors struct {
+	Host    string   `koanf:"host"`    // part of the host, the first matching entry is used
+	Remove  []string `koanf:"remove"`  // replaces the default removed elements, scripts and styles are always removed
+	Content string   `koanf:"content"` // extract text only inside this element
+}
+
+// askAdditionalContextOptions limits messages added by $c argument,
+// older messages over the limits are summarized or dropped
+type askAdditionalContextOptions struct {
+	MaxMessages int  `koanf:"max_messages"` // 0 - unlimited
+	MaxLength   int  `koanf:"max_length"`   // in characters, 0 - unlimited
+	Summarize   bool `koanf:"summarize"`    // summarize older messages via utility model
+}
+
+// askFailureOptions configures the answer sent when all attempts
+// (request model, fallbacks and retries) failed
+type askFailureOptions struct {
+	Message     string              `koanf:"message"`      // markdown, replaces the default message
+	ShowError   bool                `koanf:"show_error"`   // append the error text
+	RetryButton bool                `koanf:"retry_button"` // one-tap retry of the request
+	Canned      []askCannedResponse `koanf:"canned"`       // offline answers for common queries
+}
+
+type askCannedResponse struct {
+	Pattern string `koanf:"pattern"` // case-insensitive regexp matched against the query
+	Answer  string `koanf:"answer"`
+}
+
+// CannedAnswer returns the answer of the first response matching the query
+func (o askFailureOptions) CannedAnswer(query string) string {
+	for _, canned := range o.Canned {
+		re, err := regexp.Compile("(?i)" + canned.Pattern)
+		if err != nil || canned.Answer == "" {
+			continue
+		}
+		if re.MatchString(query) {
+			return canned.Answer
+		}
+	}
+	return ""
 }
 
+const (
+	ToolTruncateHead   = "head"
+	ToolTruncateTail   = "tail"
+	ToolTruncateMiddle = "middle"
+)
+
 type askToolsOptions struct {
 	Enabled       bool     `koanf:"enabled"`
 	AutoRun       bool     `koanf:"auto_run"`
 	MaxIterations int      `koanf:"max_iterations"`
 	Allowed       []string `koanf:"allowed"`
 	Excluded      []string `koanf:"excluded"`
+	// MaxPendingReminders limits reminders set by one user with set_reminder tool, 0 - unlimited
+	MaxPendingReminders int `koanf:"max_pending_reminders"`
+	// ImageDocumentMinSize is the longest side in pixels from which generated images
+	// are also sent as a document, Telegram recompresses photos larger than 1280px. 0 - never
+	ImageDocumentMinSize int `koanf:"image_document_min_size"`
+	// ConvertRatesURL returns exchange rates for the convert tool, {"usd": {"eur": 0.9}}
+	// of currency-api or {"base": "EUR", "rates": {"USD": 1.1}}, USD if the base is not set
+	ConvertRatesURL string `koanf:"convert_rates_url"`
+	// MaxButtons limits buttons to run single tools called in the answer,
+	// with more calls only the "Run all tools" button is shown
+	MaxButtons int `koanf:"max_buttons"`
+	// Timeout limits one tool attempt (generate_image only by TotalTimeout),
+	// TotalTimeout limits running all tools of the answer. 0 - unlimited
+	Timeout      time.Duration `koanf:"timeout"`
+	TotalTimeout time.Duration `koanf:"total_timeout"`
+	// MaxResponseLength limits a tool response in characters passed to the model, 0 - unlimited.
+	// Truncate is the part of the longer response kept: head, tail or middle (the middle is cut),
+	// empty - middle for fetch and search tools, head for others
+	MaxResponseLength int    `koanf:"max_response_length"`
+	Truncate          string `koanf:"truncate"`
 }
 
 func (f askFetcherOptions) inWhitelist(URL string) bool {
@@ -619,14 +1058,28 @@ type commandConfig struct {
 
 type AskCommandConfig struct {
 	CommandConfig       commandConfig
-	MaxContextTurns     int               `koanf:"max_context_turns"`
-	GenerateTitleWithAI bool              `koanf:"generate_title_with_ai"`
-	Display             askDisplayOptions `koanf:"display"`
-	Fetcher             askFetcherOptions `koanf:"fetcher"`
-	Images              askImagesOptions  `koanf:"images"`
-	Audio               askAudioOptions   `koanf:"audio"`
-	Files               askFilesOptions   `koanf:"files"`
-	Tools               askToolsOptions   `koanf:"tools"`
+	MaxContextTurns     int                         `koanf:"max_context_turns"`
+	GenerateTitleWithAI bool                        `koanf:"generate_title_with_ai"`
+	Display             askDisplayOptions           `koanf:"display"`
+	Fetcher             askFetcherOptions           `koanf:"fetcher"`
+	Images              askImagesOptions            `koanf:"images"`
+	Audio               askAudioOptions             `koanf:"audio"`
+	Files               askFilesOptions             `koanf:"files"`
+	Tools               askToolsOptions             `koanf:"tools"`
+	Reaction            askReactionOptions          `koanf:"reaction"`
+	QuickActions        askQuickActionsOptions      `koanf:"quick_actions"`
+	Dedup               askDedupOptions             `koanf:"dedup"`
+	Search              askSearchOptions            `koanf:"search"`
+	Edits               askEditsOptions             `koanf:"edits"`
+	Memory              askMemoryOptions            `koanf:"memory"`
+	AdditionalContext   askAdditionalContextOptions `koanf:"additional_context"`
+	Failure             askFailureOptions           `koanf:"failure"`
+}
+
+type StartCommandConfig struct {
+	CommandConfig commandConfig
+	Onboarding    bool   `koanf:"onboarding"` // show capability overview on first /start
+	Greeting      string `koanf:"greeting"`   // custom greeting, replaces the default one
 }
 
 type rCommandConfig struct {
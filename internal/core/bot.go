@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
 	"github.com/muratoffalex/gachigazer/internal/commands"
 	"github.com/muratoffalex/gachigazer/internal/commands/ask"
 	"github.com/muratoffalex/gachigazer/internal/commands/instagram"
@@ -51,18 +52,33 @@ func NewBot(
 	}, nil
 }
 
+// Start receives updates by long polling and handles them until the context is done
 func (b *Bot) Start(ctx context.Context) error {
 	u := b.tg.NewUpdate(0, 60, 0)
+	u.AllowedUpdates = b.AllowedUpdates()
 
-	updates := b.tg.GetUpdatesChan(u)
+	return b.Run(ctx, b.tg.GetUpdatesChan(u))
+}
+
+// AllowedUpdates returns the update types requested from telegram, reactions are requested
+// only for the quick actions. The list is always explicit, telegram keeps the types of
+// the previous request for an empty one, so reactions would stay after the quick actions are off
+func (b *Bot) AllowedUpdates() []string {
+	return telegram.AllowedUpdates(b.cfg.GetAskCommandConfig().QuickActions.Enabled)
+}
 
+// Run handles updates from the channel until the context is done or the channel is closed
+func (b *Bot) Run(ctx context.Context, updates <-chan tgbotapi.Update) error {
 	b.logger.Info("Bot started")
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case update := <-updates:
+		case update, ok := <-updates:
+			if !ok {
+				return ctx.Err()
+			}
 			jsonData, _ := json.Marshal(update)
 			b.logger.WithFields(logger.Fields{
 				"update_structure": string(jsonData),
@@ -126,6 +142,11 @@ func (b *Bot) Start(ctx context.Context) error {
 				continue
 			}
 
+			if reaction := update.MessageReaction; reaction != nil {
+				b.handleReaction(reaction)
+				continue
+			}
+
 			if update.EditedMessage != nil {
 				currentMessage, err := b.db.GetMessage(
 					update.EditedMessage.Chat.ID,
@@ -135,6 +156,7 @@ func (b *Bot) Start(ctx context.Context) error {
 					b.logger.WithError(err).Error("Failed to get message from database")
 				}
 				if currentMessage != nil {
+					previous := currentMessage.Message
 					currentMessage.Message = update.EditedMessage
 					editedJSONMessage, err := json.Marshal(currentMessage)
 					if err != nil {
@@ -151,6 +173,7 @@ func (b *Bot) Start(ctx context.Context) error {
 								"chat_id": update.EditedMessage.Chat.ID,
 								"message": update.EditedMessage.MessageID,
 							}).Debug("Updated message in database")
+							b.handleEdit(previous, update.EditedMessage)
 						}
 					}
 				} else {
@@ -158,6 +181,11 @@ func (b *Bot) Start(ctx context.Context) error {
 				}
 			}
 
+			if update.Message == nil && update.BusinessMessage != nil {
+				// business messages are handled as regular ones, telegram client adds the business connection to replies
+				update.Message = update.BusinessMessage
+			}
+
 			msg := update.Message
 			if msg == nil {
 				continue
@@ -390,6 +418,87 @@ func (b *Bot) RegisterCommand(cmd commands.Command) {
 	b.queue.StartQueue(context.Background(), cmd.Name(), cmd)
 }
 
+// handleReaction passes the quick action of the reaction added to a message to the ask command,
+// the command checks the message is an answer to the user
+func (b *Bot) handleReaction(reaction *tgbotapi.MessageReactionUpdated) {
+	cmd, ok := b.commands[ask.CommandName]
+	if !ok || reaction.User == nil {
+		return
+	}
+	action := addedReactionAction(reaction, b.cfg.GetAskCommandConfig().QuickActions.Action)
+	if action == "" {
+		return
+	}
+	if !b.cfg.Telegram().IsAllowed(reaction.User.ID, reaction.Chat.ID) {
+		b.logger.WithFields(logger.Fields{
+			"user_id": reaction.User.ID,
+			"chat_id": reaction.Chat.ID,
+		}).Warn("Unauthorized reaction")
+		return
+	}
+
+	update := telegram.Update{
+		CallbackQuery: &telegram.CallbackQuery{
+			From:    reaction.User,
+			Message: &telegram.MessageOriginal{MessageID: reaction.MessageID, Chat: reaction.Chat},
+			Data:    ask.QuickActionCallbackData(action),
+		},
+	}
+	go func(cmd commands.Command, update telegram.Update) {
+		if err := cmd.Handle(update); err != nil {
+			b.logger.WithError(err).Error("Failed to handle reaction")
+			b.sendErrorMessage(err, reaction.Chat.ID, reaction.MessageID)
+		}
+	}(cmd, update)
+}
+
+// handleEdit passes the edited message to the ask command rerunning the request it answered.
+// Only edits of the text by users are passed, the bot edits its own messages all the time
+func (b *Bot) handleEdit(previous, edited *telegram.MessageOriginal) {
+	cmd, ok := b.commands[ask.CommandName]
+	if !ok || !b.cfg.GetAskCommandConfig().Edits.Enabled || edited.From == nil || edited.From.IsBot {
+		return
+	}
+	if previous != nil && previous.Text == edited.Text && previous.Caption == edited.Caption {
+		return
+	}
+	if !b.cfg.Telegram().IsAllowed(edited.From.ID, edited.Chat.ID) {
+		b.logger.WithFields(logger.Fields{
+			"user_id": edited.From.ID,
+			"chat_id": edited.Chat.ID,
+		}).Warn("Unauthorized edit")
+		return
+	}
+
+	update := telegram.Update{
+		CallbackQuery: &telegram.CallbackQuery{
+			From:    edited.From,
+			Message: edited,
+			Data:    ask.EditCallbackData(),
+		},
+	}
+	go func(cmd commands.Command, update telegram.Update) {
+		if err := cmd.Handle(update); err != nil {
+			b.logger.WithError(err).Error("Failed to handle edited message")
+			b.sendErrorMessage(err, edited.Chat.ID, edited.MessageID)
+		}
+	}(cmd, update)
+}
+
+// addedReactionAction returns the action of the first emoji added by the update,
+// removed and kept reactions don't trigger actions
+func addedReactionAction(reaction *tgbotapi.MessageReactionUpdated, action func(emoji string) string) string {
+	for _, added := range reaction.NewReaction {
+		if !added.IsEmoji() || slices.Contains(reaction.OldReaction, added) {
+			continue
+		}
+		if name := action(added.Emoji); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
 func isIgnoreMessage(text string) bool {
 	return strings.HasPrefix(text, ">")
 }
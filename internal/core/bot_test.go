@@ -0,0 +1,108 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/muratoffalex/gachigazer/internal/commands"
+	"github.com/muratoffalex/gachigazer/internal/commands/ask"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// handledCommand passes the handled updates to the channel
+type handledCommand struct {
+	commands.Command
+	handled chan telegram.Update
+}
+
+func (c handledCommand) Handle(update telegram.Update) error {
+	c.handled <- update
+	return nil
+}
+
+func TestBot_handleEdit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("gachigazer.toml", []byte(`
+[telegram]
+token = "token"
+allowed_users = [1]
+allowed_chats = [200]
+
+[commands.ask.edits]
+enabled = true
+`), 0o644))
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	cmd := handledCommand{handled: make(chan telegram.Update, 1)}
+	bot := &Bot{
+		commands: map[string]commands.Command{ask.CommandName: cmd},
+		logger:   logger.NewTestLogger(),
+		cfg:      cfg,
+	}
+
+	message := func(from *tgbotapi.User, text string) *telegram.MessageOriginal {
+		return &telegram.MessageOriginal{MessageID: 5, Chat: tgbotapi.Chat{ID: 100}, From: from, Text: text}
+	}
+	user := &tgbotapi.User{ID: 1}
+	tests := []struct {
+		name     string
+		previous *telegram.MessageOriginal
+		edited   *telegram.MessageOriginal
+	}{
+		{"edit by bot", nil, message(&tgbotapi.User{ID: 1, IsBot: true}, "answer")},
+		{"text isn't changed", message(user, "request"), message(user, "request")},
+		{"edit without author", nil, message(nil, "request")},
+		{"not allowed user", nil, message(&tgbotapi.User{ID: 2}, "request")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot.handleEdit(tt.previous, tt.edited)
+			select {
+			case <-cmd.handled:
+				t.Fatal("edit is passed to the ask command")
+			case <-time.After(20 * time.Millisecond):
+			}
+		})
+	}
+
+	bot.handleEdit(message(user, "request"), message(user, "edited request"))
+	select {
+	case update := <-cmd.handled:
+		require.NotNil(t, update.CallbackQuery)
+		assert.Equal(t, ask.EditCallbackData(), update.CallbackQuery.Data)
+		assert.Equal(t, "edited request", update.CallbackQuery.Message.Text)
+	case <-time.After(time.Second):
+		t.Fatal("edit isn't passed to the ask command")
+	}
+}
+
+func TestBot_AllowedUpdates(t *testing.T) {
+	t.Chdir(t.TempDir())
+	load := func(quickActions bool) *config.Config {
+		require.NoError(t, os.WriteFile("gachigazer.toml", fmt.Appendf(nil, `
+[telegram]
+token = "token"
+
+[commands.ask.quick_actions]
+enabled = %t
+`, quickActions), 0o644))
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		return cfg
+	}
+
+	updates := (&Bot{cfg: load(true)}).AllowedUpdates()
+	assert.Contains(t, updates, tgbotapi.UpdateTypeMessageReaction)
+
+	updates = (&Bot{cfg: load(false)}).AllowedUpdates()
+	assert.NotEmpty(t, updates, "Empty list keeps reactions of the previous request")
+	assert.Contains(t, updates, tgbotapi.UpdateTypeMessage)
+	assert.NotContains(t, updates, tgbotapi.UpdateTypeMessageReaction)
+}
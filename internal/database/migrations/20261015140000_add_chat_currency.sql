@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS chat_currency (
+    chat_id INTEGER PRIMARY KEY,
+    code TEXT NOT NULL,
+    symbol TEXT NOT NULL DEFAULT '',
+    precision INTEGER NOT NULL DEFAULT 0,
+    rate REAL NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS chat_currency;
+-- +goose StatementEnd
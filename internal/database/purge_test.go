@@ -0,0 +1,77 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// insertConversationMessage saves a message of the conversation created the given time ago
+func insertConversationMessage(t *testing.T, db *sqliteDB, chatID, conversationID int64, messageID int, saved bool, age time.Duration) {
+	t.Helper()
+	_, err := db.db.Exec(`
+		INSERT INTO conversation_history (chat_id, conversation_id, message_id, user_id, role, text, saved, created_at)
+		VALUES (?, ?, ?, 1, 'user', 'text', ?, ?)`,
+		chatID, conversationID, messageID, saved, time.Now().UTC().Add(-age).Format(time.DateTime))
+	require.NoError(t, err)
+}
+
+func countRows(t *testing.T, db *sqliteDB, query string, args ...any) int {
+	t.Helper()
+	var count int
+	require.NoError(t, db.db.QueryRow(query, args...).Scan(&count))
+	return count
+}
+
+func TestPurgeOldConversations(t *testing.T) {
+	db := newMigratedTestDB(t)
+	const day = 24 * time.Hour
+
+	// old conversation with a split answer
+	insertConversationMessage(t, db, 10, 1, 1, false, 40*day)
+	insertConversationMessage(t, db, 10, 1, 2, false, 35*day)
+	require.NoError(t, db.SaveMessagePart(10, 3, 2))
+	// old conversation continued recently
+	insertConversationMessage(t, db, 10, 2, 4, false, 40*day)
+	insertConversationMessage(t, db, 10, 2, 5, false, time.Hour)
+	require.NoError(t, db.SaveMessagePart(10, 6, 5))
+	// old saved conversation
+	insertConversationMessage(t, db, 10, 3, 7, true, 40*day)
+
+	deleted, err := db.PurgeOldConversations(30, 100)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	assert.Equal(t, 0, countRows(t, db, "SELECT COUNT(*) FROM conversation_history WHERE conversation_id = 1"))
+	assert.Equal(t, 2, countRows(t, db, "SELECT COUNT(*) FROM conversation_history WHERE conversation_id = 2"), "Chain isn't cut in the middle")
+	assert.Equal(t, 1, countRows(t, db, "SELECT COUNT(*) FROM conversation_history WHERE conversation_id = 3"), "Saved conversation is kept")
+
+	firstMessageID, err := db.GetFirstMessagePart(10, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, firstMessageID, "Parts of the purged answer are deleted")
+	firstMessageID, err = db.GetFirstMessagePart(10, 6)
+	require.NoError(t, err)
+	assert.Equal(t, 5, firstMessageID)
+}
+
+func TestPurgeOldConversations_Batches(t *testing.T) {
+	db := newMigratedTestDB(t)
+	for conversationID := range int64(3) {
+		insertConversationMessage(t, db, 10, conversationID, int(conversationID)+1, false, 40*24*time.Hour)
+	}
+
+	deleted, err := db.PurgeOldConversations(30, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	assert.Equal(t, 1, countRows(t, db, "SELECT COUNT(*) FROM conversation_history"))
+
+	deleted, err = db.PurgeOldConversations(30, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	deleted, err = db.PurgeOldConversations(30, 2)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
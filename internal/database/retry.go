@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const busyRetries = 5
+
+// delay before the first retry, it grows with each attempt
+var busyRetryDelay = 50 * time.Millisecond
+
+// isBusyError reports whether the database was locked by another connection
+func isBusyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		// extended result codes keep the primary code in the lower byte
+		code := sqliteErr.Code() & 0xff
+		return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+	}
+	return strings.Contains(err.Error(), "database is locked") || strings.Contains(err.Error(), "database table is locked")
+}
+
+// retryBusy runs the operation again while the database is locked,
+// other errors are returned right away
+func retryBusy(ctx context.Context, l logger.Logger, op func() error) error {
+	var err error
+	for attempt := 1; attempt <= busyRetries; attempt++ {
+		if err = op(); !isBusyError(err) || attempt == busyRetries {
+			return err
+		}
+		l.WithFields(logger.Fields{
+			"attempt": attempt,
+			"error":   err.Error(),
+		}).Warn("Database locked, retrying...")
+		select {
+		case <-time.After(busyRetryDelay * time.Duration(attempt)):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+	return err
+}
+
+// WithRetry runs the operation retrying it while the database is locked,
+// the operation must be safe to repeat, e.g. a single statement
+func (s *sqliteDB) WithRetry(ctx context.Context, op func() error) error {
+	return retryBusy(ctx, s.logger, op)
+}
+
+func (s *sqliteDB) ExecWithRetry(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var res sql.Result
+	err := s.WithRetry(ctx, func() error {
+		var err error
+		res, err = s.ExecContext(ctx, query, args...)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestDB(t *testing.T, dsn string) *sql.DB {
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestExecWithRetry_LockedDatabase(t *testing.T) {
+	busyRetryDelay = 20 * time.Millisecond
+	path := filepath.Join(t.TempDir(), "test.db")
+	locker := openTestDB(t, path)
+	_, err := locker.Exec("CREATE TABLE items (name TEXT)")
+	require.NoError(t, err)
+
+	// the database is written by another connection
+	ctx := context.Background()
+	conn, err := locker.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, "BEGIN EXCLUSIVE")
+	require.NoError(t, err)
+
+	db := &sqliteDB{db: openTestDB(t, path+"?_pragma=busy_timeout(0)"), logger: logger.NewTestLogger()}
+	_, err = db.Exec("INSERT INTO items (name) VALUES ('a')")
+	require.Error(t, err)
+	assert.True(t, isBusyError(err))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		conn.ExecContext(ctx, "COMMIT")
+	}()
+	_, err = db.ExecWithRetry(ctx, "INSERT INTO items (name) VALUES ('b')")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM items").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestWithRetry(t *testing.T) {
+	busyRetryDelay = time.Millisecond
+	db := &sqliteDB{logger: logger.NewTestLogger()}
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		calls := 0
+		err := db.WithRetry(context.Background(), func() error {
+			calls++
+			return sql.ErrNoRows
+		})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("gives up after retries", func(t *testing.T) {
+		calls := 0
+		err := db.WithRetry(context.Background(), func() error {
+			calls++
+			return errors.New("database is locked (5) (SQLITE_BUSY)")
+		})
+		require.Error(t, err)
+		assert.Equal(t, busyRetries, calls)
+	})
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	assert.Equal(t, "data.db", sqliteDSN("data.db"))
+	assert.Equal(t,
+		"data.db?_busy_timeout=10000&_cache=shared&_journal=WAL&_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)",
+		sqliteDSN("data.db?_busy_timeout=10000&_cache=shared&_journal=WAL"),
+	)
+	assert.Equal(t,
+		"data.db?_busy_timeout=10000&_pragma=busy_timeout(500)",
+		sqliteDSN("data.db?_busy_timeout=10000&_pragma=busy_timeout(500)"),
+		"_pragma of the DSN wins",
+	)
+}
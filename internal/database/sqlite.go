@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"time"
 
@@ -21,7 +22,7 @@ type sqliteDB struct {
 }
 
 func NewSQLiteDB(cfg *config.Config, log logger.Logger) (Database, error) {
-	db, err := sql.Open("sqlite", cfg.GetDatabaseDSN())
+	db, err := sql.Open("sqlite", sqliteDSN(cfg.GetDatabaseDSN()))
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +50,38 @@ func NewSQLiteDB(cfg *config.Config, log logger.Logger) (Database, error) {
 	return &sqliteDB{db: db, logger: log}, nil
 }
 
+// pragmas set for each connection by the sqlite driver, keyed by the DSN params
+// of the config, the driver doesn't read these params itself
+var dsnPragmas = map[string]string{
+	"_busy_timeout": "busy_timeout",
+	"_journal":      "journal_mode",
+	"_synchronous":  "synchronous",
+}
+
+// sqliteDSN converts the pragma params of the DSN (e.g. _busy_timeout=10000) into
+// _pragma params of the driver, pragmas already set with _pragma are kept
+func sqliteDSN(dsn string) string {
+	path, query, found := strings.Cut(dsn, "?")
+	if !found {
+		return dsn
+	}
+	params := strings.Split(query, "&")
+	set := map[string]bool{}
+	for _, param := range params {
+		if value, ok := strings.CutPrefix(param, "_pragma="); ok {
+			name, _, _ := strings.Cut(value, "(")
+			set[strings.ToLower(name)] = true
+		}
+	}
+	for _, param := range slices.Clone(params) {
+		key, value, _ := strings.Cut(param, "=")
+		if pragma, ok := dsnPragmas[key]; ok && value != "" && !set[pragma] {
+			params = append(params, fmt.Sprintf("_pragma=%s(%s)", pragma, value))
+		}
+	}
+	return path + "?" + strings.Join(params, "&")
+}
+
 func (s *sqliteDB) Exec(query string, args ...any) (sql.Result, error) {
 	return s.db.Exec(query, args...)
 }
@@ -69,24 +102,6 @@ func (s *sqliteDB) Close() error {
 	return s.db.Close()
 }
 
-func (s *sqliteDB) ExecWithRetry(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	var res sql.Result
-	var err error
-	for i := range 3 {
-		res, err = s.ExecContext(ctx, query, args...)
-		if err == nil || !strings.Contains(err.Error(), "database is locked") {
-			return res, err
-		}
-		s.logger.WithFields(logger.Fields{
-			"attempt": i + 1,
-			"query":   query,
-			"error":   err.Error(),
-		}).Warn("Database locked, retrying...")
-		time.Sleep(100 * time.Millisecond * time.Duration(i+1))
-	}
-	return res, err
-}
-
 func (s *sqliteDB) SaveChatModel(chatID int64, model string) error {
 	_, err := s.db.Exec(`
 		INSERT INTO chat_settings (chat_id, model) 
@@ -105,8 +120,254 @@ func (s *sqliteDB) GetChatModel(chatID int64) (string, error) {
 	return model, err
 }
 
+// DeleteChatModel resets the chat model to the default one, other settings of the chat are kept
 func (s *sqliteDB) DeleteChatModel(chatID int64) error {
-	_, err := s.db.Exec("DELETE FROM chat_settings WHERE chat_id = ?", chatID)
+	_, err := s.db.Exec("DELETE FROM chat_settings WHERE chat_id = ? AND NOT free_only AND NOT memory", chatID)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("UPDATE chat_settings SET model = '', updated_at = CURRENT_TIMESTAMP WHERE chat_id = ?", chatID)
+	return err
+}
+
+func (s *sqliteDB) SaveChatFreeOnly(chatID int64, freeOnly bool) error {
+	_, err := s.db.Exec(`
+		INSERT INTO chat_settings (chat_id, model, free_only)
+		VALUES (?, '', ?)
+		ON CONFLICT(chat_id) DO UPDATE SET free_only = excluded.free_only, updated_at = CURRENT_TIMESTAMP
+	`, chatID, freeOnly)
+	return err
+}
+
+func (s *sqliteDB) GetChatFreeOnly(chatID int64) (bool, error) {
+	var freeOnly bool
+	err := s.db.QueryRow("SELECT free_only FROM chat_settings WHERE chat_id = ?", chatID).Scan(&freeOnly)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return freeOnly, err
+}
+
+func (s *sqliteDB) SaveChatMemory(chatID int64, memory bool) error {
+	_, err := s.db.Exec(`
+		INSERT INTO chat_settings (chat_id, model, memory)
+		VALUES (?, '', ?)
+		ON CONFLICT(chat_id) DO UPDATE SET memory = excluded.memory, updated_at = CURRENT_TIMESTAMP
+	`, chatID, memory)
+	return err
+}
+
+func (s *sqliteDB) GetChatMemory(chatID int64) (bool, error) {
+	var memory bool
+	err := s.db.QueryRow("SELECT memory FROM chat_settings WHERE chat_id = ?", chatID).Scan(&memory)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return memory, err
+}
+
+func (s *sqliteDB) SaveUserModel(userID int64, model string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO user_model_preferences (user_id, model)
+		VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET model = excluded.model, updated_at = CURRENT_TIMESTAMP
+	`, userID, model)
+	return err
+}
+
+// GetUserModel returns the personal default model of the user, empty if not set
+func (s *sqliteDB) GetUserModel(userID int64) (string, error) {
+	var model string
+	err := s.db.QueryRow("SELECT model FROM user_model_preferences WHERE user_id = ?", userID).Scan(&model)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return model, err
+}
+
+func (s *sqliteDB) DeleteUserModel(userID int64) error {
+	_, err := s.db.Exec("DELETE FROM user_model_preferences WHERE user_id = ?", userID)
+	return err
+}
+
+func (s *sqliteDB) SaveMessagePart(chatID int64, messageID, firstMessageID int) error {
+	_, err := s.db.Exec(`
+		INSERT INTO message_parts (chat_id, message_id, first_message_id)
+		VALUES (?, ?, ?)
+		ON CONFLICT(chat_id, message_id) DO UPDATE SET first_message_id = excluded.first_message_id
+	`, chatID, messageID, firstMessageID)
+	return err
+}
+
+// GetFirstMessagePart returns the first message of a split answer,
+// the message itself if it's not a part of one
+func (s *sqliteDB) GetFirstMessagePart(chatID int64, messageID int) (int, error) {
+	var firstMessageID int
+	err := s.db.QueryRow(
+		"SELECT first_message_id FROM message_parts WHERE chat_id = ? AND message_id = ?",
+		chatID, messageID,
+	).Scan(&firstMessageID)
+	if err == sql.ErrNoRows {
+		return messageID, nil
+	}
+	return firstMessageID, err
+}
+
+func (s *sqliteDB) AddReminder(reminder Reminder) (int64, error) {
+	result, err := s.db.Exec(`
+		INSERT INTO reminders (chat_id, message_id, user_id, text, remind_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, reminder.ChatID, reminder.MessageID, reminder.UserID, reminder.Text, reminder.RemindAt.UTC().Truncate(time.Second))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (s *sqliteDB) CountPendingReminders(userID int64) (int, error) {
+	var count int
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM reminders WHERE user_id = ? AND status = ?",
+		userID, ReminderStatusPending,
+	).Scan(&count)
+	return count, err
+}
+
+// GetDueReminders returns pending reminders that should be sent by now, oldest first
+func (s *sqliteDB) GetDueReminders(now time.Time) ([]Reminder, error) {
+	rows, err := s.db.Query(`
+		SELECT id, chat_id, message_id, user_id, text, remind_at
+		FROM reminders
+		WHERE status = ? AND remind_at <= ?
+		ORDER BY remind_at
+	`, ReminderStatusPending, now.UTC().Truncate(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		var reminder Reminder
+		if err := rows.Scan(
+			&reminder.ID,
+			&reminder.ChatID,
+			&reminder.MessageID,
+			&reminder.UserID,
+			&reminder.Text,
+			&reminder.RemindAt,
+		); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+func (s *sqliteDB) SetReminderStatus(id int64, status string) error {
+	_, err := s.db.Exec("UPDATE reminders SET status = ? WHERE id = ?", status, id)
+	return err
+}
+
+func (s *sqliteDB) AddChatCost(chatID int64, model string, cost float64) error {
+	_, err := s.db.Exec(`
+		INSERT INTO cost_ledger (chat_id, model_name, cost)
+		VALUES (?, ?, ?)
+	`, chatID, model, cost)
+	return err
+}
+
+// GetChatDailyCost returns the chat spend since the start of the current day (UTC)
+func (s *sqliteDB) GetChatDailyCost(chatID int64) (float64, error) {
+	var cost float64
+	err := s.db.QueryRow(
+		"SELECT COALESCE(SUM(cost), 0) FROM cost_ledger WHERE chat_id = ? AND created_at >= date('now')",
+		chatID,
+	).Scan(&cost)
+	return cost, err
+}
+
+// GetChatUsageByUser returns the usage of answers in the chat grouped by the public ID
+// of the asker, the most expensive first. Period 0 means all time
+func (s *sqliteDB) GetChatUsageByUser(chatID int64, period time.Duration) ([]UsageStats, error) {
+	return s.getChatUsage(`
+		SELECT COALESCE(u.public_id, ''), COUNT(*), COALESCE(SUM(a.total_tokens), 0), COALESCE(SUM(a.total_cost), 0)
+		FROM conversation_history a
+		JOIN conversation_history q ON q.id = a.parent_message_id
+		LEFT JOIN users u ON u.id = q.user_id
+		WHERE a.chat_id = ? AND a.role = 'assistant' AND a.created_at >= datetime('now', ?)
+		GROUP BY q.user_id
+		ORDER BY 4 DESC, 3 DESC
+	`, chatID, period)
+}
+
+// GetChatUsageByModel returns the usage of answers in the chat grouped by the model,
+// the most expensive first. Period 0 means all time
+func (s *sqliteDB) GetChatUsageByModel(chatID int64, period time.Duration) ([]UsageStats, error) {
+	return s.getChatUsage(`
+		SELECT COALESCE(model_name, ''), COUNT(*), COALESCE(SUM(total_tokens), 0), COALESCE(SUM(total_cost), 0)
+		FROM conversation_history
+		WHERE chat_id = ? AND role = 'assistant' AND created_at >= datetime('now', ?)
+		GROUP BY model_name
+		ORDER BY 4 DESC, 3 DESC
+	`, chatID, period)
+}
+
+func (s *sqliteDB) getChatUsage(query string, chatID int64, period time.Duration) ([]UsageStats, error) {
+	// all time is limited by a date before any message
+	modifier := "-100 years"
+	if period > 0 {
+		modifier = fmt.Sprintf("-%d seconds", int(period.Seconds()))
+	}
+	rows, err := s.db.Query(query, chatID, modifier)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []UsageStats
+	for rows.Next() {
+		var item UsageStats
+		if err := rows.Scan(&item.Key, &item.Messages, &item.Tokens, &item.Cost); err != nil {
+			return nil, err
+		}
+		stats = append(stats, item)
+	}
+	return stats, rows.Err()
+}
+
+func (s *sqliteDB) SaveChatCurrency(chatID int64, currency config.CurrencyConfig) error {
+	_, err := s.db.Exec(`
+		INSERT INTO chat_currency (chat_id, code, symbol, precision, rate)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			code = excluded.code,
+			symbol = excluded.symbol,
+			precision = excluded.precision,
+			rate = excluded.rate,
+			updated_at = CURRENT_TIMESTAMP
+	`, chatID, currency.Code, currency.Symbol, currency.Precision, currency.Rate)
+	return err
+}
+
+// GetChatCurrency returns the chat currency, nil if the chat uses the global one
+func (s *sqliteDB) GetChatCurrency(chatID int64) (*config.CurrencyConfig, error) {
+	currency := &config.CurrencyConfig{}
+	err := s.db.QueryRow(
+		"SELECT code, symbol, precision, rate FROM chat_currency WHERE chat_id = ?",
+		chatID,
+	).Scan(&currency.Code, &currency.Symbol, &currency.Precision, &currency.Rate)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return currency, nil
+}
+
+func (s *sqliteDB) DeleteChatCurrency(chatID int64) error {
+	_, err := s.db.Exec("DELETE FROM chat_currency WHERE chat_id = ?", chatID)
 	return err
 }
 
@@ -247,9 +508,53 @@ func (s *sqliteDB) PurgeOldTasks(retentionDays int) error {
 	return err
 }
 
+// PurgeOldConversations deletes up to limit whole conversations whose newest message is older
+// than retentionDays, so chains are never cut in the middle. Saved conversations are kept.
+// Parts of split answers are deleted in the same transaction. Returns the number of deleted messages
+func (s *sqliteDB) PurgeOldConversations(retentionDays, limit int) (int64, error) {
+	const oldConversations = `
+		SELECT chat_id, conversation_id
+		FROM conversation_history
+		GROUP BY chat_id, conversation_id
+		HAVING MAX(created_at) < datetime('now', ?) AND MAX(saved) = 0
+		ORDER BY chat_id, conversation_id
+		LIMIT ?`
+	retention := fmt.Sprintf("-%d days", retentionDays)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM message_parts
+		WHERE (chat_id, first_message_id) IN (
+			SELECT chat_id, message_id
+			FROM conversation_history
+			WHERE (chat_id, conversation_id) IN (`+oldConversations+`)
+		)
+	`, retention, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete message parts: %w", err)
+	}
+	result, err := tx.Exec(`
+		DELETE FROM conversation_history
+		WHERE (chat_id, conversation_id) IN (`+oldConversations+`)
+	`, retention, limit)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
 func (s *sqliteDB) LoadAllChatModels() (map[int64]string, error) {
 	models := make(map[int64]string)
-	rows, err := s.db.Query("SELECT chat_id, model FROM chat_settings")
+	rows, err := s.db.Query("SELECT chat_id, model FROM chat_settings WHERE model != ''")
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/muratoffalex/gachigazer/internal/config"
 	"github.com/muratoffalex/gachigazer/internal/telegram"
 )
 
@@ -17,15 +18,57 @@ type Database interface {
 	QueryRow(query string, args ...any) *sql.Row
 	Close() error
 	ExecWithRetry(ctx context.Context, query string, args ...any) (sql.Result, error)
+	// WithRetry repeats the operation while the database is locked by another connection
+	WithRetry(ctx context.Context, op func() error) error
 
 	GetUser(userID int64) (*User, error)
+	// GetUserByUsername finds the user by username without @, case-insensitive
+	GetUserByUsername(username string) (*User, error)
 	SaveUser(user User) error
+	SetUserAbout(userID int64, about string) error
 
 	// Chat models management
 	SaveChatModel(chatID int64, model string) error
 	GetChatModel(chatID int64) (string, error)
 	DeleteChatModel(chatID int64) error
 	LoadAllChatModels() (map[int64]string, error)
+	SaveUserModel(userID int64, model string) error
+	GetUserModel(userID int64) (string, error)
+	DeleteUserModel(userID int64) error
+
+	// Chat settings, stored with the chat model, an empty model means the default one
+	SaveChatFreeOnly(chatID int64, freeOnly bool) error
+	GetChatFreeOnly(chatID int64) (bool, error)
+	SaveChatMemory(chatID int64, memory bool) error
+	GetChatMemory(chatID int64) (bool, error)
+
+	// Facts about the user saved with remember tool
+	AddUserFact(userID int64, fact string) (int64, error)
+	GetUserFacts(userID int64) ([]UserFact, error)
+	DeleteUserFact(userID, id int64) error
+	ClearUserFacts(userID int64) (int64, error)
+
+	// Cost tracking
+	AddChatCost(chatID int64, model string, cost float64) error
+	GetChatDailyCost(chatID int64) (float64, error)
+	GetChatUsageByUser(chatID int64, period time.Duration) ([]UsageStats, error)
+	GetChatUsageByModel(chatID int64, period time.Duration) ([]UsageStats, error)
+	SaveChatCurrency(chatID int64, currency config.CurrencyConfig) error
+	GetChatCurrency(chatID int64) (*config.CurrencyConfig, error)
+	DeleteChatCurrency(chatID int64) error
+
+	// Parts of answers split into several messages
+	SaveMessagePart(chatID int64, messageID, firstMessageID int) error
+	GetFirstMessagePart(chatID int64, messageID int) (int, error)
+
+	// Reminders
+	AddReminder(reminder Reminder) (int64, error)
+	CountPendingReminders(userID int64) (int, error)
+	GetDueReminders(now time.Time) ([]Reminder, error)
+	SetReminderStatus(id int64, status string) error
+
+	// Onboarding
+	MarkUserOnboarded(userID, chatID int64) (bool, error)
 
 	// Message storage
 	SaveMessage(chatID int64, messageID int, username, mediaGroupID string, main bool, data []byte) error
@@ -35,6 +78,38 @@ type Database interface {
 	GetMessagesWithMediaGroupID(chatID int64, mediaGroupID string) ([]telegram.Update, error)
 	PurgeOldMessages(retentionDays int) error
 	PurgeOldTasks(retentionDays int) error
+	PurgeOldConversations(retentionDays, limit int) (int64, error)
+}
+
+const (
+	ReminderStatusPending = "pending"
+	ReminderStatusSent    = "sent"
+	ReminderStatusFailed  = "failed"
+)
+
+// UsageStats is the usage of AI answers grouped by a user public ID or a model name
+type UsageStats struct {
+	Key      string
+	Messages int
+	Tokens   int64
+	Cost     float64
+}
+
+type Reminder struct {
+	ID        int64
+	ChatID    int64
+	MessageID int
+	UserID    int64
+	Text      string
+	RemindAt  time.Time
+}
+
+// UserFact is a fact about the user remembered across conversations
+type UserFact struct {
+	ID        int64
+	UserID    int64
+	Fact      string
+	CreatedAt time.Time
 }
 
 type User struct {
@@ -42,6 +117,7 @@ type User struct {
 	PublicID  string    `json:"public_id"`
 	FirstName string    `json:"first_name"`
 	Username  string    `json:"username"`
+	About     string    `json:"about"` // set by the user with /setabout
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
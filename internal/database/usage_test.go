@@ -0,0 +1,83 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// insertAnswer saves a question of the user and its answer created the given time ago
+func insertAnswer(t *testing.T, db *sqliteDB, chatID, userID int64, model string, tokens int, cost float64, age time.Duration) {
+	t.Helper()
+	createdAt := time.Now().UTC().Add(-age).Format(time.DateTime)
+	result, err := db.db.Exec(`
+		INSERT INTO conversation_history (chat_id, message_id, user_id, role, text, created_at)
+		VALUES (?, 1, ?, 'user', 'question', ?)`, chatID, userID, createdAt)
+	require.NoError(t, err)
+	questionID, err := result.LastInsertId()
+	require.NoError(t, err)
+	_, err = db.db.Exec(`
+		INSERT INTO conversation_history (parent_message_id, chat_id, message_id, user_id, role, text, model_name, total_tokens, total_cost, created_at)
+		VALUES (?, ?, 2, 0, 'assistant', 'answer', ?, ?, ?, ?)`, questionID, chatID, model, tokens, cost, createdAt)
+	require.NoError(t, err)
+}
+
+func newUsageTestDB(t *testing.T) (*sqliteDB, map[int64]string) {
+	db := newMigratedTestDB(t)
+	require.NoError(t, db.SaveUser(User{ID: 1, FirstName: "Alice", Username: "alice"}))
+	require.NoError(t, db.SaveUser(User{ID: 2, FirstName: "Bob", Username: "bob"}))
+	publicIDs := map[int64]string{}
+	for _, id := range []int64{1, 2} {
+		user, err := db.GetUser(id)
+		require.NoError(t, err)
+		publicIDs[id] = user.PublicID
+	}
+
+	const day = 24 * time.Hour
+	insertAnswer(t, db, 10, 1, "openrouter:cheap", 100, 0.125, time.Hour)
+	insertAnswer(t, db, 10, 1, "openrouter:smart", 200, 0.25, 2*day)
+	insertAnswer(t, db, 10, 2, "openrouter:smart", 50, 0.5, time.Hour)
+	insertAnswer(t, db, 10, 1, "openrouter:cheap", 1000, 1, 10*day)
+	insertAnswer(t, db, 20, 2, "openrouter:cheap", 300, 3, time.Hour)
+	return db, publicIDs
+}
+
+func TestGetChatUsageByUser(t *testing.T) {
+	db, publicIDs := newUsageTestDB(t)
+
+	stats, err := db.GetChatUsageByUser(10, 7*24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []UsageStats{
+		{Key: publicIDs[2], Messages: 1, Tokens: 50, Cost: 0.5},
+		{Key: publicIDs[1], Messages: 2, Tokens: 300, Cost: 0.375},
+	}, stats, "Most expensive first, answers older than the period and of other chats are skipped")
+
+	stats, err = db.GetChatUsageByUser(10, 0)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, UsageStats{Key: publicIDs[1], Messages: 3, Tokens: 1300, Cost: 1.375}, stats[0], "All time")
+
+	stats, err = db.GetChatUsageByUser(30, 0)
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+}
+
+func TestGetChatUsageByModel(t *testing.T) {
+	db, _ := newUsageTestDB(t)
+
+	stats, err := db.GetChatUsageByModel(10, 7*24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []UsageStats{
+		{Key: "openrouter:smart", Messages: 2, Tokens: 250, Cost: 0.75},
+		{Key: "openrouter:cheap", Messages: 1, Tokens: 100, Cost: 0.125},
+	}, stats)
+
+	stats, err = db.GetChatUsageByModel(10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []UsageStats{
+		{Key: "openrouter:cheap", Messages: 2, Tokens: 1100, Cost: 1.125},
+		{Key: "openrouter:smart", Messages: 2, Tokens: 250, Cost: 0.75},
+	}, stats)
+}
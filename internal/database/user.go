@@ -2,15 +2,37 @@ package database
 
 import (
 	"crypto/rand"
+	"database/sql"
 )
 
 func (s *sqliteDB) GetUser(userID int64) (*User, error) {
 	user := &User{}
-	err := s.db.QueryRow("SELECT id, public_id, first_name, username, created_at FROM users WHERE id = ?", userID).Scan(
+	err := s.db.QueryRow("SELECT id, public_id, first_name, username, about, created_at FROM users WHERE id = ?", userID).Scan(
 		&user.ID,
 		&user.PublicID,
 		&user.FirstName,
 		&user.Username,
+		&user.About,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+func (s *sqliteDB) GetUserByUsername(username string) (*User, error) {
+	user := &User{}
+	err := s.db.QueryRow(
+		"SELECT id, public_id, first_name, username, about, created_at FROM users WHERE username = ? COLLATE NOCASE ORDER BY updated_at DESC LIMIT 1",
+		username,
+	).Scan(
+		&user.ID,
+		&user.PublicID,
+		&user.FirstName,
+		&user.Username,
+		&user.About,
 		&user.CreatedAt,
 	)
 	if err != nil {
@@ -41,6 +63,41 @@ func (s *sqliteDB) SaveUser(user User) error {
 	return err
 }
 
+// SetUserAbout saves the user-provided description, empty clears it
+func (s *sqliteDB) SetUserAbout(userID int64, about string) error {
+	result, err := s.db.Exec(`
+		UPDATE users SET about = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, about, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// MarkUserOnboarded records that the user has seen onboarding,
+// returns false if the user was already onboarded before
+func (s *sqliteDB) MarkUserOnboarded(userID, chatID int64) (bool, error) {
+	result, err := s.db.Exec(`
+		INSERT OR IGNORE INTO user_onboarding (user_id, chat_id)
+		VALUES (?, ?)
+	`, userID, chatID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
 func generatePublicID() (string, error) {
 	bytes := make([]byte, 3)
 	if _, err := rand.Read(bytes); err != nil {
@@ -68,3 +125,60 @@ func base36Encode(num uint32) string {
 	}
 	return string(result)
 }
+
+func (s *sqliteDB) AddUserFact(userID int64, fact string) (int64, error) {
+	result, err := s.db.Exec("INSERT INTO user_memory (user_id, fact) VALUES (?, ?)", userID, fact)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetUserFacts returns the remembered facts about the user, oldest first
+func (s *sqliteDB) GetUserFacts(userID int64) ([]UserFact, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, fact, created_at
+		FROM user_memory
+		WHERE user_id = ?
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var facts []UserFact
+	for rows.Next() {
+		var fact UserFact
+		if err := rows.Scan(&fact.ID, &fact.UserID, &fact.Fact, &fact.CreatedAt); err != nil {
+			return nil, err
+		}
+		facts = append(facts, fact)
+	}
+	return facts, rows.Err()
+}
+
+// DeleteUserFact removes the fact of the user, sql.ErrNoRows if the user has no such fact
+func (s *sqliteDB) DeleteUserFact(userID, id int64) error {
+	result, err := s.db.Exec("DELETE FROM user_memory WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ClearUserFacts removes all facts about the user and returns their number
+func (s *sqliteDB) ClearUserFacts(userID int64) (int64, error) {
+	result, err := s.db.Exec("DELETE FROM user_memory WHERE user_id = ?", userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
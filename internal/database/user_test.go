@@ -0,0 +1,125 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMigratedTestDB(t *testing.T) *sqliteDB {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, RunMigrations(db))
+	return &sqliteDB{db: db, logger: logger.NewTestLogger()}
+}
+
+func TestGetUserByUsername(t *testing.T) {
+	db := newMigratedTestDB(t)
+	require.NoError(t, db.SaveUser(User{ID: 1, FirstName: "Alice", Username: "Alice_W"}))
+	require.NoError(t, db.SaveUser(User{ID: 2, FirstName: "Bob", Username: "bob"}))
+
+	user, err := db.GetUserByUsername("alice_w")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, user.ID)
+	assert.Equal(t, "Alice", user.FirstName)
+	assert.NotEmpty(t, user.PublicID)
+
+	_, err = db.GetUserByUsername("carol")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUserFacts(t *testing.T) {
+	db := newMigratedTestDB(t)
+
+	first, err := db.AddUserFact(1, "The user is a vegetarian")
+	require.NoError(t, err)
+	_, err = db.AddUserFact(1, "The user lives in Berlin")
+	require.NoError(t, err)
+	_, err = db.AddUserFact(2, "The user is a designer")
+	require.NoError(t, err)
+
+	facts, err := db.GetUserFacts(1)
+	require.NoError(t, err)
+	require.Len(t, facts, 2)
+	assert.Equal(t, "The user is a vegetarian", facts[0].Fact, "Oldest fact first")
+	assert.Equal(t, "The user lives in Berlin", facts[1].Fact)
+
+	assert.ErrorIs(t, db.DeleteUserFact(2, first), sql.ErrNoRows, "Facts of other users are not deleted")
+	require.NoError(t, db.DeleteUserFact(1, first))
+	facts, err = db.GetUserFacts(1)
+	require.NoError(t, err)
+	assert.Len(t, facts, 1)
+
+	count, err := db.ClearUserFacts(1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	facts, err = db.GetUserFacts(1)
+	require.NoError(t, err)
+	assert.Empty(t, facts)
+	facts, err = db.GetUserFacts(2)
+	require.NoError(t, err)
+	assert.Len(t, facts, 1)
+}
+
+func TestChatFreeOnly(t *testing.T) {
+	db := newMigratedTestDB(t)
+
+	freeOnly, err := db.GetChatFreeOnly(10)
+	require.NoError(t, err)
+	assert.False(t, freeOnly, "Paid models are allowed by default")
+
+	require.NoError(t, db.SaveChatModel(10, "openrouter:model"))
+	require.NoError(t, db.SaveChatFreeOnly(10, true))
+	freeOnly, err = db.GetChatFreeOnly(10)
+	require.NoError(t, err)
+	assert.True(t, freeOnly)
+	model, err := db.GetChatModel(10)
+	require.NoError(t, err)
+	assert.Equal(t, "openrouter:model", model, "Chat model is kept")
+
+	require.NoError(t, db.SaveChatFreeOnly(10, false))
+	freeOnly, err = db.GetChatFreeOnly(10)
+	require.NoError(t, err)
+	assert.False(t, freeOnly)
+
+	freeOnly, err = db.GetChatFreeOnly(20)
+	require.NoError(t, err)
+	assert.False(t, freeOnly, "Other chats aren't changed")
+}
+
+func TestChatFreeOnly_KeptOnModelReset(t *testing.T) {
+	db := newMigratedTestDB(t)
+
+	require.NoError(t, db.SaveChatModel(10, "openrouter:model"))
+	require.NoError(t, db.SaveChatFreeOnly(10, true))
+	require.NoError(t, db.DeleteChatModel(10))
+
+	freeOnly, err := db.GetChatFreeOnly(10)
+	require.NoError(t, err)
+	assert.True(t, freeOnly)
+	model, err := db.GetChatModel(10)
+	require.NoError(t, err)
+	assert.Empty(t, model)
+}
+
+func TestChatMemory_KeptOnModelReset(t *testing.T) {
+	db := newMigratedTestDB(t)
+
+	memory, err := db.GetChatMemory(10)
+	require.NoError(t, err)
+	assert.False(t, memory)
+
+	require.NoError(t, db.SaveChatModel(10, "openrouter:model"))
+	require.NoError(t, db.SaveChatMemory(10, true))
+	require.NoError(t, db.DeleteChatModel(10))
+
+	memory, err = db.GetChatMemory(10)
+	require.NoError(t, err)
+	assert.True(t, memory)
+	model, err := db.GetChatModel(10)
+	require.NoError(t, err)
+	assert.Empty(t, model)
+}
@@ -1,6 +1,7 @@
 package fetcher
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -61,10 +62,14 @@ func (f BaseFetcher) cleanText(text string) string {
 }
 
 func (f BaseFetcher) fetch(payload Request) (*http.Response, string, error) {
+	return f.fetchContext(context.Background(), payload)
+}
+
+func (f BaseFetcher) fetchContext(ctx context.Context, payload Request) (*http.Response, string, error) {
 	if _, err := url.ParseRequestURI(payload.URL()); err != nil {
 		return nil, "", fmt.Errorf("invalid URL: %w", err)
 	}
-	req, err := http.NewRequest(payload.Method(), payload.URL(), nil)
+	req, err := http.NewRequestWithContext(ctx, payload.Method(), payload.URL(), nil)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -0,0 +1,97 @@
+package fetcher
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// pages with longer text are articles, even if they mention cookies or subscriptions
+const blockedPageMaxLength = 1500
+
+// phrases of cookie consent walls and paywalls, in lower case
+var blockedPagePhrases = []string{
+	// en
+	"before you continue",
+	"we value your privacy",
+	"accept all cookies",
+	"reject all cookies",
+	"manage cookie preferences",
+	"subscribe to continue reading",
+	"subscribe to read",
+	"subscribe to unlock",
+	"this article is for subscribers",
+	"this content is for subscribers",
+	"already a subscriber",
+	"sign in to continue reading",
+	"create a free account to continue",
+	"you have reached your limit of free articles",
+	// ru
+	"мы используем файлы cookie",
+	"принять все cookie",
+	"доступно только подписчикам",
+	"оформите подписку, чтобы",
+	"чтобы продолжить чтение",
+	// de
+	"alle akzeptieren",
+	"zustimmen und weiter",
+	"jetzt abonnieren und weiterlesen",
+	"bereits abonnent",
+	// fr
+	"tout accepter",
+	"accepter et continuer",
+	"réservé aux abonnés",
+	"déjà abonné",
+	// es
+	"aceptar todas las cookies",
+	"exclusivo para suscriptores",
+	"ya eres suscriptor",
+	// it
+	"accetta tutti",
+	"riservato agli abbonati",
+}
+
+// schema.org marks paid articles, the full text is usually not in the page
+var notAccessibleForFreeRegex = regexp.MustCompile(`(?i)"isAccessibleForFree"\s*:\s*"?false"?`)
+
+// isNotAccessibleForFree checks the structured data of the page, before scripts are removed
+func isNotAccessibleForFree(doc *goquery.Document) bool {
+	paid := false
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		paid = notAccessibleForFreeRegex.MatchString(s.Text())
+		return !paid
+	})
+	return paid
+}
+
+// isBlockedPage reports whether the extracted text is a paywall or a consent wall instead
+// of the page content: the text is short and has a known phrase or the page is marked as paid
+func isBlockedPage(text string, paid bool) bool {
+	if utf8.RuneCountInString(text) > blockedPageMaxLength {
+		return false
+	}
+	if paid {
+		return true
+	}
+	lower := strings.ToLower(text)
+	for _, phrase := range blockedPagePhrases {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedFallbackURL returns the URL of the page copy from the template, {url} is replaced
+// with the page URL, e.g. https://archive.ph/newest/{url}. Empty template - no fallback
+func blockedFallbackURL(template, pageURL string) string {
+	if template == "" {
+		return ""
+	}
+	if !strings.Contains(template, "{url}") {
+		return template + pageURL
+	}
+	return strings.ReplaceAll(template, "{url}", pageURL)
+}
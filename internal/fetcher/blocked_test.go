@@ -0,0 +1,107 @@
+package fetcher
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBlockedPage(t *testing.T) {
+	assert.True(t, isBlockedPage("Before you continue to Google. We use cookies", false))
+	assert.True(t, isBlockedPage("Материал доступно только подписчикам", false))
+	assert.True(t, isBlockedPage("Short teaser of the article", true), "Paid page with short text")
+	assert.False(t, isBlockedPage("Short page without the phrases", false))
+
+	article := strings.Repeat("Long article text. ", 100) + "Accept all cookies"
+	assert.False(t, isBlockedPage(article, false), "Articles mentioning the phrases aren't blocked")
+	assert.False(t, isBlockedPage(article, true), "Paid page with the full text in HTML isn't blocked")
+}
+
+func TestBlockedFallbackURL(t *testing.T) {
+	assert.Empty(t, blockedFallbackURL("", "https://a.com/page"))
+	assert.Equal(t, "https://archive.ph/newest/https://a.com/page", blockedFallbackURL("https://archive.ph/newest/{url}", "https://a.com/page"))
+	assert.Equal(t, "https://archive.ph/newest/https://a.com/page", blockedFallbackURL("https://archive.ph/newest/", "https://a.com/page"))
+}
+
+func htmlResponse(body []byte) func(*http.Request) (*http.Response, error) {
+	return func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		}, nil
+	}
+}
+
+func requestHost(host string) any {
+	return mock.MatchedBy(func(req *http.Request) bool { return req.URL.Host == host })
+}
+
+func TestDefaultFetcher_Handle_BlockedPage(t *testing.T) {
+	consentWall, err := os.ReadFile("testdata/consent_wall.html")
+	require.NoError(t, err)
+	paywall, err := os.ReadFile("testdata/paywall.html")
+	require.NoError(t, err)
+	article := []byte("<html><body><article>" + strings.Repeat("<p>Full article text.</p>", 100) + "</article></body></html>")
+
+	for name, body := range map[string][]byte{"consent wall": consentWall, "paywall": paywall} {
+		t.Run(name, func(t *testing.T) {
+			mockClient := NewMockHTTPClient(t)
+			mockClient.EXPECT().Do(requestHost("news.example.com")).RunAndReturn(htmlResponse(body))
+
+			request, err := NewRequestPayload("https://news.example.com/energie", nil, nil)
+			require.NoError(t, err)
+			response, err := NewDefaultFetcher(logger.NewTestLogger(), mockClient, nil, "").Handle(request)
+			require.ErrorIs(t, err, ErrBlockedPage)
+			assert.True(t, response.IsError)
+			assert.Equal(t, ErrBlockedPage.Error(), response.Content[0].Text)
+		})
+	}
+
+	t.Run("copy from the fallback", func(t *testing.T) {
+		mockClient := NewMockHTTPClient(t)
+		mockClient.EXPECT().Do(requestHost("news.example.com")).RunAndReturn(htmlResponse(paywall))
+		mockClient.EXPECT().Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "https://archive.example.com/newest/https://news.example.com/energie"
+		})).RunAndReturn(htmlResponse(article))
+
+		request, err := NewRequestPayload("https://news.example.com/energie", nil, nil)
+		require.NoError(t, err)
+		response, err := NewDefaultFetcher(logger.NewTestLogger(), mockClient, nil, "https://archive.example.com/newest/{url}").Handle(request)
+		require.NoError(t, err)
+		assert.False(t, response.IsError)
+		require.Len(t, response.Content, 1)
+		assert.Contains(t, response.Content[0].Text, "Full article text.")
+	})
+
+	t.Run("blocked fallback", func(t *testing.T) {
+		mockClient := NewMockHTTPClient(t)
+		mockClient.EXPECT().Do(requestHost("news.example.com")).RunAndReturn(htmlResponse(paywall))
+		mockClient.EXPECT().Do(requestHost("archive.example.com")).RunAndReturn(htmlResponse(consentWall))
+
+		request, err := NewRequestPayload("https://news.example.com/energie", nil, nil)
+		require.NoError(t, err)
+		response, err := NewDefaultFetcher(logger.NewTestLogger(), mockClient, nil, "https://archive.example.com/newest/{url}").Handle(request)
+		require.ErrorIs(t, err, ErrBlockedPage)
+		assert.True(t, response.IsError)
+	})
+
+	t.Run("article isn't blocked", func(t *testing.T) {
+		mockClient := NewMockHTTPClient(t)
+		mockClient.EXPECT().Do(requestHost("news.example.com")).RunAndReturn(htmlResponse(article))
+
+		request, err := NewRequestPayload("https://news.example.com/energie", nil, nil)
+		require.NoError(t, err)
+		response, err := NewDefaultFetcher(logger.NewTestLogger(), mockClient, nil, "https://archive.example.com/newest/{url}").Handle(request)
+		require.NoError(t, err)
+		assert.False(t, response.IsError)
+	})
+}
@@ -6,4 +6,5 @@ var (
 	ErrNotHandle     = errors.New("not handling")
 	ErrInvalidURL    = errors.New("invalid URL")
 	ErrCannotBeEmpty = errors.New("URL cannot be empty")
+	ErrBlockedPage   = errors.New("page appears to be paywalled or behind a consent wall, its content is not available")
 )
@@ -0,0 +1,145 @@
+package fetcher
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+// new style IDs like 2401.12345v2 and old style like hep-th/9901001
+var arxivRegex = regexp.MustCompile(`^(?:https?://)?(?:www\.|export\.)?arxiv\.org/(abs|pdf)/((?:[a-z\-]+(?:\.[A-Z]{2})?/)?[0-9.]+(?:v[0-9]+)?)`)
+
+type arxivFeed struct {
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Summary   string `xml:"summary"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		PrimaryCategory struct {
+			Term string `xml:"term,attr"`
+		} `xml:"primary_category"`
+		Categories []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+		Comment    string `xml:"comment"`
+		JournalRef string `xml:"journal_ref"`
+		DOI        string `xml:"doi"`
+	} `xml:"entry"`
+}
+
+// ArxivFetcher returns the paper metadata and abstract from the arXiv API
+type ArxivFetcher struct {
+	BaseFetcher
+}
+
+func NewArxivFetcher(l logger.Logger, client HTTPClient) ArxivFetcher {
+	return ArxivFetcher{
+		BaseFetcher: NewBaseFetcher(FetcherNameArxiv, `(?:www\.|export\.)?arxiv\.org/(?:abs|pdf)/`, client, l),
+	}
+}
+
+func (f ArxivFetcher) Handle(request Request) (Response, error) {
+	matches := arxivRegex.FindStringSubmatch(request.URL())
+	if len(matches) < 3 {
+		return Response{}, ErrNotHandle
+	}
+	isPDF := matches[1] == "pdf"
+	id := strings.TrimSuffix(matches[2], ".")
+
+	apiURL := "https://export.arxiv.org/api/query?id_list=" + url.QueryEscape(id)
+	resp, body, err := f.fetch(MustNewRequestPayload(apiURL, nil, nil))
+	if err != nil {
+		return f.errorResponse(fmt.Errorf("arxiv paper %s: %w", id, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return f.errorResponse(fmt.Errorf("arxiv paper %s: status %d", id, resp.StatusCode))
+	}
+
+	text, err := f.formatPaper(id, body)
+	if err != nil {
+		return f.errorResponse(fmt.Errorf("arxiv paper %s: %w", id, err))
+	}
+	if isPDF {
+		// the abstract is usually enough, the whole paper goes through the file handling
+		text += fmt.Sprintf(
+			"\n\nThis is only the abstract. The full text is in the PDF: https://arxiv.org/pdf/%s "+
+				"If the question needs the whole paper, suggest the user to send the PDF file as a document",
+			id,
+		)
+	}
+
+	return Response{Content: []Content{{Type: ContentTypeText, Text: text}}}, nil
+}
+
+func (f ArxivFetcher) formatPaper(id, body string) (string, error) {
+	var feed arxivFeed
+	if err := xml.Unmarshal([]byte(body), &feed); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	// unknown IDs return an empty feed, malformed ones an entry with the error
+	if len(feed.Entries) == 0 || strings.Contains(feed.Entries[0].ID, "/api/errors") {
+		return "", fmt.Errorf("not found")
+	}
+	entry := feed.Entries[0]
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "arXiv %s: %s\n", id, f.cleanText(entry.Title))
+	if len(entry.Authors) > 0 {
+		authors := make([]string, len(entry.Authors))
+		for i, author := range entry.Authors {
+			authors[i] = strings.TrimSpace(author.Name)
+		}
+		fmt.Fprintf(&text, "Authors: %s\n", strings.Join(authors, ", "))
+	}
+	categories := []string{}
+	if primary := entry.PrimaryCategory.Term; primary != "" {
+		categories = append(categories, primary)
+	}
+	for _, category := range entry.Categories {
+		if category.Term != "" && category.Term != entry.PrimaryCategory.Term {
+			categories = append(categories, category.Term)
+		}
+	}
+	if len(categories) > 0 {
+		fmt.Fprintf(&text, "Categories: %s\n", strings.Join(categories, ", "))
+	}
+	if date := formatArxivDate(entry.Published); date != "" {
+		fmt.Fprintf(&text, "Submitted: %s\n", date)
+	}
+	if date := formatArxivDate(entry.Updated); date != "" && entry.Updated != entry.Published {
+		fmt.Fprintf(&text, "Updated: %s\n", date)
+	}
+	if entry.JournalRef != "" {
+		fmt.Fprintf(&text, "Journal: %s\n", f.cleanText(entry.JournalRef))
+	}
+	if entry.DOI != "" {
+		fmt.Fprintf(&text, "DOI: %s\n", entry.DOI)
+	}
+	if entry.Comment != "" {
+		fmt.Fprintf(&text, "Comment: %s\n", f.cleanText(entry.Comment))
+	}
+	if entry.Summary != "" {
+		text.WriteString("\nAbstract:\n" + f.cleanText(entry.Summary))
+	}
+
+	return strings.TrimSpace(text.String()), nil
+}
+
+func formatArxivDate(value string) string {
+	date, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
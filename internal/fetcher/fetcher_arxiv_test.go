@@ -0,0 +1,83 @@
+package fetcher
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArxivFetcher_CanHandle(t *testing.T) {
+	f := NewArxivFetcher(logger.NewTestLogger(), nil)
+
+	assert.True(t, f.CanHandle("https://arxiv.org/abs/1706.03762"))
+	assert.True(t, f.CanHandle("https://arxiv.org/pdf/1706.03762v7"))
+	assert.True(t, f.CanHandle("http://export.arxiv.org/abs/hep-th/9901001"))
+	assert.False(t, f.CanHandle("https://arxiv.org/list/cs.CL/recent"))
+	assert.False(t, f.CanHandle("https://arxiv.org"))
+}
+
+func TestArxivFetcher_Handle_Success(t *testing.T) {
+	atom, err := os.ReadFile("testdata/arxiv_success.xml")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		url     string
+		apiURL  string
+		wantPDF bool
+	}{
+		{"abstract", "https://arxiv.org/abs/1706.03762v7?context=cs", "https://export.arxiv.org/api/query?id_list=1706.03762v7", false},
+		{"pdf", "https://arxiv.org/pdf/1706.03762.pdf", "https://export.arxiv.org/api/query?id_list=1706.03762", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := NewMockHTTPClient(t)
+			expectWikipediaRequest(mockClient, tt.apiURL, wikipediaResponse(http.StatusOK, string(atom)))
+
+			f := NewArxivFetcher(logger.NewTestLogger(), mockClient)
+			request, err := NewRequestPayload(tt.url, nil, nil)
+			require.NoError(t, err)
+
+			resp, err := f.Handle(request)
+			require.NoError(t, err)
+			require.False(t, resp.IsError)
+			require.Len(t, resp.Content, 1)
+
+			text := resp.Content[0].Text
+			assert.Contains(t, text, ": Attention Is All You Need\n")
+			assert.Contains(t, text, "Authors: Ashish Vaswani, Noam Shazeer, Niki Parmar\n")
+			assert.Contains(t, text, "Categories: cs.CL, cs.LG\n")
+			assert.Contains(t, text, "Submitted: 2017-06-12\n")
+			assert.Contains(t, text, "Updated: 2023-08-02\n")
+			assert.Contains(t, text, "Comment: 15 pages, 5 figures\n")
+			assert.Contains(t, text, "Abstract:\nThe dominant sequence transduction models are based on complex recurrent or convolutional")
+			if tt.wantPDF {
+				assert.Contains(t, text, "https://arxiv.org/pdf/1706.03762 ")
+				assert.Contains(t, text, "send the PDF file")
+			} else {
+				assert.NotContains(t, text, "PDF")
+			}
+		})
+	}
+}
+
+func TestArxivFetcher_Handle_NotFound(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	expectWikipediaRequest(mockClient,
+		"https://export.arxiv.org/api/query?id_list=9999.99999",
+		wikipediaResponse(http.StatusOK, `<?xml version="1.0" encoding="UTF-8"?><feed xmlns="http://www.w3.org/2005/Atom"><title>ArXiv Query</title></feed>`),
+	)
+
+	f := NewArxivFetcher(logger.NewTestLogger(), mockClient)
+	request, err := NewRequestPayload("https://arxiv.org/abs/9999.99999", nil, nil)
+	require.NoError(t, err)
+
+	resp, err := f.Handle(request)
+	require.Error(t, err)
+	assert.True(t, resp.IsError)
+	assert.Contains(t, err.Error(), "not found")
+}
@@ -1,35 +1,136 @@
 package fetcher
 
 import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
 	"github.com/muratoffalex/gachigazer/internal/logger"
 )
 
-func defaultHandle(f BaseFetcher, request Request) (Response, error) {
+// HostSelectors overrides HTML cleanup of the default fetcher for a host
+type HostSelectors struct {
+	Host string // part of the host
+	// Remove replaces the default removed elements, scripts and styles are always removed
+	Remove []string
+	// Content limits extraction to the element, the whole page is used when it's not found
+	Content string
+}
+
+// defaultHandle extracts the text of the page. A paywall or consent wall is returned as
+// ErrBlockedPage, the copy of the page from blockedFallback is tried first when it's set
+func defaultHandle(f BaseFetcher, request Request, selectors *HostSelectors, blockedFallback string) (Response, error) {
+	response, blocked, err := parseDefault(f, request, selectors)
+	if err != nil || !blocked {
+		return response, err
+	}
+	blockedLog := f.logger.WithField("url", request.URL())
+	blockedLog.Info("Page appears to be paywalled or behind a consent wall")
+
+	if fallbackURL := blockedFallbackURL(blockedFallback, request.URL()); fallbackURL != "" {
+		fallbackRequest, err := NewRequestPayload(fallbackURL, nil, nil)
+		if err != nil {
+			blockedLog.WithError(err).Warn("Invalid fallback URL of the blocked page")
+			return f.errorResponse(ErrBlockedPage)
+		}
+		// the copy has its own layout, host selectors don't match it
+		fallback, blocked, err := parseDefault(f, fallbackRequest, nil)
+		if err == nil && !blocked {
+			blockedLog.WithField("fallback_url", fallbackURL).Info("Blocked page fetched from the fallback")
+			// the canonical URL of the copy isn't the page URL
+			fallback.Content = fallback.Content[:1]
+			return fallback, nil
+		}
+		blockedLog.WithError(err).WithField("fallback_url", fallbackURL).Warn("Fallback of the blocked page failed")
+	}
+	return f.errorResponse(ErrBlockedPage)
+}
+
+// parseDefault returns the text of the page, blocked reports the text looks like
+// a paywall or a consent wall instead of the content
+func parseDefault(f BaseFetcher, request Request, selectors *HostSelectors) (Response, bool, error) {
 	resp, body, err := f.fetch(request)
 	if err != nil {
-		return f.errorResponse(err)
+		response, err := f.errorResponse(err)
+		return response, false, err
 	}
 
 	if !f.isHTMLContent(resp, body) {
 		return Response{
 			Content: []Content{{Type: ContentTypeText, Text: body}},
-		}, nil
+		}, false, nil
 	}
 
 	doc, err := f.getGoqueryDoc(body)
 	if err != nil {
-		return f.errorResponse(err)
+		response, err := f.errorResponse(err)
+		return response, false, err
 	}
 
-	f.cleanDoc(doc)
-	text := doc.Text()
+	canonicalURL := canonicalPageURL(doc, request.URL())
+	paid := isNotAccessibleForFree(doc)
+
+	if selectors != nil && len(selectors.Remove) > 0 {
+		doc.Find(strings.Join(append([]string{"script", "style"}, selectors.Remove...), ", ")).Remove()
+	} else {
+		f.cleanDoc(doc)
+	}
+	content := doc.Selection
+	if selectors != nil && selectors.Content != "" {
+		if found := doc.Find(selectors.Content); found.Length() > 0 {
+			content = found
+		} else {
+			f.logger.WithField("selector", selectors.Content).Warn("Content selector not found, use the whole page")
+		}
+	}
+	text := content.Text()
 	normalizedText := f.cleanText(text)
 
-	return Response{
+	response := Response{
 		Content: []Content{{Type: ContentTypeText, Text: normalizedText}},
-	}, nil
+	}
+	if canonicalURL != "" {
+		response.Content = append(response.Content, Content{Type: ContentTypeCanonicalURL, Text: canonicalURL})
+	}
+	return response, isBlockedPage(normalizedText, paid), nil
+}
+
+// canonicalPageURL returns the absolute URL from <link rel="canonical">, empty if it's missing or invalid
+func canonicalPageURL(doc *goquery.Document, pageURL string) string {
+	href, exists := doc.Find(`link[rel="canonical"]`).First().Attr("href")
+	if !exists || strings.TrimSpace(href) == "" {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	canonical, err := base.Parse(strings.TrimSpace(href))
+	if err != nil || (canonical.Scheme != "http" && canonical.Scheme != "https") {
+		return ""
+	}
+	canonical.Fragment = ""
+	return canonical.String()
+}
+
+// matchSelectors returns the first selectors whose host is part of the URL host
+func matchSelectors(selectors []HostSelectors, rawURL string) *HostSelectors {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	for i, item := range selectors {
+		if item.Host != "" && strings.Contains(parsed.Host, item.Host) {
+			return &selectors[i]
+		}
+	}
+	return nil
 }
 
-func NewDefaultFetcher(l logger.Logger, httpClient HTTPClient) FuncFetcher {
-	return NewFuncFetcher(FetcherNameDefault, "", httpClient, l, defaultHandle)
+// NewDefaultFetcher creates the fetcher of any page, blockedFallback is the URL template
+// of a page copy fetched when the page is behind a paywall or a consent wall
+func NewDefaultFetcher(l logger.Logger, httpClient HTTPClient, selectors []HostSelectors, blockedFallback string) FuncFetcher {
+	return NewFuncFetcher(FetcherNameDefault, "", httpClient, l, func(f BaseFetcher, request Request) (Response, error) {
+		return defaultHandle(f, request, matchSelectors(selectors, request.URL()), blockedFallback)
+	})
 }
@@ -44,7 +44,7 @@ func TestDefaultFetcher_Handle_HTMLContent(t *testing.T) {
 			},
 		}, nil)
 
-	fetcher := NewDefaultFetcher(l, mockClient)
+	fetcher := NewDefaultFetcher(l, mockClient, nil, "")
 
 	request, err := NewRequestPayload(
 		"https://example.com/test",
@@ -82,7 +82,7 @@ And some special characters: !@#$%^&*()`
 			},
 		}, nil)
 
-	fetcher := NewDefaultFetcher(l, mockClient)
+	fetcher := NewDefaultFetcher(l, mockClient, nil, "")
 
 	request, err := NewRequestPayload(
 		"https://example.com/text.txt",
@@ -121,7 +121,7 @@ func TestDefaultFetcher_Handle_JSONContent(t *testing.T) {
 			},
 		}, nil)
 
-	fetcher := NewDefaultFetcher(l, mockClient)
+	fetcher := NewDefaultFetcher(l, mockClient, nil, "")
 
 	request, err := NewRequestPayload(
 		"https://api.example.com/data",
@@ -154,7 +154,7 @@ func TestDefaultFetcher_Handle_InvalidHTML(t *testing.T) {
 			},
 		}, nil)
 
-	fetcher := NewDefaultFetcher(l, mockClient)
+	fetcher := NewDefaultFetcher(l, mockClient, nil, "")
 
 	request, err := NewRequestPayload(
 		"https://example.com/broken",
@@ -179,7 +179,7 @@ func TestDefaultFetcher_Handle_HTTPError(t *testing.T) {
 		Do(mock.AnythingOfType("*http.Request")).
 		Return(nil, assert.AnError)
 
-	fetcher := NewDefaultFetcher(l, mockClient)
+	fetcher := NewDefaultFetcher(l, mockClient, nil, "")
 
 	request, err := NewRequestPayload(
 		"https://example.com/error",
@@ -205,7 +205,7 @@ func TestDefaultFetcher_Handle_EmptyResponse(t *testing.T) {
 			Header:     make(http.Header),
 		}, nil)
 
-	fetcher := NewDefaultFetcher(l, mockClient)
+	fetcher := NewDefaultFetcher(l, mockClient, nil, "")
 
 	request, err := NewRequestPayload(
 		"https://example.com/empty",
@@ -246,7 +246,7 @@ func TestDefaultFetcher_Handle_WithImagesInHTML(t *testing.T) {
 			},
 		}, nil)
 
-	fetcher := NewDefaultFetcher(l, mockClient)
+	fetcher := NewDefaultFetcher(l, mockClient, nil, "")
 
 	request, err := NewRequestPayload(
 		"https://example.com/images",
@@ -262,3 +262,79 @@ func TestDefaultFetcher_Handle_WithImagesInHTML(t *testing.T) {
 
 	assert.Equal(t, expectedText, response.Content[0].Text, "Should remove image tags and keep only text")
 }
+
+func TestDefaultFetcher_Handle_Selectors(t *testing.T) {
+	htmlContent := `<html>
+<head><script>track()</script></head>
+<body>
+	<nav>Navigation</nav>
+	<aside class="article-body"><p>Main text in aside.</p><div class="ads">Buy now</div></aside>
+	<div class="comments">Comments</div>
+	<footer>Footer content</footer>
+</body>
+</html>`
+	selectors := []HostSelectors{
+		{Host: "blog.example.com", Content: ".article-body", Remove: []string{".ads"}},
+		{Host: "example.com", Remove: []string{"nav", "footer"}},
+		{Host: "missing.org", Content: "article"},
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"content and remove", "https://blog.example.com/post", "Main text in aside."},
+		{"custom remove keeps aside", "https://www.example.com/post", "Main text in aside.Buy now Comments"},
+		{"content not found uses the whole page", "https://missing.org/post", "Comments"},
+		{"no match uses default cleanup", "https://other.net/post", "Comments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := NewMockHTTPClient(t)
+			mockClient.EXPECT().
+				Do(mock.AnythingOfType("*http.Request")).
+				Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(htmlContent))),
+					Header:     http.Header{"Content-Type": []string{"text/html"}},
+				}, nil)
+
+			fetcher := NewDefaultFetcher(logger.NewTestLogger(), mockClient, selectors, "")
+			response, err := fetcher.Handle(MustNewRequestPayload(tt.url, nil, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, response.Content[0].Text)
+		})
+	}
+}
+
+func TestDefaultFetcher_Handle_CanonicalURL(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		want string
+	}{
+		{"absolute", `<link rel="canonical" href="https://example.com/article">`, "https://example.com/article"},
+		{"relative", `<link rel="canonical" href="/article#top">`, "https://example.com/article"},
+		{"not http", `<link rel="canonical" href="javascript:void(0)">`, ""},
+		{"missing", ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := NewMockHTTPClient(t)
+			mockClient.EXPECT().
+				Do(mock.AnythingOfType("*http.Request")).
+				Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`<html><head>` + tt.head + `</head><body><p>Article</p></body></html>`))),
+					Header:     http.Header{"Content-Type": []string{"text/html"}},
+				}, nil)
+
+			fetcher := NewDefaultFetcher(logger.NewTestLogger(), mockClient, nil, "")
+			response, err := fetcher.Handle(MustNewRequestPayload("https://example.com/article?utm_source=tg", nil, nil))
+			require.NoError(t, err)
+			assert.Equal(t, "Article", response.GetText())
+			assert.Equal(t, tt.want, response.GetCanonicalURL())
+		})
+	}
+}
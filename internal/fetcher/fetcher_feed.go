@@ -0,0 +1,228 @@
+package fetcher
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+const (
+	// FeedOptionLimit is the request option with the max number of feed items
+	FeedOptionLimit = "limit"
+
+	feedDefaultLimit     = 10
+	feedMaxLimit         = 50
+	feedMaxSummaryLength = 500
+	// the whole output, items that don't fit are dropped
+	feedMaxLength = 8000
+)
+
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+}
+
+type rssFeed struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			PubDate     string `xml:"pubDate"`
+			Description string `xml:"description"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomFeed struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+	} `xml:"entry"`
+}
+
+type feedItem struct {
+	Title   string
+	Link    string
+	Date    string
+	Summary string
+}
+
+// FeedFetcher returns a compact list of RSS 2.0 or Atom feed items
+type FeedFetcher struct {
+	BaseFetcher
+}
+
+func NewFeedFetcher(l logger.Logger, client HTTPClient) FeedFetcher {
+	return FeedFetcher{
+		BaseFetcher: NewBaseFetcher(FetcherNameFeed, `(?i)(?:/feed|/rss|\.rss|\.atom|/atom\.xml|/rss\.xml|/feed\.xml)/?(?:[?#]|$)`, client, l),
+	}
+}
+
+func (f FeedFetcher) Handle(request Request) (Response, error) {
+	limit := feedDefaultLimit
+	if value, ok := request.Options()[FeedOptionLimit].(int); ok && value > 0 {
+		limit = min(value, feedMaxLimit)
+	}
+
+	resp, body, err := f.fetch(request)
+	if err != nil {
+		return f.errorResponse(err)
+	}
+	defer resp.Body.Close()
+
+	title, items, err := f.parseFeed(body)
+	if err != nil {
+		// not a feed, let other fetchers handle the page
+		return Response{}, fmt.Errorf("%w: %w", ErrNotHandle, err)
+	}
+	if len(items) == 0 {
+		return Response{
+			Content: []Content{{Type: ContentTypeText, Text: "No feed items found"}},
+			IsError: true,
+		}, nil
+	}
+
+	return Response{
+		Content: []Content{{Type: ContentTypeText, Text: formatFeed(title, items, limit)}},
+	}, nil
+}
+
+func (f FeedFetcher) parseFeed(body string) (string, []feedItem, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := newFeedDecoder(body).Decode(&root); err != nil {
+		return "", nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	var items []feedItem
+	switch root.XMLName.Local {
+	case "rss":
+		var feed rssFeed
+		if err := newFeedDecoder(body).Decode(&feed); err != nil {
+			return "", nil, fmt.Errorf("failed to parse rss: %w", err)
+		}
+		for _, item := range feed.Channel.Items {
+			items = append(items, feedItem{
+				Title:   strings.TrimSpace(item.Title),
+				Link:    strings.TrimSpace(item.Link),
+				Date:    formatFeedDate(item.PubDate),
+				Summary: f.feedSummary(item.Description),
+			})
+		}
+		return strings.TrimSpace(feed.Channel.Title), items, nil
+	case "feed":
+		var feed atomFeed
+		if err := newFeedDecoder(body).Decode(&feed); err != nil {
+			return "", nil, fmt.Errorf("failed to parse atom: %w", err)
+		}
+		for _, entry := range feed.Entries {
+			item := feedItem{
+				Title:   strings.TrimSpace(entry.Title),
+				Summary: f.feedSummary(entry.Summary),
+			}
+			if item.Summary == "" {
+				item.Summary = f.feedSummary(entry.Content)
+			}
+			for _, link := range entry.Links {
+				if link.Rel == "" || link.Rel == "alternate" {
+					item.Link = link.Href
+					break
+				}
+			}
+			item.Date = formatFeedDate(entry.Published)
+			if item.Date == "" {
+				item.Date = formatFeedDate(entry.Updated)
+			}
+			items = append(items, item)
+		}
+		return strings.TrimSpace(feed.Title), items, nil
+	default:
+		return "", nil, fmt.Errorf("unknown feed format: %s", root.XMLName.Local)
+	}
+}
+
+// feedSummary strips the markup of the item description
+func (f FeedFetcher) feedSummary(html string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return ""
+	}
+	f.cleanDoc(doc)
+	text := []rune(f.cleanText(doc.Text()))
+	if len(text) > feedMaxSummaryLength {
+		return string(text[:feedMaxSummaryLength]) + "..."
+	}
+	return string(text)
+}
+
+func formatFeed(title string, items []feedItem, limit int) string {
+	var result strings.Builder
+	if title != "" {
+		fmt.Fprintf(&result, "Feed: %s\n", title)
+	}
+	shown := 0
+	for i, item := range items[:min(limit, len(items))] {
+		var entry strings.Builder
+		fmt.Fprintf(&entry, "\n%d. %s\n", i+1, item.Title)
+		if item.Date != "" {
+			fmt.Fprintf(&entry, "Date: %s\n", item.Date)
+		}
+		if item.Link != "" {
+			fmt.Fprintf(&entry, "Link: %s\n", item.Link)
+		}
+		if item.Summary != "" {
+			entry.WriteString(item.Summary + "\n")
+		}
+		if result.Len()+entry.Len() > feedMaxLength {
+			break
+		}
+		result.WriteString(entry.String())
+		shown++
+	}
+	if rest := len(items) - shown; rest > 0 {
+		fmt.Fprintf(&result, "\n(%d more items not shown)\n", rest)
+	}
+	return strings.TrimSpace(result.String())
+}
+
+func formatFeedDate(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	for _, layout := range feedDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.UTC().Format("2006-01-02 15:04")
+		}
+	}
+	return value
+}
+
+func newFeedDecoder(body string) *xml.Decoder {
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	decoder.Strict = false
+	// the body is already converted to UTF-8 by fetch, the declared encoding is ignored
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder
+}
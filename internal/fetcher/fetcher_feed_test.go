@@ -0,0 +1,137 @@
+package fetcher
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testRSSFeed = `<?xml version="1.0" encoding="windows-1251"?>
+<rss version="2.0">
+<channel>
+  <title>Go News</title>
+  <item>
+    <title>Go 1.26 released</title>
+    <link>https://go.dev/blog/go1.26</link>
+    <pubDate>Tue, 10 Feb 2026 18:00:00 +0000</pubDate>
+    <description><![CDATA[<p>The <b>new</b> release is out.</p><script>track()</script>]]></description>
+  </item>
+  <item>
+    <title>Survey results</title>
+    <link>https://go.dev/blog/survey</link>
+    <pubDate>Mon, 2 Feb 2026 10:30:00 GMT</pubDate>
+    <description>Thanks to everyone</description>
+  </item>
+</channel>
+</rss>`
+
+const testAtomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example Blog</title>
+  <entry>
+    <title>First post</title>
+    <link rel="self" href="https://example.com/api/1"/>
+    <link rel="alternate" href="https://example.com/1"/>
+    <updated>2026-03-01T12:00:00Z</updated>
+    <content type="html">&lt;p&gt;Hello &lt;i&gt;world&lt;/i&gt;&lt;/p&gt;</content>
+  </entry>
+</feed>`
+
+func expectFeedRequest(client *MockHTTPClient, body string) {
+	client.EXPECT().
+		Do(mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"application/xml"}},
+		}, nil).
+		Once()
+}
+
+func TestFeedFetcher_CanHandle(t *testing.T) {
+	f := NewFeedFetcher(logger.NewTestLogger(), nil)
+
+	assert.True(t, f.CanHandle("https://example.com/feed"))
+	assert.True(t, f.CanHandle("https://example.com/blog/rss.xml"))
+	assert.True(t, f.CanHandle("https://example.com/news.atom?page=1"))
+	assert.False(t, f.CanHandle("https://example.com/feedback"))
+	assert.False(t, f.CanHandle("https://example.com/article"))
+}
+
+func TestFeedFetcher_Handle_RSS(t *testing.T) {
+	client := NewMockHTTPClient(t)
+	expectFeedRequest(client, testRSSFeed)
+
+	f := NewFeedFetcher(logger.NewTestLogger(), client)
+	resp, err := f.Handle(MustNewRequestPayload("https://go.dev/blog/feed.atom", nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, `Feed: Go News
+
+1. Go 1.26 released
+Date: 2026-02-10 18:00
+Link: https://go.dev/blog/go1.26
+The new release is out.
+
+2. Survey results
+Date: 2026-02-02 10:30
+Link: https://go.dev/blog/survey
+Thanks to everyone`, resp.GetText())
+}
+
+func TestFeedFetcher_Handle_Atom(t *testing.T) {
+	client := NewMockHTTPClient(t)
+	expectFeedRequest(client, testAtomFeed)
+
+	f := NewFeedFetcher(logger.NewTestLogger(), client)
+	resp, err := f.Handle(MustNewRequestPayload("https://example.com/feed", nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, `Feed: Example Blog
+
+1. First post
+Date: 2026-03-01 12:00
+Link: https://example.com/1
+Hello world`, resp.GetText())
+}
+
+func TestFeedFetcher_Handle_Limit(t *testing.T) {
+	client := NewMockHTTPClient(t)
+	expectFeedRequest(client, testRSSFeed)
+
+	f := NewFeedFetcher(logger.NewTestLogger(), client)
+	resp, err := f.Handle(MustNewRequestPayload("https://go.dev/feed", nil, map[string]any{FeedOptionLimit: 1}))
+	require.NoError(t, err)
+	assert.Contains(t, resp.GetText(), "1. Go 1.26 released")
+	assert.NotContains(t, resp.GetText(), "Survey results")
+	assert.True(t, strings.HasSuffix(resp.GetText(), "(1 more items not shown)"))
+}
+
+func TestFeedFetcher_Handle_MaxLength(t *testing.T) {
+	var items strings.Builder
+	for i := range feedMaxLimit {
+		fmt.Fprintf(&items, "<item><title>Item %d</title><description>%s</description></item>", i, strings.Repeat("word ", 200))
+	}
+	client := NewMockHTTPClient(t)
+	expectFeedRequest(client, `<rss><channel><title>Long</title>`+items.String()+`</channel></rss>`)
+
+	f := NewFeedFetcher(logger.NewTestLogger(), client)
+	resp, err := f.Handle(MustNewRequestPayload("https://example.com/feed", nil, map[string]any{FeedOptionLimit: feedMaxLimit}))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(resp.GetText()), feedMaxLength+50)
+	assert.Contains(t, resp.GetText(), "more items not shown")
+}
+
+func TestFeedFetcher_Handle_NotFeed(t *testing.T) {
+	client := NewMockHTTPClient(t)
+	expectFeedRequest(client, "<html><body>Not a feed</body></html>")
+
+	f := NewFeedFetcher(logger.NewTestLogger(), client)
+	_, err := f.Handle(MustNewRequestPayload("https://example.com/feed", nil, nil))
+	assert.ErrorIs(t, err, ErrNotHandle)
+}
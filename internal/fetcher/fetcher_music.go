@@ -0,0 +1,291 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+var (
+	soundCloudRegex     = regexp.MustCompile(`^(?:https?://)?(?:(?:www|m)\.)?soundcloud\.com/[^/?#]+/[^/?#]+|^(?:https?://)?on\.soundcloud\.com/\w+`)
+	bandcampRegex       = regexp.MustCompile(`^(?:https?://)?[\w\-]+\.bandcamp\.com/(?:track|album)/[^/?#]+`)
+	soundCloudHydration = regexp.MustCompile(`(?s)window\.__sc_hydration\s*=\s*(\[.*?\]);\s*</script>`)
+	isoDurationRegex    = regexp.MustCompile(`^P(?:T)?(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)(?:\.\d+)?S)?$`)
+)
+
+// IsMusicURL reports whether the URL is a SoundCloud or Bandcamp track, album or playlist
+func IsMusicURL(url string) bool {
+	return soundCloudRegex.MatchString(url) || bandcampRegex.MatchString(url)
+}
+
+type SoundCloudOEmbed struct {
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name"`
+	Description  string `json:"description"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+// SoundCloudSound is a track or a playlist from the page hydration data
+type SoundCloudSound struct {
+	Title         string `json:"title"`
+	Duration      int64  `json:"duration"` // milliseconds
+	PlaybackCount int64  `json:"playback_count"`
+	LikesCount    int64  `json:"likes_count"`
+	TrackCount    int    `json:"track_count"`
+	Genre         string `json:"genre"`
+	ArtworkURL    string `json:"artwork_url"`
+	Policy        string `json:"policy"`
+	User          struct {
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+type BandcampRelease struct {
+	Type     string `json:"@type"`
+	Name     string `json:"name"`
+	Duration string `json:"duration"`
+	ByArtist struct {
+		Name string `json:"name"`
+	} `json:"byArtist"`
+	InAlbum struct {
+		Name string `json:"name"`
+	} `json:"inAlbum"`
+	DatePublished string          `json:"datePublished"`
+	Image         json.RawMessage `json:"image"`
+	Track         struct {
+		ItemListElement []struct {
+			Position int `json:"position"`
+			Item     struct {
+				Name     string `json:"name"`
+				Duration string `json:"duration"`
+			} `json:"item"`
+		} `json:"itemListElement"`
+	} `json:"track"`
+}
+
+// MusicFetcher returns metadata of SoundCloud and Bandcamp releases, audio is never downloaded
+type MusicFetcher struct {
+	BaseFetcher
+}
+
+func NewMusicFetcher(l logger.Logger, client HTTPClient) MusicFetcher {
+	return MusicFetcher{
+		BaseFetcher: NewBaseFetcher(FetcherNameMusic, "soundcloud\\.com/|[\\w\\-]+\\.bandcamp\\.com/(?:track|album)/", client, l),
+	}
+}
+
+func (f MusicFetcher) Handle(request Request) (Response, error) {
+	link := request.URL()
+	switch {
+	case soundCloudRegex.MatchString(link):
+		return f.handleSoundCloud(link)
+	case bandcampRegex.MatchString(link):
+		return f.handleBandcamp(link)
+	default:
+		return Response{}, ErrNotHandle
+	}
+}
+
+func (f MusicFetcher) handleSoundCloud(link string) (Response, error) {
+	oembedURL := "https://soundcloud.com/oembed?format=json&url=" + url.QueryEscape(link)
+	resp, body, err := f.fetch(MustNewRequestPayload(oembedURL, nil, nil))
+	// private tracks are not embeddable and return 403, deleted ones 404
+	if err != nil && strings.Contains(err.Error(), "(403)") || err == nil && resp.StatusCode == http.StatusNotFound {
+		return f.errorResponse(fmt.Errorf("soundcloud: %s is private or doesn't exist", link))
+	}
+	if err != nil {
+		return f.errorResponse(fmt.Errorf("soundcloud: %w", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return f.errorResponse(fmt.Errorf("soundcloud: status %d", resp.StatusCode))
+	}
+	var oembed SoundCloudOEmbed
+	if err := json.Unmarshal([]byte(body), &oembed); err != nil {
+		return f.errorResponse(fmt.Errorf("soundcloud: failed to parse oembed: %w", err))
+	}
+
+	// oembed has no stats, they are taken from the page when available
+	sound, err := f.getSoundCloudSound(link)
+	if err != nil {
+		f.logger.WithError(err).WithField("url", link).Warn("Failed to get SoundCloud stats")
+	}
+	if sound != nil && sound.Policy == "BLOCK" {
+		return f.errorResponse(fmt.Errorf("soundcloud: %s is not available in this region", link))
+	}
+
+	title, artist, artwork := oembed.Title, oembed.AuthorName, oembed.ThumbnailURL
+	if sound != nil {
+		title, artist = sound.Title, sound.User.Username
+		if sound.ArtworkURL != "" {
+			artwork = strings.Replace(sound.ArtworkURL, "-large.", "-t500x500.", 1)
+		}
+	}
+
+	kind := "track"
+	if strings.Contains(link, "/sets/") {
+		kind = "playlist"
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "SoundCloud %s: %s\nArtist: %s\n", kind, title, artist)
+	if sound != nil {
+		if sound.Duration > 0 {
+			fmt.Fprintf(&text, "Duration: %s\n", formatTimestamp(time.Duration(sound.Duration)*time.Millisecond))
+		}
+		if sound.TrackCount > 0 {
+			fmt.Fprintf(&text, "Tracks: %d\n", sound.TrackCount)
+		}
+		if sound.PlaybackCount > 0 {
+			fmt.Fprintf(&text, "Plays: %s | Likes: %s\n", FormatCount(float64(sound.PlaybackCount)), FormatCount(float64(sound.LikesCount)))
+		}
+		if sound.Genre != "" {
+			fmt.Fprintf(&text, "Genre: %s\n", sound.Genre)
+		}
+	}
+	if oembed.Description != "" {
+		text.WriteString("\nDescription:\n" + oembed.Description)
+	}
+
+	return f.musicResponse(text.String(), artwork), nil
+}
+
+// getSoundCloudSound returns the track or playlist from the hydration data of the page
+func (f MusicFetcher) getSoundCloudSound(link string) (*SoundCloudSound, error) {
+	if !strings.HasPrefix(link, "http") {
+		link = "https://" + link
+	}
+	resp, body, err := f.fetch(MustNewRequestPayload(link, nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	matches := soundCloudHydration.FindStringSubmatch(body)
+	if len(matches) < 2 {
+		return nil, fmt.Errorf("hydration data not found")
+	}
+	var hydration []struct {
+		Hydratable string          `json:"hydratable"`
+		Data       json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(matches[1]), &hydration); err != nil {
+		return nil, fmt.Errorf("failed to parse hydration data: %w", err)
+	}
+	for _, item := range hydration {
+		if item.Hydratable != "sound" && item.Hydratable != "playlist" {
+			continue
+		}
+		var sound SoundCloudSound
+		if err := json.Unmarshal(item.Data, &sound); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", item.Hydratable, err)
+		}
+		return &sound, nil
+	}
+	return nil, fmt.Errorf("no sound in hydration data")
+}
+
+func (f MusicFetcher) handleBandcamp(link string) (Response, error) {
+	if !strings.HasPrefix(link, "http") {
+		link = "https://" + link
+	}
+	resp, body, err := f.fetch(MustNewRequestPayload(link, nil, nil))
+	if err != nil {
+		return f.errorResponse(fmt.Errorf("bandcamp: %w", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return f.errorResponse(fmt.Errorf("bandcamp: %s doesn't exist or was removed", link))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return f.errorResponse(fmt.Errorf("bandcamp: status %d", resp.StatusCode))
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return f.errorResponse(fmt.Errorf("bandcamp: failed to parse page: %w", err))
+	}
+	var release *BandcampRelease
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(i int, s *goquery.Selection) bool {
+		var item BandcampRelease
+		if err := json.Unmarshal([]byte(s.Text()), &item); err == nil && (item.Type == "MusicRecording" || item.Type == "MusicAlbum") {
+			release = &item
+			return false
+		}
+		return true
+	})
+	if release == nil {
+		return f.errorResponse(fmt.Errorf("bandcamp: release info not found on %s", link))
+	}
+
+	kind := "track"
+	if release.Type == "MusicAlbum" {
+		kind = "album"
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "Bandcamp %s: %s\nArtist: %s\n", kind, release.Name, release.ByArtist.Name)
+	if release.InAlbum.Name != "" {
+		fmt.Fprintf(&text, "Album: %s\n", release.InAlbum.Name)
+	}
+	if duration, ok := parseISODuration(release.Duration); ok {
+		fmt.Fprintf(&text, "Duration: %s\n", formatTimestamp(duration))
+	}
+	if published, err := time.Parse("02 Jan 2006 15:04:05 MST", release.DatePublished); err == nil {
+		fmt.Fprintf(&text, "Released: %s\n", published.Format("2006-01-02"))
+	}
+	if tracks := release.Track.ItemListElement; len(tracks) > 0 {
+		text.WriteString("\nTracks:\n")
+		for _, track := range tracks {
+			fmt.Fprintf(&text, "%d. %s", track.Position, track.Item.Name)
+			if duration, ok := parseISODuration(track.Item.Duration); ok {
+				fmt.Fprintf(&text, " (%s)", formatTimestamp(duration))
+			}
+			text.WriteString("\n")
+		}
+	}
+
+	return f.musicResponse(text.String(), bandcampImage(release.Image)), nil
+}
+
+func (f MusicFetcher) musicResponse(text, artwork string) Response {
+	content := []Content{{Type: ContentTypeText, Text: strings.TrimSpace(text)}}
+	if artwork != "" {
+		content = append(content, Content{Type: ContentTypeImage, Text: artwork})
+	}
+	return Response{Content: content}
+}
+
+// bandcampImage returns the first image, it's a string or a list of strings
+func bandcampImage(raw json.RawMessage) string {
+	var image string
+	if err := json.Unmarshal(raw, &image); err == nil {
+		return image
+	}
+	var images []string
+	if err := json.Unmarshal(raw, &images); err == nil && len(images) > 0 {
+		return images[0]
+	}
+	return ""
+}
+
+// parseISODuration parses durations like P00H03M25S and PT3M25S
+func parseISODuration(value string) (time.Duration, bool) {
+	matches := isoDurationRegex.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, false
+	}
+	var duration time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		if n, err := strconv.Atoi(matches[i+1]); err == nil {
+			duration += time.Duration(n) * unit
+		}
+	}
+	return duration, duration > 0
+}
@@ -0,0 +1,147 @@
+package fetcher
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func expectMusicRequest(client *MockHTTPClient, url string, status int, body string) {
+	client.EXPECT().
+		Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == url
+		})).
+		Return(&http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Header:     make(http.Header),
+		}, nil).
+		Once()
+}
+
+const soundCloudOEmbedURL = "https://soundcloud.com/oembed?format=json&url=https%3A%2F%2Fsoundcloud.com%2Fartist%2Fsong"
+
+func TestIsMusicURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://soundcloud.com/artist/song":             true,
+		"https://m.soundcloud.com/artist/sets/album":     true,
+		"https://on.soundcloud.com/AbCd12":               true,
+		"https://soundcloud.com/artist":                  false,
+		"https://artist.bandcamp.com/track/song":         true,
+		"https://x.rarebird.bandcamp.com/album/record":   false,
+		"https://rarebird.bandcamp.com/album/record?x=1": true,
+		"https://artist.bandcamp.com/music":              false,
+	}
+	for url, want := range tests {
+		assert.Equal(t, want, IsMusicURL(url), url)
+	}
+}
+
+func TestMusicFetcher_Handle_SoundCloud(t *testing.T) {
+	client := NewMockHTTPClient(t)
+	expectMusicRequest(client, soundCloudOEmbedURL, http.StatusOK,
+		`{"title":"Song by Artist","author_name":"Artist","description":"Recorded live","thumbnail_url":"https://i1.sndcdn.com/artworks-1-t500x500.jpg"}`)
+	expectMusicRequest(client, "https://soundcloud.com/artist/song", http.StatusOK,
+		`<html><script>window.__sc_hydration = [{"hydratable":"user","data":{}},{"hydratable":"sound","data":{"title":"Song","duration":215000,"playback_count":1234567,"likes_count":8900,"genre":"Ambient","artwork_url":"https://i1.sndcdn.com/artworks-2-large.jpg","policy":"ALLOW","user":{"username":"Artist"}}}];</script></html>`)
+
+	f := NewMusicFetcher(logger.NewTestLogger(), client)
+	require.True(t, f.CanHandle("https://soundcloud.com/artist/song"))
+
+	resp, err := f.Handle(MustNewRequestPayload("https://soundcloud.com/artist/song", nil, nil))
+	require.NoError(t, err)
+	require.Len(t, resp.Content, 2)
+	assert.Equal(t, `SoundCloud track: Song
+Artist: Artist
+Duration: 3:35
+Plays: 1.2M | Likes: 8.9K
+Genre: Ambient
+
+Description:
+Recorded live`, resp.Content[0].Text)
+	assert.Equal(t, Content{Type: ContentTypeImage, Text: "https://i1.sndcdn.com/artworks-2-t500x500.jpg"}, resp.Content[1])
+}
+
+func TestMusicFetcher_Handle_SoundCloudWithoutStats(t *testing.T) {
+	client := NewMockHTTPClient(t)
+	expectMusicRequest(client, soundCloudOEmbedURL, http.StatusOK,
+		`{"title":"Song by Artist","author_name":"Artist","thumbnail_url":"https://i1.sndcdn.com/artworks-1-t500x500.jpg"}`)
+	expectMusicRequest(client, "https://soundcloud.com/artist/song", http.StatusOK, `<html></html>`)
+
+	f := NewMusicFetcher(logger.NewTestLogger(), client)
+	resp, err := f.Handle(MustNewRequestPayload("https://soundcloud.com/artist/song", nil, nil))
+	require.NoError(t, err)
+	require.Len(t, resp.Content, 2)
+	assert.Equal(t, "SoundCloud track: Song by Artist\nArtist: Artist", resp.Content[0].Text)
+	assert.Equal(t, "https://i1.sndcdn.com/artworks-1-t500x500.jpg", resp.Content[1].Text)
+}
+
+func TestMusicFetcher_Handle_SoundCloudPrivate(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusNotFound} {
+		client := NewMockHTTPClient(t)
+		expectMusicRequest(client, soundCloudOEmbedURL, status, "")
+
+		f := NewMusicFetcher(logger.NewTestLogger(), client)
+		resp, err := f.Handle(MustNewRequestPayload("https://soundcloud.com/artist/song", nil, nil))
+		assert.EqualError(t, err, "soundcloud: https://soundcloud.com/artist/song is private or doesn't exist")
+		assert.True(t, resp.IsError)
+	}
+}
+
+func TestMusicFetcher_Handle_SoundCloudGeoblocked(t *testing.T) {
+	client := NewMockHTTPClient(t)
+	expectMusicRequest(client, soundCloudOEmbedURL, http.StatusOK, `{"title":"Song by Artist","author_name":"Artist"}`)
+	expectMusicRequest(client, "https://soundcloud.com/artist/song", http.StatusOK,
+		`<script>window.__sc_hydration = [{"hydratable":"sound","data":{"title":"Song","policy":"BLOCK"}}];</script>`)
+
+	f := NewMusicFetcher(logger.NewTestLogger(), client)
+	resp, err := f.Handle(MustNewRequestPayload("https://soundcloud.com/artist/song", nil, nil))
+	assert.EqualError(t, err, "soundcloud: https://soundcloud.com/artist/song is not available in this region")
+	assert.True(t, resp.IsError)
+}
+
+func TestMusicFetcher_Handle_BandcampAlbum(t *testing.T) {
+	client := NewMockHTTPClient(t)
+	expectMusicRequest(client, "https://artist.bandcamp.com/album/record", http.StatusOK, `<html><head>
+<script type="application/ld+json">{
+  "@type": "MusicAlbum",
+  "name": "Record",
+  "byArtist": {"name": "Artist"},
+  "datePublished": "14 Feb 2025 00:00:00 GMT",
+  "image": ["https://f4.bcbits.com/img/a1_10.jpg"],
+  "track": {"itemListElement": [
+    {"position": 1, "item": {"name": "Intro", "duration": "P00H01M05S"}},
+    {"position": 2, "item": {"name": "Song", "duration": "P00H04M30S"}}
+  ]}
+}</script></head></html>`)
+
+	f := NewMusicFetcher(logger.NewTestLogger(), client)
+	require.True(t, f.CanHandle("https://artist.bandcamp.com/album/record"))
+
+	resp, err := f.Handle(MustNewRequestPayload("https://artist.bandcamp.com/album/record", nil, nil))
+	require.NoError(t, err)
+	require.Len(t, resp.Content, 2)
+	assert.Equal(t, `Bandcamp album: Record
+Artist: Artist
+Released: 2025-02-14
+
+Tracks:
+1. Intro (1:05)
+2. Song (4:30)`, resp.Content[0].Text)
+	assert.Equal(t, Content{Type: ContentTypeImage, Text: "https://f4.bcbits.com/img/a1_10.jpg"}, resp.Content[1])
+}
+
+func TestMusicFetcher_Handle_BandcampNotFound(t *testing.T) {
+	client := NewMockHTTPClient(t)
+	expectMusicRequest(client, "https://artist.bandcamp.com/track/gone", http.StatusNotFound, "")
+
+	f := NewMusicFetcher(logger.NewTestLogger(), client)
+	resp, err := f.Handle(MustNewRequestPayload("https://artist.bandcamp.com/track/gone", nil, nil))
+	assert.EqualError(t, err, "bandcamp: https://artist.bandcamp.com/track/gone doesn't exist or was removed")
+	assert.True(t, resp.IsError)
+}
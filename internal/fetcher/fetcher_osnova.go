@@ -0,0 +1,261 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+const (
+	osnovaMaxComments = 50
+	osnovaImageURL    = "https://leonardo.osnova.io/%s/"
+)
+
+// https://vc.ru/tech/123456-slug, https://dtf.ru/u/1-user/123456-slug, https://dtf.ru/123456
+var osnovaRegex = regexp.MustCompile(`^(?:https?://)?(?:www\.)?(vc\.ru|dtf\.ru)/((?:[\w\-]+/)*)(\d+)(?:-[\w\-]*)?/?(?:[?#].*)?$`)
+
+// IsOsnovaURL reports whether the URL is a link to a vc.ru or dtf.ru article
+func IsOsnovaURL(url string) bool {
+	matches := osnovaRegex.FindStringSubmatch(url)
+	// vc.ru/u/123-name is a user profile, not an article
+	return len(matches) == 4 && matches[2] != "u/"
+}
+
+type OsnovaContent struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	Date   int64  `json:"date"`
+	Author struct {
+		Name string `json:"name"`
+	} `json:"author"`
+	Subsite struct {
+		Name string `json:"name"`
+	} `json:"subsite"`
+	Blocks []osnovaBlock `json:"blocks"`
+	Likes  struct {
+		Counter int `json:"counter"`
+	} `json:"likes"`
+}
+
+type osnovaBlock struct {
+	Type string `json:"type"`
+	Data struct {
+		Text string `json:"text"`
+		// strings for lists, media items for media blocks
+		Items []json.RawMessage `json:"items"`
+	} `json:"data"`
+}
+
+type osnovaMediaItem struct {
+	Image struct {
+		Data struct {
+			UUID string `json:"uuid"`
+		} `json:"data"`
+	} `json:"image"`
+}
+
+type osnovaComment struct {
+	ID      int `json:"id"`
+	ReplyTo int `json:"replyTo"`
+	Author  struct {
+		Name string `json:"name"`
+	} `json:"author"`
+	Text  string `json:"text"`
+	Likes struct {
+		Counter int `json:"counter"`
+	} `json:"likes"`
+}
+
+// OsnovaFetcher handles articles of vc.ru and dtf.ru through their public API
+type OsnovaFetcher struct {
+	BaseFetcher
+}
+
+func NewOsnovaFetcher(l logger.Logger, client HTTPClient) OsnovaFetcher {
+	return OsnovaFetcher{
+		BaseFetcher: NewBaseFetcher(FetcherNameOsnova, "(?:^|[/.])(?:vc|dtf)\\.ru/", client, l),
+	}
+}
+
+func (f OsnovaFetcher) Handle(request Request) (Response, error) {
+	if !IsOsnovaURL(request.URL()) {
+		return Response{}, ErrNotHandle
+	}
+	matches := osnovaRegex.FindStringSubmatch(request.URL())
+	host, contentID := matches[1], matches[3]
+	apiURL := "https://api." + host + "/v2.1"
+
+	content, err := f.getContent(apiURL + "/content?id=" + contentID)
+	if err != nil {
+		return f.errorResponse(fmt.Errorf("%s article %s: %w", host, contentID, err))
+	}
+
+	text, images := f.renderBlocks(content.Blocks)
+	if content.Title == "" && text == "" {
+		return Response{
+			Content: []Content{{Type: ContentTypeText, Text: "No " + host + " content found"}},
+			IsError: true,
+		}, nil
+	}
+
+	var imagesText strings.Builder
+	for _, image := range images {
+		fmt.Fprintf(&imagesText, "Image: %s\n", image)
+	}
+	comments := f.parseOsnovaComments(apiURL + "/comments?contentId=" + contentID)
+
+	fullText := fmt.Sprintf(
+		"Title: %s\nAuthor: %s\nSubsite: %s\nDate: %s\nRating: %d\nText:\n%s\n\nImages:\n%s\nComments:\n%s",
+		content.Title,
+		content.Author.Name,
+		content.Subsite.Name,
+		time.Unix(content.Date, 0).UTC().Format("2006-01-02 15:04"),
+		content.Likes.Counter,
+		text,
+		imagesText.String(),
+		comments,
+	)
+
+	return Response{
+		Content: []Content{{Type: ContentTypeText, Text: fullText}},
+	}, nil
+}
+
+func (f OsnovaFetcher) getContent(apiURL string) (*OsnovaContent, error) {
+	resp, body, err := f.fetch(MustNewRequestPayload(apiURL, map[string]string{
+		"Accept": "application/json",
+	}, nil))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var data struct {
+		Result OsnovaContent `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse content: %w", err)
+	}
+	return &data.Result, nil
+}
+
+// renderBlocks returns the article text and the image URLs of media blocks
+func (f OsnovaFetcher) renderBlocks(blocks []osnovaBlock) (string, []string) {
+	var parts []string
+	var images []string
+	for _, block := range blocks {
+		switch block.Type {
+		case "text", "incut":
+			parts = append(parts, osnovaHTMLToText(block.Data.Text))
+		case "header":
+			parts = append(parts, "## "+osnovaHTMLToText(block.Data.Text))
+		case "quote":
+			parts = append(parts, "> "+osnovaHTMLToText(block.Data.Text))
+		case "list":
+			items := make([]string, 0, len(block.Data.Items))
+			for _, raw := range block.Data.Items {
+				var item string
+				if err := json.Unmarshal(raw, &item); err == nil {
+					items = append(items, "- "+osnovaHTMLToText(item))
+				}
+			}
+			parts = append(parts, strings.Join(items, "\n"))
+		case "media":
+			for _, raw := range block.Data.Items {
+				var item osnovaMediaItem
+				if err := json.Unmarshal(raw, &item); err != nil || item.Image.Data.UUID == "" {
+					continue
+				}
+				images = append(images, fmt.Sprintf(osnovaImageURL, item.Image.Data.UUID))
+			}
+		}
+	}
+
+	nonEmpty := parts[:0]
+	for _, part := range parts {
+		if strings.TrimSpace(strings.TrimLeft(part, "#> ")) != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n"), images
+}
+
+func (f OsnovaFetcher) parseOsnovaComments(apiURL string) string {
+	resp, body, err := f.fetch(MustNewRequestPayload(apiURL, map[string]string{
+		"Accept": "application/json",
+	}, nil))
+	if err != nil {
+		return fmt.Sprintf("Get comments error: %v", err.Error())
+	}
+	defer resp.Body.Close()
+
+	var data struct {
+		Result []osnovaComment `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return "Unmarshal error " + err.Error()
+	}
+	if len(data.Result) == 0 {
+		return "No comments found"
+	}
+
+	var result strings.Builder
+	for i, comment := range data.Result {
+		if i >= osnovaMaxComments {
+			break
+		}
+		parentIDStr := ""
+		if comment.ReplyTo != 0 {
+			parentIDStr = " parentId: " + strconv.Itoa(comment.ReplyTo) + ";"
+		}
+		fmt.Fprintf(
+			&result,
+			"- id: %d;%s score: %d; author: %s; text: %s\n",
+			comment.ID,
+			parentIDStr,
+			comment.Likes.Counter,
+			comment.Author.Name,
+			osnovaHTMLToText(comment.Text),
+		)
+	}
+	return result.String()
+}
+
+// osnovaHTMLToText strips the markup of text blocks keeping link targets,
+// so the recursive extractor can follow them
+func osnovaHTMLToText(html string) string {
+	if !strings.Contains(html, "<") {
+		return strings.TrimSpace(html)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return strings.TrimSpace(html)
+	}
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		if text := strings.TrimSpace(s.Text()); text != "" && text != href {
+			s.SetText(fmt.Sprintf("%s (%s)", text, href))
+		} else {
+			s.SetText(href)
+		}
+	})
+	doc.Find("br").ReplaceWithHtml("\n")
+	doc.Find("p").Each(func(i int, s *goquery.Selection) {
+		s.AppendHtml("\n")
+	})
+	return strings.TrimSpace(doc.Text())
+}
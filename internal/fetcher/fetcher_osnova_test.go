@@ -0,0 +1,131 @@
+package fetcher
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsOsnovaURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://vc.ru/tech/123456-kak-my-pereehali":  true,
+		"https://dtf.ru/games/123456":                 true,
+		"dtf.ru/123456-slug":                          true,
+		"https://vc.ru/u/42-ivan/123456-slug?from=tg": true,
+		"https://vc.ru/u/42-ivan":                     false,
+		"https://vc.ru/tech":                          false,
+		"https://notvc.ru/tech/123456-slug":           false,
+	}
+	for url, want := range tests {
+		assert.Equal(t, want, IsOsnovaURL(url), url)
+	}
+}
+
+func TestOsnovaFetcher_Handle_Success(t *testing.T) {
+	l := logger.NewTestLogger()
+	mockClient := NewMockHTTPClient(t)
+
+	contentJSON, err := os.ReadFile("testdata/osnova_content.json")
+	require.NoError(t, err, "Failed to read test content JSON file")
+	commentsJSON, err := os.ReadFile("testdata/osnova_comments.json")
+	require.NoError(t, err, "Failed to read test comments JSON file")
+
+	for url, body := range map[string][]byte{
+		"https://api.vc.ru/v2.1/content?id=123456":         contentJSON,
+		"https://api.vc.ru/v2.1/comments?contentId=123456": commentsJSON,
+	} {
+		mockClient.EXPECT().
+			Do(mock.MatchedBy(func(req *http.Request) bool {
+				return req.URL.String() == url
+			})).
+			Return(&http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(body)),
+				Header:     make(http.Header),
+			}, nil).Once()
+	}
+
+	fetcher := NewOsnovaFetcher(l, mockClient)
+	require.True(t, fetcher.CanHandle("https://vc.ru/tech/123456-kak-my-pereehali"))
+
+	response, err := fetcher.Handle(MustNewRequestPayload("https://vc.ru/tech/123456-kak-my-pereehali", nil, nil))
+	require.NoError(t, err)
+	assert.False(t, response.IsError)
+	require.Len(t, response.Content, 1)
+
+	expectedText := `Title: Как мы перенесли сервис в облако
+Author: Иван Петров
+Subsite: Облака
+Date: 2026-01-05 12:00
+Rating: 37
+Text:
+Рассказываем, как переехали в облако (https://example.com/cloud) за месяц.
+
+## С чего начали
+
+- Инвентаризация
+- Миграция баз
+
+> Главное — не торопиться
+
+Images:
+Image: https://leonardo.osnova.io/0a1b2c3d-1111-2222-3333-444455556666/
+Image: https://leonardo.osnova.io/9f8e7d6c-aaaa-bbbb-cccc-ddddeeeeffff/
+
+Comments:
+- id: 1001; score: 12; author: alex; text: Отличная статья
+- id: 1002; parentId: 1001; score: 3; author: maria; text: Согласна, особенно про базы
+`
+	assert.Equal(t, expectedText, response.Content[0].Text)
+}
+
+func TestOsnovaFetcher_Handle_NotArticle(t *testing.T) {
+	fetcher := NewOsnovaFetcher(logger.NewTestLogger(), NewMockHTTPClient(t))
+
+	_, err := fetcher.Handle(MustNewRequestPayload("https://vc.ru/tech", nil, nil))
+	assert.ErrorIs(t, err, ErrNotHandle)
+}
+
+func TestOsnovaFetcher_Handle_NotFound(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	mockClient.EXPECT().
+		Do(mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       io.NopCloser(strings.NewReader(`{"message":"Not found"}`)),
+			Header:     make(http.Header),
+		}, nil).Once()
+
+	fetcher := NewOsnovaFetcher(logger.NewTestLogger(), mockClient)
+	response, err := fetcher.Handle(MustNewRequestPayload("https://dtf.ru/games/999999-slug", nil, nil))
+	assert.EqualError(t, err, "dtf.ru article 999999: not found")
+	assert.True(t, response.IsError)
+}
+
+func TestOsnovaFetcher_parseOsnovaComments_Limit(t *testing.T) {
+	comments := make([]string, osnovaMaxComments+10)
+	for i := range comments {
+		comments[i] = fmt.Sprintf(`{"id": %d, "author": {"name": "user"}, "text": "comment"}`, i+1)
+	}
+	mockClient := NewMockHTTPClient(t)
+	mockClient.EXPECT().
+		Do(mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"result": [` + strings.Join(comments, ",") + `]}`)),
+			Header:     make(http.Header),
+		}, nil).Once()
+
+	fetcher := NewOsnovaFetcher(logger.NewTestLogger(), mockClient)
+	result := fetcher.parseOsnovaComments("https://api.dtf.ru/v2.1/comments?contentId=1")
+	assert.Equal(t, osnovaMaxComments, strings.Count(result, "\n"))
+}
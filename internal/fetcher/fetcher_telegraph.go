@@ -0,0 +1,291 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+const (
+	telegraphAPIURL  = "https://api.telegra.ph/getPage/%s?return_content=true"
+	telegraphBaseURL = "https://telegra.ph"
+)
+
+// https://telegra.ph/Sample-Page-12-15, https://graph.org/Sample-Page-12-15
+var telegraphRegex = regexp.MustCompile(`^(?:https?://)?(?:www\.)?(?:telegra\.ph|graph\.org)/([\w\-%]+)/?(?:[?#].*)?$`)
+
+// IsTelegraphURL reports whether the URL is a link to a Telegraph page
+func IsTelegraphURL(url string) bool {
+	return telegraphRegex.MatchString(url)
+}
+
+type TelegraphPage struct {
+	Path       string          `json:"path"`
+	Title      string          `json:"title"`
+	AuthorName string          `json:"author_name"`
+	AuthorURL  string          `json:"author_url"`
+	ImageURL   string          `json:"image_url"`
+	Views      int             `json:"views"`
+	Content    []telegraphNode `json:"content"`
+}
+
+// telegraphNode is a text node (a JSON string) or an element with a tag, attributes and children
+type telegraphNode struct {
+	Text     string
+	Tag      string
+	Attrs    map[string]string
+	Children []telegraphNode
+}
+
+func (n *telegraphNode) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &n.Text)
+	}
+	var element struct {
+		Tag      string            `json:"tag"`
+		Attrs    map[string]string `json:"attrs"`
+		Children []telegraphNode   `json:"children"`
+	}
+	if err := json.Unmarshal(data, &element); err != nil {
+		return err
+	}
+	n.Tag, n.Attrs, n.Children = element.Tag, element.Attrs, element.Children
+	return nil
+}
+
+// TelegraphFetcher handles telegra.ph and graph.org pages through the Telegraph API
+type TelegraphFetcher struct {
+	BaseFetcher
+}
+
+func NewTelegraphFetcher(l logger.Logger, client HTTPClient) TelegraphFetcher {
+	return TelegraphFetcher{
+		BaseFetcher: NewBaseFetcher(FetcherNameTelegraph, "(?:^|[/.])(?:telegra\\.ph|graph\\.org)/", client, l),
+	}
+}
+
+func (f TelegraphFetcher) Handle(request Request) (Response, error) {
+	matches := telegraphRegex.FindStringSubmatch(request.URL())
+	if len(matches) < 2 {
+		return Response{}, ErrNotHandle
+	}
+	path := matches[1]
+
+	page, err := f.getPage(fmt.Sprintf(telegraphAPIURL, path))
+	if err != nil {
+		return f.errorResponse(fmt.Errorf("telegraph page %s: %w", path, err))
+	}
+
+	renderer := &telegraphRenderer{}
+	text := renderer.render(page.Content)
+	if page.Title == "" && text == "" {
+		return Response{
+			Content: []Content{{Type: ContentTypeText, Text: "No telegraph content found"}},
+			IsError: true,
+		}, nil
+	}
+
+	author := page.AuthorName
+	if page.AuthorURL != "" {
+		author = strings.TrimSpace(fmt.Sprintf("%s (%s)", author, page.AuthorURL))
+	}
+	fullText := fmt.Sprintf(
+		"Title: %s\nAuthor: %s\nViews: %d\nText:\n%s",
+		page.Title,
+		author,
+		page.Views,
+		text,
+	)
+
+	content := []Content{{Type: ContentTypeText, Text: fullText}}
+	images := renderer.images
+	if page.ImageURL != "" && !slices.Contains(images, page.ImageURL) {
+		images = append([]string{page.ImageURL}, images...)
+	}
+	for _, image := range images {
+		content = append(content, Content{Type: ContentTypeImage, Text: image})
+	}
+	return Response{Content: content}, nil
+}
+
+func (f TelegraphFetcher) getPage(apiURL string) (*TelegraphPage, error) {
+	resp, body, err := f.fetch(MustNewRequestPayload(apiURL, map[string]string{
+		"Accept": "application/json",
+	}, nil))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var data struct {
+		OK     bool          `json:"ok"`
+		Error  string        `json:"error"`
+		Result TelegraphPage `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse page: %w", err)
+	}
+	if !data.OK {
+		if data.Error == "PAGE_NOT_FOUND" {
+			return nil, fmt.Errorf("not found")
+		}
+		return nil, fmt.Errorf("api error %s", data.Error)
+	}
+	return &data.Result, nil
+}
+
+// telegraphRenderer converts the nodes to text with markdown-like headers, quotes and lists,
+// links keep their targets for the recursive extractor, images are collected separately
+type telegraphRenderer struct {
+	images []string
+}
+
+func (r *telegraphRenderer) render(nodes []telegraphNode) string {
+	var blocks []string
+	for _, node := range nodes {
+		if block := strings.TrimSpace(r.block(node, "")); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func (r *telegraphRenderer) block(node telegraphNode, indent string) string {
+	switch node.Tag {
+	case "":
+		return node.Text
+	case "h3":
+		return "## " + r.inline(node.Children)
+	case "h4":
+		return "### " + r.inline(node.Children)
+	case "blockquote", "aside":
+		return "> " + strings.ReplaceAll(strings.TrimSpace(r.inline(node.Children)), "\n", "\n> ")
+	case "pre":
+		return "```\n" + strings.Trim(r.inline(node.Children), "\n") + "\n```"
+	case "ul", "ol":
+		return r.list(node, indent)
+	case "figure":
+		// images are collected, embeds and captions are kept in the text
+		var parts []string
+		for _, child := range node.Children {
+			part := r.inline([]telegraphNode{child})
+			if child.Tag == "figcaption" {
+				part = r.inline(child.Children)
+			}
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return strings.Join(parts, "\n")
+	case "iframe":
+		return r.embed(node.Attrs["src"])
+	case "hr":
+		return ""
+	default:
+		return r.inline(node.Children)
+	}
+}
+
+func (r *telegraphRenderer) list(node telegraphNode, indent string) string {
+	var items []string
+	number := 0
+	for _, item := range node.Children {
+		if item.Tag != "li" {
+			continue
+		}
+		number++
+		marker := "- "
+		if node.Tag == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+		}
+		var text []telegraphNode
+		var nested []string
+		for _, child := range item.Children {
+			if child.Tag == "ul" || child.Tag == "ol" {
+				nested = append(nested, r.list(child, indent+"  "))
+			} else {
+				text = append(text, child)
+			}
+		}
+		items = append(items, indent+marker+strings.TrimSpace(r.inline(text)))
+		items = append(items, nested...)
+	}
+	return strings.Join(items, "\n")
+}
+
+func (r *telegraphRenderer) inline(nodes []telegraphNode) string {
+	var text strings.Builder
+	for _, node := range nodes {
+		switch node.Tag {
+		case "":
+			text.WriteString(node.Text)
+		case "br":
+			text.WriteString("\n")
+		case "img":
+			if src := node.Attrs["src"]; src != "" {
+				r.addImage(src)
+			}
+		case "video":
+			if src := node.Attrs["src"]; src != "" {
+				text.WriteString("Video: " + telegraphAbsoluteURL(src))
+			}
+		case "iframe":
+			text.WriteString(r.embed(node.Attrs["src"]))
+		case "a":
+			href := node.Attrs["href"]
+			label := strings.TrimSpace(r.inline(node.Children))
+			switch {
+			case href == "":
+				text.WriteString(label)
+			case label == "" || label == href:
+				text.WriteString(telegraphAbsoluteURL(href))
+			default:
+				fmt.Fprintf(&text, "%s (%s)", label, telegraphAbsoluteURL(href))
+			}
+		default:
+			text.WriteString(r.inline(node.Children))
+		}
+	}
+	return text.String()
+}
+
+func (r *telegraphRenderer) addImage(src string) {
+	src = telegraphAbsoluteURL(src)
+	if !slices.Contains(r.images, src) {
+		r.images = append(r.images, src)
+	}
+}
+
+// embed returns the original URL of embedded media like /embed/youtube?url=...
+func (r *telegraphRenderer) embed(src string) string {
+	if src == "" {
+		return ""
+	}
+	if parsed, err := url.Parse(src); err == nil {
+		if original := parsed.Query().Get("url"); original != "" {
+			return "Embed: " + original
+		}
+	}
+	return "Embed: " + telegraphAbsoluteURL(src)
+}
+
+// telegraphAbsoluteURL resolves paths like /file/abc.jpg of the files uploaded to Telegraph
+func telegraphAbsoluteURL(src string) string {
+	if strings.HasPrefix(src, "/") && !strings.HasPrefix(src, "//") {
+		return telegraphBaseURL + src
+	}
+	if strings.HasPrefix(src, "//") {
+		return "https:" + src
+	}
+	return src
+}
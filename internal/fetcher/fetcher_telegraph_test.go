@@ -0,0 +1,99 @@
+package fetcher
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTelegraphURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://telegra.ph/Kak-my-pereehali-v-oblako-01-05":            true,
+		"telegra.ph/Sample-Page-12-15?utm_source=tg":                    true,
+		"https://graph.org/Sample-Page-12-15/":                          true,
+		"https://telegra.ph/file/0a1b2c3d4e5f.jpg":                      false,
+		"https://telegra.ph/":                                           false,
+		"https://nottelegra.ph/Sample-Page-12-15":                       false,
+		"https://example.com/telegra.ph/Sample-Page-12-15":              false,
+		"https://telegra.ph/%D0%9F%D1%80%D0%B8%D0%B2%D0%B5%D1%82-01-05": true,
+	}
+	for url, want := range tests {
+		assert.Equal(t, want, IsTelegraphURL(url), url)
+	}
+}
+
+func TestTelegraphFetcher_Handle_Success(t *testing.T) {
+	body, err := os.ReadFile("testdata/telegraph_success.json")
+	require.NoError(t, err, "Failed to read test page JSON file")
+
+	mockClient := NewMockHTTPClient(t)
+	mockClient.EXPECT().
+		Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "https://api.telegra.ph/getPage/Kak-my-pereehali-v-oblako-01-05?return_content=true"
+		})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Header:     make(http.Header),
+		}, nil).Once()
+
+	fetcher := NewTelegraphFetcher(logger.NewTestLogger(), mockClient)
+	require.True(t, fetcher.CanHandle("https://telegra.ph/Kak-my-pereehali-v-oblako-01-05"))
+
+	response, err := fetcher.Handle(MustNewRequestPayload("https://telegra.ph/Kak-my-pereehali-v-oblako-01-05", nil, nil))
+	require.NoError(t, err)
+	assert.False(t, response.IsError)
+
+	expectedText := "Title: Как мы переехали в облако\n" +
+		"Author: Иван Петров (https://t.me/ivan_petrov)\n" +
+		"Views: 1234\n" +
+		"Text:\n" +
+		"Рассказываем, как переехали в облако (https://example.com/cloud) за месяц.\n\n" +
+		"Схема миграции\n\n" +
+		"## С чего начали\n\n" +
+		"1. Инвентаризация\n" +
+		"2. Миграция баз\n" +
+		"  - PostgreSQL\n" +
+		"  - Redis\n\n" +
+		"> Главное — не торопиться\n\n" +
+		"```\nterraform apply\nkubectl rollout status\n```\n\n" +
+		"Первая строка\nВторая строка\n\n" +
+		"Embed: https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+	require.NotEmpty(t, response.Content)
+	assert.Equal(t, expectedText, response.Content[0].Text)
+	assert.Equal(t, []string{
+		"https://telegra.ph/file/0a1b2c3d4e5f.jpg",
+		"https://example.com/images/rack.png",
+	}, response.GetImages())
+}
+
+func TestTelegraphFetcher_Handle_NotFound(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	mockClient.EXPECT().
+		Do(mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"ok":false,"error":"PAGE_NOT_FOUND"}`)),
+			Header:     make(http.Header),
+		}, nil).Once()
+
+	fetcher := NewTelegraphFetcher(logger.NewTestLogger(), mockClient)
+	response, err := fetcher.Handle(MustNewRequestPayload("https://graph.org/Missing-Page-01-01", nil, nil))
+	assert.EqualError(t, err, "telegraph page Missing-Page-01-01: not found")
+	assert.True(t, response.IsError)
+}
+
+func TestTelegraphFetcher_Handle_NotPage(t *testing.T) {
+	fetcher := NewTelegraphFetcher(logger.NewTestLogger(), NewMockHTTPClient(t))
+
+	_, err := fetcher.Handle(MustNewRequestPayload("https://telegra.ph/file/0a1b2c3d4e5f.jpg", nil, nil))
+	assert.ErrorIs(t, err, ErrNotHandle)
+}
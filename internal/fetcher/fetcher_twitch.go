@@ -0,0 +1,257 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+var ErrTwitchNotConfigured = errors.New("Twitch API not configured")
+
+const (
+	twitchTokenURL = "https://id.twitch.tv/oauth2/token"
+	twitchAPIURL   = "https://api.twitch.tv/helix"
+)
+
+var (
+	twitchClipRegex = regexp.MustCompile(`(?:clips\.twitch\.tv/(?:embed\?clip=)?|twitch\.tv/\w+/clip/)([\w-]+)`)
+	twitchVODRegex  = regexp.MustCompile(`twitch\.tv/videos/(\d+)`)
+)
+
+type TwitchClip struct {
+	ID              string    `json:"id"`
+	BroadcasterName string    `json:"broadcaster_name"`
+	CreatorName     string    `json:"creator_name"`
+	GameID          string    `json:"game_id"`
+	Title           string    `json:"title"`
+	ViewCount       int       `json:"view_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	Duration        float64   `json:"duration"`
+}
+
+type TwitchVideo struct {
+	ID           string    `json:"id"`
+	UserName     string    `json:"user_name"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	CreatedAt    time.Time `json:"created_at"`
+	ViewCount    int       `json:"view_count"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	Duration     string    `json:"duration"`
+}
+
+type twitchToken struct {
+	mu        sync.Mutex
+	value     string
+	expiresAt time.Time
+}
+
+type TwitchFetcher struct {
+	BaseFetcher
+	clientID     string
+	clientSecret string
+	token        *twitchToken
+}
+
+func NewTwitchFetcher(l logger.Logger, client HTTPClient, clientID, clientSecret string) TwitchFetcher {
+	return TwitchFetcher{
+		BaseFetcher:  NewBaseFetcher(FetcherNameTwitch, "twitch\\.tv/videos/|twitch\\.tv/\\w+/clip/|clips\\.twitch\\.tv/", client, l),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		token:        &twitchToken{},
+	}
+}
+
+func (f TwitchFetcher) Handle(request Request) (Response, error) {
+	if f.clientID == "" || f.clientSecret == "" {
+		return f.errorResponse(ErrTwitchNotConfigured)
+	}
+
+	if matches := twitchClipRegex.FindStringSubmatch(request.URL()); len(matches) > 1 {
+		return f.handleClip(matches[1])
+	}
+	if matches := twitchVODRegex.FindStringSubmatch(request.URL()); len(matches) > 1 {
+		return f.handleVideo(matches[1])
+	}
+
+	return Response{}, ErrNotHandle
+}
+
+func (f TwitchFetcher) handleClip(id string) (Response, error) {
+	var clips struct {
+		Data []TwitchClip `json:"data"`
+	}
+	if err := f.getHelix("clips?id="+url.QueryEscape(id), &clips); err != nil {
+		return f.errorResponse(fmt.Errorf("clip info: %w", err))
+	}
+	if len(clips.Data) == 0 {
+		return f.errorResponse(fmt.Errorf("clip %s not found", id))
+	}
+	clip := clips.Data[0]
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Twitch Clip: %s\n"+
+		"Streamer: %s\n",
+		clip.Title,
+		clip.BroadcasterName,
+	)
+	if clip.GameID != "" {
+		if game, err := f.getGameName(clip.GameID); err != nil {
+			f.logger.WithError(err).Warn("Failed to get game name")
+		} else if game != "" {
+			fmt.Fprintf(&text, "Category: %s\n", game)
+		}
+	}
+	fmt.Fprintf(&text, "Clipped by: %s\n"+
+		"Views: %d | Duration: %.0fs\n"+
+		"Created: %s",
+		clip.CreatorName,
+		clip.ViewCount,
+		clip.Duration,
+		clip.CreatedAt.Format("2006-01-02"),
+	)
+
+	content := []Content{{Type: ContentTypeText, Text: text.String()}}
+	if clip.ThumbnailURL != "" {
+		content = append(content, Content{Type: ContentTypeImage, Text: clip.ThumbnailURL})
+	}
+
+	return Response{Content: content}, nil
+}
+
+func (f TwitchFetcher) handleVideo(id string) (Response, error) {
+	var videos struct {
+		Data []TwitchVideo `json:"data"`
+	}
+	if err := f.getHelix("videos?id="+url.QueryEscape(id), &videos); err != nil {
+		return f.errorResponse(fmt.Errorf("video info: %w", err))
+	}
+	if len(videos.Data) == 0 {
+		return f.errorResponse(fmt.Errorf("video %s not found", id))
+	}
+	video := videos.Data[0]
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Twitch VOD: %s\n"+
+		"Streamer: %s\n"+
+		"Views: %d | Duration: %s\n"+
+		"Created: %s",
+		video.Title,
+		video.UserName,
+		video.ViewCount,
+		video.Duration,
+		video.CreatedAt.Format("2006-01-02"),
+	)
+	if video.Description != "" {
+		text.WriteString("\n\nDescription:\n" + video.Description)
+	}
+
+	content := []Content{{Type: ContentTypeText, Text: text.String()}}
+	// VOD thumbnails are templates with size placeholders
+	if thumbnail := strings.NewReplacer("%{width}", "1280", "%{height}", "720").Replace(video.ThumbnailURL); thumbnail != "" {
+		content = append(content, Content{Type: ContentTypeImage, Text: thumbnail})
+	}
+
+	return Response{Content: content}, nil
+}
+
+func (f TwitchFetcher) getGameName(id string) (string, error) {
+	var games struct {
+		Data []struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	if err := f.getHelix("games?id="+url.QueryEscape(id), &games); err != nil {
+		return "", err
+	}
+	if len(games.Data) == 0 {
+		return "", nil
+	}
+	return games.Data[0].Name, nil
+}
+
+func (f TwitchFetcher) getHelix(path string, v any) error {
+	token, err := f.getToken()
+	if err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+
+	resp, body, err := f.fetch(MustNewRequestPayload(twitchAPIURL+"/"+path, map[string]string{
+		"Client-Id":     f.clientID,
+		"Authorization": "Bearer " + token,
+	}, nil))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		f.token.reset()
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return json.Unmarshal([]byte(body), v)
+}
+
+// getToken returns an app access token (client credentials flow), cached until it expires
+func (f TwitchFetcher) getToken() (string, error) {
+	f.token.mu.Lock()
+	defer f.token.mu.Unlock()
+
+	if f.token.value != "" && time.Now().Before(f.token.expiresAt) {
+		return f.token.value, nil
+	}
+
+	query := url.Values{}
+	query.Set("client_id", f.clientID)
+	query.Set("client_secret", f.clientSecret)
+	query.Set("grant_type", "client_credentials")
+	payload, err := NewRequestPayloadWithMethod(twitchTokenURL+"?"+query.Encode(), http.MethodPost, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, body, err := f.fetch(payload)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal([]byte(body), &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("empty access token")
+	}
+
+	f.token.value = token.AccessToken
+	// refresh a bit earlier to avoid using an expired token
+	f.token.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+
+	return f.token.value, nil
+}
+
+func (t *twitchToken) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.value = ""
+}
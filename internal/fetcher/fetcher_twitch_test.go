@@ -0,0 +1,189 @@
+package fetcher
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTwitchJSONResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}
+}
+
+func expectTwitchToken(mockClient *MockHTTPClient) {
+	mockClient.EXPECT().
+		Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost &&
+				strings.HasPrefix(req.URL.String(), "https://id.twitch.tv/oauth2/token") &&
+				req.URL.Query().Get("client_id") == "client-id" &&
+				req.URL.Query().Get("grant_type") == "client_credentials"
+		})).
+		Return(newTwitchJSONResponse(`{"access_token": "token", "expires_in": 3600}`), nil).
+		Once()
+}
+
+func TestTwitchFetcher_CanHandle(t *testing.T) {
+	f := NewTwitchFetcher(logger.NewTestLogger(), nil, "", "")
+
+	assert.True(t, f.CanHandle("https://www.twitch.tv/videos/123456"))
+	assert.True(t, f.CanHandle("https://www.twitch.tv/streamer/clip/FunnyClip-abc_123"))
+	assert.True(t, f.CanHandle("https://clips.twitch.tv/FunnyClip-abc_123"))
+	assert.False(t, f.CanHandle("https://www.twitch.tv/streamer"))
+}
+
+func TestTwitchFetcher_Handle_NotConfigured(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	f := NewTwitchFetcher(logger.NewTestLogger(), mockClient, "", "")
+
+	request, err := NewRequestPayload("https://clips.twitch.tv/FunnyClip", nil, nil)
+	require.NoError(t, err)
+
+	response, err := f.Handle(request)
+	require.ErrorIs(t, err, ErrTwitchNotConfigured)
+	assert.True(t, response.IsError)
+	assert.Equal(t, "Twitch API not configured", response.GetText())
+	mockClient.AssertNotCalled(t, "Do", mock.Anything)
+}
+
+func TestTwitchFetcher_Handle_ClipSuccess(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	expectTwitchToken(mockClient)
+
+	mockClient.EXPECT().
+		Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "https://api.twitch.tv/helix/clips?id=FunnyClip-abc_123" &&
+				req.Header.Get("Client-Id") == "client-id" &&
+				req.Header.Get("Authorization") == "Bearer token"
+		})).
+		Return(newTwitchJSONResponse(`{"data": [{
+			"id": "FunnyClip-abc_123",
+			"broadcaster_name": "Streamer",
+			"creator_name": "Clipper",
+			"game_id": "509658",
+			"title": "Funny moment",
+			"view_count": 1500,
+			"created_at": "2025-05-01T12:00:00Z",
+			"thumbnail_url": "https://clips-media-assets2.twitch.tv/thumb.jpg",
+			"duration": 29.5
+		}]}`), nil).
+		Once()
+
+	mockClient.EXPECT().
+		Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "https://api.twitch.tv/helix/games?id=509658"
+		})).
+		Return(newTwitchJSONResponse(`{"data": [{"id": "509658", "name": "Just Chatting"}]}`), nil).
+		Once()
+
+	f := NewTwitchFetcher(logger.NewTestLogger(), mockClient, "client-id", "client-secret")
+
+	request, err := NewRequestPayload("https://www.twitch.tv/streamer/clip/FunnyClip-abc_123", nil, nil)
+	require.NoError(t, err)
+
+	response, err := f.Handle(request)
+	require.NoError(t, err)
+	assert.False(t, response.IsError)
+
+	expectedText := `Twitch Clip: Funny moment
+Streamer: Streamer
+Category: Just Chatting
+Clipped by: Clipper
+Views: 1500 | Duration: 30s
+Created: 2025-05-01`
+	assert.Equal(t, expectedText, response.GetText())
+	assert.Equal(t, []string{"https://clips-media-assets2.twitch.tv/thumb.jpg"}, response.GetImages())
+}
+
+func TestTwitchFetcher_Handle_VideoSuccess(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	expectTwitchToken(mockClient)
+
+	mockClient.EXPECT().
+		Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "https://api.twitch.tv/helix/videos?id=123456"
+		})).
+		Return(newTwitchJSONResponse(`{"data": [{
+			"id": "123456",
+			"user_name": "Streamer",
+			"title": "Long stream",
+			"description": "Playing games",
+			"created_at": "2025-04-30T18:00:00Z",
+			"view_count": 42000,
+			"thumbnail_url": "https://static-cdn.jtvnw.net/thumb-%{width}x%{height}.jpg",
+			"duration": "3h8m33s"
+		}]}`), nil).
+		Once()
+
+	f := NewTwitchFetcher(logger.NewTestLogger(), mockClient, "client-id", "client-secret")
+
+	request, err := NewRequestPayload("https://www.twitch.tv/videos/123456", nil, nil)
+	require.NoError(t, err)
+
+	response, err := f.Handle(request)
+	require.NoError(t, err)
+
+	expectedText := `Twitch VOD: Long stream
+Streamer: Streamer
+Views: 42000 | Duration: 3h8m33s
+Created: 2025-04-30
+
+Description:
+Playing games`
+	assert.Equal(t, expectedText, response.GetText())
+	assert.Equal(t, []string{"https://static-cdn.jtvnw.net/thumb-1280x720.jpg"}, response.GetImages())
+}
+
+func TestTwitchFetcher_Handle_TokenCached(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	expectTwitchToken(mockClient)
+
+	mockClient.EXPECT().
+		Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "https://api.twitch.tv/helix/videos?id=123456"
+		})).
+		RunAndReturn(func(req *http.Request) (*http.Response, error) {
+			return newTwitchJSONResponse(`{"data": [{"id": "123456", "title": "Stream"}]}`), nil
+		}).
+		Times(2)
+
+	f := NewTwitchFetcher(logger.NewTestLogger(), mockClient, "client-id", "client-secret")
+	request, err := NewRequestPayload("https://www.twitch.tv/videos/123456", nil, nil)
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = f.Handle(request)
+		require.NoError(t, err)
+	}
+}
+
+func TestTwitchFetcher_Handle_NotFound(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	expectTwitchToken(mockClient)
+
+	mockClient.EXPECT().
+		Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "https://api.twitch.tv/helix/clips?id=Missing"
+		})).
+		Return(newTwitchJSONResponse(`{"data": []}`), nil).
+		Once()
+
+	f := NewTwitchFetcher(logger.NewTestLogger(), mockClient, "client-id", "client-secret")
+	request, err := NewRequestPayload("https://clips.twitch.tv/Missing", nil, nil)
+	require.NoError(t, err)
+
+	response, err := f.Handle(request)
+	require.Error(t, err)
+	assert.True(t, response.IsError)
+	assert.Contains(t, err.Error(), "clip Missing not found")
+}
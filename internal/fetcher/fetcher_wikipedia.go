@@ -0,0 +1,158 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+var wikipediaRegex = regexp.MustCompile(`^(?:https?://)?([a-z][a-z0-9\-]*)\.(?:m\.)?wikipedia\.org/wiki/([^?#]+)`)
+
+type WikipediaSummary struct {
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Extract     string `json:"extract"`
+	Thumbnail   struct {
+		Source string `json:"source"`
+	} `json:"thumbnail"`
+	OriginalImage struct {
+		Source string `json:"source"`
+	} `json:"originalimage"`
+}
+
+type WikipediaFetcher struct {
+	BaseFetcher
+}
+
+func NewWikipediaFetcher(l logger.Logger, client HTTPClient) WikipediaFetcher {
+	return WikipediaFetcher{
+		BaseFetcher: NewBaseFetcher(FetcherNameWikipedia, "[a-z][a-z0-9\\-]*\\.(?:m\\.)?wikipedia\\.org/wiki/", client, l),
+	}
+}
+
+func (f WikipediaFetcher) Handle(request Request) (Response, error) {
+	matches := wikipediaRegex.FindStringSubmatch(request.URL())
+	if len(matches) < 3 {
+		return Response{}, ErrNotHandle
+	}
+	lang := matches[1]
+	title, err := url.PathUnescape(matches[2])
+	if err != nil {
+		title = matches[2]
+	}
+	title = strings.ReplaceAll(title, " ", "_")
+
+	// escape the title as a single path segment, titles like AC/DC contain slashes
+	apiURL := fmt.Sprintf("https://%s.wikipedia.org/api/rest_v1/page", lang)
+	escapedTitle := url.PathEscape(title)
+
+	summary, err := f.getSummary(apiURL + "/summary/" + escapedTitle)
+	if err != nil {
+		return f.errorResponse(fmt.Errorf("wikipedia article %s (%s): %w", title, lang, err))
+	}
+	if summary.Type == "disambiguation" {
+		return f.errorResponse(fmt.Errorf("wikipedia article %s (%s) is a disambiguation page, link a specific article instead", summary.Title, lang))
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Wikipedia (%s): %s\n", lang, summary.Title)
+	if summary.Description != "" {
+		fmt.Fprintf(&text, "Description: %s\n", summary.Description)
+	}
+	if summary.Extract != "" {
+		text.WriteString("\nSummary:\n" + summary.Extract + "\n")
+	}
+
+	// the summary is enough to answer, so full text errors are not fatal
+	if fullText, err := f.getFullText(apiURL + "/html/" + escapedTitle); err != nil {
+		f.logger.WithError(err).WithField("title", title).Warn("Failed to get full article text")
+	} else if fullText != "" {
+		text.WriteString("\nFull text:\n" + fullText)
+	}
+
+	content := []Content{{Type: ContentTypeText, Text: strings.TrimSpace(text.String())}}
+	thumbnail := summary.Thumbnail.Source
+	if thumbnail == "" {
+		thumbnail = summary.OriginalImage.Source
+	}
+	if thumbnail != "" {
+		content = append(content, Content{Type: ContentTypeImage, Text: thumbnail})
+	}
+
+	return Response{Content: content}, nil
+}
+
+func (f WikipediaFetcher) getSummary(apiURL string) (*WikipediaSummary, error) {
+	resp, body, err := f.fetch(MustNewRequestPayload(apiURL, map[string]string{
+		"Accept": "application/json",
+	}, nil))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var summary WikipediaSummary
+	if err := json.Unmarshal([]byte(body), &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// getFullText returns the article sections as plain text without
+// references, tables, infoboxes and navigation templates
+func (f WikipediaFetcher) getFullText(apiURL string) (string, error) {
+	resp, body, err := f.fetch(MustNewRequestPayload(apiURL, nil, nil))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	doc, err := f.getGoqueryDoc(body)
+	if err != nil {
+		return "", err
+	}
+	f.cleanDoc(doc)
+	doc.Find("table, figure, sup.reference, .mw-ref, .reference, .reflist, .mw-references-wrap, .navbox, .hatnote, .mw-editsection").Remove()
+
+	var text strings.Builder
+	doc.Find("h2, h3, h4, p, li").Each(func(i int, s *goquery.Selection) {
+		node := s
+		if goquery.NodeName(s) == "li" {
+			// nested lists are printed as separate items
+			node = s.Clone()
+			node.Find("ul, ol").Remove()
+		}
+		line := f.cleanText(node.Text())
+		if line == "" {
+			return
+		}
+		switch goquery.NodeName(s) {
+		case "h2", "h3", "h4":
+			text.WriteString("\n## " + line + "\n")
+		case "li":
+			text.WriteString(strings.Repeat("  ", s.ParentsFiltered("li").Length()) + "- " + line + "\n")
+		default:
+			text.WriteString(line + "\n")
+		}
+	})
+
+	return strings.TrimSpace(text.String()), nil
+}
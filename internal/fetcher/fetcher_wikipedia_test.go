@@ -0,0 +1,142 @@
+package fetcher
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func wikipediaResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		Header:     make(http.Header),
+	}
+}
+
+func expectWikipediaRequest(client *MockHTTPClient, url string, resp *http.Response) {
+	client.EXPECT().
+		Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == url
+		})).
+		Return(resp, nil).
+		Once()
+}
+
+func TestWikipediaFetcher_CanHandle(t *testing.T) {
+	f := NewWikipediaFetcher(logger.NewTestLogger(), nil)
+
+	assert.True(t, f.CanHandle("https://en.wikipedia.org/wiki/Go_(programming_language)"))
+	assert.True(t, f.CanHandle("https://ru.m.wikipedia.org/wiki/%D0%93%D0%BE"))
+	assert.True(t, f.CanHandle("https://zh-yue.wikipedia.org/wiki/Go"))
+	assert.False(t, f.CanHandle("https://en.wikipedia.org/w/index.php?title=Go"))
+	assert.False(t, f.CanHandle("https://wikipedia.org"))
+}
+
+func TestWikipediaFetcher_Handle_Success(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	expectWikipediaRequest(mockClient,
+		"https://en.wikipedia.org/api/rest_v1/page/summary/Go_%28programming_language%29",
+		wikipediaResponse(http.StatusOK, `{
+			"type": "standard",
+			"title": "Go (programming language)",
+			"description": "Programming language",
+			"extract": "Go is a statically typed, compiled high-level programming language.",
+			"thumbnail": {"source": "https://upload.wikimedia.org/go-thumb.png"},
+			"originalimage": {"source": "https://upload.wikimedia.org/go.png"}
+		}`),
+	)
+	expectWikipediaRequest(mockClient,
+		"https://en.wikipedia.org/api/rest_v1/page/html/Go_%28programming_language%29",
+		wikipediaResponse(http.StatusOK, `<html><body>
+			<section><p>Go was designed at Google.<sup class="reference">[1]</sup></p>
+			<table class="infobox"><tr><td>Paradigm</td></tr></table></section>
+			<section><h2>History</h2><p>Go was publicly announced in 2009.</p>
+			<ul><li>Generics<ul><li>Type parameters</li></ul></li></ul></section>
+		</body></html>`),
+	)
+
+	f := NewWikipediaFetcher(logger.NewTestLogger(), mockClient)
+	request, err := NewRequestPayload("https://en.wikipedia.org/wiki/Go_(programming_language)#History", nil, nil)
+	require.NoError(t, err)
+
+	resp, err := f.Handle(request)
+	require.NoError(t, err)
+	require.False(t, resp.IsError)
+	require.Len(t, resp.Content, 2)
+
+	text := resp.Content[0].Text
+	assert.Contains(t, text, "Wikipedia (en): Go (programming language)")
+	assert.Contains(t, text, "Description: Programming language")
+	assert.Contains(t, text, "Go is a statically typed")
+	assert.Contains(t, text, "Go was designed at Google.")
+	assert.Contains(t, text, "## History")
+	assert.Contains(t, text, "- Generics\n  - Type parameters")
+	assert.NotContains(t, text, "[1]")
+	assert.NotContains(t, text, "Paradigm")
+	assert.Equal(t, Content{Type: ContentTypeImage, Text: "https://upload.wikimedia.org/go-thumb.png"}, resp.Content[1])
+}
+
+func TestWikipediaFetcher_Handle_Language(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	expectWikipediaRequest(mockClient,
+		"https://ru.wikipedia.org/api/rest_v1/page/summary/%D0%93%D0%BE",
+		wikipediaResponse(http.StatusOK, `{"type": "standard", "title": "Го", "extract": "Го — язык программирования."}`),
+	)
+	// full text is optional
+	expectWikipediaRequest(mockClient,
+		"https://ru.wikipedia.org/api/rest_v1/page/html/%D0%93%D0%BE",
+		wikipediaResponse(http.StatusNotFound, ""),
+	)
+
+	f := NewWikipediaFetcher(logger.NewTestLogger(), mockClient)
+	request, err := NewRequestPayload("https://ru.m.wikipedia.org/wiki/%D0%93%D0%BE", nil, nil)
+	require.NoError(t, err)
+
+	resp, err := f.Handle(request)
+	require.NoError(t, err)
+	require.Len(t, resp.Content, 1)
+	assert.Contains(t, resp.Content[0].Text, "Wikipedia (ru): Го")
+	assert.Contains(t, resp.Content[0].Text, "Го — язык программирования.")
+	assert.NotContains(t, resp.Content[0].Text, "Full text:")
+}
+
+func TestWikipediaFetcher_Handle_Disambiguation(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	expectWikipediaRequest(mockClient,
+		"https://en.wikipedia.org/api/rest_v1/page/summary/Go",
+		wikipediaResponse(http.StatusOK, `{"type": "disambiguation", "title": "Go", "extract": "Go may refer to:"}`),
+	)
+
+	f := NewWikipediaFetcher(logger.NewTestLogger(), mockClient)
+	request, err := NewRequestPayload("https://en.wikipedia.org/wiki/Go", nil, nil)
+	require.NoError(t, err)
+
+	resp, err := f.Handle(request)
+	require.Error(t, err)
+	assert.True(t, resp.IsError)
+	assert.Contains(t, err.Error(), "disambiguation")
+}
+
+func TestWikipediaFetcher_Handle_NotFound(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	expectWikipediaRequest(mockClient,
+		"https://en.wikipedia.org/api/rest_v1/page/summary/No_such_article",
+		wikipediaResponse(http.StatusNotFound, `{"type": "https://mediawiki.org/wiki/HyperSwitch/errors/not_found"}`),
+	)
+
+	f := NewWikipediaFetcher(logger.NewTestLogger(), mockClient)
+	request, err := NewRequestPayload("https://en.wikipedia.org/wiki/No_such_article", nil, nil)
+	require.NoError(t, err)
+
+	resp, err := f.Handle(request)
+	require.Error(t, err)
+	assert.True(t, resp.IsError)
+	assert.Contains(t, err.Error(), "not found")
+}
@@ -0,0 +1,143 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+const xImagesHost = "https://pbs.twimg.com/"
+
+// fixupx.com and similar hosts come from fix_x_previews and preview fixers in other bots
+var xStatusRegex = regexp.MustCompile(`(?:^|[/.])(?:twitter|x|fixupx|fxtwitter|vxtwitter)\.com/(\w+)/status/(\d+)`)
+
+// IsXStatusURL reports whether the URL is a link to an X (Twitter) post
+func IsXStatusURL(url string) bool {
+	return xStatusRegex.MatchString(url)
+}
+
+type XFetcher struct {
+	BaseFetcher
+	instance string
+	timeout  time.Duration
+}
+
+// NewXFetcher creates a fetcher for X posts through a Nitter-style instance, e.g. https://nitter.net
+func NewXFetcher(l logger.Logger, client HTTPClient, instance string, timeout time.Duration) XFetcher {
+	return XFetcher{
+		BaseFetcher: NewBaseFetcher(FetcherNameX, xStatusRegex.String(), client, l),
+		instance:    strings.TrimRight(instance, "/"),
+		timeout:     timeout,
+	}
+}
+
+func (f XFetcher) Handle(request Request) (Response, error) {
+	if f.instance == "" {
+		return Response{}, ErrNotHandle
+	}
+	matches := xStatusRegex.FindStringSubmatch(request.URL())
+	if len(matches) < 3 {
+		return Response{}, ErrNotHandle
+	}
+	user, id := matches[1], matches[2]
+
+	ctx := context.Background()
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+
+	resp, body, err := f.fetchContext(ctx, MustNewRequestPayload(fmt.Sprintf("%s/%s/status/%s", f.instance, user, id), nil, nil))
+	if err != nil {
+		f.logger.WithError(err).WithField("instance", f.instance).Error("Nitter instance request failed")
+		return f.errorResponse(fmt.Errorf("nitter instance %s is unavailable", f.instance))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return f.errorResponse(fmt.Errorf("post %s not found (%d)", id, resp.StatusCode))
+	}
+
+	doc, err := f.getGoqueryDoc(body)
+	if err != nil {
+		return f.errorResponse(err)
+	}
+	tweet := doc.Find(".main-tweet").First()
+	if tweet.Length() == 0 {
+		return f.errorResponse(fmt.Errorf("post %s content not found", id))
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "X Post by %s (%s)\n",
+		strings.TrimSpace(tweet.Find(".fullname").First().Text()),
+		strings.TrimSpace(tweet.Find(".username").First().Text()),
+	)
+	if date, ok := tweet.Find(".tweet-date a").First().Attr("title"); ok {
+		fmt.Fprintf(&text, "Posted: %s\n", date)
+	}
+	stats := f.extractStats(tweet)
+	fmt.Fprintf(&text, "Likes: %s | Retweets: %s | Replies: %s\n\n%s",
+		stats["heart"],
+		stats["retweet"],
+		stats["comment"],
+		f.extractText(tweet.Find(".tweet-content").First()),
+	)
+
+	content := []Content{{Type: ContentTypeText, Text: text.String()}}
+	for _, image := range f.extractImages(tweet) {
+		content = append(content, Content{Type: ContentTypeImage, Text: image})
+	}
+
+	return Response{Content: content}, nil
+}
+
+// extractText replaces shortened link titles with full URLs to keep them for recursive extraction
+func (f XFetcher) extractText(s *goquery.Selection) string {
+	s.Find("a").Each(func(i int, a *goquery.Selection) {
+		if href, ok := a.Attr("href"); ok && strings.HasPrefix(href, "http") {
+			a.SetText(href)
+		}
+	})
+	return strings.TrimSpace(s.Text())
+}
+
+func (f XFetcher) extractStats(tweet *goquery.Selection) map[string]string {
+	stats := map[string]string{"comment": "0", "retweet": "0", "heart": "0"}
+	tweet.Find(".tweet-stats .tweet-stat").Each(func(i int, s *goquery.Selection) {
+		value := strings.TrimSpace(s.Text())
+		if value == "" {
+			return
+		}
+		for name := range stats {
+			if s.Find(".icon-"+name).Length() > 0 {
+				stats[name] = value
+			}
+		}
+	})
+	return stats
+}
+
+// extractImages converts instance proxied images (/pic/orig/media%2F...) to the original ones
+func (f XFetcher) extractImages(tweet *goquery.Selection) []string {
+	var images []string
+	tweet.Find(".attachments .still-image").Each(func(i int, s *goquery.Selection) {
+		href, ok := s.Attr("href")
+		if !ok {
+			return
+		}
+		path, err := url.PathUnescape(strings.TrimPrefix(href, "/pic/"))
+		if err != nil || path == href {
+			images = append(images, f.instance+href)
+			return
+		}
+		images = append(images, xImagesHost+strings.TrimPrefix(path, "orig/"))
+	})
+	return images
+}
@@ -0,0 +1,132 @@
+package fetcher
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXFetcher_CanHandle(t *testing.T) {
+	f := NewXFetcher(logger.NewTestLogger(), nil, "https://nitter.net", time.Second)
+
+	assert.True(t, f.CanHandle("https://x.com/golang/status/1955000000000000000"))
+	assert.True(t, f.CanHandle("https://twitter.com/golang/status/1955000000000000000?s=20"))
+	assert.True(t, f.CanHandle("https://mobile.twitter.com/golang/status/1955000000000000000"))
+	assert.True(t, f.CanHandle("https://fixupx.com/golang/status/1955000000000000000"))
+	assert.False(t, f.CanHandle("https://x.com/golang"))
+	assert.False(t, f.CanHandle("https://box.com/golang/status/1955000000000000000"))
+}
+
+func TestXFetcher_Handle_NotConfigured(t *testing.T) {
+	mockClient := NewMockHTTPClient(t)
+	f := NewXFetcher(logger.NewTestLogger(), mockClient, "", time.Second)
+
+	request, err := NewRequestPayload("https://x.com/golang/status/1955000000000000000", nil, nil)
+	require.NoError(t, err)
+
+	_, err = f.Handle(request)
+	require.ErrorIs(t, err, ErrNotHandle)
+	mockClient.AssertNotCalled(t, "Do", mock.Anything)
+}
+
+func TestXFetcher_Handle_Success(t *testing.T) {
+	htmlContent, err := os.ReadFile("testdata/x_success.html")
+	require.NoError(t, err)
+
+	mockClient := NewMockHTTPClient(t)
+	mockClient.EXPECT().
+		Do(mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "https://nitter.net/golang/status/1955000000000000000"
+		})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(htmlContent)),
+			Header:     http.Header{"Content-Type": []string{"text/html"}},
+		}, nil).
+		Once()
+
+	f := NewXFetcher(logger.NewTestLogger(), mockClient, "https://nitter.net/", time.Second)
+	request, err := NewRequestPayload("https://x.com/golang/status/1955000000000000000?s=20", nil, nil)
+	require.NoError(t, err)
+
+	response, err := f.Handle(request)
+	require.NoError(t, err)
+	assert.False(t, response.IsError)
+
+	text := response.GetText()
+	assert.Contains(t, text, "X Post by Go (@golang)")
+	assert.Contains(t, text, "Posted: Aug 12, 2025 · 5:00 PM UTC")
+	assert.Contains(t, text, "Likes: 5,600 | Retweets: 1,024 | Replies: 120")
+	assert.Contains(t, text, "Go 1.25 is released! 🎉")
+	assert.Contains(t, text, "Read more: https://go.dev/blog/go1.25 via @gopher")
+	assert.Equal(t, []string{
+		"https://pbs.twimg.com/media/Gopher123.jpg",
+		"https://pbs.twimg.com/media/Gopher456.png",
+	}, response.GetImages())
+}
+
+func TestXFetcher_Handle_InstanceUnavailable(t *testing.T) {
+	request, err := NewRequestPayload("https://x.com/golang/status/1955000000000000000", nil, nil)
+	require.NoError(t, err)
+
+	t.Run("request error", func(t *testing.T) {
+		log := logger.NewTestLogger()
+		mockClient := NewMockHTTPClient(t)
+		mockClient.EXPECT().Do(mock.Anything).Return(nil, errors.New("connection refused")).Once()
+
+		response, err := NewXFetcher(log, mockClient, "https://nitter.example", time.Second).Handle(request)
+		require.Error(t, err)
+		assert.True(t, response.IsError)
+		assert.Equal(t, "nitter instance https://nitter.example is unavailable", response.GetText())
+		require.Len(t, log.GetEntries(), 1)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		mockClient := NewMockHTTPClient(t)
+		mockClient.EXPECT().
+			Do(mock.Anything).
+			RunAndReturn(func(req *http.Request) (*http.Response, error) {
+				<-req.Context().Done()
+				return nil, req.Context().Err()
+			}).
+			Once()
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := NewXFetcher(logger.NewTestLogger(), mockClient, "https://nitter.example", 10*time.Millisecond).Handle(request)
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			require.Error(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("fetcher hangs on unavailable instance")
+		}
+	})
+
+	t.Run("bad gateway", func(t *testing.T) {
+		mockClient := NewMockHTTPClient(t)
+		mockClient.EXPECT().
+			Do(mock.Anything).
+			Return(&http.Response{
+				StatusCode: http.StatusBadGateway,
+				Body:       io.NopCloser(bytes.NewReader(nil)),
+				Header:     make(http.Header),
+			}, nil).
+			Once()
+
+		response, err := NewXFetcher(logger.NewTestLogger(), mockClient, "https://nitter.example", time.Second).Handle(request)
+		require.Error(t, err)
+		assert.True(t, response.IsError)
+	})
+}
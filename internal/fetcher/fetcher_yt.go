@@ -2,7 +2,7 @@ package fetcher
 
 import (
 	"errors"
-	"net/http"
+	"fmt"
 	"strings"
 	"time"
 
@@ -67,7 +67,7 @@ type YoutubeFetcher struct {
 	service youtubeService
 }
 
-func NewYoutubeFetcher(l logger.Logger, httpClient *http.Client, ytService youtubeService) YoutubeFetcher {
+func NewYoutubeFetcher(l logger.Logger, httpClient HTTPClient, ytService youtubeService) YoutubeFetcher {
 	return YoutubeFetcher{
 		BaseFetcher: NewBaseFetcher(FetcherNameYoutube, "youtube\\.com|youtu\\.be", httpClient, l),
 		service:     ytService,
@@ -110,6 +110,16 @@ func (f YoutubeFetcher) Handle(request Request) (Response, error) {
 	metadataString := strings.Join(metadataItems, " | ")
 	responseString := "Title: " + title + "\n"
 	responseString += metadataString
+	hasStructure := transcript.Description != "" || len(transcript.Chapters) > 0
+	if transcript.Description != "" {
+		responseString += "\nDescription:\n" + transcript.Description
+	}
+	if len(transcript.Chapters) > 0 {
+		responseString += "\nChapters:\n" + formatChapters(transcript.Chapters)
+	}
+	if hasStructure && normalizedTranscript != "" {
+		responseString += "\nTranscript:"
+	}
 	responseString += "\n" + strings.TrimSpace(normalizedTranscript)
 	if transcript.Comments != "" {
 		responseString += "\nPopular comments:\n" + transcript.Comments
@@ -120,3 +130,21 @@ func (f YoutubeFetcher) Handle(request Request) (Response, error) {
 		IsError: false,
 	}, nil
 }
+
+func formatChapters(chapters []youtube.Chapter) string {
+	lines := make([]string, len(chapters))
+	for i, chapter := range chapters {
+		lines[i] = fmt.Sprintf("%d. %s %s", i+1, formatTimestamp(chapter.Start), chapter.Title)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatTimestamp formats duration like youtube does: 1:02:03 or 2:03
+func formatTimestamp(d time.Duration) string {
+	total := int(d.Seconds())
+	hours, minutes, seconds := total/3600, total%3600/60, total%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
@@ -182,6 +182,56 @@ Simple transcript.`
 	assert.Equal(t, expectedText, response.Content[0].Text)
 }
 
+func TestYoutubeFetcher_Handle_Success_WithChapters(t *testing.T) {
+	l := logger.NewTestLogger()
+	mockService := newMockYoutubeService(t)
+
+	expectedData := &youtube.YoutubeData{
+		Title:       "Video With Chapters",
+		Description: "Everything about Go",
+		Chapters: []youtube.Chapter{
+			{Title: "Intro", Start: 0, End: 90 * time.Second},
+			{Title: "Generics", Start: 90 * time.Second, End: time.Hour + 5*time.Second},
+			{Title: "Outro", Start: time.Hour + 5*time.Second},
+		},
+		Transcript: "Hello and welcome.",
+	}
+
+	mockService.EXPECT().
+		FetchYoutubeData(
+			"https://youtube.com/watch?v=chapters",
+			youtube.FetchTranscript,
+			0,
+		).
+		Return(expectedData, nil)
+
+	fetcher := NewYoutubeFetcher(l, &http.Client{}, mockService)
+
+	request, err := NewYoutubeRequest(
+		"https://youtube.com/watch?v=chapters",
+		nil,
+		youtube.FetchTranscript,
+		0,
+	)
+	require.NoError(t, err)
+
+	response, err := fetcher.Handle(request)
+	require.NoError(t, err)
+	assert.False(t, response.IsError)
+
+	expectedText := `Title: Video With Chapters
+
+Description:
+Everything about Go
+Chapters:
+1. 0:00 Intro
+2. 1:30 Generics
+3. 1:00:05 Outro
+Transcript:
+Hello and welcome.`
+	assert.Equal(t, expectedText, response.Content[0].Text)
+}
+
 func TestYoutubeFetcher_Handle_ServiceError(t *testing.T) {
 	l := logger.NewTestLogger()
 	mockService := newMockYoutubeService(t)
@@ -0,0 +1,49 @@
+package fetcher
+
+import (
+	"net/http"
+	"strings"
+)
+
+// browserHeaders are sent by a desktop browser opening a page, some sites block requests without them
+var browserHeaders = map[string]string{
+	"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+	"Accept-Language":           "en-US,en;q=0.9",
+	"Upgrade-Insecure-Requests": "1",
+	"Sec-Fetch-Dest":            "document",
+	"Sec-Fetch-Mode":            "navigate",
+	"Sec-Fetch-Site":            "none",
+	"Sec-Fetch-User":            "?1",
+}
+
+// BrowserHeadersClient adds browser-like headers to requests to the matching hosts,
+// headers already set by the request are kept
+type BrowserHeadersClient struct {
+	client HTTPClient
+	hosts  []string
+}
+
+// NewBrowserHeadersClient wraps the client, hosts are parts of the host, "*" matches any host
+func NewBrowserHeadersClient(client HTTPClient, hosts []string) *BrowserHeadersClient {
+	return &BrowserHeadersClient{client: client, hosts: hosts}
+}
+
+func (c *BrowserHeadersClient) Do(req *http.Request) (*http.Response, error) {
+	if c.matches(req.URL.Hostname()) {
+		for key, value := range browserHeaders {
+			if req.Header.Get(key) == "" {
+				req.Header.Set(key, value)
+			}
+		}
+	}
+	return c.client.Do(req)
+}
+
+func (c *BrowserHeadersClient) matches(host string) bool {
+	for _, pattern := range c.hosts {
+		if pattern == "*" || (pattern != "" && strings.Contains(host, pattern)) {
+			return true
+		}
+	}
+	return false
+}
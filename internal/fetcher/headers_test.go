@@ -0,0 +1,49 @@
+package fetcher
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headersTestClient records the headers of the last request
+type headersTestClient struct {
+	headers *http.Header
+}
+
+func (c headersTestClient) Do(req *http.Request) (*http.Response, error) {
+	*c.headers = req.Header.Clone()
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestBrowserHeadersClient(t *testing.T) {
+	headers := &http.Header{}
+	client := NewBrowserHeadersClient(headersTestClient{headers: headers}, []string{"example.com"})
+
+	doRequest := func(url string, header map[string]string) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	doRequest("https://news.example.com/page", map[string]string{"Accept-Language": "ru"})
+	assert.Equal(t, "navigate", headers.Get("Sec-Fetch-Mode"))
+	assert.Contains(t, headers.Get("Accept"), "text/html")
+	assert.Equal(t, "ru", headers.Get("Accept-Language"), "Headers of the request are kept")
+
+	doRequest("https://other.org/page", nil)
+	assert.Empty(t, headers.Get("Sec-Fetch-Mode"), "Other hosts get no browser headers")
+
+	client = NewBrowserHeadersClient(headersTestClient{headers: headers}, []string{"*"})
+	doRequest("https://other.org/page", nil)
+	assert.Equal(t, "document", headers.Get("Sec-Fetch-Dest"))
+}
@@ -54,6 +54,15 @@ func (f *Manager) Fetch(request Request) (Response, error) {
 	return Response{}, fmt.Errorf("no fetcher found for URL: %s", URL)
 }
 
+// FetchWith handles the request with the registered fetcher by name, the URL pattern is not checked
+func (f *Manager) FetchWith(name string, request Request) (Response, error) {
+	fetcher, exists := f.fetcherMap[name]
+	if !exists {
+		return Response{}, fmt.Errorf("fetcher %s is not registered", name)
+	}
+	return fetcher.Handle(request)
+}
+
 func (f *Manager) SetDefaultFetcher(fetcher Fetcher) {
 	f.defaultFetcher = fetcher
 }
@@ -86,6 +95,14 @@ func (r Response) GetImages() []string {
 	return r.getByType(ContentTypeImage)
 }
 
+// GetCanonicalURL returns the canonical URL of the page, empty if the page doesn't have it
+func (r Response) GetCanonicalURL() string {
+	if urls := r.getByType(ContentTypeCanonicalURL); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
+
 func (r Response) getByType(contentType ContentType) []string {
 	result := []string{}
 	for _, item := range r.Content {
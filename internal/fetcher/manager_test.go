@@ -303,6 +303,24 @@ func TestManager_Fetch(t *testing.T) {
 	})
 }
 
+func TestManager_FetchWith(t *testing.T) {
+	manager := NewManager(logger.NewTestLogger())
+	mockFetcher := NewMockFetcher(t)
+	mockFetcher.EXPECT().GetName().Return("test-fetcher")
+	manager.RegisterFetcher(mockFetcher)
+
+	request := MustNewRequestPayload("https://example.com", nil, nil)
+	expectedResponse := Response{Content: []Content{{Type: ContentTypeText, Text: "test content"}}}
+	mockFetcher.EXPECT().Handle(request).Return(expectedResponse, nil).Once()
+
+	response, err := manager.FetchWith("test-fetcher", request)
+	require.NoError(t, err)
+	assert.Equal(t, expectedResponse, response)
+
+	_, err = manager.FetchWith("missing", request)
+	assert.EqualError(t, err, "fetcher missing is not registered")
+}
+
 func TestManager_Integration(t *testing.T) {
 	log := logger.NewTestLogger()
 
@@ -0,0 +1,94 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+// ProxyRotator sends requests through a pool of clients, one per proxy. Proxies are used
+// in turn, or with sticky rotation each host keeps its proxy until the host blocks it.
+// A request blocked by the host (403 or 429) is retried once with the next proxy
+type ProxyRotator struct {
+	clients []HTTPClient
+	sticky  bool
+	logger  logger.Logger
+
+	mu    sync.Mutex
+	next  int
+	hosts map[string]int
+}
+
+func NewProxyRotator(clients []HTTPClient, sticky bool, l logger.Logger) *ProxyRotator {
+	return &ProxyRotator{
+		clients: clients,
+		sticky:  sticky,
+		logger:  l,
+		hosts:   make(map[string]int),
+	}
+}
+
+func (r *ProxyRotator) Do(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	index := r.pick(host)
+	resp, err := r.clients[index].Do(req)
+	if err != nil || len(r.clients) == 1 || !isBlockedStatus(resp.StatusCode) {
+		return resp, err
+	}
+	// the body is already sent and can't be read again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+
+	nextIndex := r.rotate(host, index)
+	r.logger.WithFields(logger.Fields{
+		"host":   host,
+		"status": resp.StatusCode,
+		"proxy":  nextIndex,
+	}).Info("Request blocked by host, retry with the next proxy")
+	resp, err = r.clients[nextIndex].Do(retry)
+	if err != nil {
+		return nil, fmt.Errorf("retry with the next proxy: %w", err)
+	}
+	return resp, nil
+}
+
+// pick returns the index of the client for the request to the host
+func (r *ProxyRotator) pick(host string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if index, ok := r.hosts[host]; ok && r.sticky {
+		return index
+	}
+	index := r.next
+	r.next = (r.next + 1) % len(r.clients)
+	if r.sticky {
+		r.hosts[host] = index
+	}
+	return index
+}
+
+// rotate returns the client after the blocked one, the host sticks to it
+func (r *ProxyRotator) rotate(host string, blocked int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	index := (blocked + 1) % len(r.clients)
+	if r.sticky {
+		r.hosts[host] = index
+	}
+	return index
+}
+
+func isBlockedStatus(status int) bool {
+	return status == http.StatusForbidden || status == http.StatusTooManyRequests
+}
@@ -0,0 +1,103 @@
+package fetcher
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// proxyTestClient answers with the status for the blocked hosts and 200 for others
+type proxyTestClient struct {
+	name    string
+	blocked map[string]int
+	calls   *[]string
+}
+
+func (c proxyTestClient) Do(req *http.Request) (*http.Response, error) {
+	*c.calls = append(*c.calls, c.name+" "+req.URL.Host)
+	status := http.StatusOK
+	if blockedStatus, ok := c.blocked[req.URL.Host]; ok {
+		status = blockedStatus
+	}
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(c.name))}, nil
+}
+
+func newProxyTestRotator(sticky bool, blocked map[string]int) (*ProxyRotator, *[]string) {
+	calls := &[]string{}
+	clients := []HTTPClient{
+		proxyTestClient{name: "p1", blocked: blocked, calls: calls},
+		proxyTestClient{name: "p2", calls: calls},
+		proxyTestClient{name: "p3", calls: calls},
+	}
+	return NewProxyRotator(clients, sticky, logger.NewTestLogger()), calls
+}
+
+func doProxyTestRequest(t *testing.T, client HTTPClient, url string) (int, string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestProxyRotator_RoundRobin(t *testing.T) {
+	rotator, calls := newProxyTestRotator(false, nil)
+	for range 4 {
+		doProxyTestRequest(t, rotator, "https://a.com")
+	}
+	assert.Equal(t, []string{"p1 a.com", "p2 a.com", "p3 a.com", "p1 a.com"}, *calls)
+}
+
+func TestProxyRotator_Sticky(t *testing.T) {
+	rotator, calls := newProxyTestRotator(true, nil)
+	doProxyTestRequest(t, rotator, "https://a.com")
+	doProxyTestRequest(t, rotator, "https://b.com")
+	doProxyTestRequest(t, rotator, "https://a.com/page")
+	doProxyTestRequest(t, rotator, "https://b.com/page")
+	assert.Equal(t, []string{"p1 a.com", "p2 b.com", "p1 a.com", "p2 b.com"}, *calls)
+}
+
+func TestProxyRotator_RetryBlocked(t *testing.T) {
+	t.Run("sticky host moves to the next proxy", func(t *testing.T) {
+		rotator, calls := newProxyTestRotator(true, map[string]int{"a.com": http.StatusTooManyRequests})
+		status, body := doProxyTestRequest(t, rotator, "https://a.com")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "p2", body)
+
+		doProxyTestRequest(t, rotator, "https://a.com")
+		assert.Equal(t, []string{"p1 a.com", "p2 a.com", "p2 a.com"}, *calls)
+	})
+
+	t.Run("retried only once", func(t *testing.T) {
+		calls := &[]string{}
+		blocked := map[string]int{"a.com": http.StatusForbidden}
+		rotator := NewProxyRotator([]HTTPClient{
+			proxyTestClient{name: "p1", blocked: blocked, calls: calls},
+			proxyTestClient{name: "p2", blocked: blocked, calls: calls},
+			proxyTestClient{name: "p3", calls: calls},
+		}, false, logger.NewTestLogger())
+
+		status, _ := doProxyTestRequest(t, rotator, "https://a.com")
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, []string{"p1 a.com", "p2 a.com"}, *calls)
+	})
+
+	t.Run("single proxy isn't retried", func(t *testing.T) {
+		calls := &[]string{}
+		rotator := NewProxyRotator([]HTTPClient{
+			proxyTestClient{name: "p1", blocked: map[string]int{"a.com": http.StatusForbidden}, calls: calls},
+		}, false, logger.NewTestLogger())
+
+		status, _ := doProxyTestRequest(t, rotator, "https://a.com")
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, []string{"p1 a.com"}, *calls)
+	})
+}
@@ -18,18 +18,29 @@ const (
 	FetcherNameTelegram    = "telegram"
 	FetcherNameAvito       = "avito"
 	FetcherNameReddit      = "reddit"
+	FetcherNameTwitch      = "twitch"
+	FetcherNameX           = "x"
+	FetcherNameWikipedia   = "wikipedia"
+	FetcherNameOsnova      = "osnova"
+	FetcherNameMusic       = "music"
+	FetcherNameFeed        = "feed"
+	FetcherNameArxiv       = "arxiv"
+	FetcherNameTelegraph   = "telegraph"
 )
 
 const (
 	ContentTypeText  ContentType = "text"
 	ContentTypeURL   ContentType = "url"
 	ContentTypeImage ContentType = "image"
+	// page URL from <link rel="canonical">, not a part of the text
+	ContentTypeCanonicalURL ContentType = "canonical_url"
 )
 
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// UserAgents are rotated in requests, replaced by commands.ask.fetcher.user_agents when set
 var UserAgents = []string{
 	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/136.0.0.0 Safari/537.36",
 	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:138.0) Gecko/20100101 Firefox/138.0",
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+const Redacted = "[REDACTED]"
+
+// Redactor masks sensitive substrings (tokens, emails, etc.) in logged data
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor compiles patterns, invalid ones are skipped and returned as an error
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	var errs []error
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("pattern %q: %w", pattern, err))
+			continue
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, errors.Join(errs...)
+}
+
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
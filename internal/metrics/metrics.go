@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+const (
+	namespace       = "gachigazer"
+	labelSeparator  = "\xff"
+	shutdownTimeout = 5 * time.Second
+)
+
+// Metrics collects bot counters and exposes them in the Prometheus text format.
+// A nil *Metrics is valid and does nothing, so callers don't check if metrics are enabled
+type Metrics struct {
+	mu          sync.Mutex
+	requests    *counter
+	tokens      *counter
+	cost        *counter
+	tools       *counter
+	fetchErrors *counter
+}
+
+func New() *Metrics {
+	return &Metrics{
+		requests:    newCounter("requests_total", "AI requests answered by model", "model"),
+		tokens:      newCounter("tokens_total", "Tokens used by model and direction", "model", "direction"),
+		cost:        newCounter("cost_dollars_total", "Cost of AI requests in dollars by model", "model"),
+		tools:       newCounter("tool_calls_total", "Tool invocations by tool and status", "tool", "status"),
+		fetchErrors: newCounter("fetch_errors_total", "Failed URL fetches"),
+	}
+}
+
+// ObserveRequest counts an answered AI request with its usage
+func (m *Metrics) ObserveRequest(model string, inputTokens, outputTokens int64, cost float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests.add(1, model)
+	m.tokens.add(float64(inputTokens), model, "input")
+	m.tokens.add(float64(outputTokens), model, "output")
+	m.cost.add(cost, model)
+}
+
+// ObserveTool counts a tool invocation
+func (m *Metrics) ObserveTool(name string, failed bool) {
+	if m == nil {
+		return
+	}
+	status := "ok"
+	if failed {
+		status = "failed"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tools.add(1, name, status)
+}
+
+// ObserveFetchError counts a failed URL fetch
+func (m *Metrics) ObserveFetchError() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetchErrors.add(1)
+}
+
+// WriteTo writes all counters in the Prometheus text format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	var sb strings.Builder
+	for _, c := range []*counter{m.requests, m.tokens, m.cost, m.tools, m.fetchErrors} {
+		c.write(&sb)
+	}
+	m.mu.Unlock()
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	})
+}
+
+// Serve exposes metrics on addr at /metrics until the context is done
+func (m *Metrics) Serve(ctx context.Context, addr string, l logger.Logger) {
+	if m == nil {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		l.WithField("addr", addr).Info("Metrics server started")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			l.WithError(err).Error("Metrics server failed")
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			l.WithError(err).Warn("Failed to stop metrics server")
+		}
+	}()
+}
+
+type counter struct {
+	name   string
+	help   string
+	labels []string
+	// label values joined with labelSeparator
+	values map[string]float64
+}
+
+func newCounter(name, help string, labels ...string) *counter {
+	return &counter{
+		name:   namespace + "_" + name,
+		help:   help,
+		labels: labels,
+		values: map[string]float64{},
+	}
+}
+
+func (c *counter) add(value float64, labelValues ...string) {
+	c.values[strings.Join(labelValues, labelSeparator)] += value
+}
+
+func (c *counter) write(sb *strings.Builder) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	if len(c.labels) == 0 {
+		fmt.Fprintf(sb, "%s %s\n", c.name, formatValue(c.values[""]))
+		return
+	}
+
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		labelValues := strings.Split(key, labelSeparator)
+		pairs := make([]string, len(c.labels))
+		for i, label := range c.labels {
+			pairs[i] = fmt.Sprintf("%s=\"%s\"", label, escapeLabelValue(labelValues[i]))
+		}
+		fmt.Fprintf(sb, "%s{%s} %s\n", c.name, strings.Join(pairs, ","), formatValue(c.values[key]))
+	}
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.ObserveRequest("or:openai/gpt-4o", 100, 20, 0.0015)
+	m.ObserveRequest("or:openai/gpt-4o", 50, 10, 0.0005)
+	m.ObserveRequest(`local:"quoted"`, 1, 2, 0)
+	m.ObserveTool("search", false)
+	m.ObserveTool("search", true)
+	m.ObserveFetchError()
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain")
+	for _, line := range []string{
+		"# TYPE gachigazer_requests_total counter",
+		`gachigazer_requests_total{model="or:openai/gpt-4o"} 2`,
+		`gachigazer_requests_total{model="local:\"quoted\""} 1`,
+		`gachigazer_tokens_total{model="or:openai/gpt-4o",direction="input"} 150`,
+		`gachigazer_tokens_total{model="or:openai/gpt-4o",direction="output"} 30`,
+		`gachigazer_cost_dollars_total{model="or:openai/gpt-4o"} 0.002`,
+		`gachigazer_tool_calls_total{tool="search",status="ok"} 1`,
+		`gachigazer_tool_calls_total{tool="search",status="failed"} 1`,
+		"gachigazer_fetch_errors_total 1",
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+	require.NotPanics(t, func() {
+		m.ObserveRequest("model", 1, 1, 1)
+		m.ObserveTool("search", false)
+		m.ObserveFetchError()
+		m.Serve(t.Context(), ":0", nil)
+	})
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/muratoffalex/gachigazer/internal/ai"
@@ -9,6 +10,9 @@ import (
 	"github.com/muratoffalex/gachigazer/internal/database"
 )
 
+// ErrPaidModelsDisabled is returned for a paid model in the chat switched to free models only
+var ErrPaidModelsDisabled = errors.New("paid models are disabled in the chat")
+
 type ChatService struct {
 	db         database.Database
 	aiRegistry *ai.ProviderRegistry
@@ -25,8 +29,13 @@ func NewChatService(db database.Database, registry *ai.ProviderRegistry, cfg *co
 
 func (s *ChatService) GetCurrentModelForChat(ctx context.Context, chatID int64, userID int64, name string) (*ai.ModelInfo, error) {
 	model := &ai.ModelInfo{ID: name}
+	freeOnly := s.IsFreeOnly(chatID)
 	if name != "" {
-		return s.resolveModelByName(ctx, userID, name)
+		return s.resolveModelByName(ctx, userID, name, freeOnly)
+	}
+
+	if userModel := s.getUserModel(ctx, userID, freeOnly); userModel != nil {
+		return userModel, nil
 	}
 
 	modelSpec, err := s.db.GetChatModel(chatID)
@@ -34,21 +43,49 @@ func (s *ChatService) GetCurrentModelForChat(ctx context.Context, chatID int64,
 		return model, fmt.Errorf("failed to get chat model: %w", err)
 	}
 
-	if modelSpec == "" {
-		model, err = s.aiRegistry.GetFormattedModel(ctx, s.cfg.AI().DefaultModel, "")
-		if err != nil {
-			return model, fmt.Errorf("failed to get default model: %w", err)
-		}
-	} else {
+	if modelSpec != "" {
 		model, err = s.aiRegistry.GetFormattedModel(ctx, modelSpec, "")
 		if err != nil {
 			return model, fmt.Errorf("failed to get model: %w", err)
 		}
+		// the chat model was set before the chat was switched to free models
+		if !freeOnly || model.IsFree() {
+			return model, nil
+		}
+	}
+
+	model, err = s.aiRegistry.GetFormattedModel(ctx, s.cfg.AI().DefaultModel, "")
+	if err != nil {
+		return model, fmt.Errorf("failed to get default model: %w", err)
+	}
+	if freeOnly && !model.IsFree() {
+		return model, ErrPaidModelsDisabled
 	}
 
 	return model, nil
 }
 
+// IsFreeOnly reports whether the chat is switched to free models only with /settings
+func (s *ChatService) IsFreeOnly(chatID int64) bool {
+	freeOnly, err := s.db.GetChatFreeOnly(chatID)
+	return err == nil && freeOnly
+}
+
+func (s *ChatService) SetFreeOnly(chatID int64, freeOnly bool) error {
+	return s.db.SaveChatFreeOnly(chatID, freeOnly)
+}
+
+// IsMemoryEnabled reports whether the facts about users are remembered in the chat,
+// it's switched on with /settings
+func (s *ChatService) IsMemoryEnabled(chatID int64) bool {
+	memory, err := s.db.GetChatMemory(chatID)
+	return err == nil && memory
+}
+
+func (s *ChatService) SetMemory(chatID int64, memory bool) error {
+	return s.db.SaveChatMemory(chatID, memory)
+}
+
 func (s *ChatService) SetChatModel(ctx context.Context, chatID int64, modelSpec string) error {
 	model, err := s.aiRegistry.GetFormattedModel(ctx, modelSpec, "")
 	if err != nil {
@@ -62,6 +99,42 @@ func (s *ChatService) ResetChatModel(ctx context.Context, chatID int64) error {
 	return s.db.DeleteChatModel(chatID)
 }
 
+// GetUserModel returns the personal default model spec of the user, empty if not set
+func (s *ChatService) GetUserModel(userID int64) (string, error) {
+	return s.db.GetUserModel(userID)
+}
+
+func (s *ChatService) SetUserModel(ctx context.Context, userID int64, modelSpec string) error {
+	model, err := s.aiRegistry.GetFormattedModel(ctx, modelSpec, "")
+	if err != nil {
+		return fmt.Errorf("invalid model: %w", err)
+	}
+
+	return s.db.SaveUserModel(userID, model.FullName())
+}
+
+func (s *ChatService) ResetUserModel(userID int64) error {
+	return s.db.DeleteUserModel(userID)
+}
+
+// GetChatCurrency returns the currency for costs display in the chat,
+// the global one is used if the chat has no own currency
+func (s *ChatService) GetChatCurrency(chatID int64) config.CurrencyConfig {
+	currency, err := s.db.GetChatCurrency(chatID)
+	if err != nil || currency == nil {
+		return s.cfg.Currency()
+	}
+	return *currency
+}
+
+func (s *ChatService) SetChatCurrency(chatID int64, currency config.CurrencyConfig) error {
+	return s.db.SaveChatCurrency(chatID, currency)
+}
+
+func (s *ChatService) ResetChatCurrency(chatID int64) error {
+	return s.db.DeleteChatCurrency(chatID)
+}
+
 func (s *ChatService) GetCurrentModelSpec(ctx context.Context, chatID int64) (string, error) {
 	return s.db.GetChatModel(chatID)
 }
@@ -80,15 +153,48 @@ func (s *ChatService) MergeModelParams(chatID int64, provider, alias, prompt str
 	return baseParams.Merge(requestParams), nil
 }
 
-func (s *ChatService) resolveModelByName(ctx context.Context, userID int64, name string) (*ai.ModelInfo, error) {
+func (s *ChatService) resolveModelByName(ctx context.Context, userID int64, name string, freeOnly bool) (*ai.ModelInfo, error) {
 	model, err := s.aiRegistry.GetFormattedModel(ctx, name, "")
 	if err != nil {
 		return model, err
 	}
 
+	if !model.IsFree() && freeOnly {
+		return model, ErrPaidModelsDisabled
+	}
 	if !model.IsFree() && !s.cfg.Telegram().IsUserAllowed(userID) {
 		return model, fmt.Errorf("not allowed")
 	}
 
 	return model, nil
 }
+
+// getUserModel returns the personal default model of the user, nil if it's not set,
+// unavailable or paid while paid models are disabled for the user, the chat or the provider
+func (s *ChatService) getUserModel(ctx context.Context, userID int64, freeOnly bool) *ai.ModelInfo {
+	if userID == 0 {
+		return nil
+	}
+
+	modelSpec, err := s.db.GetUserModel(userID)
+	if err != nil || modelSpec == "" {
+		return nil
+	}
+
+	model, err := s.aiRegistry.GetFormattedModel(ctx, modelSpec, "")
+	if err != nil {
+		return nil
+	}
+
+	if !model.IsFree() && (freeOnly || !s.cfg.Telegram().IsUserAllowed(userID) || s.onlyFreeModels(model.Provider)) {
+		return nil
+	}
+
+	return model
+}
+
+// onlyFreeModels reports whether the provider is limited to free models by only_free_models
+func (s *ChatService) onlyFreeModels(providerName string) bool {
+	provider := s.cfg.AI().GetProvider(providerName)
+	return provider != nil && provider.OnlyFreeModels
+}
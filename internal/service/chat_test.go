@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/muratoffalex/gachigazer/internal/ai"
+	"github.com/muratoffalex/gachigazer/internal/config"
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testChatConfig = `
+[telegram]
+token = "token"
+allowed_users = [1]
+
+[ai]
+default_model = "%s"
+
+[[ai.providers]]
+name = "test"
+
+[[ai.providers]]
+name = "limited"
+only_free_models = true
+`
+
+type stubChatDB struct {
+	database.Database
+	chatModel string
+	userModel string
+	freeOnly  bool
+}
+
+func (db *stubChatDB) GetChatModel(chatID int64) (string, error) {
+	return db.chatModel, nil
+}
+
+func (db *stubChatDB) GetUserModel(userID int64) (string, error) {
+	return db.userModel, nil
+}
+
+func (db *stubChatDB) GetChatFreeOnly(chatID int64) (bool, error) {
+	return db.freeOnly, nil
+}
+
+type stubProvider struct {
+	ai.Provider
+	models map[string]*ai.ModelInfo
+}
+
+func (p stubProvider) GetModelInfo(name string) (*ai.ModelInfo, error) {
+	if model, ok := p.models[name]; ok {
+		return model, nil
+	}
+	return nil, errors.New("model not found")
+}
+
+func newTestChatService(t *testing.T, db *stubChatDB, defaultModel string) *ChatService {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "gachigazer"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gachigazer", "config.toml"), []byte(fmt.Sprintf(testChatConfig, defaultModel)), 0o644))
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	free := &ai.ModelPricing{Completion: "0", Prompt: "0", Image: "0", WebSearch: "0"}
+	paid := &ai.ModelPricing{Completion: "0.00001", Prompt: "0.00001", Image: "0", WebSearch: "0"}
+	registry := ai.NewProviderRegistry(cfg, logger.NewTestLogger())
+	registry.RegisterProvider("test", stubProvider{models: map[string]*ai.ModelInfo{
+		"free": {ID: "free", Provider: "test", Pricing: free},
+		"paid": {ID: "paid", Provider: "test", Pricing: paid},
+	}})
+	registry.RegisterProvider("limited", stubProvider{models: map[string]*ai.ModelInfo{
+		"paid": {ID: "paid", Provider: "limited", Pricing: paid},
+	}})
+	return NewChatService(db, registry, cfg)
+}
+
+func TestChatService_UserModel(t *testing.T) {
+	const (
+		allowedUser = int64(1)
+		otherUser   = int64(2)
+		chatID      = int64(-100)
+	)
+	tests := []struct {
+		name      string
+		userModel string
+		userID    int64
+		freeOnly  bool
+		want      string
+	}{
+		{"paid model of allowed user", "test:paid", allowedUser, false, "test:paid"},
+		{"paid model of not allowed user", "test:paid", otherUser, false, "test:free"},
+		{"free model of not allowed user", "test:free", otherUser, false, "test:free"},
+		{"paid model in free only chat", "test:paid", allowedUser, true, "test:free"},
+		{"paid model of only free provider", "limited:paid", allowedUser, false, "test:free"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestChatService(t, &stubChatDB{userModel: tt.userModel, freeOnly: tt.freeOnly}, "test:free")
+
+			model, err := s.GetCurrentModelForChat(t.Context(), chatID, tt.userID, "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, model.FullName())
+		})
+	}
+}
+
+func TestChatService_FreeOnly(t *testing.T) {
+	const (
+		userID = int64(1)
+		chatID = int64(-100)
+	)
+	tests := []struct {
+		name         string
+		chatModel    string
+		defaultModel string
+		requested    string
+		freeOnly     bool
+		want         string
+		wantErr      error
+	}{
+		{"requested paid model", "", "test:free", "test:paid", true, "test:paid", ErrPaidModelsDisabled},
+		{"requested free model", "", "test:free", "test:free", true, "test:free", nil},
+		{"paid chat model set before", "test:paid", "test:free", "", true, "test:free", nil},
+		{"paid default model", "", "test:paid", "", true, "test:paid", ErrPaidModelsDisabled},
+		{"paid chat model and default model", "test:paid", "test:paid", "", true, "test:paid", ErrPaidModelsDisabled},
+		{"paid chat model without free only", "test:paid", "test:free", "", false, "test:paid", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestChatService(t, &stubChatDB{chatModel: tt.chatModel, freeOnly: tt.freeOnly}, tt.defaultModel)
+
+			model, err := s.GetCurrentModelForChat(t.Context(), chatID, userID, tt.requested)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, model.FullName())
+		})
+	}
+}
@@ -9,13 +9,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/muratoffalex/gachigazer/internal/config"
 	"github.com/muratoffalex/gachigazer/internal/fetcher"
 )
 
 type CurrencyService struct {
 	httpClient  *http.Client
 	lastUpdated time.Time
-	currentRate float64
+	rates       map[string]float64 // all USD rates, chats may use different currencies
 	rateMutex   sync.Mutex
 }
 
@@ -44,11 +45,14 @@ func (s *CurrencyService) GetUSDRate(ctx context.Context, currencyCode string) (
 	s.rateMutex.Lock()
 	defer s.rateMutex.Unlock()
 
+	currencyCode = strings.ToLower(currencyCode)
 	if time.Since(s.lastUpdated) < 24*time.Hour {
-		return s.currentRate, nil
+		if rate, ok := s.rates[currencyCode]; ok {
+			return rate, nil
+		}
+		return 0, fmt.Errorf("%s rate not found", currencyCode)
 	}
 
-	currencyCode = strings.ToLower(currencyCode)
 	urls := []string{
 		"https://cdn.jsdelivr.net/npm/@fawazahmed0/currency-api@latest/v1/currencies/usd.min.json",
 		"https://latest.currency-api.pages.dev/v1/currencies/usd.min.json",
@@ -85,10 +89,57 @@ func (s *CurrencyService) GetUSDRate(ctx context.Context, currencyCode string) (
 			continue
 		}
 
-		s.currentRate = rate
+		s.rates = data.USD
 		s.lastUpdated = time.Now()
-		return s.currentRate, nil
+		return rate, nil
+	}
+
+	return s.rates[currencyCode], fmt.Errorf("all API endpoints failed, last error: %w", lastErr)
+}
+
+// FormatCost formats the cost in dollars in the currency, converted by its rate
+// or the current USD rate when the rate is not set
+func FormatCost(costDollars float64, currency *config.CurrencyConfig) string {
+	precision := countSignificantDecimals(costDollars)
+	if currency != nil && currency.Precision > 0 {
+		precision = currency.Precision
 	}
+	costStr := fmt.Sprintf("$%.*f", precision, costDollars)
 
-	return s.currentRate, fmt.Errorf("all API endpoints failed, last error: %w", lastErr)
+	if currency != nil && currency.Code != "" {
+		rate := currency.Rate
+		var err error
+		if rate <= 0 {
+			rate, err = GetCurrencyService().GetUSDRate(context.Background(), currency.Code)
+		}
+		if err == nil {
+			costInCurrency := costDollars * rate
+			if currency.Symbol == "" {
+				currency.Symbol = currency.Code
+			}
+			precision = currency.Precision
+			if precision == 0 {
+				precision = countSignificantDecimals(costInCurrency)
+			}
+			costStr = fmt.Sprintf("≈%s%.*f", currency.Symbol, precision, costInCurrency)
+		} else {
+			fmt.Printf("Currency error: %v\n", err)
+		}
+	}
+	return costStr
+}
+
+func countSignificantDecimals(f float64) int {
+	str := fmt.Sprintf("%f", f)
+	parts := strings.Split(str, ".")
+	if len(parts) < 2 {
+		return 0
+	}
+	decimalPart := parts[1]
+	for i, ch := range decimalPart {
+		if ch != '0' {
+			return len(decimalPart) - i
+		}
+	}
+	return 0
 }
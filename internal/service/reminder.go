@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/muratoffalex/gachigazer/internal/database"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/muratoffalex/gachigazer/internal/telegram"
+)
+
+const (
+	reminderCheckInterval = 30 * time.Second
+	// reminders that couldn't be delivered for this long are dropped
+	reminderDeliveryTimeout = 24 * time.Hour
+)
+
+// ReminderService delivers reminders set with set_reminder tool
+type ReminderService struct {
+	db        database.Database
+	tg        telegram.Client
+	localizer *Localizer
+	logger    logger.Logger
+}
+
+func NewReminderService(db database.Database, tg telegram.Client, localizer *Localizer, l logger.Logger) *ReminderService {
+	return &ReminderService{
+		db:        db,
+		tg:        tg,
+		localizer: localizer,
+		logger:    l.WithField("service", "reminder"),
+	}
+}
+
+// Start delivers reminders that became due while the bot was stopped
+// and then checks for due reminders until the context is done
+func (s *ReminderService) Start(ctx context.Context) {
+	if delivered := s.deliverDue(time.Now()); delivered > 0 {
+		s.logger.WithField("count", delivered).Info("Delivered reminders missed while the bot was stopped")
+	}
+
+	go func() {
+		ticker := time.NewTicker(reminderCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.deliverDue(now)
+			}
+		}
+	}()
+}
+
+// deliverDue sends due reminders and returns the number of delivered ones
+func (s *ReminderService) deliverDue(now time.Time) int {
+	reminders, err := s.db.GetDueReminders(now)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get due reminders")
+		return 0
+	}
+
+	delivered := 0
+	for _, reminder := range reminders {
+		log := s.logger.WithFields(logger.Fields{
+			"reminder_id": reminder.ID,
+			"chat_id":     reminder.ChatID,
+			"user_id":     reminder.UserID,
+		})
+		status := database.ReminderStatusSent
+		if err := s.send(reminder); err != nil {
+			if now.Sub(reminder.RemindAt) < reminderDeliveryTimeout {
+				log.WithError(err).Warn("Failed to send reminder, will retry")
+				continue
+			}
+			log.WithError(err).Error("Failed to send reminder, giving up")
+			status = database.ReminderStatusFailed
+		} else {
+			delivered++
+		}
+		if err := s.db.SetReminderStatus(reminder.ID, status); err != nil {
+			log.WithError(err).Error("Failed to update reminder status")
+		}
+	}
+	return delivered
+}
+
+func (s *ReminderService) send(reminder database.Reminder) error {
+	text := s.localizer.Localize("reminder.message", map[string]any{
+		"Text": reminder.Text,
+	})
+	msg := telegram.NewMessage(reminder.ChatID, text, reminder.MessageID)
+	if _, err := s.tg.Send(msg); err != nil {
+		// the original message may be deleted, send without reply
+		msg.ReplyTo = 0
+		if _, err := s.tg.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,9 +1,12 @@
 package youtube
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/lrstanley/go-ytdlp"
@@ -17,6 +20,9 @@ const (
 	FetchComments
 )
 
+// maxDescriptionLength caps the video description in runes, descriptions are often full of links and ads
+const maxDescriptionLength = 2000
+
 var (
 	ErrExtractYoutubeData = errors.New("failed to extract youtube data")
 	ErrExtractVideoInfo   = errors.New("failed to extract video info")
@@ -60,10 +66,18 @@ type YoutubeData struct {
 	ViewCount    *float64
 	UploadedAt   *time.Time
 	Title        string
+	Description  string
+	Chapters     []Chapter
 	Transcript   string
 	Comments     string
 }
 
+type Chapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
 func (f *Service) FetchYoutubeData(url string, flags FetchFlag, maxComments int) (*YoutubeData, error) {
 	output, err := f.contentExtractor.Extract(context.Background(), url, FetchOptions{
 		SkipDownload:  true,
@@ -112,6 +126,10 @@ func (f *Service) extractVideoInfo(info *ytdlp.ExtractedInfo) *YoutubeData {
 	if info.Title != nil {
 		result.Title = *info.Title
 	}
+	if info.Description != nil {
+		result.Description = truncateDescription(strings.TrimSpace(*info.Description), maxDescriptionLength)
+	}
+	result.Chapters = parseChapters(info.Chapters)
 	if timestamp := info.Timestamp; timestamp != nil {
 		val := time.Unix(int64(*timestamp), 0)
 		result.UploadedAt = &val
@@ -120,6 +138,45 @@ func (f *Service) extractVideoInfo(info *ytdlp.ExtractedInfo) *YoutubeData {
 	return result
 }
 
+// parseChapters converts yt-dlp chapter markers, skipping chapters without start time
+func parseChapters(chapters []*ytdlp.ExtractedChapterData) []Chapter {
+	result := make([]Chapter, 0, len(chapters))
+	for _, chapter := range chapters {
+		if chapter == nil || chapter.StartTime == nil {
+			continue
+		}
+		item := Chapter{
+			Start: secondsToDuration(*chapter.StartTime),
+		}
+		if chapter.Title != nil {
+			item.Title = strings.TrimSpace(*chapter.Title)
+		}
+		if chapter.EndTime != nil {
+			item.End = secondsToDuration(*chapter.EndTime)
+		}
+		result = append(result, item)
+	}
+	slices.SortStableFunc(result, func(a, b Chapter) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second)
+}
+
+func truncateDescription(description string, maxLength int) string {
+	runes := []rune(description)
+	if len(runes) <= maxLength {
+		return description
+	}
+	return strings.TrimSpace(string(runes[:maxLength])) + "...[truncated]"
+}
+
 func FormatCount(count float64) string {
 	switch {
 	case count < 1000:
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -159,9 +160,79 @@ func TestService_extractVideoInfo(t *testing.T) {
 		result := service.extractVideoInfo(info)
 
 		assert.Empty(t, result.Title)
+		assert.Empty(t, result.Description)
+		assert.Nil(t, result.Chapters)
 		assert.Nil(t, result.LikeCount)
 		assert.Nil(t, result.UploadedAt)
 	})
+
+	t.Run("description and chapters", func(t *testing.T) {
+		service := Service{}
+		description := "  Video about Go\n\n0:00 Intro\n1:30 Generics  "
+		start, end := 90.0, 300.0
+		chapterTitle := "Generics"
+
+		info := &ytdlp.ExtractedInfo{
+			Description: &description,
+			Chapters: []*ytdlp.ExtractedChapterData{
+				{StartTime: &start, EndTime: &end, Title: &chapterTitle},
+			},
+		}
+
+		result := service.extractVideoInfo(info)
+
+		assert.Equal(t, "Video about Go\n\n0:00 Intro\n1:30 Generics", result.Description)
+		assert.Equal(t, []Chapter{
+			{Title: "Generics", Start: 90 * time.Second, End: 5 * time.Minute},
+		}, result.Chapters)
+	})
+
+	t.Run("long description is truncated", func(t *testing.T) {
+		service := Service{}
+		description := strings.Repeat("я", maxDescriptionLength+10)
+
+		result := service.extractVideoInfo(&ytdlp.ExtractedInfo{Description: &description})
+
+		assert.Equal(t, strings.Repeat("я", maxDescriptionLength)+"...[truncated]", result.Description)
+	})
+}
+
+func TestParseChapters(t *testing.T) {
+	ptr := func(v float64) *float64 { return &v }
+	str := func(v string) *string { return &v }
+
+	t.Run("sorted by start time", func(t *testing.T) {
+		chapters := []*ytdlp.ExtractedChapterData{
+			{StartTime: ptr(125.4), EndTime: ptr(3725), Title: str("Main part")},
+			{StartTime: ptr(0), EndTime: ptr(125.4), Title: str(" Intro ")},
+			{StartTime: ptr(3725), EndTime: ptr(3800), Title: str("Outro")},
+		}
+
+		result := parseChapters(chapters)
+
+		assert.Equal(t, []Chapter{
+			{Title: "Intro", Start: 0, End: 2*time.Minute + 5*time.Second},
+			{Title: "Main part", Start: 2*time.Minute + 5*time.Second, End: time.Hour + 2*time.Minute + 5*time.Second},
+			{Title: "Outro", Start: time.Hour + 2*time.Minute + 5*time.Second, End: time.Hour + 3*time.Minute + 20*time.Second},
+		}, result)
+	})
+
+	t.Run("skips invalid chapters", func(t *testing.T) {
+		chapters := []*ytdlp.ExtractedChapterData{
+			nil,
+			{Title: str("Without start")},
+			{StartTime: ptr(10)},
+		}
+
+		result := parseChapters(chapters)
+
+		assert.Equal(t, []Chapter{{Start: 10 * time.Second}}, result)
+	})
+
+	t.Run("no chapters", func(t *testing.T) {
+		assert.Nil(t, parseChapters(nil))
+		assert.Nil(t, parseChapters([]*ytdlp.ExtractedChapterData{nil}))
+	})
 }
 
 func TestFormatCount(t *testing.T) {
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	tgbotapi "github.com/OvyFlash/telegram-bot-api"
@@ -16,6 +17,8 @@ type BotClient struct {
 	bot      *tgbotapi.BotAPI
 	markdown *markdown.MarkdownProcessor
 	logger   logger.Logger
+	// chat ID -> business connection ID, replies to business chats must carry it
+	businessConnections sync.Map
 }
 
 func NewBotClient(
@@ -31,7 +34,7 @@ func NewBotClient(
 }
 
 func (c *BotClient) Send(msg MessageConfig) (*Message, error) {
-	sentMsg, err := c.bot.Send(msg.ToChattable())
+	sentMsg, err := c.bot.Send(c.withBusinessConnection(msg).ToChattable())
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +47,7 @@ func (c *BotClient) SendWithRetry(msg MessageConfig, maxRetryCount int) (*Messag
 		maxRetries = maxRetryCount
 	}
 	retryCount := 0
+	msg = c.withBusinessConnection(msg)
 
 	for {
 		sentMsg, err := c.bot.Send(msg.ToChattable())
@@ -93,15 +97,21 @@ func (c *BotClient) EscapeText(text string) string {
 
 func (c *BotClient) GetUpdatesChan(config UpdateConfig) <-chan tgbotapi.Update {
 	tgConfig := tgbotapi.UpdateConfig{
-		Offset:  config.Offset,
-		Limit:   config.Limit,
-		Timeout: config.Timeout,
+		Offset:         config.Offset,
+		Limit:          config.Limit,
+		Timeout:        config.Timeout,
+		AllowedUpdates: config.AllowedUpdates,
+	}
+	// getUpdates is rejected while a webhook is set, e.g. after switching from the webhook mode
+	if _, err := c.bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		c.logger.WithError(err).Warn("Failed to delete webhook")
 	}
 	srcChan := c.bot.GetUpdatesChan(tgConfig)
 	dstChan := make(chan tgbotapi.Update)
 
 	go func() {
 		for update := range srcChan {
+			c.trackBusinessConnection(update)
 			dstChan <- update
 		}
 		close(dstChan)
@@ -111,7 +121,7 @@ func (c *BotClient) GetUpdatesChan(config UpdateConfig) <-chan tgbotapi.Update {
 }
 
 func (c *BotClient) Request(message MessageConfig) (*tgbotapi.APIResponse, error) {
-	return c.bot.Request(message.ToChattable())
+	return c.bot.Request(c.withBusinessConnection(message).ToChattable())
 }
 
 func (c *BotClient) RequestRaw(message tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
@@ -119,7 +129,17 @@ func (c *BotClient) RequestRaw(message tgbotapi.Chattable) (*tgbotapi.APIRespons
 }
 
 func (c *BotClient) SendChatAction(chatID int64, action ChatAction) error {
-	_, err := c.bot.Request(tgbotapi.NewChatAction(chatID, string(action)))
+	config := tgbotapi.NewChatAction(chatID, string(action))
+	if connectionID, ok := c.businessConnections.Load(chatID); ok {
+		config.BusinessConnectionID = tgbotapi.BusinessConnectionID(connectionID.(string))
+	}
+	_, err := c.bot.Request(config)
+	return err
+}
+
+func (c *BotClient) SetReaction(chatID int64, messageID int, emoji string) error {
+	reaction := []tgbotapi.ReactionType{{Type: tgbotapi.ReactionTypeEmoji, Emoji: emoji}}
+	_, err := c.bot.Request(tgbotapi.NewSetMessageReaction(chatID, messageID, reaction, false))
 	return err
 }
 
@@ -143,6 +163,38 @@ func (c *BotClient) Self() User {
 	return adaptUser(&c.bot.Self)
 }
 
+// trackBusinessConnection remembers business connections of chats from incoming business messages
+// and forgets them when the connection is disabled
+func (c *BotClient) trackBusinessConnection(update tgbotapi.Update) {
+	if msg := update.BusinessMessage; msg != nil && msg.BusinessConnectionID != "" {
+		c.businessConnections.Store(msg.Chat.ID, msg.BusinessConnectionID)
+	}
+	if conn := update.BusinessConnection; conn != nil && !conn.IsEnabled {
+		c.businessConnections.Range(func(chatID, connectionID any) bool {
+			if connectionID == conn.ID {
+				c.businessConnections.Delete(chatID)
+			}
+			return true
+		})
+	}
+}
+
+// withBusinessConnection sets the business connection of the chat if the message doesn't have one
+func (c *BotClient) withBusinessConnection(msg MessageConfig) MessageConfig {
+	businessMsg, ok := msg.(businessMessageConfig)
+	if !ok {
+		return msg
+	}
+	chatID, connectionID := businessMsg.businessChat()
+	if connectionID != "" {
+		return msg
+	}
+	if connectionID, ok := c.businessConnections.Load(chatID); ok {
+		return businessMsg.withBusinessConnection(connectionID.(string))
+	}
+	return msg
+}
+
 func FormatMessageLink(chatID int64, messageID int) string {
 	return fmt.Sprintf("https://t.me/c/%d/%d", chatID, messageID)
 }
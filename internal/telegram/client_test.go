@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotClient_withBusinessConnection(t *testing.T) {
+	client := &BotClient{}
+	client.trackBusinessConnection(tgbotapi.Update{BusinessMessage: &tgbotapi.Message{
+		MessageID:            1,
+		Chat:                 tgbotapi.Chat{ID: 100},
+		BusinessConnectionID: "conn",
+	}})
+
+	t.Run("messages to business chat carry connection", func(t *testing.T) {
+		text, ok := client.withBusinessConnection(NewMessage(100, "hi", 1)).ToChattable().(tgbotapi.MessageConfig)
+		require.True(t, ok)
+		assert.Equal(t, tgbotapi.BusinessConnectionID("conn"), text.BusinessConnectionID)
+
+		doc, ok := client.withBusinessConnection(NewDocumentMessage(100, FileBytes{Name: "a.txt"}, "", 1)).ToChattable().(tgbotapi.DocumentConfig)
+		require.True(t, ok)
+		assert.Equal(t, tgbotapi.BusinessConnectionID("conn"), doc.BusinessConnectionID)
+
+		edit, ok := client.withBusinessConnection(NewEditMessageText(100, 2, "edited")).ToChattable().(tgbotapi.EditMessageTextConfig)
+		require.True(t, ok)
+		assert.Equal(t, tgbotapi.BusinessConnectionID("conn"), edit.BusinessConnectionID)
+	})
+
+	t.Run("messages to other chats are unchanged", func(t *testing.T) {
+		text, ok := client.withBusinessConnection(NewMessage(200, "hi", 1)).ToChattable().(tgbotapi.MessageConfig)
+		require.True(t, ok)
+		assert.Empty(t, text.BusinessConnectionID)
+	})
+
+	t.Run("explicit connection is kept", func(t *testing.T) {
+		msg := NewMessage(100, "hi", 1)
+		msg.BusinessConnectionID = "other"
+		text, ok := client.withBusinessConnection(msg).ToChattable().(tgbotapi.MessageConfig)
+		require.True(t, ok)
+		assert.Equal(t, tgbotapi.BusinessConnectionID("other"), text.BusinessConnectionID)
+	})
+
+	t.Run("disabled connection is forgotten", func(t *testing.T) {
+		client.trackBusinessConnection(tgbotapi.Update{BusinessConnection: &tgbotapi.BusinessConnection{
+			ID:        "conn",
+			IsEnabled: false,
+		}})
+
+		text, ok := client.withBusinessConnection(NewMessage(100, "hi", 1)).ToChattable().(tgbotapi.MessageConfig)
+		require.True(t, ok)
+		assert.Empty(t, text.BusinessConnectionID)
+	})
+}
@@ -1,6 +1,8 @@
 package telegram
 
 import (
+	"context"
+
 	tgbotapi "github.com/OvyFlash/telegram-bot-api"
 )
 
@@ -22,6 +24,9 @@ type (
 	EditVideo       = tgbotapi.EditMessageMediaConfig
 	Chattable       = tgbotapi.Chattable
 	RequestFileData = tgbotapi.RequestFileData
+	ChatMember      = tgbotapi.ChatMember
+	CallbackQuery   = tgbotapi.CallbackQuery
+	Document        = tgbotapi.Document
 
 	InlineKeyboardMarkup = tgbotapi.InlineKeyboardMarkup
 	InlineKeyboardButton = tgbotapi.InlineKeyboardButton
@@ -39,6 +44,16 @@ func NewInlineKeyboardButtonData(text, data string) InlineKeyboardButton {
 	return tgbotapi.NewInlineKeyboardButtonData(text, data)
 }
 
+// NewGetChatMember requests the member status of the user in the chat with Client.RequestRaw
+func NewGetChatMember(chatID, userID int64) Chattable {
+	return tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{
+			ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+			UserID:     userID,
+		},
+	}
+}
+
 type Message struct {
 	MessageID     int
 	Chat          Chat
@@ -72,6 +87,13 @@ type MessageConfig interface {
 	ToChattable() tgbotapi.Chattable
 }
 
+// businessMessageConfig is a message that can be sent to a chat connected to a Telegram Business account
+type businessMessageConfig interface {
+	MessageConfig
+	businessChat() (chatID int64, connectionID string)
+	withBusinessConnection(connectionID string) MessageConfig
+}
+
 type CallbackConfig struct {
 	CallbackQueryID string
 	Text            string
@@ -97,12 +119,13 @@ func (c *CallbackConfig) ToChattable() tgbotapi.Chattable {
 }
 
 type TextMessage struct {
-	ChatID              int64
-	Text                string
-	ReplyTo             int
-	ReplyMarkup         *InlineKeyboardMarkup
-	LinkPreviewDisabled bool
-	ParseMode           ParseMode
+	ChatID               int64
+	Text                 string
+	ReplyTo              int
+	ReplyMarkup          *InlineKeyboardMarkup
+	LinkPreviewDisabled  bool
+	ParseMode            ParseMode
+	BusinessConnectionID string
 }
 
 func NewMessage(chatID int64, text string, replyTo int) TextMessage {
@@ -122,16 +145,27 @@ func (m TextMessage) ToChattable() tgbotapi.Chattable {
 		msg.ReplyMarkup = m.ReplyMarkup
 	}
 	msg.LinkPreviewOptions.IsDisabled = m.LinkPreviewDisabled
+	msg.BusinessConnectionID = tgbotapi.BusinessConnectionID(m.BusinessConnectionID)
 	return msg
 }
 
+func (m TextMessage) businessChat() (int64, string) {
+	return m.ChatID, m.BusinessConnectionID
+}
+
+func (m TextMessage) withBusinessConnection(connectionID string) MessageConfig {
+	m.BusinessConnectionID = connectionID
+	return m
+}
+
 type PhotoMessage struct {
-	ChatID      int64
-	Photo       RequestFileData
-	Caption     string
-	ReplyTo     int
-	ParseMode   string
-	ReplyMarkup any
+	ChatID               int64
+	Photo                RequestFileData
+	Caption              string
+	ReplyTo              int
+	ParseMode            string
+	ReplyMarkup          any
+	BusinessConnectionID string
 }
 
 func NewPhotoMessage(chatID int64, photo RequestFileData, caption string, replyTo int) PhotoMessage {
@@ -149,16 +183,27 @@ func (m PhotoMessage) ToChattable() tgbotapi.Chattable {
 	msg.ReplyParameters.MessageID = m.ReplyTo
 	msg.ParseMode = m.ParseMode
 	msg.ReplyMarkup = m.ReplyMarkup
+	msg.BusinessConnectionID = tgbotapi.BusinessConnectionID(m.BusinessConnectionID)
 	return msg
 }
 
+func (m PhotoMessage) businessChat() (int64, string) {
+	return m.ChatID, m.BusinessConnectionID
+}
+
+func (m PhotoMessage) withBusinessConnection(connectionID string) MessageConfig {
+	m.BusinessConnectionID = connectionID
+	return m
+}
+
 type VideoMessage struct {
-	ChatID      int64
-	Video       RequestFileData
-	Caption     string
-	ReplyTo     int
-	ParseMode   string
-	ReplyMarkup any
+	ChatID               int64
+	Video                RequestFileData
+	Caption              string
+	ReplyTo              int
+	ParseMode            string
+	ReplyMarkup          any
+	BusinessConnectionID string
 }
 
 func NewVideoMessage(chatID int64, video RequestFileData, caption string, replyTo int) VideoMessage {
@@ -176,16 +221,150 @@ func (m VideoMessage) ToChattable() tgbotapi.Chattable {
 	msg.ReplyParameters.MessageID = m.ReplyTo
 	msg.ParseMode = m.ParseMode
 	msg.ReplyMarkup = m.ReplyMarkup
+	msg.BusinessConnectionID = tgbotapi.BusinessConnectionID(m.BusinessConnectionID)
+	return msg
+}
+
+func (m VideoMessage) businessChat() (int64, string) {
+	return m.ChatID, m.BusinessConnectionID
+}
+
+func (m VideoMessage) withBusinessConnection(connectionID string) MessageConfig {
+	m.BusinessConnectionID = connectionID
+	return m
+}
+
+type AudioMessage struct {
+	ChatID               int64
+	Audio                RequestFileData
+	Caption              string
+	ReplyTo              int
+	ParseMode            string
+	Title                string
+	Performer            string
+	Duration             int
+	ReplyMarkup          any
+	BusinessConnectionID string
+}
+
+func NewAudioMessage(chatID int64, audio RequestFileData, caption string, replyTo int) AudioMessage {
+	return AudioMessage{
+		ChatID:  chatID,
+		Audio:   audio,
+		Caption: caption,
+		ReplyTo: replyTo,
+	}
+}
+
+func (m AudioMessage) ToChattable() tgbotapi.Chattable {
+	msg := tgbotapi.NewAudio(m.ChatID, m.Audio)
+	msg.Caption = m.Caption
+	msg.ReplyParameters.MessageID = m.ReplyTo
+	msg.ParseMode = m.ParseMode
+	msg.Title = m.Title
+	msg.Performer = m.Performer
+	msg.Duration = m.Duration
+	msg.ReplyMarkup = m.ReplyMarkup
+	msg.BusinessConnectionID = tgbotapi.BusinessConnectionID(m.BusinessConnectionID)
+	return msg
+}
+
+func (m AudioMessage) businessChat() (int64, string) {
+	return m.ChatID, m.BusinessConnectionID
+}
+
+func (m AudioMessage) withBusinessConnection(connectionID string) MessageConfig {
+	m.BusinessConnectionID = connectionID
+	return m
+}
+
+type VoiceMessage struct {
+	ChatID               int64
+	Voice                RequestFileData
+	Caption              string
+	ReplyTo              int
+	ParseMode            string
+	Duration             int
+	ReplyMarkup          any
+	BusinessConnectionID string
+}
+
+// NewVoiceMessage creates a voice note, the file must be OGG with OPUS
+func NewVoiceMessage(chatID int64, voice RequestFileData, caption string, replyTo int) VoiceMessage {
+	return VoiceMessage{
+		ChatID:  chatID,
+		Voice:   voice,
+		Caption: caption,
+		ReplyTo: replyTo,
+	}
+}
+
+func (m VoiceMessage) ToChattable() tgbotapi.Chattable {
+	msg := tgbotapi.NewVoice(m.ChatID, m.Voice)
+	msg.Caption = m.Caption
+	msg.ReplyParameters.MessageID = m.ReplyTo
+	msg.ParseMode = m.ParseMode
+	msg.Duration = m.Duration
+	msg.ReplyMarkup = m.ReplyMarkup
+	msg.BusinessConnectionID = tgbotapi.BusinessConnectionID(m.BusinessConnectionID)
 	return msg
 }
 
+func (m VoiceMessage) businessChat() (int64, string) {
+	return m.ChatID, m.BusinessConnectionID
+}
+
+func (m VoiceMessage) withBusinessConnection(connectionID string) MessageConfig {
+	m.BusinessConnectionID = connectionID
+	return m
+}
+
+type DocumentMessage struct {
+	ChatID               int64
+	Document             RequestFileData
+	Caption              string
+	ReplyTo              int
+	ParseMode            string
+	ReplyMarkup          any
+	BusinessConnectionID string
+}
+
+func NewDocumentMessage(chatID int64, document RequestFileData, caption string, replyTo int) DocumentMessage {
+	return DocumentMessage{
+		ChatID:   chatID,
+		Document: document,
+		Caption:  caption,
+		ReplyTo:  replyTo,
+	}
+}
+
+func (m DocumentMessage) ToChattable() tgbotapi.Chattable {
+	msg := tgbotapi.NewDocument(m.ChatID, m.Document)
+	msg.Caption = m.Caption
+	msg.ReplyParameters.MessageID = m.ReplyTo
+	msg.ParseMode = m.ParseMode
+	msg.ReplyMarkup = m.ReplyMarkup
+	msg.BusinessConnectionID = tgbotapi.BusinessConnectionID(m.BusinessConnectionID)
+	return msg
+}
+
+func (m DocumentMessage) businessChat() (int64, string) {
+	return m.ChatID, m.BusinessConnectionID
+}
+
+func (m DocumentMessage) withBusinessConnection(connectionID string) MessageConfig {
+	m.BusinessConnectionID = connectionID
+	return m
+}
+
 type EditMessageVideoConfig struct {
-	ChatID      int64
-	MessageID   int
-	Video       RequestFileData
-	Caption     string
-	ParseMode   string
-	ReplyMarkup *InlineKeyboardMarkup
+	ChatID               int64
+	MessageID            int
+	Video                RequestFileData
+	Caption              string
+	ParseMode            string
+	ReplyMarkup          *InlineKeyboardMarkup
+	BusinessConnectionID string
 }
 
 func NewEditMessageVideo(chatID int64, messageID int, video RequestFileData) EditMessageVideoConfig {
@@ -200,16 +379,27 @@ func (m EditMessageVideoConfig) ToChattable() tgbotapi.Chattable {
 	videoMedia := tgbotapi.NewInputMediaVideo(m.Video)
 	msg := tgbotapi.NewEditMessageMedia(m.ChatID, m.MessageID, &videoMedia)
 	msg.ReplyMarkup = m.ReplyMarkup
+	msg.BusinessConnectionID = tgbotapi.BusinessConnectionID(m.BusinessConnectionID)
 	return msg
 }
 
+func (m EditMessageVideoConfig) businessChat() (int64, string) {
+	return m.ChatID, m.BusinessConnectionID
+}
+
+func (m EditMessageVideoConfig) withBusinessConnection(connectionID string) MessageConfig {
+	m.BusinessConnectionID = connectionID
+	return m
+}
+
 type EditMessageTextConfig struct {
-	ChatID              int64
-	MessageID           int
-	Text                string
-	ParseMode           string
-	ReplyMarkup         *InlineKeyboardMarkup
-	LinkPreviewDisabled bool
+	ChatID               int64
+	MessageID            int
+	Text                 string
+	ParseMode            string
+	ReplyMarkup          *InlineKeyboardMarkup
+	LinkPreviewDisabled  bool
+	BusinessConnectionID string
 }
 
 func NewEditMessageText(chatID int64, messageID int, text string) EditMessageTextConfig {
@@ -226,13 +416,24 @@ func (m EditMessageTextConfig) ToChattable() tgbotapi.Chattable {
 	msg.LinkPreviewOptions.IsDisabled = m.LinkPreviewDisabled
 	msg.ParseMode = m.ParseMode
 	msg.ReplyMarkup = m.ReplyMarkup
+	msg.BusinessConnectionID = tgbotapi.BusinessConnectionID(m.BusinessConnectionID)
 	return msg
 }
 
+func (m EditMessageTextConfig) businessChat() (int64, string) {
+	return m.ChatID, m.BusinessConnectionID
+}
+
+func (m EditMessageTextConfig) withBusinessConnection(connectionID string) MessageConfig {
+	m.BusinessConnectionID = connectionID
+	return m
+}
+
 type EditMessageReplyMarkupConfig struct {
-	ChatID      int64
-	MessageID   int
-	ReplyMarkup *InlineKeyboardMarkup
+	ChatID               int64
+	MessageID            int
+	ReplyMarkup          *InlineKeyboardMarkup
+	BusinessConnectionID string
 }
 
 func NewEditMessageReplyMarkup(chatID int64, messageID int, replyMarkup *InlineKeyboardMarkup) EditMessageReplyMarkupConfig {
@@ -244,7 +445,18 @@ func NewEditMessageReplyMarkup(chatID int64, messageID int, replyMarkup *InlineK
 }
 
 func (c EditMessageReplyMarkupConfig) ToChattable() tgbotapi.Chattable {
-	return tgbotapi.NewEditMessageReplyMarkup(c.ChatID, c.MessageID, *c.ReplyMarkup)
+	msg := tgbotapi.NewEditMessageReplyMarkup(c.ChatID, c.MessageID, *c.ReplyMarkup)
+	msg.BusinessConnectionID = tgbotapi.BusinessConnectionID(c.BusinessConnectionID)
+	return msg
+}
+
+func (c EditMessageReplyMarkupConfig) businessChat() (int64, string) {
+	return c.ChatID, c.BusinessConnectionID
+}
+
+func (c EditMessageReplyMarkupConfig) withBusinessConnection(connectionID string) MessageConfig {
+	c.BusinessConnectionID = connectionID
+	return c
 }
 
 type InputMedia interface {
@@ -296,9 +508,10 @@ func (v VideoMedia) ToMedia() tgbotapi.InputMedia {
 }
 
 type MediaGroupMessage struct {
-	ChatID  int64
-	Media   []InputMedia
-	ReplyTo int
+	ChatID               int64
+	Media                []InputMedia
+	ReplyTo              int
+	BusinessConnectionID string
 }
 
 func NewMediaGroupMessage(chatID int64, media []InputMedia) MediaGroupMessage {
@@ -314,13 +527,42 @@ func (m MediaGroupMessage) ToChattable() tgbotapi.Chattable {
 		media = append(media, item.ToMedia())
 	}
 
-	return tgbotapi.NewMediaGroup(m.ChatID, media)
+	msg := tgbotapi.NewMediaGroup(m.ChatID, media)
+	msg.BusinessConnectionID = tgbotapi.BusinessConnectionID(m.BusinessConnectionID)
+	return msg
+}
+
+func (m MediaGroupMessage) businessChat() (int64, string) {
+	return m.ChatID, m.BusinessConnectionID
+}
+
+func (m MediaGroupMessage) withBusinessConnection(connectionID string) MessageConfig {
+	m.BusinessConnectionID = connectionID
+	return m
 }
 
 type UpdateConfig struct {
 	Offset  int
 	Limit   int
 	Timeout int
+	// empty - the list of the previous request, telegram defaults at first
+	AllowedUpdates []string
+}
+
+// AllowedUpdates returns the update types handled by the bot, reactions
+// are sent by telegram only when requested explicitly
+func AllowedUpdates(reactions bool) []string {
+	updates := []string{
+		tgbotapi.UpdateTypeMessage,
+		tgbotapi.UpdateTypeEditedMessage,
+		tgbotapi.UpdateTypeCallbackQuery,
+		tgbotapi.UpdateTypeBusinessConnection,
+		tgbotapi.UpdateTypeBusinessMessage,
+	}
+	if reactions {
+		updates = append(updates, tgbotapi.UpdateTypeMessageReaction)
+	}
+	return updates
 }
 
 type ChatAction string
@@ -329,6 +571,9 @@ const (
 	ActionTyping      ChatAction = "typing"
 	ActionUploadPhoto ChatAction = "upload_photo"
 	ActionUploadVideo ChatAction = "upload_video"
+	// also used for audio files, upload_voice is meant for voice notes
+	ActionUploadDocument ChatAction = "upload_document"
+	ActionUploadVoice    ChatAction = "upload_voice"
 )
 
 type Client interface {
@@ -339,9 +584,11 @@ type Client interface {
 	GetFileURL(fileID string) (string, error)
 	EscapeText(text string) string
 	GetUpdatesChan(config UpdateConfig) <-chan tgbotapi.Update
+	ListenWebhook(ctx context.Context, config WebhookConfig) (*Webhook, error)
 	Request(message MessageConfig) (*tgbotapi.APIResponse, error)
 	RequestRaw(message tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
 	SendChatAction(chatID int64, action ChatAction) error
+	SetReaction(chatID int64, messageID int, emoji string) error
 	TelegramifyMarkdown(text string) (string, error)
 	NewUpdate(offset, timeout, limit int) UpdateConfig
 	Self() User
@@ -0,0 +1,161 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+)
+
+const (
+	webhookSecretHeader    = "X-Telegram-Bot-Api-Secret-Token"
+	webhookShutdownTimeout = 10 * time.Second
+	// limits the body of an update, telegram updates are much smaller
+	webhookMaxBodySize = 1 << 20
+)
+
+type WebhookConfig struct {
+	ListenAddr  string
+	PublicURL   string
+	SecretToken string
+	// empty - the list of the previous registration, telegram defaults at first
+	AllowedUpdates []string
+}
+
+// Webhook is a running HTTP server receiving updates from telegram
+type Webhook struct {
+	updates chan tgbotapi.Update
+	stopped chan struct{}
+	err     error
+	addr    net.Addr
+}
+
+// Updates returns the received updates, the same as GetUpdatesChan returns for long polling
+func (w *Webhook) Updates() <-chan tgbotapi.Update {
+	return w.updates
+}
+
+// Stopped is closed when the server is shut down after the context is done or when it fails
+func (w *Webhook) Stopped() <-chan struct{} {
+	return w.stopped
+}
+
+// Err returns the error the server failed with, nil when it was shut down.
+// Valid after Stopped is closed
+func (w *Webhook) Err() error {
+	return w.err
+}
+
+// ListenWebhook binds the listen address, registers the webhook with telegram and serves the path
+// of the public URL until the context is done. In-flight requests are finished on shutdown
+func (c *BotClient) ListenWebhook(ctx context.Context, config WebhookConfig) (*Webhook, error) {
+	publicURL, err := url.Parse(config.PublicURL)
+	if err != nil || publicURL.Scheme != "https" || publicURL.Host == "" {
+		return nil, fmt.Errorf("invalid webhook public URL %q, HTTPS URL is required", config.PublicURL)
+	}
+	if config.SecretToken == "" {
+		c.logger.Warn("Webhook secret token is empty, anyone who knows the URL can send updates")
+	}
+
+	webhook := &Webhook{
+		updates: make(chan tgbotapi.Update),
+		stopped: make(chan struct{}),
+	}
+	path := publicURL.Path
+	if path == "" {
+		path = "/"
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, newWebhookHandler(ctx, config.SecretToken, webhook.updates, c.trackBusinessConnection, c.logger))
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// the address is bound before the registration, telegram shouldn't send updates nobody receives
+	listener, err := net.Listen("tcp", config.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen webhook address: %w", err)
+	}
+	setWebhook := tgbotapi.WebhookConfig{
+		URL:            publicURL,
+		SecretToken:    config.SecretToken,
+		AllowedUpdates: config.AllowedUpdates,
+	}
+	if _, err := c.bot.Request(setWebhook); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set webhook: %w", err)
+	}
+	webhook.addr = listener.Addr()
+
+	c.logger.WithFields(logger.Fields{
+		"addr": webhook.addr.String(),
+		"path": path,
+	}).Info("Webhook server started")
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	go func() {
+		defer close(webhook.stopped)
+		select {
+		case err := <-served:
+			webhook.err = fmt.Errorf("webhook server failed: %w", err)
+			c.logger.WithError(err).Error("Webhook server failed")
+			return
+		case <-ctx.Done():
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			c.logger.WithError(err).Warn("Failed to stop webhook server")
+			return
+		}
+		c.logger.Info("Webhook server stopped")
+	}()
+	return webhook, nil
+}
+
+// newWebhookHandler accepts updates with the secret token and passes them to the channel.
+// After the context is done updates are rejected, telegram redelivers them later
+func newWebhookHandler(
+	ctx context.Context,
+	secretToken string,
+	updates chan<- tgbotapi.Update,
+	onUpdate func(tgbotapi.Update),
+	l logger.Logger,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if secretToken != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(webhookSecretHeader)), []byte(secretToken)) != 1 {
+			l.WithField("remote_addr", r.RemoteAddr).Warn("Webhook request with invalid secret token")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		var update tgbotapi.Update
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, webhookMaxBodySize)).Decode(&update); err != nil {
+			l.WithError(err).Warn("Failed to decode webhook update")
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		onUpdate(update)
+
+		select {
+		case updates <- update:
+			w.WriteHeader(http.StatusOK)
+		case <-ctx.Done():
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/muratoffalex/gachigazer/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := make(chan tgbotapi.Update, 1)
+	var tracked []int
+	handler := newWebhookHandler(ctx, "secret", updates, func(update tgbotapi.Update) {
+		tracked = append(tracked, update.UpdateID)
+	}, logger.NewTestLogger())
+
+	request := func(method, secret, body string) int {
+		req := httptest.NewRequest(method, "/webhook", strings.NewReader(body))
+		if secret != "" {
+			req.Header.Set(webhookSecretHeader, secret)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	update := `{"update_id": 7, "message": {"message_id": 1, "chat": {"id": 100}, "text": "hi"}}`
+	assert.Equal(t, http.StatusMethodNotAllowed, request(http.MethodGet, "secret", ""))
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "", update))
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "wrong", update))
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "secret", "{"))
+	assert.Empty(t, updates)
+
+	require.Equal(t, http.StatusOK, request(http.MethodPost, "secret", update))
+	received := <-updates
+	assert.Equal(t, 7, received.UpdateID)
+	assert.Equal(t, "hi", received.Message.Text)
+	assert.Equal(t, []int{7}, tracked)
+
+	cancel()
+	updates <- tgbotapi.Update{}
+	assert.Equal(t, http.StatusServiceUnavailable, request(http.MethodPost, "secret", update),
+		"Updates are rejected on shutdown, telegram delivers them again")
+}
+
+// newWebhookTestClient returns a client of the fake Bot API, setWebhook responds with the result
+func newWebhookTestClient(t *testing.T, setWebhookOK bool) (*BotClient, *atomic.Value) {
+	var setWebhook atomic.Value
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			fmt.Fprint(w, `{"ok": true, "result": {"id": 1, "is_bot": true, "username": "bot"}}`)
+		case strings.HasSuffix(r.URL.Path, "/setWebhook"):
+			_ = r.ParseForm()
+			setWebhook.Store(r.Form.Encode())
+			if setWebhookOK {
+				fmt.Fprint(w, `{"ok": true, "result": true}`)
+			} else {
+				fmt.Fprint(w, `{"ok": false, "error_code": 400, "description": "Bad Request: bad webhook"}`)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(api.Close)
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", api.URL+"/bot%s/%s")
+	require.NoError(t, err)
+	return &BotClient{bot: bot, logger: logger.NewTestLogger()}, &setWebhook
+}
+
+func TestBotClient_ListenWebhook(t *testing.T) {
+	client, setWebhook := newWebhookTestClient(t, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	webhook, err := client.ListenWebhook(ctx, WebhookConfig{
+		ListenAddr:  "127.0.0.1:0",
+		PublicURL:   "https://example.com/webhook",
+		SecretToken: "secret",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, setWebhook.Load(), "secret_token=secret")
+	assert.Contains(t, setWebhook.Load(), "url=https%3A%2F%2Fexample.com%2Fwebhook")
+
+	go func() {
+		req, _ := http.NewRequest(http.MethodPost, "http://"+webhook.addr.String()+"/webhook", strings.NewReader(`{"update_id": 7}`))
+		req.Header.Set(webhookSecretHeader, "secret")
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	select {
+	case update := <-webhook.Updates():
+		assert.Equal(t, 7, update.UpdateID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("update isn't received")
+	}
+
+	cancel()
+	select {
+	case <-webhook.Stopped():
+		assert.NoError(t, webhook.Err())
+	case <-time.After(5 * time.Second):
+		t.Fatal("server isn't stopped")
+	}
+}
+
+func TestBotClient_ListenWebhook_Errors(t *testing.T) {
+	t.Run("invalid public URL", func(t *testing.T) {
+		client, setWebhook := newWebhookTestClient(t, true)
+		_, err := client.ListenWebhook(t.Context(), WebhookConfig{ListenAddr: "127.0.0.1:0", PublicURL: "http://example.com"})
+		assert.ErrorContains(t, err, "HTTPS URL is required")
+		assert.Nil(t, setWebhook.Load())
+	})
+
+	t.Run("address in use", func(t *testing.T) {
+		busy, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer busy.Close()
+		client, setWebhook := newWebhookTestClient(t, true)
+
+		_, err = client.ListenWebhook(t.Context(), WebhookConfig{ListenAddr: busy.Addr().String(), PublicURL: "https://example.com"})
+		assert.ErrorContains(t, err, "failed to listen webhook address")
+		assert.Nil(t, setWebhook.Load(), "Webhook isn't registered when updates can't be received")
+	})
+
+	t.Run("registration failed", func(t *testing.T) {
+		free, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := free.Addr().String()
+		require.NoError(t, free.Close())
+		client, _ := newWebhookTestClient(t, false)
+
+		_, err = client.ListenWebhook(t.Context(), WebhookConfig{ListenAddr: addr, PublicURL: "https://example.com"})
+		assert.ErrorContains(t, err, "failed to set webhook")
+
+		listener, err := net.Listen("tcp", addr)
+		require.NoError(t, err, "Address is released")
+		listener.Close()
+	})
+}